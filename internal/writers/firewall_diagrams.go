@@ -3,7 +3,10 @@ package writers
 import (
 	"bufio"
 	"fmt"
+	"net"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"cipgram/pkg/types"
@@ -74,7 +77,13 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 	fmt.Fprintf(w, "═══════════════════════════════════════════════════════════════════════════════\n")
 	fmt.Fprintf(w, "Configuration: %s\n", g.model.Metadata.Source)
 	fmt.Fprintf(w, "Generated: %s\n", g.model.Metadata.Timestamp.Format("2006-01-02 15:04:05"))
-	fmt.Fprintf(w, "Total Networks: %d | Total Policies: %d\n", len(g.model.Networks), len(g.model.Policies))
+	disabled := 0
+	for _, policy := range g.model.Policies {
+		if !policy.Enabled {
+			disabled++
+		}
+	}
+	fmt.Fprintf(w, "Total Networks: %d | Total Policies: %d (%d disabled)\n", len(g.model.Networks), len(g.model.Policies), disabled)
 	fmt.Fprintf(w, "═══════════════════════════════════════════════════════════════════════════════\n\n")
 
 	// Network Segments Summary
@@ -158,6 +167,9 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 				}
 				if len(portStrs) > 0 {
 					ports = strings.Join(portStrs, ",")
+					if policy.NegatePorts {
+						ports = "!" + ports
+					}
 					if len(ports) > 13 {
 						ports = ports[:10] + "..."
 					}
@@ -169,6 +181,13 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 			if description == "" {
 				description = "No description"
 			}
+			// Flag rules whose exported hit counters show no matched traffic
+			if policy.Hits != nil && policy.Hits.Packets == 0 {
+				description = "(no hits) " + description
+			}
+			if !policy.Enabled {
+				description = "(disabled) " + description
+			}
 			if len(description) > 40 {
 				description = description[:37] + "..."
 			}
@@ -198,6 +217,30 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 
 	fmt.Fprintf(w, "\n")
 
+	// NAT translations
+	if len(g.model.NATRules) > 0 {
+		fmt.Fprintf(w, "🔀 NAT TRANSLATIONS\n")
+		fmt.Fprintf(w, "───────────────────────────────────────────────────────────────────────────────\n")
+		fmt.Fprintf(w, "%-13s | %-8s | %-30s | %-30s | %s\n",
+			"TYPE", "IFACE", "ORIGINAL", "TRANSLATED", "DESCRIPTION")
+		fmt.Fprintf(w, "───────────────────────────────────────────────────────────────────────────────\n")
+
+		for _, rule := range g.model.NATRules {
+			original, translated := g.formatNATTranslation(rule)
+			description := rule.Description
+			if !rule.Enabled {
+				description = "(disabled) " + description
+			}
+			fmt.Fprintf(w, "%-13s | %-8s | %-30s | %-30s | %s\n",
+				rule.Type,
+				rule.Interface,
+				g.truncateString(original, 30),
+				g.truncateString(translated, 30),
+				g.truncateString(description, 40))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
 	// Zone-based Risk Assessment
 	fmt.Fprintf(w, "⚠️  RISK ASSESSMENT BY ZONE\n")
 	fmt.Fprintf(w, "───────────────────────────────────────────────────────────────────────────────\n")
@@ -233,10 +276,12 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 	// Recommendations with specific rule analysis
 	fmt.Fprintf(w, "💡 SEGMENTATION RECOMMENDATIONS\n")
 	fmt.Fprintf(w, "───────────────────────────────────────────────────────────────────────────────\n")
-	fmt.Fprintf(w, "🔒 IMPLICIT DEFAULT DENY: OPNsense has a built-in default deny rule (∞) that\n")
-	fmt.Fprintf(w, "   blocks all traffic not explicitly allowed by the rules above. This provides\n")
-	fmt.Fprintf(w, "   a secure-by-default stance - only explicitly permitted traffic flows.\n")
-	fmt.Fprintf(w, "\n")
+	if g.hasImplicitDefaultDeny() {
+		fmt.Fprintf(w, "🔒 IMPLICIT DEFAULT DENY: %s has a built-in default deny rule (∞) that\n", g.firewallVendor())
+		fmt.Fprintf(w, "   blocks all traffic not explicitly allowed by the rules above. This provides\n")
+		fmt.Fprintf(w, "   a secure-by-default stance - only explicitly permitted traffic flows.\n")
+		fmt.Fprintf(w, "\n")
+	}
 
 	// Analyze rules for specific security issues
 	fmt.Fprintf(w, "🚨 CRITICAL SECURITY ISSUES IDENTIFIED:\n")
@@ -248,8 +293,8 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 	dmzRisks := []string{}
 
 	for i, policy := range g.model.Policies {
-		if policy.ID == "implicit-default-deny" {
-			continue // Skip the implicit rule
+		if policy.ID == "implicit-default-deny" || !policy.Enabled {
+			continue // Skip the implicit rule and inactive rules
 		}
 
 		ruleNum := fmt.Sprintf("#%d", i+1)
@@ -311,6 +356,16 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 		fmt.Fprintf(w, "\n")
 	}
 
+	natExposures := g.natExposures()
+	if len(natExposures) > 0 {
+		fmt.Fprintf(w, "🚪 INDUSTRIAL SERVICES EXPOSED THROUGH NAT (Highest Risk):\n")
+		for _, exposure := range natExposures {
+			fmt.Fprintf(w, "%s\n", exposure)
+		}
+		fmt.Fprintf(w, "   → Industrial protocols have no authentication - never publish them to untrusted networks!\n")
+		fmt.Fprintf(w, "\n")
+	}
+
 	if len(dmzRisks) > 0 {
 		fmt.Fprintf(w, "🌐 DMZ ZONE RISKS (Internet-Exposed Systems):\n")
 		for _, rule := range dmzRisks {
@@ -334,6 +389,13 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 		priorityCount++
 	}
 
+	for _, exposure := range natExposures {
+		foundIssues = true
+		fmt.Fprintf(w, "%d. 🚨 URGENT: %s\n", priorityCount, exposure)
+		fmt.Fprintf(w, "   → Remove the NAT rule and provide access through a VPN and DMZ jump host instead\n")
+		priorityCount++
+	}
+
 	for _, rule := range manufacturingZoneRisks {
 		foundIssues = true
 		fmt.Fprintf(w, "%d. 🚨 URGENT: %s\n", priorityCount, rule)
@@ -355,7 +417,7 @@ func (g *FirewallDiagramGenerator) GenerateFirewallRulesSummary(outputPath strin
 	criticalRisks := 0
 
 	for _, policy := range g.model.Policies {
-		if policy.ID == "implicit-default-deny" {
+		if policy.ID == "implicit-default-deny" || !policy.Enabled {
 			continue
 		}
 
@@ -613,6 +675,9 @@ func (g *FirewallDiagramGenerator) generateSecurityPolicyFlows(w *bufio.Writer)
 	fmt.Fprintln(w, "  // Security Policy Flows")
 
 	for _, policy := range g.model.Policies {
+		if !policy.Enabled {
+			continue // Inactive rules don't create flows
+		}
 		if policy.Source.CIDR == "any" || policy.Destination.CIDR == "any" {
 			continue // Skip overly broad rules for clarity
 		}
@@ -718,6 +783,9 @@ func (g *FirewallDiagramGenerator) generateConduits(w *bufio.Writer, zoneNetwork
 	zonePairs := make(map[string]bool)
 
 	for _, policy := range g.model.Policies {
+		if !policy.Enabled {
+			continue
+		}
 		srcZone := g.getZoneForNetwork(policy.Source.CIDR)
 		dstZone := g.getZoneForNetwork(policy.Destination.CIDR)
 
@@ -763,7 +831,7 @@ func (g *FirewallDiagramGenerator) generateFirewallCentricTopology(w *bufio.Writ
 	// Create the central firewall node
 	fmt.Fprintln(w, "  // Central Firewall")
 	fmt.Fprintln(w, "  firewall [")
-	fmt.Fprintln(w, "    label=\"🔥 Firewall\\n(OPNsense)\\n\\nInterfaces:\";")
+	fmt.Fprintf(w, "    label=\"🔥 Firewall\\n(%s)\\n\\nInterfaces:\";\n", g.firewallVendor())
 	fmt.Fprintln(w, "    shape=box;")
 	fmt.Fprintln(w, "    style=\"filled,rounded\";")
 	fmt.Fprintln(w, "    fillcolor=\"#e3f2fd\";")
@@ -788,11 +856,56 @@ func (g *FirewallDiagramGenerator) generateFirewallCentricTopology(w *bufio.Writ
 	}
 	fmt.Fprintln(w, "")
 
+	// Add NAT translations
+	g.generateNATConnections(w)
+
 	// Add rule annotations
 	fmt.Fprintln(w, "  // Security Rules (as edge labels)")
 	g.generateRuleAnnotations(w)
 }
 
+// generateNATConnections draws port forwards and 1:1 mappings from the ingress
+// interface to the network holding the translated host
+func (g *FirewallDiagramGenerator) generateNATConnections(w *bufio.Writer) {
+	if len(g.model.NATRules) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "  // NAT Translations")
+	for _, rule := range g.model.NATRules {
+		if !rule.Enabled || rule.Type == types.OutboundNAT {
+			continue
+		}
+
+		from := g.findNetworkByReference(rule.Interface)
+		to := ""
+		for _, addr := range append([]string{rule.TranslatedDestination.CIDR}, rule.TranslatedDestination.IPs...) {
+			if to = g.findNetworkForAddress(addr); to != "" {
+				break
+			}
+		}
+		if from == "" || to == "" {
+			continue
+		}
+
+		original, translated := g.formatNATTranslation(rule)
+		color := "#1976d2"
+		if len(g.exposedIndustrialServices(rule)) > 0 {
+			color = "#d32f2f"
+		}
+
+		fmt.Fprintf(w, "  net_%s -> net_%s [\n", from, to)
+		fmt.Fprintf(w, "    label=\"NAT %s\\n%s\\n→ %s\";\n", rule.Type, original, translated)
+		fmt.Fprintln(w, "    style=dashed;")
+		fmt.Fprintf(w, "    color=\"%s\";\n", color)
+		fmt.Fprintf(w, "    fontcolor=\"%s\";\n", color)
+		fmt.Fprintln(w, "    fontsize=8;")
+		fmt.Fprintln(w, "    constraint=false;")
+		fmt.Fprintln(w, "  ];")
+	}
+	fmt.Fprintln(w, "")
+}
+
 // generateNetworkNode creates a node for a network segment
 func (g *FirewallDiagramGenerator) generateNetworkNode(w *bufio.Writer, network *types.NetworkSegment) {
 	nodeID := fmt.Sprintf("net_%s", network.ID)
@@ -991,6 +1104,26 @@ func (g *FirewallDiagramGenerator) findNetworkByReference(ref string) string {
 	return ""
 }
 
+// findNetworkForAddress returns the segment whose CIDR contains an IP or CIDR address
+func (g *FirewallDiagramGenerator) findNetworkForAddress(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		if parsed, _, err := net.ParseCIDR(addr); err == nil {
+			ip = parsed
+		}
+	}
+	if ip == nil {
+		return ""
+	}
+
+	for id, network := range g.model.Networks {
+		if _, subnet, err := net.ParseCIDR(network.CIDR); err == nil && subnet.Contains(ip) {
+			return id
+		}
+	}
+	return ""
+}
+
 func (g *FirewallDiagramGenerator) getZoneForNetwork(networkRef string) types.IEC62443Zone {
 	networkID := g.findNetworkByReference(networkRef)
 	if networkID != "" {
@@ -1014,3 +1147,177 @@ func (g *FirewallDiagramGenerator) truncateString(s string, maxLen int) string {
 	}
 	return s[:maxLen-3] + "..."
 }
+
+// firewallVendor returns the display name of the firewall the model was parsed from
+func (g *FirewallDiagramGenerator) firewallVendor() string {
+	switch g.model.Metadata.Type {
+	case types.InputTypePfSense:
+		return "pfSense"
+	case types.InputTypeFortiGate:
+		return "FortiGate"
+	case types.InputTypeVyatta:
+		return "VyOS"
+	case types.InputTypeIptables:
+		return "iptables"
+	case types.InputTypeFirewalla:
+		return "Firewalla"
+	default:
+		return "OPNsense"
+	}
+}
+
+// hasImplicitDefaultDeny reports whether the parser added a built-in default deny policy
+func (g *FirewallDiagramGenerator) hasImplicitDefaultDeny() bool {
+	for _, policy := range g.model.Policies {
+		if policy.ID == "implicit-default-deny" {
+			return true
+		}
+	}
+	return false
+}
+
+// industrialServicePorts lists OT protocols that must never be published through NAT
+var industrialServicePorts = map[uint16]string{
+	102:   "S7comm",
+	502:   "Modbus",
+	2222:  "EtherNet/IP I/O",
+	2404:  "IEC 60870-5-104",
+	4840:  "OPC UA",
+	9600:  "OMRON FINS",
+	18245: "GE SRTP",
+	20000: "DNP3",
+	34962: "PROFINET",
+	44818: "EtherNet/IP",
+	47808: "BACnet",
+}
+
+// formatNATTranslation renders the original and translated side of a NAT rule
+func (g *FirewallDiagramGenerator) formatNATTranslation(rule *types.NATRule) (string, string) {
+	switch rule.Type {
+	case types.OutboundNAT:
+		original := natAddress(rule.Source)
+		translated := natAddress(rule.TranslatedSource)
+		if ports := formatNATPorts(rule.TranslatedPorts); ports != "" {
+			translated += ":" + ports
+		}
+		if translated == "any" {
+			translated = "no NAT"
+		}
+		return original, translated
+	default:
+		original := natAddress(rule.Destination)
+		if ports := formatNATPorts(rule.Ports); ports != "" {
+			original += ":" + ports
+		}
+		translated := natAddress(rule.TranslatedDestination)
+		if ports := formatNATPorts(rule.TranslatedPorts); ports != "" {
+			translated += ":" + ports
+		}
+		if translated == "any" {
+			translated = "no redirect"
+		}
+		return original, translated
+	}
+}
+
+// natExposures lists enabled inbound NAT rules that publish industrial services
+func (g *FirewallDiagramGenerator) natExposures() []string {
+	exposures := []string{}
+	for _, rule := range g.model.NATRules {
+		services := g.exposedIndustrialServices(rule)
+		if len(services) == 0 {
+			continue
+		}
+		original, translated := g.formatNATTranslation(rule)
+		exposures = append(exposures, fmt.Sprintf("   %s (%s on %s): %s → %s exposes %s - %s",
+			rule.ID, rule.Type, rule.Interface, original, translated, strings.Join(services, ", "), rule.Description))
+	}
+	return exposures
+}
+
+// exposedIndustrialServices returns the industrial services an inbound NAT rule makes
+// reachable. 1:1 mappings and port forwards without a port list publish every port,
+// so any host in an industrial zone counts.
+func (g *FirewallDiagramGenerator) exposedIndustrialServices(rule *types.NATRule) []string {
+	if !rule.Enabled || rule.Type == types.OutboundNAT || natAddress(rule.TranslatedDestination) == "any" {
+		return nil
+	}
+
+	ports := rule.TranslatedPorts
+	if len(ports) == 0 {
+		ports = rule.Ports
+	}
+
+	if len(ports) == 0 {
+		for _, addr := range append([]string{rule.TranslatedDestination.CIDR}, rule.TranslatedDestination.IPs...) {
+			if id := g.findNetworkForAddress(addr); id != "" && g.model.Networks[id].Zone == types.IndustrialZone {
+				return []string{"all ports of an " + string(types.IndustrialZone) + " host"}
+			}
+		}
+		return nil
+	}
+
+	services := []string{}
+	for _, port := range sortedIndustrialPorts() {
+		for _, p := range ports {
+			low, high := natPortRange(p)
+			if port >= low && port <= high {
+				services = append(services, fmt.Sprintf("%s/%d", industrialServicePorts[port], port))
+				break
+			}
+		}
+	}
+	return services
+}
+
+// natAddress returns a display address for one side of a NAT rule
+func natAddress(r types.NetworkRange) string {
+	if r.CIDR != "" {
+		return r.CIDR
+	}
+	if len(r.IPs) > 0 {
+		return strings.Join(r.IPs, ",")
+	}
+	return "any"
+}
+
+// formatNATPorts renders ports as "502,8000-8010"; unresolved aliases keep their name
+func formatNATPorts(ports []types.Port) string {
+	parts := []string{}
+	for _, port := range ports {
+		_, spec, _ := strings.Cut(port.Protocol, ":")
+		switch {
+		case port.Number == 0 && spec != "":
+			parts = append(parts, spec)
+		case strings.Contains(spec, "-"):
+			parts = append(parts, spec)
+		case port.Number > 0:
+			parts = append(parts, fmt.Sprintf("%d", port.Number))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// natPortRange returns the port span of a parser Port ("proto:start-end" for ranges)
+func natPortRange(port types.Port) (uint16, uint16) {
+	if _, spec, ok := strings.Cut(port.Protocol, ":"); ok {
+		if low, high, isRange := strings.Cut(spec, "-"); isRange {
+			start, errLow := strconv.Atoi(low)
+			end, errHigh := strconv.Atoi(high)
+			if errLow == nil && errHigh == nil && start <= end && end <= 65535 {
+				return uint16(start), uint16(end)
+			}
+		}
+	}
+	return port.Number, port.Number
+}
+
+// sortedIndustrialPorts returns the industrial service ports in ascending order
+func sortedIndustrialPorts() []uint16 {
+	ports := make([]uint16, 0, len(industrialServicePorts))
+	for port := range industrialServicePorts {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
@@ -64,7 +64,7 @@ func (c *CombinedAnalyzer) GenerateCombinedModel() (*types.NetworkModel, error)
 		switch model.Metadata.Type {
 		case types.InputTypePCAP:
 			c.integratePCAPModel(combined, model)
-		case types.InputTypeOPNsense:
+		case types.InputTypeOPNsense, types.InputTypePfSense, types.InputTypeFortiGate, types.InputTypeIptables, types.InputTypeVyatta, types.InputTypeFirewalla:
 			c.integrateFirewallModel(combined, model)
 		default:
 			log.Printf("Warning: Unknown model type: %s", model.Metadata.Type)
@@ -98,6 +98,14 @@ func (c *CombinedAnalyzer) integratePCAPModel(combined *types.NetworkModel, pcap
 		combined.Flows[key] = flow
 	}
 
+	// Keep the sessions the flows link to, so diagrams know client from server
+	for key, session := range pcapModel.Sessions {
+		if combined.Sessions == nil {
+			combined.Sessions = make(map[types.SessionKey]*types.Session)
+		}
+		combined.Sessions[key] = session
+	}
+
 	// Merge inferred networks from PCAP
 	for id, network := range pcapModel.Networks {
 		if existing, exists := combined.Networks[id]; exists {
@@ -126,6 +134,7 @@ func (c *CombinedAnalyzer) integrateFirewallModel(combined *types.NetworkModel,
 
 	// Add all security policies
 	combined.Policies = append(combined.Policies, fwModel.Policies...)
+	combined.NATRules = append(combined.NATRules, fwModel.NATRules...)
 
 	// Create placeholder assets for networks defined in firewall but not seen in traffic
 	for _, network := range fwModel.Networks {
@@ -167,10 +176,14 @@ func (c *CombinedAnalyzer) reconcileModels(combined *types.NetworkModel) {
 	c.validateFlowsAgainstPolicies(combined)
 }
 
-// validateFlowsAgainstPolicies marks flows as allowed/denied based on policies
+// validateFlowsAgainstPolicies marks flows as allowed/denied based on policies. Reply
+// flows take the decision of the connection their sessions show they answer.
 func (c *CombinedAnalyzer) validateFlowsAgainstPolicies(model *types.NetworkModel) {
+	engine := NewPolicyEngine(model.Policies, model.Networks)
 	for _, flow := range model.Flows {
-		flow.Allowed = c.isFlowAllowedByPolicies(flow, model.Policies)
+		decision := engine.Evaluate(flow)
+		flow.Allowed = decision.Allowed
+		flow.PolicyID = decision.RuleID
 	}
 }
 
@@ -199,13 +212,26 @@ func (c *CombinedAnalyzer) performAdvancedAnalysis(combined *types.NetworkModel)
 func (c *CombinedAnalyzer) identifyPolicyViolations(model *types.NetworkModel) []PolicyViolation {
 	violations := []PolicyViolation{}
 
+	engine := NewPolicyEngine(model.Policies, model.Networks)
 	for _, flow := range model.Flows {
-		allowed := c.isFlowAllowedByPolicies(flow, model.Policies)
-		if !allowed {
+		if flow.IsReply() {
+			// Reported once, on the flow that opened the connection
+			continue
+		}
+		decision := engine.Evaluate(flow)
+		if !decision.Allowed {
+			description := fmt.Sprintf("Traffic from %s to %s (%s) hit rule %s (%s)", flow.Source, flow.Destination, flow.Protocol, decision.RuleID, decision.Action)
+			severity := "High"
+			if attemptsOnly(flowSessions(model, flow)) {
+				// No connection was established, so the traffic was most likely blocked
+				description += "; no connection attempt succeeded"
+				severity = "Low"
+			}
 			violations = append(violations, PolicyViolation{
 				Flow:        flow,
-				Description: fmt.Sprintf("Traffic from %s to %s not explicitly allowed", flow.Source, flow.Destination),
-				Severity:    "High",
+				RuleID:      decision.RuleID,
+				Description: description,
+				Severity:    severity,
 			})
 		}
 	}
@@ -249,16 +275,22 @@ func (c *CombinedAnalyzer) assessSecurityPosture(model *types.NetworkModel) Secu
 		RiskLevel:       types.MediumRisk,
 	}
 
-	// Calculate compliance score based on policy coverage
-	allowedFlows := 0
+	// Calculate compliance score based on policy coverage of the flows that open
+	// connections; replies share the decision of their request
+	requestFlows, allowedFlows := 0, 0
+	engine := NewPolicyEngine(model.Policies, model.Networks)
 	for _, flow := range model.Flows {
-		if c.isFlowAllowedByPolicies(flow, model.Policies) {
+		if flow.IsReply() {
+			continue
+		}
+		requestFlows++
+		if engine.Evaluate(flow).Allowed {
 			allowedFlows++
 		}
 	}
 
-	if posture.TotalFlows > 0 {
-		posture.ComplianceScore = float64(allowedFlows) / float64(posture.TotalFlows) * 100
+	if requestFlows > 0 {
+		posture.ComplianceScore = float64(allowedFlows) / float64(requestFlows) * 100
 	}
 
 	// Assess overall risk
@@ -274,6 +306,27 @@ func (c *CombinedAnalyzer) assessSecurityPosture(model *types.NetworkModel) Secu
 
 // Helper functions
 
+// flowSessions returns the sessions linked to a flow
+func flowSessions(model *types.NetworkModel, flow *types.Flow) []*types.Session {
+	var sessions []*types.Session
+	for _, key := range flow.Sessions {
+		if session, ok := model.Sessions[key]; ok {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// attemptsOnly reports whether every session is a connection attempt that failed
+func attemptsOnly(sessions []*types.Session) bool {
+	for _, session := range sessions {
+		if !session.Failed() {
+			return false
+		}
+	}
+	return len(sessions) > 0
+}
+
 func (c *CombinedAnalyzer) mergeAssets(target, source *types.Asset) {
 	// Merge protocols
 	for _, proto := range source.Protocols {
@@ -349,25 +402,6 @@ func (c *CombinedAnalyzer) findNetworkForAsset(asset *types.Asset, networks map[
 	return ""
 }
 
-func (c *CombinedAnalyzer) isFlowAllowedByPolicies(flow *types.Flow, policies []*types.SecurityPolicy) bool {
-	// Simplified policy checking - would be more complex in practice
-	for _, policy := range policies {
-		if policy.Action == types.Allow {
-			// Check if this policy covers the flow
-			if c.policyCoversFlow(policy, flow) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func (c *CombinedAnalyzer) policyCoversFlow(policy *types.SecurityPolicy, flow *types.Flow) bool {
-	// Simplified policy matching
-	return policy.Source.CIDR == "any" || policy.Destination.CIDR == "any" ||
-		policy.Source.CIDR == flow.Source || policy.Destination.CIDR == flow.Destination
-}
-
 func (c *CombinedAnalyzer) extractNetworkIP(cidr string) string {
 	if cidr == "" {
 		return ""
@@ -417,6 +451,7 @@ func (c *CombinedAnalyzer) calculatePriority(srcAsset, dstAsset *types.Asset) st
 
 type PolicyViolation struct {
 	Flow        *types.Flow
+	RuleID      string // Policy that denied the flow
 	Description string
 	Severity    string
 }
@@ -0,0 +1,556 @@
+package analysis
+
+import (
+	"bytes"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"cipgram/pkg/types"
+)
+
+// ImplicitDenyRuleID is reported when no policy matches a flow
+const ImplicitDenyRuleID = "implicit-default-deny"
+
+// PolicyDecision is the outcome of evaluating a flow against a rule set
+type PolicyDecision struct {
+	RuleID  string                // ID of the deciding policy
+	Action  types.RuleAction      // Action of the deciding policy
+	Allowed bool                  // Whether the flow is permitted
+	Policy  *types.SecurityPolicy // Deciding policy, nil for the implicit deny or when not evaluated
+	Reason  string                // Why the flow was not evaluated, e.g. it never crosses the firewall
+}
+
+// PolicyEngine evaluates flows against an ordered list of security policies.
+//
+// Policies are walked in order and the first matching rule decides, except for
+// non-quick floating rules (pf semantics), where the last match is remembered
+// until a quick rule matches or the list ends. Flows matching nothing hit the
+// implicit default deny.
+//
+// Flows carrying a server's replies are decided by the connection their request
+// opened: stateful firewalls pass the replies of allowed connections. Rule sets
+// that match connection states (iptables conntrack, VyOS state) must in addition
+// accept the replies themselves, usually with an ESTABLISHED rule.
+type PolicyEngine struct {
+	policies     []*types.SecurityPolicy
+	networks     map[string]*types.NetworkSegment
+	subnets      map[string]*net.IPNet // Segment ID -> parsed network
+	tracksStates bool                  // Some rule matches connection states
+}
+
+// NewPolicyEngine creates an engine for the given policies; networks are used to
+// resolve interface references ("lan", "opt1", "wanip") and rule interfaces.
+// Rules bound to interfaces missing from networks are reported once here and never match.
+func NewPolicyEngine(policies []*types.SecurityPolicy, networks map[string]*types.NetworkSegment) *PolicyEngine {
+	engine := &PolicyEngine{
+		policies: policies,
+		networks: networks,
+		subnets:  make(map[string]*net.IPNet),
+	}
+	for id, network := range networks {
+		if _, subnet, err := net.ParseCIDR(network.CIDR); err == nil {
+			engine.subnets[id] = subnet
+		}
+	}
+
+	warned := make(map[string]bool)
+	for _, policy := range policies {
+		if !policy.Enabled || policy.Local {
+			continue
+		}
+		if len(policy.States) > 0 {
+			engine.tracksStates = true
+		}
+		for _, name := range append(zoneNames(policy.Zone), zoneNames(policy.EgressZone)...) {
+			if _, ok := networks[name]; !ok && !isUnboundZone(name) && !warned[name] {
+				log.Printf("Warning: rule %s is bound to unknown interface %q; rules on it never match", policy.ID, name)
+				warned[name] = true
+			}
+		}
+	}
+	return engine
+}
+
+// Evaluate returns the policy decision for a flow
+func (e *PolicyEngine) Evaluate(flow *types.Flow) PolicyDecision {
+	if !flow.IsReply() {
+		return e.evaluate(flow)
+	}
+
+	request := *flow
+	request.Source, request.Destination = flow.Destination, flow.Source
+	decision := e.evaluate(&request)
+	if decision.Allowed && e.tracksStates {
+		return e.evaluate(flow)
+	}
+	if decision.Reason == "" {
+		decision.Reason = "reply to a connection decided by " + decision.RuleID
+	}
+	return decision
+}
+
+// evaluate walks the policies for a flow in the direction it was seen
+func (e *PolicyEngine) evaluate(flow *types.Flow) PolicyDecision {
+	srcIP, dstIP := net.ParseIP(flow.Source), net.ParseIP(flow.Destination)
+	if srcIP == nil || dstIP == nil {
+		// Layer 2 traffic (ARP, PROFINET DCP, ...) is never routed through the firewall
+		return PolicyDecision{Allowed: true, Action: types.Allow, Reason: "non-IP traffic"}
+	}
+
+	ingress, egress := e.segmentFor(srcIP), e.segmentFor(dstIP)
+	if ingress != "" && ingress == egress {
+		return PolicyDecision{Allowed: true, Action: types.Allow, Reason: "intra-segment traffic in " + ingress}
+	}
+
+	transport, port := flowTransport(flow)
+	if transport == "" && len(flow.Sessions) > 0 {
+		transport = flow.Sessions[0].Transport
+	}
+
+	var lastMatch *types.SecurityPolicy
+	for _, policy := range e.policies {
+		if !policy.Enabled || policy.Action == types.Log || policy.Local {
+			// Disabled rules never match; LOG does not terminate evaluation; rules for the
+			// firewall's own traffic (INPUT, OUTPUT, local zones) never see forwarded flows
+			continue
+		}
+		if !e.matches(policy, flow, srcIP, dstIP, ingress, egress, transport, port) {
+			continue
+		}
+
+		// Interface rules are always final; floating rules only when quick
+		if policy.Quick || !policy.Floating {
+			return decisionFor(policy)
+		}
+		lastMatch = policy
+	}
+
+	if lastMatch != nil {
+		return decisionFor(lastMatch)
+	}
+	return PolicyDecision{RuleID: ImplicitDenyRuleID, Action: types.Deny, Allowed: false}
+}
+
+// decisionFor converts a matching policy into a decision
+func decisionFor(policy *types.SecurityPolicy) PolicyDecision {
+	return PolicyDecision{
+		RuleID:  policy.ID,
+		Action:  policy.Action,
+		Allowed: policy.Action == types.Allow,
+		Policy:  policy,
+	}
+}
+
+// matches checks every criterion of a policy against a flow
+func (e *PolicyEngine) matches(policy *types.SecurityPolicy, flow *types.Flow, srcIP, dstIP net.IP,
+	ingress, egress, transport string, port uint16) bool {
+	return familyMatches(policy.IPFamily, srcIP) &&
+		e.interfaceMatches(policy, ingress, egress) &&
+		protocolMatches(policy.Protocol, transport) &&
+		e.rangeContains(policy.Source, srcIP) &&
+		e.rangeContains(policy.Destination, dstIP) &&
+		portsMatch(policy.Ports, policy.NegatePorts, transport, flowPorts(flow, port)) &&
+		sourcePortsMatch(policy, transport, flowSourcePorts(flow)) &&
+		statesMatch(policy.States, flow)
+}
+
+// statesMatch applies a rule's connection tracking states. Flows carrying replies
+// belong to established connections; any other flow opens new ones.
+func statesMatch(states []string, flow *types.Flow) bool {
+	if len(states) == 0 {
+		return true
+	}
+
+	state := "NEW"
+	if flow.IsReply() {
+		state = "ESTABLISHED"
+	}
+	for _, s := range states {
+		if strings.EqualFold(strings.TrimSpace(s), state) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentFor returns the ID of the segment containing ip, preferring the most specific
+func (e *PolicyEngine) segmentFor(ip net.IP) string {
+	best, bestBits := "", -1
+	for id, subnet := range e.subnets {
+		if bits, _ := subnet.Mask.Size(); subnet.Contains(ip) && bits > bestBits {
+			best, bestBits = id, bits
+		}
+	}
+	return best
+}
+
+// interfaceMatches applies a policy's interface binding and direction. Rules bound at
+// both ends must see the flow enter through Zone and leave through EgressZone.
+func (e *PolicyEngine) interfaceMatches(policy *types.SecurityPolicy, ingress, egress string) bool {
+	if policy.EgressZone != "" {
+		return e.zoneMatches(policy.Zone, "in", ingress, egress) && e.zoneMatches(policy.EgressZone, "out", ingress, egress)
+	}
+	return e.zoneMatches(policy.Zone, policy.Direction, ingress, egress)
+}
+
+// zoneMatches checks the ingress and egress segments of a flow against a list of
+// interfaces in the given direction. Empty zones and zones naming "any" or "all" are
+// not interface bound; interfaces missing from the networks never match.
+func (e *PolicyEngine) zoneMatches(zone, direction, ingress, egress string) bool {
+	names := zoneNames(zone)
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if isUnboundZone(name) {
+			return true
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := e.networks[name]; !ok {
+			continue
+		}
+		switch direction {
+		case "in":
+			if e.onInterface(name, ingress) {
+				return true
+			}
+		case "out":
+			if e.onInterface(name, egress) {
+				return true
+			}
+		default:
+			if e.onInterface(name, ingress) || e.onInterface(name, egress) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// zoneNames splits a comma-separated interface list; an empty zone yields no names
+func zoneNames(zone string) []string {
+	var names []string
+	for _, name := range strings.Split(zone, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isUnboundZone reports zone names that bind a rule to every interface
+func isUnboundZone(name string) bool {
+	switch strings.ToLower(name) {
+	case "any", "all":
+		return true
+	}
+	return false
+}
+
+// onInterface reports whether traffic to/from segment is seen on interface name.
+// Addresses outside every known subnet are reached through an interface without
+// a modelled subnet, typically a DHCP WAN.
+func (e *PolicyEngine) onInterface(name, segment string) bool {
+	if segment != "" {
+		return name == segment
+	}
+	_, hasSubnet := e.subnets[name]
+	return !hasSubnet || strings.Contains(strings.ToLower(name), "wan") || e.networks[name].Purpose == "Internet"
+}
+
+// rangeContains matches an address against a policy range. Resolved members in
+// IPs (hosts, CIDRs, a-b ranges) take precedence over the CIDR field, which may
+// hold an alias or interface name; interface names are resolved through the
+// network segments. References that cannot be resolved never match.
+func (e *PolicyEngine) rangeContains(r types.NetworkRange, ip net.IP) bool {
+	ref := strings.TrimSpace(r.CIDR)
+	negate := strings.HasPrefix(ref, "!")
+	ref = strings.TrimPrefix(ref, "!")
+
+	if isAnyReference(ref) && len(r.IPs) == 0 {
+		return !negate
+	}
+
+	entries := r.IPs
+	if len(entries) == 0 {
+		entries = strings.Split(ref, ",")
+	}
+
+	matched := false
+	for _, entry := range entries {
+		entry = strings.TrimSpace(strings.TrimPrefix(entry, "!"))
+		contains, ok := addressEntryContains(entry, ip)
+		if !ok {
+			contains = e.referenceContains(entry, ip)
+		}
+		if contains {
+			matched = true
+			break
+		}
+	}
+	return matched != negate
+}
+
+// referenceContains resolves interface references: "lan" is the segment subnet,
+// "lanip" the interface address
+func (e *PolicyEngine) referenceContains(ref string, ip net.IP) bool {
+	if subnet, ok := e.subnets[ref]; ok {
+		return subnet.Contains(ip)
+	}
+	if name, ok := strings.CutSuffix(ref, "ip"); ok {
+		if network, exists := e.networks[name]; exists {
+			if addr, _, err := net.ParseCIDR(network.CIDR); err == nil {
+				return addr.Equal(ip)
+			}
+		}
+	}
+	return false
+}
+
+// isAnyReference reports whether a range reference means every address
+func isAnyReference(ref string) bool {
+	switch strings.ToLower(ref) {
+	case "", "any", "all", "0.0.0.0/0", "::/0":
+		return true
+	}
+	return false
+}
+
+// familyMatches checks the address family of a flow against an inet/inet6 policy
+func familyMatches(family string, ip net.IP) bool {
+	switch family {
+	case "inet":
+		return ip.To4() != nil
+	case "inet6":
+		return ip.To4() == nil
+	default:
+		return true
+	}
+}
+
+// addressEntryContains checks ip against a host, CIDR or "start-end" entry; ok is
+// false when the entry is not an address at all (e.g. an alias or interface name)
+func addressEntryContains(entry string, ip net.IP) (contains bool, ok bool) {
+	if _, subnet, err := net.ParseCIDR(entry); err == nil {
+		return subnet.Contains(ip), true
+	}
+	if host := net.ParseIP(entry); host != nil {
+		return host.Equal(ip), true
+	}
+	if low, high, found := strings.Cut(entry, "-"); found {
+		start, end := net.ParseIP(strings.TrimSpace(low)), net.ParseIP(strings.TrimSpace(high))
+		if start != nil && end != nil {
+			if (start.To4() == nil) != (ip.To4() == nil) {
+				return false, true
+			}
+			return bytes.Compare(ip.To16(), start.To16()) >= 0 && bytes.Compare(ip.To16(), end.To16()) <= 0, true
+		}
+	}
+	return false, false
+}
+
+// protocolMatches compares a rule protocol ("tcp", "tcp/udp", "icmp", "any") with
+// the flow transport; unknown transports and vendor-specific protocols match
+func protocolMatches(protocol types.Protocol, transport string) bool {
+	rule := strings.ToLower(string(protocol))
+	if transport == "" || rule == "" || rule == "any" || rule == "all" || rule == "ip" {
+		return true
+	}
+
+	known := false
+	for _, proto := range strings.FieldsFunc(rule, func(r rune) bool { return r == '/' || r == ',' || r == ' ' }) {
+		proto = normalizeTransport(proto)
+		switch proto {
+		case "tcp", "udp", "icmp", "icmp6":
+			known = true
+			if proto == transport {
+				return true
+			}
+		}
+	}
+	return !known
+}
+
+// portsMatch checks the flow's destination ports against the policy ports. Ranges
+// are encoded in Port.Protocol as "proto:start-end"; Number 0 marks an unresolved
+// alias, which is treated as matching. Negated lists match flows seen on any other
+// port. Flows without a known port never match a rule restricted to ports.
+func portsMatch(policyPorts []types.Port, negate bool, transport string, candidates []uint16) bool {
+	if len(policyPorts) == 0 {
+		return true
+	}
+	for _, allowed := range policyPorts {
+		if allowed.Number == 0 {
+			return true
+		}
+	}
+
+	for _, candidate := range candidates {
+		if portListed(policyPorts, transport, candidate) != negate {
+			return true
+		}
+	}
+	return false
+}
+
+// sourcePortsMatch checks the client ports of the flow's sessions against the
+// policy's source ports. Flows without sessions carry no client port; as client
+// ports are ephemeral, source port restrictions do not apply to them.
+func sourcePortsMatch(policy *types.SecurityPolicy, transport string, candidates []uint16) bool {
+	if len(candidates) == 0 {
+		return true
+	}
+	return portsMatch(policy.SourcePorts, policy.NegateSourcePorts, transport, candidates)
+}
+
+// portListed reports whether a port is one of the policy ports or inside one of their ranges
+func portListed(policyPorts []types.Port, transport string, candidate uint16) bool {
+	for _, allowed := range policyPorts {
+		proto, spec, _ := strings.Cut(allowed.Protocol, ":")
+		if t := normalizeTransport(proto); transport != "" && (t == "tcp" || t == "udp") && t != transport {
+			continue
+		}
+
+		start, end := allowed.Number, allowed.Number
+		if low, high, found := strings.Cut(spec, "-"); found {
+			if lo, err := strconv.Atoi(low); err == nil {
+				if hi, err := strconv.Atoi(high); err == nil && lo <= hi && hi <= 65535 {
+					start, end = uint16(lo), uint16(hi)
+				}
+			}
+		}
+		if candidate >= start && candidate <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// flowPorts returns the destination ports a flow was seen on: its recorded ports, the
+// session ports at the destination's end, or failing both the well-known port of its
+// protocol
+func flowPorts(flow *types.Flow, protocolPort uint16) []uint16 {
+	ports := []uint16{}
+	for _, p := range flow.Ports {
+		ports = append(ports, p.Number)
+	}
+	for _, key := range flow.Sessions {
+		switch flow.Destination {
+		case key.Server:
+			ports = append(ports, key.ServerPort)
+		case key.Client:
+			ports = append(ports, key.ClientPort)
+		}
+	}
+	if len(ports) == 0 && protocolPort != 0 {
+		ports = append(ports, protocolPort)
+	}
+	return ports
+}
+
+// flowSourcePorts returns the session ports at the flow source's end: client ports
+// for requests, the server port for replies
+func flowSourcePorts(flow *types.Flow) []uint16 {
+	ports := []uint16{}
+	for _, key := range flow.Sessions {
+		switch flow.Source {
+		case key.Client:
+			ports = append(ports, key.ClientPort)
+		case key.Server:
+			ports = append(ports, key.ServerPort)
+		}
+	}
+	return ports
+}
+
+// normalizeTransport maps vendor spellings onto tcp/udp/icmp/icmp6
+func normalizeTransport(proto string) string {
+	switch strings.ToLower(strings.TrimSpace(proto)) {
+	case "tcp", "6":
+		return "tcp"
+	case "udp", "17":
+		return "udp"
+	case "icmp", "1":
+		return "icmp"
+	case "icmp6", "icmpv6", "ipv6-icmp", "58":
+		return "icmp6"
+	}
+	return strings.ToLower(strings.TrimSpace(proto))
+}
+
+// serviceTransports maps protocol names reported by PCAP analysis to their transport and port
+var serviceTransports = map[string]struct {
+	transport string
+	port      uint16
+}{
+	"ethernet/ip":     {"tcp", 44818},
+	"ethernet/ip i/o": {"udp", 2222},
+	"modbus":          {"tcp", 502},
+	"modbus tcp":      {"tcp", 502},
+	"s7comm":          {"tcp", 102},
+	"opc-ua":          {"tcp", 4840},
+	"opc ua":          {"tcp", 4840},
+	"opc classic":     {"tcp", 135},
+	"dnp3":            {"tcp", 20000},
+	"fins":            {"tcp", 9600},
+	"slmp":            {"tcp", 5007},
+	"melsec q":        {"tcp", 1025},
+	"omron tcp":       {"tcp", 20547},
+	"sinec":           {"tcp", 8834},
+	"bacnet/ip":       {"udp", 47808},
+	"bacnet":          {"udp", 47808},
+	"cc-link":         {"udp", 18246},
+	"http":            {"tcp", 80},
+	"https":           {"tcp", 443},
+	"ssh":             {"tcp", 22},
+	"telnet":          {"tcp", 23},
+	"ftp":             {"tcp", 21},
+	"smtp":            {"tcp", 25},
+	"ldap":            {"tcp", 389},
+	"ldaps":           {"tcp", 636},
+	"rdp":             {"tcp", 3389},
+	"vnc":             {"tcp", 5900},
+	"sql server":      {"tcp", 1433},
+	"mysql":           {"tcp", 3306},
+	"postgresql":      {"tcp", 5432},
+	"dns":             {"udp", 53},
+	"dhcp server":     {"udp", 67},
+	"dhcp client":     {"udp", 68},
+	"tftp":            {"udp", 69},
+	"ntp":             {"udp", 123},
+	"snmp":            {"udp", 161},
+	"snmp trap":       {"udp", 162},
+	"syslog":          {"udp", 514},
+	"mdns":            {"udp", 5353},
+	"tcp":             {"tcp", 0},
+	"udp":             {"udp", 0},
+	"icmp":            {"icmp", 0},
+	"icmpv6":          {"icmp6", 0},
+}
+
+// flowTransport infers transport and destination port from the flow protocol name,
+// accepting both PCAP names ("Modbus TCP") and typed constants ("Modbus-TCP-502")
+func flowTransport(flow *types.Flow) (string, uint16) {
+	name := strings.ToLower(string(flow.Protocol))
+	if service, ok := serviceTransports[name]; ok {
+		return service.transport, service.port
+	}
+
+	parts := strings.Split(name, "-")
+	for i, part := range parts {
+		if part != "tcp" && part != "udp" {
+			continue
+		}
+		if i+1 < len(parts) {
+			if port, err := strconv.Atoi(parts[i+1]); err == nil && port > 0 && port <= 65535 {
+				return part, uint16(port)
+			}
+		}
+		return part, 0
+	}
+	return "", 0
+}
@@ -179,6 +179,9 @@ func (a *App) runConfigAnalysis() error {
 	}
 
 	fmt.Printf("CIPgram Config Analysis - Project: %s\n", a.config.ProjectName)
+	if len(a.config.FirewallConfigs) > 1 {
+		fmt.Printf("Config files: %s\n", strings.Join(a.config.FirewallConfigs, ", "))
+	}
 	fmt.Printf("Output directory: %s\n", paths.ProjectRoot)
 
 	// Check Graphviz installation if images are requested
@@ -225,16 +228,123 @@ func (a *App) runCombinedAnalysis() error {
 	return nil
 }
 
-// runFirewallAnalysis performs firewall-only analysis
+// runFirewallAnalysis performs firewall-only analysis of every config file given
 func (a *App) runFirewallAnalysis(paths *output.OutputPaths) error {
+	configFiles := a.config.FirewallConfigs
+	if len(configFiles) == 0 {
+		configFiles = []string{a.config.FirewallConfig}
+	}
+
+	if len(configFiles) == 1 {
+		return a.analyzeFirewallConfig(configFiles[0], paths)
+	}
+
+	// Several (possibly mixed-vendor) configs: one output subdirectory per file
+	names := FirewallOutputNames(configFiles)
+	var failed []string
+	for i, configFile := range configFiles {
+		filePaths, err := firewallOutputPaths(paths, names[i])
+		if err == nil {
+			err = a.analyzeFirewallConfig(configFile, filePaths)
+		}
+		if err != nil {
+			log.Printf("Error: %s: %v", configFile, err)
+			failed = append(failed, configFile)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to analyze %d of %d config files: %s",
+			len(failed), len(configFiles), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// FirewallOutputNames returns the output subdirectory name of each config file: its
+// file name, prefixed with its parent directory when several files share the name
+// (site-a/config.xml, site-b/config.xml), and suffixed with a counter if still taken
+func FirewallOutputNames(configFiles []string) []string {
+	shared := make(map[string]int)
+	for _, configFile := range configFiles {
+		shared[filepath.Base(configFile)]++
+	}
+
+	names := make([]string, len(configFiles))
+	taken := make(map[string]bool)
+	for i, configFile := range configFiles {
+		name := outputName(filepath.Base(configFile))
+		if shared[filepath.Base(configFile)] > 1 {
+			if abs, err := filepath.Abs(configFile); err == nil {
+				if parent := filepath.Base(filepath.Dir(abs)); parent != string(filepath.Separator) {
+					name = outputName(parent) + "_" + name
+				}
+			}
+		}
+
+		unique := name
+		for n := 2; taken[unique]; n++ {
+			unique = fmt.Sprintf("%s_%d", name, n)
+		}
+		taken[unique] = true
+		names[i] = unique
+	}
+	return names
+}
+
+func outputName(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// firewallOutputPaths returns paths with the firewall outputs redirected into the
+// named subdirectory
+func firewallOutputPaths(paths *output.OutputPaths, name string) (*output.OutputPaths, error) {
+	filePaths := *paths
+	filePaths.FirewallAnalysis = filepath.Join(paths.FirewallAnalysis, name)
+	filePaths.IEC62443Diagrams = filepath.Join(paths.IEC62443Diagrams, name)
+
+	for _, dir := range []string{filePaths.FirewallAnalysis, filePaths.IEC62443Diagrams} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %v", dir, err)
+		}
+	}
+	return &filePaths, nil
+}
+
+// resolveFirewallType returns the vendor given on the command line, or detects it from the file content
+func (a *App) resolveFirewallType(configFile string) (types.InputType, error) {
+	if a.config.FirewallType != "" && !strings.EqualFold(a.config.FirewallType, "auto") {
+		return types.InputType(strings.ToLower(a.config.FirewallType)), nil
+	}
+
+	detection, err := firewall.DetectFirewall(configFile)
+	if err != nil {
+		return "", err
+	}
+
+	version := detection.Version
+	if version == "" {
+		version = "unknown"
+	}
+	log.Printf("Detected %s configuration (version %s, %.0f%% confidence: %s)",
+		detection.Type, version, detection.Confidence*100, detection.Evidence)
+
+	return detection.Type, nil
+}
+
+// analyzeFirewallConfig parses one firewall config and generates its diagrams and summaries
+func (a *App) analyzeFirewallConfig(configFile string, paths *output.OutputPaths) error {
 	log.Printf("Firewall Configuration Analysis")
-	log.Printf("Config file: %s", a.config.FirewallConfig)
+	log.Printf("Config file: %s", configFile)
+
+	firewallType, err := a.resolveFirewallType(configFile)
+	if err != nil {
+		return err
+	}
 
-	// Create parser factory and detect/parse firewall config
+	// Create parser factory and parse firewall config
 	factory := &firewall.ParserFactory{}
 
-	// For now, assume OPNsense - TODO: implement auto-detection
-	parser, err := factory.NewParser(a.config.FirewallConfig, types.InputTypeOPNsense)
+	parser, err := factory.NewParser(configFile, firewallType)
 	if err != nil {
 		return fmt.Errorf("failed to create firewall parser: %v", err)
 	}
@@ -843,29 +953,82 @@ func (a *App) convertNetworkModelToGraph(model *types.NetworkModel) *types.Graph
 		graph.Hosts[asset.ID] = host
 	}
 
-	// Convert Flows to Edges
+	// Convert Flows to Edges, drawn from client to server. Replies join the edge of the
+	// requests they answer once those are in.
+	var replies []types.FlowKey
 	for flowKey, flow := range model.Flows {
-		edge := &types.Edge{
-			Src:       flow.Source,
-			Dst:       flow.Destination,
-			Protocol:  flow.Protocol,
-			Packets:   int(flow.Packets),
-			Bytes:     flow.Bytes,
-			FirstSeen: flow.FirstSeen,
-			LastSeen:  flow.LastSeen,
+		if flow.IsReply() {
+			replies = append(replies, flowKey)
+			continue
 		}
-
-		// Infer Purdue level from source host if available
-		if srcHost, exists := graph.Hosts[flow.Source]; exists {
-			edge.InferredLevel = srcHost.InferredLevel
+		graph.Edges[flowKey] = newFlowEdge(graph, flow, flow.Source, flow.Destination)
+	}
+	for _, flowKey := range replies {
+		flow := model.Flows[flowKey]
+		requestKey := types.FlowKey{SrcIP: flowKey.DstIP, DstIP: flowKey.SrcIP, Proto: flowKey.Proto}
+		edge := graph.Edges[requestKey]
+		if edge == nil {
+			graph.Edges[requestKey] = newFlowEdge(graph, flow, flow.Destination, flow.Source)
+			continue
+		}
+		edge.Packets += int(flow.Packets)
+		edge.Bytes += flow.Bytes
+		if flow.FirstSeen.Before(edge.FirstSeen) {
+			edge.FirstSeen = flow.FirstSeen
 		}
+		if flow.LastSeen.After(edge.LastSeen) {
+			edge.LastSeen = flow.LastSeen
+		}
+	}
 
-		graph.Edges[flowKey] = edge
+	// Count the sessions each host initiated and served, per protocol
+	for key, session := range model.Sessions {
+		client, server := graph.Hosts[key.Client], graph.Hosts[key.Server]
+		if client == nil || server == nil {
+			continue
+		}
+		client.InitiatedCounts[session.Protocol]++
+		if client.PeersByProtoInitiated[session.Protocol] == nil {
+			client.PeersByProtoInitiated[session.Protocol] = make(map[string]bool)
+		}
+		client.PeersByProtoInitiated[session.Protocol][key.Server] = true
+		server.ReceivedCounts[session.Protocol]++
+		if server.PeersByProtoReceived[session.Protocol] == nil {
+			server.PeersByProtoReceived[session.Protocol] = make(map[string]bool)
+		}
+		server.PeersByProtoReceived[session.Protocol][key.Client] = true
 	}
 
 	return graph
 }
 
+// newFlowEdge creates the diagram edge for a flow, drawn from src to dst
+func newFlowEdge(graph *types.Graph, flow *types.Flow, src, dst string) *types.Edge {
+	edge := &types.Edge{
+		Src:       src,
+		Dst:       dst,
+		Protocol:  flow.Protocol,
+		Packets:   int(flow.Packets),
+		Bytes:     flow.Bytes,
+		FirstSeen: flow.FirstSeen,
+		LastSeen:  flow.LastSeen,
+	}
+
+	// Infer Purdue level from source host if available
+	if srcHost, exists := graph.Hosts[src]; exists {
+		edge.InferredLevel = srcHost.InferredLevel
+	}
+
+	// Most frequent CIP service seen by DPI
+	if flow.DPI != nil {
+		if service := flow.DPI.TopOperation(types.OperationCIPService); service != nil {
+			edge.CIPService = service.Name
+			edge.CIPServiceCode = fmt.Sprintf("0x%02X", service.Code)
+		}
+	}
+	return edge
+}
+
 // generatePurdueModelDiagrams creates traditional Purdue model with horizontal bars per level
 func (a *App) generatePurdueModelDiagrams(graph *types.Graph, basePath string, model *types.NetworkModel) error {
 	// Generate DOT file with traditional Purdue layout
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"cipgram/internal/output"
@@ -139,7 +140,7 @@ func (a *App) verifyInstallation() {
 // configurePCAPAnalysis sets up configuration and displays analysis info
 func (a *App) configurePCAPAnalysis(paths *output.OutputPaths) {
 	log.Printf("PCAP Traffic Analysis")
-	log.Printf("PCAP file: %s", a.config.PcapPath)
+	log.Printf("PCAP file: %s", strings.Join(a.pcapInputs(), ", "))
 	log.Printf("JSON file: %s", a.config.OutJSON)
 
 	// Set default output paths if not specified
@@ -175,21 +176,43 @@ func (a *App) parsePCAPFile() (*types.NetworkModel, error) {
 		HideUnknown:        a.config.HideUnknown,
 		MaxNodes:           a.config.MaxNodes,
 		ConfigPath:         a.config.ConfigPath,
+		Workers:            a.config.Workers,
+	}
+	if pcapConfig.Workers == 0 {
+		pcapConfig.Workers = runtime.NumCPU()
 	}
 
-	parser := pcap.NewPCAPParser(a.config.PcapPath, pcapConfig)
-	log.Printf("Parsing PCAP file...")
+	// Directories and globs expand to every capture they hold; all files merge into one model
+	files, err := pcap.ExpandCaptureInputs(a.pcapInputs())
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse the PCAP file
+	parser := pcap.NewCaptureInputsParser(files, pcapConfig)
+	if len(files) > 1 {
+		log.Printf("Parsing and merging %d PCAP files...", len(files))
+	} else {
+		log.Printf("Parsing PCAP file...")
+	}
+
+	// Parse the PCAP files
 	model, err := parser.Parse()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse PCAP file %s: %w", a.config.PcapPath, err)
+		return nil, fmt.Errorf("failed to parse PCAP file %s: %w", strings.Join(a.pcapInputs(), ", "), err)
 	}
 
 	log.Printf("Parsed PCAP: %d assets, %d flows", len(model.Assets), len(model.Flows))
 	return model, nil
 }
 
+// pcapInputs returns the capture files, directories and globs given on the command line
+func (a *App) pcapInputs() []string {
+	if len(a.config.PcapPaths) > 0 {
+		return a.config.PcapPaths
+	}
+	return []string{a.config.PcapPath}
+}
+
 // generatePCAPDiagrams creates all PCAP-related diagrams
 func (a *App) generatePCAPDiagrams(model *types.NetworkModel, paths *output.OutputPaths) error {
 	// Convert NetworkModel to Graph for proper PCAP diagram generation
@@ -32,9 +32,12 @@ type Config struct {
 	Command string // The command being executed
 
 	// Input options
-	PcapPath       string
-	FirewallConfig string
-	ConfigPath     string
+	PcapPath        string
+	PcapPaths       []string // All capture inputs; the pcap command merges several files, directories or globs
+	FirewallConfig  string
+	FirewallConfigs []string // All config files; the config command accepts several, possibly mixed-vendor
+	FirewallType    string   // Firewall vendor (opnsense, fortigate, ...); auto-detected when empty
+	ConfigPath      string
 
 	// Output options
 	OutDOT      string
@@ -50,6 +53,7 @@ type Config struct {
 	EnableVendorLookup bool
 	EnableDNSLookup    bool
 	FastMode           bool
+	Workers            int // Decode and DPI workers for PCAP parsing; 0 = one per CPU
 	DiagramType        string
 	BothDiagrams       bool
 }
@@ -60,7 +64,7 @@ func GetCommands() []Command {
 		{
 			Name:        "pcap",
 			Description: "Analyze PCAP network traffic files",
-			Usage:       "cipgram pcap <file.pcap> [options]",
+			Usage:       "cipgram pcap <file.pcap|directory|glob>... [options]",
 			Flags: []Flag{
 				{Name: "project", Type: "string", Description: "Project name for organized output (auto-generated if not specified)", Required: false},
 				{Name: "config", Type: "string", Description: "Optional YAML with subnet→Purdue mappings", Required: false},
@@ -74,15 +78,17 @@ func GetCommands() []Command {
 				{Name: "vendor-lookup", Type: "bool", Description: "Enable MAC vendor lookup for device identification", Default: true},
 				{Name: "dns-lookup", Type: "bool", Description: "Enable DNS hostname resolution (requires network access)", Default: false},
 				{Name: "fast", Type: "bool", Description: "Fast mode: disable vendor and DNS lookups for maximum speed", Default: false},
+				{Name: "workers", Type: "int", Description: "Parallel packet decoding and DPI workers (0 = one per CPU, 1 = sequential)", Default: 0},
 				{Name: "diagram", Type: "string", Description: "Diagram type: 'purdue' for functional modeling, 'network' for segmentation planning, 'both' for both types", Default: "both"},
 			},
 		},
 		{
 			Name:        "config",
 			Description: "Analyze firewall configuration files",
-			Usage:       "cipgram config <file.xml|file.conf> [options]",
+			Usage:       "cipgram config <file.xml|file.conf> [more files...] [options]",
 			Flags: []Flag{
 				{Name: "project", Type: "string", Description: "Project name for organized output (auto-generated if not specified)", Required: false},
+				{Name: "type", Type: "string", Description: "Firewall vendor: opnsense, pfsense, fortigate, vyatta, iptables, firewalla", Default: "auto-detect"},
 				{Name: "out", Type: "string", Description: "Output Graphviz DOT path (default: output/PROJECT/network_diagrams/diagram.dot)", Required: false},
 				{Name: "json", Type: "string", Description: "Output JSON path (default: output/PROJECT/data/diagram.json)", Required: false},
 				{Name: "images", Type: "bool", Description: "Generate PNG/SVG images from DOT file (requires Graphviz)", Default: true},
@@ -187,10 +193,16 @@ func ParseArgs(args []string) (*Config, error) {
 	// Handle pcap command
 	if command == "pcap" {
 		if len(args) < 2 {
-			return nil, fmt.Errorf("pcap command requires a file argument. Usage: cipgram pcap <file.pcap>")
+			return nil, fmt.Errorf("pcap command requires a file argument. Usage: cipgram pcap <file.pcap|directory|glob>...")
+		}
+		// Every argument before the first flag is a capture file, directory or glob
+		inputs := 1
+		for inputs < len(args) && !isCommandFlag("pcap", args[inputs]) {
+			inputs++
 		}
 		config.PcapPath = args[1]
-		return parsePcapCommand(args[2:], config)
+		config.PcapPaths = args[1:inputs]
+		return parsePcapCommand(args[inputs:], config)
 	}
 
 	// Handle config command
@@ -198,8 +210,14 @@ func ParseArgs(args []string) (*Config, error) {
 		if len(args) < 2 {
 			return nil, fmt.Errorf("config command requires a file argument. Usage: cipgram config <file.xml|file.conf>")
 		}
+		// Every argument before the first flag is a config file
+		files := 1
+		for files < len(args) && !isCommandFlag("config", args[files]) {
+			files++
+		}
 		config.FirewallConfig = args[1]
-		return parseConfigCommand(args[2:], config)
+		config.FirewallConfigs = args[1:files]
+		return parseConfigCommand(args[files:], config)
 	}
 
 	// Handle combined command
@@ -208,13 +226,35 @@ func ParseArgs(args []string) (*Config, error) {
 			return nil, fmt.Errorf("combined command requires two file arguments. Usage: cipgram combined <file.pcap> <file.xml|file.conf>")
 		}
 		config.PcapPath = args[1]
+		config.PcapPaths = []string{args[1]}
 		config.FirewallConfig = args[2]
+		config.FirewallConfigs = []string{args[2]}
 		return parseCombinedCommand(args[3:], config)
 	}
 
 	return nil, fmt.Errorf("unknown command: %s. Use 'cipgram help' for available commands", command)
 }
 
+// isCommandFlag reports whether arg names one of the command's flags
+func isCommandFlag(commandName, arg string) bool {
+	cleanArg := strings.TrimLeft(arg, "-")
+	if cleanArg == "help" {
+		return true
+	}
+
+	for _, cmd := range GetCommands() {
+		if cmd.Name != commandName {
+			continue
+		}
+		for _, flag := range cmd.Flags {
+			if cleanArg == flag.Name || cleanArg == "no-"+flag.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // parsePcapCommand parses arguments for the pcap command
 func parsePcapCommand(args []string, config *Config) (*Config, error) {
 	for i := 0; i < len(args); i++ {
@@ -260,6 +300,11 @@ func parsePcapCommand(args []string, config *Config) (*Config, error) {
 			config.EnableDNSLookup = true
 		case cleanArg == "fast":
 			config.FastMode = true
+		case cleanArg == "workers" && i+1 < len(args):
+			if _, err := fmt.Sscanf(args[i+1], "%d", &config.Workers); err != nil {
+				return nil, fmt.Errorf("invalid value for workers: %s", args[i+1])
+			}
+			i++
 		case cleanArg == "help":
 			ShowHelp("pcap")
 			return nil, fmt.Errorf("help displayed")
@@ -285,6 +330,9 @@ func parseConfigCommand(args []string, config *Config) (*Config, error) {
 		case cleanArg == "project" && i+1 < len(args):
 			config.ProjectName = args[i+1]
 			i++
+		case cleanArg == "type" && i+1 < len(args):
+			config.FirewallType = args[i+1]
+			i++
 		case cleanArg == "out" && i+1 < len(args):
 			config.OutDOT = args[i+1]
 			i++
@@ -490,11 +538,15 @@ func ShowHelp(commandName string) {
 				fmt.Println("  cipgram pcap traffic.pcap project MyProject")
 				fmt.Println("  cipgram pcap capture.pcap fast no-images")
 				fmt.Println("  cipgram pcap data.pcap config purdue_mappings.yaml")
+				fmt.Println("  cipgram pcap span1/ span2/ project SiteCapture")
+				fmt.Println("  cipgram pcap 'captures/trace.pcap*' fast")
 			} else if cmd.Name == "config" {
 				fmt.Println("EXAMPLES:")
 				fmt.Println("  cipgram config firewall.xml")
 				fmt.Println("  cipgram config opnsense.xml project SecurityAudit")
-				fmt.Println("  cipgram config pfsense.conf no-images")
+				fmt.Println("  cipgram config pfsense.xml no-images")
+				fmt.Println("  cipgram config site-a.xml site-b.conf rules.v4 project SiteAudit")
+				fmt.Println("  cipgram config fw_backup.conf type fortigate")
 				fmt.Println("  cipgram config router.conf summary")
 			} else if cmd.Name == "combined" {
 				fmt.Println("EXAMPLES:")
@@ -544,15 +596,27 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("combined command requires both PCAP and firewall configuration files")
 	}
 
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative: %d", c.Workers)
+	}
+
 	// Comprehensive input validation with security checks
-	if c.PcapPath != "" {
-		if err := validateFilePath(c.PcapPath, "PCAP"); err != nil {
+	captureInputs := c.PcapPaths
+	if len(captureInputs) == 0 && c.PcapPath != "" {
+		captureInputs = []string{c.PcapPath}
+	}
+	for _, input := range captureInputs {
+		if err := validateCaptureInput(input); err != nil {
 			return err
 		}
 	}
 
-	if c.FirewallConfig != "" {
-		if err := validateFilePath(c.FirewallConfig, "config"); err != nil {
+	configFiles := c.FirewallConfigs
+	if len(configFiles) == 0 && c.FirewallConfig != "" {
+		configFiles = []string{c.FirewallConfig}
+	}
+	for _, configFile := range configFiles {
+		if err := validateFilePath(configFile, "config"); err != nil {
 			return err
 		}
 	}
@@ -589,7 +653,11 @@ func (c *Config) SetDefaults() {
 	// Auto-generate project name if not specified
 	if c.ProjectName == "" {
 		if c.PcapPath != "" {
+			// A glob is named after its directory, e.g. "site1" for site1/*.pcap
 			base := filepath.Base(c.PcapPath)
+			if strings.ContainsAny(base, "*?[") {
+				base = filepath.Base(filepath.Dir(c.PcapPath))
+			}
 			c.ProjectName = strings.TrimSuffix(base, filepath.Ext(base))
 		} else if c.FirewallConfig != "" {
 			base := filepath.Base(c.FirewallConfig)
@@ -410,6 +410,20 @@ func (a *App) generateGraphJSON(graph *types.Graph, model *types.NetworkModel, o
 		"edges":    convertEdgesMapToArray(graph.Edges),
 		"flows":    convertFlowsMapToArray(model.Flows),
 		"networks": model.Networks,
+		// TCP and UDP sessions by 5-tuple, client to server, with handshake state
+		"sessions": convertSessionsMapToArray(model.Sessions),
+		// Which masters wrote to each Modbus slave
+		"modbus_write_access": summarizeModbusWriters(model.Flows),
+		// Which masters issued controls or restarts to each DNP3 outstation
+		"dnp3_control_masters": summarizeDNP3Controllers(model.Flows),
+		// Packets that renamed, re-addressed or reset a device
+		"events": model.Events,
+		// Deprecated TLS versions and cipher suites negotiated by OT zone assets
+		"ot_tls_weaknesses": summarizeOTTLSWeaknesses(model),
+		// Names seen for each address in DNS, mDNS, LLMNR and NBNS answers
+		"hostnames": model.Hostnames,
+		// Domains that OT zone assets looked up, to spot hosts resolving internet names
+		"ot_dns_queries": summarizeOTDNSQueries(model),
 	}
 
 	jsonData, err := json.MarshalIndent(data, "", "  ")
@@ -440,7 +454,7 @@ func convertHostsMapToArray(hosts map[string]*types.Host) []map[string]interface
 func convertEdgesMapToArray(edges map[types.FlowKey]*types.Edge) []map[string]interface{} {
 	var result []map[string]interface{}
 	for _, edge := range edges {
-		result = append(result, map[string]interface{}{
+		entry := map[string]interface{}{
 			"src":        edge.Src,
 			"dst":        edge.Dst,
 			"protocol":   edge.Protocol,
@@ -448,7 +462,12 @@ func convertEdgesMapToArray(edges map[types.FlowKey]*types.Edge) []map[string]in
 			"bytes":      edge.Bytes,
 			"first_seen": edge.FirstSeen,
 			"last_seen":  edge.LastSeen,
-		})
+		}
+		if edge.CIPService != "" {
+			entry["cip_service"] = edge.CIPService
+			entry["cip_service_code"] = edge.CIPServiceCode
+		}
+		result = append(result, entry)
 	}
 	return result
 }
@@ -456,7 +475,7 @@ func convertEdgesMapToArray(edges map[types.FlowKey]*types.Edge) []map[string]in
 func convertFlowsMapToArray(flows map[types.FlowKey]*types.Flow) []map[string]interface{} {
 	var result []map[string]interface{}
 	for _, flow := range flows {
-		result = append(result, map[string]interface{}{
+		entry := map[string]interface{}{
 			"source":      flow.Source,
 			"destination": flow.Destination,
 			"protocol":    flow.Protocol,
@@ -465,11 +484,276 @@ func convertFlowsMapToArray(flows map[types.FlowKey]*types.Flow) []map[string]in
 			"first_seen":  flow.FirstSeen,
 			"last_seen":   flow.LastSeen,
 			"allowed":     flow.Allowed,
-		})
+		}
+		if flow.PolicyID != "" {
+			entry["policy_id"] = flow.PolicyID
+		}
+		if len(flow.VLANs) > 0 {
+			entry["vlans"] = flow.VLANs
+		}
+		if len(flow.ServiceVLANs) > 0 {
+			entry["service_vlans"] = flow.ServiceVLANs
+		}
+		if flow.DPI != nil {
+			entry["dpi"] = convertFlowDPI(flow.DPI)
+		}
+		if flow.SessionCount > 0 {
+			entry["sessions"] = flow.SessionCount
+			entry["reply"] = flow.IsReply()
+		}
+		result = append(result, entry)
 	}
 	return result
 }
 
+// convertSessionsMapToArray lists sessions in the order they started
+func convertSessionsMapToArray(sessions map[types.SessionKey]*types.Session) []map[string]interface{} {
+	ordered := make([]*types.Session, 0, len(sessions))
+	for _, session := range sessions {
+		ordered = append(ordered, session)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if !ordered[i].FirstSeen.Equal(ordered[j].FirstSeen) {
+			return ordered[i].FirstSeen.Before(ordered[j].FirstSeen)
+		}
+		return ordered[i].Key.String() < ordered[j].Key.String()
+	})
+
+	result := make([]map[string]interface{}, 0, len(ordered))
+	for _, session := range ordered {
+		entry := map[string]interface{}{
+			"transport":        session.Key.Transport,
+			"client":           session.Key.Client,
+			"client_port":      session.Key.ClientPort,
+			"server":           session.Key.Server,
+			"server_port":      session.Key.ServerPort,
+			"protocol":         session.Protocol,
+			"initiated_by_syn": session.InitiatedBySYN,
+			"client_packets":   session.ClientPackets,
+			"client_bytes":     session.ClientBytes,
+			"server_packets":   session.ServerPackets,
+			"server_bytes":     session.ServerBytes,
+			"first_seen":       session.FirstSeen,
+			"last_seen":        session.LastSeen,
+		}
+		if session.Key.Attempt > 0 {
+			entry["attempt"] = session.Key.Attempt
+		}
+		if session.State != "" {
+			entry["state"] = session.State
+			entry["handshake"] = session.Handshake
+			entry["resets"] = session.Resets
+			entry["failed"] = session.Failed()
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// convertFlowDPI serializes DPI attributes with the operation histogram as an ordered list
+func convertFlowDPI(dpi *types.FlowDPI) map[string]interface{} {
+	entry := map[string]interface{}{
+		"operations": dpi.SortedOperations(),
+	}
+	if len(dpi.UnitIDs) > 0 {
+		entry["unit_ids"] = dpi.UnitIDs
+	}
+	if len(dpi.CIPObjects) > 0 {
+		entry["cip_objects"] = dpi.CIPObjects
+	}
+	if len(dpi.UserAgents) > 0 {
+		entry["user_agents"] = dpi.UserAgents
+	}
+	if dpi.Modbus != nil {
+		entry["modbus"] = dpi.Modbus
+	}
+	if len(dpi.HighRiskOperations) > 0 {
+		entry["high_risk_operations"] = dpi.HighRiskOperations
+	}
+	if dpi.OPCUA != nil {
+		entry["opcua"] = dpi.OPCUA
+	}
+	if len(dpi.DNP3) > 0 {
+		entry["dnp3"] = dpi.DNP3
+	}
+	if dpi.TLS != nil {
+		entry["tls"] = dpi.TLS
+	}
+	return entry
+}
+
+// summarizeModbusWriters maps each Modbus slave to the sorted list of masters that issued writes to it
+func summarizeModbusWriters(flows map[types.FlowKey]*types.Flow) map[string][]string {
+	writers := make(map[string][]string)
+	for _, flow := range flows {
+		if flow.DPI == nil || flow.DPI.Modbus == nil || flow.DPI.Modbus.WriteCount == 0 {
+			continue
+		}
+		profile := flow.DPI.Modbus
+		if !contains(writers[profile.Slave], profile.Master) {
+			writers[profile.Slave] = append(writers[profile.Slave], profile.Master)
+		}
+	}
+	for slave := range writers {
+		sort.Strings(writers[slave])
+	}
+	return writers
+}
+
+// summarizeDNP3Controllers maps each DNP3 outstation, as "host/address", to the sorted
+// list of masters that sent it controls or restarts
+func summarizeDNP3Controllers(flows map[types.FlowKey]*types.Flow) map[string][]string {
+	controllers := make(map[string][]string)
+	for _, flow := range flows {
+		if flow.DPI == nil {
+			continue
+		}
+		for _, session := range flow.DPI.DNP3 {
+			if session.Controls == 0 && session.Restarts == 0 {
+				continue
+			}
+			outstation := fmt.Sprintf("%s/%d", session.OutstationHost, session.Outstation)
+			master := fmt.Sprintf("%s/%d", session.MasterHost, session.Master)
+			if !contains(controllers[outstation], master) {
+				controllers[outstation] = append(controllers[outstation], master)
+			}
+		}
+	}
+	for outstation := range controllers {
+		sort.Strings(controllers[outstation])
+	}
+	return controllers
+}
+
+// summarizeOTTLSWeaknesses maps each "client -> server" connection with an endpoint in the
+// industrial or safety zone to the deprecated TLS versions and cipher suites it used
+func summarizeOTTLSWeaknesses(model *types.NetworkModel) map[string][]string {
+	weaknesses := make(map[string][]string)
+	for _, flow := range model.Flows {
+		if flow.DPI == nil || flow.DPI.TLS == nil || len(flow.DPI.TLS.Weaknesses) == 0 {
+			continue
+		}
+		if !isOTZoneAsset(model.Assets[flow.Source]) && !isOTZoneAsset(model.Assets[flow.Destination]) {
+			continue
+		}
+		connection := flow.Source + " -> " + flow.Destination
+		for _, weakness := range flow.DPI.TLS.Weaknesses {
+			if !contains(weaknesses[connection], weakness) {
+				weaknesses[connection] = append(weaknesses[connection], weakness)
+			}
+		}
+	}
+	for connection := range weaknesses {
+		sort.Strings(weaknesses[connection])
+	}
+	return weaknesses
+}
+
+// summarizeOTDNSQueries maps each industrial or safety zone asset to the sorted domains it
+// looked up, leaving out single-label and local-only names
+func summarizeOTDNSQueries(model *types.NetworkModel) map[string][]string {
+	queries := make(map[string][]string)
+	for id, asset := range model.Assets {
+		if !isOTZoneAsset(asset) {
+			continue
+		}
+		for _, domain := range asset.DNSQueries {
+			if isLocalDomain(domain) {
+				continue
+			}
+			queries[id] = append(queries[id], domain)
+		}
+		sort.Strings(queries[id])
+	}
+	return queries
+}
+
+// isLocalDomain reports names that cannot resolve on the internet
+func isLocalDomain(domain string) bool {
+	if !strings.Contains(domain, ".") {
+		return true
+	}
+	for _, suffix := range []string{".local", ".localdomain", ".lan", ".home.arpa", ".internal", ".localhost"} {
+		if strings.HasSuffix(domain, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOTZoneAsset(asset *types.Asset) bool {
+	return asset != nil && (asset.IEC62443Zone == types.IndustrialZone || asset.IEC62443Zone == types.SafetyZone)
+}
+
+// formatDNP3Sessions renders sessions as "1->10: 2 controls (CROB 3 LATCH_ON), 1 restarts, SAv5"
+// joined with "; "
+func formatDNP3Sessions(sessions []*types.DNP3Session) string {
+	var rendered []string
+	for _, session := range sessions {
+		var parts []string
+		if session.Controls > 0 {
+			controls := fmt.Sprintf("%d controls", session.Controls)
+			if len(session.ControlPoints) > 0 {
+				controls += " (" + strings.Join(session.ControlPoints, ", ") + ")"
+			}
+			parts = append(parts, controls)
+		}
+		if session.Restarts > 0 {
+			parts = append(parts, fmt.Sprintf("%d restarts", session.Restarts))
+		}
+		if session.UnsolicitedResponses > 0 {
+			parts = append(parts, fmt.Sprintf("%d unsolicited", session.UnsolicitedResponses))
+		}
+		if session.UnsolicitedDisabled {
+			parts = append(parts, "unsolicited disabled")
+		}
+		if session.SecureAuthentication {
+			parts = append(parts, "SAv5")
+		}
+		if len(session.IINFlags) > 0 {
+			parts = append(parts, "IIN "+strings.Join(session.IINFlags, " "))
+		}
+		entry := fmt.Sprintf("%d->%d", session.Master, session.Outstation)
+		if len(parts) > 0 {
+			entry += ": " + strings.Join(parts, ", ")
+		}
+		rendered = append(rendered, entry)
+	}
+	return strings.Join(rendered, "; ")
+}
+
+// formatModbusProfile renders a profile as "read holding_registers 0-9; write coils 4; 3 writes; 1 exceptions"
+func formatModbusProfile(profile *types.ModbusProfile) string {
+	if profile == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, r := range profile.Reads {
+		parts = append(parts, "read "+r.String())
+	}
+	for _, r := range profile.Writes {
+		parts = append(parts, "write "+r.String())
+	}
+	if profile.WriteCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d writes", profile.WriteCount))
+	}
+	if profile.DiagnosticCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d diagnostics", profile.DiagnosticCount))
+	}
+	if profile.DeviceIDCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d device identification", profile.DeviceIDCount))
+	}
+	var exceptions int64
+	for _, count := range profile.Exceptions {
+		exceptions += count
+	}
+	if exceptions > 0 {
+		parts = append(parts, fmt.Sprintf("%d exceptions", exceptions))
+	}
+	return strings.Join(parts, "; ")
+}
+
 // generateSVGFromDOT converts DOT file to SVG using Graphviz
 func (a *App) generateSVGFromDOT(dotPath, svgPath string) error {
 	cmd := exec.Command("dot", "-Tsvg", dotPath, "-o", svgPath)
@@ -522,6 +806,15 @@ func (a *App) generateConversationCSV(model *types.NetworkModel, paths *output.O
 		"Destination Network",
 		"First Seen",
 		"Last Seen",
+		"Operations",
+		"Unit IDs",
+		"CIP Objects",
+		"User Agents",
+		"Modbus Profile",
+		"High-Risk Operations",
+		"OPC UA Security",
+		"DNP3 Sessions",
+		"TLS",
 	}
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write CSV header: %v", err)
@@ -558,6 +851,7 @@ func (a *App) generateConversationCSV(model *types.NetworkModel, paths *output.O
 			flow.FirstSeen.Format("2006-01-02 15:04:05"),
 			flow.LastSeen.Format("2006-01-02 15:04:05"),
 		}
+		record = append(record, formatFlowDPIColumns(flow.DPI)...)
 
 		if err := writer.Write(record); err != nil {
 			return fmt.Errorf("failed to write CSV record: %v", err)
@@ -567,6 +861,97 @@ func (a *App) generateConversationCSV(model *types.NetworkModel, paths *output.O
 	return nil
 }
 
+// formatFlowDPIColumns renders DPI attributes as the trailing conversation CSV columns.
+// Operations are written as "0x03 Read Holding Registers (12)" joined with "; ".
+func formatFlowDPIColumns(dpi *types.FlowDPI) []string {
+	if dpi == nil {
+		return []string{"", "", "", "", "", "", "", "", ""}
+	}
+
+	var operations []string
+	for _, op := range dpi.SortedOperations() {
+		operations = append(operations, fmt.Sprintf("0x%02X %s (%d)", op.Code, op.Name, op.Count))
+	}
+	var unitIDs []string
+	for _, id := range dpi.UnitIDs {
+		unitIDs = append(unitIDs, strconv.Itoa(int(id)))
+	}
+
+	return []string{
+		strings.Join(operations, "; "),
+		strings.Join(unitIDs, "; "),
+		strings.Join(dpi.CIPObjects, "; "),
+		strings.Join(dpi.UserAgents, "; "),
+		formatModbusProfile(dpi.Modbus),
+		strings.Join(dpi.HighRiskOperations, "; "),
+		formatOPCUAChannel(dpi.OPCUA),
+		formatDNP3Sessions(dpi.DNP3),
+		formatTLSSession(dpi.TLS),
+	}
+}
+
+// formatOPCUAChannel renders a channel as "INSECURE; policy None; mode None; opc.tcp://plc:4840"
+func formatOPCUAChannel(channel *types.OPCUAChannel) string {
+	if channel == nil {
+		return ""
+	}
+
+	var parts []string
+	if channel.Insecure {
+		parts = append(parts, "INSECURE")
+	}
+	if channel.SecurityPolicy != "" {
+		parts = append(parts, "policy "+channel.SecurityPolicy)
+	}
+	if channel.SecurityMode != "" {
+		parts = append(parts, "mode "+channel.SecurityMode)
+	}
+	parts = append(parts, channel.EndpointURLs...)
+	return strings.Join(parts, "; ")
+}
+
+// formatTLSSession renders a session as "WEAK TLS 1.0, TLS_RSA_WITH_RC4_128_SHA; TLS 1.0;
+// TLS_RSA_WITH_RC4_128_SHA; SNI plc.local; JA3 <hash>; cert CN=plc (self-signed)"
+func formatTLSSession(session *types.TLSSession) string {
+	if session == nil {
+		return ""
+	}
+
+	var parts []string
+	if len(session.Weaknesses) > 0 {
+		parts = append(parts, "WEAK "+strings.Join(session.Weaknesses, ", "))
+	}
+	if session.Version != "" {
+		parts = append(parts, session.Version)
+	}
+	if session.CipherSuite != "" {
+		parts = append(parts, session.CipherSuite)
+	}
+	if session.ServerName != "" {
+		parts = append(parts, "SNI "+session.ServerName)
+	}
+	if len(session.ALPN) > 0 {
+		parts = append(parts, "ALPN "+strings.Join(session.ALPN, ","))
+	}
+	if session.JA3 != "" {
+		parts = append(parts, "JA3 "+session.JA3)
+	}
+	if session.JA3S != "" {
+		parts = append(parts, "JA3S "+session.JA3S)
+	}
+	if cert := session.Certificate; cert != nil {
+		certificate := "cert " + cert.Subject
+		if cert.SelfSigned {
+			certificate += " (self-signed)"
+		}
+		if cert.Expired {
+			certificate += " (expired)"
+		}
+		parts = append(parts, certificate)
+	}
+	return strings.Join(parts, "; ")
+}
+
 // isRoutedConversation determines if a conversation crosses network boundaries
 func (a *App) isRoutedConversation(srcIP, dstIP string, model *types.NetworkModel) bool {
 	srcNet := a.findNetworkForIP(srcIP, model)
@@ -611,6 +996,11 @@ func (a *App) extractPortFromFlow(flow *types.Flow) string {
 		return strconv.Itoa(int(flow.Ports[0].Number))
 	}
 
+	// Captured flows know the server port of their sessions
+	if len(flow.Sessions) > 0 {
+		return strconv.Itoa(int(flow.Sessions[0].ServerPort))
+	}
+
 	// Try to infer from protocol
 	protocol := strings.ToLower(string(flow.Protocol))
 	switch {
@@ -43,18 +43,44 @@ func validateFilePath(filePath, fileType string) error {
 	return nil
 }
 
+// validateCaptureInput checks one pcap command input: a capture file, a directory of
+// captures or a glob pattern. Which files a directory or pattern yields is decided when
+// the captures are read.
+func validateCaptureInput(input string) error {
+	if strings.ContainsAny(input, "*?[") {
+		if strings.Contains(filepath.Clean(input), "..") {
+			return fmt.Errorf("directory traversal detected in PCAP pattern: %s", input)
+		}
+		if _, err := filepath.Match(input, ""); err != nil {
+			return fmt.Errorf("invalid PCAP pattern %s: %v", input, err)
+		}
+		return nil
+	}
+
+	if info, err := os.Stat(input); err == nil && info.IsDir() {
+		if strings.Contains(filepath.Clean(input), "..") {
+			return fmt.Errorf("directory traversal detected in PCAP directory: %s", input)
+		}
+		return nil
+	}
+
+	return validateFilePath(input, "PCAP")
+}
+
 // validateFileExtension ensures files have expected extensions
 func validateFileExtension(filePath, fileType string) error {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch fileType {
 	case "PCAP":
+		// Rotated tcpdump -C/-W files carry a counter, e.g. trace.pcap3
+		ext = strings.TrimRight(ext, "0123456789")
 		validExts := []string{".pcap", ".pcapng", ".cap"}
 		if !contains(validExts, ext) {
 			return fmt.Errorf("invalid PCAP file extension: %s (expected: %v)", ext, validExts)
 		}
 	case "config":
-		validExts := []string{".xml", ".conf", ".cfg", ".config"}
+		validExts := []string{".xml", ".conf", ".cfg", ".config", ".rules", ".v4", ".v6", ".save", ".boot", ".json"}
 		if !contains(validExts, ext) {
 			return fmt.Errorf("invalid config file extension: %s (expected: %v)", ext, validExts)
 		}
@@ -0,0 +1,271 @@
+package firewall
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"cipgram/pkg/types"
+)
+
+// maxSniffBytes bounds how much of a config file is read for detection
+const maxSniffBytes = 1 << 20
+
+// DetectionResult describes the firewall type detected from a config file
+type DetectionResult struct {
+	Type       types.InputType
+	Confidence float32 // 0.0 - 1.0
+	Version    string  // Vendor/config version when the file records one
+	Evidence   string  // What the detection was based on
+}
+
+// DetectFirewallType attempts to detect firewall type from config file
+func DetectFirewallType(configPath string) (types.InputType, error) {
+	result, err := DetectFirewall(configPath)
+	if err != nil {
+		return "", err
+	}
+	return result.Type, nil
+}
+
+// DetectFirewall sniffs the content of a config file and returns the most
+// likely firewall type with a confidence score and detected version
+func DetectFirewall(configPath string) (*DetectionResult, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open config file: %v", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSniffBytes))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	result := DetectFirewallContent(data)
+	if result == nil {
+		return nil, fmt.Errorf("unable to detect firewall type of %s; specify it with 'type <vendor>'", configPath)
+	}
+	return result, nil
+}
+
+// DetectFirewallContent runs every detector over data and returns the most
+// confident match, or nil when no detector recognises the content
+func DetectFirewallContent(data []byte) *DetectionResult {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	detectors := []func([]byte) *DetectionResult{
+		detectXML,
+		detectFirewallaJSON,
+		detectFortiGate,
+		detectIptables,
+		detectVyatta,
+	}
+
+	var best *DetectionResult
+	for _, detect := range detectors {
+		if result := detect(trimmed); result != nil && (best == nil || result.Confidence > best.Confidence) {
+			best = result
+		}
+	}
+	return best
+}
+
+// detectXML distinguishes OPNsense and pfSense by their config.xml root element
+func detectXML(data []byte) *DetectionResult {
+	if data[0] != '<' {
+		return nil
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	var result *DetectionResult
+	inVersion := false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return result
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				switch t.Name.Local {
+				case "opnsense":
+					result = &DetectionResult{Type: types.InputTypeOPNsense, Confidence: 0.95, Evidence: "<opnsense> root element"}
+				case "pfsense":
+					result = &DetectionResult{Type: types.InputTypePfSense, Confidence: 0.95, Evidence: "<pfsense> root element"}
+				default:
+					return nil
+				}
+			}
+			inVersion = depth == 2 && t.Name.Local == "version"
+		case xml.CharData:
+			if inVersion {
+				result.Version = strings.TrimSpace(string(t))
+				result.Confidence = 0.99
+				result.Evidence += " with <version>"
+				return result
+			}
+		case xml.EndElement:
+			depth--
+			inVersion = false
+			if depth == 2 && result != nil && t.Name.Local == "interfaces" {
+				// Past the sections that identify the vendor; no root-level version
+				return result
+			}
+		}
+	}
+}
+
+// detectFirewallaJSON looks for the top-level sections of a Firewalla export
+func detectFirewallaJSON(data []byte) *DetectionResult {
+	if data[0] != '{' {
+		return nil
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+
+	matched := []string{}
+	for _, key := range []string{"interfaces", "networks", "devices", "groups", "policies", "rules", "vpns", "monitoring", "ids"} {
+		if _, ok := doc[key]; ok {
+			matched = append(matched, key)
+		}
+	}
+	if len(matched) < 2 {
+		return nil
+	}
+
+	result := &DetectionResult{
+		Type:       types.InputTypeFirewalla,
+		Confidence: min(0.5+0.1*float32(len(matched)), 0.95),
+		Evidence:   "JSON sections: " + strings.Join(matched, ", "),
+	}
+	var version string
+	if raw, ok := doc["version"]; ok && json.Unmarshal(raw, &version) == nil {
+		result.Version = version
+	}
+	return result
+}
+
+var fortiHeader = regexp.MustCompile(`^#config-version=([A-Za-z0-9]+)-(\d+\.\d+(?:\.\d+)?)`)
+
+// detectFortiGate recognises the FortiOS backup header or its config/edit/next/end syntax
+func detectFortiGate(data []byte) *DetectionResult {
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if m := fortiHeader.FindSubmatch(bytes.TrimSpace(firstLine)); m != nil {
+		return &DetectionResult{
+			Type:       types.InputTypeFortiGate,
+			Confidence: 0.99,
+			Version:    string(m[2]),
+			Evidence:   fmt.Sprintf("#config-version header (%s)", m[1]),
+		}
+	}
+
+	hasConfig, hasEdit, hasEnd := false, false, false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxSniffBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "config system ") || strings.HasPrefix(line, "config firewall "):
+			hasConfig = true
+		case strings.HasPrefix(line, "edit "):
+			hasEdit = true
+		case line == "end":
+			hasEnd = true
+		}
+	}
+	if hasConfig && hasEdit && hasEnd {
+		return &DetectionResult{Type: types.InputTypeFortiGate, Confidence: 0.8, Evidence: "config/edit/next/end blocks"}
+	}
+	return nil
+}
+
+var iptablesHeader = regexp.MustCompile(`^# Generated by ip(?:6)?tables-save v(\S+)`)
+
+// detectIptables recognises iptables-save table headers, chain declarations and COMMIT
+func detectIptables(data []byte) *DetectionResult {
+	version := ""
+	hasTable, hasChain, hasCommit := false, false, false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxSniffBytes)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case version == "" && strings.HasPrefix(line, "#"):
+			if m := iptablesHeader.FindStringSubmatch(line); m != nil {
+				version = m[1]
+			}
+		case strings.HasPrefix(line, "*"):
+			hasTable = true
+		case strings.HasPrefix(line, ":") && strings.Contains(line, "["):
+			hasChain = true
+		case line == "COMMIT":
+			hasCommit = true
+		}
+	}
+
+	if !hasTable || !hasCommit {
+		return nil
+	}
+
+	result := &DetectionResult{Type: types.InputTypeIptables, Confidence: 0.85, Version: version, Evidence: "*table ... COMMIT structure"}
+	if hasChain {
+		result.Confidence = 0.9
+	}
+	if version != "" {
+		result.Confidence = 0.99
+		result.Evidence = "iptables-save header"
+	}
+	return result
+}
+
+var (
+	vyosVersionFooter  = regexp.MustCompile(`Release version[:\s]+"?(?:VyOS\s+)?([0-9][^\s"*]*)`)
+	vyattaConfigFooter = regexp.MustCompile(`vyatta-config-version|vyos-config-version`)
+	vyosTopLevelBlocks = regexp.MustCompile(`(?m)^(interfaces|firewall|system|service|protocols|zone-policy)\s*\{\s*$`)
+	vyosSetCommand     = regexp.MustCompile(`(?m)^set (interfaces|firewall|system|service|protocols|zone-policy) `)
+)
+
+// detectVyatta recognises config.boot (curly braces and version footer) and "set" command output
+func detectVyatta(data []byte) *DetectionResult {
+	version := ""
+	if m := vyosVersionFooter.FindSubmatch(data); m != nil {
+		version = string(m[1])
+	}
+	footer := version != "" || vyattaConfigFooter.Match(data)
+
+	blocks := len(vyosTopLevelBlocks.FindAll(data, -1))
+	sets := len(vyosSetCommand.FindAll(data, -1))
+
+	switch {
+	case blocks > 0 && footer:
+		return &DetectionResult{Type: types.InputTypeVyatta, Confidence: 0.99, Version: version, Evidence: "config.boot with version footer"}
+	case blocks >= 2:
+		return &DetectionResult{Type: types.InputTypeVyatta, Confidence: 0.8, Version: version, Evidence: "config.boot top-level blocks"}
+	case sets > 0 && !bytes.Contains(data, []byte("\nconfig ")) && !bytes.HasPrefix(data, []byte("config ")):
+		confidence := float32(0.7)
+		if sets >= 3 {
+			confidence = 0.85
+		}
+		return &DetectionResult{Type: types.InputTypeVyatta, Confidence: confidence, Version: version, Evidence: "set command output"}
+	}
+	return nil
+}
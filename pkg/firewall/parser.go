@@ -5,6 +5,7 @@ import (
 	"cipgram/pkg/firewall/parsers/fortigate"
 	"cipgram/pkg/firewall/parsers/iptables"
 	"cipgram/pkg/firewall/parsers/opnsense"
+	"cipgram/pkg/firewall/parsers/pfsense"
 	"cipgram/pkg/firewall/parsers/vyatta"
 	"cipgram/pkg/types"
 	"fmt"
@@ -26,6 +27,8 @@ func (f *ParserFactory) NewParser(configPath string, firewallType types.InputTyp
 	switch firewallType {
 	case types.InputTypeOPNsense:
 		return opnsense.NewOPNsenseParser(configPath), nil
+	case types.InputTypePfSense:
+		return pfsense.NewPfSenseParser(configPath), nil
 	case types.InputTypeFortiGate:
 		return fortigate.NewFortiGateParser(configPath), nil
 	case types.InputTypeVyatta:
@@ -39,13 +42,6 @@ func (f *ParserFactory) NewParser(configPath string, firewallType types.InputTyp
 	}
 }
 
-// DetectFirewallType attempts to detect firewall type from config file
-func DetectFirewallType(configPath string) (types.InputType, error) {
-	// TODO: Implement auto-detection logic based on file content
-	// For now, return unknown and require explicit type specification
-	return "", fmt.Errorf("firewall type auto-detection not yet implemented")
-}
-
 // Common firewall configuration structures
 type CommonFirewallConfig struct {
 	Interfaces  []Interface
@@ -76,14 +72,8 @@ type Rule struct {
 	Description string
 }
 
-type NATPolicy struct {
-	ID                    string
-	Source                types.NetworkRange
-	Destination           types.NetworkRange
-	TranslatedSource      types.NetworkRange
-	TranslatedDestination types.NetworkRange
-	Description           string
-}
+// NATPolicy is the NAT entry parsers store in types.NetworkModel.NATRules
+type NATPolicy = types.NATRule
 
 type VPNConfig struct {
 	ID             string
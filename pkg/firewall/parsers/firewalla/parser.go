@@ -1,9 +1,20 @@
 package firewalla
 
 import (
-	"cipgram/pkg/types"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"cipgram/pkg/types"
 )
 
 // FirewallaParser implements FirewallParser for Firewalla configurations
@@ -21,9 +32,39 @@ func NewFirewallaParser(configPath string) *FirewallaParser {
 
 // Parse implements FirewallParser.Parse for Firewalla configs
 func (p *FirewallaParser) Parse() (*types.NetworkModel, error) {
-	// TODO: Implement Firewalla configuration parsing
-	// Firewalla uses JSON-based configuration files
-	return nil, fmt.Errorf("firewalla parser not yet implemented")
+	if err := p.loadConfig(); err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	model := &types.NetworkModel{
+		Assets:   make(map[string]*types.Asset),
+		Networks: make(map[string]*types.NetworkSegment),
+		Flows:    make(map[types.FlowKey]*types.Flow),
+		Policies: []*types.SecurityPolicy{},
+		Metadata: p.GetMetadata(),
+	}
+
+	// Networks/VLANs and otherwise unassigned interfaces become segments
+	p.parseNetworks(model)
+
+	// Known devices become assets in their segment
+	p.parseDevices(model)
+
+	// IDS block lists become drop policies, enforced ahead of every rule
+	p.parseIDS(model)
+
+	// Block/allow rules, scoped by the policies that reference them
+	p.parseRules(model)
+
+	// Network isolation settings become deny policies
+	p.parseIsolation(model)
+
+	// VPN servers become Remote Access Zone segments holding their clients
+	p.parseVPNs(model)
+
+	p.assessRisk(model)
+
+	return model, nil
 }
 
 // GetType implements FirewallParser.GetType
@@ -33,159 +74,827 @@ func (p *FirewallaParser) GetType() types.InputType {
 
 // GetMetadata implements FirewallParser.GetMetadata
 func (p *FirewallaParser) GetMetadata() types.InputMetadata {
+	info, _ := os.Stat(p.configPath)
+	size := int64(0)
+	modTime := time.Now()
+
+	if info != nil {
+		size = info.Size()
+		modTime = info.ModTime()
+	}
+
 	return types.InputMetadata{
 		Source:    p.configPath,
 		Type:      types.InputTypeFirewalla,
-		Timestamp: time.Now(),
-		Size:      0, // TODO: Get actual file size
+		Timestamp: modTime,
+		Size:      size,
+		Hash:      calculateFileHash(p.configPath),
 	}
 }
 
 // Validate implements FirewallParser.Validate
 func (p *FirewallaParser) Validate() error {
-	// TODO: Implement Firewalla config validation
-	return fmt.Errorf("firewalla validation not yet implemented")
-}
-
-// FirewallaConfig represents the structure of a Firewalla configuration
-// TODO: Define actual Firewalla configuration structures
-type FirewallaConfig struct {
-	// Firewalla configurations are typically JSON-based with sections like:
-	// - Network interfaces and VLANs
-	// - Security policies and rules
-	// - Network monitoring settings
-	// - VPN configurations
-	// - Intrusion detection settings
-
-	// Placeholder structures for future implementation
-	Version    string                    `json:"version"`
-	Timestamp  string                    `json:"timestamp"`
-	Interfaces []FirewallaInterface      `json:"interfaces"`
-	Networks   []FirewallaNetwork        `json:"networks"`
-	Policies   []FirewallaPolicy         `json:"policies"`
-	Rules      []FirewallaRule           `json:"rules"`
-	VPNs       []FirewallaVPN            `json:"vpns"`
-	Monitoring FirewallaMonitoringConfig `json:"monitoring"`
-	IDS        FirewallaIDSConfig        `json:"ids"`
-}
-
-type FirewallaInterface struct {
-	Name        string   `json:"name"`
-	Type        string   `json:"type"` // ethernet, wifi, vlan
-	MAC         string   `json:"mac"`
-	IP          string   `json:"ip"`
-	Netmask     string   `json:"netmask"`
-	Gateway     string   `json:"gateway"`
-	DNS         []string `json:"dns"`
-	VLAN        int      `json:"vlan,omitempty"`
-	Description string   `json:"description"`
-	Enabled     bool     `json:"enabled"`
-}
-
-type FirewallaNetwork struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	CIDR        string `json:"cidr"`
-	Interface   string `json:"interface"`
-	Type        string `json:"type"` // lan, guest, iot, etc.
-	Isolation   bool   `json:"isolation"`
-	Description string `json:"description"`
-}
-
-type FirewallaPolicy struct {
-	ID          string            `json:"id"`
-	Name        string            `json:"name"`
-	Type        string            `json:"type"` // security, qos, routing
-	Networks    []string          `json:"networks"`
-	Devices     []string          `json:"devices"`
-	Rules       []string          `json:"rules"`
-	Schedule    FirewallaSchedule `json:"schedule"`
-	Enabled     bool              `json:"enabled"`
-	Description string            `json:"description"`
-}
-
-type FirewallaRule struct {
-	ID          string              `json:"id"`
-	Name        string              `json:"name"`
-	Type        string              `json:"type"`      // allow, block, monitor
-	Direction   string              `json:"direction"` // inbound, outbound, both
-	Source      FirewallaRuleTarget `json:"source"`
-	Destination FirewallaRuleTarget `json:"destination"`
-	Service     FirewallaService    `json:"service"`
-	Action      string              `json:"action"`
-	Log         bool                `json:"log"`
-	Schedule    FirewallaSchedule   `json:"schedule"`
-	Priority    int                 `json:"priority"`
-	Enabled     bool                `json:"enabled"`
-	Description string              `json:"description"`
-}
-
-type FirewallaRuleTarget struct {
-	Type     string   `json:"type"` // any, network, device, group
-	Networks []string `json:"networks,omitempty"`
-	Devices  []string `json:"devices,omitempty"`
-	Groups   []string `json:"groups,omitempty"`
-}
-
-type FirewallaService struct {
-	Type      string   `json:"type"` // any, predefined, custom
-	Protocols []string `json:"protocols,omitempty"`
-	Ports     []string `json:"ports,omitempty"`
-}
-
-type FirewallaSchedule struct {
-	Type      string   `json:"type"` // always, time_range, recurring
-	StartTime string   `json:"start_time,omitempty"`
-	EndTime   string   `json:"end_time,omitempty"`
-	Days      []string `json:"days,omitempty"`
-	Timezone  string   `json:"timezone,omitempty"`
-}
-
-type FirewallaVPN struct {
-	ID          string               `json:"id"`
-	Name        string               `json:"name"`
-	Type        string               `json:"type"` // wireguard, openvpn, ipsec
-	ServerIP    string               `json:"server_ip"`
-	ServerPort  int                  `json:"server_port"`
-	Protocol    string               `json:"protocol"`
-	Encryption  string               `json:"encryption"`
-	Networks    []string             `json:"networks"`
-	Clients     []FirewallaVPNClient `json:"clients"`
-	Enabled     bool                 `json:"enabled"`
-	Description string               `json:"description"`
-}
-
-type FirewallaVPNClient struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	PublicKey string `json:"public_key"`
-	IP        string `json:"ip"`
-	Enabled   bool   `json:"enabled"`
-}
-
-type FirewallaMonitoringConfig struct {
-	TrafficAnalysis  bool     `json:"traffic_analysis"`
-	DeviceDiscovery  bool     `json:"device_discovery"`
-	ThreatDetection  bool     `json:"threat_detection"`
-	BandwidthMonitor bool     `json:"bandwidth_monitor"`
-	AlertChannels    []string `json:"alert_channels"`
-}
-
-type FirewallaIDSConfig struct {
-	Enabled      bool     `json:"enabled"`
-	Mode         string   `json:"mode"`        // monitor, block
-	Sensitivity  string   `json:"sensitivity"` // low, medium, high
-	Categories   []string `json:"categories"`
-	CustomRules  []string `json:"custom_rules"`
-	WhitelistIPs []string `json:"whitelist_ips"`
-	BlacklistIPs []string `json:"blacklist_ips"`
-}
-
-// TODO: Implement parsing functions for Firewalla JSON format
-// parseInterfaces() error
-// parseNetworks() error
-// parsePolicies() error
-// parseRules() error
-// parseVPNs() error
-// parseMonitoring() error
-// parseIDS() error
+	if _, err := os.Stat(p.configPath); os.IsNotExist(err) {
+		return fmt.Errorf("config file not found: %s", p.configPath)
+	}
+
+	if err := p.loadConfig(); err != nil {
+		return fmt.Errorf("invalid Firewalla configuration: %v", err)
+	}
+
+	if len(p.config.Interfaces) == 0 && len(p.config.Networks) == 0 {
+		return fmt.Errorf("invalid Firewalla configuration: no interfaces or networks found")
+	}
+
+	return nil
+}
+
+// GetConfig returns the parsed Firewalla configuration (nil until Parse or Validate succeeds)
+func (p *FirewallaParser) GetConfig() *FirewallaConfig {
+	return p.config
+}
+
+// loadConfig decodes the Firewalla JSON export
+func (p *FirewallaParser) loadConfig() error {
+	data, err := os.ReadFile(p.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	var config FirewallaConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("cannot parse JSON: %v", err)
+	}
+
+	p.config = &config
+	return nil
+}
+
+// parseNetworks creates segments from networks/VLANs, then from addressed
+// interfaces that no network refers to (typically the WAN uplink)
+func (p *FirewallaParser) parseNetworks(model *types.NetworkModel) {
+	interfaces := make(map[string]FirewallaInterface)
+	for _, iface := range p.config.Interfaces {
+		interfaces[iface.Name] = iface
+	}
+
+	used := make(map[string]bool)
+	for _, network := range p.config.Networks {
+		id := network.ID
+		if id == "" {
+			id = network.Name
+		}
+
+		cidr := network.CIDR
+		if cidr == "" {
+			if iface, ok := interfaces[network.Interface]; ok {
+				cidr = buildCIDR(iface.IP, iface.Netmask)
+			}
+		}
+		used[network.Interface] = true
+
+		name := network.Name
+		if name == "" {
+			name = id
+		}
+
+		text := strings.Join([]string{network.Type, network.Name, network.Description}, " ")
+		if iface, ok := interfaces[network.Interface]; ok && iface.VLAN > 0 {
+			name = fmt.Sprintf("%s (VLAN %d)", name, iface.VLAN)
+		}
+
+		model.Networks[id] = &types.NetworkSegment{
+			ID:       id,
+			Name:     name,
+			CIDR:     cidr,
+			Zone:     inferZone(text),
+			Assets:   []*types.Asset{},
+			Policies: []*types.SecurityPolicy{},
+			Purpose:  inferPurpose(text),
+		}
+	}
+
+	for _, iface := range p.config.Interfaces {
+		if used[iface.Name] || !iface.Enabled || iface.IP == "" {
+			continue
+		}
+
+		text := iface.Name + " " + iface.Description
+		zone := inferZone(text)
+		purpose := inferPurpose(text)
+		if iface.Gateway != "" {
+			// The interface holding the default gateway faces the internet
+			zone = types.DMZZone
+			purpose = "Internet"
+		}
+
+		name := iface.Description
+		if name == "" {
+			name = iface.Name
+		}
+
+		model.Networks[iface.Name] = &types.NetworkSegment{
+			ID:       iface.Name,
+			Name:     name,
+			CIDR:     buildCIDR(iface.IP, iface.Netmask),
+			Zone:     zone,
+			Assets:   []*types.Asset{},
+			Policies: []*types.SecurityPolicy{},
+			Purpose:  purpose,
+		}
+	}
+}
+
+// parseDevices creates assets for the devices Firewalla has discovered
+func (p *FirewallaParser) parseDevices(model *types.NetworkModel) {
+	for _, device := range p.config.Devices {
+		id := device.IP
+		if id == "" {
+			id = device.ID
+		}
+		if id == "" {
+			continue
+		}
+
+		asset := &types.Asset{
+			ID:         id,
+			IP:         device.IP,
+			MAC:        device.MAC,
+			Hostname:   device.Name,
+			DeviceName: device.Name,
+			Vendor:     device.Vendor,
+			Roles:      []string{},
+			Protocols:  []types.Protocol{},
+		}
+		if device.Type != "" {
+			asset.Roles = append(asset.Roles, device.Type)
+		}
+
+		if segment := p.deviceSegment(model, device); segment != nil {
+			asset.IEC62443Zone = segment.Zone
+			segment.Assets = append(segment.Assets, asset)
+		}
+
+		model.Assets[id] = asset
+	}
+}
+
+// deviceSegment finds a device's segment by network ID, falling back to CIDR containment
+func (p *FirewallaParser) deviceSegment(model *types.NetworkModel, device FirewallaDevice) *types.NetworkSegment {
+	if segment, ok := model.Networks[device.Network]; ok {
+		return segment
+	}
+
+	ip := net.ParseIP(device.IP)
+	if ip == nil {
+		return nil
+	}
+	for _, id := range sortedKeys(model.Networks) {
+		if _, network, err := net.ParseCIDR(model.Networks[id].CIDR); err == nil && network.Contains(ip) {
+			return model.Networks[id]
+		}
+	}
+	return nil
+}
+
+// parseRules converts rules into policies in priority order. A rule referenced by a
+// security policy applies to that policy's networks and devices when its own target is "any".
+func (p *FirewallaParser) parseRules(model *types.NetworkModel) {
+	scopes := make(map[string][]FirewallaPolicy)
+	for _, policy := range p.config.Policies {
+		if policy.Type != "" && policy.Type != "security" {
+			continue // QoS and routing policies do not filter traffic
+		}
+		for _, ruleID := range policy.Rules {
+			scopes[ruleID] = append(scopes[ruleID], policy)
+		}
+	}
+
+	rules := append([]FirewallaRule{}, p.config.Rules...)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority < rules[j].Priority
+	})
+
+	for i, rule := range rules {
+		id := rule.ID
+		if id == "" {
+			id = fmt.Sprintf("rule-%d", i+1)
+		}
+
+		if len(scopes[rule.ID]) == 0 {
+			p.addRulePolicies(model, rule, id, nil)
+			continue
+		}
+		for _, scope := range scopes[rule.ID] {
+			scope := scope
+			p.addRulePolicies(model, rule, fmt.Sprintf("%s/%s", scope.ID, id), &scope)
+		}
+	}
+}
+
+// addRulePolicies appends the policy for a rule, plus its mirror for bidirectional rules
+func (p *FirewallaParser) addRulePolicies(model *types.NetworkModel, rule FirewallaRule, id string, scope *FirewallaPolicy) {
+	source := p.resolveTarget(rule.Source)
+	destination := p.resolveTarget(rule.Destination)
+	zone, egress := p.targetZone(rule.Source), p.targetZone(rule.Destination)
+	enabled := rule.Enabled
+
+	description := rule.Description
+	if description == "" {
+		description = rule.Name
+	}
+	if description == "" {
+		description = fmt.Sprintf("Firewalla rule %s", id)
+	}
+
+	if scope != nil {
+		if source.CIDR == "any" {
+			source = p.scopeRange(*scope)
+			zone = p.scopeZone(*scope)
+		}
+		enabled = enabled && scope.Enabled
+		description = fmt.Sprintf("%s (policy %s)", description, scope.Name)
+	}
+	if schedule := describeSchedule(rule.Schedule); schedule != "" {
+		description = fmt.Sprintf("%s [%s]", description, schedule)
+	}
+	if rule.Log {
+		description += " [logged]"
+	}
+
+	action := rule.Action
+	if action == "" {
+		action = rule.Type
+	}
+	protocol, ports := parseService(rule.Service)
+
+	policy := &types.SecurityPolicy{
+		ID:          id,
+		Source:      source,
+		Destination: destination,
+		Ports:       ports,
+		Protocol:    protocol,
+		Action:      mapAction(action),
+		Zone:        zone,
+		Direction:   "in",
+		EgressZone:  egress,
+		Description: description,
+		Enabled:     enabled,
+	}
+	p.addPolicy(model, policy, rule.Source, rule.Destination)
+
+	if strings.ToLower(rule.Direction) == "both" {
+		reverse := *policy
+		reverse.ID = id + "-reverse"
+		reverse.Source, reverse.Destination = policy.Destination, policy.Source
+		reverse.Zone, reverse.EgressZone = policy.EgressZone, policy.Zone
+		p.addPolicy(model, &reverse, rule.Destination, rule.Source)
+	}
+}
+
+// addPolicy records a policy on the model and on every network it names
+func (p *FirewallaParser) addPolicy(model *types.NetworkModel, policy *types.SecurityPolicy, targets ...FirewallaRuleTarget) {
+	model.Policies = append(model.Policies, policy)
+
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		for _, networkID := range target.Networks {
+			if segment, ok := model.Networks[networkID]; ok && !seen[networkID] {
+				segment.Policies = append(segment.Policies, policy)
+				seen[networkID] = true
+			}
+		}
+	}
+}
+
+// resolveTarget converts a rule target into a network range. Network targets are
+// referenced by network ID so diagrams can match them to segments.
+func (p *FirewallaParser) resolveTarget(target FirewallaRuleTarget) types.NetworkRange {
+	switch strings.ToLower(target.Type) {
+	case "", "any":
+		if len(target.Networks)+len(target.Devices)+len(target.Groups) == 0 {
+			return types.NetworkRange{CIDR: "any", IPs: []string{}}
+		}
+	}
+
+	var refs []string
+	ips := []string{}
+
+	for _, networkID := range target.Networks {
+		refs = append(refs, networkID)
+		if cidr := p.networkCIDR(networkID); cidr != "" {
+			ips = append(ips, cidr)
+		}
+	}
+	for _, deviceID := range target.Devices {
+		device := p.findDevice(deviceID)
+		if device == nil {
+			refs = append(refs, deviceID)
+			continue
+		}
+		if device.IP != "" {
+			refs = append(refs, device.IP)
+			ips = append(ips, device.IP)
+		} else {
+			refs = append(refs, deviceID)
+		}
+	}
+	for _, groupID := range target.Groups {
+		group := p.findGroup(groupID)
+		if group == nil {
+			refs = append(refs, groupID)
+			continue
+		}
+		refs = append(refs, group.Name)
+		for _, deviceID := range group.Devices {
+			if device := p.findDevice(deviceID); device != nil && device.IP != "" {
+				ips = append(ips, device.IP)
+			}
+		}
+	}
+
+	return types.NetworkRange{CIDR: strings.Join(refs, ","), IPs: ips}
+}
+
+// scopeRange describes the networks and devices a policy applies to
+func (p *FirewallaParser) scopeRange(policy FirewallaPolicy) types.NetworkRange {
+	if len(policy.Networks) == 0 && len(policy.Devices) == 0 {
+		return types.NetworkRange{CIDR: "any", IPs: []string{}}
+	}
+	return p.resolveTarget(FirewallaRuleTarget{
+		Type:     "network",
+		Networks: policy.Networks,
+		Devices:  policy.Devices,
+	})
+}
+
+// targetZone returns the networks a rule target is bound to, or "any" when the target
+// is not confined to known networks (any address, devices without a network)
+func (p *FirewallaParser) targetZone(target FirewallaRuleTarget) string {
+	networks := append([]string{}, target.Networks...)
+	devices := append([]string{}, target.Devices...)
+	for _, groupID := range target.Groups {
+		group := p.findGroup(groupID)
+		if group == nil {
+			return "any"
+		}
+		devices = append(devices, group.Devices...)
+	}
+	for _, deviceID := range devices {
+		device := p.findDevice(deviceID)
+		if device == nil || device.Network == "" {
+			return "any"
+		}
+		networks = append(networks, device.Network)
+	}
+	if len(networks) == 0 {
+		return "any"
+	}
+
+	seen := make(map[string]bool)
+	var unique []string
+	for _, network := range networks {
+		if !seen[network] {
+			seen[network] = true
+			unique = append(unique, network)
+		}
+	}
+	return strings.Join(unique, ",")
+}
+
+// scopeZone returns the networks a policy scopes its rules to
+func (p *FirewallaParser) scopeZone(policy FirewallaPolicy) string {
+	return p.targetZone(FirewallaRuleTarget{Networks: policy.Networks, Devices: policy.Devices})
+}
+
+// parseIsolation adds a drop policy from each isolated network to every other local network
+func (p *FirewallaParser) parseIsolation(model *types.NetworkModel) {
+	for _, network := range p.config.Networks {
+		if !network.Isolation {
+			continue
+		}
+
+		var others []string
+		for _, other := range p.config.Networks {
+			if other.ID != network.ID {
+				others = append(others, other.ID)
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+
+		policy := &types.SecurityPolicy{
+			ID:          fmt.Sprintf("network-%s-isolation", network.ID),
+			Source:      p.resolveTarget(FirewallaRuleTarget{Type: "network", Networks: []string{network.ID}}),
+			Destination: p.resolveTarget(FirewallaRuleTarget{Type: "network", Networks: others}),
+			Ports:       []types.Port{},
+			Protocol:    "any",
+			Action:      types.Drop,
+			Zone:        network.ID,
+			Direction:   "in",
+			EgressZone:  strings.Join(others, ","),
+			Description: fmt.Sprintf("%s is isolated from other local networks", network.Name),
+			Enabled:     true,
+		}
+		p.addPolicy(model, policy, FirewallaRuleTarget{Networks: []string{network.ID}})
+	}
+}
+
+// parseVPNs creates a Remote Access Zone segment per VPN server with its clients as assets,
+// a policy exposing the VPN listener and a policy granting clients access to their networks
+func (p *FirewallaParser) parseVPNs(model *types.NetworkModel) {
+	for _, vpn := range p.config.VPNs {
+		if !vpn.Enabled {
+			continue
+		}
+
+		id := fmt.Sprintf("vpn-%s", vpn.ID)
+		name := vpn.Name
+		if name == "" {
+			name = fmt.Sprintf("%s VPN", vpn.Type)
+		}
+
+		segment := &types.NetworkSegment{
+			ID:       id,
+			Name:     name,
+			Zone:     types.RemoteAccessZone,
+			Assets:   []*types.Asset{},
+			Policies: []*types.SecurityPolicy{},
+			Purpose:  "Remote Access",
+		}
+
+		clientIPs := []string{}
+		for _, client := range vpn.Clients {
+			if !client.Enabled {
+				continue
+			}
+
+			ip := client.IP
+			if host, _, err := net.ParseCIDR(ip); err == nil {
+				ip = host.String()
+			}
+			assetID := ip
+			if assetID == "" {
+				assetID = fmt.Sprintf("%s-%s", id, client.ID)
+			}
+
+			asset := &types.Asset{
+				ID:           assetID,
+				IP:           ip,
+				Hostname:     client.Name,
+				DeviceName:   client.Name,
+				IEC62443Zone: types.RemoteAccessZone,
+				Roles:        []string{"VPN Client"},
+				Protocols:    []types.Protocol{},
+				Exposure:     types.InternetExposed,
+			}
+			segment.Assets = append(segment.Assets, asset)
+			model.Assets[assetID] = asset
+			if ip != "" {
+				clientIPs = append(clientIPs, ip)
+			}
+		}
+		model.Networks[id] = segment
+
+		protocol := strings.ToLower(vpn.Protocol)
+		if protocol == "" {
+			protocol = "udp"
+			if vpn.Type == "openvpn" {
+				protocol = "tcp/udp"
+			}
+		}
+		ports := []types.Port{}
+		if vpn.ServerPort > 0 {
+			ports = append(ports, types.Port{
+				Number:   uint16(vpn.ServerPort),
+				Protocol: fmt.Sprintf("%s:%s", protocol, getPortName(vpn.ServerPort)),
+			})
+		}
+
+		server := types.NetworkRange{CIDR: "any", IPs: []string{}}
+		if vpn.ServerIP != "" {
+			server = types.NetworkRange{CIDR: vpn.ServerIP, IPs: []string{vpn.ServerIP}}
+		}
+
+		listener := &types.SecurityPolicy{
+			ID:          id + "-listener",
+			Source:      types.NetworkRange{CIDR: "any", IPs: []string{}},
+			Destination: server,
+			Ports:       ports,
+			Protocol:    types.Protocol(protocol),
+			Action:      types.Allow,
+			Zone:        id,
+			Description: fmt.Sprintf("%s %s server", name, vpn.Type),
+			Enabled:     true,
+			Local:       true, // The VPN server runs on the Firewalla itself
+		}
+
+		destination := types.NetworkRange{CIDR: "any", IPs: []string{}}
+		if len(vpn.Networks) > 0 {
+			destination = p.resolveTarget(FirewallaRuleTarget{Type: "network", Networks: vpn.Networks})
+		}
+		access := &types.SecurityPolicy{
+			ID:          id + "-access",
+			Source:      types.NetworkRange{CIDR: id, IPs: clientIPs},
+			Destination: destination,
+			Ports:       []types.Port{},
+			Protocol:    "any",
+			Action:      types.Allow,
+			Zone:        id,
+			Direction:   "in",
+			Description: fmt.Sprintf("%s clients to local networks", name),
+			Enabled:     true,
+		}
+
+		model.Policies = append(model.Policies, listener, access)
+		segment.Policies = append(segment.Policies, listener, access)
+		for _, networkID := range vpn.Networks {
+			if target, ok := model.Networks[networkID]; ok {
+				target.Policies = append(target.Policies, access)
+			}
+		}
+	}
+}
+
+// parseIDS turns the IDS block list into a drop policy when the IDS blocks
+func (p *FirewallaParser) parseIDS(model *types.NetworkModel) {
+	ids := p.config.IDS
+	if !ids.Enabled || strings.ToLower(ids.Mode) != "block" || len(ids.BlacklistIPs) == 0 {
+		return
+	}
+
+	model.Policies = append(model.Policies, &types.SecurityPolicy{
+		ID:          "ids-blacklist",
+		Source:      types.NetworkRange{CIDR: strings.Join(ids.BlacklistIPs, ","), IPs: append([]string{}, ids.BlacklistIPs...)},
+		Destination: types.NetworkRange{CIDR: "any", IPs: []string{}},
+		Ports:       []types.Port{},
+		Protocol:    "any",
+		Action:      types.Drop,
+		Zone:        "any",
+		Description: "IDS block list",
+		Enabled:     true,
+	})
+}
+
+func (p *FirewallaParser) networkCIDR(id string) string {
+	for _, network := range p.config.Networks {
+		if network.ID == id {
+			return network.CIDR
+		}
+	}
+	return ""
+}
+
+func (p *FirewallaParser) findDevice(id string) *FirewallaDevice {
+	for i := range p.config.Devices {
+		device := &p.config.Devices[i]
+		if device.ID == id || (device.MAC != "" && strings.EqualFold(device.MAC, id)) || device.IP == id {
+			return device
+		}
+	}
+	return nil
+}
+
+func (p *FirewallaParser) findGroup(id string) *FirewallaDeviceGroup {
+	for i := range p.config.Groups {
+		if p.config.Groups[i].ID == id || p.config.Groups[i].Name == id {
+			return &p.config.Groups[i]
+		}
+	}
+	return nil
+}
+
+// parseService converts a rule service into a protocol and the "proto:name" port format
+func parseService(service FirewallaService) (types.Protocol, []types.Port) {
+	ports := []types.Port{}
+	if strings.ToLower(service.Type) == "any" {
+		return "any", ports
+	}
+
+	var protocols []string
+	for _, proto := range service.Protocols {
+		if proto = strings.ToLower(strings.TrimSpace(proto)); proto != "" {
+			protocols = append(protocols, proto)
+		}
+	}
+	protocol := "any"
+	if len(protocols) > 0 {
+		protocol = strings.Join(protocols, "/")
+	}
+
+	for _, spec := range service.Ports {
+		spec = strings.TrimSpace(spec)
+		if low, high, ok := strings.Cut(spec, "-"); ok {
+			start, end := parsePortNumber(low), parsePortNumber(high)
+			if start > 0 && end >= start {
+				ports = append(ports, types.Port{
+					Number:   uint16(start),
+					Protocol: fmt.Sprintf("%s:%d-%d", protocol, start, end),
+				})
+			}
+			continue
+		}
+
+		if num := parsePortNumber(spec); num > 0 {
+			ports = append(ports, types.Port{
+				Number:   uint16(num),
+				Protocol: fmt.Sprintf("%s:%s", protocol, getPortName(num)),
+			})
+		} else if spec != "" {
+			// Predefined service name (e.g. "ssh")
+			ports = append(ports, types.Port{Number: 0, Protocol: fmt.Sprintf("%s:%s", protocol, spec)})
+		}
+	}
+
+	return types.Protocol(protocol), ports
+}
+
+func describeSchedule(schedule FirewallaSchedule) string {
+	switch strings.ToLower(schedule.Type) {
+	case "", "always":
+		return ""
+	}
+
+	parts := []string{"schedule"}
+	if schedule.StartTime != "" || schedule.EndTime != "" {
+		parts = append(parts, fmt.Sprintf("%s-%s", schedule.StartTime, schedule.EndTime))
+	}
+	if len(schedule.Days) > 0 {
+		parts = append(parts, strings.Join(schedule.Days, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+func mapAction(action string) types.RuleAction {
+	switch strings.ToLower(action) {
+	case "allow", "accept":
+		return types.Allow
+	case "block", "drop":
+		return types.Drop // Firewalla blocks silently
+	case "reject":
+		return types.Reject
+	case "monitor", "log":
+		return types.Log
+	default:
+		return types.Deny
+	}
+}
+
+func inferZone(text string) types.IEC62443Zone {
+	lower := strings.ToLower(text)
+
+	switch {
+	case containsWord(lower, "vpn", "remote", "wireguard", "openvpn", "ipsec"):
+		return types.RemoteAccessZone
+	case containsWord(lower, "wan", "dmz", "internet", "guest"):
+		return types.DMZZone
+	case containsWord(lower, "safety", "sis"):
+		return types.SafetyZone
+	case inferPurpose(text) == "Production OT":
+		return types.IndustrialZone
+	default:
+		return types.EnterpriseZone
+	}
+}
+
+func inferPurpose(text string) string {
+	lower := strings.ToLower(text)
+
+	switch {
+	case containsWord(lower, "ot", "ics", "scada", "plc", "hmi", "rtu", "plant", "production", "process",
+		"control", "pump", "station", "telemetry", "field", "automation", "industrial"):
+		return "Production OT"
+	case containsWord(lower, "dmz"):
+		return "DMZ"
+	case containsWord(lower, "guest"):
+		return "Guest"
+	case containsWord(lower, "mgmt", "management", "admin"):
+		return "Management"
+	case containsWord(lower, "wan", "internet"):
+		return "Internet"
+	case containsWord(lower, "corp", "office", "business", "enterprise", "it", "lan"):
+		return "Corporate IT"
+	default:
+		return "General"
+	}
+}
+
+// containsWord reports whether any keyword appears as a word (or word prefix for longer keywords) in s
+func containsWord(s string, keywords ...string) bool {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, word := range words {
+		for _, keyword := range keywords {
+			if word == keyword || (len(keyword) > 3 && strings.HasPrefix(word, keyword)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// assessRisk evaluates risk levels for network segments
+func (p *FirewallaParser) assessRisk(model *types.NetworkModel) {
+	for _, segment := range model.Networks {
+		switch segment.Zone {
+		case types.IndustrialZone, types.SafetyZone:
+			segment.Risk = types.HighRisk
+		case types.DMZZone, types.RemoteAccessZone:
+			segment.Risk = types.MediumRisk
+		default:
+			segment.Risk = types.LowRisk
+		}
+	}
+}
+
+// buildCIDR combines an address with a dotted or prefix-length netmask
+func buildCIDR(ip, netmask string) string {
+	if ip == "" {
+		return ""
+	}
+	if strings.Contains(ip, "/") {
+		return ip
+	}
+	if netmask == "" {
+		return ""
+	}
+	if prefix, err := strconv.Atoi(netmask); err == nil {
+		return fmt.Sprintf("%s/%d", ip, prefix)
+	}
+
+	mask := net.ParseIP(netmask).To4()
+	if mask == nil {
+		return ""
+	}
+	ones, _ := net.IPMask(mask).Size()
+	return fmt.Sprintf("%s/%d", ip, ones)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parsePortNumber converts a string to a port number, returning 0 when invalid
+func parsePortNumber(s string) int {
+	num, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || num < 0 || num > 65535 {
+		return 0
+	}
+	return num
+}
+
+// getPortName returns common service names for well-known ports
+func getPortName(port int) string {
+	wellKnownPorts := map[int]string{
+		22:    "SSH",
+		23:    "Telnet",
+		53:    "DNS",
+		80:    "HTTP",
+		102:   "S7comm",
+		123:   "NTP",
+		161:   "SNMP",
+		443:   "HTTPS",
+		502:   "Modbus",
+		1194:  "OpenVPN",
+		1883:  "MQTT",
+		2222:  "ENIP-IO",
+		3389:  "RDP",
+		4840:  "OPC-UA",
+		8883:  "MQTTS",
+		20000: "DNP3",
+		44818: "ENIP",
+		47808: "BACnet",
+		51820: "WireGuard",
+	}
+
+	if name, exists := wellKnownPorts[port]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("Port-%d", port)
+}
+
+// calculateFileHash computes SHA256 hash of a file for integrity checking
+func calculateFileHash(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,152 @@
+package firewalla
+
+// FirewallaConfig represents the structure of a Firewalla box JSON export.
+// The export is organised in sections:
+// - Network interfaces and VLANs
+// - Known devices and device groups
+// - Security policies and rules
+// - Network monitoring settings
+// - VPN configurations
+// - Intrusion detection settings
+type FirewallaConfig struct {
+	Version    string                    `json:"version"`
+	Timestamp  string                    `json:"timestamp"`
+	Interfaces []FirewallaInterface      `json:"interfaces"`
+	Networks   []FirewallaNetwork        `json:"networks"`
+	Devices    []FirewallaDevice         `json:"devices"`
+	Groups     []FirewallaDeviceGroup    `json:"groups"`
+	Policies   []FirewallaPolicy         `json:"policies"`
+	Rules      []FirewallaRule           `json:"rules"`
+	VPNs       []FirewallaVPN            `json:"vpns"`
+	Monitoring FirewallaMonitoringConfig `json:"monitoring"`
+	IDS        FirewallaIDSConfig        `json:"ids"`
+}
+
+type FirewallaInterface struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"` // ethernet, wifi, vlan
+	MAC         string   `json:"mac"`
+	IP          string   `json:"ip"`
+	Netmask     string   `json:"netmask"`
+	Gateway     string   `json:"gateway"`
+	DNS         []string `json:"dns"`
+	VLAN        int      `json:"vlan,omitempty"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+}
+
+type FirewallaNetwork struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	CIDR        string `json:"cidr"`
+	Interface   string `json:"interface"`
+	Type        string `json:"type"` // lan, guest, iot, etc.
+	Isolation   bool   `json:"isolation"`
+	Description string `json:"description"`
+}
+
+type FirewallaDevice struct {
+	ID      string `json:"id"` // usually the MAC address
+	Name    string `json:"name"`
+	MAC     string `json:"mac"`
+	IP      string `json:"ip"`
+	Network string `json:"network"` // network ID
+	Type    string `json:"type"`    // plc, hmi, computer, phone, etc.
+	Vendor  string `json:"vendor"`
+}
+
+type FirewallaDeviceGroup struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Devices     []string `json:"devices"` // device IDs
+	Description string   `json:"description"`
+}
+
+type FirewallaPolicy struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"` // security, qos, routing
+	Networks    []string          `json:"networks"`
+	Devices     []string          `json:"devices"`
+	Rules       []string          `json:"rules"`
+	Schedule    FirewallaSchedule `json:"schedule"`
+	Enabled     bool              `json:"enabled"`
+	Description string            `json:"description"`
+}
+
+type FirewallaRule struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`      // allow, block, monitor
+	Direction   string              `json:"direction"` // inbound, outbound, both
+	Source      FirewallaRuleTarget `json:"source"`
+	Destination FirewallaRuleTarget `json:"destination"`
+	Service     FirewallaService    `json:"service"`
+	Action      string              `json:"action"`
+	Log         bool                `json:"log"`
+	Schedule    FirewallaSchedule   `json:"schedule"`
+	Priority    int                 `json:"priority"`
+	Enabled     bool                `json:"enabled"`
+	Description string              `json:"description"`
+}
+
+type FirewallaRuleTarget struct {
+	Type     string   `json:"type"` // any, network, device, group
+	Networks []string `json:"networks,omitempty"`
+	Devices  []string `json:"devices,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+type FirewallaService struct {
+	Type      string   `json:"type"` // any, predefined, custom
+	Protocols []string `json:"protocols,omitempty"`
+	Ports     []string `json:"ports,omitempty"`
+}
+
+type FirewallaSchedule struct {
+	Type      string   `json:"type"` // always, time_range, recurring
+	StartTime string   `json:"start_time,omitempty"`
+	EndTime   string   `json:"end_time,omitempty"`
+	Days      []string `json:"days,omitempty"`
+	Timezone  string   `json:"timezone,omitempty"`
+}
+
+type FirewallaVPN struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Type        string               `json:"type"` // wireguard, openvpn, ipsec
+	ServerIP    string               `json:"server_ip"`
+	ServerPort  int                  `json:"server_port"`
+	Protocol    string               `json:"protocol"`
+	Encryption  string               `json:"encryption"`
+	Networks    []string             `json:"networks"`
+	Clients     []FirewallaVPNClient `json:"clients"`
+	Enabled     bool                 `json:"enabled"`
+	Description string               `json:"description"`
+}
+
+type FirewallaVPNClient struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+	IP        string `json:"ip"`
+	Enabled   bool   `json:"enabled"`
+}
+
+type FirewallaMonitoringConfig struct {
+	TrafficAnalysis  bool     `json:"traffic_analysis"`
+	DeviceDiscovery  bool     `json:"device_discovery"`
+	ThreatDetection  bool     `json:"threat_detection"`
+	BandwidthMonitor bool     `json:"bandwidth_monitor"`
+	AlertChannels    []string `json:"alert_channels"`
+}
+
+type FirewallaIDSConfig struct {
+	Enabled      bool     `json:"enabled"`
+	Mode         string   `json:"mode"`        // monitor, block
+	Sensitivity  string   `json:"sensitivity"` // low, medium, high
+	Categories   []string `json:"categories"`
+	CustomRules  []string `json:"custom_rules"`
+	WhitelistIPs []string `json:"whitelist_ips"`
+	BlacklistIPs []string `json:"blacklist_ips"`
+}
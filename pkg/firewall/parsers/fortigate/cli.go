@@ -0,0 +1,228 @@
+package fortigate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// cliNode is a "config" block or an "edit" entry from a FortiOS CLI configuration
+type cliNode struct {
+	Name     string              // Block path ("firewall policy") or edit key ("1", "port1")
+	Settings map[string][]string // "set" options keyed by option name
+	Edits    []*cliNode          // "edit" entries inside a config block
+	Blocks   []*cliNode          // Nested "config" blocks
+}
+
+func newCLINode(name string) *cliNode {
+	return &cliNode{
+		Name:     name,
+		Settings: make(map[string][]string),
+	}
+}
+
+// block returns the direct child config block with the given path
+func (n *cliNode) block(path string) *cliNode {
+	for _, b := range n.Blocks {
+		if b.Name == path {
+			return b
+		}
+	}
+	return nil
+}
+
+// get returns the first value of a "set" option, or def when it is not present
+func (n *cliNode) get(key, def string) string {
+	if values, ok := n.Settings[key]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return def
+}
+
+// cliDocument is a parsed FortiOS configuration file
+type cliDocument struct {
+	Root    *cliNode
+	Headers map[string]string // "#key=value" header lines such as config-version
+}
+
+// scopes returns the nodes that hold top-level config blocks: the root itself,
+// the "config global" block and every VDOM on multi-VDOM devices
+func (d *cliDocument) scopes() []*cliNode {
+	scopes := []*cliNode{d.Root}
+	if global := d.Root.block("global"); global != nil {
+		scopes = append(scopes, global)
+	}
+	for _, b := range d.Root.Blocks {
+		if b.Name == "vdom" {
+			scopes = append(scopes, b.Edits...)
+		}
+	}
+	return scopes
+}
+
+// sections returns every config block with the given path across all scopes
+func (d *cliDocument) sections(path string) []*cliNode {
+	var result []*cliNode
+	for _, scope := range d.scopes() {
+		for _, b := range scope.Blocks {
+			if b.Name == path {
+				result = append(result, b)
+			}
+		}
+	}
+	return result
+}
+
+// readCLI parses the FortiOS "config / edit / set / next / end" format
+func readCLI(r io.Reader) (*cliDocument, error) {
+	doc := &cliDocument{
+		Root:    newCLINode(""),
+		Headers: make(map[string]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Certificates and scripts produce very long lines
+
+	stack := []*cliNode{doc.Root}
+	isEdit := []bool{false}
+	lineNum := 0
+	pending := ""
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		// Quoted values (certificates, replacement messages) may span lines
+		if pending != "" {
+			line = pending + "\n" + line
+			pending = ""
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			if kv := strings.SplitN(strings.TrimPrefix(trimmed, "#"), "=", 2); len(kv) == 2 {
+				doc.Headers[kv[0]] = kv[1]
+			}
+			continue
+		}
+
+		tokens, complete := tokenizeCLILine(trimmed)
+		if !complete {
+			pending = line
+			continue
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		current := stack[len(stack)-1]
+
+		switch tokens[0] {
+		case "config":
+			if len(tokens) < 2 {
+				return nil, fmt.Errorf("line %d: config statement without a path", lineNum)
+			}
+			block := newCLINode(strings.Join(tokens[1:], " "))
+			current.Blocks = append(current.Blocks, block)
+			stack = append(stack, block)
+			isEdit = append(isEdit, false)
+		case "edit":
+			if len(tokens) < 2 {
+				return nil, fmt.Errorf("line %d: edit statement without a name", lineNum)
+			}
+			if isEdit[len(isEdit)-1] {
+				return nil, fmt.Errorf("line %d: edit %q inside another edit", lineNum, tokens[1])
+			}
+			entry := newCLINode(tokens[1])
+			current.Edits = append(current.Edits, entry)
+			stack = append(stack, entry)
+			isEdit = append(isEdit, true)
+		case "set":
+			if len(tokens) >= 2 {
+				current.Settings[tokens[1]] = tokens[2:]
+			}
+		case "append":
+			if len(tokens) >= 2 {
+				current.Settings[tokens[1]] = append(current.Settings[tokens[1]], tokens[2:]...)
+			}
+		case "unset":
+			if len(tokens) >= 2 {
+				delete(current.Settings, tokens[1])
+			}
+		case "next":
+			if !isEdit[len(isEdit)-1] {
+				return nil, fmt.Errorf("line %d: next outside of an edit entry", lineNum)
+			}
+			stack = stack[:len(stack)-1]
+			isEdit = isEdit[:len(isEdit)-1]
+		case "end":
+			// Tolerate a missing "next" before "end"
+			if isEdit[len(isEdit)-1] {
+				stack = stack[:len(stack)-1]
+				isEdit = isEdit[:len(isEdit)-1]
+			}
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("line %d: end without matching config", lineNum)
+			}
+			stack = stack[:len(stack)-1]
+			isEdit = isEdit[:len(isEdit)-1]
+		default:
+			// Ignore statements we don't model (e.g. "rename", "move")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read config: %v", err)
+	}
+	if pending != "" {
+		return nil, fmt.Errorf("unterminated quoted value at end of file")
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("unterminated config block %q", stack[len(stack)-1].Name)
+	}
+
+	return doc, nil
+}
+
+// tokenizeCLILine splits a CLI statement into words, honouring double quotes and
+// backslash escapes. complete is false when a quoted value continues on the next line.
+func tokenizeCLILine(line string) (tokens []string, complete bool) {
+	var current strings.Builder
+	inQuotes := false
+	hasToken := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+			hasToken = true
+		case c == '"':
+			inQuotes = !inQuotes
+			hasToken = true
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if hasToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				hasToken = false
+			}
+		default:
+			current.WriteByte(c)
+			hasToken = true
+		}
+	}
+
+	if inQuotes {
+		return nil, false
+	}
+	if hasToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, true
+}
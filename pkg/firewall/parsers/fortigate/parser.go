@@ -1,9 +1,20 @@
 package fortigate
 
 import (
-	"cipgram/pkg/types"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"cipgram/pkg/analysis"
+	"cipgram/pkg/types"
 )
 
 // FortiGateParser implements FirewallParser for FortiGate configurations
@@ -21,9 +32,31 @@ func NewFortiGateParser(configPath string) *FortiGateParser {
 
 // Parse implements FirewallParser.Parse for FortiGate configs
 func (p *FortiGateParser) Parse() (*types.NetworkModel, error) {
-	// TODO: Implement FortiGate configuration parsing
-	// FortiGate uses its own CLI-based configuration format
-	return nil, fmt.Errorf("FortiGate parser not yet implemented")
+	if err := p.loadConfig(); err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	model := &types.NetworkModel{
+		Assets:   make(map[string]*types.Asset),
+		Networks: make(map[string]*types.NetworkSegment),
+		Flows:    make(map[types.FlowKey]*types.Flow),
+		Policies: []*types.SecurityPolicy{},
+		Metadata: p.GetMetadata(),
+	}
+
+	// Interfaces become network segments
+	p.parseInterfaces(model)
+
+	// Firewall policies become security policies with addresses and services resolved
+	p.parsePolicies(model)
+
+	if len(p.config.Routes) > 0 {
+		log.Printf("FortiGate %s: %d static routes", p.config.Hostname, len(p.config.Routes))
+	}
+
+	p.assessRisk(model)
+
+	return model, nil
 }
 
 // GetType implements FirewallParser.GetType
@@ -33,69 +66,755 @@ func (p *FortiGateParser) GetType() types.InputType {
 
 // GetMetadata implements FirewallParser.GetMetadata
 func (p *FortiGateParser) GetMetadata() types.InputMetadata {
+	info, _ := os.Stat(p.configPath)
+	size := int64(0)
+	modTime := time.Now()
+
+	if info != nil {
+		size = info.Size()
+		modTime = info.ModTime()
+	}
+
 	return types.InputMetadata{
 		Source:    p.configPath,
 		Type:      types.InputTypeFortiGate,
-		Timestamp: time.Now(),
-		Size:      0, // TODO: Get actual file size
+		Timestamp: modTime,
+		Size:      size,
+		Hash:      calculateFileHash(p.configPath),
 	}
 }
 
 // Validate implements FirewallParser.Validate
 func (p *FortiGateParser) Validate() error {
-	// TODO: Implement FortiGate config validation
-	return fmt.Errorf("FortiGate validation not yet implemented")
-}
-
-// FortiGateConfig represents the structure of a FortiGate configuration
-// TODO: Define actual FortiGate configuration structures
-type FortiGateConfig struct {
-	// FortiGate configurations are typically CLI-based with sections like:
-	// config system interface
-	// config firewall policy
-	// config router static
-	// etc.
-
-	// Placeholder structures for future implementation
-	Version    string
-	Hostname   string
-	Interfaces []FortiGateInterface
-	Policies   []FortiGatePolicy
-	Routes     []FortiGateRoute
-}
-
-type FortiGateInterface struct {
-	Name        string
-	Type        string
-	IP          string
-	Netmask     string
-	Zone        string
-	Description string
-	Status      string
-}
-
-type FortiGatePolicy struct {
-	ID       int
-	Name     string
-	SrcIntf  []string
-	DstIntf  []string
-	SrcAddr  []string
-	DstAddr  []string
-	Service  []string
-	Action   string
-	Status   string
-	Comments string
-}
-
-type FortiGateRoute struct {
-	Destination string
-	Gateway     string
-	Interface   string
-	Distance    int
-}
-
-// TODO: Implement parsing functions for FortiGate CLI format
-// parseInterfaces() error
-// parsePolicies() error
-// parseRoutes() error
-// parseZones() error
+	if _, err := os.Stat(p.configPath); os.IsNotExist(err) {
+		return fmt.Errorf("config file not found: %s", p.configPath)
+	}
+
+	if err := p.loadConfig(); err != nil {
+		return fmt.Errorf("invalid FortiGate configuration: %v", err)
+	}
+
+	if len(p.config.Interfaces) == 0 && len(p.config.Policies) == 0 {
+		return fmt.Errorf("invalid FortiGate configuration: no system interfaces or firewall policies found")
+	}
+
+	return nil
+}
+
+// GetConfig returns the parsed FortiGate configuration (nil until Parse or Validate succeeds)
+func (p *FortiGateParser) GetConfig() *FortiGateConfig {
+	return p.config
+}
+
+// loadConfig reads the FortiOS CLI backup and extracts the sections we model
+func (p *FortiGateParser) loadConfig() error {
+	file, err := os.Open(p.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot open config file: %v", err)
+	}
+	defer file.Close()
+
+	doc, err := readCLI(file)
+	if err != nil {
+		return fmt.Errorf("cannot parse FortiOS config: %v", err)
+	}
+
+	config := &FortiGateConfig{
+		Addresses:     make(map[string]*FortiGateAddress),
+		AddressGroups: make(map[string]*FortiGateAddressGroup),
+		Services:      make(map[string]*FortiGateService),
+		ServiceGroups: make(map[string]*FortiGateServiceGroup),
+	}
+
+	// Header looks like "#config-version=FGT60F-7.2.5-FW-build1517-230606:opmode=0:vdom=0:user=admin"
+	if header, ok := doc.Headers["config-version"]; ok {
+		fields := strings.Split(strings.SplitN(header, ":", 2)[0], "-")
+		config.Model = fields[0]
+		if len(fields) > 1 {
+			config.Version = fields[1]
+		}
+	}
+
+	for _, section := range doc.sections("system global") {
+		if hostname := section.get("hostname", ""); hostname != "" {
+			config.Hostname = hostname
+		}
+	}
+
+	extractZones(doc, config)
+	extractInterfaces(doc, config)
+	extractAddresses(doc, config)
+	extractServices(doc, config)
+	extractPolicies(doc, config)
+	extractRoutes(doc, config)
+
+	p.config = config
+	return nil
+}
+
+func extractZones(doc *cliDocument, config *FortiGateConfig) {
+	for _, section := range doc.sections("system zone") {
+		for _, entry := range section.Edits {
+			config.Zones = append(config.Zones, FortiGateZone{
+				Name:       entry.Name,
+				Interfaces: entry.Settings["interface"],
+			})
+		}
+	}
+}
+
+func extractInterfaces(doc *cliDocument, config *FortiGateConfig) {
+	zoneOf := make(map[string]string)
+	for _, zone := range config.Zones {
+		for _, member := range zone.Interfaces {
+			zoneOf[member] = zone.Name
+		}
+	}
+
+	for _, section := range doc.sections("system interface") {
+		for _, entry := range section.Edits {
+			iface := FortiGateInterface{
+				Name:        entry.Name,
+				Type:        entry.get("type", "physical"),
+				Zone:        zoneOf[entry.Name],
+				Description: entry.get("description", ""),
+				Alias:       entry.get("alias", ""),
+				Role:        entry.get("role", ""),
+				Mode:        entry.get("mode", "static"),
+				Parent:      entry.get("interface", ""),
+				Status:      entry.get("status", "up"),
+			}
+
+			// "set ip" is either "<addr> <mask>" or "<addr>/<prefix>"
+			if ip := entry.Settings["ip"]; len(ip) > 0 {
+				if addr, mask, ok := splitAddressMask(ip); ok {
+					iface.IP = addr
+					iface.Netmask = mask
+				}
+			}
+
+			if vlan, err := strconv.Atoi(entry.get("vlanid", "")); err == nil {
+				iface.VLANID = vlan
+			}
+
+			config.Interfaces = append(config.Interfaces, iface)
+		}
+	}
+}
+
+func extractAddresses(doc *cliDocument, config *FortiGateConfig) {
+	for _, path := range []string{"firewall address", "firewall address6"} {
+		for _, section := range doc.sections(path) {
+			for _, entry := range section.Edits {
+				addr := &FortiGateAddress{
+					Name:      entry.Name,
+					Type:      entry.get("type", "ipmask"),
+					StartIP:   entry.get("start-ip", ""),
+					EndIP:     entry.get("end-ip", ""),
+					FQDN:      entry.get("fqdn", ""),
+					Country:   entry.get("country", ""),
+					Interface: entry.get("interface", ""),
+					Comment:   entry.get("comment", ""),
+				}
+
+				if subnet := entry.Settings["subnet"]; len(subnet) > 0 {
+					if ip, mask, ok := splitAddressMask(subnet); ok {
+						addr.Subnet = toCIDR(ip, mask)
+					}
+				} else if ip6 := entry.get("ip6", ""); ip6 != "" {
+					addr.Subnet = ip6
+				} else if addr.Type == "ipmask" {
+					addr.Subnet = "0.0.0.0/0" // FortiOS default for a bare address object
+				}
+
+				config.Addresses[addr.Name] = addr
+			}
+		}
+	}
+
+	for _, path := range []string{"firewall addrgrp", "firewall addrgrp6"} {
+		for _, section := range doc.sections(path) {
+			for _, entry := range section.Edits {
+				config.AddressGroups[entry.Name] = &FortiGateAddressGroup{
+					Name:    entry.Name,
+					Members: entry.Settings["member"],
+					Comment: entry.get("comment", ""),
+				}
+			}
+		}
+	}
+}
+
+func extractServices(doc *cliDocument, config *FortiGateConfig) {
+	for _, section := range doc.sections("firewall service custom") {
+		for _, entry := range section.Edits {
+			svc := &FortiGateService{
+				Name:           entry.Name,
+				Protocol:       strings.ToUpper(entry.get("protocol", "TCP/UDP/SCTP")),
+				TCPPortRanges:  parsePortRanges(entry.Settings["tcp-portrange"]),
+				UDPPortRanges:  parsePortRanges(entry.Settings["udp-portrange"]),
+				SCTPPortRanges: parsePortRanges(entry.Settings["sctp-portrange"]),
+				Comment:        entry.get("comment", ""),
+			}
+			if num, err := strconv.Atoi(entry.get("protocol-number", "")); err == nil {
+				svc.ProtocolNumber = num
+			}
+			config.Services[svc.Name] = svc
+		}
+	}
+
+	for _, section := range doc.sections("firewall service group") {
+		for _, entry := range section.Edits {
+			config.ServiceGroups[entry.Name] = &FortiGateServiceGroup{
+				Name:    entry.Name,
+				Members: entry.Settings["member"],
+				Comment: entry.get("comment", ""),
+			}
+		}
+	}
+}
+
+func extractPolicies(doc *cliDocument, config *FortiGateConfig) {
+	for _, section := range doc.sections("firewall policy") {
+		for _, entry := range section.Edits {
+			id, _ := strconv.Atoi(entry.Name)
+
+			config.Policies = append(config.Policies, FortiGatePolicy{
+				ID:       id,
+				Name:     entry.get("name", ""),
+				UUID:     entry.get("uuid", ""),
+				SrcIntf:  entry.Settings["srcintf"],
+				DstIntf:  entry.Settings["dstintf"],
+				SrcAddr:  append(append([]string{}, entry.Settings["srcaddr"]...), entry.Settings["srcaddr6"]...),
+				DstAddr:  append(append([]string{}, entry.Settings["dstaddr"]...), entry.Settings["dstaddr6"]...),
+				Service:  entry.Settings["service"],
+				Action:   entry.get("action", "deny"), // FortiOS default action is deny
+				Status:   entry.get("status", "enable"),
+				Schedule: entry.get("schedule", ""),
+				NAT:      entry.get("nat", "disable") == "enable",
+				Comments: entry.get("comments", ""),
+			})
+		}
+	}
+}
+
+func extractRoutes(doc *cliDocument, config *FortiGateConfig) {
+	for _, section := range doc.sections("router static") {
+		for _, entry := range section.Edits {
+			route := FortiGateRoute{
+				Destination: "0.0.0.0/0",
+				Gateway:     entry.get("gateway", ""),
+				Interface:   entry.get("device", ""),
+				Distance:    10, // FortiOS default administrative distance
+				Blackhole:   entry.get("blackhole", "disable") == "enable",
+				Status:      entry.get("status", "enable"),
+			}
+
+			if dst := entry.Settings["dst"]; len(dst) > 0 {
+				if ip, mask, ok := splitAddressMask(dst); ok {
+					route.Destination = toCIDR(ip, mask)
+				}
+			}
+			if distance, err := strconv.Atoi(entry.get("distance", "")); err == nil {
+				route.Distance = distance
+			}
+
+			config.Routes = append(config.Routes, route)
+		}
+	}
+}
+
+// parseInterfaces creates network segments from addressed, administratively up interfaces.
+// DHCP and PPPoE interfaces get their address at runtime and become segments without a CIDR.
+func (p *FortiGateParser) parseInterfaces(model *types.NetworkModel) {
+	for _, iface := range p.config.Interfaces {
+		if iface.Status == "down" {
+			continue
+		}
+		cidr := ""
+		if iface.IP != "" && iface.IP != "0.0.0.0" {
+			cidr = toCIDR(iface.IP, iface.Netmask)
+		} else if iface.Mode != "dhcp" && iface.Mode != "pppoe" {
+			continue
+		}
+
+		name := iface.Alias
+		if name == "" {
+			name = iface.Description
+		}
+		if name == "" {
+			name = iface.Name
+		}
+
+		segment := &types.NetworkSegment{
+			ID:       iface.Name,
+			Name:     name,
+			CIDR:     cidr,
+			Assets:   []*types.Asset{},
+			Policies: []*types.SecurityPolicy{},
+			Purpose:  inferPurpose(iface),
+			Zone:     inferZone(iface),
+		}
+
+		model.Networks[segment.ID] = segment
+	}
+}
+
+// parsePolicies creates security policies from firewall policies, keeping their order
+func (p *FortiGateParser) parsePolicies(model *types.NetworkModel) {
+	for _, fp := range p.config.Policies {
+		description := fp.Name
+		if description == "" {
+			description = fp.Comments
+		} else if fp.Comments != "" {
+			description = fmt.Sprintf("%s - %s", fp.Name, fp.Comments)
+		}
+
+		protocol, ports := p.resolveServices(fp.Service)
+		srcIntf := p.expandInterfaces(fp.SrcIntf)
+
+		// Policies match traffic entering through srcintf and leaving through dstintf
+		policy := &types.SecurityPolicy{
+			ID:          fmt.Sprintf("policy-%d", fp.ID),
+			Source:      p.resolveAddresses(fp.SrcAddr),
+			Destination: p.resolveAddresses(fp.DstAddr),
+			Ports:       ports,
+			Protocol:    protocol,
+			Action:      mapAction(fp.Action),
+			Zone:        strings.Join(srcIntf, ","),
+			Direction:   "in",
+			EgressZone:  strings.Join(p.expandInterfaces(fp.DstIntf), ","),
+			Description: description,
+			Enabled:     fp.Status != "disable",
+		}
+
+		model.Policies = append(model.Policies, policy)
+
+		// Attach the policy to the segments it applies to
+		for _, intf := range srcIntf {
+			if segment, ok := model.Networks[intf]; ok {
+				segment.Policies = append(segment.Policies, policy)
+			}
+		}
+	}
+
+	// FortiOS ends every policy list with implicit deny policy 0
+	model.Policies = append(model.Policies, &types.SecurityPolicy{
+		ID:          analysis.ImplicitDenyRuleID,
+		Description: "Implicit deny (built-in FortiGate policy 0)",
+		Enabled:     true,
+		Action:      types.Deny,
+		Zone:        "all",
+		Source: types.NetworkRange{
+			CIDR: "any",
+			IPs:  []string{},
+		},
+		Destination: types.NetworkRange{
+			CIDR: "any",
+			IPs:  []string{},
+		},
+		Protocol: "any",
+		Ports:    []types.Port{},
+	})
+}
+
+// expandInterfaces replaces FortiGate zone names with their member interfaces
+func (p *FortiGateParser) expandInterfaces(names []string) []string {
+	var result []string
+	for _, name := range names {
+		expanded := false
+		for _, zone := range p.config.Zones {
+			if zone.Name == name {
+				result = append(result, zone.Interfaces...)
+				expanded = true
+			}
+		}
+		if !expanded {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// resolveAddresses flattens address objects and groups into a network range.
+// A single subnet object resolves to its CIDR; anything else keeps the object
+// names in CIDR and lists the resolved members in IPs.
+func (p *FortiGateParser) resolveAddresses(names []string) types.NetworkRange {
+	var values []string
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		if isAnyAddress(name) {
+			return types.NetworkRange{CIDR: "any", IPs: []string{}}
+		}
+		values = append(values, p.expandAddress(name, seen)...)
+	}
+
+	if len(values) == 0 {
+		return types.NetworkRange{CIDR: "any", IPs: []string{}}
+	}
+
+	if len(names) == 1 && len(values) == 1 {
+		if _, _, err := net.ParseCIDR(values[0]); err == nil {
+			if values[0] == "0.0.0.0/0" || values[0] == "::/0" {
+				return types.NetworkRange{CIDR: "any", IPs: []string{}}
+			}
+			return types.NetworkRange{CIDR: values[0], IPs: values}
+		}
+	}
+
+	return types.NetworkRange{
+		CIDR: strings.Join(names, ","),
+		IPs:  values,
+	}
+}
+
+// expandAddress resolves an address or address group into concrete values
+func (p *FortiGateParser) expandAddress(name string, seen map[string]bool) []string {
+	if seen[name] {
+		return nil // Guard against group loops
+	}
+	seen[name] = true
+
+	if group, ok := p.config.AddressGroups[name]; ok {
+		var values []string
+		for _, member := range group.Members {
+			values = append(values, p.expandAddress(member, seen)...)
+		}
+		return values
+	}
+
+	addr, ok := p.config.Addresses[name]
+	if !ok {
+		return []string{name} // Unknown object, keep the reference
+	}
+
+	switch addr.Type {
+	case "iprange":
+		return []string{fmt.Sprintf("%s-%s", addr.StartIP, addr.EndIP)}
+	case "fqdn":
+		return []string{addr.FQDN}
+	case "geography":
+		return []string{"geo:" + addr.Country}
+	case "ipmask", "interface-subnet", "":
+		if addr.Subnet != "" {
+			return []string{addr.Subnet}
+		}
+	}
+
+	return []string{name}
+}
+
+// resolveServices flattens services and service groups into a protocol and port list
+func (p *FortiGateParser) resolveServices(names []string) (types.Protocol, []types.Port) {
+	protocols := make(map[string]bool)
+	ports := []types.Port{}
+	anyProtocol := false
+	seen := make(map[string]bool)
+
+	var visit func(name string)
+	visit = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+
+		if group, ok := p.config.ServiceGroups[name]; ok {
+			for _, member := range group.Members {
+				visit(member)
+			}
+			return
+		}
+
+		svc, ok := p.config.Services[name]
+		if !ok {
+			svc, ok = predefinedServices[name]
+		}
+		if !ok {
+			// Unknown service: keep the name for display like an unresolved alias
+			ports = append(ports, types.Port{Number: 0, Protocol: fmt.Sprintf("any:%s", name)})
+			return
+		}
+
+		switch svc.Protocol {
+		case "ALL":
+			anyProtocol = true
+		case "IP":
+			if svc.ProtocolNumber == 0 {
+				anyProtocol = true
+			} else {
+				protocols[ipProtocolName(svc.ProtocolNumber)] = true
+			}
+		case "ICMP":
+			protocols["icmp"] = true
+		case "ICMP6":
+			protocols["ipv6-icmp"] = true
+		default:
+			for _, pr := range []struct {
+				proto  string
+				ranges []FortiGatePortRange
+			}{
+				{"tcp", svc.TCPPortRanges},
+				{"udp", svc.UDPPortRanges},
+				{"sctp", svc.SCTPPortRanges},
+			} {
+				if len(pr.ranges) == 0 {
+					continue
+				}
+				protocols[pr.proto] = true
+				for _, r := range pr.ranges {
+					ports = append(ports, portFromRange(pr.proto, svc.Name, r))
+				}
+			}
+		}
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+
+	sort.SliceStable(ports, func(i, j int) bool {
+		return ports[i].Number < ports[j].Number
+	})
+
+	if anyProtocol {
+		return "any", filterNamed(ports)
+	}
+	if len(protocols) == 0 {
+		return "any", ports
+	}
+
+	var list []string
+	for proto := range protocols {
+		list = append(list, proto)
+	}
+	sort.Strings(list)
+	return types.Protocol(strings.Join(list, "/")), ports
+}
+
+// portFromRange converts a service port range to the "proto:name" port format
+// used by the other firewall parsers
+func portFromRange(proto, serviceName string, r FortiGatePortRange) types.Port {
+	if r.Low == r.High {
+		return types.Port{Number: r.Low, Protocol: fmt.Sprintf("%s:%s", proto, serviceName)}
+	}
+	return types.Port{Number: r.Low, Protocol: fmt.Sprintf("%s:%d-%d", proto, r.Low, r.High)}
+}
+
+// filterNamed keeps only unresolved service references when the policy matches any protocol
+func filterNamed(ports []types.Port) []types.Port {
+	result := []types.Port{}
+	for _, port := range ports {
+		if port.Number == 0 {
+			result = append(result, port)
+		}
+	}
+	return result
+}
+
+// parsePortRanges parses "*-portrange" values such as "80", "8000-8080" or "502:1024-65535"
+// (destination[:source]); only destination ranges are kept
+func parsePortRanges(values []string) []FortiGatePortRange {
+	var ranges []FortiGatePortRange
+	for _, value := range values {
+		dst := strings.SplitN(value, ":", 2)[0]
+		bounds := strings.SplitN(dst, "-", 2)
+
+		low, err := strconv.ParseUint(bounds[0], 10, 16)
+		if err != nil {
+			continue
+		}
+		high := low
+		if len(bounds) == 2 {
+			if h, err := strconv.ParseUint(bounds[1], 10, 16); err == nil && h >= low {
+				high = h
+			}
+		}
+
+		ranges = append(ranges, FortiGatePortRange{Low: uint16(low), High: uint16(high)})
+	}
+	return ranges
+}
+
+// predefinedServices covers the FortiOS built-in services that backups may reference
+// without redefining them under "config firewall service custom"
+var predefinedServices = map[string]*FortiGateService{
+	"ALL":      {Name: "ALL", Protocol: "ALL"},
+	"ALL_TCP":  {Name: "ALL_TCP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{1, 65535}}},
+	"ALL_UDP":  {Name: "ALL_UDP", Protocol: "TCP/UDP/SCTP", UDPPortRanges: []FortiGatePortRange{{1, 65535}}},
+	"ALL_ICMP": {Name: "ALL_ICMP", Protocol: "ICMP"},
+	"PING":     {Name: "PING", Protocol: "ICMP"},
+	"HTTP":     {Name: "HTTP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{80, 80}}},
+	"HTTPS":    {Name: "HTTPS", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{443, 443}}},
+	"SSH":      {Name: "SSH", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{22, 22}}},
+	"TELNET":   {Name: "TELNET", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{23, 23}}},
+	"FTP":      {Name: "FTP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{21, 21}}},
+	"SMTP":     {Name: "SMTP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{25, 25}}},
+	"DNS":      {Name: "DNS", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{53, 53}}, UDPPortRanges: []FortiGatePortRange{{53, 53}}},
+	"NTP":      {Name: "NTP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{123, 123}}, UDPPortRanges: []FortiGatePortRange{{123, 123}}},
+	"SNMP":     {Name: "SNMP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{161, 162}}, UDPPortRanges: []FortiGatePortRange{{161, 162}}},
+	"SYSLOG":   {Name: "SYSLOG", Protocol: "TCP/UDP/SCTP", UDPPortRanges: []FortiGatePortRange{{514, 514}}},
+	"LDAP":     {Name: "LDAP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{389, 389}}},
+	"SAMBA":    {Name: "SAMBA", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{139, 139}}},
+	"SMB":      {Name: "SMB", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{445, 445}}},
+	"RDP":      {Name: "RDP", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{3389, 3389}}},
+	"VNC":      {Name: "VNC", Protocol: "TCP/UDP/SCTP", TCPPortRanges: []FortiGatePortRange{{5900, 5900}}},
+	"DHCP":     {Name: "DHCP", Protocol: "TCP/UDP/SCTP", UDPPortRanges: []FortiGatePortRange{{67, 68}}},
+	"TFTP":     {Name: "TFTP", Protocol: "TCP/UDP/SCTP", UDPPortRanges: []FortiGatePortRange{{69, 69}}},
+}
+
+func ipProtocolName(number int) string {
+	switch number {
+	case 1:
+		return "icmp"
+	case 6:
+		return "tcp"
+	case 17:
+		return "udp"
+	case 47:
+		return "gre"
+	case 50:
+		return "esp"
+	case 58:
+		return "ipv6-icmp"
+	case 89:
+		return "ospf"
+	default:
+		return fmt.Sprintf("ip-%d", number)
+	}
+}
+
+func isAnyAddress(name string) bool {
+	switch strings.ToLower(name) {
+	case "all", "all6", "any", "any6":
+		return true
+	}
+	return false
+}
+
+func mapAction(action string) types.RuleAction {
+	switch strings.ToLower(action) {
+	case "accept", "ipsec":
+		return types.Allow
+	default:
+		return types.Deny
+	}
+}
+
+// splitAddressMask accepts "<addr> <mask>" or "<addr>/<prefix>" token lists
+func splitAddressMask(tokens []string) (addr, mask string, ok bool) {
+	if len(tokens) >= 2 {
+		return tokens[0], tokens[1], true
+	}
+	if len(tokens) == 1 {
+		if parts := strings.SplitN(tokens[0], "/", 2); len(parts) == 2 {
+			return parts[0], parts[1], true
+		}
+	}
+	return "", "", false
+}
+
+// toCIDR converts an address with a dotted or prefix-length mask into "addr/prefix"
+func toCIDR(addr, mask string) string {
+	if mask == "" {
+		return addr
+	}
+	if _, err := strconv.Atoi(mask); err == nil {
+		return fmt.Sprintf("%s/%s", addr, mask)
+	}
+
+	ip := net.ParseIP(mask).To4()
+	if ip == nil {
+		return addr
+	}
+	ones, bits := net.IPMask(ip).Size()
+	if bits == 0 {
+		return addr // Non-contiguous (wildcard) mask
+	}
+	return fmt.Sprintf("%s/%d", addr, ones)
+}
+
+func inferPurpose(iface FortiGateInterface) string {
+	lower := strings.ToLower(strings.Join([]string{iface.Name, iface.Alias, iface.Description, iface.Zone}, " "))
+
+	switch {
+	case containsAny(lower, "ot", "ics", "scada", "plc", "hmi", "plant", "production", "process",
+		"control", "cell", "line", "field", "automation", "industrial", "manufacturing"):
+		return "Production OT"
+	case containsAny(lower, "dmz") || iface.Role == "dmz":
+		return "DMZ"
+	case containsAny(lower, "mgmt", "management", "admin"):
+		return "Management"
+	case iface.Role == "wan" || containsAny(lower, "wan", "internet", "isp"):
+		return "Internet"
+	case containsAny(lower, "corp", "office", "business", "enterprise", "it"):
+		return "Corporate IT"
+	default:
+		return "General"
+	}
+}
+
+func inferZone(iface FortiGateInterface) types.IEC62443Zone {
+	lower := strings.ToLower(strings.Join([]string{iface.Name, iface.Alias, iface.Description, iface.Zone}, " "))
+
+	switch {
+	case iface.Type == "tunnel" || strings.HasPrefix(iface.Name, "ssl.") ||
+		containsAny(lower, "vpn", "remote", "wireguard", "ipsec"):
+		return types.RemoteAccessZone
+	case iface.Role == "wan" || iface.Role == "dmz" || containsAny(lower, "wan", "dmz", "internet"):
+		return types.DMZZone // WAN typically goes to DMZ, matching the OPNsense mapping
+	case containsAny(lower, "safety", "sis"):
+		return types.SafetyZone
+	case inferPurpose(iface) == "Production OT":
+		return types.IndustrialZone
+	default:
+		return types.EnterpriseZone
+	}
+}
+
+// containsAny reports whether any keyword appears as a whole word in s
+func containsAny(s string, keywords ...string) bool {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, word := range words {
+		for _, keyword := range keywords {
+			if word == keyword || (len(keyword) > 3 && strings.HasPrefix(word, keyword)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// assessRisk evaluates risk levels for network segments
+func (p *FortiGateParser) assessRisk(model *types.NetworkModel) {
+	for _, segment := range model.Networks {
+		switch segment.Zone {
+		case types.IndustrialZone, types.SafetyZone:
+			segment.Risk = types.HighRisk
+		case types.DMZZone, types.RemoteAccessZone:
+			segment.Risk = types.MediumRisk
+		default:
+			segment.Risk = types.LowRisk
+		}
+	}
+}
+
+// calculateFileHash computes SHA256 hash of a file for integrity checking
+func calculateFileHash(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
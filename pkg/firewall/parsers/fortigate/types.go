@@ -0,0 +1,109 @@
+package fortigate
+
+// FortiGateConfig represents the structure of a FortiGate configuration
+type FortiGateConfig struct {
+	Version       string // FortiOS version from the #config-version header (e.g. "7.2.5")
+	Model         string // Hardware model from the #config-version header (e.g. "FGT60F")
+	Hostname      string
+	Interfaces    []FortiGateInterface
+	Zones         []FortiGateZone
+	Addresses     map[string]*FortiGateAddress
+	AddressGroups map[string]*FortiGateAddressGroup
+	Services      map[string]*FortiGateService
+	ServiceGroups map[string]*FortiGateServiceGroup
+	Policies      []FortiGatePolicy
+	Routes        []FortiGateRoute
+}
+
+// FortiGateInterface represents an entry in "config system interface"
+type FortiGateInterface struct {
+	Name        string
+	Type        string // physical, vlan, aggregate, tunnel, loopback, etc.
+	IP          string
+	Netmask     string
+	Mode        string // Addressing mode: static, dhcp or pppoe
+	Zone        string // Name of the "config system zone" entry that contains this interface
+	Description string
+	Alias       string
+	Role        string // lan, wan, dmz, undefined
+	VLANID      int
+	Parent      string // Parent interface for VLAN sub-interfaces
+	Status      string
+}
+
+// FortiGateZone represents an entry in "config system zone"
+type FortiGateZone struct {
+	Name       string
+	Interfaces []string
+}
+
+// FortiGateAddress represents an entry in "config firewall address" or "config firewall address6"
+type FortiGateAddress struct {
+	Name      string
+	Type      string // ipmask, iprange, fqdn, geography, wildcard, interface-subnet, etc.
+	Subnet    string // CIDR notation for ipmask and interface-subnet addresses
+	StartIP   string
+	EndIP     string
+	FQDN      string
+	Country   string
+	Interface string
+	Comment   string
+}
+
+// FortiGateAddressGroup represents an entry in "config firewall addrgrp" or "config firewall addrgrp6"
+type FortiGateAddressGroup struct {
+	Name    string
+	Members []string
+	Comment string
+}
+
+// FortiGateService represents an entry in "config firewall service custom"
+type FortiGateService struct {
+	Name           string
+	Protocol       string // TCP/UDP/SCTP, ICMP, ICMP6, IP, ALL
+	ProtocolNumber int
+	TCPPortRanges  []FortiGatePortRange
+	UDPPortRanges  []FortiGatePortRange
+	SCTPPortRanges []FortiGatePortRange
+	Comment        string
+}
+
+// FortiGatePortRange represents a destination port range from a *-portrange option
+type FortiGatePortRange struct {
+	Low  uint16
+	High uint16
+}
+
+// FortiGateServiceGroup represents an entry in "config firewall service group"
+type FortiGateServiceGroup struct {
+	Name    string
+	Members []string
+	Comment string
+}
+
+// FortiGatePolicy represents an entry in "config firewall policy"
+type FortiGatePolicy struct {
+	ID       int
+	Name     string
+	UUID     string
+	SrcIntf  []string
+	DstIntf  []string
+	SrcAddr  []string
+	DstAddr  []string
+	Service  []string
+	Action   string
+	Status   string
+	Schedule string
+	NAT      bool
+	Comments string
+}
+
+// FortiGateRoute represents an entry in "config router static"
+type FortiGateRoute struct {
+	Destination string
+	Gateway     string
+	Interface   string
+	Distance    int
+	Blackhole   bool
+	Status      string
+}
@@ -1,9 +1,19 @@
 package iptables
 
 import (
-	"cipgram/pkg/types"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"cipgram/pkg/types"
 )
 
 // IptablesParser implements FirewallParser for iptables configurations
@@ -21,9 +31,40 @@ func NewIptablesParser(configPath string) *IptablesParser {
 
 // Parse implements FirewallParser.Parse for iptables configs
 func (p *IptablesParser) Parse() (*types.NetworkModel, error) {
-	// TODO: Implement iptables configuration parsing
-	// iptables rules can be in various formats: iptables-save, scripts, or individual commands
-	return nil, fmt.Errorf("iptables parser not yet implemented")
+	if err := p.loadConfig(); err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	model := &types.NetworkModel{
+		Assets:   make(map[string]*types.Asset),
+		Networks: make(map[string]*types.NetworkSegment),
+		Flows:    make(map[types.FlowKey]*types.Flow),
+		Policies: []*types.SecurityPolicy{},
+		Metadata: p.GetMetadata(),
+	}
+
+	// Filter table rules become security policies, in evaluation order
+	for _, key := range p.config.TableOrder {
+		table := p.config.Tables[key]
+		if table.Name == TableFilter {
+			p.parseFilterTable(table, model)
+		}
+	}
+
+	// Interfaces referenced by rules become network segments
+	p.parseInterfaces(model)
+
+	if p.config.HasCounters {
+		unused := 0
+		for _, policy := range model.Policies {
+			if policy.Hits != nil && policy.Hits.Packets == 0 {
+				unused++
+			}
+		}
+		log.Printf("iptables counters: %d of %d rules have not matched any traffic", unused, len(model.Policies))
+	}
+
+	return model, nil
 }
 
 // GetType implements FirewallParser.GetType
@@ -33,129 +74,1044 @@ func (p *IptablesParser) GetType() types.InputType {
 
 // GetMetadata implements FirewallParser.GetMetadata
 func (p *IptablesParser) GetMetadata() types.InputMetadata {
+	info, _ := os.Stat(p.configPath)
+	size := int64(0)
+	modTime := time.Now()
+
+	if info != nil {
+		size = info.Size()
+		modTime = info.ModTime()
+	}
+
 	return types.InputMetadata{
 		Source:    p.configPath,
 		Type:      types.InputTypeIptables,
-		Timestamp: time.Now(),
-		Size:      0, // TODO: Get actual file size
+		Timestamp: modTime,
+		Size:      size,
+		Hash:      calculateFileHash(p.configPath),
 	}
 }
 
 // Validate implements FirewallParser.Validate
 func (p *IptablesParser) Validate() error {
-	// TODO: Implement iptables config validation
-	return fmt.Errorf("iptables validation not yet implemented")
+	if _, err := os.Stat(p.configPath); os.IsNotExist(err) {
+		return fmt.Errorf("config file not found: %s", p.configPath)
+	}
+
+	if err := p.loadConfig(); err != nil {
+		return fmt.Errorf("invalid iptables-save output: %v", err)
+	}
+
+	if len(p.config.Tables) == 0 {
+		return fmt.Errorf("invalid iptables-save output: no tables found")
+	}
+
+	return nil
+}
+
+// GetConfig returns the parsed iptables rule set (nil until Parse or Validate succeeds)
+func (p *IptablesParser) GetConfig() *IptablesConfig {
+	return p.config
 }
 
-// IptablesConfig represents the structure of iptables rules
-// TODO: Define actual iptables configuration structures
-type IptablesConfig struct {
-	// iptables configurations can be in several formats:
-	// 1. iptables-save output format
-	// 2. Shell scripts with iptables commands
-	// 3. Individual rule files
+// loadConfig reads iptables-save / ip6tables-save output
+func (p *IptablesParser) loadConfig() error {
+	file, err := os.Open(p.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot open config file: %v", err)
+	}
+	defer file.Close()
 
-	// Placeholder structures for future implementation
-	Tables map[string]IptablesTable // filter, nat, mangle, raw, security
+	config, err := parseIptablesSave(file)
+	if err != nil {
+		return err
+	}
+
+	p.config = config
+	return nil
 }
 
-type IptablesTable struct {
-	Name     string
-	Chains   map[string]IptablesChain
-	Policies map[string]string // default policies for built-in chains
+// parseIptablesSave parses the iptables-save format:
+//
+//	*filter
+//	:INPUT DROP [0:0]
+//	[12:720] -A INPUT -i eth1 -p tcp -m tcp --dport 22 -j ACCEPT
+//	COMMIT
+func parseIptablesSave(r io.Reader) (*IptablesConfig, error) {
+	config := &IptablesConfig{
+		Tables: make(map[string]*IptablesTable),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	family := "ipv4"
+	var table *IptablesTable
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			// "# Generated by ip6tables-save v1.8.7 on ..." switches the address family
+			if strings.Contains(line, "ip6tables-save") {
+				family = "ipv6"
+			} else if strings.Contains(line, "iptables-save") {
+				family = "ipv4"
+			}
+		case strings.HasPrefix(line, "*"):
+			if table != nil {
+				return nil, fmt.Errorf("line %d: table %s started before COMMIT of %s", lineNum, line[1:], table.Name)
+			}
+			table = &IptablesTable{
+				Name:     strings.TrimSpace(line[1:]),
+				Family:   family,
+				Chains:   make(map[string]*IptablesChain),
+				Policies: make(map[string]string),
+			}
+		case line == "COMMIT":
+			if table == nil {
+				return nil, fmt.Errorf("line %d: COMMIT outside of a table", lineNum)
+			}
+			key := table.Name
+			if table.Family == "ipv6" {
+				key = "ip6:" + table.Name
+			}
+			if _, exists := config.Tables[key]; !exists {
+				config.TableOrder = append(config.TableOrder, key)
+			}
+			config.Tables[key] = table
+			table = nil
+		case strings.HasPrefix(line, ":"):
+			if table == nil {
+				return nil, fmt.Errorf("line %d: chain declared outside of a table", lineNum)
+			}
+			chain, err := parseChainHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			table.Chains[chain.Name] = chain
+			table.ChainOrder = append(table.ChainOrder, chain.Name)
+			if chain.Type == "built-in" {
+				table.Policies[chain.Name] = chain.Policy
+			}
+		default:
+			if table == nil {
+				return nil, fmt.Errorf("line %d: rule outside of a table", lineNum)
+			}
+			rule, hasCounters, err := parseRule(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			chain, ok := table.Chains[rule.Chain]
+			if !ok {
+				return nil, fmt.Errorf("line %d: rule appended to undeclared chain %s", lineNum, rule.Chain)
+			}
+			rule.LineNumber = len(chain.Rules) + 1
+			chain.Rules = append(chain.Rules, rule)
+			config.HasCounters = config.HasCounters || hasCounters
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read config: %v", err)
+	}
+	if table != nil {
+		return nil, fmt.Errorf("table %s is missing COMMIT", table.Name)
+	}
+
+	return config, nil
 }
 
-type IptablesChain struct {
-	Name     string
-	Type     string // built-in (INPUT, OUTPUT, FORWARD) or user-defined
-	Policy   string // ACCEPT, DROP, REJECT (for built-in chains)
-	Rules    []IptablesRule
-	Counters IptablesCounters
+// parseChainHeader parses ":NAME POLICY [packets:bytes]"; user-defined chains use "-" as policy
+func parseChainHeader(line string) (*IptablesChain, error) {
+	fields := strings.Fields(line[1:])
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("malformed chain declaration %q", line)
+	}
+
+	chain := &IptablesChain{
+		Name:   fields[0],
+		Type:   "user-defined",
+		Policy: fields[1],
+	}
+	if chain.Policy != "-" {
+		chain.Type = "built-in"
+	}
+	if len(fields) > 2 {
+		chain.Counters, _ = parseCounters(fields[2])
+	}
+
+	return chain, nil
 }
 
-type IptablesRule struct {
-	LineNumber      int
-	Target          string // ACCEPT, DROP, REJECT, LOG, custom chain, etc.
-	Protocol        string
-	Source          IptablesAddress
-	Destination     IptablesAddress
-	InputInterface  string
-	OutputInterface string
-	Match           IptablesMatch
-	Counters        IptablesCounters
-	Comment         string
+// parseCounters parses "[packets:bytes]"
+func parseCounters(s string) (IptablesCounters, bool) {
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return IptablesCounters{}, false
+	}
+	parts := strings.SplitN(s[1:len(s)-1], ":", 2)
+	if len(parts) != 2 {
+		return IptablesCounters{}, false
+	}
+	packets, err1 := strconv.ParseInt(parts[0], 10, 64)
+	bytes, err2 := strconv.ParseInt(parts[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return IptablesCounters{}, false
+	}
+	return IptablesCounters{Packets: packets, Bytes: bytes}, true
 }
 
-type IptablesAddress struct {
-	Address string // IP address or network (CIDR)
-	Port    string // port number or range
+// parseRule parses a single "-A CHAIN ..." line, optionally prefixed with "[packets:bytes]"
+func parseRule(line string) (IptablesRule, bool, error) {
+	var rule IptablesRule
+	hasCounters := false
+
+	tokens, err := splitArgs(line)
+	if err != nil {
+		return rule, false, err
+	}
+
+	if len(tokens) > 0 && strings.HasPrefix(tokens[0], "[") {
+		rule.Counters, hasCounters = parseCounters(tokens[0])
+		tokens = tokens[1:]
+	}
+
+	// value returns the argument of the option at index i
+	value := func(i int) (string, error) {
+		if i+1 >= len(tokens) {
+			return "", fmt.Errorf("option %s requires a value", tokens[i])
+		}
+		return tokens[i+1], nil
+	}
+
+	negate := false
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		prefix := ""
+		if negate {
+			prefix = "!"
+		}
+
+		if tok == "!" {
+			negate = true
+			continue
+		}
+
+		// Everything after the target belongs to the target (--reject-with, --log-prefix, ...)
+		if rule.Target != "" {
+			rule.TargetOptions = append(rule.TargetOptions, tok)
+			continue
+		}
+
+		switch tok {
+		case "-A", "--append":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Chain = v
+			i++
+		case "-c", "--set-counters":
+			if i+2 >= len(tokens) {
+				return rule, false, fmt.Errorf("option -c requires packets and bytes")
+			}
+			rule.Counters, hasCounters = parseCounters(fmt.Sprintf("[%s:%s]", tokens[i+1], tokens[i+2]))
+			i += 2
+		case "-s", "--source", "--src":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Source.Address = prefix + v
+			i++
+		case "-d", "--destination", "--dst":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Destination.Address = prefix + v
+			i++
+		case "-p", "--protocol":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Protocol = prefix + strings.ToLower(v)
+			i++
+		case "-i", "--in-interface":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.InputInterface = prefix + v
+			i++
+		case "-o", "--out-interface":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.OutputInterface = prefix + v
+			i++
+		case "-m", "--match":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Modules = append(rule.Modules, v)
+			i++
+		case "--dport", "--destination-port":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Destination.Port = prefix + v
+			i++
+		case "--sport", "--source-port":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Source.Port = prefix + v
+			i++
+		case "--dports", "--destination-ports":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Match.Multiport.DestPorts = portList(prefix, v)
+			i++
+		case "--sports", "--source-ports":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Match.Multiport.SourcePorts = portList(prefix, v)
+			i++
+		case "--ports":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Match.Multiport.Ports = portList(prefix, v)
+			i++
+		case "--state", "--ctstate":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Match.State = strings.Split(v, ",")
+			if negate {
+				rule.Match.State = invertStates(rule.Match.State)
+			}
+			i++
+		case "--src-range":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Source.Range = prefix + v
+			i++
+		case "--dst-range":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Destination.Range = prefix + v
+			i++
+		case "--comment":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Comment = v
+			i++
+		case "--tcp-flags":
+			if i+2 >= len(tokens) {
+				return rule, false, fmt.Errorf("option --tcp-flags requires mask and comparison")
+			}
+			rule.Match.TCPFlags = prefix + tokens[i+1] + " " + tokens[i+2]
+			i += 2
+		case "--icmp-type", "--icmpv6-type":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Match.ICMPType = prefix + v
+			i++
+		case "--limit":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Match.Limit = v
+			i++
+		case "-j", "--jump", "-g", "--goto":
+			v, err := value(i)
+			if err != nil {
+				return rule, false, err
+			}
+			rule.Target = v
+			rule.Goto = tok == "-g" || tok == "--goto"
+			i++
+		default:
+			// Unmodelled match option: skip its arguments
+			for i+1 < len(tokens) && !strings.HasPrefix(tokens[i+1], "-") && tokens[i+1] != "!" {
+				i++
+			}
+		}
+		negate = false
+	}
+
+	if rule.Chain == "" {
+		return rule, false, fmt.Errorf("unrecognised line %q", line)
+	}
+
+	return rule, hasCounters, nil
 }
 
-type IptablesMatch struct {
-	State     []string // NEW, ESTABLISHED, RELATED, INVALID
-	TCPFlags  string
-	ICMPType  string
-	Limit     string
-	Recent    string
-	Multiport IptablesMultiport
-	String    IptablesStringMatch
-	Time      IptablesTimeMatch
+// portList splits a multiport list, prefixing every port with "!" when negated
+func portList(prefix, value string) []string {
+	ports := strings.Split(value, ",")
+	for i := range ports {
+		ports[i] = prefix + ports[i]
+	}
+	return ports
 }
 
-type IptablesMultiport struct {
-	Ports       []string
-	DestPorts   []string
-	SourcePorts []string
+// connStates are the conntrack states a negated --state/--ctstate list selects from
+var connStates = []string{"NEW", "ESTABLISHED", "RELATED", "INVALID", "UNTRACKED"}
+
+// invertStates returns the conntrack states missing from a negated list
+// ("! --ctstate INVALID" is NEW,ESTABLISHED,RELATED,UNTRACKED)
+func invertStates(states []string) []string {
+	var inverted []string
+	for _, state := range connStates {
+		if !containsState(states, state) {
+			inverted = append(inverted, state)
+		}
+	}
+	return inverted
 }
 
-type IptablesStringMatch struct {
-	Algorithm string
-	Pattern   string
+// splitArgs splits a rule into shell-style words, honouring double quotes
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuotes := false
+	hasArg := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+			hasArg = true
+		case c == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteByte(c)
+			hasArg = true
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args, nil
 }
 
-type IptablesTimeMatch struct {
-	TimeStart string
-	TimeStop  string
-	Weekdays  []string
-	MonthDays []int
+// parseFilterTable walks the built-in chains of the filter table, following
+// jumps into user-defined chains, and emits one policy per verdict rule
+func (p *IptablesParser) parseFilterTable(table *IptablesTable, model *types.NetworkModel) {
+	for _, chainName := range []string{ChainInput, ChainForward, ChainOutput} {
+		chain, ok := table.Chains[chainName]
+		if !ok {
+			continue
+		}
+
+		idPrefix := chainName
+		family := "inet"
+		if table.Family == "ipv6" {
+			idPrefix = "ip6-" + chainName
+			family = "inet6"
+		}
+
+		w := &chainWalker{parser: p, table: table, builtin: chain, idPrefix: idPrefix, family: family, model: model}
+		w.walk(chain, 0, nil, idPrefix, nil, map[string]bool{chainName: true})
+		w.chainPolicy(idPrefix+"-policy", nil)
+	}
 }
 
-type IptablesCounters struct {
-	Packets int64
-	Bytes   int64
+// chainWalker emits the policies of one built-in chain of a filter table
+type chainWalker struct {
+	parser   *IptablesParser
+	table    *IptablesTable
+	builtin  *IptablesChain
+	idPrefix string // ID prefix of the built-in chain ("FORWARD", "ip6-FORWARD")
+	family   string // "inet" or "inet6", from the table's address family
+	model    *types.NetworkModel
 }
 
-// Common iptables chains
-const (
-	ChainInput       = "INPUT"
-	ChainOutput      = "OUTPUT"
-	ChainForward     = "FORWARD"
-	ChainPrerouting  = "PREROUTING"
-	ChainPostrouting = "POSTROUTING"
-)
+// returnPoint is where a RETURN from a called chain continues: the rule after the jump
+type returnPoint struct {
+	chain    *IptablesChain
+	next     int
+	parents  []IptablesRule
+	idPrefix string
+	visiting map[string]bool
+}
 
-// Common iptables tables
-const (
-	TableFilter   = "filter"
-	TableNat      = "nat"
-	TableMangle   = "mangle"
-	TableRaw      = "raw"
-	TableSecurity = "security"
-)
+// walk emits policies for the rules of a chain from index from on. parents holds the jump
+// rules that led here; their matches constrain every rule in the called chain. returns
+// holds where a RETURN continues, innermost calling chain last.
+func (w *chainWalker) walk(chain *IptablesChain, from int, parents []IptablesRule, idPrefix string,
+	returns []returnPoint, visiting map[string]bool) {
 
-// Common iptables targets
-const (
-	TargetAccept     = "ACCEPT"
-	TargetDrop       = "DROP"
-	TargetReject     = "REJECT"
-	TargetLog        = "LOG"
-	TargetSNAT       = "SNAT"
-	TargetDNAT       = "DNAT"
-	TargetMasquerade = "MASQUERADE"
-)
+	for i := from; i < len(chain.Rules); i++ {
+		rule := chain.Rules[i]
+		id := fmt.Sprintf("%s-%d", idPrefix, rule.LineNumber)
+
+		if sub, ok := w.table.Chains[rule.Target]; ok {
+			if visiting[sub.Name] {
+				log.Printf("Warning: iptables chain loop via %s -> %s ignored", chain.Name, sub.Name)
+				continue
+			}
+			// -j returns to the next rule here, -g to where this chain was called from
+			resume := returns
+			if !rule.Goto {
+				resume = append(returns[:len(returns):len(returns)],
+					returnPoint{chain: chain, next: i + 1, parents: parents, idPrefix: idPrefix, visiting: visiting})
+			}
+			called := map[string]bool{sub.Name: true}
+			for name := range visiting {
+				called[name] = true
+			}
+			w.walk(sub, 0, append(parents[:len(parents):len(parents)], rule), id+"/"+sub.Name, resume, called)
+			continue
+		}
+
+		if rule.Target == TargetReturn {
+			if !hasMatch(rule) {
+				return // The rest of the chain is never reached
+			}
+			// Returned traffic meets the rest of the calling chains before the rules below
+			w.resume(returns, append(parents[:len(parents):len(parents)], rule), id)
+			continue
+		}
+
+		action, ok := verdicts[rule.Target]
+		if !ok {
+			continue // MARK, CONNMARK and other non-verdict targets
+		}
+
+		effective, ok := mergeRule(parents, rule)
+		if !ok {
+			continue // Contradicts the jump rules that led here, so it never matches
+		}
+		policy := w.policy(id, effective, action)
+		if w.parser.config.HasCounters {
+			policy.Hits = &types.PolicyHits{Packets: rule.Counters.Packets, Bytes: rule.Counters.Bytes}
+		}
+
+		w.model.Policies = append(w.model.Policies, policy)
+	}
+}
+
+// resume emits the rules traffic matching a conditional RETURN (the last of parents)
+// meets next: the rest of each calling chain, then the built-in chain's policy
+func (w *chainWalker) resume(returns []returnPoint, parents []IptablesRule, id string) {
+	for i := len(returns) - 1; i >= 0; i-- {
+		point := returns[i]
+		w.walk(point.chain, point.next, parents, id+"/"+point.idPrefix, returns[:i], point.visiting)
+	}
+	w.chainPolicy(id+"/"+w.idPrefix+"-policy", parents)
+}
+
+// chainPolicy emits the default policy, which applies to packets that fall off the end
+// of the built-in chain; parents limit it to the traffic of a conditional RETURN
+func (w *chainWalker) chainPolicy(id string, parents []IptablesRule) {
+	effective, ok := mergeRule(parents, IptablesRule{})
+	if !ok {
+		return
+	}
+
+	policy := w.policy(id, effective, mapTarget(w.builtin.Policy))
+	policy.Description = fmt.Sprintf("Default %s chain policy (%s)", w.builtin.Name, w.builtin.Policy)
+	policy.Hits = &types.PolicyHits{Packets: w.builtin.Counters.Packets, Bytes: w.builtin.Counters.Bytes}
+	w.model.Policies = append(w.model.Policies, policy)
+}
+
+// policy converts a rule merged with its jump rules into a security policy
+func (w *chainWalker) policy(id string, rule IptablesRule, action types.RuleAction) *types.SecurityPolicy {
+	ports, negatePorts := rulePorts(rule.Protocol, destinationPortSpecs(rule))
+	sourcePorts, negateSourcePorts := rulePorts(rule.Protocol, sourcePortSpecs(rule))
+
+	policy := &types.SecurityPolicy{
+		ID:                id,
+		Source:            toNetworkRange(rule.Source),
+		Destination:       toNetworkRange(rule.Destination),
+		Ports:             ports,
+		SourcePorts:       sourcePorts,
+		NegatePorts:       negatePorts,
+		NegateSourcePorts: negateSourcePorts,
+		Protocol:          types.Protocol(rule.Protocol),
+		Action:            action,
+		Description:       ruleDescription(rule),
+		Enabled:           true,
+		Local:             w.builtin.Name != ChainForward,
+		States:            rule.Match.State,
+		IPFamily:          w.family,
+	}
+	bindInterfaces(policy, rule)
+	if policy.Protocol == "" {
+		policy.Protocol = "any"
+	}
+	return policy
+}
+
+// verdicts maps targets that decide a packet's fate (or log it) to policy actions
+var verdicts = map[string]types.RuleAction{
+	TargetAccept: types.Allow,
+	TargetDrop:   types.Drop,
+	TargetReject: types.Reject,
+	TargetLog:    types.Log,
+	"NFLOG":      types.Log,
+	"ULOG":       types.Log,
+}
 
-// TODO: Implement parsing functions for different iptables formats
-// parseIptablesSave() error - parse iptables-save format
-// parseIptablesScript() error - parse shell script with iptables commands
-// parseIptablesRules() error - parse individual rule files
-// detectFormat() (string, error) - detect input format
+func mapTarget(target string) types.RuleAction {
+	if action, ok := verdicts[target]; ok {
+		return action
+	}
+	return types.Deny
+}
+
+// hasMatch reports whether a rule restricts the packets it applies to by any of the
+// matches policies carry
+func hasMatch(rule IptablesRule) bool {
+	multiport := rule.Match.Multiport
+	return rule.Protocol != "" || rule.InputInterface != "" || rule.OutputInterface != "" ||
+		rule.Source != (IptablesAddress{}) || rule.Destination != (IptablesAddress{}) ||
+		len(rule.Match.State) > 0 ||
+		len(multiport.Ports)+len(multiport.DestPorts)+len(multiport.SourcePorts) > 0
+}
+
+// mergeRule fills the fields a rule leaves unset from the jump rules above it. Where both
+// give a network, the narrower applies. ok is false when a jump rule contradicts the rule
+// (another protocol or interface, a disjoint network or no common state), so it never matches.
+func mergeRule(parents []IptablesRule, rule IptablesRule) (IptablesRule, bool) {
+	merged := rule
+	for i := len(parents) - 1; i >= 0; i-- {
+		parent := parents[i]
+		var ok bool
+		if merged.Protocol, ok = mergeField(merged.Protocol, parent.Protocol); !ok {
+			return merged, false
+		}
+		if merged.InputInterface, ok = mergeField(merged.InputInterface, parent.InputInterface); !ok {
+			return merged, false
+		}
+		if merged.OutputInterface, ok = mergeField(merged.OutputInterface, parent.OutputInterface); !ok {
+			return merged, false
+		}
+		if merged.Source, ok = mergeAddress(merged.Source, parent.Source); !ok {
+			return merged, false
+		}
+		if merged.Destination, ok = mergeAddress(merged.Destination, parent.Destination); !ok {
+			return merged, false
+		}
+		if merged.Source.Port == "" && merged.Destination.Port == "" &&
+			len(merged.Match.Multiport.DestPorts) == 0 && len(merged.Match.Multiport.SourcePorts) == 0 &&
+			len(merged.Match.Multiport.Ports) == 0 {
+			merged.Source.Port = parent.Source.Port
+			merged.Destination.Port = parent.Destination.Port
+			merged.Match.Multiport = parent.Match.Multiport
+		}
+		if merged.Match.State, ok = mergeStates(merged.Match.State, parent.Match.State); !ok {
+			return merged, false
+		}
+		if merged.Comment == "" {
+			merged.Comment = parent.Comment
+		}
+	}
+	return merged, true
+}
+
+// mergeField combines a protocol or interface with the jump rule's. Negated and
+// wildcard (eth+) values are not compared; the rule's own value is kept.
+func mergeField(value, parent string) (string, bool) {
+	switch {
+	case value == "":
+		return parent, true
+	case parent == "" || value == parent:
+		return value, true
+	case strings.HasPrefix(value, "!") || strings.HasPrefix(parent, "!") ||
+		strings.HasSuffix(value, "+") || strings.HasSuffix(parent, "+"):
+		return value, true
+	default:
+		return value, false
+	}
+}
+
+// mergeAddress combines a rule's address with the jump rule's, keeping the narrower of
+// two networks. Negated networks and ranges are not compared; the rule's own is kept.
+func mergeAddress(addr, parent IptablesAddress) (IptablesAddress, bool) {
+	if addr.Address == "" && addr.Range == "" {
+		addr.Address, addr.Range = parent.Address, parent.Range
+		return addr, true
+	}
+	if addr.Range != "" || parent.Range != "" {
+		return addr, true
+	}
+
+	network, parentNetwork := parseNetwork(addr.Address), parseNetwork(parent.Address)
+	if network == nil || parentNetwork == nil {
+		return addr, true
+	}
+	networkBits, _ := network.Mask.Size()
+	parentBits, _ := parentNetwork.Mask.Size()
+	switch {
+	case networkBits >= parentBits && parentNetwork.Contains(network.IP):
+		return addr, true
+	case parentBits > networkBits && network.Contains(parentNetwork.IP):
+		addr.Address = parent.Address
+		return addr, true
+	default:
+		return addr, false
+	}
+}
+
+// parseNetwork parses a plain address or network; nil for negated or other values
+func parseNetwork(address string) *net.IPNet {
+	if !strings.Contains(address, "/") {
+		if ip := net.ParseIP(address); ip != nil {
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		return nil
+	}
+	if _, network, err := net.ParseCIDR(address); err == nil {
+		return network
+	}
+	return nil
+}
+
+// mergeStates keeps the conntrack states both a rule and its jump rule accept
+func mergeStates(states, parent []string) ([]string, bool) {
+	if len(states) == 0 {
+		return parent, true
+	}
+	if len(parent) == 0 {
+		return states, true
+	}
+
+	var common []string
+	for _, state := range states {
+		if containsState(parent, state) {
+			common = append(common, state)
+		}
+	}
+	return common, len(common) > 0
+}
+
+func containsState(states []string, state string) bool {
+	for _, s := range states {
+		if strings.EqualFold(s, state) {
+			return true
+		}
+	}
+	return false
+}
+
+func toNetworkRange(addr IptablesAddress) types.NetworkRange {
+	switch {
+	case addr.Address != "" && addr.Address != "0.0.0.0/0" && addr.Address != "::/0":
+		return types.NetworkRange{CIDR: addr.Address, IPs: []string{}}
+	case addr.Range != "":
+		return types.NetworkRange{CIDR: addr.Range, IPs: []string{addr.Range}}
+	default:
+		return types.NetworkRange{CIDR: "any", IPs: []string{}}
+	}
+}
+
+// destinationPortSpecs returns the --dport and multiport --dports specs of a rule.
+// Multiport --ports matches either end of the connection; it is kept with the
+// destination ports, which is where services are usually listed.
+func destinationPortSpecs(rule IptablesRule) []string {
+	var specs []string
+	if rule.Destination.Port != "" {
+		specs = append(specs, rule.Destination.Port)
+	}
+	specs = append(specs, rule.Match.Multiport.DestPorts...)
+	return append(specs, rule.Match.Multiport.Ports...)
+}
+
+// sourcePortSpecs returns the --sport and multiport --sports specs of a rule
+func sourcePortSpecs(rule IptablesRule) []string {
+	var specs []string
+	if rule.Source.Port != "" {
+		specs = append(specs, rule.Source.Port)
+	}
+	return append(specs, rule.Match.Multiport.SourcePorts...)
+}
+
+// rulePorts converts port specs into the "proto:name" port format. Negated specs
+// ("! --dport 22") give a negated list; next to ports listed without negation they
+// are dropped, as the listed ports are the narrower match.
+func rulePorts(protocol string, specs []string) ([]types.Port, bool) {
+	proto := strings.TrimPrefix(protocol, "!")
+	if proto == "" {
+		proto = "any"
+	}
+
+	var listed, negated []string
+	for _, spec := range specs {
+		if port, ok := strings.CutPrefix(spec, "!"); ok {
+			negated = append(negated, port)
+		} else {
+			listed = append(listed, spec)
+		}
+	}
+	negate := len(listed) == 0 && len(negated) > 0
+	specs = listed
+	if negate {
+		specs = negated
+	}
+
+	ports := []types.Port{}
+	for _, spec := range specs {
+		if low, high, ok := strings.Cut(spec, ":"); ok {
+			start, end := parsePortNumber(low), parsePortNumber(high)
+			if start > 0 && end >= start {
+				ports = append(ports, types.Port{
+					Number:   uint16(start),
+					Protocol: fmt.Sprintf("%s:%d-%d", proto, start, end),
+				})
+			}
+			continue
+		}
+
+		if num := parsePortNumber(spec); num > 0 {
+			ports = append(ports, types.Port{
+				Number:   uint16(num),
+				Protocol: fmt.Sprintf("%s:%s", proto, getPortName(num)),
+			})
+		} else if spec != "" {
+			// Named service from /etc/services (e.g. "ssh")
+			ports = append(ports, types.Port{Number: 0, Protocol: fmt.Sprintf("%s:%s", proto, spec)})
+		}
+	}
+
+	return ports, negate
+}
+
+// bindInterfaces binds a policy to the rule's -i interface as ingress and its -o
+// interface as egress. Negated and wildcard (eth+) interfaces are left unbound.
+func bindInterfaces(policy *types.SecurityPolicy, rule IptablesRule) {
+	in, out := bindable(rule.InputInterface), bindable(rule.OutputInterface)
+	switch {
+	case in != "":
+		policy.Zone, policy.Direction, policy.EgressZone = in, "in", out
+	case out != "":
+		policy.Zone, policy.Direction = out, "out"
+	default:
+		policy.Zone = "any"
+	}
+}
+
+func bindable(iface string) string {
+	if strings.HasPrefix(iface, "!") || strings.HasSuffix(iface, "+") {
+		return ""
+	}
+	return iface
+}
+
+func ruleDescription(rule IptablesRule) string {
+	description := rule.Comment
+	if len(rule.Match.State) > 0 {
+		state := "ctstate " + strings.Join(rule.Match.State, ",")
+		if description == "" {
+			description = state
+		} else {
+			description = fmt.Sprintf("%s [%s]", description, state)
+		}
+	}
+	return description
+}
+
+// parseInterfaces creates a segment for every interface referenced by filter rules.
+// iptables carries no interface addressing, so the CIDR is taken from the first
+// rule that pairs the interface with a plain source (-i) or destination (-o) network.
+func (p *IptablesParser) parseInterfaces(model *types.NetworkModel) {
+	for _, key := range p.config.TableOrder {
+		table := p.config.Tables[key]
+		if table.Name != TableFilter {
+			continue
+		}
+
+		for _, chainName := range table.ChainOrder {
+			for _, rule := range table.Chains[chainName].Rules {
+				p.addInterfaceSegment(model, rule.InputInterface, rule.Source.Address)
+				p.addInterfaceSegment(model, rule.OutputInterface, rule.Destination.Address)
+			}
+		}
+	}
+
+	for _, segment := range model.Networks {
+		segment.Zone = inferZone(segment.ID)
+		segment.Purpose = inferPurpose(segment.ID)
+		switch segment.Zone {
+		case types.IndustrialZone:
+			segment.Risk = types.HighRisk
+		case types.DMZZone, types.RemoteAccessZone:
+			segment.Risk = types.MediumRisk
+		default:
+			segment.Risk = types.LowRisk
+		}
+	}
+}
+
+func (p *IptablesParser) addInterfaceSegment(model *types.NetworkModel, iface, network string) {
+	if iface == "" || strings.HasPrefix(iface, "!") || iface == "lo" {
+		return
+	}
+
+	segment, ok := model.Networks[iface]
+	if !ok {
+		segment = &types.NetworkSegment{
+			ID:       iface,
+			Name:     iface,
+			Assets:   []*types.Asset{},
+			Policies: []*types.SecurityPolicy{},
+		}
+		model.Networks[iface] = segment
+
+		for _, policy := range model.Policies {
+			if policy.Zone == iface {
+				segment.Policies = append(segment.Policies, policy)
+			}
+		}
+	}
+
+	if segment.CIDR == "" && network != "" && !strings.HasPrefix(network, "!") &&
+		network != "0.0.0.0/0" && network != "::/0" {
+		segment.CIDR = network
+	}
+}
+
+// inferZone maps Linux interface naming conventions onto IEC 62443 zones
+func inferZone(iface string) types.IEC62443Zone {
+	lower := strings.ToLower(iface)
+
+	switch {
+	case hasAnyPrefix(lower, "wg", "tun", "tap", "ipsec", "vti", "ppp") || strings.Contains(lower, "vpn"):
+		return types.RemoteAccessZone
+	case strings.Contains(lower, "wan") || strings.Contains(lower, "ext") || strings.Contains(lower, "dmz"):
+		return types.DMZZone
+	case inferPurpose(iface) == "Production OT":
+		return types.IndustrialZone
+	default:
+		return types.EnterpriseZone
+	}
+}
+
+func inferPurpose(iface string) string {
+	lower := strings.ToLower(iface)
+
+	switch {
+	case strings.Contains(lower, "ot") || strings.Contains(lower, "ics") || strings.Contains(lower, "scada") ||
+		strings.Contains(lower, "plc") || strings.Contains(lower, "cell") || strings.Contains(lower, "plant"):
+		return "Production OT"
+	case strings.Contains(lower, "dmz"):
+		return "DMZ"
+	case strings.Contains(lower, "mgmt"):
+		return "Management"
+	case strings.Contains(lower, "wan") || strings.Contains(lower, "ext") || hasAnyPrefix(lower, "ppp"):
+		return "Internet"
+	default:
+		return "General"
+	}
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePortNumber converts a string to a port number, returning 0 when invalid
+func parsePortNumber(s string) int {
+	num, err := strconv.Atoi(s)
+	if err != nil || num < 0 || num > 65535 {
+		return 0
+	}
+	return num
+}
+
+// getPortName returns common service names for well-known ports
+func getPortName(port int) string {
+	wellKnownPorts := map[int]string{
+		22:    "SSH",
+		23:    "Telnet",
+		53:    "DNS",
+		80:    "HTTP",
+		102:   "S7comm",
+		123:   "NTP",
+		161:   "SNMP",
+		443:   "HTTPS",
+		502:   "Modbus",
+		1883:  "MQTT",
+		2222:  "ENIP-IO",
+		3389:  "RDP",
+		4840:  "OPC-UA",
+		8883:  "MQTTS",
+		20000: "DNP3",
+		44818: "ENIP",
+		47808: "BACnet",
+	}
+
+	if name, exists := wellKnownPorts[port]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("Port-%d", port)
+}
+
+// calculateFileHash computes SHA256 hash of a file for integrity checking
+func calculateFileHash(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,115 @@
+package iptables
+
+// IptablesConfig represents the structure of iptables rules loaded from
+// iptables-save / ip6tables-save output
+type IptablesConfig struct {
+	// Tables keyed by table name ("filter", "nat", ...). Tables from
+	// ip6tables-save output are keyed with an "ip6:" prefix ("ip6:filter").
+	Tables      map[string]*IptablesTable
+	TableOrder  []string
+	HasCounters bool // Rule counters were exported (iptables-save -c)
+}
+
+type IptablesTable struct {
+	Name       string
+	Family     string // "ipv4" or "ipv6"
+	Chains     map[string]*IptablesChain
+	ChainOrder []string
+	Policies   map[string]string // default policies for built-in chains
+}
+
+type IptablesChain struct {
+	Name     string
+	Type     string // built-in (INPUT, OUTPUT, FORWARD) or user-defined
+	Policy   string // ACCEPT, DROP, REJECT (for built-in chains)
+	Rules    []IptablesRule
+	Counters IptablesCounters
+}
+
+type IptablesRule struct {
+	LineNumber      int    // 1-based position of the rule within its chain
+	Chain           string // Chain the rule is appended to
+	Target          string // ACCEPT, DROP, REJECT, LOG, custom chain, etc.
+	Goto            bool   // Target was given with -g instead of -j
+	TargetOptions   []string
+	Protocol        string
+	Source          IptablesAddress
+	Destination     IptablesAddress
+	InputInterface  string
+	OutputInterface string
+	Modules         []string // Match modules loaded with -m
+	Match           IptablesMatch
+	Counters        IptablesCounters
+	Comment         string
+}
+
+type IptablesAddress struct {
+	Address string // IP address or network (CIDR), prefixed with "!" when negated
+	Port    string // port number or range, prefixed with "!" when negated
+	Range   string // iprange match (--src-range / --dst-range), e.g. "10.0.0.1-10.0.0.20"
+}
+
+type IptablesMatch struct {
+	State     []string // NEW, ESTABLISHED, RELATED, INVALID (-m state or -m conntrack --ctstate); negated lists are stored inverted
+	TCPFlags  string
+	ICMPType  string
+	Limit     string
+	Recent    string
+	Multiport IptablesMultiport
+	String    IptablesStringMatch
+	Time      IptablesTimeMatch
+}
+
+// IptablesMultiport holds multiport lists; entries are prefixed with "!" when negated
+type IptablesMultiport struct {
+	Ports       []string
+	DestPorts   []string
+	SourcePorts []string
+}
+
+type IptablesStringMatch struct {
+	Algorithm string
+	Pattern   string
+}
+
+type IptablesTimeMatch struct {
+	TimeStart string
+	TimeStop  string
+	Weekdays  []string
+	MonthDays []int
+}
+
+type IptablesCounters struct {
+	Packets int64
+	Bytes   int64
+}
+
+// Common iptables chains
+const (
+	ChainInput       = "INPUT"
+	ChainOutput      = "OUTPUT"
+	ChainForward     = "FORWARD"
+	ChainPrerouting  = "PREROUTING"
+	ChainPostrouting = "POSTROUTING"
+)
+
+// Common iptables tables
+const (
+	TableFilter   = "filter"
+	TableNat      = "nat"
+	TableMangle   = "mangle"
+	TableRaw      = "raw"
+	TableSecurity = "security"
+)
+
+// Common iptables targets
+const (
+	TargetAccept     = "ACCEPT"
+	TargetDrop       = "DROP"
+	TargetReject     = "REJECT"
+	TargetLog        = "LOG"
+	TargetReturn     = "RETURN"
+	TargetSNAT       = "SNAT"
+	TargetDNAT       = "DNAT"
+	TargetMasquerade = "MASQUERADE"
+)
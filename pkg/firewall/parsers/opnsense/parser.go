@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -19,6 +21,7 @@ import (
 type OPNsenseParser struct {
 	configPath string
 	config     *OPNsenseConfig
+	aliases    map[string]Alias // Enabled aliases by name, from both alias layouts
 }
 
 // NewOPNsenseParser creates a new OPNsense configuration parser
@@ -40,6 +43,7 @@ func (p *OPNsenseParser) Parse() (*types.NetworkModel, error) {
 		Networks: make(map[string]*types.NetworkSegment),
 		Flows:    make(map[types.FlowKey]*types.Flow),
 		Policies: []*types.SecurityPolicy{},
+		NATRules: []*types.NATRule{},
 		Metadata: p.GetMetadata(),
 	}
 
@@ -48,14 +52,19 @@ func (p *OPNsenseParser) Parse() (*types.NetworkModel, error) {
 		return nil, fmt.Errorf("failed to parse interfaces: %v", err)
 	}
 
+	// Parse aliases for network groupings; rules reference them by name
+	if err := p.parseAliases(model); err != nil {
+		return nil, fmt.Errorf("failed to parse aliases: %v", err)
+	}
+
 	// Parse firewall rules and create policies
 	if err := p.parseFirewallRules(model); err != nil {
 		return nil, fmt.Errorf("failed to parse firewall rules: %v", err)
 	}
 
-	// Parse aliases for network groupings
-	if err := p.parseAliases(model); err != nil {
-		return nil, fmt.Errorf("failed to parse aliases: %v", err)
+	// Parse port forwards, 1:1 and outbound NAT
+	if err := p.parseNAT(model); err != nil {
+		return nil, fmt.Errorf("failed to parse NAT rules: %v", err)
 	}
 
 	// Infer zones and risk levels
@@ -179,29 +188,56 @@ func (p *OPNsenseParser) parseFirewallRules(model *types.NetworkModel) error {
 			ruleID = fmt.Sprintf("rule-%d", i+1)
 		}
 
+		floating := rule.Floating == "yes" || rule.Floating == "1"
+		description := rule.Descr
+		if floating {
+			description = fmt.Sprintf("%s [floating, %s]", description, ruleDirection(rule.Direction, floating))
+		}
+		if rule.Log == "1" {
+			description += " [logged]"
+		}
+
 		policy := &types.SecurityPolicy{
 			ID:          ruleID,
-			Description: rule.Descr,
-			Enabled:     true, // OPNsense doesn't have disabled field in this format
+			Description: strings.TrimSpace(description),
+			Enabled:     rule.Disabled != "1", // Disabled rules are kept but inactive
 			Action:      p.mapRuleAction(rule.Type),
 			Zone:        rule.Interface,
+			Direction:   ruleDirection(rule.Direction, floating),
+			IPFamily:    ruleFamily(rule.IPProtocol),
+			Floating:    floating,
+			// Interface rules are always quick; floating rules only when <quick> is set
+			Quick: !floating || rule.Quick == "1" || rule.Quick == "yes",
 		}
 
 		// Parse source and destination
-		policy.Source = p.parseRuleTarget(rule.Source)
-		policy.Destination = p.parseRuleTarget(rule.Destination)
+		policy.Source = p.parseRuleTarget(rule.Source, model)
+		policy.Destination = p.parseRuleTarget(rule.Destination, model)
 
 		// Parse protocol and ports
 		if rule.Protocol != "" {
 			policy.Protocol = types.Protocol(rule.Protocol)
 		}
 
-		// Parse ports and add them to the policy
-		policy.Ports = p.parsePorts(rule.SrcPort, rule.DstPort, rule.Protocol)
+		// Ports live in <dstport>/<srcport> or, in the legacy layout, inside the target
+		srcPort, dstPort := rule.SrcPort, rule.DstPort
+		if srcPort == "" {
+			srcPort = rule.Source.Port
+		}
+		if dstPort == "" {
+			dstPort = rule.Destination.Port
+		}
+		policy.Ports = p.parsePortString(dstPort, "destination", rule.Protocol)
+		policy.SourcePorts = p.parsePortString(srcPort, "source", rule.Protocol)
 
 		model.Policies = append(model.Policies, policy)
 	}
 
+	// Floating rules are evaluated before interface rules, each in configuration order
+	sort.SliceStable(model.Policies, func(i, j int) bool {
+		return model.Policies[i].Floating && !model.Policies[j].Floating
+	})
+
 	// Add implicit default deny rule (OPNsense behavior)
 	defaultDeny := &types.SecurityPolicy{
 		ID:          "implicit-default-deny",
@@ -209,6 +245,9 @@ func (p *OPNsenseParser) parseFirewallRules(model *types.NetworkModel) error {
 		Enabled:     true,
 		Action:      "DENY",
 		Zone:        "all",
+		Direction:   "any",
+		IPFamily:    "inet46",
+		Quick:       true,
 		Source: types.NetworkRange{
 			CIDR: "any",
 			IPs:  []string{},
@@ -226,26 +265,239 @@ func (p *OPNsenseParser) parseFirewallRules(model *types.NetworkModel) error {
 	return nil
 }
 
-// parseAliases processes network aliases for grouping
+// parseAliases builds the alias table used to resolve rule targets and ports,
+// and names network segments after the network aliases that describe them
 func (p *OPNsenseParser) parseAliases(model *types.NetworkModel) error {
-	// OPNsense aliases help identify network groupings and can enhance our network segments
-	// Implementation plan:
-	// 1. Parse p.config.Aliases.Alias array
-	// 2. For each alias of type "network" or "host":
-	//    - Extract CIDR ranges or individual IPs
-	//    - Associate with existing network segments
-	//    - Create new segments if needed
-	// 3. For port aliases: enhance security policy port mappings
-	// 4. Update segment names and descriptions based on alias names
+	p.aliases = make(map[string]Alias)
+	if p.config == nil {
+		return nil
+	}
 
-	if p.config == nil || len(p.config.Aliases.Alias) == 0 {
-		return nil // No aliases to process
+	// Legacy <aliases> first, so that the alias model wins on name clashes
+	all := append(append([]Alias{}, p.config.Aliases.Alias...), p.config.ModelAliases...)
+	for _, alias := range all {
+		if alias.Name == "" || alias.Enabled == "0" {
+			continue
+		}
+		p.aliases[alias.Name] = alias
 	}
 
-	log.Printf("Found %d aliases in configuration (parsing not yet implemented)", len(p.config.Aliases.Alias))
+	unresolved := 0
+	for _, name := range sortedAliasNames(p.aliases) {
+		alias := p.aliases[name]
+		switch alias.Type {
+		case "host", "network", "networkgroup", "port":
+		default:
+			// urltable, url, geoip, dynipv6host, ... need external data
+			unresolved++
+			continue
+		}
+
+		if alias.Type == "network" || alias.Type == "networkgroup" {
+			p.nameSegmentFromAlias(model, alias)
+		}
+	}
+
+	if unresolved > 0 {
+		log.Printf("OPNsense: %d aliases reference external data (urltable/geoip) and are kept by name", unresolved)
+	}
 	return nil
 }
 
+// nameSegmentFromAlias tags a segment whose subnet is exactly one of the alias networks with the alias name
+func (p *OPNsenseParser) nameSegmentFromAlias(model *types.NetworkModel, alias Alias) {
+	for _, entry := range p.expandAlias(alias.Name, map[string]bool{}) {
+		_, aliasNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			continue
+		}
+		for _, segment := range model.Networks {
+			_, segmentNet, err := net.ParseCIDR(segment.CIDR)
+			if err != nil || segmentNet.String() != aliasNet.String() {
+				continue
+			}
+			if segment.Name == "" {
+				segment.Name = alias.Name
+			} else if !strings.Contains(segment.Name, "("+alias.Name+")") {
+				segment.Name = fmt.Sprintf("%s (%s)", segment.Name, alias.Name)
+			}
+		}
+	}
+}
+
+// expandAlias resolves a host/network alias to its addresses, following nested
+// aliases. Aliases that cannot be resolved offline expand to nothing.
+func (p *OPNsenseParser) expandAlias(name string, seen map[string]bool) []string {
+	ips := []string{}
+	alias, ok := p.aliases[name]
+	if !ok || seen[name] {
+		return ips
+	}
+	seen[name] = true
+
+	switch alias.Type {
+	case "host", "network", "networkgroup":
+	default:
+		return ips
+	}
+
+	for _, entry := range alias.Entries() {
+		if _, nested := p.aliases[entry]; nested {
+			ips = append(ips, p.expandAlias(entry, seen)...)
+		} else {
+			ips = append(ips, entry)
+		}
+	}
+	return ips
+}
+
+// sortedAliasNames returns alias names in a stable order
+func sortedAliasNames(aliases map[string]Alias) []string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseNAT converts port forwards, 1:1 mappings and manual outbound rules into NAT entries
+func (p *OPNsenseParser) parseNAT(model *types.NetworkModel) error {
+	nat := p.config.NAT
+
+	for i, rule := range nat.Rules {
+		id := rule.UUID
+		if id == "" {
+			id = fmt.Sprintf("nat-%d", i+1)
+		}
+
+		entry := &types.NATRule{
+			ID:          id,
+			Type:        types.PortForwardNAT,
+			Interface:   rule.Interface,
+			Protocol:    types.Protocol(rule.Protocol),
+			Source:      p.parseRuleTarget(rule.Source, model),
+			Destination: p.parseRuleTarget(rule.Destination, model),
+			Ports:       p.parsePortString(rule.Destination.Port, "destination", rule.Protocol),
+			Description: rule.Descr,
+			Enabled:     rule.Disabled != "1",
+		}
+
+		if rule.NoRDR == "1" {
+			// "No RDR" excludes matching traffic from redirection
+			entry.Description = strings.TrimSpace(entry.Description + " [no redirect]")
+		} else {
+			entry.TranslatedDestination = p.resolveNATAddress(rule.Target, model)
+			entry.TranslatedPorts = p.translatePorts(entry.Ports, rule.LocalPort, rule.Protocol)
+		}
+
+		model.NATRules = append(model.NATRules, entry)
+	}
+
+	for i, rule := range nat.OneToOne {
+		id := rule.UUID
+		if id == "" {
+			id = fmt.Sprintf("onetoone-%d", i+1)
+		}
+
+		external := rule.External
+		if rule.Subnet != "" && rule.Subnet != "32" && !strings.Contains(external, "/") {
+			external = fmt.Sprintf("%s/%s", external, rule.Subnet)
+		}
+
+		// Modelled in the inbound direction: peer -> external is translated to internal
+		model.NATRules = append(model.NATRules, &types.NATRule{
+			ID:                    id,
+			Type:                  types.OneToOneNAT,
+			Interface:             rule.Interface,
+			Protocol:              "any",
+			Source:                p.parseRuleTarget(rule.Destination, model),
+			Destination:           types.NetworkRange{CIDR: external, IPs: []string{external}},
+			TranslatedSource:      types.NetworkRange{CIDR: external, IPs: []string{external}},
+			TranslatedDestination: p.parseRuleTarget(rule.Source, model),
+			Description:           rule.Descr,
+			Enabled:               rule.Disabled != "1",
+		})
+	}
+
+	// Manual outbound rules only apply in hybrid and advanced mode
+	manualOutbound := nat.Outbound.Mode == "hybrid" || nat.Outbound.Mode == "advanced"
+	for i, rule := range nat.Outbound.Rules {
+		id := rule.UUID
+		if id == "" {
+			id = fmt.Sprintf("outbound-%d", i+1)
+		}
+
+		entry := &types.NATRule{
+			ID:          id,
+			Type:        types.OutboundNAT,
+			Interface:   rule.Interface,
+			Protocol:    types.Protocol(rule.Protocol),
+			Source:      p.parseRuleTarget(rule.Source, model),
+			Destination: p.parseRuleTarget(rule.Destination, model),
+			Ports:       p.parsePortString(rule.Destination.Port, "destination", rule.Protocol),
+			Description: rule.Descr,
+			Enabled:     rule.Disabled != "1" && manualOutbound,
+		}
+
+		if rule.NoNAT == "1" {
+			entry.Description = strings.TrimSpace(entry.Description + " [no NAT]")
+		} else {
+			target := rule.Target
+			if target == "" {
+				target = rule.Interface + "ip" // Interface address
+			} else if rule.TargetSubnet != "" && rule.TargetSubnet != "32" && net.ParseIP(target) != nil {
+				target = fmt.Sprintf("%s/%s", target, rule.TargetSubnet)
+			}
+			entry.TranslatedSource = p.resolveNATAddress(target, model)
+			entry.TranslatedPorts = p.parsePortString(rule.NATPort, "source", rule.Protocol)
+		}
+
+		model.NATRules = append(model.NATRules, entry)
+	}
+
+	if len(model.NATRules) > 0 {
+		log.Printf("OPNsense: parsed %d NAT rules (%d port forwards, %d 1:1, %d outbound)",
+			len(model.NATRules), len(nat.Rules), len(nat.OneToOne), len(nat.Outbound.Rules))
+	}
+	return nil
+}
+
+// resolveNATAddress resolves a NAT target (address, alias or interface reference)
+func (p *OPNsenseParser) resolveNATAddress(target string, model *types.NetworkModel) types.NetworkRange {
+	if target == "" {
+		return types.NetworkRange{IPs: []string{}}
+	}
+	return types.NetworkRange{
+		CIDR: target,
+		IPs:  p.resolveNetworkRef(target, model),
+	}
+}
+
+// translatePorts maps the original destination ports onto the redirect target port.
+// OPNsense stores only the first local port; ranges keep their original width.
+func (p *OPNsenseParser) translatePorts(original []types.Port, localPort, protocol string) []types.Port {
+	if localPort == "" {
+		return original
+	}
+
+	translated := p.parsePortString(localPort, "destination", protocol)
+	if len(original) != 1 || len(translated) != 1 || translated[0].Number == 0 {
+		return translated
+	}
+
+	if _, span, ok := strings.Cut(original[0].Protocol, ":"); ok {
+		if low, high, isRange := strings.Cut(span, "-"); isRange {
+			width := parsePortNumber(high) - parsePortNumber(low)
+			start := int(translated[0].Number)
+			if width > 0 && start+width <= 65535 {
+				translated[0].Protocol = fmt.Sprintf("%s:%d-%d", protocol, start, start+width)
+			}
+		}
+	}
+	return translated
+}
+
 // inferZones assigns IEC 62443 zones based on network analysis
 func (p *OPNsenseParser) inferZones(model *types.NetworkModel) {
 	for _, segment := range model.Networks {
@@ -273,23 +525,67 @@ func (p *OPNsenseParser) buildCIDR(ip, subnet string) string {
 	return fmt.Sprintf("%s/%s", ip, subnet)
 }
 
-func (p *OPNsenseParser) parseRuleTarget(target RuleTarget) types.NetworkRange {
-	if target.Any == "1" {
-		return types.NetworkRange{
-			CIDR: "any",
-			IPs:  []string{},
-		}
+func (p *OPNsenseParser) parseRuleTarget(target RuleTarget, model *types.NetworkModel) types.NetworkRange {
+	negate := ""
+	if target.Not == "1" {
+		negate = "!"
 	}
 
-	if target.Network != "" {
+	switch {
+	case target.Network != "":
 		// Handle network references like "lan", "wan", "opt5ip", etc.
 		return types.NetworkRange{
-			CIDR: target.Network,
-			IPs:  []string{},
+			CIDR: negate + target.Network,
+			IPs:  p.resolveNetworkRef(target.Network, model),
+		}
+	case target.Address != "":
+		// Hosts, CIDRs and alias names, possibly several comma separated
+		ips := []string{}
+		for _, entry := range strings.FieldsFunc(target.Address, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if _, ok := p.aliases[entry]; ok {
+				ips = append(ips, p.expandAlias(entry, map[string]bool{})...)
+			} else {
+				ips = append(ips, entry)
+			}
+		}
+		return types.NetworkRange{
+			CIDR: negate + target.Address,
+			IPs:  ips,
+		}
+	}
+
+	// <any/> (often written as an empty <any></any>) or no target at all
+	return types.NetworkRange{
+		CIDR: negate + "any",
+		IPs:  []string{},
+	}
+}
+
+// resolveNetworkRef maps "lan"/"opt1" to the interface subnet and "lanip" to its address.
+// NAT rules may also put literal CIDRs or aliases in <network>.
+func (p *OPNsenseParser) resolveNetworkRef(ref string, model *types.NetworkModel) []string {
+	if _, _, err := net.ParseCIDR(ref); err == nil || net.ParseIP(ref) != nil {
+		return []string{ref}
+	}
+	if _, ok := p.aliases[ref]; ok {
+		return p.expandAlias(ref, map[string]bool{})
+	}
+
+	if segment, ok := model.Networks[ref]; ok && segment.CIDR != "" {
+		if _, network, err := net.ParseCIDR(segment.CIDR); err == nil {
+			return []string{network.String()}
 		}
 	}
 
-	return types.NetworkRange{}
+	if name, ok := strings.CutSuffix(ref, "ip"); ok {
+		if iface, exists := p.config.Interfaces.GetAllInterfaces()[name]; exists && iface != nil {
+			if net.ParseIP(iface.IPAddr) != nil {
+				return []string{iface.IPAddr}
+			}
+		}
+	}
+
+	return []string{}
 }
 
 func (p *OPNsenseParser) inferZoneFromInterface(name string, iface *Interface) types.IEC62443Zone {
@@ -338,23 +634,6 @@ func (p *OPNsenseParser) inferZoneFromInterface(name string, iface *Interface) t
 	}
 }
 
-// parsePorts parses port specifications from OPNsense rules
-func (p *OPNsenseParser) parsePorts(srcPort, dstPort, protocol string) []types.Port {
-	var ports []types.Port
-
-	// Parse destination ports (most common)
-	if dstPort != "" {
-		ports = append(ports, p.parsePortString(dstPort, "destination", protocol)...)
-	}
-
-	// Parse source ports (less common)
-	if srcPort != "" {
-		ports = append(ports, p.parsePortString(srcPort, "source", protocol)...)
-	}
-
-	return ports
-}
-
 // parsePortString parses a port string like "22,443" or "8080-8090" or "OT_MGMT_PORTS"
 func (p *OPNsenseParser) parsePortString(portStr, direction, protocol string) []types.Port {
 	var ports []types.Port
@@ -363,28 +642,19 @@ func (p *OPNsenseParser) parsePortString(portStr, direction, protocol string) []
 		return ports
 	}
 
-	// Handle aliases (like "OT_MGMT_PORTS", "VENDOR_VPN_PORTS")
-	if !strings.Contains(portStr, ",") && !strings.Contains(portStr, "-") && !isNumeric(portStr) {
-		// This is likely an alias - resolve it if possible, otherwise use as-is
-		resolvedPorts := p.resolvePortAlias(portStr)
-		if resolvedPorts != "" {
-			portStr = resolvedPorts
-		} else {
-			// Keep the alias name for display - use port 0 with protocol containing the alias
-			ports = append(ports, types.Port{
-				Number:   0,                                       // Unknown port number
-				Protocol: fmt.Sprintf("%s:%s", protocol, portStr), // Include alias in protocol
-			})
-			return ports
-		}
-	}
-
-	// Split by commas for multiple ports
-	portParts := strings.Split(portStr, ",")
-	for _, part := range portParts {
+	// Split by commas for multiple ports; each part may itself be a port alias
+	for _, part := range p.expandPortAlias(portStr, map[string]bool{}) {
 		part = strings.TrimSpace(part)
+		// Port aliases write ranges as "1000:2000", rules as "1000-2000"
+		part = strings.Replace(part, ":", "-", 1)
 
-		if strings.Contains(part, "-") {
+		if !strings.Contains(part, "-") && !isNumeric(part) {
+			// Unresolvable alias - keep the alias name for display - use port 0 with protocol containing the alias
+			ports = append(ports, types.Port{
+				Number:   0,                                    // Unknown port number
+				Protocol: fmt.Sprintf("%s:%s", protocol, part), // Include alias in protocol
+			})
+		} else if strings.Contains(part, "-") {
 			// Handle port ranges like "8080-8090"
 			rangeParts := strings.Split(part, "-")
 			if len(rangeParts) == 2 {
@@ -416,15 +686,41 @@ func (p *OPNsenseParser) parsePortString(portStr, direction, protocol string) []
 	return ports
 }
 
-// resolvePortAlias tries to resolve port aliases from the configuration
-func (p *OPNsenseParser) resolvePortAlias(aliasName string) string {
-	// Look through aliases to find port type aliases
-	for _, alias := range p.config.Aliases.Alias {
-		if alias.Name == aliasName && alias.Type == "port" {
-			return alias.Address
+// expandPortAlias splits a port specification and resolves (nested) port aliases
+func (p *OPNsenseParser) expandPortAlias(portStr string, seen map[string]bool) []string {
+	var parts []string
+	for _, part := range strings.FieldsFunc(portStr, func(r rune) bool { return r == ',' || r == ' ' }) {
+		alias, ok := p.aliases[part]
+		if !ok || alias.Type != "port" || seen[part] {
+			parts = append(parts, part)
+			continue
 		}
+		seen[part] = true
+		for _, entry := range alias.Entries() {
+			parts = append(parts, p.expandPortAlias(entry, seen)...)
+		}
+	}
+	return parts
+}
+
+// ruleDirection normalises <direction>; interface rules default to inbound, floating rules to both
+func ruleDirection(direction string, floating bool) string {
+	switch direction {
+	case "in", "out", "any":
+		return direction
+	}
+	if floating {
+		return "any"
+	}
+	return "in"
+}
+
+// ruleFamily normalises <ipprotocol>; OPNsense defaults to IPv4
+func ruleFamily(ipProtocol string) string {
+	if ipProtocol == "" {
+		return "inet"
 	}
-	return ""
+	return ipProtocol
 }
 
 // isNumeric checks if a string contains only digits
@@ -1,5 +1,7 @@
 package opnsense
 
+import "strings"
+
 // OPNsenseConfig represents the root structure of an OPNsense configuration XML
 type OPNsenseConfig struct {
 	XMLName    struct{}   `xml:"opnsense"`
@@ -7,6 +9,10 @@ type OPNsenseConfig struct {
 	Filter     Filter     `xml:"filter"`
 	System     System     `xml:"system"`
 	Aliases    Aliases    `xml:"aliases"`
+	NAT        NAT        `xml:"nat"`
+
+	// Aliases managed by the MVC alias model (OPNsense 20.1+)
+	ModelAliases []Alias `xml:"OPNsense>Firewall>Alias>aliases>alias"`
 }
 
 // Interfaces contains all network interface configurations
@@ -49,6 +55,8 @@ type Filter struct {
 // Rule represents a single firewall rule with UUID
 type Rule struct {
 	UUID        string      `xml:"uuid,attr"`   // Rule UUID
+	Disabled    string      `xml:"disabled"`    // 1 for disabled rules
+	Log         string      `xml:"log"`         // 1 when matches are logged
 	Type        string      `xml:"type"`        // pass, block, reject
 	Interface   string      `xml:"interface"`   // Interface name
 	IPProtocol  string      `xml:"ipprotocol"`  // inet, inet6
@@ -72,9 +80,70 @@ type Rule struct {
 type RuleTarget struct {
 	Any     string `xml:"any"`     // 1 for "any"
 	Network string `xml:"network"` // Network identifier (lan, wan, opt1, opt5ip, etc.)
+	Address string `xml:"address"` // Host, CIDR or alias name(s), comma separated
+	Port    string `xml:"port"`    // Port, range or port alias (legacy layout)
 	Not     string `xml:"not"`     // 1 for negation
 }
 
+// NAT contains port forward, 1:1 and outbound NAT rules
+type NAT struct {
+	Rules    []NATRule      `xml:"rule"`     // Port forwards
+	OneToOne []OneToOneRule `xml:"onetoone"` // 1:1 (BINAT) mappings
+	Outbound OutboundNAT    `xml:"outbound"`
+}
+
+// NATRule represents a port forward (destination NAT) rule
+type NATRule struct {
+	UUID             string     `xml:"uuid,attr"`
+	Disabled         string     `xml:"disabled"`           // 1 when disabled
+	NoRDR            string     `xml:"nordr"`              // 1 for "no redirect" exceptions
+	Interface        string     `xml:"interface"`          // Interface(s), comma separated
+	IPProtocol       string     `xml:"ipprotocol"`         // inet, inet6
+	Protocol         string     `xml:"protocol"`           // tcp, udp, tcp/udp
+	Source           RuleTarget `xml:"source"`             // Original source
+	Destination      RuleTarget `xml:"destination"`        // Original destination and port
+	Target           string     `xml:"target"`             // Internal (redirect) address or alias
+	LocalPort        string     `xml:"local-port"`         // Internal port (start of range)
+	Descr            string     `xml:"descr"`              // Description
+	AssociatedRuleID string     `xml:"associated-rule-id"` // Linked filter rule
+}
+
+// OneToOneRule represents a 1:1 NAT mapping between an external and internal address
+type OneToOneRule struct {
+	UUID        string     `xml:"uuid,attr"`
+	Disabled    string     `xml:"disabled"`
+	Interface   string     `xml:"interface"`
+	Type        string     `xml:"type"`        // binat or nat
+	External    string     `xml:"external"`    // External address
+	Subnet      string     `xml:"subnet"`      // Prefix length applied to both sides
+	Source      RuleTarget `xml:"source"`      // Internal address
+	Destination RuleTarget `xml:"destination"` // Optional destination restriction
+	Descr       string     `xml:"descr"`
+}
+
+// OutboundNAT contains outbound (source) NAT settings
+type OutboundNAT struct {
+	Mode  string            `xml:"mode"` // automatic, hybrid, advanced, disabled
+	Rules []OutboundNATRule `xml:"rule"`
+}
+
+// OutboundNATRule represents a manual outbound NAT rule
+type OutboundNATRule struct {
+	UUID          string     `xml:"uuid,attr"`
+	Disabled      string     `xml:"disabled"`
+	NoNAT         string     `xml:"nonat"`     // 1 to exclude matching traffic from NAT
+	Interface     string     `xml:"interface"` // Egress interface
+	IPProtocol    string     `xml:"ipprotocol"`
+	Protocol      string     `xml:"protocol"`
+	Source        RuleTarget `xml:"source"`
+	Destination   RuleTarget `xml:"destination"`
+	Target        string     `xml:"target"`          // Translation address; empty means interface address
+	TargetSubnet  string     `xml:"targetip_subnet"` // Prefix length of the translation address
+	NATPort       string     `xml:"natport"`         // Translated source port
+	StaticNATPort string     `xml:"staticnatport"`
+	Descr         string     `xml:"descr"`
+}
+
 // RuleHistory tracks rule modification history
 type RuleHistory struct {
 	Username    string `xml:"username"`
@@ -97,10 +166,25 @@ type Aliases struct {
 
 // Alias represents a single alias definition
 type Alias struct {
-	Name    string `xml:"name"`
-	Type    string `xml:"type"`    // network, host, port
-	Address string `xml:"address"` // The actual value(s)
-	Descr   string `xml:"descr"`   // Description
+	UUID        string `xml:"uuid,attr"`
+	Enabled     string `xml:"enabled"` // "0" when disabled (alias model only)
+	Name        string `xml:"name"`
+	Type        string `xml:"type"`        // host, network, port, networkgroup, urltable, geoip, ...
+	Address     string `xml:"address"`     // The actual value(s), legacy layout
+	Content     string `xml:"content"`     // The actual value(s), alias model layout (newline separated)
+	Descr       string `xml:"descr"`       // Description
+	Description string `xml:"description"` // Description, alias model layout
+}
+
+// Entries returns the alias values regardless of layout or separator
+func (a Alias) Entries() []string {
+	value := a.Address
+	if a.Content != "" {
+		value = a.Content
+	}
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
 }
 
 // Helper method to get all interfaces as a slice for easier iteration
@@ -0,0 +1,483 @@
+package pfsense
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cipgram/pkg/types"
+)
+
+// PfSenseParser implements FirewallParser for pfSense configurations
+type PfSenseParser struct {
+	configPath string
+	config     *PfSenseConfig
+	aliases    map[string]Alias
+}
+
+// NewPfSenseParser creates a new pfSense configuration parser
+func NewPfSenseParser(configPath string) *PfSenseParser {
+	return &PfSenseParser{
+		configPath: configPath,
+	}
+}
+
+// Parse implements FirewallParser.Parse for pfSense configs
+func (p *PfSenseParser) Parse() (*types.NetworkModel, error) {
+	if err := p.loadConfig(); err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	model := &types.NetworkModel{
+		Assets:   make(map[string]*types.Asset),
+		Networks: make(map[string]*types.NetworkSegment),
+		Flows:    make(map[types.FlowKey]*types.Flow),
+		Policies: []*types.SecurityPolicy{},
+		Metadata: p.GetMetadata(),
+	}
+
+	// Parse network interfaces and create segments
+	p.parseInterfaces(model)
+
+	// Parse filter rules and create policies
+	p.parseFirewallRules(model)
+
+	p.assessRisk(model)
+
+	return model, nil
+}
+
+// GetType implements FirewallParser.GetType
+func (p *PfSenseParser) GetType() types.InputType {
+	return types.InputTypePfSense
+}
+
+// GetMetadata implements FirewallParser.GetMetadata
+func (p *PfSenseParser) GetMetadata() types.InputMetadata {
+	info, _ := os.Stat(p.configPath)
+	size := int64(0)
+	modTime := time.Now()
+
+	if info != nil {
+		size = info.Size()
+		modTime = info.ModTime()
+	}
+
+	return types.InputMetadata{
+		Source:    p.configPath,
+		Type:      types.InputTypePfSense,
+		Timestamp: modTime,
+		Size:      size,
+		Hash:      calculateFileHash(p.configPath),
+	}
+}
+
+// Validate implements FirewallParser.Validate
+func (p *PfSenseParser) Validate() error {
+	if _, err := os.Stat(p.configPath); os.IsNotExist(err) {
+		return fmt.Errorf("config file not found: %s", p.configPath)
+	}
+
+	if err := p.loadConfig(); err != nil {
+		return fmt.Errorf("invalid pfSense configuration: %v", err)
+	}
+
+	return nil
+}
+
+// GetConfig returns the parsed pfSense configuration (nil until Parse or Validate succeeds)
+func (p *PfSenseParser) GetConfig() *PfSenseConfig {
+	return p.config
+}
+
+// loadConfig loads and parses the pfSense XML configuration file
+func (p *PfSenseParser) loadConfig() error {
+	data, err := os.ReadFile(p.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %v", err)
+	}
+
+	var config PfSenseConfig
+	if err := xml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("cannot parse XML config: %v", err)
+	}
+
+	p.config = &config
+	p.aliases = make(map[string]Alias)
+	for _, alias := range config.Aliases.Alias {
+		p.aliases[alias.Name] = alias
+	}
+	return nil
+}
+
+// parseInterfaces creates network segments from pfSense interfaces
+func (p *PfSenseParser) parseInterfaces(model *types.NetworkModel) {
+	for _, iface := range p.config.Interfaces.Interface {
+		name := iface.Name()
+
+		// pfSense only writes <enable/> for enabled interfaces
+		if !iface.Enabled() || name == "lo0" {
+			continue
+		}
+
+		descr := iface.Descr
+		if descr == "" {
+			descr = strings.ToUpper(name)
+		}
+
+		segment := &types.NetworkSegment{
+			ID:       name,
+			Name:     descr,
+			Assets:   []*types.Asset{},
+			Policies: []*types.SecurityPolicy{},
+			Purpose:  inferPurpose(descr, name),
+			Zone:     inferZoneFromInterface(name, descr),
+		}
+
+		// "dhcp", "pppoe" etc. leave the CIDR unknown
+		if iface.IPAddr != "" && iface.Subnet != "" && isNumeric(iface.Subnet) {
+			segment.CIDR = fmt.Sprintf("%s/%s", iface.IPAddr, iface.Subnet)
+		}
+
+		model.Networks[segment.ID] = segment
+	}
+}
+
+// parseFirewallRules creates security policies from filter rules, followed by
+// pfSense's built-in default deny
+func (p *PfSenseParser) parseFirewallRules(model *types.NetworkModel) {
+	for i, rule := range p.config.Filter.Rules {
+		// The tracker is what the pfSense firewall log reports for a rule
+		ruleID := rule.Tracker
+		if ruleID == "" {
+			ruleID = rule.UUID
+		}
+		if ruleID == "" {
+			ruleID = fmt.Sprintf("rule-%d", i+1)
+		}
+
+		description := rule.Descr
+		if rule.Floating == "yes" {
+			description = fmt.Sprintf("%s [floating, %s]", description, floatingDirection(rule.Direction))
+		}
+		if rule.AssociatedRuleID != "" {
+			description = fmt.Sprintf("%s [NAT %s]", description, rule.AssociatedRuleID)
+		}
+		if rule.Log != nil {
+			description += " [logged]"
+		}
+
+		protocol := rule.Protocol
+		if protocol == "" {
+			protocol = "any"
+		}
+
+		policy := &types.SecurityPolicy{
+			ID:          ruleID,
+			Description: strings.TrimSpace(description),
+			Enabled:     rule.Disabled == nil,
+			Action:      mapRuleAction(rule.Type),
+			Zone:        rule.Interface,
+			Source:      p.parseRuleTarget(rule.Source),
+			Destination: p.parseRuleTarget(rule.Destination),
+			Protocol:    types.Protocol(protocol),
+			Direction:   "in",
+			IPFamily:    rule.IPProtocol,
+			Floating:    rule.Floating == "yes",
+			Quick:       rule.Floating != "yes" || rule.Quick == "yes",
+		}
+		if policy.Floating {
+			policy.Direction = floatingDirection(rule.Direction)
+		}
+		if policy.IPFamily == "" {
+			policy.IPFamily = "inet"
+		}
+
+		policy.Ports = p.parsePortString(rule.Destination.Port, protocol)
+		policy.SourcePorts = p.parsePortString(rule.Source.Port, protocol)
+
+		model.Policies = append(model.Policies, policy)
+		for _, ifName := range strings.Split(rule.Interface, ",") {
+			if segment, ok := model.Networks[ifName]; ok {
+				segment.Policies = append(segment.Policies, policy)
+			}
+		}
+	}
+
+	// Floating rules are evaluated before interface rules
+	sort.SliceStable(model.Policies, func(i, j int) bool {
+		return model.Policies[i].Floating && !model.Policies[j].Floating
+	})
+
+	// pfSense ends the ruleset with "Default deny rule IPv4/IPv6" (trackers
+	// 1000000103/1000000104), which blocks and logs unmatched inbound traffic
+	model.Policies = append(model.Policies, &types.SecurityPolicy{
+		ID:          "implicit-default-deny",
+		Description: "Default deny rule IPv4/IPv6 (built-in pfSense behavior)",
+		Enabled:     true,
+		Action:      types.Drop,
+		Zone:        "all",
+		Direction:   "in",
+		IPFamily:    "inet46",
+		Quick:       true,
+		Source: types.NetworkRange{
+			CIDR: "any",
+			IPs:  []string{},
+		},
+		Destination: types.NetworkRange{
+			CIDR: "any",
+			IPs:  []string{},
+		},
+		Protocol: "any",
+		Ports:    []types.Port{},
+	})
+}
+
+// parseRuleTarget converts a rule source/destination into a network range,
+// expanding host and network aliases
+func (p *PfSenseParser) parseRuleTarget(target RuleTarget) types.NetworkRange {
+	negate := ""
+	if target.Not != nil {
+		negate = "!"
+	}
+
+	switch {
+	case target.Any != nil:
+		return types.NetworkRange{CIDR: negate + "any", IPs: []string{}}
+	case target.Network != "":
+		// Interface references like "lan", "opt1", "wanip" or "(self)"
+		return types.NetworkRange{CIDR: negate + target.Network, IPs: []string{}}
+	case target.Address != "":
+		if _, ok := p.aliases[target.Address]; ok {
+			return types.NetworkRange{
+				CIDR: negate + target.Address,
+				IPs:  p.expandAlias(target.Address, map[string]bool{}),
+			}
+		}
+		return types.NetworkRange{CIDR: negate + target.Address, IPs: []string{target.Address}}
+	}
+
+	return types.NetworkRange{}
+}
+
+// expandAlias resolves a host/network alias to its addresses, following nested aliases
+func (p *PfSenseParser) expandAlias(name string, seen map[string]bool) []string {
+	ips := []string{}
+	alias, ok := p.aliases[name]
+	if !ok || seen[name] {
+		return ips
+	}
+	seen[name] = true
+
+	for _, entry := range strings.Fields(alias.Address) {
+		if _, nested := p.aliases[entry]; nested {
+			ips = append(ips, p.expandAlias(entry, seen)...)
+		} else {
+			ips = append(ips, entry)
+		}
+	}
+	return ips
+}
+
+// parsePortString parses a port like "502", "8000-8080" or a port alias name
+func (p *PfSenseParser) parsePortString(portStr, protocol string) []types.Port {
+	ports := []types.Port{}
+	if portStr == "" {
+		return ports
+	}
+
+	specs := []string{portStr}
+	if alias, ok := p.aliases[portStr]; ok && alias.Type == "port" {
+		specs = strings.Fields(alias.Address)
+	} else if !isNumeric(portStr) && !strings.ContainsAny(portStr, "-:") {
+		// Unknown alias - keep the name for display
+		return append(ports, types.Port{
+			Number:   0,
+			Protocol: fmt.Sprintf("%s:%s", protocol, portStr),
+		})
+	}
+
+	for _, spec := range specs {
+		// Rules write ranges as "1000-2000", aliases as "1000:2000"
+		spec = strings.Replace(spec, ":", "-", 1)
+
+		if low, high, ok := strings.Cut(spec, "-"); ok {
+			start, end := parsePortNumber(low), parsePortNumber(high)
+			if start > 0 && end >= start {
+				ports = append(ports, types.Port{
+					Number:   uint16(start),
+					Protocol: fmt.Sprintf("%s:%d-%d", protocol, start, end),
+				})
+			}
+			continue
+		}
+
+		if num := parsePortNumber(spec); num > 0 {
+			ports = append(ports, types.Port{
+				Number:   uint16(num),
+				Protocol: fmt.Sprintf("%s:%s", protocol, getPortName(num)),
+			})
+		}
+	}
+
+	return ports
+}
+
+// assessRisk evaluates risk levels for network segments
+func (p *PfSenseParser) assessRisk(model *types.NetworkModel) {
+	for _, segment := range model.Networks {
+		switch segment.Zone {
+		case types.IndustrialZone:
+			segment.Risk = types.HighRisk // Critical OT systems
+		case types.DMZZone:
+			segment.Risk = types.MediumRisk
+		default:
+			segment.Risk = types.LowRisk
+		}
+	}
+}
+
+// Helper functions
+
+func floatingDirection(direction string) string {
+	if direction == "" {
+		return "any"
+	}
+	return direction
+}
+
+func inferZoneFromInterface(name, descr string) types.IEC62443Zone {
+	lowerName := strings.ToLower(name)
+	lowerDescr := strings.ToLower(descr)
+	purpose := inferPurpose(descr, name)
+
+	switch {
+	case strings.Contains(lowerName, "wan") || strings.Contains(lowerDescr, "wan"):
+		return types.DMZZone // WAN typically goes to DMZ
+	case strings.Contains(lowerDescr, "vpn") || strings.Contains(lowerDescr, "wireguard") ||
+		strings.HasPrefix(lowerName, "ovpn") || strings.HasPrefix(lowerName, "tun_wg"):
+		return types.RemoteAccessZone
+	case purpose == "Production OT":
+		return types.IndustrialZone
+	case purpose == "DMZ":
+		return types.DMZZone
+	case lowerName == "lan":
+		return types.IndustrialZone // LAN often contains OT devices, matching OPNsense
+	default:
+		return types.EnterpriseZone
+	}
+}
+
+func inferPurpose(descr, ifName string) string {
+	lower := strings.ToLower(descr + " " + ifName)
+
+	switch {
+	case containsAny(lower, "production", "cell", "line", "plant", "factory", "manufacturing",
+		"industrial", "scada", "hmi", "plc", "control", "process", "automation", "field"):
+		return "Production OT"
+	case strings.Contains(lower, "dmz"):
+		return "DMZ"
+	case containsAny(lower, "management", "mgmt", "admin"):
+		return "Management"
+	case containsAny(lower, "wan", "internet"):
+		return "Internet"
+	case containsAny(lower, "corp", "office", "business", "enterprise"):
+		return "Corporate IT"
+	default:
+		return "General"
+	}
+}
+
+func containsAny(s string, substrings ...string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func mapRuleAction(ruleType string) types.RuleAction {
+	switch strings.ToLower(ruleType) {
+	case "pass":
+		return types.Allow
+	case "block":
+		return types.Drop // pfSense "block" silently drops
+	case "reject":
+		return types.Reject
+	default:
+		return types.Deny
+	}
+}
+
+// isNumeric checks if a string contains only digits
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// parsePortNumber converts a string to a port number, returning 0 when invalid
+func parsePortNumber(s string) int {
+	num, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || num < 0 || num > 65535 {
+		return 0
+	}
+	return num
+}
+
+// getPortName returns common service names for well-known ports
+func getPortName(port int) string {
+	wellKnownPorts := map[int]string{
+		22:    "SSH",
+		23:    "Telnet",
+		53:    "DNS",
+		80:    "HTTP",
+		102:   "S7comm",
+		123:   "NTP",
+		443:   "HTTPS",
+		502:   "Modbus",
+		1194:  "OpenVPN",
+		1883:  "MQTT",
+		3389:  "RDP",
+		4840:  "OPC-UA",
+		8883:  "MQTTS",
+		20000: "DNP3",
+		44818: "ENIP",
+	}
+
+	if name, exists := wellKnownPorts[port]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("Port-%d", port)
+}
+
+// calculateFileHash computes SHA256 hash of a file for integrity checking
+func calculateFileHash(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
@@ -0,0 +1,83 @@
+package pfsense
+
+import (
+	"encoding/xml"
+
+	"cipgram/pkg/firewall/parsers/opnsense"
+)
+
+// PfSenseConfig represents the root structure of a pfSense config.xml.
+// The layout follows OPNsense (which forked from pfSense), so the shared
+// elements reuse the opnsense types; the differences are modelled here.
+type PfSenseConfig struct {
+	XMLName    struct{}        `xml:"pfsense"`
+	Version    string          `xml:"version"`
+	System     opnsense.System `xml:"system"`
+	Interfaces Interfaces      `xml:"interfaces"`
+	Filter     Filter          `xml:"filter"`
+	Aliases    Aliases         `xml:"aliases"`
+}
+
+// Interfaces holds every interface element (wan, lan, opt1 ... optN) in config order.
+// pfSense does not cap the number of OPT interfaces, so they are collected generically.
+type Interfaces struct {
+	Interface []Interface `xml:",any"`
+}
+
+// Interface is an OPNsense-style interface whose <enable/> flag is an empty
+// element: present means enabled, absent means disabled
+type Interface struct {
+	XMLName xml.Name
+	opnsense.Interface
+	Enable *string `xml:"enable"`
+}
+
+// Name returns the pfSense interface identifier (wan, lan, opt1, ...)
+func (i Interface) Name() string {
+	return i.XMLName.Local
+}
+
+// Enabled reports whether the <enable/> flag is present
+func (i Interface) Enabled() bool {
+	return i.Enable != nil
+}
+
+// Filter contains the filter rules in evaluation order
+type Filter struct {
+	Rules []Rule `xml:"rule"`
+}
+
+// Rule is an OPNsense-style rule with the pfSense-specific fields. pfSense
+// stores ports inside the source/destination elements and uses empty
+// elements for the <disabled/>, <log/>, <any/> and <not/> flags.
+type Rule struct {
+	opnsense.Rule
+	ID               string     `xml:"id"`
+	Tracker          string     `xml:"tracker"`            // Stable rule identifier shown in the firewall log
+	Disabled         *string    `xml:"disabled"`           // Present when the rule is disabled
+	Log              *string    `xml:"log"`                // Present when matches are logged
+	AssociatedRuleID string     `xml:"associated-rule-id"` // NAT port forward that created this rule
+	Source           RuleTarget `xml:"source"`
+	Destination      RuleTarget `xml:"destination"`
+}
+
+// RuleTarget represents source or destination in a pfSense filter rule
+type RuleTarget struct {
+	Any     *string `xml:"any"`     // Present for "any"
+	Network string  `xml:"network"` // Interface network reference (lan, opt1, lanip, (self), ...)
+	Address string  `xml:"address"` // Host, CIDR or alias name
+	Port    string  `xml:"port"`    // Port, range (1000-2000) or port alias
+	Not     *string `xml:"not"`     // Present for negation
+}
+
+// Aliases contains network, host, and port aliases
+type Aliases struct {
+	Alias []Alias `xml:"alias"`
+}
+
+// Alias is an OPNsense-style alias. pfSense separates addresses with spaces,
+// writes port ranges as "1000:2000" and keeps per-entry descriptions in Detail.
+type Alias struct {
+	opnsense.Alias
+	Detail string `xml:"detail"` // Entry descriptions separated by "||"
+}
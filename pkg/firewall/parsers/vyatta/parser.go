@@ -1,9 +1,18 @@
 package vyatta
 
 import (
-	"cipgram/pkg/types"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"cipgram/pkg/types"
 )
 
 // VyattaParser implements FirewallParser for Vyatta/VyOS configurations
@@ -21,9 +30,34 @@ func NewVyattaParser(configPath string) *VyattaParser {
 
 // Parse implements FirewallParser.Parse for Vyatta/VyOS configs
 func (p *VyattaParser) Parse() (*types.NetworkModel, error) {
-	// TODO: Implement Vyatta/VyOS configuration parsing
-	// Vyatta uses a hierarchical configuration format similar to Juniper
-	return nil, fmt.Errorf("Vyatta/VyOS parser not yet implemented")
+	if err := p.loadConfig(); err != nil {
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	model := &types.NetworkModel{
+		Assets:   make(map[string]*types.Asset),
+		Networks: make(map[string]*types.NetworkSegment),
+		Flows:    make(map[types.FlowKey]*types.Flow),
+		Policies: []*types.SecurityPolicy{},
+		Metadata: p.GetMetadata(),
+	}
+
+	// Interfaces become network segments
+	p.parseInterfaces(model)
+
+	// Rulesets bound to interfaces become interface-scoped policies
+	p.parseInterfaceFirewalls(model)
+
+	// Zone-policy rulesets become policies between zone pairs
+	p.parseZonePolicies(model)
+
+	if routes := len(p.config.Protocols.Static.Route); routes > 0 {
+		log.Printf("VyOS %s: %d static routes", p.config.System.HostName, routes)
+	}
+
+	p.assessRisk(model)
+
+	return model, nil
 }
 
 // GetType implements FirewallParser.GetType
@@ -33,132 +67,735 @@ func (p *VyattaParser) GetType() types.InputType {
 
 // GetMetadata implements FirewallParser.GetMetadata
 func (p *VyattaParser) GetMetadata() types.InputMetadata {
+	info, _ := os.Stat(p.configPath)
+	size := int64(0)
+	modTime := time.Now()
+
+	if info != nil {
+		size = info.Size()
+		modTime = info.ModTime()
+	}
+
 	return types.InputMetadata{
 		Source:    p.configPath,
 		Type:      types.InputTypeVyatta,
-		Timestamp: time.Now(),
-		Size:      0, // TODO: Get actual file size
+		Timestamp: modTime,
+		Size:      size,
+		Hash:      calculateFileHash(p.configPath),
 	}
 }
 
 // Validate implements FirewallParser.Validate
 func (p *VyattaParser) Validate() error {
-	// TODO: Implement Vyatta/VyOS config validation
-	return fmt.Errorf("Vyatta/VyOS validation not yet implemented")
+	if _, err := os.Stat(p.configPath); os.IsNotExist(err) {
+		return fmt.Errorf("config file not found: %s", p.configPath)
+	}
+
+	if err := p.loadConfig(); err != nil {
+		return fmt.Errorf("invalid Vyatta/VyOS configuration: %v", err)
+	}
+
+	if len(p.config.Interfaces) == 0 {
+		return fmt.Errorf("invalid Vyatta/VyOS configuration: no interfaces found")
+	}
+
+	return nil
+}
+
+// GetConfig returns the parsed Vyatta/VyOS configuration (nil until Parse or Validate succeeds)
+func (p *VyattaParser) GetConfig() *VyattaConfig {
+	return p.config
+}
+
+// loadConfig reads config.boot or "set" command output
+func (p *VyattaParser) loadConfig() error {
+	file, err := os.Open(p.configPath)
+	if err != nil {
+		return fmt.Errorf("cannot open config file: %v", err)
+	}
+	defer file.Close()
+
+	root, err := readConfigTree(file)
+	if err != nil {
+		return fmt.Errorf("cannot parse config: %v", err)
+	}
+
+	config := &VyattaConfig{
+		System: VyattaSystem{
+			HostName:   root.value("system", "host-name"),
+			DomainName: root.value("system", "domain-name"),
+			TimeZone:   root.value("system", "time-zone"),
+			NameServer: root.keys("system", "name-server"),
+		},
+		Interfaces: make(map[string]VyattaInterface),
+		ZonePolicy: make(map[string]VyattaZone),
+	}
+
+	extractInterfaces(root, config)
+	extractFirewall(root, config)
+	extractZonePolicy(root, config)
+	extractProtocols(root, config)
+
+	p.config = config
+	return nil
+}
+
+func extractInterfaces(root *configNode, config *VyattaConfig) {
+	for _, ifType := range root.keys("interfaces") {
+		for _, name := range root.keys("interfaces", ifType) {
+			node := root.get("interfaces", ifType, name)
+			config.Interfaces[name] = buildInterface(name, ifType, node)
+
+			// VLAN sub-interfaces ("vif 100") are addressed like separate interfaces
+			for _, vif := range node.keys("vif") {
+				vifName := fmt.Sprintf("%s.%s", name, vif)
+				config.Interfaces[vifName] = buildInterface(vifName, "vif", node.get("vif", vif))
+			}
+		}
+	}
+}
+
+func buildInterface(name, ifType string, node *configNode) VyattaInterface {
+	return VyattaInterface{
+		Name:        name,
+		Type:        ifType,
+		Address:     node.keys("address"),
+		Description: node.value("description"),
+		Disabled:    node.has("disable"),
+		Firewall: VyattaInterfaceFirewall{
+			In:    node.value("firewall", "in", "name"),
+			Out:   node.value("firewall", "out", "name"),
+			Local: node.value("firewall", "local", "name"),
+		},
+	}
+}
+
+func extractFirewall(root *configNode, config *VyattaConfig) {
+	fw := root.get("firewall")
+
+	config.Firewall = VyattaFirewall{
+		Name:          make(map[string]VyattaRuleset),
+		NetworkGroups: make(map[string][]string),
+		AddressGroups: make(map[string][]string),
+		PortGroups:    make(map[string][]string),
+	}
+	if fw == nil {
+		return
+	}
+
+	for _, name := range fw.keys("group", "network-group") {
+		config.Firewall.NetworkGroups[name] = fw.keys("group", "network-group", name, "network")
+	}
+	for _, name := range fw.keys("group", "address-group") {
+		config.Firewall.AddressGroups[name] = fw.keys("group", "address-group", name, "address")
+	}
+	for _, name := range fw.keys("group", "port-group") {
+		config.Firewall.PortGroups[name] = fw.keys("group", "port-group", name, "port")
+	}
+
+	// IPv4 rulesets live under "name", IPv6 rulesets under "ipv6-name"
+	for _, section := range []string{"name", "ipv6-name"} {
+		for _, name := range fw.keys(section) {
+			node := fw.get(section, name)
+			ruleset := VyattaRuleset{
+				Description:   node.value("description"),
+				DefaultAction: node.value("default-action"),
+				Rules:         make(map[int]VyattaRule),
+			}
+			if ruleset.DefaultAction == "" {
+				ruleset.DefaultAction = "drop" // VyOS default
+			}
+
+			for _, num := range node.keys("rule") {
+				n, err := strconv.Atoi(num)
+				if err != nil {
+					continue
+				}
+				r := node.get("rule", num)
+				ruleset.Rules[n] = VyattaRule{
+					Action:      r.value("action"),
+					Description: r.value("description"),
+					Disabled:    r.has("disable"),
+					Source:      buildRuleAddress(r.get("source")),
+					Destination: buildRuleAddress(r.get("destination")),
+					Protocol:    r.value("protocol"),
+					State: VyattaRuleState{
+						Established: r.value("state", "established"),
+						Invalid:     r.value("state", "invalid"),
+						New:         r.value("state", "new"),
+						Related:     r.value("state", "related"),
+					},
+				}
+			}
+
+			config.Firewall.Name[name] = ruleset
+		}
+	}
+}
+
+func buildRuleAddress(node *configNode) VyattaRuleAddress {
+	if node == nil {
+		return VyattaRuleAddress{}
+	}
+
+	addr := VyattaRuleAddress{
+		Address:   node.value("address"),
+		Port:      node.value("port"),
+		PortGroup: node.value("group", "port-group"),
+	}
+	if group := node.value("group", "address-group"); group != "" {
+		addr.AddressGroup = group
+	} else {
+		addr.AddressGroup = node.value("group", "network-group")
+	}
+	return addr
+}
+
+func extractZonePolicy(root *configNode, config *VyattaConfig) {
+	for _, name := range root.keys("zone-policy", "zone") {
+		node := root.get("zone-policy", "zone", name)
+		zone := VyattaZone{
+			Name:          name,
+			Description:   node.value("description"),
+			DefaultAction: node.value("default-action"),
+			Interfaces:    node.keys("interface"),
+			LocalZone:     node.has("local-zone"),
+			From:          make(map[string]string),
+		}
+		if zone.DefaultAction == "" {
+			zone.DefaultAction = "drop"
+		}
+		for _, from := range node.keys("from") {
+			zone.From[from] = node.value("from", from, "firewall", "name")
+		}
+		config.ZonePolicy[name] = zone
+	}
+}
+
+func extractProtocols(root *configNode, config *VyattaConfig) {
+	config.Protocols.Static.Route = make(map[string]VyattaStaticRoute)
+	for _, prefix := range root.keys("protocols", "static", "route") {
+		config.Protocols.Static.Route[prefix] = VyattaStaticRoute{
+			NextHop: root.value("protocols", "static", "route", prefix, "next-hop"),
+		}
+	}
+
+	config.Protocols.OSPF.Area = make(map[string]VyattaOSPFArea)
+	for _, area := range root.keys("protocols", "ospf", "area") {
+		config.Protocols.OSPF.Area[area] = VyattaOSPFArea{
+			Network: root.keys("protocols", "ospf", "area", area, "network"),
+		}
+	}
+
+	config.Protocols.BGP.Neighbor = make(map[string]VyattaBGPNeighbor)
+	if asn := root.value("protocols", "bgp"); asn != "" {
+		config.Protocols.BGP.ASN, _ = strconv.Atoi(asn)
+		for _, neighbor := range root.keys("protocols", "bgp", asn, "neighbor") {
+			remoteAS, _ := strconv.Atoi(root.value("protocols", "bgp", asn, "neighbor", neighbor, "remote-as"))
+			config.Protocols.BGP.Neighbor[neighbor] = VyattaBGPNeighbor{RemoteAS: remoteAS}
+		}
+	}
 }
 
-// VyattaConfig represents the structure of a Vyatta/VyOS configuration
-// TODO: Define actual Vyatta configuration structures
-type VyattaConfig struct {
-	// Vyatta/VyOS configurations use hierarchical structure like:
-	// interfaces {
-	//     ethernet eth0 {
-	//         address 192.168.1.1/24
-	//         description "LAN interface"
-	//     }
-	// }
-	// firewall {
-	//     name LAN_to_WAN {
-	//         rule 10 {
-	//             action accept
-	//             source {
-	//                 address 192.168.1.0/24
-	//             }
-	//         }
-	//     }
-	// }
+// parseInterfaces creates network segments from statically addressed interfaces
+func (p *VyattaParser) parseInterfaces(model *types.NetworkModel) {
+	zoneOf := p.interfaceZones()
 
-	// Placeholder structures for future implementation
-	System     VyattaSystem
-	Interfaces map[string]VyattaInterface
-	Firewall   VyattaFirewall
-	Protocols  VyattaProtocols
+	for _, name := range sortedKeys(p.config.Interfaces) {
+		iface := p.config.Interfaces[name]
+		if iface.Disabled || iface.Type == "loopback" {
+			continue
+		}
+
+		// Prefer the first static IPv4 address, then any static address
+		cidr := ""
+		for _, addr := range iface.Address {
+			if strings.Contains(addr, "/") && !strings.Contains(addr, ":") {
+				cidr = addr
+				break
+			}
+		}
+		if cidr == "" {
+			for _, addr := range iface.Address {
+				if strings.Contains(addr, "/") {
+					cidr = addr
+					break
+				}
+			}
+		}
+		if cidr == "" && zoneOf[name] == "" && iface.Firewall == (VyattaInterfaceFirewall{}) {
+			continue // Unaddressed, unfiltered interface (e.g. bond member)
+		}
+
+		segmentName := iface.Description
+		if segmentName == "" {
+			segmentName = name
+		}
+
+		segment := &types.NetworkSegment{
+			ID:       name,
+			Name:     segmentName,
+			CIDR:     cidr,
+			Assets:   []*types.Asset{},
+			Policies: []*types.SecurityPolicy{},
+			Purpose:  inferPurpose(name + " " + iface.Description + " " + zoneOf[name]),
+		}
+
+		if zone := zoneOf[name]; zone != "" {
+			segment.Zone = p.zoneToIEC(p.config.ZonePolicy[zone])
+		} else {
+			segment.Zone = inferZone(iface.Type, name+" "+iface.Description)
+		}
+
+		model.Networks[name] = segment
+	}
 }
 
-type VyattaSystem struct {
-	HostName   string
-	DomainName string
-	TimeZone   string
-	NameServer []string
+// parseInterfaceFirewalls creates policies for rulesets bound in/out/local on an interface
+func (p *VyattaParser) parseInterfaceFirewalls(model *types.NetworkModel) {
+	for _, name := range sortedKeys(p.config.Interfaces) {
+		iface := p.config.Interfaces[name]
+
+		for _, binding := range []struct {
+			direction string
+			ruleset   string
+		}{
+			{"in", iface.Firewall.In},
+			{"out", iface.Firewall.Out},
+			{"local", iface.Firewall.Local},
+		} {
+			if binding.ruleset == "" {
+				continue
+			}
+			ruleset, ok := p.config.Firewall.Name[binding.ruleset]
+			if !ok {
+				log.Printf("Warning: interface %s references unknown firewall ruleset %s", name, binding.ruleset)
+				continue
+			}
+
+			idPrefix := fmt.Sprintf("%s-%s-%s", name, binding.direction, binding.ruleset)
+			policies := p.rulesetPolicies(ruleset, binding.ruleset, idPrefix, types.NetworkRange{}, types.NetworkRange{})
+
+			// Local rulesets filter traffic entering the interface for the router itself
+			for _, policy := range policies {
+				policy.Zone = name
+				policy.Direction = binding.direction
+				if binding.direction == "local" {
+					policy.Direction, policy.Local = "in", true
+				}
+			}
+
+			model.Policies = append(model.Policies, policies...)
+			if segment, ok := model.Networks[name]; ok {
+				segment.Policies = append(segment.Policies, policies...)
+			}
+		}
+	}
 }
 
-type VyattaInterface struct {
-	Type        string // ethernet, loopback, tunnel, etc.
-	Address     []string
-	Description string
-	Firewall    VyattaInterfaceFirewall
+// parseZonePolicies creates policies for each "zone X from Y firewall name Z" binding.
+// Rules without addresses are scoped to the interfaces of the source and destination zones.
+func (p *VyattaParser) parseZonePolicies(model *types.NetworkModel) {
+	for _, toName := range sortedKeys(p.config.ZonePolicy) {
+		to := p.config.ZonePolicy[toName]
+
+		for _, fromName := range sortedKeys(to.From) {
+			rulesetName := to.From[fromName]
+			ruleset, ok := p.config.Firewall.Name[rulesetName]
+			if !ok {
+				log.Printf("Warning: zone %s from %s references unknown firewall ruleset %s", toName, fromName, rulesetName)
+				continue
+			}
+
+			from := p.config.ZonePolicy[fromName]
+			idPrefix := fmt.Sprintf("zone-%s-%s-%s", fromName, toName, rulesetName)
+
+			policies := p.rulesetPolicies(ruleset, rulesetName, idPrefix,
+				p.zoneRange(from), p.zoneRange(to))
+
+			// Traffic enters through the source zone's interfaces and leaves through the
+			// destination zone's; pairs with the local zone filter the router's own traffic.
+			// Record the IEC 62443 conduit the zone pair represents.
+			fromIEC, toIEC := p.zoneToIEC(from), p.zoneToIEC(to)
+			for _, policy := range policies {
+				policy.Zone = strings.Join(from.Interfaces, ",")
+				policy.Direction = "in"
+				policy.EgressZone = strings.Join(to.Interfaces, ",")
+				policy.Local = from.LocalZone || to.LocalZone
+				policy.Description = fmt.Sprintf("%s [%s → %s]", policy.Description, fromIEC, toIEC)
+			}
+
+			model.Policies = append(model.Policies, policies...)
+			for _, iface := range to.Interfaces {
+				if segment, ok := model.Networks[iface]; ok {
+					segment.Policies = append(segment.Policies, policies...)
+				}
+			}
+		}
+	}
 }
 
-type VyattaInterfaceFirewall struct {
-	In    string // firewall ruleset name for inbound traffic
-	Out   string // firewall ruleset name for outbound traffic
-	Local string // firewall ruleset name for local traffic
+// rulesetPolicies converts a ruleset into ordered policies followed by its default action.
+// defaultSrc/defaultDst are used when a rule does not constrain the address. Callers bind
+// the policies to the interfaces the ruleset is attached to.
+func (p *VyattaParser) rulesetPolicies(ruleset VyattaRuleset, name, idPrefix string,
+	defaultSrc, defaultDst types.NetworkRange) []*types.SecurityPolicy {
+
+	var numbers []int
+	for num := range ruleset.Rules {
+		numbers = append(numbers, num)
+	}
+	sort.Ints(numbers)
+
+	anyRange := types.NetworkRange{CIDR: "any", IPs: []string{}}
+	if defaultSrc.CIDR == "" {
+		defaultSrc = anyRange
+	}
+	if defaultDst.CIDR == "" {
+		defaultDst = anyRange
+	}
+
+	var policies []*types.SecurityPolicy
+	for _, num := range numbers {
+		rule := ruleset.Rules[num]
+
+		description := rule.Description
+		if description == "" {
+			description = fmt.Sprintf("%s rule %d", name, num)
+		}
+		states := ruleStates(rule.State)
+		if len(states) > 0 {
+			description = fmt.Sprintf("%s [state %s]", description, strings.Join(states, ","))
+		}
+
+		protocol := mapProtocol(rule.Protocol)
+		dstPorts, negateDst := p.resolvePorts(rule.Destination, protocol)
+		srcPorts, negateSrc := p.resolvePorts(rule.Source, protocol)
+
+		policy := &types.SecurityPolicy{
+			ID:                fmt.Sprintf("%s-%d", idPrefix, num),
+			Source:            p.resolveAddress(rule.Source, defaultSrc),
+			Destination:       p.resolveAddress(rule.Destination, defaultDst),
+			Ports:             dstPorts,
+			SourcePorts:       srcPorts,
+			NegatePorts:       negateDst,
+			NegateSourcePorts: negateSrc,
+			Protocol:          protocol,
+			Action:            mapAction(rule.Action),
+			Description:       description,
+			Enabled:           !rule.Disabled,
+			States:            states,
+		}
+
+		policies = append(policies, policy)
+	}
+
+	policies = append(policies, &types.SecurityPolicy{
+		ID:          idPrefix + "-default",
+		Source:      defaultSrc,
+		Destination: defaultDst,
+		Ports:       []types.Port{},
+		Protocol:    "any",
+		Action:      mapAction(ruleset.DefaultAction),
+		Description: fmt.Sprintf("%s default action (%s)", name, ruleset.DefaultAction),
+		Enabled:     true,
+	})
+
+	return policies
 }
 
-type VyattaFirewall struct {
-	Name map[string]VyattaRuleset // named rulesets
+// zoneRange describes a VyOS zone as a network range over its member interfaces,
+// referenced by interface name like OPNsense "lan" network references
+func (p *VyattaParser) zoneRange(zone VyattaZone) types.NetworkRange {
+	if zone.LocalZone || len(zone.Interfaces) == 0 {
+		return types.NetworkRange{}
+	}
+
+	ips := []string{}
+	for _, name := range zone.Interfaces {
+		if iface, ok := p.config.Interfaces[name]; ok {
+			for _, addr := range iface.Address {
+				if strings.Contains(addr, "/") {
+					ips = append(ips, addr)
+				}
+			}
+		}
+	}
+
+	return types.NetworkRange{CIDR: strings.Join(zone.Interfaces, ","), IPs: ips}
 }
 
-type VyattaRuleset struct {
-	Description   string
-	DefaultAction string
-	Rules         map[int]VyattaRule
+// resolveAddress converts a rule address or group reference into a network range
+func (p *VyattaParser) resolveAddress(addr VyattaRuleAddress, def types.NetworkRange) types.NetworkRange {
+	switch {
+	case addr.Address != "":
+		return types.NetworkRange{CIDR: addr.Address, IPs: []string{}}
+	case addr.AddressGroup != "":
+		group := strings.TrimPrefix(addr.AddressGroup, "!")
+		members, ok := p.config.Firewall.AddressGroups[group]
+		if !ok {
+			members = p.config.Firewall.NetworkGroups[group]
+		}
+		if len(members) == 1 && !strings.HasPrefix(addr.AddressGroup, "!") {
+			return types.NetworkRange{CIDR: members[0], IPs: members}
+		}
+		return types.NetworkRange{CIDR: addr.AddressGroup, IPs: append([]string{}, members...)}
+	default:
+		return def
+	}
 }
 
-type VyattaRule struct {
-	Action      string
-	Description string
-	Source      VyattaRuleAddress
-	Destination VyattaRuleAddress
-	Protocol    string
-	State       VyattaRuleState
+// resolvePorts converts "22,80,1000-2000" or a port-group into the "proto:name" port
+// format. A "!" prefix ("port !22", "port-group !MGMT") negates the list.
+func (p *VyattaParser) resolvePorts(addr VyattaRuleAddress, protocol types.Protocol) ([]types.Port, bool) {
+	negate := false
+	var specs []string
+	if addr.Port != "" {
+		for _, spec := range strings.Split(addr.Port, ",") {
+			spec = strings.TrimSpace(spec)
+			if strings.HasPrefix(spec, "!") {
+				negate = true
+			}
+			specs = append(specs, strings.TrimPrefix(spec, "!"))
+		}
+	}
+	if addr.PortGroup != "" {
+		group := addr.PortGroup
+		if strings.HasPrefix(group, "!") {
+			negate = true
+			group = group[1:]
+		}
+		if members, ok := p.config.Firewall.PortGroups[group]; ok {
+			specs = append(specs, members...)
+		} else {
+			specs = append(specs, group)
+		}
+	}
+
+	ports := []types.Port{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if low, high, ok := strings.Cut(spec, "-"); ok {
+			start, end := parsePortNumber(low), parsePortNumber(high)
+			if start > 0 && end >= start {
+				ports = append(ports, types.Port{
+					Number:   uint16(start),
+					Protocol: fmt.Sprintf("%s:%d-%d", protocol, start, end),
+				})
+			}
+			continue
+		}
+
+		if num := parsePortNumber(spec); num > 0 {
+			ports = append(ports, types.Port{
+				Number:   uint16(num),
+				Protocol: fmt.Sprintf("%s:%s", protocol, getPortName(num)),
+			})
+		} else if spec != "" {
+			// Named service (e.g. "http") or unresolved port-group
+			ports = append(ports, types.Port{Number: 0, Protocol: fmt.Sprintf("%s:%s", protocol, spec)})
+		}
+	}
+
+	return ports, negate
+}
+
+// interfaceZones maps interface names to the zone-policy zone that contains them
+func (p *VyattaParser) interfaceZones() map[string]string {
+	result := make(map[string]string)
+	for name, zone := range p.config.ZonePolicy {
+		for _, iface := range zone.Interfaces {
+			result[iface] = name
+		}
+	}
+	return result
 }
 
-type VyattaRuleAddress struct {
-	Address string
-	Port    string
+// zoneToIEC maps a VyOS zone onto an IEC 62443 zone from its name, description and interfaces
+func (p *VyattaParser) zoneToIEC(zone VyattaZone) types.IEC62443Zone {
+	text := zone.Name + " " + zone.Description
+	ifType := ""
+	for _, name := range zone.Interfaces {
+		if iface, ok := p.config.Interfaces[name]; ok {
+			text += " " + iface.Description
+			if ifType == "" {
+				ifType = iface.Type
+			}
+		}
+	}
+	return inferZone(ifType, text)
+}
+
+func inferZone(ifType, text string) types.IEC62443Zone {
+	lower := strings.ToLower(text)
+
+	switch {
+	case ifType == "wireguard" || ifType == "openvpn" || ifType == "vti" ||
+		containsWord(lower, "vpn", "remote", "wireguard", "ipsec"):
+		return types.RemoteAccessZone
+	case ifType == "pppoe" || containsWord(lower, "wan", "dmz", "internet", "outside", "untrust"):
+		return types.DMZZone
+	case containsWord(lower, "safety", "sis"):
+		return types.SafetyZone
+	case inferPurpose(text) == "Production OT":
+		return types.IndustrialZone
+	default:
+		return types.EnterpriseZone
+	}
+}
+
+func inferPurpose(text string) string {
+	lower := strings.ToLower(text)
+
+	switch {
+	case containsWord(lower, "ot", "ics", "scada", "plc", "hmi", "plant", "production", "process",
+		"control", "cell", "line", "field", "automation", "industrial", "manufacturing"):
+		return "Production OT"
+	case containsWord(lower, "dmz"):
+		return "DMZ"
+	case containsWord(lower, "mgmt", "management", "admin"):
+		return "Management"
+	case containsWord(lower, "wan", "internet", "outside", "isp"):
+		return "Internet"
+	case containsWord(lower, "corp", "office", "business", "enterprise", "it", "lan"):
+		return "Corporate IT"
+	default:
+		return "General"
+	}
 }
 
-type VyattaRuleState struct {
-	Established string
-	Invalid     string
-	New         string
-	Related     string
+// containsWord reports whether any keyword appears as a word (or word prefix for longer keywords) in s
+func containsWord(s string, keywords ...string) bool {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, word := range words {
+		for _, keyword := range keywords {
+			if word == keyword || (len(keyword) > 3 && strings.HasPrefix(word, keyword)) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-type VyattaProtocols struct {
-	Static VyattaStaticRoutes
-	OSPF   VyattaOSPF
-	BGP    VyattaBGP
+func ruleStates(state VyattaRuleState) []string {
+	var states []string
+	for _, s := range []struct {
+		name  string
+		value string
+	}{
+		{"established", state.Established},
+		{"related", state.Related},
+		{"new", state.New},
+		{"invalid", state.Invalid},
+	} {
+		if s.value == "enable" {
+			states = append(states, s.name)
+		}
+	}
+	return states
 }
 
-type VyattaStaticRoutes struct {
-	Route map[string]VyattaStaticRoute
+func mapProtocol(protocol string) types.Protocol {
+	switch strings.ToLower(protocol) {
+	case "", "all":
+		return "any"
+	case "tcp_udp":
+		return "tcp/udp"
+	default:
+		return types.Protocol(strings.ToLower(protocol))
+	}
 }
 
-type VyattaStaticRoute struct {
-	NextHop string
+func mapAction(action string) types.RuleAction {
+	switch strings.ToLower(action) {
+	case "accept":
+		return types.Allow
+	case "reject":
+		return types.Reject
+	case "drop":
+		return types.Drop
+	default:
+		return types.Deny
+	}
 }
 
-type VyattaOSPF struct {
-	Area map[string]VyattaOSPFArea
+// assessRisk evaluates risk levels for network segments
+func (p *VyattaParser) assessRisk(model *types.NetworkModel) {
+	for _, segment := range model.Networks {
+		switch segment.Zone {
+		case types.IndustrialZone, types.SafetyZone:
+			segment.Risk = types.HighRisk
+		case types.DMZZone, types.RemoteAccessZone:
+			segment.Risk = types.MediumRisk
+		default:
+			segment.Risk = types.LowRisk
+		}
+	}
 }
 
-type VyattaOSPFArea struct {
-	Network []string
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
-type VyattaBGP struct {
-	ASN      int
-	Neighbor map[string]VyattaBGPNeighbor
+// parsePortNumber converts a string to a port number, returning 0 when invalid
+func parsePortNumber(s string) int {
+	num, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || num < 0 || num > 65535 {
+		return 0
+	}
+	return num
 }
 
-type VyattaBGPNeighbor struct {
-	RemoteAS int
+// getPortName returns common service names for well-known ports
+func getPortName(port int) string {
+	wellKnownPorts := map[int]string{
+		22:    "SSH",
+		23:    "Telnet",
+		53:    "DNS",
+		80:    "HTTP",
+		102:   "S7comm",
+		123:   "NTP",
+		161:   "SNMP",
+		443:   "HTTPS",
+		502:   "Modbus",
+		1883:  "MQTT",
+		2222:  "ENIP-IO",
+		3389:  "RDP",
+		4840:  "OPC-UA",
+		8883:  "MQTTS",
+		20000: "DNP3",
+		44818: "ENIP",
+		47808: "BACnet",
+	}
+
+	if name, exists := wellKnownPorts[port]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("Port-%d", port)
 }
 
-// TODO: Implement parsing functions for Vyatta hierarchical format
-// parseSystem() error
-// parseInterfaces() error
-// parseFirewall() error
-// parseProtocols() error
+// calculateFileHash computes SHA256 hash of a file for integrity checking
+func calculateFileHash(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		log.Printf("Warning: Failed to calculate file hash for %s: %v", filePath, err)
+		return ""
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
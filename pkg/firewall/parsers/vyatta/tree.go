@@ -0,0 +1,180 @@
+package vyatta
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// configNode is a node in the VyOS configuration tree. Every word of a
+// config path is a node, so a leaf value such as "address 10.0.0.1/24" is
+// stored as the child "10.0.0.1/24" of the "address" node.
+type configNode struct {
+	children map[string]*configNode
+	order    []string
+}
+
+func newConfigNode() *configNode {
+	return &configNode{children: make(map[string]*configNode)}
+}
+
+// add returns the child with the given key, creating it when missing
+func (n *configNode) add(key string) *configNode {
+	if child, ok := n.children[key]; ok {
+		return child
+	}
+	child := newConfigNode()
+	n.children[key] = child
+	n.order = append(n.order, key)
+	return child
+}
+
+// get walks a path of keys, returning nil when any element is missing
+func (n *configNode) get(path ...string) *configNode {
+	current := n
+	for _, key := range path {
+		if current == nil {
+			return nil
+		}
+		current = current.children[key]
+	}
+	return current
+}
+
+// keys returns the children of the node at path in configuration order
+func (n *configNode) keys(path ...string) []string {
+	if node := n.get(path...); node != nil {
+		return node.order
+	}
+	return nil
+}
+
+// value returns the first leaf value under path, or "" when absent
+func (n *configNode) value(path ...string) string {
+	if keys := n.keys(path...); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// has reports whether path exists (used for valueless flags like "disable")
+func (n *configNode) has(path ...string) bool {
+	return n.get(path...) != nil
+}
+
+// readConfigTree parses either the curly-brace config.boot format or
+// "set ..." command output into a configuration tree
+func readConfigTree(r io.Reader) (*configNode, error) {
+	root := newConfigNode()
+	stack := []*configNode{root}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") ||
+			strings.HasPrefix(line, "/*") {
+			continue
+		}
+
+		words, err := splitWords(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		// "set" command format: every line is a full path from the root
+		if words[0] == "set" {
+			node := root
+			for _, word := range words[1:] {
+				node = node.add(word)
+			}
+			continue
+		}
+		if words[0] == "delete" || words[0] == "commit" || words[0] == "save" {
+			continue
+		}
+
+		// Curly-brace format
+		switch {
+		case words[len(words)-1] == "{":
+			node := stack[len(stack)-1]
+			for _, word := range words[:len(words)-1] {
+				node = node.add(word)
+			}
+			stack = append(stack, node)
+		case words[0] == "}":
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("line %d: unbalanced closing brace", lineNum)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			node := stack[len(stack)-1]
+			for _, word := range words {
+				node = node.add(word)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read config: %v", err)
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("unterminated block: missing %d closing brace(s)", len(stack)-1)
+	}
+
+	return root, nil
+}
+
+// splitWords splits a line into words, honouring the double quotes used by
+// config.boot and the single quotes used by "set" commands
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	var quote byte
+	hasWord := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(line) {
+				i++
+				current.WriteByte(line[i])
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			hasWord = true
+		case c == ' ' || c == '\t':
+			if hasWord {
+				words = append(words, current.String())
+				current.Reset()
+				hasWord = false
+			}
+		case (c == '{' || c == '}') && !hasWord:
+			words = append(words, string(c))
+		default:
+			current.WriteByte(c)
+			hasWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if hasWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,121 @@
+package vyatta
+
+// VyattaConfig represents the structure of a Vyatta/VyOS configuration.
+// Both the hierarchical config.boot format and "set" command output are
+// read into the same structures:
+//
+//	interfaces {
+//	    ethernet eth0 {
+//	        address 192.168.1.1/24
+//	        description "LAN interface"
+//	    }
+//	}
+//
+//	set interfaces ethernet eth0 address '192.168.1.1/24'
+type VyattaConfig struct {
+	System     VyattaSystem
+	Interfaces map[string]VyattaInterface // Keyed by interface name; VLANs as "eth0.100"
+	Firewall   VyattaFirewall
+	ZonePolicy map[string]VyattaZone
+	Protocols  VyattaProtocols
+}
+
+type VyattaSystem struct {
+	HostName   string
+	DomainName string
+	TimeZone   string
+	NameServer []string
+}
+
+type VyattaInterface struct {
+	Name        string
+	Type        string // ethernet, loopback, tunnel, vif, etc.
+	Address     []string
+	Description string
+	Disabled    bool
+	Firewall    VyattaInterfaceFirewall
+}
+
+type VyattaInterfaceFirewall struct {
+	In    string // firewall ruleset name for inbound traffic
+	Out   string // firewall ruleset name for outbound traffic
+	Local string // firewall ruleset name for local traffic
+}
+
+type VyattaFirewall struct {
+	Name          map[string]VyattaRuleset // named rulesets
+	NetworkGroups map[string][]string
+	AddressGroups map[string][]string
+	PortGroups    map[string][]string
+}
+
+type VyattaRuleset struct {
+	Description   string
+	DefaultAction string
+	Rules         map[int]VyattaRule
+}
+
+type VyattaRule struct {
+	Action      string
+	Description string
+	Disabled    bool
+	Source      VyattaRuleAddress
+	Destination VyattaRuleAddress
+	Protocol    string
+	State       VyattaRuleState
+}
+
+type VyattaRuleAddress struct {
+	Address      string
+	Port         string
+	AddressGroup string // address-group or network-group reference
+	PortGroup    string
+}
+
+type VyattaRuleState struct {
+	Established string
+	Invalid     string
+	New         string
+	Related     string
+}
+
+// VyattaZone represents a "zone-policy zone" block
+type VyattaZone struct {
+	Name          string
+	Description   string
+	DefaultAction string
+	Interfaces    []string
+	LocalZone     bool
+	From          map[string]string // source zone -> firewall ruleset name
+}
+
+type VyattaProtocols struct {
+	Static VyattaStaticRoutes
+	OSPF   VyattaOSPF
+	BGP    VyattaBGP
+}
+
+type VyattaStaticRoutes struct {
+	Route map[string]VyattaStaticRoute
+}
+
+type VyattaStaticRoute struct {
+	NextHop string
+}
+
+type VyattaOSPF struct {
+	Area map[string]VyattaOSPFArea
+}
+
+type VyattaOSPFArea struct {
+	Network []string
+}
+
+type VyattaBGP struct {
+	ASN      int
+	Neighbor map[string]VyattaBGPNeighbor
+}
+
+type VyattaBGPNeighbor struct {
+	RemoteAS int
+}
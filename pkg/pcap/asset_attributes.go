@@ -0,0 +1,68 @@
+package pcap
+
+import (
+	"cipgram/pkg/pcap/core"
+	"cipgram/pkg/types"
+)
+
+// recordAssetAttributes copies identification the sender reported about itself onto its asset
+func recordAssetAttributes(src *types.Asset, result *core.AnalysisResult) {
+	if result == nil {
+		return
+	}
+
+	switch result.Protocol {
+	case "S7Comm":
+		recordS7Identification(src, result.Details)
+	case "BACnet/IP":
+		recordBACnetIAm(src, result.Details)
+	case "TLS":
+		recordTLSProfile(src, result.Details)
+	}
+}
+
+// recordBACnetIAm stores an I-Am announcement on the sender. I-Am messages relayed by a
+// BBMD or a router describe a device behind it, not the IP host that sent them.
+func recordBACnetIAm(asset *types.Asset, details map[string]interface{}) {
+	if detailString(details, "bacnet_service") != "I-Am" {
+		return
+	}
+	if _, routed := details["snet"]; routed {
+		return
+	}
+	if _, forwarded := details["forwarded_from"]; forwarded {
+		return
+	}
+
+	instance, ok := detailUint(details, "device_instance")
+	if !ok {
+		return
+	}
+	vendorID, _ := detailUint(details, "vendor_id")
+	maxAPDU, _ := detailUint(details, "max_apdu")
+
+	asset.BACnet = &types.BACnetDevice{
+		DeviceInstance: uint32(instance),
+		VendorID:       uint16(vendorID),
+		MaxAPDU:        uint32(maxAPDU),
+		Segmentation:   detailString(details, "segmentation"),
+	}
+}
+
+// recordS7Identification applies SZL module and component identification from a CPU's
+// Read SZL response. The module type name ("CPU 315-2 PN/DP") is preferred over the
+// order number ("6ES7 315-2EH14-0AB0") as the model.
+func recordS7Identification(asset *types.Asset, details map[string]interface{}) {
+	if detailString(details, "direction") != "response" {
+		return
+	}
+
+	if moduleType := detailString(details, "s7_module_type"); moduleType != "" {
+		asset.Model = moduleType
+	} else if orderNumber := detailString(details, "s7_order_number"); orderNumber != "" && asset.Model == "" {
+		asset.Model = orderNumber
+	}
+	if firmware := detailString(details, "s7_firmware_version"); firmware != "" {
+		asset.Version = firmware
+	}
+}
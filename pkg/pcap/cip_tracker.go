@@ -0,0 +1,248 @@
+package pcap
+
+import (
+	"time"
+
+	"cipgram/pkg/pcap/core"
+	"cipgram/pkg/types"
+)
+
+// cipTracker follows EtherNet/IP explicit messaging across packets so that replies and
+// implicit I/O can be tied to the requests that set them up
+type cipTracker struct {
+	identityRequests map[cipRequestKey]bool                    // Identity Get_Attributes_All awaiting a reply
+	opens            map[cipConnectionKey]*types.CIPConnection // By connection triad
+	connections      map[uint32]*types.CIPConnection           // By O->T and T->O connection ID
+}
+
+// cipRequestKey matches an unconnected reply to its request
+type cipRequestKey struct {
+	client  string
+	server  string
+	session uint32
+	context uint64
+}
+
+// cipConnectionKey is the connection triad plus the endpoints
+type cipConnectionKey struct {
+	originator       string
+	target           string
+	serial           uint16
+	vendor           uint16
+	originatorSerial uint32
+}
+
+func newCIPTracker() *cipTracker {
+	return &cipTracker{
+		identityRequests: make(map[cipRequestKey]bool),
+		opens:            make(map[cipConnectionKey]*types.CIPConnection),
+		connections:      make(map[uint32]*types.CIPConnection),
+	}
+}
+
+// observe updates session, connection and identity state from one DPI result
+func (t *cipTracker) observe(src, dst *types.Asset, flow *types.Flow, result *core.AnalysisResult, seen time.Time) {
+	if result == nil {
+		return
+	}
+	details := result.Details
+
+	switch result.Protocol {
+	case "EtherNet/IP I/O":
+		t.observeImplicitIO(flow, details, seen)
+	case "EtherNet/IP":
+		response := detailString(details, "direction") == "response"
+		if response {
+			t.observeReply(src, dst, details, seen)
+		} else {
+			t.observeRequest(src, dst, details, seen)
+		}
+	}
+}
+
+// observeRequest records Identity reads, Forward_Open and Forward_Close requests
+func (t *cipTracker) observeRequest(src, dst *types.Asset, details map[string]interface{}, seen time.Time) {
+	if service, _ := detailUint(details, "cip_service_code"); service == 0x01 {
+		if class, _ := detailUint(details, "cip_class_id"); class == 0x01 {
+			t.identityRequests[requestKey(src.ID, dst.ID, details)] = true
+		}
+	}
+
+	if _, closing := details["forward_close"]; closing {
+		if conn := t.opens[connectionKey(src.ID, dst.ID, details)]; conn != nil {
+			conn.Closed = true
+		}
+		return
+	}
+
+	if detailString(details, "forward_open") != "request" {
+		return
+	}
+
+	key := connectionKey(src.ID, dst.ID, details)
+	conn := t.opens[key]
+	if conn == nil {
+		conn = &types.CIPConnection{
+			Originator:       src.ID,
+			Target:           dst.ID,
+			ConnectionSerial: key.serial,
+			OriginatorVendor: key.vendor,
+			OriginatorSerial: key.originatorSerial,
+		}
+		t.opens[key] = conn
+		cipInfo(src).AddConnection(conn)
+		cipInfo(dst).AddConnection(conn)
+	}
+
+	otRPI, _ := detailUint(details, "ot_rpi")
+	toRPI, _ := detailUint(details, "to_rpi")
+	otSize, _ := detailUint(details, "ot_connection_size")
+	toSize, _ := detailUint(details, "to_connection_size")
+	toID, _ := detailUint(details, "to_connection_id")
+	large, _ := details["large_forward_open"].(bool)
+
+	conn.TOConnectionID = uint32(toID)
+	conn.OTRPI = uint32(otRPI)
+	conn.TORPI = uint32(toRPI)
+	conn.OTSize = uint16(otSize)
+	conn.TOSize = uint16(toSize)
+	conn.LargeForwardOpen = large
+	conn.Path = detailString(details, "connection_path")
+	conn.OpenedAt = seen
+	conn.Closed = false
+}
+
+// observeReply records session handles, Forward_Open results and Identity attributes
+func (t *cipTracker) observeReply(src, dst *types.Asset, details map[string]interface{}, seen time.Time) {
+	status, _ := detailUint(details, "status")
+
+	switch detailString(details, "command") {
+	case "RegisterSession":
+		if handle, ok := detailUint(details, "session_handle"); ok && status == 0 && handle != 0 {
+			cipInfo(src).AddSession(uint32(handle))
+			cipInfo(dst).AddSession(uint32(handle))
+		}
+		return
+	case "ListIdentity":
+		if detailString(details, "identity_source") == "list_identity" {
+			setCIPIdentity(src, details)
+		}
+		return
+	}
+
+	if detailString(details, "identity_source") == "get_attributes_all" {
+		key := requestKey(dst.ID, src.ID, details)
+		if t.identityRequests[key] {
+			delete(t.identityRequests, key)
+			setCIPIdentity(src, details)
+		}
+	}
+
+	if detailString(details, "forward_open") != "reply" {
+		return
+	}
+
+	// Replies travel target -> originator
+	key := connectionKey(dst.ID, src.ID, details)
+	conn := t.opens[key]
+	if conn == nil {
+		// Request not captured; the reply still identifies the connection
+		conn = &types.CIPConnection{
+			Originator:       dst.ID,
+			Target:           src.ID,
+			ConnectionSerial: key.serial,
+			OriginatorVendor: key.vendor,
+			OriginatorSerial: key.originatorSerial,
+			OpenedAt:         seen,
+		}
+		t.opens[key] = conn
+		cipInfo(src).AddConnection(conn)
+		cipInfo(dst).AddConnection(conn)
+	}
+
+	otID, _ := detailUint(details, "ot_connection_id")
+	toID, _ := detailUint(details, "to_connection_id")
+	conn.OTConnectionID = uint32(otID)
+	conn.TOConnectionID = uint32(toID)
+	conn.Established = true
+	for _, id := range []uint32{conn.OTConnectionID, conn.TOConnectionID} {
+		if id != 0 {
+			t.connections[id] = conn
+		}
+	}
+}
+
+// observeImplicitIO counts I/O datagrams against the connection that carries their ID
+func (t *cipTracker) observeImplicitIO(flow *types.Flow, details map[string]interface{}, seen time.Time) {
+	id, ok := detailUint(details, "connection_id")
+	if !ok {
+		return
+	}
+	conn := t.connections[uint32(id)]
+	if conn == nil {
+		return
+	}
+
+	conn.IOPackets++
+	conn.LastIO = seen
+
+	// Name the otherwise anonymous UDP 2222 flow after the assemblies it carries
+	if conn.Path != "" {
+		if flow.DPI == nil {
+			flow.DPI = types.NewFlowDPI()
+		}
+		flow.DPI.AddCIPObject(conn.Path)
+	}
+}
+
+func requestKey(client, server string, details map[string]interface{}) cipRequestKey {
+	session, _ := detailUint(details, "session_handle")
+	context, _ := detailUint(details, "sender_context")
+	return cipRequestKey{client: client, server: server, session: uint32(session), context: context}
+}
+
+func connectionKey(originator, target string, details map[string]interface{}) cipConnectionKey {
+	serial, _ := detailUint(details, "connection_serial")
+	vendor, _ := detailUint(details, "originator_vendor_id")
+	originatorSerial, _ := detailUint(details, "originator_serial")
+	return cipConnectionKey{
+		originator:       originator,
+		target:           target,
+		serial:           uint16(serial),
+		vendor:           uint16(vendor),
+		originatorSerial: uint32(originatorSerial),
+	}
+}
+
+// cipInfo returns the asset's CIP information, creating it on first use
+func cipInfo(asset *types.Asset) *types.CIPDeviceInfo {
+	if asset.CIP == nil {
+		asset.CIP = &types.CIPDeviceInfo{}
+	}
+	return asset.CIP
+}
+
+// setCIPIdentity stores decoded Identity attributes on the asset and fills in its model
+func setCIPIdentity(asset *types.Asset, details map[string]interface{}) {
+	vendor, _ := detailUint(details, "identity_vendor_id")
+	deviceType, _ := detailUint(details, "identity_device_type")
+	productCode, _ := detailUint(details, "identity_product_code")
+	serial, _ := detailUint(details, "identity_serial")
+
+	identity := &types.CIPIdentity{
+		VendorID:     uint16(vendor),
+		DeviceType:   uint16(deviceType),
+		ProductCode:  uint16(productCode),
+		Revision:     detailString(details, "identity_revision"),
+		SerialNumber: uint32(serial),
+		ProductName:  detailString(details, "identity_product_name"),
+	}
+	cipInfo(asset).Identity = identity
+
+	if asset.Model == "" {
+		asset.Model = identity.ProductName
+	}
+	if asset.Version == "" {
+		asset.Version = identity.Revision
+	}
+}
@@ -241,7 +241,7 @@ func (cm *DefaultConfigManager) GetEnabledAnalyzers() []string {
 		enabled = append(enabled, "DNS")
 	}
 	if dpiConfig.EnableIndustrial {
-		enabled = append(enabled, "Modbus", "EtherNet/IP", "DNP3", "BACnet")
+		enabled = append(enabled, "Modbus", "EtherNet/IP", "S7Comm", "OPC-UA", "Profinet", "DNP3", "BACnet")
 	}
 
 	return enabled
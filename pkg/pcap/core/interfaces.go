@@ -58,6 +58,18 @@ type DPIAnalyzer interface {
 	GetConfidenceThreshold() float32
 }
 
+// StreamAnalyzer is a DPIAnalyzer for a protocol carried over TCP. The DPI engine hands
+// it reassembled stream data, so PDUs split across segments or batched into one segment
+// are analyzed whole.
+type StreamAnalyzer interface {
+	DPIAnalyzer
+	// PDULength returns the length of the PDU at the start of data, 0 if more data is
+	// needed to tell, or -1 if data does not start with a PDU of this protocol
+	PDULength(data []byte) int
+	// AnalyzePDU analyzes one PDU of the TCP stream that packet belongs to
+	AnalyzePDU(packet gopacket.Packet, pdu []byte) *AnalysisResult
+}
+
 // AnalysisResult contains detailed analysis results
 type AnalysisResult struct {
 	Protocol    string
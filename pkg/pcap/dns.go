@@ -0,0 +1,79 @@
+package pcap
+
+import (
+	"strings"
+
+	"cipgram/pkg/pcap/core"
+	"cipgram/pkg/types"
+	"cipgram/pkg/vendor"
+)
+
+// recordNameResolution adds the address-to-name mappings of a DNS, mDNS, LLMNR or NBNS
+// message to the model and the domains a host looked up over unicast DNS to its asset
+func recordNameResolution(model *types.NetworkModel, src *types.Asset, result *core.AnalysisResult) {
+	if result == nil || result.Protocol != "DNS" {
+		return
+	}
+	details := result.Details
+
+	resolved, _ := details["resolved_addresses"].(map[string][]string)
+	for address, names := range resolved {
+		if model.Hostnames == nil {
+			model.Hostnames = make(map[string][]string)
+		}
+		for _, name := range names {
+			if !containsFold(model.Hostnames[address], name) {
+				model.Hostnames[address] = append(model.Hostnames[address], name)
+			}
+		}
+	}
+
+	// mDNS, LLMNR and NBNS only resolve names on the local link
+	if detailString(details, "dns_variant") != "DNS" || detailString(details, "direction") != "request" {
+		return
+	}
+	queries, _ := details["queries"].([]string)
+	for _, query := range queries {
+		domain := strings.ToLower(strings.TrimSuffix(query, "."))
+		// Reverse lookups name an address, not a domain
+		if domain == "" || strings.HasSuffix(domain, ".arpa") {
+			continue
+		}
+		if !containsFold(src.DNSQueries, domain) {
+			src.DNSQueries = append(src.DNSQueries, domain)
+		}
+	}
+}
+
+// applyHostnames names assets from the names observed in the capture. Live reverse DNS
+// from the analysis host is only a fallback, and only when enabled.
+func (p *PCAPParser) applyHostnames(model *types.NetworkModel) {
+	for _, asset := range model.Assets {
+		if asset.Hostname != "" || asset.IP == "" || asset.IP == asset.MAC {
+			continue
+		}
+		if names := model.Hostnames[asset.IP]; len(names) > 0 {
+			asset.Hostname = shortHostname(names[0])
+		} else if p.config.EnableDNSLookup {
+			asset.Hostname = vendor.ResolveHostname(asset.IP)
+		}
+	}
+}
+
+// shortHostname keeps the host part of a fully qualified name, e.g. "plc1" of
+// "plc1.plant.local"
+func shortHostname(name string) string {
+	if host, _, found := strings.Cut(name, "."); found && host != "" {
+		return host
+	}
+	return name
+}
+
+func containsFold(values []string, value string) bool {
+	for _, existing := range values {
+		if strings.EqualFold(existing, value) {
+			return true
+		}
+	}
+	return false
+}
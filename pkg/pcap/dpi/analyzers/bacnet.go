@@ -0,0 +1,661 @@
+package analyzers
+
+import (
+	"cipgram/pkg/pcap/core"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// BVLC functions
+const (
+	bvlcResult                       uint8 = 0x00
+	bvlcForwardedNPDU                uint8 = 0x04
+	bvlcRegisterForeignDevice        uint8 = 0x05
+	bvlcDistributeBroadcastToNetwork uint8 = 0x09
+	bvlcOriginalUnicastNPDU          uint8 = 0x0A
+	bvlcOriginalBroadcastNPDU        uint8 = 0x0B
+)
+
+// APDU types
+const (
+	apduConfirmedRequest   uint8 = 0x0
+	apduUnconfirmedRequest uint8 = 0x1
+	apduSimpleACK          uint8 = 0x2
+	apduComplexACK         uint8 = 0x3
+	apduSegmentACK         uint8 = 0x4
+	apduError              uint8 = 0x5
+	apduReject             uint8 = 0x6
+	apduAbort              uint8 = 0x7
+)
+
+// Services whose parameters are decoded
+const (
+	bacnetSubscribeCOV               uint8 = 0x05
+	bacnetReadProperty               uint8 = 0x0C
+	bacnetWriteProperty              uint8 = 0x0F
+	bacnetDeviceCommunicationControl uint8 = 0x11
+	bacnetReinitializeDevice         uint8 = 0x14
+
+	bacnetIAm   uint8 = 0x00
+	bacnetWhoIs uint8 = 0x08
+)
+
+// BACnetAnalyzer implements DPI for BACnet/IP (BVLC, NPDU and APDU)
+type BACnetAnalyzer struct {
+	bvlcFunctions       map[uint8]string
+	apduTypes           map[uint8]string
+	confirmedServices   map[uint8]string
+	unconfirmedServices map[uint8]string
+	objectTypes         map[uint16]string
+	properties          map[uint32]string
+	segmentation        map[uint32]string
+	reinitializeStates  map[uint32]string
+	dccStates           map[uint32]string
+}
+
+// NewBACnetAnalyzer creates a new BACnet/IP analyzer
+func NewBACnetAnalyzer() *BACnetAnalyzer {
+	return &BACnetAnalyzer{
+		bvlcFunctions: map[uint8]string{
+			0x00: "BVLC-Result",
+			0x01: "Write-Broadcast-Distribution-Table",
+			0x02: "Read-Broadcast-Distribution-Table",
+			0x03: "Read-Broadcast-Distribution-Table-Ack",
+			0x04: "Forwarded-NPDU",
+			0x05: "Register-Foreign-Device",
+			0x06: "Read-Foreign-Device-Table",
+			0x07: "Read-Foreign-Device-Table-Ack",
+			0x08: "Delete-Foreign-Device-Table-Entry",
+			0x09: "Distribute-Broadcast-To-Network",
+			0x0A: "Original-Unicast-NPDU",
+			0x0B: "Original-Broadcast-NPDU",
+		},
+		apduTypes: map[uint8]string{
+			apduConfirmedRequest:   "Confirmed-Request",
+			apduUnconfirmedRequest: "Unconfirmed-Request",
+			apduSimpleACK:          "SimpleACK",
+			apduComplexACK:         "ComplexACK",
+			apduSegmentACK:         "SegmentACK",
+			apduError:              "Error",
+			apduReject:             "Reject",
+			apduAbort:              "Abort",
+		},
+		confirmedServices: map[uint8]string{
+			0x00: "AcknowledgeAlarm",
+			0x01: "ConfirmedCOVNotification",
+			0x02: "ConfirmedEventNotification",
+			0x05: "SubscribeCOV",
+			0x06: "AtomicReadFile",
+			0x07: "AtomicWriteFile",
+			0x08: "AddListElement",
+			0x09: "RemoveListElement",
+			0x0A: "CreateObject",
+			0x0B: "DeleteObject",
+			0x0C: "ReadProperty",
+			0x0E: "ReadPropertyMultiple",
+			0x0F: "WriteProperty",
+			0x10: "WritePropertyMultiple",
+			0x11: "DeviceCommunicationControl",
+			0x12: "ConfirmedPrivateTransfer",
+			0x13: "ConfirmedTextMessage",
+			0x14: "ReinitializeDevice",
+			0x1A: "ReadRange",
+			0x1C: "SubscribeCOVProperty",
+			0x1D: "GetEventInformation",
+		},
+		unconfirmedServices: map[uint8]string{
+			0x00: "I-Am",
+			0x01: "I-Have",
+			0x02: "UnconfirmedCOVNotification",
+			0x03: "UnconfirmedEventNotification",
+			0x04: "UnconfirmedPrivateTransfer",
+			0x05: "UnconfirmedTextMessage",
+			0x06: "TimeSynchronization",
+			0x07: "Who-Has",
+			0x08: "Who-Is",
+			0x09: "UTCTimeSynchronization",
+		},
+		objectTypes: map[uint16]string{
+			0:  "analog-input",
+			1:  "analog-output",
+			2:  "analog-value",
+			3:  "binary-input",
+			4:  "binary-output",
+			5:  "binary-value",
+			6:  "calendar",
+			7:  "command",
+			8:  "device",
+			9:  "event-enrollment",
+			10: "file",
+			11: "group",
+			12: "loop",
+			13: "multi-state-input",
+			14: "multi-state-output",
+			15: "notification-class",
+			16: "program",
+			17: "schedule",
+			19: "multi-state-value",
+			20: "trend-log",
+		},
+		properties: map[uint32]string{
+			8:   "all",
+			28:  "description",
+			44:  "firmware-revision",
+			56:  "local-date",
+			57:  "local-time",
+			62:  "max-apdu-length-accepted",
+			70:  "model-name",
+			75:  "object-identifier",
+			76:  "object-list",
+			77:  "object-name",
+			79:  "object-type",
+			81:  "out-of-service",
+			85:  "present-value",
+			87:  "priority-array",
+			103: "reliability",
+			104: "relinquish-default",
+			111: "status-flags",
+			112: "system-status",
+			117: "units",
+			120: "vendor-identifier",
+			121: "vendor-name",
+		},
+		segmentation: map[uint32]string{
+			0: "segmented-both",
+			1: "segmented-transmit",
+			2: "segmented-receive",
+			3: "no-segmentation",
+		},
+		reinitializeStates: map[uint32]string{
+			0: "coldstart",
+			1: "warmstart",
+			2: "startbackup",
+			3: "endbackup",
+			4: "startrestore",
+			5: "endrestore",
+			6: "abortrestore",
+			7: "activate-changes",
+		},
+		dccStates: map[uint32]string{
+			0: "enable",
+			1: "disable",
+			2: "disable-initiation",
+		},
+	}
+}
+
+// CanAnalyze determines if this analyzer can process the packet
+func (b *BACnetAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
+	udpLayer := packet.Layer(layers.LayerTypeUDP)
+	if udpLayer == nil {
+		return false
+	}
+
+	udp := udpLayer.(*layers.UDP)
+
+	// Check BACnet/IP port (47808)
+	if udp.SrcPort == 47808 || udp.DstPort == 47808 {
+		payload := udp.Payload
+		if len(payload) >= 4 {
+			// Check for BACnet/IP header
+			return payload[0] == 0x81 // BVLC Type
+		}
+	}
+	return false
+}
+
+// Analyze performs BACnet/IP protocol analysis
+func (b *BACnetAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
+	udpLayer := packet.Layer(layers.LayerTypeUDP)
+	if udpLayer == nil {
+		return nil
+	}
+
+	udp := udpLayer.(*layers.UDP)
+	payload := udp.Payload
+
+	if len(payload) < 4 || payload[0] != 0x81 {
+		return nil
+	}
+
+	frame := b.parseBVLC(payload)
+	if frame == nil {
+		return nil
+	}
+
+	return &core.AnalysisResult{
+		Protocol:    "BACnet/IP",
+		Subprotocol: frame.Subprotocol,
+		Confidence:  frame.Confidence,
+		Details:     frame.Details,
+		Metadata:    frame.Metadata,
+	}
+}
+
+// GetProtocolName returns the protocol name
+func (b *BACnetAnalyzer) GetProtocolName() string {
+	return "BACnet/IP"
+}
+
+// GetConfidenceThreshold returns the minimum confidence threshold
+func (b *BACnetAnalyzer) GetConfidenceThreshold() float32 {
+	return 0.90
+}
+
+// BACnetFrame represents a parsed BACnet/IP datagram
+type BACnetFrame struct {
+	BVLCFunction uint8
+	Subprotocol  string
+	Confidence   float32
+	Details      map[string]interface{}
+	Metadata     map[string]string
+}
+
+// parseBVLC parses the BACnet Virtual Link Control header and the NPDU it carries
+func (b *BACnetAnalyzer) parseBVLC(payload []byte) *BACnetFrame {
+	function := payload[1]
+	length := binary.BigEndian.Uint16(payload[2:4])
+	if int(length) < 4 {
+		return nil
+	}
+	if int(length) < len(payload) {
+		payload = payload[:length]
+	}
+
+	functionName, exists := b.bvlcFunctions[function]
+	if !exists {
+		functionName = fmt.Sprintf("Unknown Function (0x%02X)", function)
+	}
+
+	frame := &BACnetFrame{
+		BVLCFunction: function,
+		Subprotocol:  functionName,
+		Confidence:   0.90,
+		Details:      make(map[string]interface{}),
+		Metadata: map[string]string{
+			"protocol_type": "industrial",
+			"layer":         "application",
+		},
+	}
+	frame.Details["bvlc_type"] = payload[0]
+	frame.Details["bvlc_function"] = function
+	frame.Details["bvlc_function_name"] = functionName
+	frame.Details["bvlc_length"] = length
+
+	offset := 4
+	switch function {
+	case bvlcForwardedNPDU:
+		// Original source B/IP address of the broadcast
+		if len(payload) < 10 {
+			return frame
+		}
+		frame.Details["forwarded_from"] = fmt.Sprintf("%s:%d", net.IP(payload[4:8]), binary.BigEndian.Uint16(payload[8:10]))
+		offset = 10
+	case bvlcOriginalUnicastNPDU, bvlcOriginalBroadcastNPDU, bvlcDistributeBroadcastToNetwork:
+	case bvlcResult:
+		if len(payload) >= 6 {
+			frame.Details["bvlc_result"] = binary.BigEndian.Uint16(payload[4:6])
+		}
+		return frame
+	case bvlcRegisterForeignDevice:
+		if len(payload) >= 6 {
+			frame.Details["foreign_device_ttl"] = binary.BigEndian.Uint16(payload[4:6])
+		}
+		return frame
+	default:
+		return frame
+	}
+
+	if b.parseNPDU(payload[offset:], frame) {
+		frame.Confidence = 0.95
+	}
+	return frame
+}
+
+// parseNPDU parses the network layer header, including routing information, and the APDU
+func (b *BACnetAnalyzer) parseNPDU(npdu []byte, frame *BACnetFrame) bool {
+	if len(npdu) < 2 || npdu[0] != 0x01 {
+		return false
+	}
+	control := npdu[1]
+	offset := 2
+
+	frame.Details["npdu_control"] = control
+	if control&0x04 != 0 {
+		frame.Details["expecting_reply"] = true
+	}
+
+	// Destination network and MAC address; an empty DADR is a broadcast on DNET
+	hasDestination := control&0x20 != 0
+	if hasDestination {
+		if offset+3 > len(npdu) {
+			return false
+		}
+		frame.Details["dnet"] = binary.BigEndian.Uint16(npdu[offset : offset+2])
+		dlen := int(npdu[offset+2])
+		offset += 3
+		if offset+dlen > len(npdu) {
+			return false
+		}
+		frame.Details["dadr"] = hex.EncodeToString(npdu[offset : offset+dlen])
+		offset += dlen
+	}
+
+	// Source network and MAC address, added by the router that forwarded the message
+	if control&0x08 != 0 {
+		if offset+3 > len(npdu) {
+			return false
+		}
+		frame.Details["snet"] = binary.BigEndian.Uint16(npdu[offset : offset+2])
+		slen := int(npdu[offset+2])
+		offset += 3
+		if offset+slen > len(npdu) {
+			return false
+		}
+		frame.Details["sadr"] = hex.EncodeToString(npdu[offset : offset+slen])
+		offset += slen
+	}
+
+	if hasDestination {
+		if offset >= len(npdu) {
+			return false
+		}
+		frame.Details["hop_count"] = npdu[offset]
+		offset++
+	}
+
+	// Network layer messages carry no APDU
+	if control&0x80 != 0 {
+		if offset >= len(npdu) {
+			return false
+		}
+		frame.Details["network_message_type"] = npdu[offset]
+		frame.Subprotocol = fmt.Sprintf("%s (Network Message 0x%02X)", frame.Subprotocol, npdu[offset])
+		return true
+	}
+
+	return b.parseAPDU(npdu[offset:], frame)
+}
+
+// parseAPDU parses the application layer PDU type, service choice and selected service parameters
+func (b *BACnetAnalyzer) parseAPDU(apdu []byte, frame *BACnetFrame) bool {
+	if len(apdu) < 1 {
+		return false
+	}
+
+	pduType := apdu[0] >> 4
+	typeName, exists := b.apduTypes[pduType]
+	if !exists {
+		return false
+	}
+	frame.Details["apdu_type"] = typeName
+
+	var service uint8
+	var params []byte
+	services := b.confirmedServices
+
+	switch pduType {
+	case apduConfirmedRequest:
+		// Flags, max segments/APDU, invoke ID, [sequence number, window], service
+		headerLength := 4
+		if apdu[0]&0x08 != 0 {
+			headerLength = 6
+		}
+		if len(apdu) < headerLength {
+			return false
+		}
+		frame.Details["invoke_id"] = apdu[2]
+		service = apdu[headerLength-1]
+		params = apdu[headerLength:]
+	case apduUnconfirmedRequest:
+		if len(apdu) < 2 {
+			return false
+		}
+		service = apdu[1]
+		params = apdu[2:]
+		services = b.unconfirmedServices
+	case apduSimpleACK, apduError:
+		if len(apdu) < 3 {
+			return false
+		}
+		frame.Details["invoke_id"] = apdu[1]
+		service = apdu[2]
+	case apduComplexACK:
+		headerLength := 3
+		if apdu[0]&0x08 != 0 {
+			headerLength = 5
+		}
+		if len(apdu) < headerLength {
+			return false
+		}
+		frame.Details["invoke_id"] = apdu[1]
+		service = apdu[headerLength-1]
+	default:
+		// Segment ACK, Reject and Abort name no service
+		if len(apdu) >= 2 {
+			frame.Details["invoke_id"] = apdu[1]
+		}
+		frame.Subprotocol = typeName
+		return true
+	}
+
+	serviceName, exists := services[service]
+	if !exists {
+		serviceName = fmt.Sprintf("Unknown Service (0x%02X)", service)
+	}
+	frame.Details["bacnet_service_choice"] = service
+	frame.Details["bacnet_service"] = serviceName
+	frame.Subprotocol = serviceName
+	if pduType != apduConfirmedRequest && pduType != apduUnconfirmedRequest {
+		frame.Subprotocol = fmt.Sprintf("%s (%s)", typeName, serviceName)
+	}
+
+	if pduType == apduUnconfirmedRequest {
+		switch service {
+		case bacnetIAm:
+			b.parseIAm(params, frame.Details)
+		case bacnetWhoIs:
+			b.parseWhoIs(params, frame.Details)
+		}
+		return true
+	}
+	if pduType != apduConfirmedRequest {
+		return true
+	}
+
+	switch service {
+	case bacnetReadProperty, bacnetWriteProperty:
+		b.parsePropertyReference(params, frame.Details)
+	case bacnetSubscribeCOV:
+		b.parseSubscribeCOV(params, frame.Details)
+	case bacnetDeviceCommunicationControl:
+		b.parseDeviceCommunicationControl(params, frame.Details)
+	case bacnetReinitializeDevice:
+		b.parseReinitializeDevice(params, frame.Details)
+	}
+	return true
+}
+
+// parseIAm decodes the device identifier, max APDU, segmentation support and vendor ID
+func (b *BACnetAnalyzer) parseIAm(params []byte, details map[string]interface{}) {
+	r := &bacnetTagReader{data: params}
+
+	objectType, instance, ok := r.objectIdentifier(12, false)
+	if !ok || objectType != 8 {
+		return
+	}
+	maxAPDU, ok := r.unsigned(2, false)
+	if !ok {
+		return
+	}
+	segmentation, ok := r.unsigned(9, false)
+	if !ok {
+		return
+	}
+	vendorID, ok := r.unsigned(2, false)
+	if !ok {
+		return
+	}
+
+	details["device_instance"] = instance
+	details["max_apdu"] = maxAPDU
+	details["segmentation"] = b.segmentation[segmentation]
+	details["vendor_id"] = uint16(vendorID)
+}
+
+// parseWhoIs decodes the optional device instance range limits
+func (b *BACnetAnalyzer) parseWhoIs(params []byte, details map[string]interface{}) {
+	r := &bacnetTagReader{data: params}
+	if low, ok := r.unsigned(0, true); ok {
+		details["who_is_low"] = low
+		if high, ok := r.unsigned(1, true); ok {
+			details["who_is_high"] = high
+		}
+	}
+}
+
+// parsePropertyReference decodes the object and property addressed by ReadProperty or WriteProperty
+func (b *BACnetAnalyzer) parsePropertyReference(params []byte, details map[string]interface{}) {
+	r := &bacnetTagReader{data: params}
+
+	objectType, instance, ok := r.objectIdentifier(0, true)
+	if !ok {
+		return
+	}
+	b.setObject(objectType, instance, details)
+
+	property, ok := r.unsigned(1, true)
+	if !ok {
+		return
+	}
+	details["property_id"] = property
+	if name, exists := b.properties[property]; exists {
+		details["property"] = name
+	} else {
+		details["property"] = fmt.Sprintf("property-%d", property)
+	}
+}
+
+// parseSubscribeCOV decodes the subscriber process and monitored object
+func (b *BACnetAnalyzer) parseSubscribeCOV(params []byte, details map[string]interface{}) {
+	r := &bacnetTagReader{data: params}
+
+	process, ok := r.unsigned(0, true)
+	if !ok {
+		return
+	}
+	details["subscriber_process_id"] = process
+
+	if objectType, instance, ok := r.objectIdentifier(1, true); ok {
+		b.setObject(objectType, instance, details)
+	}
+}
+
+// parseDeviceCommunicationControl decodes the requested communication state
+func (b *BACnetAnalyzer) parseDeviceCommunicationControl(params []byte, details map[string]interface{}) {
+	r := &bacnetTagReader{data: params}
+
+	// Optional time duration in minutes
+	if duration, ok := r.unsigned(0, true); ok {
+		details["dcc_duration"] = duration
+	}
+	state, ok := r.unsigned(1, true)
+	if !ok {
+		return
+	}
+	details["dcc_state"] = b.dccStates[state]
+	if state != 0 {
+		details["high_risk_operation"] = "Disable Device Communication"
+	}
+}
+
+// parseReinitializeDevice decodes the requested restart or backup state
+func (b *BACnetAnalyzer) parseReinitializeDevice(params []byte, details map[string]interface{}) {
+	r := &bacnetTagReader{data: params}
+
+	state, ok := r.unsigned(0, true)
+	if !ok {
+		return
+	}
+	details["reinitialize_state"] = b.reinitializeStates[state]
+	details["high_risk_operation"] = "Reinitialize Device"
+}
+
+func (b *BACnetAnalyzer) setObject(objectType uint16, instance uint32, details map[string]interface{}) {
+	name, exists := b.objectTypes[objectType]
+	if !exists {
+		name = fmt.Sprintf("object-type-%d", objectType)
+	}
+	details["object_type"] = name
+	details["object_instance"] = instance
+	details["object"] = fmt.Sprintf("%s:%d", name, instance)
+}
+
+// bacnetTagReader reads ASN.1-style BACnet tagged values in order
+type bacnetTagReader struct {
+	data   []byte
+	offset int
+}
+
+// next reads the tag header at the current position when it matches the expected
+// tag number and class, returning the value bytes
+func (r *bacnetTagReader) next(tag uint8, context bool) ([]byte, bool) {
+	if r.offset >= len(r.data) {
+		return nil, false
+	}
+	header := r.data[r.offset]
+	if header>>4 != tag || (header&0x08 != 0) != context {
+		return nil, false
+	}
+
+	offset := r.offset + 1
+	length := int(header & 0x07)
+	if length == 5 {
+		// Extended length in the next octet
+		if offset >= len(r.data) {
+			return nil, false
+		}
+		length = int(r.data[offset])
+		offset++
+		if length > 253 {
+			return nil, false
+		}
+	} else if length > 5 {
+		// Opening and closing tags carry no value
+		return nil, false
+	}
+	if offset+length > len(r.data) {
+		return nil, false
+	}
+
+	r.offset = offset + length
+	return r.data[offset : offset+length], true
+}
+
+// unsigned reads an unsigned or enumerated value of up to four octets
+func (r *bacnetTagReader) unsigned(tag uint8, context bool) (uint32, bool) {
+	value, ok := r.next(tag, context)
+	if !ok || len(value) == 0 || len(value) > 4 {
+		return 0, false
+	}
+	var v uint32
+	for _, octet := range value {
+		v = v<<8 | uint32(octet)
+	}
+	return v, true
+}
+
+// objectIdentifier reads a 10-bit object type and 22-bit instance number
+func (r *bacnetTagReader) objectIdentifier(tag uint8, context bool) (uint16, uint32, bool) {
+	value, ok := r.next(tag, context)
+	if !ok || len(value) != 4 {
+		return 0, 0, false
+	}
+	id := binary.BigEndian.Uint32(value)
+	return uint16(id >> 22), id & 0x3FFFFF, true
+}
@@ -4,14 +4,44 @@ import (
 	"cipgram/pkg/pcap/core"
 	"encoding/binary"
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
 )
 
+// Application function codes that are decoded further or flagged
+const (
+	dnp3Write                uint8 = 0x02
+	dnp3Select               uint8 = 0x03
+	dnp3Operate              uint8 = 0x04
+	dnp3DirectOperate        uint8 = 0x05
+	dnp3DirectOperateNoAck   uint8 = 0x06
+	dnp3FreezeAtTime         uint8 = 0x0B
+	dnp3FreezeAtTimeNoAck    uint8 = 0x0C
+	dnp3OpenFile             uint8 = 0x19
+	dnp3AuthenticateRequest  uint8 = 0x20
+	dnp3AuthRequestNoAck     uint8 = 0x21
+	dnp3Response             uint8 = 0x81
+	dnp3AuthenticateResponse uint8 = 0x83
+)
+
+// Object groups that are decoded further
+const (
+	dnp3GroupCROB           uint8 = 12
+	dnp3GroupAnalogOutput   uint8 = 41
+	dnp3GroupFileControl    uint8 = 70
+	dnp3GroupAuthentication uint8 = 120
+)
+
 // DNP3Analyzer implements DPI for DNP3 protocol
 type DNP3Analyzer struct {
-	functionCodes map[uint8]string
+	functionCodes     map[uint8]string
+	linkFunctions     map[bool]map[uint8]string // By PRM bit
+	highRiskFunctions map[uint8]bool
+	objectSizes       map[uint16]int // Bits per object by group<<8 | variation
+	authMessages      map[uint8]string
 }
 
 // NewDNP3Analyzer creates a new DNP3 analyzer
@@ -47,10 +77,83 @@ func NewDNP3Analyzer() *DNP3Analyzer {
 			0x1A: "Close File",
 			0x1B: "Delete File",
 			0x1C: "Get File Info",
-			0x1D: "Authenticate",
-			0x1E: "Abort",
+			0x1D: "Authenticate File",
+			0x1E: "Abort File",
+			0x1F: "Activate Config",
+			0x20: "Authenticate Request",
+			0x21: "Authenticate Request No Ack",
 			0x81: "Response",
 			0x82: "Unsolicited Response",
+			0x83: "Authenticate Response",
+		},
+		linkFunctions: map[bool]map[uint8]string{
+			true: {
+				0x0: "Reset Link States",
+				0x2: "Test Link States",
+				0x3: "Confirmed User Data",
+				0x4: "Unconfirmed User Data",
+				0x9: "Request Link Status",
+			},
+			false: {
+				0x0: "ACK",
+				0x1: "NACK",
+				0xB: "Link Status",
+				0xF: "Not Supported",
+			},
+		},
+		highRiskFunctions: map[uint8]bool{
+			dnp3Operate:            true,
+			dnp3DirectOperate:      true,
+			dnp3DirectOperateNoAck: true,
+			0x0D:                   true, // Cold Restart
+			0x0E:                   true, // Warm Restart
+			0x0F:                   true, // Initialize Data
+			0x12:                   true, // Stop Application
+			0x15:                   true, // Disable Unsolicited
+			0x1B:                   true, // Delete File
+			0x1F:                   true, // Activate Config
+		},
+		objectSizes: map[uint16]int{
+			0x0101: 1, 0x0102: 8, // Binary input
+			0x0201: 8, 0x0202: 56, 0x0203: 24, // Binary input event
+			0x0301: 2, 0x0302: 8, // Double-bit binary input
+			0x0401: 8, 0x0402: 56, 0x0403: 24, // Double-bit binary input event
+			0x0A01: 1, 0x0A02: 8, // Binary output
+			0x0B01: 8, 0x0B02: 56, // Binary output event
+			0x0C01: 88, 0x0C02: 88, 0x0C03: 1, // CROB, pattern control block, pattern mask
+			0x0D01: 8, 0x0D02: 56, // Binary output command event
+			0x1401: 40, 0x1402: 24, 0x1405: 32, 0x1406: 16, // Counter
+			0x1501: 40, 0x1502: 24, 0x1505: 88, 0x1506: 72, 0x1509: 32, 0x150A: 16, // Frozen counter
+			0x1601: 40, 0x1602: 24, 0x1605: 88, 0x1606: 72, // Counter event
+			0x1701: 40, 0x1702: 24, 0x1705: 88, 0x1706: 72, // Frozen counter event
+			0x1E01: 40, 0x1E02: 24, 0x1E03: 32, 0x1E04: 16, 0x1E05: 40, 0x1E06: 72, // Analog input
+			0x1F01: 40, 0x1F02: 24, 0x1F03: 88, 0x1F04: 72, 0x1F05: 32, 0x1F06: 16, 0x1F07: 40, 0x1F08: 72, // Frozen analog input
+			0x2001: 40, 0x2002: 24, 0x2003: 88, 0x2004: 72, 0x2005: 40, 0x2006: 72, 0x2007: 88, 0x2008: 120, // Analog input event
+			0x2201: 16, 0x2202: 32, 0x2203: 32, // Analog input deadband
+			0x2801: 40, 0x2802: 24, 0x2803: 40, 0x2804: 72, // Analog output status
+			0x2901: 40, 0x2902: 24, 0x2903: 40, 0x2904: 72, // Analog output block
+			0x2A01: 40, 0x2A02: 24, 0x2A03: 88, 0x2A04: 72, 0x2A05: 40, 0x2A06: 72, 0x2A07: 88, 0x2A08: 120, // Analog output event
+			0x3201: 48, 0x3202: 80, 0x3203: 48, 0x3204: 88, // Time and date
+			0x3301: 48, 0x3302: 48, // Common time of occurrence
+			0x3401: 16, 0x3402: 16, // Time delay
+			0x5001: 1, // Internal indications
+		},
+		authMessages: map[uint8]string{
+			1:  "Challenge",
+			2:  "Reply",
+			3:  "Aggressive Mode Request",
+			4:  "Session Key Status Request",
+			5:  "Session Key Status",
+			6:  "Session Key Change",
+			7:  "Error",
+			8:  "User Certificate",
+			9:  "MAC",
+			10: "User Status Change",
+			11: "Update Key Change Request",
+			12: "Update Key Change Reply",
+			13: "Update Key Change",
+			14: "Update Key Change Signature",
+			15: "Update Key Change Confirmation",
 		},
 	}
 }
@@ -85,9 +188,24 @@ func (d *DNP3Analyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
 		return nil
 	}
 
-	tcp := tcpLayer.(*layers.TCP)
-	payload := tcp.Payload
+	return d.AnalyzePDU(packet, tcpLayer.(*layers.TCP).Payload)
+}
+
+// PDULength returns the length of the link layer frame at the start of data. The length
+// field excludes the CRCs that follow the header and every 16 bytes of user data.
+func (d *DNP3Analyzer) PDULength(data []byte) int {
+	if len(data) < 3 {
+		return 0
+	}
+	if data[0] != 0x05 || data[1] != 0x64 || data[2] < 5 {
+		return -1
+	}
+	userData := int(data[2]) - 5
+	return 10 + userData + 2*((userData+15)/16)
+}
 
+// AnalyzePDU analyzes one link layer frame of the TCP stream that packet belongs to
+func (d *DNP3Analyzer) AnalyzePDU(packet gopacket.Packet, payload []byte) *core.AnalysisResult {
 	if len(payload) < 10 {
 		return nil
 	}
@@ -99,8 +217,11 @@ func (d *DNP3Analyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
 	}
 
 	subprotocol := dnp3.FunctionName
+	if subprotocol == "" {
+		subprotocol = dnp3.LinkFunction
+	}
 	if dnp3.ObjectCount > 0 {
-		subprotocol = fmt.Sprintf("%s (%d objects)", dnp3.FunctionName, dnp3.ObjectCount)
+		subprotocol = fmt.Sprintf("%s (%d objects)", subprotocol, dnp3.ObjectCount)
 	}
 
 	return &core.AnalysisResult{
@@ -129,9 +250,11 @@ type DNP3Frame struct {
 	Control      uint8
 	Destination  uint16
 	Source       uint16
+	LinkFunction string
+	FromMaster   bool // DIR bit of the link control field
 	FunctionCode uint8
 	FunctionName string
-	IIN          uint16 // Internal Indication
+	IIN          uint16 // Internal Indication, IIN1 in the low byte
 	ObjectCount  int
 	Objects      []DNP3Object
 	Confidence   float32
@@ -139,15 +262,22 @@ type DNP3Frame struct {
 	Metadata     map[string]string
 }
 
-// DNP3Object represents a DNP3 data object
+// DNP3Object represents a DNP3 object header
 type DNP3Object struct {
 	Group     uint8
 	Variation uint8
 	Qualifier uint8
 	Range     string
+	Count     int // Number of objects following the header, 0 for "all objects"
+}
+
+// String renders the header as "g12v1 q0x28 count 1"
+func (o DNP3Object) String() string {
+	return fmt.Sprintf("g%dv%d q0x%02X %s", o.Group, o.Variation, o.Qualifier, o.Range)
 }
 
-// parseDNP3 parses a DNP3 frame
+// parseDNP3 parses the first DNP3 link frame of a segment, reassembling its transport
+// segment from the CRC-protected data blocks
 func (d *DNP3Analyzer) parseDNP3(payload []byte) *DNP3Frame {
 	if len(payload) < 10 {
 		return nil
@@ -165,30 +295,46 @@ func (d *DNP3Analyzer) parseDNP3(payload []byte) *DNP3Frame {
 	destination := binary.LittleEndian.Uint16(payload[4:6])
 	source := binary.LittleEndian.Uint16(payload[6:8])
 
-	// Validate length
-	if length < 5 || int(length) > len(payload)-3 {
+	// Length counts control, addresses and user data but not CRCs
+	if length < 5 {
 		return nil
 	}
 
-	// Calculate and verify checksum (simple validation)
-	if !d.validateChecksum(payload[:length+3]) {
-		return nil
+	prm := control&0x40 != 0
+	linkFunction := control & 0x0F
+	linkName, exists := d.linkFunctions[prm][linkFunction]
+	if !exists {
+		linkName = fmt.Sprintf("Unknown Link Function (0x%X)", linkFunction)
 	}
 
 	frame := &DNP3Frame{
-		Start:       start,
-		Length:      length,
-		Control:     control,
-		Destination: destination,
-		Source:      source,
-		Confidence:  0.90,
-		Details:     make(map[string]interface{}),
-		Metadata:    make(map[string]string),
-	}
-
-	// Parse application layer if present
-	if len(payload) > 10 {
-		d.parseApplicationLayer(frame, payload[10:])
+		Start:        start,
+		Length:       length,
+		Control:      control,
+		Destination:  destination,
+		Source:       source,
+		LinkFunction: linkName,
+		FromMaster:   control&0x80 != 0,
+		Confidence:   0.90,
+		Details:      make(map[string]interface{}),
+		Metadata:     make(map[string]string),
+	}
+
+	userData, crcValid := d.userData(payload, int(length)-5)
+	if !crcValid {
+		frame.Confidence = 0.70
+	}
+
+	// Transport header: FIN, FIR and sequence. Only the first segment of a
+	// fragment starts with the application header.
+	if prm && (linkFunction == 0x3 || linkFunction == 0x4) && len(userData) >= 1 {
+		transport := userData[0]
+		frame.Details["transport_fir"] = transport&0x40 != 0
+		frame.Details["transport_fin"] = transport&0x80 != 0
+		frame.Details["transport_seq"] = transport & 0x3F
+		if transport&0x40 != 0 {
+			d.parseApplicationLayer(frame, userData[1:])
+		}
 	}
 
 	// Fill in details
@@ -196,6 +342,23 @@ func (d *DNP3Analyzer) parseDNP3(payload []byte) *DNP3Frame {
 	frame.Details["source"] = source
 	frame.Details["control"] = control
 	frame.Details["length"] = length
+	frame.Details["link_function"] = linkName
+	frame.Details["from_master"] = frame.FromMaster
+	frame.Details["crc_valid"] = crcValid
+	if frame.FromMaster {
+		frame.Details["master_address"] = source
+		frame.Details["outstation_address"] = destination
+	} else {
+		frame.Details["master_address"] = destination
+		frame.Details["outstation_address"] = source
+	}
+	if _, exists := frame.Details["direction"]; !exists {
+		if frame.FromMaster {
+			frame.Details["direction"] = "request"
+		} else {
+			frame.Details["direction"] = "response"
+		}
+	}
 
 	// Add metadata
 	frame.Metadata["protocol_version"] = "3.0"
@@ -204,6 +367,52 @@ func (d *DNP3Analyzer) parseDNP3(payload []byte) *DNP3Frame {
 	return frame
 }
 
+// userData strips the CRCs from the link header and each 16-byte data block. It returns
+// as much user data as the segment holds and whether every CRC present was correct.
+func (d *DNP3Analyzer) userData(payload []byte, size int) ([]byte, bool) {
+	valid := dnp3CRC(payload[0:8]) == binary.LittleEndian.Uint16(payload[8:10])
+
+	var data []byte
+	rest := payload[10:]
+	for size > 0 && len(rest) > 0 {
+		block := size
+		if block > 16 {
+			block = 16
+		}
+		if len(rest) < block+2 {
+			// Truncated block; keep what is there without a CRC to check
+			if block > len(rest) {
+				block = len(rest)
+			}
+			data = append(data, rest[:block]...)
+			break
+		}
+		if dnp3CRC(rest[:block]) != binary.LittleEndian.Uint16(rest[block:block+2]) {
+			valid = false
+		}
+		data = append(data, rest[:block]...)
+		rest = rest[block+2:]
+		size -= block
+	}
+	return data, valid
+}
+
+// dnp3CRC computes the DNP3 CRC-16 (polynomial 0x3D65, reflected, inverted)
+func dnp3CRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA6BC
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return ^crc
+}
+
 // looksLikeDNP3 performs heuristic check for DNP3 content
 func (d *DNP3Analyzer) looksLikeDNP3(payload []byte) bool {
 	if len(payload) < 10 {
@@ -215,20 +424,11 @@ func (d *DNP3Analyzer) looksLikeDNP3(payload []byte) bool {
 		return false
 	}
 
-	// Check length field
-	length := payload[2]
-	if length < 5 || int(length) > len(payload)-3 {
+	// Off-port traffic must carry a valid header CRC
+	if payload[2] < 5 {
 		return false
 	}
-
-	return true
-}
-
-// validateChecksum performs basic checksum validation
-func (d *DNP3Analyzer) validateChecksum(data []byte) bool {
-	// DNP3 uses CRC-16, but for simplicity we'll do basic validation
-	// In a full implementation, you'd calculate the actual CRC
-	return len(data) >= 10
+	return dnp3CRC(payload[0:8]) == binary.LittleEndian.Uint16(payload[8:10])
 }
 
 // parseApplicationLayer parses DNP3 application layer
@@ -252,151 +452,377 @@ func (d *DNP3Analyzer) parseApplicationLayer(frame *DNP3Frame, data []byte) {
 	frame.Details["function_code"] = functionCode
 	frame.Details["function_name"] = frame.FunctionName
 	frame.Details["app_control"] = appControl
+	frame.Details["app_seq"] = appControl & 0x0F
+	frame.Details["confirm_requested"] = appControl&0x20 != 0
+	frame.Details["unsolicited"] = appControl&0x10 != 0
 
-	// Parse IIN (Internal Indication) for responses
-	if functionCode == 0x81 || functionCode == 0x82 {
-		if len(data) >= 4 {
-			frame.IIN = binary.LittleEndian.Uint16(data[2:4])
-			frame.Details["iin"] = frame.IIN
-			frame.Metadata["iin_flags"] = d.parseIIN(frame.IIN)
+	isResponse := functionCode >= dnp3Response
+	if isResponse {
+		frame.Details["direction"] = "response"
+	} else {
+		frame.Details["direction"] = "request"
+		if d.highRiskFunctions[functionCode] {
+			frame.Details["high_risk_operation"] = frame.FunctionName
 		}
 	}
 
-	// Parse objects if present
+	// Responses carry the outstation's Internal Indications
 	objectData := data[2:]
-	if functionCode == 0x81 || functionCode == 0x82 {
-		objectData = data[4:] // Skip IIN for responses
+	if isResponse {
+		if len(data) < 4 {
+			return
+		}
+		frame.IIN = binary.LittleEndian.Uint16(data[2:4])
+		flags := d.iinFlags(frame.IIN)
+		frame.Details["iin"] = frame.IIN
+		frame.Details["iin_flags"] = flags
+		if frame.IIN&0x0080 != 0 {
+			frame.Details["device_restart"] = true
+		}
+		frame.Metadata["iin_flags"] = d.parseIIN(frame.IIN)
+		objectData = data[4:]
+	}
+
+	if functionCode == dnp3AuthenticateRequest || functionCode == dnp3AuthRequestNoAck || functionCode == dnp3AuthenticateResponse {
+		frame.Details["secure_authentication"] = true
 	}
 
 	if len(objectData) > 0 {
-		frame.Objects = d.parseObjects(objectData)
+		frame.Objects = d.parseObjects(frame, objectData, d.carriesObjectData(functionCode))
 		frame.ObjectCount = len(frame.Objects)
 		frame.Details["object_count"] = frame.ObjectCount
+
+		headers := make([]string, 0, len(frame.Objects))
+		for _, obj := range frame.Objects {
+			headers = append(headers, obj.String())
+		}
+		frame.Details["object_headers"] = headers
 	}
 
 	frame.Metadata["function_category"] = d.categorizeFunctionCode(functionCode)
 }
 
-// parseObjects parses DNP3 data objects
-func (d *DNP3Analyzer) parseObjects(data []byte) []DNP3Object {
+// carriesObjectData reports whether object headers of a function are followed by object
+// values. Read, freeze, restart and unsolicited control requests carry headers only.
+func (d *DNP3Analyzer) carriesObjectData(functionCode uint8) bool {
+	switch {
+	case functionCode >= dnp3Response:
+		return true
+	case functionCode == dnp3Write, functionCode >= dnp3Select && functionCode <= dnp3DirectOperateNoAck:
+		return true
+	case functionCode == dnp3FreezeAtTime, functionCode == dnp3FreezeAtTimeNoAck:
+		return true
+	case functionCode >= dnp3OpenFile && functionCode <= dnp3AuthRequestNoAck:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseObjects walks the object headers of a fragment. Values are skipped using the
+// known object sizes; parsing stops at the first object whose size is unknown.
+func (d *DNP3Analyzer) parseObjects(frame *DNP3Frame, data []byte, withData bool) []DNP3Object {
 	var objects []DNP3Object
-	offset := 0
 
-	for offset < len(data)-2 {
-		if offset+3 > len(data) {
+	for len(data) >= 3 {
+		obj := DNP3Object{Group: data[0], Variation: data[1], Qualifier: data[2]}
+		prefixCode := (obj.Qualifier >> 4) & 0x07
+		rangeCode := obj.Qualifier & 0x0F
+		data = data[3:]
+
+		start, count, rangeSize, ok := d.parseRange(rangeCode, data)
+		if !ok {
+			obj.Range = "Unknown"
+			objects = append(objects, obj)
 			break
 		}
-
-		group := data[offset]
-		variation := data[offset+1]
-		qualifier := data[offset+2]
-
-		obj := DNP3Object{
-			Group:     group,
-			Variation: variation,
-			Qualifier: qualifier,
-			Range:     d.parseRange(qualifier, data[offset+3:]),
+		data = data[rangeSize:]
+		obj.Count = count
+		switch {
+		case rangeCode == 0x06:
+			obj.Range = "all"
+		case rangeCode <= 0x05:
+			obj.Range = fmt.Sprintf("range %d-%d", start, start+uint32(count)-1)
+		default:
+			obj.Range = fmt.Sprintf("count %d", count)
 		}
-
 		objects = append(objects, obj)
 
-		// Skip to next object (simplified - would need proper parsing)
-		offset += 3 + d.getRangeSize(qualifier)
-		if offset >= len(data) {
+		if obj.Group == dnp3GroupAuthentication {
+			frame.Details["secure_authentication"] = true
+			if _, exists := frame.Details["sa_message"]; !exists {
+				if name, known := d.authMessages[obj.Variation]; known {
+					frame.Details["sa_message"] = name
+				}
+			}
+		}
+
+		if !withData || rangeCode == 0x06 || count == 0 {
+			continue
+		}
+		size, ok := d.objectDataSize(obj, prefixCode, count, data)
+		if !ok {
 			break
 		}
+		d.decodeObjectValues(frame, obj, prefixCode, start, data[:size])
+		data = data[size:]
 	}
 
 	return objects
 }
 
-// parseRange parses object range based on qualifier
-func (d *DNP3Analyzer) parseRange(qualifier uint8, data []byte) string {
-	// Simplified range parsing
-	switch qualifier & 0x0F {
-	case 0x00, 0x01:
-		if len(data) >= 2 {
-			start := binary.LittleEndian.Uint16(data[0:2])
-			return fmt.Sprintf("Start: %d", start)
+// parseRange reads the range field of an object header. It returns the first index for
+// ranges, the object count, and the number of bytes consumed.
+func (d *DNP3Analyzer) parseRange(rangeCode uint8, data []byte) (uint32, int, int, bool) {
+	switch rangeCode {
+	case 0x00, 0x03:
+		if len(data) < 2 || data[1] < data[0] {
+			return 0, 0, 0, false
+		}
+		return uint32(data[0]), int(data[1]-data[0]) + 1, 2, true
+	case 0x01, 0x04:
+		if len(data) < 4 {
+			return 0, 0, 0, false
+		}
+		first, last := binary.LittleEndian.Uint16(data[0:2]), binary.LittleEndian.Uint16(data[2:4])
+		if last < first {
+			return 0, 0, 0, false
 		}
-	case 0x07, 0x08:
-		if len(data) >= 1 {
-			count := data[0]
-			return fmt.Sprintf("Count: %d", count)
+		return uint32(first), int(last-first) + 1, 4, true
+	case 0x02, 0x05:
+		if len(data) < 8 {
+			return 0, 0, 0, false
 		}
+		first, last := binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint32(data[4:8])
+		if last < first || last-first >= 0xFFFF {
+			return 0, 0, 0, false
+		}
+		return first, int(last-first) + 1, 8, true
+	case 0x06:
+		return 0, 0, 0, true
+	case 0x07, 0x0B:
+		if len(data) < 1 {
+			return 0, 0, 0, false
+		}
+		return 0, int(data[0]), 1, true
+	case 0x08:
+		if len(data) < 2 {
+			return 0, 0, 0, false
+		}
+		return 0, int(binary.LittleEndian.Uint16(data[0:2])), 2, true
+	case 0x09:
+		if len(data) < 4 {
+			return 0, 0, 0, false
+		}
+		count := binary.LittleEndian.Uint32(data[0:4])
+		if count > 0xFFFF {
+			return 0, 0, 0, false
+		}
+		return 0, int(count), 4, true
 	}
-	return "Unknown"
+	return 0, 0, 0, false
 }
 
-// getRangeSize returns the size of range data based on qualifier
-func (d *DNP3Analyzer) getRangeSize(qualifier uint8) int {
-	switch qualifier & 0x0F {
-	case 0x00, 0x01:
-		return 2 // 16-bit range
-	case 0x07, 0x08:
-		return 1 // 8-bit count
-	default:
-		return 0
+// dnp3PrefixSizes is the index or size prefix width by qualifier prefix code
+var dnp3PrefixSizes = []int{0, 1, 2, 4, 1, 2, 4}
+
+// objectDataSize returns how many bytes the values of one object header occupy
+func (d *DNP3Analyzer) objectDataSize(obj DNP3Object, prefixCode uint8, count int, data []byte) (int, bool) {
+	if int(prefixCode) >= len(dnp3PrefixSizes) {
+		return 0, false
+	}
+	prefixSize := dnp3PrefixSizes[prefixCode]
+
+	// Size prefixes give each object's length, as used by free-format objects
+	if prefixCode >= 4 {
+		offset := 0
+		for i := 0; i < count; i++ {
+			if offset+prefixSize > len(data) {
+				return 0, false
+			}
+			var objectSize int
+			switch prefixSize {
+			case 1:
+				objectSize = int(data[offset])
+			case 2:
+				objectSize = int(binary.LittleEndian.Uint16(data[offset:]))
+			case 4:
+				objectSize = int(binary.LittleEndian.Uint32(data[offset:]))
+			}
+			offset += prefixSize + objectSize
+		}
+		if offset > len(data) {
+			return 0, false
+		}
+		return offset, true
 	}
-}
 
-// parseIIN parses Internal Indication flags
-func (d *DNP3Analyzer) parseIIN(iin uint16) string {
-	var flags []string
-
-	if iin&0x0001 != 0 {
-		flags = append(flags, "ALL_STATIONS")
-	}
-	if iin&0x0002 != 0 {
-		flags = append(flags, "CLASS_1_EVENTS")
+	bits, known := d.objectSizes[uint16(obj.Group)<<8|uint16(obj.Variation)]
+	if !known {
+		return 0, false
 	}
-	if iin&0x0004 != 0 {
-		flags = append(flags, "CLASS_2_EVENTS")
+	var size int
+	if bits%8 != 0 && prefixSize == 0 {
+		// Packed bits such as binary input status
+		size = (count*bits + 7) / 8
+	} else {
+		size = count * (prefixSize + (bits+7)/8)
 	}
-	if iin&0x0008 != 0 {
-		flags = append(flags, "CLASS_3_EVENTS")
+	if size > len(data) {
+		return 0, false
 	}
-	if iin&0x0010 != 0 {
-		flags = append(flags, "NEED_TIME")
+	return size, true
+}
+
+// decodeObjectValues extracts control points and file names from request objects
+func (d *DNP3Analyzer) decodeObjectValues(frame *DNP3Frame, obj DNP3Object, prefixCode uint8, start uint32, data []byte) {
+	if frame.FunctionCode >= dnp3Response {
+		return
 	}
-	if iin&0x0020 != 0 {
-		flags = append(flags, "LOCAL_CONTROL")
+	prefixSize := dnp3PrefixSizes[prefixCode]
+
+	switch {
+	case obj.Group == dnp3GroupCROB && obj.Variation == 1 && prefixCode <= 3:
+		for i := 0; i < obj.Count; i++ {
+			index, value := d.indexedObject(data, i, prefixSize, 11, start)
+			if value == nil {
+				return
+			}
+			frame.Details["control_points"] = appendUniqueString(controlPoints(frame), fmt.Sprintf("CROB %d %s", index, crobOperation(value[0])))
+		}
+	case obj.Group == dnp3GroupAnalogOutput && prefixCode <= 3:
+		sizes := map[uint8]int{1: 5, 2: 3, 3: 5, 4: 9}
+		objectSize, known := sizes[obj.Variation]
+		if !known {
+			return
+		}
+		for i := 0; i < obj.Count; i++ {
+			index, value := d.indexedObject(data, i, prefixSize, objectSize, start)
+			if value == nil {
+				return
+			}
+			frame.Details["control_points"] = appendUniqueString(controlPoints(frame), fmt.Sprintf("AO %d = %s", index, analogOutputValue(obj.Variation, value)))
+		}
+	case obj.Group == dnp3GroupFileControl && obj.Variation == 3 && prefixCode >= 4:
+		// File command: name offset and size, creation time, permissions, auth key,
+		// file size, operational mode, max block size, request ID, file name
+		if len(data) < prefixSize+26 {
+			return
+		}
+		command := data[prefixSize:]
+		nameOffset := int(binary.LittleEndian.Uint16(command[0:2]))
+		nameSize := int(binary.LittleEndian.Uint16(command[2:4]))
+		mode := binary.LittleEndian.Uint16(command[20:22])
+		if name := boundedSlice(command, nameOffset, nameSize); name != nil {
+			frame.Details["file_name"] = string(name)
+		}
+		if frame.FunctionCode != dnp3OpenFile {
+			return
+		}
+		switch mode {
+		case 1:
+			frame.Details["file_mode"] = "read"
+		case 2:
+			frame.Details["file_mode"] = "write"
+			frame.Details["high_risk_operation"] = "File Write"
+		case 3:
+			frame.Details["file_mode"] = "append"
+			frame.Details["high_risk_operation"] = "File Write"
+		}
 	}
-	if iin&0x0040 != 0 {
-		flags = append(flags, "DEVICE_TROUBLE")
+}
+
+// indexedObject returns the point index and value bytes of the i-th object
+func (d *DNP3Analyzer) indexedObject(data []byte, i, prefixSize, objectSize int, start uint32) (uint32, []byte) {
+	offset := i * (prefixSize + objectSize)
+	if offset+prefixSize+objectSize > len(data) {
+		return 0, nil
+	}
+	index := start + uint32(i)
+	switch prefixSize {
+	case 1:
+		index = uint32(data[offset])
+	case 2:
+		index = uint32(binary.LittleEndian.Uint16(data[offset:]))
+	case 4:
+		index = binary.LittleEndian.Uint32(data[offset:])
+	}
+	return index, data[offset+prefixSize : offset+prefixSize+objectSize]
+}
+
+func controlPoints(frame *DNP3Frame) []string {
+	points, _ := frame.Details["control_points"].([]string)
+	return points
+}
+
+// crobOperation names a CROB control code, e.g. "PULSE_ON CLOSE"
+func crobOperation(code uint8) string {
+	operations := map[uint8]string{0: "NUL", 1: "PULSE_ON", 2: "PULSE_OFF", 3: "LATCH_ON", 4: "LATCH_OFF"}
+	operation, known := operations[code&0x0F]
+	if !known {
+		operation = fmt.Sprintf("0x%X", code&0x0F)
 	}
-	if iin&0x0080 != 0 {
-		flags = append(flags, "DEVICE_RESTART")
+	switch code >> 6 {
+	case 1:
+		operation += " CLOSE"
+	case 2:
+		operation += " TRIP"
 	}
+	return operation
+}
 
-	if len(flags) == 0 {
-		return "None"
+// analogOutputValue formats the value of an analog output block
+func analogOutputValue(variation uint8, value []byte) string {
+	switch variation {
+	case 1:
+		return fmt.Sprintf("%d", int32(binary.LittleEndian.Uint32(value)))
+	case 2:
+		return fmt.Sprintf("%d", int16(binary.LittleEndian.Uint16(value)))
+	case 3:
+		return fmt.Sprintf("%g", math.Float32frombits(binary.LittleEndian.Uint32(value)))
+	default:
+		return fmt.Sprintf("%g", math.Float64frombits(binary.LittleEndian.Uint64(value)))
 	}
+}
+
+// dnp3IINNames names IIN1 bits 0-7 followed by IIN2 bits 0-5
+var dnp3IINNames = []string{
+	"ALL_STATIONS", "CLASS_1_EVENTS", "CLASS_2_EVENTS", "CLASS_3_EVENTS",
+	"NEED_TIME", "LOCAL_CONTROL", "DEVICE_TROUBLE", "DEVICE_RESTART",
+	"NO_FUNC_CODE_SUPPORT", "OBJECT_UNKNOWN", "PARAMETER_ERROR", "EVENT_BUFFER_OVERFLOW",
+	"ALREADY_EXECUTING", "CONFIG_CORRUPT",
+}
 
-	result := ""
-	for i, flag := range flags {
-		if i > 0 {
-			result += ", "
+// iinFlags lists the set Internal Indication bits
+func (d *DNP3Analyzer) iinFlags(iin uint16) []string {
+	flags := []string{}
+	for bit, name := range dnp3IINNames {
+		if iin&(1<<uint(bit)) != 0 {
+			flags = append(flags, name)
 		}
-		result += flag
 	}
-	return result
+	return flags
+}
+
+// parseIIN parses Internal Indication flags
+func (d *DNP3Analyzer) parseIIN(iin uint16) string {
+	flags := d.iinFlags(iin)
+	if len(flags) == 0 {
+		return "None"
+	}
+	return strings.Join(flags, ", ")
 }
 
-// categorizeControl categorizes control field
+// categorizeControl describes the link control field, e.g. "Primary from Master"
 func (d *DNP3Analyzer) categorizeControl(control uint8) string {
-	dir := (control & 0x80) != 0
-	prm := (control & 0x40) != 0
-
-	if dir && prm {
-		return "Request from Master"
-	} else if !dir && !prm {
-		return "Response from Outstation"
-	} else if dir && !prm {
-		return "Unsolicited Response"
-	} else {
-		return "Reserved"
+	station := "Outstation"
+	if control&0x80 != 0 {
+		station = "Master"
+	}
+	if control&0x40 != 0 {
+		return "Primary from " + station
 	}
+	return "Secondary from " + station
 }
 
 // categorizeFunctionCode categorizes function codes
@@ -408,10 +834,12 @@ func (d *DNP3Analyzer) categorizeFunctionCode(code uint8) string {
 		return "Freeze Functions"
 	case code >= 0x0D && code <= 0x12:
 		return "Application Control"
-	case code >= 0x13 && code <= 0x17:
+	case code >= 0x13 && code <= 0x18:
 		return "Configuration"
-	case code >= 0x18 && code <= 0x1E:
+	case code >= 0x19 && code <= 0x1F:
 		return "File Transfer"
+	case code == 0x20 || code == 0x21 || code == 0x83:
+		return "Secure Authentication"
 	case code == 0x81:
 		return "Response"
 	case code == 0x82:
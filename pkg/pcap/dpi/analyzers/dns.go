@@ -0,0 +1,509 @@
+package analyzers
+
+import (
+	"cipgram/pkg/pcap/core"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Ports of the name resolution protocols sharing the DNS message format
+const (
+	dnsPort   uint16 = 53
+	nbnsPort  uint16 = 137
+	mdnsPort  uint16 = 5353
+	llmnrPort uint16 = 5355
+)
+
+// Resource record types that carry names or addresses
+const (
+	dnsTypeA     uint16 = 1
+	dnsTypeCNAME uint16 = 5
+	dnsTypePTR   uint16 = 12
+	dnsTypeAAAA  uint16 = 28
+	nbnsTypeNB   uint16 = 0x20
+	nbnsTypeStat uint16 = 0x21
+)
+
+// dnsMaxPointer bounds the compression pointers followed per name, and CNAME hops per answer
+const dnsMaxPointer = 16
+
+// DNSAnalyzer implements DPI for DNS and the local name protocols that share its message
+// format: multicast DNS, LLMNR and NetBIOS Name Service
+type DNSAnalyzer struct {
+	recordTypes map[uint16]string
+	nbnsOpcodes map[uint16]string
+}
+
+// NewDNSAnalyzer creates a new DNS analyzer
+func NewDNSAnalyzer() *DNSAnalyzer {
+	return &DNSAnalyzer{
+		recordTypes: map[uint16]string{
+			1:   "A",
+			2:   "NS",
+			5:   "CNAME",
+			6:   "SOA",
+			12:  "PTR",
+			15:  "MX",
+			16:  "TXT",
+			28:  "AAAA",
+			33:  "SRV",
+			47:  "NSEC",
+			64:  "SVCB",
+			65:  "HTTPS",
+			255: "ANY",
+		},
+		nbnsOpcodes: map[uint16]string{
+			0: "Query",
+			5: "Registration",
+			6: "Release",
+			7: "WACK",
+			8: "Refresh",
+		},
+	}
+}
+
+// CanAnalyze determines if this analyzer can process the packet
+func (d *DNSAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
+	variant, message := d.message(packet)
+	return variant != "" && d.looksLikeDNS(variant, message)
+}
+
+// Analyze performs DNS protocol analysis
+func (d *DNSAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
+	variant, message := d.message(packet)
+	return d.analyzeMessage(packet, variant, message)
+}
+
+// PDULength returns the length of the length-prefixed DNS over TCP message at the start
+// of data
+func (d *DNSAnalyzer) PDULength(data []byte) int {
+	if len(data) < 2 {
+		return 0
+	}
+	length := int(binary.BigEndian.Uint16(data[0:2]))
+	if length < 12 {
+		return -1
+	}
+	return 2 + length
+}
+
+// AnalyzePDU analyzes one message of a DNS over TCP stream
+func (d *DNSAnalyzer) AnalyzePDU(packet gopacket.Packet, pdu []byte) *core.AnalysisResult {
+	if len(pdu) < 2 {
+		return nil
+	}
+	return d.analyzeMessage(packet, "DNS", pdu[2:])
+}
+
+// analyzeMessage analyzes a DNS message of the given variant
+func (d *DNSAnalyzer) analyzeMessage(packet gopacket.Packet, variant string, message []byte) *core.AnalysisResult {
+	if variant == "" || !d.looksLikeDNS(variant, message) {
+		return nil
+	}
+
+	msg := &DNSMessage{
+		Variant:  variant,
+		Resolved: make(map[string][]string),
+	}
+	if err := d.parseMessage(message, msg); err != nil {
+		return nil
+	}
+	if variant == "NBNS" && msg.Response {
+		if network := packet.NetworkLayer(); network != nil {
+			// Node status responses list the names of the responding host
+			for _, name := range msg.StatusNames {
+				msg.addResolved(network.NetworkFlow().Src().String(), name)
+			}
+		}
+	}
+
+	subprotocol := variant + " Query"
+	direction := "request"
+	if msg.Response {
+		subprotocol = variant + " Response"
+		direction = "response"
+	}
+
+	details := map[string]interface{}{
+		"dns_variant":    variant,
+		"direction":      direction,
+		"transaction_id": msg.TransactionID,
+		"opcode":         msg.Opcode,
+		"response_code":  msg.ResponseCode,
+		"query_count":    msg.QueryCount,
+		"answer_count":   msg.AnswerCount,
+	}
+	if variant == "NBNS" {
+		details["nbns_operation"] = d.nbnsOpcodes[msg.Opcode]
+	}
+	if len(msg.Queries) > 0 {
+		details["queries"] = msg.Queries
+		details["query_types"] = msg.QueryTypes
+	}
+	if len(msg.Answers) > 0 {
+		details["answers"] = msg.Answers
+	}
+	if len(msg.Resolved) > 0 {
+		details["resolved_addresses"] = msg.Resolved
+	}
+
+	return &core.AnalysisResult{
+		Protocol:    "DNS",
+		Subprotocol: subprotocol,
+		Confidence:  0.9,
+		Details:     details,
+		Metadata: map[string]string{
+			"protocol_type": "network",
+			"layer":         "application",
+		},
+	}
+}
+
+// GetProtocolName returns the protocol name
+func (d *DNSAnalyzer) GetProtocolName() string {
+	return "DNS"
+}
+
+// GetConfidenceThreshold returns the minimum confidence threshold
+func (d *DNSAnalyzer) GetConfidenceThreshold() float32 {
+	return 0.9
+}
+
+// DNSMessage represents a parsed DNS, mDNS, LLMNR or NBNS message
+type DNSMessage struct {
+	Variant       string // DNS, mDNS, LLMNR or NBNS
+	TransactionID uint16
+	Response      bool
+	Opcode        uint16
+	ResponseCode  uint16
+	QueryCount    uint16
+	AnswerCount   uint16
+	Queries       []string
+	QueryTypes    []string
+	Answers       []string            // e.g. "plc1.plant.local A 10.0.1.20"
+	Resolved      map[string][]string // Address -> names, from answer and additional records
+	StatusNames   []string            // Unique names of an NBNS node status response
+}
+
+func (m *DNSMessage) addResolved(address, name string) {
+	name = strings.TrimSuffix(name, ".")
+	if address == "" || name == "" {
+		return
+	}
+	for _, existing := range m.Resolved[address] {
+		if strings.EqualFold(existing, name) {
+			return
+		}
+	}
+	m.Resolved[address] = append(m.Resolved[address], name)
+}
+
+// message returns the protocol variant and DNS message of a packet, or "" if the
+// packet is not on a name resolution port. DNS over TCP carries a two-byte length prefix;
+// a message continuing in later segments is returned truncated.
+func (d *DNSAnalyzer) message(packet gopacket.Packet) (string, []byte) {
+	if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
+		udp := udpLayer.(*layers.UDP)
+		for _, port := range []uint16{uint16(udp.SrcPort), uint16(udp.DstPort)} {
+			switch port {
+			case dnsPort:
+				return "DNS", udp.Payload
+			case mdnsPort:
+				return "mDNS", udp.Payload
+			case llmnrPort:
+				return "LLMNR", udp.Payload
+			case nbnsPort:
+				return "NBNS", udp.Payload
+			}
+		}
+		return "", nil
+	}
+
+	if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+		tcp := tcpLayer.(*layers.TCP)
+		if uint16(tcp.SrcPort) != dnsPort && uint16(tcp.DstPort) != dnsPort {
+			return "", nil
+		}
+		payload := tcp.Payload
+		if len(payload) < 2 {
+			return "", nil
+		}
+		if length := int(binary.BigEndian.Uint16(payload[0:2])); length < len(payload)-2 {
+			return "DNS", payload[2 : 2+length]
+		}
+		return "DNS", payload[2:]
+	}
+
+	return "", nil
+}
+
+// looksLikeDNS validates the header opcode and response code for the variant
+func (d *DNSAnalyzer) looksLikeDNS(variant string, payload []byte) bool {
+	if len(payload) < 12 {
+		return false
+	}
+
+	flags := binary.BigEndian.Uint16(payload[2:4])
+	opcode := (flags >> 11) & 0xF
+	rcode := flags & 0xF
+
+	if variant == "NBNS" {
+		_, known := d.nbnsOpcodes[opcode]
+		return known && rcode <= 7
+	}
+	// Query, inverse query and status; 4 and 5 are NOTIFY and UPDATE
+	return opcode <= 5 && opcode != 3 && rcode <= 10
+}
+
+// parseMessage decodes the header, questions, answers and additional records. Authority
+// records are skipped: in mDNS they carry probed names that are not yet confirmed.
+func (d *DNSAnalyzer) parseMessage(payload []byte, msg *DNSMessage) error {
+	flags := binary.BigEndian.Uint16(payload[2:4])
+	msg.TransactionID = binary.BigEndian.Uint16(payload[0:2])
+	msg.Response = flags&0x8000 != 0
+	msg.Opcode = (flags >> 11) & 0xF
+	msg.ResponseCode = flags & 0xF
+	msg.QueryCount = binary.BigEndian.Uint16(payload[4:6])
+	msg.AnswerCount = binary.BigEndian.Uint16(payload[6:8])
+	authorityCount := binary.BigEndian.Uint16(payload[8:10])
+	additionalCount := binary.BigEndian.Uint16(payload[10:12])
+
+	offset := 12
+	for i := 0; i < int(msg.QueryCount); i++ {
+		name, next, err := d.readName(payload, offset, msg.Variant)
+		if err != nil {
+			return err
+		}
+		if next+4 > len(payload) {
+			return fmt.Errorf("truncated question")
+		}
+		qtype := binary.BigEndian.Uint16(payload[next : next+2])
+		msg.Queries = append(msg.Queries, name)
+		msg.QueryTypes = append(msg.QueryTypes, d.typeName(msg.Variant, qtype))
+		offset = next + 4
+	}
+
+	// CNAME chains resolve the queried name through aliases
+	aliases := make(map[string]string)
+	sections := []struct {
+		count    uint16
+		recorded bool
+	}{
+		{msg.AnswerCount, true},
+		{authorityCount, false},
+		{additionalCount, true},
+	}
+	for _, section := range sections {
+		for i := 0; i < int(section.count); i++ {
+			next, err := d.parseRecord(payload, offset, msg, section.recorded, aliases)
+			if err != nil {
+				// Keep what was decoded from a truncated message
+				return nil
+			}
+			offset = next
+		}
+	}
+	return nil
+}
+
+// parseRecord decodes one resource record, returning the offset of the next one
+func (d *DNSAnalyzer) parseRecord(payload []byte, offset int, msg *DNSMessage, recorded bool, aliases map[string]string) (int, error) {
+	name, next, err := d.readName(payload, offset, msg.Variant)
+	if err != nil {
+		return 0, err
+	}
+	if next+10 > len(payload) {
+		return 0, fmt.Errorf("truncated record header")
+	}
+	rtype := binary.BigEndian.Uint16(payload[next : next+2])
+	length := int(binary.BigEndian.Uint16(payload[next+8 : next+10]))
+	start := next + 10
+	if start+length > len(payload) {
+		return 0, fmt.Errorf("truncated record data")
+	}
+	rdata := payload[start : start+length]
+	end := start + length
+	if !recorded {
+		return end, nil
+	}
+
+	switch {
+	case msg.Variant == "NBNS" && rtype == nbnsTypeNB:
+		// Each entry is NB flags followed by an IPv4 address
+		for i := 0; i+6 <= len(rdata); i += 6 {
+			address := net.IP(rdata[i+2 : i+6]).String()
+			msg.Answers = append(msg.Answers, fmt.Sprintf("%s NB %s", name, address))
+			msg.addResolved(address, name)
+		}
+	case msg.Variant == "NBNS" && rtype == nbnsTypeStat:
+		msg.StatusNames = append(msg.StatusNames, d.nodeStatusNames(rdata)...)
+	case rtype == dnsTypeA && length == 4, rtype == dnsTypeAAAA && length == 16:
+		address := net.IP(rdata).String()
+		msg.Answers = append(msg.Answers, fmt.Sprintf("%s %s %s", name, d.typeName(msg.Variant, rtype), address))
+		msg.addResolved(address, name)
+		alias := aliases[strings.ToLower(name)]
+		for hops := 0; alias != "" && hops < dnsMaxPointer; hops++ {
+			msg.addResolved(address, alias)
+			alias = aliases[strings.ToLower(alias)]
+		}
+	case rtype == dnsTypeCNAME:
+		if target, _, err := d.readName(payload, start, msg.Variant); err == nil {
+			msg.Answers = append(msg.Answers, fmt.Sprintf("%s CNAME %s", name, target))
+			aliases[strings.ToLower(target)] = name
+		}
+	case rtype == dnsTypePTR:
+		target, _, err := d.readName(payload, start, msg.Variant)
+		if err != nil {
+			break
+		}
+		msg.Answers = append(msg.Answers, fmt.Sprintf("%s PTR %s", name, target))
+		if address := reverseLookupAddress(name); address != "" {
+			msg.addResolved(address, target)
+		}
+	}
+	return end, nil
+}
+
+// readName decodes a possibly compressed domain name. NBNS names are additionally
+// first-level encoded; their 16th byte, the NetBIOS suffix, is dropped.
+func (d *DNSAnalyzer) readName(payload []byte, offset int, variant string) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if offset >= len(payload) {
+			return "", 0, fmt.Errorf("truncated name")
+		}
+		length := int(payload[offset])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			name := strings.Join(labels, ".")
+			if variant == "NBNS" && len(labels) > 0 {
+				name = decodeNetBIOSName(labels[0])
+			}
+			return name, next, nil
+		case length&0xC0 == 0xC0:
+			if offset+1 >= len(payload) {
+				return "", 0, fmt.Errorf("truncated name pointer")
+			}
+			if jumps++; jumps > dnsMaxPointer {
+				return "", 0, fmt.Errorf("name pointer loop")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(payload[offset:offset+2]) & 0x3FFF)
+		case length > 63:
+			return "", 0, fmt.Errorf("invalid label length %d", length)
+		default:
+			if offset+1+length > len(payload) {
+				return "", 0, fmt.Errorf("truncated label")
+			}
+			labels = append(labels, string(payload[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+}
+
+// decodeNetBIOSName reverses NetBIOS first-level encoding, where each byte is sent as two
+// letters 'A' + nibble, and trims the space padding of the 15-character name
+func decodeNetBIOSName(encoded string) string {
+	if len(encoded) != 32 {
+		return encoded
+	}
+	decoded := make([]byte, 16)
+	for i := 0; i < 16; i++ {
+		high, low := encoded[2*i]-'A', encoded[2*i+1]-'A'
+		if high > 0x0F || low > 0x0F {
+			return encoded
+		}
+		decoded[i] = high<<4 | low
+	}
+	if decoded[0] == '*' {
+		return "*"
+	}
+	return strings.TrimRight(string(decoded[:15]), " \x00")
+}
+
+// nodeStatusNames lists the unique (non-group) names of an NBSTAT response
+func (d *DNSAnalyzer) nodeStatusNames(rdata []byte) []string {
+	if len(rdata) < 1 {
+		return nil
+	}
+	var names []string
+	count := int(rdata[0])
+	for i := 0; i < count && 1+(i+1)*18 <= len(rdata); i++ {
+		entry := rdata[1+i*18 : 1+(i+1)*18]
+		flags := binary.BigEndian.Uint16(entry[16:18])
+		if flags&0x8000 != 0 { // Group name
+			continue
+		}
+		name := strings.TrimRight(string(entry[:15]), " \x00")
+		duplicate := false
+		for _, existing := range names {
+			duplicate = duplicate || existing == name
+		}
+		if name != "" && !duplicate {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// typeName names a record type; NBNS reuses 0x20 and 0x21 for NB and NBSTAT
+func (d *DNSAnalyzer) typeName(variant string, rtype uint16) string {
+	if variant == "NBNS" {
+		switch rtype {
+		case nbnsTypeNB:
+			return "NB"
+		case nbnsTypeStat:
+			return "NBSTAT"
+		}
+	}
+	if name, known := d.recordTypes[rtype]; known {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", rtype)
+}
+
+// reverseLookupAddress converts a PTR owner name ("20.1.0.10.in-addr.arpa" or an
+// ip6.arpa nibble name) to the address it describes, or "" if it is neither
+func reverseLookupAddress(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa"):
+		parts := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+		if len(parts) != 4 {
+			return ""
+		}
+		for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+			parts[i], parts[j] = parts[j], parts[i]
+		}
+		if ip := net.ParseIP(strings.Join(parts, ".")); ip != nil {
+			return ip.String()
+		}
+	case strings.HasSuffix(name, ".ip6.arpa"):
+		nibbles := strings.Split(strings.TrimSuffix(name, ".ip6.arpa"), ".")
+		if len(nibbles) != 32 {
+			return ""
+		}
+		var hex strings.Builder
+		for i := len(nibbles) - 1; i >= 0; i-- {
+			hex.WriteString(nibbles[i])
+			if i%4 == 0 && i > 0 {
+				hex.WriteByte(':')
+			}
+		}
+		if ip := net.ParseIP(hex.String()); ip != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
@@ -4,11 +4,28 @@ import (
 	"cipgram/pkg/pcap/core"
 	"encoding/binary"
 	"fmt"
+	"strings"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
 )
 
+// Common Packet Format item types
+const (
+	cpfCIPIdentity      uint16 = 0x000C
+	cpfConnectedAddress uint16 = 0x00A1
+	cpfConnectedData    uint16 = 0x00B1
+	cpfUnconnectedData  uint16 = 0x00B2
+	cpfSequencedAddress uint16 = 0x8002
+)
+
+// Connection Manager services
+const (
+	cipForwardClose     uint8 = 0x4E
+	cipForwardOpen      uint8 = 0x54
+	cipLargeForwardOpen uint8 = 0x5B
+)
+
 // EtherNetIPAnalyzer implements DPI for EtherNet/IP protocol
 type EtherNetIPAnalyzer struct {
 	commands    map[uint16]string
@@ -29,14 +46,22 @@ func NewEtherNetIPAnalyzer() *EtherNetIPAnalyzer {
 		},
 		cipServices: map[uint8]string{
 			0x01: "Get_Attributes_All",
+			0x02: "Set_Attributes_All",
+			0x03: "Get_Attribute_List",
+			0x04: "Set_Attribute_List",
+			0x05: "Reset",
+			0x08: "Create",
+			0x09: "Delete",
+			0x0A: "Multiple_Service_Packet",
 			0x0E: "Get_Attribute_Single",
 			0x10: "Set_Attribute_Single",
-			0x4C: "Create",
-			0x4E: "Delete",
-			0x52: "Multiple_Service_Packet",
-			0x54: "Read_Tag",
-			0x55: "Write_Tag",
 			0x4B: "Execute_PCCC",
+			0x4C: "Read_Tag",
+			0x4D: "Write_Tag",
+			0x4E: "Forward_Close",
+			0x52: "Unconnected_Send",
+			0x54: "Forward_Open",
+			0x5B: "Large_Forward_Open",
 		},
 		cipClasses: map[uint16]string{
 			0x0001: "Identity",
@@ -53,6 +78,12 @@ func NewEtherNetIPAnalyzer() *EtherNetIPAnalyzer {
 
 // CanAnalyze determines if this analyzer can process the packet
 func (e *EtherNetIPAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
+	if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
+		udp := udpLayer.(*layers.UDP)
+		// Implicit I/O (2222) and ListIdentity discovery (44818)
+		return udp.SrcPort == 2222 || udp.DstPort == 2222 || udp.SrcPort == 44818 || udp.DstPort == 44818
+	}
+
 	tcpLayer := packet.Layer(layers.LayerTypeTCP)
 	if tcpLayer == nil {
 		return false
@@ -76,20 +107,47 @@ func (e *EtherNetIPAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
 
 // Analyze performs EtherNet/IP protocol analysis
 func (e *EtherNetIPAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
-	tcpLayer := packet.Layer(layers.LayerTypeTCP)
-	if tcpLayer == nil {
-		return nil
+	if udpLayer := packet.Layer(layers.LayerTypeUDP); udpLayer != nil {
+		udp := udpLayer.(*layers.UDP)
+		if udp.SrcPort == 2222 || udp.DstPort == 2222 {
+			return e.analyzeImplicitIO(udp.Payload)
+		}
+		return e.analyzeEncapsulation(udp.Payload, udp.SrcPort == 44818)
+	}
+	if tcpLayer := packet.Layer(layers.LayerTypeTCP); tcpLayer != nil {
+		return e.AnalyzePDU(packet, tcpLayer.(*layers.TCP).Payload)
 	}
+	return nil
+}
 
-	tcp := tcpLayer.(*layers.TCP)
-	payload := tcp.Payload
+// PDULength returns the length of the encapsulation message at the start of data
+func (e *EtherNetIPAnalyzer) PDULength(data []byte) int {
+	if len(data) < 24 {
+		return 0
+	}
+	if !e.looksLikeEtherNetIP(data) {
+		return -1
+	}
+	return 24 + int(binary.LittleEndian.Uint16(data[2:4]))
+}
+
+// AnalyzePDU analyzes one encapsulation message of the TCP stream that packet belongs to
+func (e *EtherNetIPAnalyzer) AnalyzePDU(packet gopacket.Packet, pdu []byte) *core.AnalysisResult {
+	tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !ok {
+		return nil
+	}
+	return e.analyzeEncapsulation(pdu, tcp.SrcPort == 44818 && tcp.DstPort != 44818)
+}
 
+// analyzeEncapsulation analyzes an encapsulation message carried over TCP or UDP
+func (e *EtherNetIPAnalyzer) analyzeEncapsulation(payload []byte, isResponse bool) *core.AnalysisResult {
 	if len(payload) < 24 {
 		return nil
 	}
 
 	// Parse EtherNet/IP encapsulation header
-	enip := e.parseEtherNetIP(payload)
+	enip := e.parseEtherNetIP(payload, isResponse)
 	if enip == nil {
 		return nil
 	}
@@ -130,6 +188,7 @@ type EtherNetIPFrame struct {
 	Status        uint32
 	SenderContext uint64
 	Options       uint32
+	IsResponse    bool // Sent from port 44818
 	Data          []byte
 	CIPService    string
 	CIPClass      string
@@ -140,8 +199,14 @@ type EtherNetIPFrame struct {
 	Metadata      map[string]string
 }
 
+// cpfItem is one Common Packet Format item
+type cpfItem struct {
+	Type uint16
+	Data []byte
+}
+
 // parseEtherNetIP parses an EtherNet/IP encapsulation header
-func (e *EtherNetIPAnalyzer) parseEtherNetIP(payload []byte) *EtherNetIPFrame {
+func (e *EtherNetIPAnalyzer) parseEtherNetIP(payload []byte, isResponse bool) *EtherNetIPFrame {
 	if len(payload) < 24 {
 		return nil
 	}
@@ -168,6 +233,7 @@ func (e *EtherNetIPAnalyzer) parseEtherNetIP(payload []byte) *EtherNetIPFrame {
 		Status:        status,
 		SenderContext: senderContext,
 		Options:       options,
+		IsResponse:    isResponse,
 		Confidence:    0.95,
 		Details:       make(map[string]interface{}),
 		Metadata:      make(map[string]string),
@@ -182,20 +248,85 @@ func (e *EtherNetIPAnalyzer) parseEtherNetIP(payload []byte) *EtherNetIPFrame {
 	frame.Details["command"] = commandName
 	frame.Details["session_handle"] = sessionHandle
 	frame.Details["status"] = status
+	frame.Details["sender_context"] = senderContext
 	frame.Details["data_length"] = len(frame.Data)
+	frame.Details["direction"] = "request"
+	if isResponse {
+		frame.Details["direction"] = "response"
+	}
 
 	// Add metadata
 	frame.Metadata["protocol_version"] = "TCP"
 	frame.Metadata["command_category"] = e.categorizeCommand(command)
 
-	// Parse CIP data if present
-	if len(frame.Data) > 0 && (command == 0x006F || command == 0x0070) {
-		e.parseCIPData(frame)
+	switch command {
+	case 0x006F, 0x0070:
+		// Interface handle (4 bytes) and timeout (2 bytes) precede the CPF items
+		if len(frame.Data) > 6 {
+			e.parseCIPData(frame, parseCPF(frame.Data[6:]))
+		}
+	case 0x0064:
+		// ListIdentity replies carry a CIP Identity item
+		for _, item := range parseCPF(frame.Data) {
+			if item.Type == cpfCIPIdentity {
+				e.parseListIdentityItem(frame, item.Data)
+			}
+		}
 	}
 
 	return frame
 }
 
+// analyzeImplicitIO parses a class 0/1 implicit I/O datagram sent to UDP 2222
+func (e *EtherNetIPAnalyzer) analyzeImplicitIO(payload []byte) *core.AnalysisResult {
+	details := make(map[string]interface{})
+	for _, item := range parseCPF(payload) {
+		switch item.Type {
+		case cpfSequencedAddress:
+			if len(item.Data) >= 8 {
+				details["connection_id"] = binary.LittleEndian.Uint32(item.Data[0:4])
+				details["sequence_number"] = binary.LittleEndian.Uint32(item.Data[4:8])
+			}
+		case cpfConnectedData:
+			details["data_length"] = len(item.Data)
+		}
+	}
+
+	if _, ok := details["connection_id"]; !ok {
+		return nil
+	}
+
+	return &core.AnalysisResult{
+		Protocol:    "EtherNet/IP I/O",
+		Subprotocol: "Implicit I/O",
+		Confidence:  0.95,
+		Details:     details,
+		Metadata:    map[string]string{"protocol_version": "UDP"},
+	}
+}
+
+// parseCPF splits Common Packet Format data into items
+func parseCPF(data []byte) []cpfItem {
+	if len(data) < 2 {
+		return nil
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[0:2]))
+	offset := 2
+	var items []cpfItem
+	for i := 0; i < count && offset+4 <= len(data); i++ {
+		itemType := binary.LittleEndian.Uint16(data[offset : offset+2])
+		itemLength := int(binary.LittleEndian.Uint16(data[offset+2 : offset+4]))
+		offset += 4
+		if offset+itemLength > len(data) {
+			break
+		}
+		items = append(items, cpfItem{Type: itemType, Data: data[offset : offset+itemLength]})
+		offset += itemLength
+	}
+	return items
+}
+
 // looksLikeEtherNetIP performs heuristic check for EtherNet/IP content
 func (e *EtherNetIPAnalyzer) looksLikeEtherNetIP(payload []byte) bool {
 	if len(payload) < 24 {
@@ -232,88 +363,270 @@ func (e *EtherNetIPAnalyzer) categorizeCommand(command uint16) string {
 	}
 }
 
-// parseCIPData parses CIP (Common Industrial Protocol) data
-func (e *EtherNetIPAnalyzer) parseCIPData(frame *EtherNetIPFrame) {
-	data := frame.Data
+// parseCIPData parses the CIP (Common Industrial Protocol) message carried in the CPF items
+func (e *EtherNetIPAnalyzer) parseCIPData(frame *EtherNetIPFrame, items []cpfItem) {
+	var data []byte
+	for _, item := range items {
+		switch item.Type {
+		case cpfConnectedAddress:
+			if len(item.Data) >= 4 {
+				frame.Details["connection_id"] = binary.LittleEndian.Uint32(item.Data[0:4])
+			}
+		case cpfUnconnectedData:
+			data = item.Data
+		case cpfConnectedData:
+			// Connected messages start with a 2 byte sequence count
+			if len(item.Data) >= 2 {
+				data = item.Data[2:]
+			}
+		}
+	}
+
 	if len(data) < 2 {
 		return
 	}
 
-	// Skip interface handle and timeout for SendRRData
-	if frame.Command == 0x006F && len(data) >= 6 {
-		data = data[6:] // Skip interface handle (4 bytes) + timeout (2 bytes)
+	// Parse CIP service code; the high bit marks a reply
+	serviceCode := data[0] & 0x7F
+	isReply := data[0]&0x80 != 0
+	serviceName, exists := e.cipServices[serviceCode]
+	if !exists {
+		serviceName = fmt.Sprintf("Unknown Service (0x%02X)", serviceCode)
 	}
+	frame.CIPService = serviceName
+	frame.Details["cip_service"] = serviceName
+	frame.Details["cip_service_code"] = serviceCode
+	frame.Metadata["cip_service_category"] = e.categorizeCIPService(serviceCode)
 
-	// Skip connection ID and sequence for SendUnitData
-	if frame.Command == 0x0070 && len(data) >= 6 {
-		data = data[6:] // Skip connection ID (4 bytes) + sequence (2 bytes)
+	if isReply {
+		e.parseCIPReply(frame, serviceCode, data)
+		return
 	}
 
-	if len(data) < 2 {
+	// Request: path size in words, path, then service data
+	pathSize := int(data[1]) * 2
+	if len(data) < 2+pathSize {
 		return
 	}
+	e.parseCIPPath(frame, data[2:2+pathSize])
 
-	// Parse CIP service code
-	serviceCode := data[0]
-	serviceName, exists := e.cipServices[serviceCode]
-	if exists {
-		frame.CIPService = serviceName
-		frame.Details["cip_service"] = serviceName
-		frame.Details["cip_service_code"] = serviceCode
-	} else {
-		frame.CIPService = fmt.Sprintf("Unknown Service (0x%02X)", serviceCode)
-		frame.Details["cip_service"] = frame.CIPService
-		frame.Details["cip_service_code"] = serviceCode
+	classID, _ := frame.Details["cip_class_id"].(uint16)
+	if classID != 0x06 {
+		return
 	}
-
-	// Parse class/instance/attribute if present
-	if len(data) >= 4 {
-		e.parseCIPPath(frame, data[1:])
+	switch serviceCode {
+	case cipForwardOpen, cipLargeForwardOpen:
+		e.parseForwardOpenRequest(frame, data[2+pathSize:], serviceCode == cipLargeForwardOpen)
+	case cipForwardClose:
+		e.parseForwardCloseRequest(frame, data[2+pathSize:])
 	}
-
-	frame.Metadata["cip_service_category"] = e.categorizeCIPService(serviceCode)
 }
 
-// parseCIPPath parses CIP path information
-func (e *EtherNetIPAnalyzer) parseCIPPath(frame *EtherNetIPFrame, data []byte) {
-	if len(data) < 3 {
+// parseCIPReply parses the general status and the service specific reply data
+func (e *EtherNetIPAnalyzer) parseCIPReply(frame *EtherNetIPFrame, serviceCode uint8, data []byte) {
+	frame.Details["cip_reply"] = true
+	if len(data) < 4 {
 		return
 	}
 
-	pathSize := data[0] // Path size in words
-	if pathSize == 0 || len(data) < int(pathSize*2+1) {
+	generalStatus := data[2]
+	frame.Details["cip_general_status"] = generalStatus
+	replyData := data[4:]
+	if extended := int(data[3]) * 2; len(replyData) >= extended {
+		replyData = replyData[extended:]
+	}
+	if generalStatus != 0 {
 		return
 	}
 
-	pathData := data[1 : pathSize*2+1]
+	switch serviceCode {
+	case cipForwardOpen, cipLargeForwardOpen:
+		e.parseForwardOpenReply(frame, replyData)
+	case 0x01:
+		// The reply does not name the class; callers match it to an Identity request
+		e.parseIdentityAttributes(frame, replyData)
+	}
+}
+
+// parseCIPPath parses CIP logical and port segments into class/instance/attribute details
+func (e *EtherNetIPAnalyzer) parseCIPPath(frame *EtherNetIPFrame, path []byte) {
+	var rendered []string
+	for i := 0; i < len(path); {
+		segment := path[i]
+
+		// Port segment: port number in the low nibble, link address in the next byte
+		if segment&0xE0 == 0x00 {
+			if i+1 >= len(path) {
+				break
+			}
+			rendered = append(rendered, fmt.Sprintf("port%d:%d", segment&0x0F, path[i+1]))
+			i += 2
+			continue
+		}
 
-	// Simple path parsing - look for class/instance/attribute
-	for i := 0; i < len(pathData)-1; i += 2 {
-		segment := pathData[i]
-		value := pathData[i+1]
+		// Logical segments with 8 bit (format 0) or 16 bit (format 1) values
+		var value uint16
+		switch segment & 0x03 {
+		case 0x00:
+			if i+1 >= len(path) {
+				return
+			}
+			value = uint16(path[i+1])
+			i += 2
+		case 0x01:
+			if i+3 >= len(path) {
+				return
+			}
+			value = binary.LittleEndian.Uint16(path[i+2 : i+4])
+			i += 4
+		default:
+			// 32 bit and reserved formats are not used in the paths we decode
+			frame.Details["connection_path"] = strings.Join(rendered, "/")
+			return
+		}
 
-		switch segment {
+		switch segment & 0xFC {
 		case 0x20: // Class ID
-			classID := uint16(value)
-			className, exists := e.cipClasses[classID]
+			className, exists := e.cipClasses[value]
 			if exists {
 				frame.CIPClass = className
-				frame.Details["cip_class"] = className
 			} else {
-				frame.CIPClass = fmt.Sprintf("Unknown Class (0x%04X)", classID)
-				frame.Details["cip_class"] = frame.CIPClass
+				frame.CIPClass = fmt.Sprintf("Unknown Class (0x%04X)", value)
+			}
+			frame.Details["cip_class"] = frame.CIPClass
+			frame.Details["cip_class_id"] = value
+			if exists {
+				rendered = append(rendered, className)
+			} else {
+				rendered = append(rendered, fmt.Sprintf("0x%02X", value))
 			}
-			frame.Details["cip_class_id"] = classID
 
 		case 0x24: // Instance ID
-			frame.CIPInstance = uint16(value)
-			frame.Details["cip_instance"] = frame.CIPInstance
+			frame.CIPInstance = value
+			frame.Details["cip_instance"] = value
+			rendered = append(rendered, fmt.Sprintf("%d", value))
+
+		case 0x2C: // Connection point
+			rendered = append(rendered, fmt.Sprintf("cp:%d", value))
 
 		case 0x30: // Attribute ID
-			frame.CIPAttribute = uint16(value)
-			frame.Details["cip_attribute"] = frame.CIPAttribute
+			frame.CIPAttribute = value
+			frame.Details["cip_attribute"] = value
+			rendered = append(rendered, fmt.Sprintf("%d", value))
 		}
 	}
+	frame.Details["connection_path"] = strings.Join(rendered, "/")
+}
+
+// parseForwardOpenRequest decodes the connection parameters of a (Large_)Forward_Open request
+func (e *EtherNetIPAnalyzer) parseForwardOpenRequest(frame *EtherNetIPFrame, data []byte, large bool) {
+	paramSize := 2
+	if large {
+		paramSize = 4
+	}
+	fixed := 22 + 2*(4+paramSize) + 2
+	if len(data) < fixed {
+		return
+	}
+
+	frame.Details["forward_open"] = "request"
+	frame.Details["large_forward_open"] = large
+	frame.Details["ot_connection_id"] = binary.LittleEndian.Uint32(data[2:6])
+	frame.Details["to_connection_id"] = binary.LittleEndian.Uint32(data[6:10])
+	frame.Details["connection_serial"] = binary.LittleEndian.Uint16(data[10:12])
+	frame.Details["originator_vendor_id"] = binary.LittleEndian.Uint16(data[12:14])
+	frame.Details["originator_serial"] = binary.LittleEndian.Uint32(data[14:18])
+
+	offset := 22
+	otRPI := binary.LittleEndian.Uint32(data[offset : offset+4])
+	otSize := connectionSize(data[offset+4:offset+4+paramSize], large)
+	offset += 4 + paramSize
+	toRPI := binary.LittleEndian.Uint32(data[offset : offset+4])
+	toSize := connectionSize(data[offset+4:offset+4+paramSize], large)
+	offset += 4 + paramSize
+
+	frame.Details["ot_rpi"] = otRPI
+	frame.Details["ot_connection_size"] = otSize
+	frame.Details["to_rpi"] = toRPI
+	frame.Details["to_connection_size"] = toSize
+	frame.Details["transport_trigger"] = data[offset]
+
+	// The connection path names the target's assembly instances
+	pathSize := int(data[offset+1]) * 2
+	if len(data) >= offset+2+pathSize {
+		pathFrame := &EtherNetIPFrame{Details: make(map[string]interface{})}
+		e.parseCIPPath(pathFrame, data[offset+2:offset+2+pathSize])
+		frame.Details["connection_path"] = pathFrame.Details["connection_path"]
+	}
+}
+
+// parseForwardOpenReply decodes the connection IDs assigned in a successful Forward_Open reply
+func (e *EtherNetIPAnalyzer) parseForwardOpenReply(frame *EtherNetIPFrame, data []byte) {
+	if len(data) < 26 {
+		return
+	}
+
+	frame.Details["forward_open"] = "reply"
+	frame.Details["ot_connection_id"] = binary.LittleEndian.Uint32(data[0:4])
+	frame.Details["to_connection_id"] = binary.LittleEndian.Uint32(data[4:8])
+	frame.Details["connection_serial"] = binary.LittleEndian.Uint16(data[8:10])
+	frame.Details["originator_vendor_id"] = binary.LittleEndian.Uint16(data[10:12])
+	frame.Details["originator_serial"] = binary.LittleEndian.Uint32(data[12:16])
+	frame.Details["ot_api"] = binary.LittleEndian.Uint32(data[16:20])
+	frame.Details["to_api"] = binary.LittleEndian.Uint32(data[20:24])
+}
+
+// parseForwardCloseRequest decodes the connection triad of a Forward_Close request
+func (e *EtherNetIPAnalyzer) parseForwardCloseRequest(frame *EtherNetIPFrame, data []byte) {
+	if len(data) < 10 {
+		return
+	}
+
+	frame.Details["forward_close"] = true
+	frame.Details["connection_serial"] = binary.LittleEndian.Uint16(data[2:4])
+	frame.Details["originator_vendor_id"] = binary.LittleEndian.Uint16(data[4:6])
+	frame.Details["originator_serial"] = binary.LittleEndian.Uint32(data[6:10])
+}
+
+// parseListIdentityItem decodes the CIP Identity item of a ListIdentity reply
+func (e *EtherNetIPAnalyzer) parseListIdentityItem(frame *EtherNetIPFrame, data []byte) {
+	// Encapsulation version (2 bytes) and socket address (16 bytes) precede the identity attributes
+	if len(data) < 18 {
+		return
+	}
+	if e.parseIdentityAttributes(frame, data[18:]) {
+		frame.Details["identity_source"] = "list_identity"
+	}
+}
+
+// parseIdentityAttributes decodes Identity object attributes 1-7 as returned by
+// Get_Attributes_All and ListIdentity. It reports false when the layout does not fit.
+func (e *EtherNetIPAnalyzer) parseIdentityAttributes(frame *EtherNetIPFrame, data []byte) bool {
+	if len(data) < 15 {
+		return false
+	}
+	nameLength := int(data[14])
+	// The product name may be followed by the state attribute
+	if len(data) != 15+nameLength && len(data) != 16+nameLength {
+		return false
+	}
+
+	frame.Details["identity_vendor_id"] = binary.LittleEndian.Uint16(data[0:2])
+	frame.Details["identity_device_type"] = binary.LittleEndian.Uint16(data[2:4])
+	frame.Details["identity_product_code"] = binary.LittleEndian.Uint16(data[4:6])
+	frame.Details["identity_revision"] = fmt.Sprintf("%d.%d", data[6], data[7])
+	frame.Details["identity_serial"] = binary.LittleEndian.Uint32(data[10:14])
+	frame.Details["identity_product_name"] = string(data[15 : 15+nameLength])
+	frame.Details["identity_source"] = "get_attributes_all"
+	return true
+}
+
+// connectionSize extracts the connection size from network connection parameters
+func connectionSize(params []byte, large bool) uint16 {
+	if large {
+		return uint16(binary.LittleEndian.Uint32(params) & 0xFFFF)
+	}
+	return binary.LittleEndian.Uint16(params) & 0x01FF
 }
 
 // categorizeCIPService categorizes CIP service codes
@@ -1,6 +1,7 @@
 package analyzers
 
 import (
+	"bytes"
 	"cipgram/pkg/pcap/core"
 	"regexp"
 	"strconv"
@@ -67,9 +68,40 @@ func (h *HTTPAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
 		return nil
 	}
 
-	tcp := tcpLayer.(*layers.TCP)
-	payload := tcp.Payload
+	return h.AnalyzePDU(packet, tcpLayer.(*layers.TCP).Payload)
+}
+
+// PDULength returns the length of the HTTP message at the start of data: its headers
+// and a Content-Length body. Other bodies are left unframed and skipped by the engine.
+func (h *HTTPAnalyzer) PDULength(data []byte) int {
+	end := bytes.Index(data, []byte("\r\n\r\n"))
+	if end < 0 {
+		// Wait for the rest of the headers unless this is not the start of a message
+		if len(data) < 16 || h.looksLikeHTTP(string(data[:min(len(data), 100)])) {
+			return 0
+		}
+		return -1
+	}
+	headers := string(data[:end])
+	if !h.methodRegex.MatchString(headers) && !h.responseRegex.MatchString(headers) {
+		return -1
+	}
+
+	length := end + 4
+	for _, line := range strings.Split(headers, "\r\n")[1:] {
+		name, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if body, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && body > 0 {
+				length += body
+			}
+			break
+		}
+	}
+	return length
+}
 
+// AnalyzePDU analyzes one HTTP message of the TCP stream that packet belongs to
+func (h *HTTPAnalyzer) AnalyzePDU(packet gopacket.Packet, payload []byte) *core.AnalysisResult {
 	if len(payload) == 0 {
 		return nil
 	}
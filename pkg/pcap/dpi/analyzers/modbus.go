@@ -11,7 +11,8 @@ import (
 
 // ModbusAnalyzer implements DPI for Modbus TCP protocol
 type ModbusAnalyzer struct {
-	functionCodes map[uint8]string
+	functionCodes  map[uint8]string
+	exceptionCodes map[uint8]string
 }
 
 // NewModbusAnalyzer creates a new Modbus analyzer
@@ -24,12 +25,31 @@ func NewModbusAnalyzer() *ModbusAnalyzer {
 			0x04: "Read Input Registers",
 			0x05: "Write Single Coil",
 			0x06: "Write Single Register",
+			0x07: "Read Exception Status",
+			0x08: "Diagnostics",
+			0x0B: "Get Comm Event Counter",
+			0x0C: "Get Comm Event Log",
 			0x0F: "Write Multiple Coils",
 			0x10: "Write Multiple Registers",
+			0x11: "Report Server ID",
+			0x14: "Read File Record",
+			0x15: "Write File Record",
 			0x16: "Mask Write Register",
 			0x17: "Read/Write Multiple Registers",
+			0x18: "Read FIFO Queue",
 			0x2B: "Encapsulated Interface Transport",
 		},
+		exceptionCodes: map[uint8]string{
+			0x01: "Illegal Function",
+			0x02: "Illegal Data Address",
+			0x03: "Illegal Data Value",
+			0x04: "Server Device Failure",
+			0x05: "Acknowledge",
+			0x06: "Server Device Busy",
+			0x08: "Memory Parity Error",
+			0x0A: "Gateway Path Unavailable",
+			0x0B: "Gateway Target Device Failed to Respond",
+		},
 	}
 }
 
@@ -63,15 +83,34 @@ func (m *ModbusAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
 		return nil
 	}
 
-	tcp := tcpLayer.(*layers.TCP)
-	payload := tcp.Payload
+	return m.AnalyzePDU(packet, tcpLayer.(*layers.TCP).Payload)
+}
 
-	if len(payload) < 7 {
+// PDULength returns the length of the Modbus TCP ADU at the start of data
+func (m *ModbusAnalyzer) PDULength(data []byte) int {
+	if len(data) < 6 {
+		return 0
+	}
+	if binary.BigEndian.Uint16(data[2:4]) != 0 {
+		return -1
+	}
+	// The length field counts the unit identifier and the PDU of at most 253 bytes
+	length := int(binary.BigEndian.Uint16(data[4:6]))
+	if length < 2 || length > 254 {
+		return -1
+	}
+	return 6 + length
+}
+
+// AnalyzePDU analyzes one Modbus TCP ADU of the stream that packet belongs to
+func (m *ModbusAnalyzer) AnalyzePDU(packet gopacket.Packet, payload []byte) *core.AnalysisResult {
+	tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !ok || len(payload) < 7 {
 		return nil
 	}
 
 	// Parse Modbus TCP header
-	modbus := m.parseModbusTCP(payload)
+	modbus := m.parseModbusTCP(payload, tcp.SrcPort == 502 && tcp.DstPort != 502)
 	if modbus == nil {
 		return nil
 	}
@@ -103,6 +142,7 @@ type ModbusTCPFrame struct {
 	UnitID        uint8
 	FunctionCode  uint8
 	FunctionName  string
+	IsResponse    bool // Sent by the slave (source port 502)
 	Data          []byte
 	Confidence    float32
 	Details       map[string]interface{}
@@ -110,7 +150,7 @@ type ModbusTCPFrame struct {
 }
 
 // parseModbusTCP parses a Modbus TCP frame
-func (m *ModbusAnalyzer) parseModbusTCP(payload []byte) *ModbusTCPFrame {
+func (m *ModbusAnalyzer) parseModbusTCP(payload []byte, isResponse bool) *ModbusTCPFrame {
 	if len(payload) < 7 {
 		return nil
 	}
@@ -143,9 +183,12 @@ func (m *ModbusAnalyzer) parseModbusTCP(payload []byte) *ModbusTCPFrame {
 		return nil
 	}
 
-	functionName, exists := m.functionCodes[functionCode]
+	functionName, exists := m.functionCodes[functionCode&0x7F]
 	if !exists {
-		functionName = fmt.Sprintf("Unknown Function (0x%02X)", functionCode)
+		functionName = fmt.Sprintf("Unknown Function (0x%02X)", functionCode&0x7F)
+	}
+	if functionCode >= 0x80 {
+		functionName = "Exception: " + functionName
 	}
 
 	// Extract data portion
@@ -161,6 +204,7 @@ func (m *ModbusAnalyzer) parseModbusTCP(payload []byte) *ModbusTCPFrame {
 		UnitID:        unitID,
 		FunctionCode:  functionCode,
 		FunctionName:  functionName,
+		IsResponse:    isResponse,
 		Data:          data,
 		Confidence:    0.95,
 		Details:       make(map[string]interface{}),
@@ -173,17 +217,68 @@ func (m *ModbusAnalyzer) parseModbusTCP(payload []byte) *ModbusTCPFrame {
 	frame.Details["function_code"] = functionCode
 	frame.Details["function_name"] = functionName
 	frame.Details["data_length"] = len(data)
+	frame.Details["direction"] = "request"
+	if isResponse {
+		frame.Details["direction"] = "response"
+	}
+	if access := m.accessType(functionCode, data); access != "" {
+		frame.Details["access"] = access
+	}
 
 	// Add metadata
 	frame.Metadata["protocol_version"] = "TCP"
 	frame.Metadata["function_category"] = m.categorizeFunctionCode(functionCode)
 
-	// Parse function-specific data
-	m.parseFunctionData(frame)
+	// Exception responses carry the exception code instead of function data
+	if functionCode >= 0x80 {
+		m.parseException(frame)
+		return frame
+	}
+
+	// Parse function-specific data; address fields only exist in requests
+	if !isResponse {
+		m.parseFunctionData(frame)
+	}
 
 	return frame
 }
 
+// accessType classifies a function code as read, write, read_write, diagnostic or device_identification
+func (m *ModbusAnalyzer) accessType(code uint8, data []byte) string {
+	switch code {
+	case 0x01, 0x02, 0x03, 0x04:
+		return "read"
+	case 0x05, 0x06, 0x0F, 0x10, 0x16:
+		return "write"
+	case 0x17:
+		return "read_write"
+	case 0x08:
+		return "diagnostic"
+	case 0x2B:
+		// MEI type 14 is Read Device Identification
+		if len(data) >= 1 && data[0] == 0x0E {
+			return "device_identification"
+		}
+	}
+	return ""
+}
+
+// parseException decodes the exception code of an exception response
+func (m *ModbusAnalyzer) parseException(frame *ModbusTCPFrame) {
+	frame.Details["exception"] = true
+	if len(frame.Data) < 1 {
+		return
+	}
+
+	code := frame.Data[0]
+	name, exists := m.exceptionCodes[code]
+	if !exists {
+		name = fmt.Sprintf("Unknown Exception (0x%02X)", code)
+	}
+	frame.Details["exception_code"] = code
+	frame.Details["exception_name"] = name
+}
+
 // looksLikeModbus performs heuristic check for Modbus-like content
 func (m *ModbusAnalyzer) looksLikeModbus(payload []byte) bool {
 	if len(payload) < 7 {
@@ -232,8 +327,12 @@ func (m *ModbusAnalyzer) categorizeFunctionCode(code uint8) string {
 		return "Write Single"
 	case code >= 15 && code <= 16:
 		return "Write Multiple"
+	case code == 22:
+		return "Write Single"
 	case code == 23:
 		return "Read/Write Multiple"
+	case code == 8 || code == 11 || code == 12:
+		return "Diagnostics"
 	case code >= 43 && code <= 44:
 		return "Encapsulated Interface"
 	default:
@@ -256,6 +355,14 @@ func (m *ModbusAnalyzer) parseFunctionData(frame *ModbusTCPFrame) {
 		m.parseWriteMultipleCoilsRequest(frame)
 	case 0x10: // Write Multiple Registers
 		m.parseWriteMultipleRegistersRequest(frame)
+	case 0x16: // Mask Write Register
+		m.parseMaskWriteRegisterRequest(frame)
+	case 0x17: // Read/Write Multiple Registers
+		m.parseReadWriteMultipleRegistersRequest(frame)
+	case 0x08: // Diagnostics
+		m.parseDiagnosticsRequest(frame)
+	case 0x2B: // Encapsulated Interface Transport
+		m.parseEncapsulatedInterfaceRequest(frame)
 	}
 }
 
@@ -267,6 +374,10 @@ func (m *ModbusAnalyzer) parseReadBitsRequest(frame *ModbusTCPFrame) {
 
 		frame.Details["start_address"] = startAddress
 		frame.Details["quantity"] = quantity
+		frame.Details["table"] = "coils"
+		if frame.FunctionCode == 0x02 {
+			frame.Details["table"] = "discrete_inputs"
+		}
 		frame.Metadata["operation"] = "read_bits"
 	}
 }
@@ -279,6 +390,10 @@ func (m *ModbusAnalyzer) parseReadRegistersRequest(frame *ModbusTCPFrame) {
 
 		frame.Details["start_address"] = startAddress
 		frame.Details["quantity"] = quantity
+		frame.Details["table"] = "holding_registers"
+		if frame.FunctionCode == 0x04 {
+			frame.Details["table"] = "input_registers"
+		}
 		frame.Metadata["operation"] = "read_registers"
 	}
 }
@@ -291,6 +406,7 @@ func (m *ModbusAnalyzer) parseWriteSingleCoilRequest(frame *ModbusTCPFrame) {
 
 		frame.Details["address"] = address
 		frame.Details["value"] = value
+		frame.Details["table"] = "coils"
 		frame.Metadata["operation"] = "write_single_coil"
 	}
 }
@@ -303,6 +419,7 @@ func (m *ModbusAnalyzer) parseWriteSingleRegisterRequest(frame *ModbusTCPFrame)
 
 		frame.Details["address"] = address
 		frame.Details["value"] = value
+		frame.Details["table"] = "holding_registers"
 		frame.Metadata["operation"] = "write_single_register"
 	}
 }
@@ -317,6 +434,7 @@ func (m *ModbusAnalyzer) parseWriteMultipleCoilsRequest(frame *ModbusTCPFrame) {
 		frame.Details["start_address"] = startAddress
 		frame.Details["quantity"] = quantity
 		frame.Details["byte_count"] = byteCount
+		frame.Details["table"] = "coils"
 		frame.Metadata["operation"] = "write_multiple_coils"
 	}
 }
@@ -331,6 +449,52 @@ func (m *ModbusAnalyzer) parseWriteMultipleRegistersRequest(frame *ModbusTCPFram
 		frame.Details["start_address"] = startAddress
 		frame.Details["quantity"] = quantity
 		frame.Details["byte_count"] = byteCount
+		frame.Details["table"] = "holding_registers"
 		frame.Metadata["operation"] = "write_multiple_registers"
 	}
 }
+
+// parseMaskWriteRegisterRequest parses mask write register request
+func (m *ModbusAnalyzer) parseMaskWriteRegisterRequest(frame *ModbusTCPFrame) {
+	if len(frame.Data) >= 6 {
+		address := binary.BigEndian.Uint16(frame.Data[0:2])
+
+		frame.Details["address"] = address
+		frame.Details["and_mask"] = binary.BigEndian.Uint16(frame.Data[2:4])
+		frame.Details["or_mask"] = binary.BigEndian.Uint16(frame.Data[4:6])
+		frame.Details["table"] = "holding_registers"
+		frame.Metadata["operation"] = "mask_write_register"
+	}
+}
+
+// parseReadWriteMultipleRegistersRequest parses read/write multiple registers request
+func (m *ModbusAnalyzer) parseReadWriteMultipleRegistersRequest(frame *ModbusTCPFrame) {
+	if len(frame.Data) >= 8 {
+		frame.Details["start_address"] = binary.BigEndian.Uint16(frame.Data[0:2])
+		frame.Details["quantity"] = binary.BigEndian.Uint16(frame.Data[2:4])
+		frame.Details["write_start_address"] = binary.BigEndian.Uint16(frame.Data[4:6])
+		frame.Details["write_quantity"] = binary.BigEndian.Uint16(frame.Data[6:8])
+		frame.Details["table"] = "holding_registers"
+		frame.Metadata["operation"] = "read_write_registers"
+	}
+}
+
+// parseDiagnosticsRequest parses the diagnostics sub-function
+func (m *ModbusAnalyzer) parseDiagnosticsRequest(frame *ModbusTCPFrame) {
+	if len(frame.Data) >= 2 {
+		frame.Details["sub_function"] = binary.BigEndian.Uint16(frame.Data[0:2])
+		frame.Metadata["operation"] = "diagnostics"
+	}
+}
+
+// parseEncapsulatedInterfaceRequest parses MEI requests such as Read Device Identification
+func (m *ModbusAnalyzer) parseEncapsulatedInterfaceRequest(frame *ModbusTCPFrame) {
+	if len(frame.Data) >= 1 {
+		frame.Details["mei_type"] = frame.Data[0]
+	}
+	if len(frame.Data) >= 3 && frame.Data[0] == 0x0E {
+		frame.Details["read_device_id_code"] = frame.Data[1]
+		frame.Details["object_id"] = frame.Data[2]
+		frame.Metadata["operation"] = "read_device_identification"
+	}
+}
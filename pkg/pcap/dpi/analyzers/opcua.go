@@ -0,0 +1,577 @@
+package analyzers
+
+import (
+	"cipgram/pkg/pcap/core"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Binary encoding IDs of the services decoded beyond their type
+const (
+	opcuaGetEndpointsResponse      uint32 = 431
+	opcuaOpenSecureChannelRequest  uint32 = 446
+	opcuaOpenSecureChannelResponse uint32 = 449
+)
+
+// opcuaSecurityPolicyNone is the policy URI of an unsigned, unencrypted channel
+const opcuaSecurityPolicyNone = "http://opcfoundation.org/UA/SecurityPolicy#None"
+
+// opcuaMaxArray bounds array lengths read from the wire
+const opcuaMaxArray = 256
+
+// OPCUAAnalyzer implements DPI for OPC UA binary (UA TCP / UASC)
+type OPCUAAnalyzer struct {
+	messageTypes  map[string]string
+	services      map[uint32]string
+	securityModes map[uint32]string
+}
+
+// NewOPCUAAnalyzer creates a new OPC UA analyzer
+func NewOPCUAAnalyzer() *OPCUAAnalyzer {
+	return &OPCUAAnalyzer{
+		messageTypes: map[string]string{
+			"HEL": "Hello",
+			"ACK": "Acknowledge",
+			"ERR": "Error",
+			"RHE": "ReverseHello",
+			"OPN": "OpenSecureChannel",
+			"MSG": "Message",
+			"CLO": "CloseSecureChannel",
+		},
+		services: map[uint32]string{
+			397: "ServiceFault",
+			422: "FindServersRequest",
+			425: "FindServersResponse",
+			428: "GetEndpointsRequest",
+			431: "GetEndpointsResponse",
+			446: "OpenSecureChannelRequest",
+			449: "OpenSecureChannelResponse",
+			452: "CloseSecureChannelRequest",
+			455: "CloseSecureChannelResponse",
+			461: "CreateSessionRequest",
+			464: "CreateSessionResponse",
+			467: "ActivateSessionRequest",
+			470: "ActivateSessionResponse",
+			473: "CloseSessionRequest",
+			476: "CloseSessionResponse",
+			488: "AddNodesRequest",
+			491: "AddNodesResponse",
+			500: "DeleteNodesRequest",
+			503: "DeleteNodesResponse",
+			527: "BrowseRequest",
+			530: "BrowseResponse",
+			533: "BrowseNextRequest",
+			536: "BrowseNextResponse",
+			554: "TranslateBrowsePathsToNodeIdsRequest",
+			557: "TranslateBrowsePathsToNodeIdsResponse",
+			560: "RegisterNodesRequest",
+			563: "RegisterNodesResponse",
+			631: "ReadRequest",
+			634: "ReadResponse",
+			664: "HistoryReadRequest",
+			667: "HistoryReadResponse",
+			673: "WriteRequest",
+			676: "WriteResponse",
+			700: "HistoryUpdateRequest",
+			703: "HistoryUpdateResponse",
+			712: "CallRequest",
+			715: "CallResponse",
+			751: "CreateMonitoredItemsRequest",
+			754: "CreateMonitoredItemsResponse",
+			763: "ModifyMonitoredItemsRequest",
+			766: "ModifyMonitoredItemsResponse",
+			769: "SetMonitoringModeRequest",
+			772: "SetMonitoringModeResponse",
+			781: "DeleteMonitoredItemsRequest",
+			784: "DeleteMonitoredItemsResponse",
+			787: "CreateSubscriptionRequest",
+			790: "CreateSubscriptionResponse",
+			793: "ModifySubscriptionRequest",
+			796: "ModifySubscriptionResponse",
+			799: "SetPublishingModeRequest",
+			802: "SetPublishingModeResponse",
+			826: "PublishRequest",
+			829: "PublishResponse",
+			832: "RepublishRequest",
+			835: "RepublishResponse",
+			841: "TransferSubscriptionsRequest",
+			844: "TransferSubscriptionsResponse",
+			847: "DeleteSubscriptionsRequest",
+			850: "DeleteSubscriptionsResponse",
+		},
+		securityModes: map[uint32]string{
+			0: "Invalid",
+			1: "None",
+			2: "Sign",
+			3: "SignAndEncrypt",
+		},
+	}
+}
+
+// CanAnalyze determines if this analyzer can process the packet
+func (o *OPCUAAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil {
+		return false
+	}
+
+	tcp := tcpLayer.(*layers.TCP)
+
+	// Check OPC UA TCP port (4840)
+	if tcp.SrcPort == 4840 || tcp.DstPort == 4840 {
+		return true
+	}
+
+	// Servers are often configured on other ports, so check the message header
+	return o.looksLikeOPCUA(tcp.Payload)
+}
+
+// Analyze performs OPC UA protocol analysis
+func (o *OPCUAAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil {
+		return nil
+	}
+
+	return o.AnalyzePDU(packet, tcpLayer.(*layers.TCP).Payload)
+}
+
+// PDULength returns the size of the message chunk at the start of data
+func (o *OPCUAAnalyzer) PDULength(data []byte) int {
+	if len(data) < 8 {
+		return 0
+	}
+	if !o.looksLikeOPCUA(data) {
+		return -1
+	}
+	return int(binary.LittleEndian.Uint32(data[4:8]))
+}
+
+// AnalyzePDU analyzes one message chunk of the TCP stream that packet belongs to
+func (o *OPCUAAnalyzer) AnalyzePDU(packet gopacket.Packet, payload []byte) *core.AnalysisResult {
+	if !o.looksLikeOPCUA(payload) {
+		return nil
+	}
+
+	msg := o.parseMessage(payload)
+	if msg == nil {
+		return nil
+	}
+
+	return &core.AnalysisResult{
+		Protocol:    "OPC-UA",
+		Subprotocol: msg.Subprotocol,
+		Confidence:  msg.Confidence,
+		Details:     msg.Details,
+		Metadata:    msg.Metadata,
+	}
+}
+
+// GetProtocolName returns the protocol name
+func (o *OPCUAAnalyzer) GetProtocolName() string {
+	return "OPC-UA"
+}
+
+// GetConfidenceThreshold returns the minimum confidence threshold
+func (o *OPCUAAnalyzer) GetConfidenceThreshold() float32 {
+	return 0.85
+}
+
+// OPCUAMessage represents a parsed OPC UA binary message chunk
+type OPCUAMessage struct {
+	MessageType string
+	ChunkType   byte
+	Size        uint32
+	Subprotocol string
+	Confidence  float32
+	Details     map[string]interface{}
+	Metadata    map[string]string
+}
+
+// parseMessage parses the message header and dispatches on the message type
+func (o *OPCUAAnalyzer) parseMessage(payload []byte) *OPCUAMessage {
+	messageType := string(payload[0:3])
+	size := binary.LittleEndian.Uint32(payload[4:8])
+
+	msg := &OPCUAMessage{
+		MessageType: messageType,
+		ChunkType:   payload[3],
+		Size:        size,
+		Subprotocol: o.messageTypes[messageType],
+		Confidence:  0.90,
+		Details:     make(map[string]interface{}),
+		Metadata:    make(map[string]string),
+	}
+	msg.Details["message_type"] = messageType
+	msg.Details["message_type_name"] = o.messageTypes[messageType]
+	msg.Details["chunk_type"] = string(payload[3])
+	msg.Details["message_size"] = size
+	msg.Metadata["message_type"] = messageType
+
+	if int(size) < len(payload) {
+		payload = payload[:size]
+	}
+	r := &opcuaReader{data: payload, offset: 8}
+
+	switch messageType {
+	case "HEL":
+		msg.Details["direction"] = "request"
+		msg.Details["protocol_version"] = r.uint32()
+		msg.Details["receive_buffer_size"] = r.uint32()
+		msg.Details["send_buffer_size"] = r.uint32()
+		msg.Details["max_message_size"] = r.uint32()
+		msg.Details["max_chunk_count"] = r.uint32()
+		if url := r.string(); !r.failed && url != "" {
+			msg.Details["endpoint_url"] = url
+		}
+	case "ACK":
+		msg.Details["direction"] = "response"
+		msg.Details["protocol_version"] = r.uint32()
+		msg.Details["receive_buffer_size"] = r.uint32()
+		msg.Details["send_buffer_size"] = r.uint32()
+	case "ERR":
+		msg.Details["direction"] = "response"
+		msg.Details["error_code"] = r.uint32()
+		if reason := r.string(); reason != "" {
+			msg.Details["error_reason"] = reason
+		}
+	case "OPN":
+		o.parseOpenSecureChannel(r, msg)
+	case "MSG", "CLO":
+		msg.Details["secure_channel_id"] = r.uint32()
+		msg.Details["token_id"] = r.uint32()
+		o.parseBody(r, msg)
+	}
+
+	// A decoded service or handshake is stronger evidence than the header alone
+	if msg.Details["opcua_service"] != nil || (!r.failed && messageType != "MSG" && messageType != "CLO") {
+		msg.Confidence = 0.95
+	}
+	return msg
+}
+
+// parseOpenSecureChannel reads the asymmetric security header and, for
+// SecurityPolicy#None, the plaintext OpenSecureChannel request or response
+func (o *OPCUAAnalyzer) parseOpenSecureChannel(r *opcuaReader, msg *OPCUAMessage) {
+	msg.Details["secure_channel_id"] = r.uint32()
+	policyURI := r.string()
+	r.byteString() // Sender certificate
+	r.byteString() // Receiver certificate thumbprint
+	if r.failed {
+		return
+	}
+
+	policy := policyURI
+	if i := strings.LastIndex(policyURI, "#"); i >= 0 {
+		policy = policyURI[i+1:]
+	}
+	msg.Details["security_policy_uri"] = policyURI
+	msg.Details["security_policy"] = policy
+	msg.Subprotocol = fmt.Sprintf("OpenSecureChannel (%s)", policy)
+
+	if policyURI != opcuaSecurityPolicyNone {
+		// The body is encrypted
+		return
+	}
+	msg.Details["security_policy_none"] = true
+
+	o.parseBody(r, msg)
+}
+
+// parseBody reads the sequence header and the encoding ID of the service carried in the chunk
+func (o *OPCUAAnalyzer) parseBody(r *opcuaReader, msg *OPCUAMessage) {
+	msg.Details["sequence_number"] = r.uint32()
+	msg.Details["request_id"] = r.uint32()
+
+	// Abort chunks carry an error instead of a service
+	if msg.ChunkType == 'A' {
+		return
+	}
+
+	namespace, typeID, numeric := r.nodeID()
+	if r.failed || !numeric || namespace != 0 {
+		return
+	}
+	service, exists := o.services[typeID]
+	if !exists {
+		// Encrypted bodies decode to arbitrary IDs
+		return
+	}
+
+	direction := "request"
+	if strings.HasSuffix(service, "Response") || service == "ServiceFault" {
+		direction = "response"
+	}
+	msg.Details["direction"] = direction
+	msg.Details["opcua_service_id"] = uint16(typeID)
+	msg.Details["opcua_service"] = service
+	msg.Subprotocol = service
+	if policy, ok := msg.Details["security_policy"].(string); ok {
+		msg.Subprotocol = fmt.Sprintf("%s (%s)", service, policy)
+	}
+
+	switch typeID {
+	case opcuaOpenSecureChannelRequest:
+		r.requestHeader()
+		r.uint32() // Client protocol version
+		requestType := r.uint32()
+		mode := r.uint32()
+		if r.failed {
+			return
+		}
+		if requestType == 0 {
+			msg.Details["request_type"] = "Issue"
+		} else {
+			msg.Details["request_type"] = "Renew"
+		}
+		if name, exists := o.securityModes[mode]; exists {
+			msg.Details["security_mode"] = name
+		}
+	case opcuaOpenSecureChannelResponse:
+		r.responseHeader()
+		r.uint32() // Server protocol version
+		channelID := r.uint32()
+		if !r.failed {
+			msg.Details["secure_channel_id"] = channelID
+		}
+	case opcuaGetEndpointsResponse:
+		o.parseEndpoints(r, msg)
+	}
+}
+
+// parseEndpoints extracts the endpoint URLs, security modes and policies a server advertises
+func (o *OPCUAAnalyzer) parseEndpoints(r *opcuaReader, msg *OPCUAMessage) {
+	r.responseHeader()
+	count := r.arrayLength()
+
+	var urls, endpoints []string
+	for i := 0; i < count && !r.failed; i++ {
+		url := r.string()
+
+		// ApplicationDescription
+		r.string() // Application URI
+		r.string() // Product URI
+		r.localizedText()
+		r.uint32() // Application type
+		r.string() // Gateway server URI
+		r.string() // Discovery profile URI
+		r.stringArray()
+
+		r.byteString() // Server certificate
+		mode := r.uint32()
+		policyURI := r.string()
+
+		// UserTokenPolicy array
+		tokens := r.arrayLength()
+		for j := 0; j < tokens && !r.failed; j++ {
+			r.string() // Policy ID
+			r.uint32() // Token type
+			r.string() // Issued token type
+			r.string() // Issuer endpoint URL
+			r.string() // Security policy URI
+		}
+		r.string() // Transport profile URI
+		r.uint8()  // Security level
+		if r.failed {
+			break
+		}
+
+		policy := policyURI
+		if k := strings.LastIndex(policyURI, "#"); k >= 0 {
+			policy = policyURI[k+1:]
+		}
+		urls = appendUniqueString(urls, url)
+		endpoints = append(endpoints, fmt.Sprintf("%s %s/%s", url, policy, o.securityModes[mode]))
+	}
+
+	if len(urls) > 0 {
+		msg.Details["endpoint_urls"] = urls
+		msg.Details["endpoints"] = endpoints
+	}
+}
+
+// looksLikeOPCUA checks for a known message type, chunk type and plausible size
+func (o *OPCUAAnalyzer) looksLikeOPCUA(payload []byte) bool {
+	if len(payload) < 8 {
+		return false
+	}
+	if _, exists := o.messageTypes[string(payload[0:3])]; !exists {
+		return false
+	}
+	switch payload[3] {
+	case 'F', 'C', 'A':
+	default:
+		return false
+	}
+	size := binary.LittleEndian.Uint32(payload[4:8])
+	return size >= 8 && size <= 1<<24
+}
+
+// opcuaReader decodes OPC UA binary built-in types; a short read marks it failed
+// and every later read returns zero values
+type opcuaReader struct {
+	data   []byte
+	offset int
+	failed bool
+}
+
+func (r *opcuaReader) next(n int) []byte {
+	if r.failed || n < 0 || r.offset+n > len(r.data) {
+		r.failed = true
+		return nil
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *opcuaReader) uint8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *opcuaReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *opcuaReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// string reads a length-prefixed UTF-8 string; a length of -1 is a null string
+func (r *opcuaReader) string() string {
+	length := int32(r.uint32())
+	if r.failed || length <= 0 {
+		return ""
+	}
+	return string(r.next(int(length)))
+}
+
+func (r *opcuaReader) byteString() {
+	r.string()
+}
+
+// arrayLength reads an array length, treating null arrays as empty
+func (r *opcuaReader) arrayLength() int {
+	length := int32(r.uint32())
+	if length > opcuaMaxArray {
+		r.failed = true
+	}
+	if r.failed || length < 0 {
+		return 0
+	}
+	return int(length)
+}
+
+func (r *opcuaReader) stringArray() {
+	for i := r.arrayLength(); i > 0 && !r.failed; i-- {
+		r.string()
+	}
+}
+
+func (r *opcuaReader) localizedText() {
+	mask := r.uint8()
+	if mask&0x01 != 0 {
+		r.string() // Locale
+	}
+	if mask&0x02 != 0 {
+		r.string() // Text
+	}
+}
+
+// nodeID reads a NodeId and returns its namespace and, for numeric encodings, its identifier
+func (r *opcuaReader) nodeID() (uint16, uint32, bool) {
+	switch r.uint8() & 0x3F {
+	case 0x00: // Two-byte
+		return 0, uint32(r.uint8()), !r.failed
+	case 0x01: // Four-byte
+		namespace := uint16(r.uint8())
+		return namespace, uint32(r.uint16()), !r.failed
+	case 0x02: // Numeric
+		namespace := r.uint16()
+		return namespace, r.uint32(), !r.failed
+	case 0x03: // String
+		r.uint16()
+		r.string()
+	case 0x04: // GUID
+		r.next(18)
+	case 0x05: // Opaque
+		r.uint16()
+		r.byteString()
+	default:
+		r.failed = true
+	}
+	return 0, 0, false
+}
+
+// extensionObject skips an ExtensionObject with a binary or XML body
+func (r *opcuaReader) extensionObject() {
+	r.nodeID()
+	if encoding := r.uint8(); encoding == 0x01 || encoding == 0x02 {
+		r.byteString()
+	}
+}
+
+// diagnosticInfo skips a DiagnosticInfo, including nested inner diagnostics
+func (r *opcuaReader) diagnosticInfo() {
+	mask := r.uint8()
+	// Symbolic ID, namespace URI, localized text and locale string table indexes
+	for _, bit := range []uint8{0x01, 0x02, 0x04, 0x08} {
+		if mask&bit != 0 {
+			r.uint32()
+		}
+	}
+	if mask&0x10 != 0 {
+		r.string() // Additional info
+	}
+	if mask&0x20 != 0 {
+		r.uint32() // Inner status code
+	}
+	if mask&0x40 != 0 && !r.failed {
+		r.diagnosticInfo()
+	}
+}
+
+func (r *opcuaReader) requestHeader() {
+	r.nodeID() // Authentication token
+	r.next(8)  // Timestamp
+	r.uint32() // Request handle
+	r.uint32() // Return diagnostics
+	r.string() // Audit entry ID
+	r.uint32() // Timeout hint
+	r.extensionObject()
+}
+
+func (r *opcuaReader) responseHeader() {
+	r.next(8)  // Timestamp
+	r.uint32() // Request handle
+	r.uint32() // Service result
+	r.diagnosticInfo()
+	r.stringArray()
+	r.extensionObject()
+}
+
+// appendUniqueString appends value unless it is empty or already present
+func appendUniqueString(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
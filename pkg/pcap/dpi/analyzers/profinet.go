@@ -0,0 +1,446 @@
+package analyzers
+
+import (
+	"cipgram/pkg/pcap/core"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// profinetEtherType is the EtherType of PROFINET real-time frames
+const profinetEtherType layers.EthernetType = 0x8892
+
+// Frame IDs of acyclic PROFINET frames
+const (
+	pnAlarmHigh           uint16 = 0xFC01
+	pnAlarmLow            uint16 = 0xFE01
+	pnDCPHello            uint16 = 0xFEFC
+	pnDCPGetSet           uint16 = 0xFEFD
+	pnDCPIdentifyRequest  uint16 = 0xFEFE
+	pnDCPIdentifyResponse uint16 = 0xFEFF
+)
+
+// DCP services
+const (
+	dcpGet      uint8 = 0x03
+	dcpSet      uint8 = 0x04
+	dcpIdentify uint8 = 0x05
+	dcpHello    uint8 = 0x06
+)
+
+// DCP block options
+const (
+	dcpOptionIP          uint8 = 0x01
+	dcpOptionDevice      uint8 = 0x02
+	dcpOptionControl     uint8 = 0x05
+	dcpOptionAllSelector uint8 = 0xFF
+)
+
+// ProfinetAnalyzer implements DPI for PROFINET RT, alarms and DCP carried directly over Ethernet
+type ProfinetAnalyzer struct {
+	dcpServices   map[uint8]string
+	alarmTypes    map[uint16]string
+	alarmPDUTypes map[uint8]string
+	controls      map[uint8]string
+}
+
+// NewProfinetAnalyzer creates a new PROFINET analyzer
+func NewProfinetAnalyzer() *ProfinetAnalyzer {
+	return &ProfinetAnalyzer{
+		dcpServices: map[uint8]string{
+			dcpGet:      "Get",
+			dcpSet:      "Set",
+			dcpIdentify: "Identify",
+			dcpHello:    "Hello",
+		},
+		alarmTypes: map[uint16]string{
+			0x0001: "Diagnosis",
+			0x0002: "Process",
+			0x0003: "Pull",
+			0x0004: "Plug",
+			0x0005: "Status",
+			0x0006: "Update",
+			0x0007: "Media Redundancy",
+			0x0008: "Controlled by Supervisor",
+			0x0009: "Released",
+			0x000A: "Plug Wrong Submodule",
+			0x000B: "Return of Submodule",
+			0x000C: "Diagnosis Disappears",
+			0x000D: "Multicast Communication Mismatch",
+			0x000E: "Port Data Change",
+			0x000F: "Sync Data Changed",
+			0x0010: "Isochronous Mode Problem",
+			0x0011: "Network Component Problem",
+			0x0012: "Time Data Changed",
+			0x0013: "Dynamic Frame Packing Problem",
+			0x0014: "MRPD Problem",
+			0x0016: "Multiple Interface Mismatch",
+			0x001E: "Upload and Retrieval",
+			0x001F: "Pull Module",
+		},
+		alarmPDUTypes: map[uint8]string{
+			0x01: "DATA",
+			0x02: "NACK",
+			0x03: "ACK",
+			0x04: "ERR",
+		},
+		controls: map[uint8]string{
+			0x01: "Start Transaction",
+			0x02: "End Transaction",
+			0x03: "Signal",
+			0x04: "Response",
+			0x05: "Factory Reset",
+			0x06: "Reset to Factory",
+		},
+	}
+}
+
+// CanAnalyze determines if this analyzer can process the packet
+func (p *ProfinetAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
+	return len(profinetPayload(packet)) >= 2
+}
+
+// Analyze performs PROFINET protocol analysis
+func (p *ProfinetAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
+	payload := profinetPayload(packet)
+	if len(payload) < 2 {
+		return nil
+	}
+
+	frame := p.parseFrame(payload)
+	if frame == nil {
+		return nil
+	}
+
+	return &core.AnalysisResult{
+		Protocol:    "Profinet",
+		Subprotocol: frame.Subprotocol,
+		Confidence:  frame.Confidence,
+		Details:     frame.Details,
+		Metadata:    frame.Metadata,
+	}
+}
+
+// GetProtocolName returns the protocol name
+func (p *ProfinetAnalyzer) GetProtocolName() string {
+	return "Profinet"
+}
+
+// GetConfidenceThreshold returns the minimum confidence threshold
+func (p *ProfinetAnalyzer) GetConfidenceThreshold() float32 {
+	return 0.90
+}
+
+// ProfinetFrame represents a parsed PROFINET frame
+type ProfinetFrame struct {
+	FrameID     uint16
+	FrameType   string // DCP, RT, Alarm or PTCP
+	Subprotocol string
+	Confidence  float32
+	Details     map[string]interface{}
+	Metadata    map[string]string
+}
+
+// profinetPayload returns the bytes following the PROFINET EtherType, looking
+// through an 802.1Q tag since RT frames are usually priority tagged
+func profinetPayload(packet gopacket.Packet) []byte {
+	if dot1qLayer := packet.Layer(layers.LayerTypeDot1Q); dot1qLayer != nil {
+		dot1q := dot1qLayer.(*layers.Dot1Q)
+		if dot1q.Type == profinetEtherType {
+			return dot1q.Payload
+		}
+		return nil
+	}
+	if ethLayer := packet.Layer(layers.LayerTypeEthernet); ethLayer != nil {
+		eth := ethLayer.(*layers.Ethernet)
+		if eth.EthernetType == profinetEtherType {
+			return eth.Payload
+		}
+	}
+	return nil
+}
+
+// parseFrame dispatches on the FrameID range
+func (p *ProfinetAnalyzer) parseFrame(payload []byte) *ProfinetFrame {
+	frameID := binary.BigEndian.Uint16(payload[0:2])
+
+	frame := &ProfinetFrame{
+		FrameID:    frameID,
+		Confidence: 0.95,
+		Details:    make(map[string]interface{}),
+		Metadata: map[string]string{
+			"protocol_type": "industrial",
+			"layer":         "datalink",
+		},
+	}
+	frame.Details["frame_id"] = frameID
+
+	switch {
+	case frameID <= 0x00FF:
+		frame.FrameType = "PTCP"
+		frame.Subprotocol = "Time Synchronization"
+	case frameID <= 0x7FFF:
+		p.parseCyclic(payload, "RT_CLASS_3", frame)
+	case frameID <= 0xBFFF:
+		p.parseCyclic(payload, "RT_CLASS_1", frame)
+	case frameID <= 0xFBFF:
+		p.parseCyclic(payload, "RT_CLASS_UDP", frame)
+	case frameID == pnAlarmHigh || frameID == pnAlarmLow:
+		p.parseAlarm(payload, frame)
+	case frameID >= pnDCPHello && frameID <= pnDCPIdentifyResponse:
+		if !p.parseDCP(payload, frame) {
+			return nil
+		}
+	case frameID >= 0xFF00 && frameID <= 0xFF8F:
+		frame.FrameType = "PTCP"
+		frame.Subprotocol = "PTCP"
+	default:
+		frame.FrameType = "Reserved"
+		frame.Subprotocol = fmt.Sprintf("Reserved Frame ID 0x%04X", frameID)
+		frame.Confidence = 0.90
+	}
+
+	frame.Details["frame_type"] = frame.FrameType
+	frame.Metadata["frame_type"] = frame.FrameType
+	return frame
+}
+
+// parseCyclic reads the APDU status trailing cyclic I/O data:
+// cycle counter, data status and transfer status
+func (p *ProfinetAnalyzer) parseCyclic(payload []byte, class string, frame *ProfinetFrame) {
+	frame.FrameType = "RT"
+	frame.Subprotocol = fmt.Sprintf("Cyclic %s", class)
+	frame.Details["rt_class"] = class
+
+	if len(payload) < 6 {
+		return
+	}
+	status := payload[len(payload)-4:]
+	dataStatus := status[2]
+
+	frame.Details["io_data_length"] = len(payload) - 6
+	frame.Details["cycle_counter"] = binary.BigEndian.Uint16(status[0:2])
+	frame.Details["data_status"] = dataStatus
+	frame.Details["data_valid"] = dataStatus&0x04 != 0
+	if dataStatus&0x10 != 0 {
+		frame.Details["provider_state"] = "run"
+	} else {
+		frame.Details["provider_state"] = "stop"
+	}
+}
+
+// parseAlarm reads the RTA header and, for data PDUs, the alarm notification block
+func (p *ProfinetAnalyzer) parseAlarm(payload []byte, frame *ProfinetFrame) {
+	frame.FrameType = "Alarm"
+	priority := "high"
+	if frame.FrameID == pnAlarmLow {
+		priority = "low"
+	}
+	frame.Details["alarm_priority"] = priority
+	frame.Subprotocol = fmt.Sprintf("Alarm (%s)", priority)
+
+	// Destination and source endpoints, PDU type, flags, sequence numbers, var part length
+	if len(payload) < 14 {
+		return
+	}
+	pduType := payload[6] & 0x0F
+	pduName, exists := p.alarmPDUTypes[pduType]
+	if !exists {
+		pduName = fmt.Sprintf("0x%X", pduType)
+	}
+	frame.Details["alarm_pdu_type"] = pduName
+	frame.Details["send_sequence"] = binary.BigEndian.Uint16(payload[8:10])
+
+	// AlarmNotification block: header, alarm type, API, slot, subslot
+	block := payload[14:]
+	if pduType != 0x01 || len(block) < 16 {
+		return
+	}
+	blockType := binary.BigEndian.Uint16(block[0:2])
+	if blockType != 0x0001 && blockType != 0x0002 {
+		return
+	}
+	alarmType := binary.BigEndian.Uint16(block[6:8])
+	alarmName, exists := p.alarmTypes[alarmType]
+	if !exists {
+		alarmName = fmt.Sprintf("Alarm Type 0x%04X", alarmType)
+	}
+	frame.Details["alarm_type"] = alarmName
+	frame.Details["slot"] = binary.BigEndian.Uint16(block[12:14])
+	frame.Details["subslot"] = binary.BigEndian.Uint16(block[14:16])
+	frame.Subprotocol = fmt.Sprintf("Alarm (%s, %s)", priority, alarmName)
+}
+
+// parseDCP parses the DCP header and its blocks
+func (p *ProfinetAnalyzer) parseDCP(payload []byte, frame *ProfinetFrame) bool {
+	// Frame ID, service ID, service type, Xid, response delay, data length
+	if len(payload) < 12 {
+		return false
+	}
+	serviceID := payload[2]
+	serviceType := payload[3]
+	serviceName, exists := p.dcpServices[serviceID]
+	if !exists {
+		return false
+	}
+
+	frame.FrameType = "DCP"
+	direction := "request"
+	if serviceType&0x01 != 0 {
+		direction = "response"
+	}
+	frame.Details["dcp_service"] = serviceName
+	frame.Details["dcp_service_type"] = direction
+	frame.Details["direction"] = direction
+	frame.Details["dcp_xid"] = binary.BigEndian.Uint32(payload[4:8])
+	frame.Subprotocol = fmt.Sprintf("DCP %s %s", serviceName, direction)
+	if serviceType&0x04 != 0 {
+		frame.Details["dcp_status"] = "unsupported"
+	}
+
+	length := int(binary.BigEndian.Uint16(payload[10:12]))
+	blocks := payload[12:]
+	if length < len(blocks) {
+		blocks = blocks[:length]
+	}
+
+	// Get requests list option/suboption pairs rather than blocks
+	if serviceID == dcpGet && direction == "request" {
+		return true
+	}
+
+	// Identify requests carry filters; responses, Hello and Set requests carry
+	// a two-byte block info or qualifier ahead of the value
+	hasPrefix := serviceID != dcpIdentify || direction == "response"
+
+	var changes []string
+	for len(blocks) >= 4 {
+		option, suboption := blocks[0], blocks[1]
+		blockLength := int(binary.BigEndian.Uint16(blocks[2:4]))
+		if 4+blockLength > len(blocks) {
+			break
+		}
+		value := blocks[4 : 4+blockLength]
+		if hasPrefix && option != dcpOptionAllSelector && !(option == dcpOptionControl && suboption == 0x04) {
+			if len(value) < 2 {
+				value = nil
+			} else {
+				value = value[2:]
+			}
+		}
+
+		switch {
+		case serviceID == dcpSet && direction == "request":
+			if change := p.decodeSetBlock(option, suboption, value, frame.Details); change != "" {
+				changes = append(changes, change)
+			}
+		case !hasPrefix:
+			// Identify filters describe the device being searched for, not the sender
+			if option == dcpOptionDevice && suboption == 0x02 {
+				frame.Details["identify_filter_name"] = string(value)
+			}
+		default:
+			p.decodeBlock(option, suboption, value, frame.Details)
+		}
+
+		// Blocks are padded to an even length
+		next := 4 + blockLength + blockLength%2
+		if next > len(blocks) {
+			break
+		}
+		blocks = blocks[next:]
+	}
+
+	if len(changes) > 0 {
+		frame.Details["high_risk_operation"] = "DCP Set " + strings.Join(changes, " + ")
+	}
+	return true
+}
+
+// decodeBlock extracts device identification from Identify, Hello and Get responses
+func (p *ProfinetAnalyzer) decodeBlock(option, suboption uint8, value []byte, details map[string]interface{}) {
+	switch option {
+	case dcpOptionIP:
+		switch suboption {
+		case 0x01:
+			if len(value) >= 6 {
+				details["mac_address"] = net.HardwareAddr(value[0:6]).String()
+			}
+		case 0x02, 0x03:
+			if len(value) >= 12 {
+				details["ip_address"] = net.IP(value[0:4]).String()
+				details["subnet_mask"] = net.IP(value[4:8]).String()
+				details["gateway"] = net.IP(value[8:12]).String()
+			}
+		}
+	case dcpOptionDevice:
+		switch suboption {
+		case 0x01:
+			details["type_of_station"] = strings.TrimRight(string(value), " \x00")
+		case 0x02:
+			details["name_of_station"] = string(value)
+		case 0x03:
+			if len(value) >= 4 {
+				details["vendor_id"] = binary.BigEndian.Uint16(value[0:2])
+				details["device_id"] = binary.BigEndian.Uint16(value[2:4])
+			}
+		case 0x04:
+			if len(value) >= 1 {
+				details["device_role"] = dcpDeviceRoles(value[0])
+			}
+		case 0x06:
+			details["alias_name"] = string(value)
+		}
+	}
+}
+
+// decodeSetBlock records what a Set request changes and returns a short label for
+// changes that rename, re-address or reset the device
+func (p *ProfinetAnalyzer) decodeSetBlock(option, suboption uint8, value []byte, details map[string]interface{}) string {
+	switch {
+	case option == dcpOptionDevice && suboption == 0x02:
+		details["dcp_set_name"] = string(value)
+		return "NameOfStation"
+	case option == dcpOptionIP && (suboption == 0x02 || suboption == 0x03):
+		if len(value) < 12 {
+			return "IP"
+		}
+		details["dcp_set_ip"] = net.IP(value[0:4]).String()
+		details["dcp_set_subnet_mask"] = net.IP(value[4:8]).String()
+		details["dcp_set_gateway"] = net.IP(value[8:12]).String()
+		return "IP"
+	case option == dcpOptionControl:
+		name, exists := p.controls[suboption]
+		if !exists {
+			return ""
+		}
+		details["dcp_control"] = name
+		if suboption == 0x05 || suboption == 0x06 {
+			return name
+		}
+	}
+	return ""
+}
+
+// dcpDeviceRoles names the role bits, e.g. "IO-Device,IO-Controller"
+func dcpDeviceRoles(role uint8) string {
+	var roles []string
+	for _, r := range []struct {
+		bit  uint8
+		name string
+	}{
+		{0x01, "IO-Device"},
+		{0x02, "IO-Controller"},
+		{0x04, "IO-Multidevice"},
+		{0x08, "IO-Supervisor"},
+	} {
+		if role&r.bit != 0 {
+			roles = append(roles, r.name)
+		}
+	}
+	return strings.Join(roles, ",")
+}
@@ -0,0 +1,605 @@
+package analyzers
+
+import (
+	"cipgram/pkg/pcap/core"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Protocol identifiers carried in COTP data TPDUs
+const (
+	s7commProtocolID     uint8 = 0x32
+	s7commPlusProtocolID uint8 = 0x72
+)
+
+// COTP TPDU codes
+const (
+	cotpConnectionRequest uint8 = 0xE0
+	cotpConnectionConfirm uint8 = 0xD0
+	cotpDisconnectRequest uint8 = 0x80
+	cotpData              uint8 = 0xF0
+)
+
+// S7comm PDU types (ROSCTR)
+const (
+	s7Job      uint8 = 0x01
+	s7Ack      uint8 = 0x02
+	s7AckData  uint8 = 0x03
+	s7UserData uint8 = 0x07
+)
+
+// S7comm job functions
+const (
+	s7ReadVar            uint8 = 0x04
+	s7WriteVar           uint8 = 0x05
+	s7RequestDownload    uint8 = 0x1A
+	s7DownloadBlock      uint8 = 0x1B
+	s7DownloadEnded      uint8 = 0x1C
+	s7StartUpload        uint8 = 0x1D
+	s7Upload             uint8 = 0x1E
+	s7EndUpload          uint8 = 0x1F
+	s7PLCControl         uint8 = 0x28
+	s7PLCStop            uint8 = 0x29
+	s7SetupCommunication uint8 = 0xF0
+)
+
+// SZL lists that identify the CPU
+const (
+	szlModuleIdentification    uint16 = 0x0011
+	szlComponentIdentification uint16 = 0x001C
+)
+
+// S7CommAnalyzer implements DPI for Siemens S7comm and S7comm-plus over ISO-on-TCP
+type S7CommAnalyzer struct {
+	functions      map[uint8]string
+	pduTypes       map[uint8]string
+	userDataGroups map[uint8]string
+	plusOpcodes    map[uint8]string
+	blockTypes     map[string]string
+}
+
+// NewS7CommAnalyzer creates a new S7comm analyzer
+func NewS7CommAnalyzer() *S7CommAnalyzer {
+	return &S7CommAnalyzer{
+		functions: map[uint8]string{
+			0x00:                 "CPU Services",
+			s7ReadVar:            "Read Var",
+			s7WriteVar:           "Write Var",
+			s7RequestDownload:    "Request Download",
+			s7DownloadBlock:      "Download Block",
+			s7DownloadEnded:      "Download Ended",
+			s7StartUpload:        "Start Upload",
+			s7Upload:             "Upload",
+			s7EndUpload:          "End Upload",
+			s7PLCControl:         "PLC Control",
+			s7PLCStop:            "PLC Stop",
+			s7SetupCommunication: "Setup Communication",
+		},
+		pduTypes: map[uint8]string{
+			s7Job:      "Job",
+			s7Ack:      "Ack",
+			s7AckData:  "Ack_Data",
+			s7UserData: "Userdata",
+		},
+		userDataGroups: map[uint8]string{
+			0x0: "Mode Transition",
+			0x1: "Programmer Commands",
+			0x2: "Cyclic Data",
+			0x3: "Block Functions",
+			0x4: "CPU Functions",
+			0x5: "Security",
+			0x6: "PBC BSEND/BRECV",
+			0x7: "Time Functions",
+		},
+		plusOpcodes: map[uint8]string{
+			0x31: "Request",
+			0x32: "Response",
+			0x33: "Notification",
+			0x02: "Response2",
+		},
+		blockTypes: map[string]string{
+			"08": "OB",
+			"0A": "DB",
+			"0B": "SDB",
+			"0C": "FC",
+			"0D": "SFC",
+			"0E": "FB",
+			"0F": "SFB",
+		},
+	}
+}
+
+// CanAnalyze determines if this analyzer can process the packet
+func (s *S7CommAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil {
+		return false
+	}
+
+	tcp := tcpLayer.(*layers.TCP)
+
+	// Check ISO-TSAP port (102)
+	if tcp.SrcPort == 102 || tcp.DstPort == 102 {
+		return true
+	}
+
+	// Also check if payload looks like TPKT carrying S7
+	return s.looksLikeS7(tcp.Payload)
+}
+
+// Analyze performs S7comm protocol analysis
+func (s *S7CommAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil {
+		return nil
+	}
+
+	return s.AnalyzePDU(packet, tcpLayer.(*layers.TCP).Payload)
+}
+
+// PDULength returns the length of the TPKT at the start of data
+func (s *S7CommAnalyzer) PDULength(data []byte) int {
+	if len(data) < 4 {
+		return 0
+	}
+	if data[0] != 0x03 || data[1] != 0x00 {
+		return -1
+	}
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if length < 7 {
+		return -1
+	}
+	return length
+}
+
+// AnalyzePDU analyzes one TPKT of the TCP stream that packet belongs to
+func (s *S7CommAnalyzer) AnalyzePDU(packet gopacket.Packet, payload []byte) *core.AnalysisResult {
+	tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !ok || len(payload) < 7 {
+		return nil
+	}
+
+	frame := s.parseTPKT(payload, tcp.SrcPort == 102 && tcp.DstPort != 102)
+	if frame == nil {
+		return nil
+	}
+
+	return &core.AnalysisResult{
+		Protocol:    "S7Comm",
+		Subprotocol: frame.Subprotocol,
+		Confidence:  frame.Confidence,
+		Details:     frame.Details,
+		Metadata:    frame.Metadata,
+	}
+}
+
+// GetProtocolName returns the protocol name
+func (s *S7CommAnalyzer) GetProtocolName() string {
+	return "S7Comm"
+}
+
+// GetConfidenceThreshold returns the minimum confidence threshold
+func (s *S7CommAnalyzer) GetConfidenceThreshold() float32 {
+	return 0.85
+}
+
+// S7Frame represents a parsed TPKT/COTP frame and the S7 PDU it carries
+type S7Frame struct {
+	TPKTLength  uint16
+	COTPType    uint8
+	IsResponse  bool // Sent from port 102
+	Subprotocol string
+	Confidence  float32
+	Details     map[string]interface{}
+	Metadata    map[string]string
+}
+
+// parseTPKT parses the TPKT (RFC 1006) and COTP (ISO 8073) headers and dispatches on the payload
+func (s *S7CommAnalyzer) parseTPKT(payload []byte, isResponse bool) *S7Frame {
+	// TPKT: version 3, reserved, 16-bit length including the header
+	if len(payload) < 7 || payload[0] != 0x03 || payload[1] != 0x00 {
+		return nil
+	}
+	tpktLength := binary.BigEndian.Uint16(payload[2:4])
+	if tpktLength < 7 {
+		return nil
+	}
+	if int(tpktLength) < len(payload) {
+		payload = payload[:tpktLength]
+	}
+
+	// COTP: length indicator excludes itself
+	cotpLength := int(payload[4])
+	cotpType := payload[5] & 0xF0
+	if cotpLength < 1 || 5+cotpLength > len(payload) {
+		return nil
+	}
+
+	frame := &S7Frame{
+		TPKTLength: tpktLength,
+		COTPType:   cotpType,
+		IsResponse: isResponse,
+		Confidence: 0.90,
+		Details:    make(map[string]interface{}),
+		Metadata:   make(map[string]string),
+	}
+
+	direction := "request"
+	if isResponse {
+		direction = "response"
+	}
+	frame.Details["direction"] = direction
+	frame.Details["tpkt_length"] = tpktLength
+
+	switch cotpType {
+	case cotpConnectionRequest, cotpConnectionConfirm:
+		name := "Connection Request"
+		if cotpType == cotpConnectionConfirm {
+			name = "Connection Confirm"
+		}
+		frame.Details["cotp_pdu_type"] = name
+		s.parseCOTPParameters(payload[5:5+cotpLength], frame.Details)
+		frame.Subprotocol = "COTP " + name
+		return frame
+	case cotpDisconnectRequest:
+		frame.Details["cotp_pdu_type"] = "Disconnect Request"
+		frame.Subprotocol = "COTP Disconnect Request"
+		return frame
+	case cotpData:
+		frame.Details["cotp_pdu_type"] = "Data"
+	default:
+		return nil
+	}
+
+	data := payload[5+cotpLength:]
+	if len(data) == 0 {
+		return nil
+	}
+
+	switch data[0] {
+	case s7commProtocolID:
+		if !s.parseS7Comm(data, frame) {
+			return nil
+		}
+		frame.Confidence = 0.95
+	case s7commPlusProtocolID:
+		if !s.parseS7CommPlus(data, frame) {
+			return nil
+		}
+		frame.Confidence = 0.95
+	default:
+		return nil
+	}
+
+	frame.Metadata["cotp_pdu_type"] = "Data"
+	return frame
+}
+
+// parseCOTPParameters extracts the TSAPs from a connection request or confirm.
+// The destination TSAP of an S7 connection encodes the CPU rack and slot.
+func (s *S7CommAnalyzer) parseCOTPParameters(cotp []byte, details map[string]interface{}) {
+	// Fixed part: code, destination reference, source reference, class
+	if len(cotp) < 6 {
+		return
+	}
+	params := cotp[6:]
+
+	for len(params) >= 2 {
+		code := params[0]
+		length := int(params[1])
+		if 2+length > len(params) {
+			return
+		}
+		value := params[2 : 2+length]
+
+		if length == 2 {
+			tsap := binary.BigEndian.Uint16(value)
+			switch code {
+			case 0xC1:
+				details["src_tsap"] = tsap
+			case 0xC2:
+				details["dst_tsap"] = tsap
+				details["rack"] = value[1] >> 5
+				details["slot"] = value[1] & 0x1F
+			}
+		}
+		params = params[2+length:]
+	}
+}
+
+// parseS7Comm parses a classic S7comm PDU
+func (s *S7CommAnalyzer) parseS7Comm(data []byte, frame *S7Frame) bool {
+	if len(data) < 10 {
+		return false
+	}
+
+	rosctr := data[1]
+	pduType, exists := s.pduTypes[rosctr]
+	if !exists {
+		return false
+	}
+
+	headerLength := 10
+	if rosctr == s7Ack || rosctr == s7AckData {
+		headerLength = 12
+		if len(data) < headerLength {
+			return false
+		}
+	}
+
+	pduRef := binary.BigEndian.Uint16(data[4:6])
+	paramLength := int(binary.BigEndian.Uint16(data[6:8]))
+	dataLength := int(binary.BigEndian.Uint16(data[8:10]))
+
+	frame.Details["s7_pdu_type"] = pduType
+	frame.Details["s7_pdu_ref"] = pduRef
+	frame.Metadata["s7_pdu_type"] = pduType
+
+	if headerLength == 12 {
+		errorClass, errorCode := data[10], data[11]
+		if errorClass != 0 || errorCode != 0 {
+			frame.Details["error_class"] = errorClass
+			frame.Details["error_code"] = errorCode
+		}
+	}
+
+	param := boundedSlice(data, headerLength, paramLength)
+	userData := boundedSlice(data, headerLength+paramLength, dataLength)
+
+	frame.Subprotocol = pduType
+	if rosctr == s7UserData {
+		s.parseUserData(param, userData, frame)
+		return true
+	}
+	if len(param) == 0 {
+		return true
+	}
+
+	function := param[0]
+	functionName, exists := s.functions[function]
+	if !exists {
+		functionName = fmt.Sprintf("Unknown Function (0x%02X)", function)
+	}
+	frame.Details["function_code"] = function
+	frame.Details["function_name"] = functionName
+	frame.Subprotocol = fmt.Sprintf("%s (%s)", pduType, functionName)
+
+	switch function {
+	case s7SetupCommunication:
+		if len(param) >= 8 {
+			frame.Details["max_amq_calling"] = binary.BigEndian.Uint16(param[2:4])
+			frame.Details["max_amq_called"] = binary.BigEndian.Uint16(param[4:6])
+			frame.Details["pdu_length"] = binary.BigEndian.Uint16(param[6:8])
+		}
+	case s7ReadVar, s7WriteVar:
+		if len(param) >= 2 {
+			frame.Details["item_count"] = param[1]
+		}
+	case s7RequestDownload, s7DownloadBlock, s7DownloadEnded, s7StartUpload:
+		if rosctr == s7Job {
+			s.parseBlockFilename(param, frame.Details)
+		}
+	case s7PLCControl, s7PLCStop:
+		if rosctr == s7Job {
+			s.parsePIService(function, param, frame.Details)
+		}
+	}
+
+	// Requests that change what the CPU runs
+	if rosctr == s7Job {
+		switch function {
+		case s7PLCStop:
+			frame.Details["high_risk_operation"] = "PLC Stop"
+		case s7RequestDownload:
+			frame.Details["high_risk_operation"] = "Program Download"
+		}
+	}
+
+	return true
+}
+
+// parseBlockFilename decodes the block named by a download or upload request,
+// e.g. "_0A00001P" is DB1 destined for the passive file system
+func (s *S7CommAnalyzer) parseBlockFilename(param []byte, details map[string]interface{}) {
+	// Function, status, 2 unknown bytes, 4-byte upload ID, filename length, filename
+	if len(param) < 9 {
+		return
+	}
+	length := int(param[8])
+	if length < 9 || 9+length > len(param) {
+		return
+	}
+	filename := string(param[9 : 9+length])
+	details["block_filename"] = filename
+
+	blockType, exists := s.blockTypes[filename[1:3]]
+	if !exists {
+		blockType = "0x" + filename[1:3]
+	}
+	number := strings.TrimLeft(filename[3:8], "0")
+	if number == "" {
+		number = "0"
+	}
+	details["block"] = blockType + number
+}
+
+// parsePIService extracts the program invocation service name, e.g. "P_PROGRAM"
+func (s *S7CommAnalyzer) parsePIService(function uint8, param []byte, details map[string]interface{}) {
+	offset := 0
+	if function == s7PLCStop {
+		// Function, 5 unknown bytes, service length
+		offset = 6
+	} else {
+		// Function, 7 unknown bytes, parameter block length and block, service length
+		if len(param) < 10 {
+			return
+		}
+		offset = 10 + int(binary.BigEndian.Uint16(param[8:10]))
+	}
+	if offset >= len(param) {
+		return
+	}
+	length := int(param[offset])
+	if offset+1+length > len(param) {
+		return
+	}
+	service := string(param[offset+1 : offset+1+length])
+	details["pi_service"] = service
+
+	if function == s7PLCStop {
+		details["plc_control"] = "stop"
+	} else if service == "P_PROGRAM" {
+		details["plc_control"] = "start"
+	}
+}
+
+// parseUserData parses a userdata PDU and decodes SZL reads
+func (s *S7CommAnalyzer) parseUserData(param, data []byte, frame *S7Frame) {
+	// Parameter head 00 01 12, length, method, type/group, subfunction, sequence
+	if len(param) < 8 || param[0] != 0x00 || param[1] != 0x01 || param[2] != 0x12 {
+		return
+	}
+
+	functionType := param[5] >> 4
+	group := param[5] & 0x0F
+	subfunction := param[6]
+
+	groupName, exists := s.userDataGroups[group]
+	if !exists {
+		groupName = fmt.Sprintf("Unknown Group (0x%X)", group)
+	}
+	frame.Details["userdata_group"] = groupName
+	frame.Details["userdata_subfunction"] = subfunction
+	frame.Subprotocol = fmt.Sprintf("Userdata (%s)", groupName)
+
+	// CPU functions / Read SZL
+	if group != 0x4 || subfunction != 0x01 {
+		return
+	}
+	frame.Details["userdata_function"] = "Read SZL"
+	frame.Subprotocol = "Userdata (Read SZL)"
+
+	// Return code, transport size, 16-bit length, SZL ID, SZL index
+	if len(data) < 8 {
+		return
+	}
+	szlID := binary.BigEndian.Uint16(data[4:6])
+	szlIndex := binary.BigEndian.Uint16(data[6:8])
+	frame.Details["szl_id"] = szlID
+	frame.Details["szl_index"] = szlIndex
+
+	// Responses carry the list itself when the read succeeded
+	if functionType != 0x8 || data[0] != 0xFF || len(data) < 12 {
+		return
+	}
+	recordLength := int(binary.BigEndian.Uint16(data[8:10]))
+	recordCount := int(binary.BigEndian.Uint16(data[10:12]))
+	if recordLength < 2 {
+		return
+	}
+
+	records := data[12:]
+	for i := 0; i < recordCount && len(records) >= recordLength; i++ {
+		s.parseSZLRecord(szlID&0x00FF, records[:recordLength], frame.Details)
+		records = records[recordLength:]
+	}
+}
+
+// parseSZLRecord extracts identification strings from module (0x0011) and
+// component (0x001C) identification records. The high byte of the SZL ID only
+// selects partial lists, so it is masked off by the caller.
+func (s *S7CommAnalyzer) parseSZLRecord(szlID uint16, record []byte, details map[string]interface{}) {
+	index := binary.BigEndian.Uint16(record[0:2])
+
+	switch szlID {
+	case szlModuleIdentification:
+		// Index, 20-character order number, module type, version words
+		if len(record) < 28 {
+			return
+		}
+		switch index {
+		case 0x0001:
+			details["s7_order_number"] = szlString(record[2:22])
+		case 0x0007:
+			ausbg := binary.BigEndian.Uint16(record[24:26])
+			ausbe := binary.BigEndian.Uint16(record[26:28])
+			if ausbg>>8 == 'V' {
+				details["s7_firmware_version"] = fmt.Sprintf("V%d.%d.%d", ausbg&0xFF, ausbe>>8, ausbe&0xFF)
+			}
+		}
+	case szlComponentIdentification:
+		// Index and a 32-character name
+		if len(record) < 34 {
+			return
+		}
+		value := szlString(record[2:34])
+		switch index {
+		case 0x0001:
+			details["s7_system_name"] = value
+		case 0x0002:
+			details["s7_module_name"] = value
+		case 0x0005:
+			details["s7_serial_number"] = value
+		case 0x0007:
+			details["s7_module_type"] = value
+		}
+	}
+}
+
+// parseS7CommPlus identifies an S7comm-plus PDU by its opcode; the payload is not decoded
+func (s *S7CommAnalyzer) parseS7CommPlus(data []byte, frame *S7Frame) bool {
+	if len(data) < 5 {
+		return false
+	}
+
+	opcode := data[4]
+	opcodeName, exists := s.plusOpcodes[opcode]
+	if !exists {
+		opcodeName = fmt.Sprintf("Unknown Opcode (0x%02X)", opcode)
+	}
+
+	frame.Details["s7comm_plus"] = true
+	frame.Details["s7plus_version"] = data[1]
+	frame.Details["s7plus_opcode"] = opcode
+	frame.Details["s7plus_opcode_name"] = opcodeName
+	frame.Metadata["variant"] = "S7comm-plus"
+	frame.Subprotocol = fmt.Sprintf("S7comm-plus (%s)", opcodeName)
+	return true
+}
+
+// looksLikeS7 checks for a TPKT header followed by a COTP data TPDU carrying S7comm or S7comm-plus
+func (s *S7CommAnalyzer) looksLikeS7(payload []byte) bool {
+	if len(payload) < 8 || payload[0] != 0x03 || payload[1] != 0x00 {
+		return false
+	}
+	if int(binary.BigEndian.Uint16(payload[2:4])) != len(payload) {
+		return false
+	}
+	cotpLength := int(payload[4])
+	if payload[5] != cotpData || 5+cotpLength >= len(payload) {
+		return false
+	}
+	protocolID := payload[5+cotpLength]
+	return protocolID == s7commProtocolID || protocolID == s7commPlusProtocolID
+}
+
+// boundedSlice returns up to length bytes of data starting at offset
+func boundedSlice(data []byte, offset, length int) []byte {
+	if offset >= len(data) {
+		return nil
+	}
+	end := offset + length
+	if end > len(data) {
+		end = len(data)
+	}
+	return data[offset:end]
+}
+
+// szlString trims the space and NUL padding of an SZL text field
+func szlString(raw []byte) string {
+	return strings.TrimRight(string(raw), " \x00")
+}
@@ -0,0 +1,767 @@
+package analyzers
+
+import (
+	"cipgram/pkg/pcap/core"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// TLS record content types
+const (
+	tlsChangeCipherSpec uint8 = 0x14
+	tlsAlert            uint8 = 0x15
+	tlsHandshake        uint8 = 0x16
+	tlsApplicationData  uint8 = 0x17
+)
+
+// TLS handshake message types that are decoded
+const (
+	tlsClientHello uint8 = 0x01
+	tlsServerHello uint8 = 0x02
+	tlsCertificate uint8 = 0x0B
+)
+
+// TLS extensions used for fingerprinting
+const (
+	tlsExtServerName          uint16 = 0x0000
+	tlsExtSupportedGroups     uint16 = 0x000A
+	tlsExtECPointFormats      uint16 = 0x000B
+	tlsExtSignatureAlgorithms uint16 = 0x000D
+	tlsExtALPN                uint16 = 0x0010
+	tlsExtSupportedVersions   uint16 = 0x002B
+)
+
+// tlsMaxRecordLength allows for the compression and MAC expansion of TLS 1.2 records
+const tlsMaxRecordLength = 16384 + 2048
+
+// TLSAnalyzer implements DPI for TLS handshakes on any TCP port
+type TLSAnalyzer struct {
+	recordTypes    map[uint8]string
+	handshakeTypes map[uint8]string
+	cipherSuites   map[uint16]string
+	wellKnownPorts map[uint16]bool
+}
+
+// NewTLSAnalyzer creates a new TLS analyzer
+func NewTLSAnalyzer() *TLSAnalyzer {
+	return &TLSAnalyzer{
+		recordTypes: map[uint8]string{
+			tlsChangeCipherSpec: "Change Cipher Spec",
+			tlsAlert:            "Alert",
+			tlsHandshake:        "Handshake",
+			tlsApplicationData:  "Application Data",
+		},
+		handshakeTypes: map[uint8]string{
+			0x00: "HelloRequest",
+			0x01: "ClientHello",
+			0x02: "ServerHello",
+			0x04: "NewSessionTicket",
+			0x08: "EncryptedExtensions",
+			0x0B: "Certificate",
+			0x0C: "ServerKeyExchange",
+			0x0D: "CertificateRequest",
+			0x0E: "ServerHelloDone",
+			0x0F: "CertificateVerify",
+			0x10: "ClientKeyExchange",
+			0x14: "Finished",
+		},
+		cipherSuites: map[uint16]string{
+			0x0000: "TLS_NULL_WITH_NULL_NULL",
+			0x0001: "TLS_RSA_WITH_NULL_MD5",
+			0x0002: "TLS_RSA_WITH_NULL_SHA",
+			0x0003: "TLS_RSA_EXPORT_WITH_RC4_40_MD5",
+			0x0004: "TLS_RSA_WITH_RC4_128_MD5",
+			0x0005: "TLS_RSA_WITH_RC4_128_SHA",
+			0x0006: "TLS_RSA_EXPORT_WITH_RC2_CBC_40_MD5",
+			0x0008: "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA",
+			0x0009: "TLS_RSA_WITH_DES_CBC_SHA",
+			0x000A: "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+			0x0016: "TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA",
+			0x0018: "TLS_DH_anon_WITH_RC4_128_MD5",
+			0x001B: "TLS_DH_anon_WITH_3DES_EDE_CBC_SHA",
+			0x002F: "TLS_RSA_WITH_AES_128_CBC_SHA",
+			0x0033: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA",
+			0x0034: "TLS_DH_anon_WITH_AES_128_CBC_SHA",
+			0x0035: "TLS_RSA_WITH_AES_256_CBC_SHA",
+			0x0039: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA",
+			0x003B: "TLS_RSA_WITH_NULL_SHA256",
+			0x003C: "TLS_RSA_WITH_AES_128_CBC_SHA256",
+			0x003D: "TLS_RSA_WITH_AES_256_CBC_SHA256",
+			0x0067: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256",
+			0x006B: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256",
+			0x009C: "TLS_RSA_WITH_AES_128_GCM_SHA256",
+			0x009D: "TLS_RSA_WITH_AES_256_GCM_SHA384",
+			0x009E: "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256",
+			0x009F: "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384",
+			0x00FF: "TLS_EMPTY_RENEGOTIATION_INFO_SCSV",
+			0x1301: "TLS_AES_128_GCM_SHA256",
+			0x1302: "TLS_AES_256_GCM_SHA384",
+			0x1303: "TLS_CHACHA20_POLY1305_SHA256",
+			0x1304: "TLS_AES_128_CCM_SHA256",
+			0x1305: "TLS_AES_128_CCM_8_SHA256",
+			0x5600: "TLS_FALLBACK_SCSV",
+			0xC007: "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA",
+			0xC008: "TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA",
+			0xC009: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+			0xC00A: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+			0xC011: "TLS_ECDHE_RSA_WITH_RC4_128_SHA",
+			0xC012: "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA",
+			0xC013: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+			0xC014: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+			0xC023: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256",
+			0xC024: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384",
+			0xC027: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+			0xC028: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384",
+			0xC02B: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			0xC02C: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			0xC02F: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+			0xC030: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			0xCCA8: "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+			0xCCA9: "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+			0xCCAA: "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+		},
+		wellKnownPorts: map[uint16]bool{
+			443: true, 465: true, 636: true, 853: true, 993: true, 995: true,
+			4843: true, 8443: true, 8883: true, 9443: true,
+		},
+	}
+}
+
+// CanAnalyze determines if this analyzer can process the packet. TLS is recognised by
+// its record header rather than by port, since OT vendors run it on arbitrary ports.
+func (t *TLSAnalyzer) CanAnalyze(packet gopacket.Packet) bool {
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil {
+		return false
+	}
+	return t.looksLikeTLS(tcpLayer.(*layers.TCP).Payload)
+}
+
+// Analyze performs TLS protocol analysis
+func (t *TLSAnalyzer) Analyze(packet gopacket.Packet) *core.AnalysisResult {
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil {
+		return nil
+	}
+
+	return t.AnalyzePDU(packet, tcpLayer.(*layers.TCP).Payload)
+}
+
+// PDULength returns the length of the TLS records at the start of data. A handshake
+// message may span several records, so records are framed together until the messages
+// begun in them are complete. Handshake records after a Change Cipher Spec are encrypted
+// and framed on their own.
+func (t *TLSAnalyzer) PDULength(data []byte) int {
+	if len(data) < 5 {
+		return 0
+	}
+	if !t.looksLikeTLS(data) {
+		return -1
+	}
+
+	framed, offset, owed := 0, 0, 0
+	encrypted := false
+	for len(data)-offset >= 5 {
+		record := data[offset:]
+		length := int(binary.BigEndian.Uint16(record[3:5]))
+		if record[1] != 0x03 || length == 0 || length > tlsMaxRecordLength {
+			break
+		}
+		if len(record) < 5+length {
+			break
+		}
+		switch {
+		case record[0] == tlsChangeCipherSpec:
+			encrypted = true
+			owed = 0
+		case record[0] == tlsHandshake && !encrypted:
+			owed = handshakeBytesOwed(record[5:5+length], owed)
+		default:
+			owed = 0
+		}
+		offset += 5 + length
+		if owed == 0 {
+			framed = offset
+		}
+	}
+	return framed
+}
+
+// handshakeBytesOwed walks the handshake messages of a record fragment, starting owed
+// bytes into it, and returns how many bytes of the last message are still to come
+func handshakeBytesOwed(fragment []byte, owed int) int {
+	if owed >= len(fragment) {
+		return owed - len(fragment)
+	}
+	fragment = fragment[owed:]
+	for len(fragment) >= 4 {
+		length := 4 + (int(fragment[1])<<16 | int(fragment[2])<<8 | int(fragment[3]))
+		if length > len(fragment) {
+			return length - len(fragment)
+		}
+		fragment = fragment[length:]
+	}
+	if len(fragment) > 0 {
+		return 4 - len(fragment) // The message header itself continues
+	}
+	return 0
+}
+
+// AnalyzePDU analyzes TLS records of the TCP stream that packet belongs to
+func (t *TLSAnalyzer) AnalyzePDU(packet gopacket.Packet, payload []byte) *core.AnalysisResult {
+	tcp, ok := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	if !ok || !t.looksLikeTLS(payload) {
+		return nil
+	}
+
+	recordType := payload[0]
+	version := binary.BigEndian.Uint16(payload[1:3])
+	length := binary.BigEndian.Uint16(payload[3:5])
+
+	msg := &TLSMessage{
+		Subprotocol: t.recordTypes[recordType],
+		Confidence:  0.70,
+		Details: map[string]interface{}{
+			"record_type": recordType,
+			"version":     version,
+			"length":      length,
+		},
+		Metadata: map[string]string{
+			"protocol_type": "security",
+			"layer":         "presentation",
+		},
+	}
+	if t.wellKnownPorts[uint16(tcp.SrcPort)] || t.wellKnownPorts[uint16(tcp.DstPort)] {
+		msg.Confidence = 0.85
+	}
+	if captured := packet.Metadata().Timestamp; !captured.IsZero() {
+		msg.CapturedAt = captured.Unix()
+	}
+
+	t.parseHandshakes(payload, msg)
+
+	return &core.AnalysisResult{
+		Protocol:    "TLS",
+		Subprotocol: msg.Subprotocol,
+		Confidence:  msg.Confidence,
+		Details:     msg.Details,
+		Metadata:    msg.Metadata,
+	}
+}
+
+// GetProtocolName returns the protocol name
+func (t *TLSAnalyzer) GetProtocolName() string {
+	return "TLS"
+}
+
+// GetConfidenceThreshold returns the minimum confidence threshold
+func (t *TLSAnalyzer) GetConfidenceThreshold() float32 {
+	return 0.8
+}
+
+// TLSMessage represents the TLS records of one segment
+type TLSMessage struct {
+	Subprotocol string
+	Confidence  float32
+	CapturedAt  int64 // Unix seconds of the packet, 0 if unknown
+	Details     map[string]interface{}
+	Metadata    map[string]string
+}
+
+// looksLikeTLS checks the first record header and, for handshakes, the message type
+func (t *TLSAnalyzer) looksLikeTLS(payload []byte) bool {
+	if len(payload) < 5 {
+		return false
+	}
+	if _, known := t.recordTypes[payload[0]]; !known {
+		return false
+	}
+	if payload[1] != 0x03 || payload[2] > 0x04 {
+		return false
+	}
+	length := int(binary.BigEndian.Uint16(payload[3:5]))
+	if length == 0 || length > tlsMaxRecordLength {
+		return false
+	}
+	if payload[0] == tlsHandshake && len(payload) >= 6 {
+		_, known := t.handshakeTypes[payload[5]]
+		return known
+	}
+	return true
+}
+
+// parseHandshakes joins the handshake records of the segment and decodes the hellos and
+// the server certificate. Records after a Change Cipher Spec are encrypted and skipped.
+func (t *TLSAnalyzer) parseHandshakes(payload []byte, msg *TLSMessage) {
+	var handshake []byte
+	for len(payload) >= 5 {
+		recordType := payload[0]
+		length := int(binary.BigEndian.Uint16(payload[3:5]))
+		if recordType == tlsChangeCipherSpec {
+			break
+		}
+		fragment := payload[5:]
+		if length < len(fragment) {
+			fragment = fragment[:length]
+		}
+		if recordType == tlsHandshake {
+			handshake = append(handshake, fragment...)
+		}
+		if 5+length > len(payload) {
+			break
+		}
+		payload = payload[5+length:]
+	}
+
+	var names []string
+	for len(handshake) >= 4 {
+		msgType := handshake[0]
+		length := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		name, known := t.handshakeTypes[msgType]
+		if !known {
+			break
+		}
+		names = append(names, name)
+		body := handshake[4:]
+		complete := length <= len(body)
+		if complete {
+			body = body[:length]
+		}
+
+		switch msgType {
+		case tlsClientHello:
+			if complete && t.parseClientHello(body, msg) {
+				msg.Confidence = 0.95
+			}
+		case tlsServerHello:
+			if complete && t.parseServerHello(body, msg) {
+				msg.Confidence = 0.95
+			}
+		case tlsCertificate:
+			t.parseCertificate(body, msg)
+		}
+
+		if !complete {
+			break
+		}
+		handshake = handshake[4+length:]
+	}
+
+	if len(names) > 0 {
+		msg.Details["handshake_types"] = names
+		msg.Subprotocol = strings.Join(names, ", ")
+	}
+}
+
+// tlsHello holds the fields of a ClientHello or ServerHello needed for fingerprinting
+type tlsHello struct {
+	version            uint16
+	ciphers            []uint16
+	extensions         []uint16
+	groups             []uint16
+	pointFormats       []uint8
+	signatureAlgs      []uint16
+	supportedVersions  []uint16
+	serverName         string
+	alpn               []string
+	compressionMethods []uint8
+}
+
+// parseClientHello decodes a ClientHello and computes its JA3 and JA4 fingerprints
+func (t *TLSAnalyzer) parseClientHello(body []byte, msg *TLSMessage) bool {
+	r := &tlsReader{data: body}
+	hello := &tlsHello{version: r.uint16()}
+	r.skip(32)             // Random
+	r.skip(int(r.uint8())) // Session ID
+	cipherBytes := r.vector16()
+	compression := r.vector8()
+	if r.failed || hello.version>>8 != 0x03 {
+		return false
+	}
+	for i := 0; i+1 < len(cipherBytes); i += 2 {
+		if suite := binary.BigEndian.Uint16(cipherBytes[i:]); !isGREASE(suite) {
+			hello.ciphers = append(hello.ciphers, suite)
+		}
+	}
+	hello.compressionMethods = compression
+	t.parseExtensions(r, hello, true)
+
+	maxVersion := hello.version
+	for _, v := range hello.supportedVersions {
+		if v > maxVersion {
+			maxVersion = v
+		}
+	}
+
+	msg.Details["direction"] = "request"
+	msg.Details["client_version"] = tlsVersionName(hello.version)
+	msg.Details["offered_cipher_suites"] = hello.ciphers
+	if len(hello.supportedVersions) > 0 {
+		var versions []string
+		for _, v := range hello.supportedVersions {
+			versions = append(versions, tlsVersionName(v))
+		}
+		msg.Details["supported_versions"] = versions
+	}
+	if hello.serverName != "" {
+		msg.Details["server_name"] = hello.serverName
+	}
+	if len(hello.alpn) > 0 {
+		msg.Details["alpn"] = hello.alpn
+	}
+
+	ja3 := t.ja3(hello)
+	msg.Details["ja3"] = ja3
+	msg.Details["ja3_hash"] = fmt.Sprintf("%x", md5.Sum([]byte(ja3)))
+	msg.Details["ja4"] = t.ja4(hello, maxVersion)
+
+	if deprecatedTLSVersion(maxVersion) {
+		msg.Details["tls_weaknesses"] = []string{"Client limited to " + tlsVersionName(maxVersion)}
+	}
+	return true
+}
+
+// parseServerHello decodes a ServerHello, the negotiated parameters and its JA3S fingerprint
+func (t *TLSAnalyzer) parseServerHello(body []byte, msg *TLSMessage) bool {
+	r := &tlsReader{data: body}
+	hello := &tlsHello{version: r.uint16()}
+	r.skip(32)             // Random
+	r.skip(int(r.uint8())) // Session ID
+	suite := r.uint16()
+	r.skip(1) // Compression method
+	if r.failed || hello.version>>8 != 0x03 {
+		return false
+	}
+	hello.ciphers = []uint16{suite}
+	t.parseExtensions(r, hello, false)
+
+	// TLS 1.3 keeps the legacy version at 1.2 and negotiates in supported_versions
+	negotiated := hello.version
+	if len(hello.supportedVersions) == 1 {
+		negotiated = hello.supportedVersions[0]
+	}
+	suiteName := t.cipherSuiteName(suite)
+
+	msg.Details["direction"] = "response"
+	msg.Details["tls_version"] = tlsVersionName(negotiated)
+	msg.Details["cipher_suite"] = suite
+	msg.Details["cipher_suite_name"] = suiteName
+	if len(hello.alpn) > 0 {
+		msg.Details["alpn"] = hello.alpn
+	}
+
+	ja3s := fmt.Sprintf("%d,%d,%s", hello.version, suite, joinUint16(hello.extensions, "-"))
+	msg.Details["ja3s"] = ja3s
+	msg.Details["ja3s_hash"] = fmt.Sprintf("%x", md5.Sum([]byte(ja3s)))
+
+	var weaknesses []string
+	if deprecatedTLSVersion(negotiated) {
+		weaknesses = append(weaknesses, tlsVersionName(negotiated))
+	}
+	if weakCipherSuite(suiteName) {
+		weaknesses = append(weaknesses, suiteName)
+	}
+	if len(weaknesses) > 0 {
+		msg.Details["tls_weaknesses"] = weaknesses
+	}
+	return true
+}
+
+// parseExtensions reads the extension block of a hello
+func (t *TLSAnalyzer) parseExtensions(r *tlsReader, hello *tlsHello, client bool) {
+	if r.remaining() < 2 {
+		return
+	}
+	extensions := &tlsReader{data: r.vector16()}
+	for extensions.remaining() >= 4 && !extensions.failed {
+		extType := extensions.uint16()
+		data := &tlsReader{data: extensions.vector16()}
+		if extensions.failed {
+			return
+		}
+		if isGREASE(extType) {
+			continue
+		}
+		hello.extensions = append(hello.extensions, extType)
+
+		switch extType {
+		case tlsExtServerName:
+			// Server name list: one host_name entry in practice
+			list := &tlsReader{data: data.vector16()}
+			if list.uint8() == 0 {
+				hello.serverName = string(list.vector16())
+			}
+		case tlsExtSupportedGroups:
+			groups := data.vector16()
+			for i := 0; i+1 < len(groups); i += 2 {
+				if group := binary.BigEndian.Uint16(groups[i:]); !isGREASE(group) {
+					hello.groups = append(hello.groups, group)
+				}
+			}
+		case tlsExtECPointFormats:
+			hello.pointFormats = data.vector8()
+		case tlsExtSignatureAlgorithms:
+			algorithms := data.vector16()
+			for i := 0; i+1 < len(algorithms); i += 2 {
+				hello.signatureAlgs = append(hello.signatureAlgs, binary.BigEndian.Uint16(algorithms[i:]))
+			}
+		case tlsExtALPN:
+			protocols := &tlsReader{data: data.vector16()}
+			for protocols.remaining() > 0 && !protocols.failed {
+				if protocol := protocols.vector8(); len(protocol) > 0 {
+					hello.alpn = append(hello.alpn, string(protocol))
+				}
+			}
+		case tlsExtSupportedVersions:
+			// A list in the ClientHello, the single selected version in the ServerHello
+			var versions []byte
+			if client {
+				versions = data.vector8()
+			} else {
+				versions = data.next(2)
+			}
+			for i := 0; i+1 < len(versions); i += 2 {
+				if v := binary.BigEndian.Uint16(versions[i:]); !isGREASE(v) {
+					hello.supportedVersions = append(hello.supportedVersions, v)
+				}
+			}
+		}
+	}
+}
+
+// parseCertificate decodes the leaf certificate of a TLS 1.2 Certificate message
+func (t *TLSAnalyzer) parseCertificate(body []byte, msg *TLSMessage) {
+	r := &tlsReader{data: body}
+	r.skip(3) // Certificate list length
+	leafLength := int(r.uint24())
+	der := r.next(leafLength)
+	if r.failed {
+		return
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return
+	}
+
+	msg.Details["direction"] = "response"
+	msg.Details["cert_subject"] = cert.Subject.String()
+	msg.Details["cert_issuer"] = cert.Issuer.String()
+	msg.Details["cert_not_before"] = cert.NotBefore
+	msg.Details["cert_not_after"] = cert.NotAfter
+	msg.Details["cert_self_signed"] = cert.Subject.String() == cert.Issuer.String()
+	if len(cert.Subject.Organization) > 0 {
+		msg.Details["cert_organization"] = cert.Subject.Organization[0]
+	}
+	if len(cert.DNSNames) > 0 {
+		msg.Details["cert_dns_names"] = cert.DNSNames
+	}
+	if msg.CapturedAt != 0 {
+		msg.Details["cert_expired"] = msg.CapturedAt > cert.NotAfter.Unix() || msg.CapturedAt < cert.NotBefore.Unix()
+	}
+}
+
+// ja3 builds the JA3 string: version, ciphers, extensions, groups, point formats
+func (t *TLSAnalyzer) ja3(hello *tlsHello) string {
+	formats := make([]uint16, len(hello.pointFormats))
+	for i, f := range hello.pointFormats {
+		formats[i] = uint16(f)
+	}
+	return fmt.Sprintf("%d,%s,%s,%s,%s", hello.version,
+		joinUint16(hello.ciphers, "-"), joinUint16(hello.extensions, "-"),
+		joinUint16(hello.groups, "-"), joinUint16(formats, "-"))
+}
+
+// ja4 builds a JA4 client fingerprint, e.g. "t13d1516h2_8daaf6152771_e5627efa2ab1"
+func (t *TLSAnalyzer) ja4(hello *tlsHello, maxVersion uint16) string {
+	versions := map[uint16]string{0x0304: "13", 0x0303: "12", 0x0302: "11", 0x0301: "10", 0x0300: "s3"}
+	version, known := versions[maxVersion]
+	if !known {
+		version = "00"
+	}
+	destination := "i"
+	if hello.serverName != "" {
+		destination = "d"
+	}
+	alpn := "00"
+	if len(hello.alpn) > 0 {
+		alpn = ja4ALPN(hello.alpn[0])
+	}
+
+	ciphers := make([]string, len(hello.ciphers))
+	for i, c := range hello.ciphers {
+		ciphers[i] = fmt.Sprintf("%04x", c)
+	}
+	sort.Strings(ciphers)
+
+	var extensions []string
+	for _, e := range hello.extensions {
+		if e != tlsExtServerName && e != tlsExtALPN {
+			extensions = append(extensions, fmt.Sprintf("%04x", e))
+		}
+	}
+	sort.Strings(extensions)
+	extensionInput := strings.Join(extensions, ",")
+	if len(hello.signatureAlgs) > 0 {
+		algorithms := make([]string, len(hello.signatureAlgs))
+		for i, a := range hello.signatureAlgs {
+			algorithms[i] = fmt.Sprintf("%04x", a)
+		}
+		extensionInput += "_" + strings.Join(algorithms, ",")
+	}
+
+	return fmt.Sprintf("t%s%s%02d%02d%s_%s_%s", version, destination,
+		minInt(len(hello.ciphers), 99), minInt(len(hello.extensions), 99), alpn,
+		ja4Hash(strings.Join(ciphers, ","), len(ciphers)), ja4Hash(extensionInput, len(extensions)))
+}
+
+// ja4ALPN takes the first and last characters of the ALPN value, or of its hex form
+// when either is not alphanumeric
+func ja4ALPN(value string) string {
+	first, last := value[0], value[len(value)-1]
+	if isAlphanumeric(first) && isAlphanumeric(last) {
+		return string([]byte{first, last})
+	}
+	encoded := hex.EncodeToString([]byte(value))
+	return string([]byte{encoded[0], encoded[len(encoded)-1]})
+}
+
+func ja4Hash(input string, count int) string {
+	if count == 0 {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func isAlphanumeric(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// cipherSuiteName names a cipher suite, falling back to its code
+func (t *TLSAnalyzer) cipherSuiteName(suite uint16) string {
+	if name, known := t.cipherSuites[suite]; known {
+		return name
+	}
+	return fmt.Sprintf("0x%04X", suite)
+}
+
+// isGREASE reports whether a value is one of the reserved GREASE values (RFC 8701)
+func isGREASE(value uint16) bool {
+	return value&0x0F0F == 0x0A0A && value>>8 == value&0xFF
+}
+
+// tlsVersionName names a protocol version, e.g. "TLS 1.2"
+func tlsVersionName(version uint16) string {
+	switch version {
+	case 0x0200:
+		return "SSL 2.0"
+	case 0x0300:
+		return "SSL 3.0"
+	case 0x0301:
+		return "TLS 1.0"
+	case 0x0302:
+		return "TLS 1.1"
+	case 0x0303:
+		return "TLS 1.2"
+	case 0x0304:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", version)
+	}
+}
+
+// deprecatedTLSVersion reports SSL and TLS versions before 1.2 (RFC 8996)
+func deprecatedTLSVersion(version uint16) bool {
+	return version < 0x0303
+}
+
+// weakCipherSuite reports suites without encryption or authentication, or with broken
+// ciphers: NULL, anonymous, export-grade, RC4, DES and 3DES, MD5
+func weakCipherSuite(name string) bool {
+	for _, marker := range []string{"_NULL_", "_anon_", "EXPORT", "RC4", "DES", "_MD5"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func joinUint16(values []uint16, sep string) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(int(v))
+	}
+	return strings.Join(parts, sep)
+}
+
+// tlsReader reads big-endian TLS fields, recording rather than panicking on truncation
+type tlsReader struct {
+	data   []byte
+	failed bool
+}
+
+func (r *tlsReader) remaining() int {
+	return len(r.data)
+}
+
+func (r *tlsReader) next(n int) []byte {
+	if r.failed || n < 0 || n > len(r.data) {
+		r.failed = true
+		return nil
+	}
+	value := r.data[:n]
+	r.data = r.data[n:]
+	return value
+}
+
+func (r *tlsReader) skip(n int) {
+	r.next(n)
+}
+
+func (r *tlsReader) uint8() uint8 {
+	if value := r.next(1); value != nil {
+		return value[0]
+	}
+	return 0
+}
+
+func (r *tlsReader) uint16() uint16 {
+	if value := r.next(2); value != nil {
+		return binary.BigEndian.Uint16(value)
+	}
+	return 0
+}
+
+func (r *tlsReader) uint24() uint32 {
+	if value := r.next(3); value != nil {
+		return uint32(value[0])<<16 | uint32(value[1])<<8 | uint32(value[2])
+	}
+	return 0
+}
+
+// vector8 reads a vector with a one-byte length
+func (r *tlsReader) vector8() []byte {
+	return r.next(int(r.uint8()))
+}
+
+// vector16 reads a vector with a two-byte length
+func (r *tlsReader) vector16() []byte {
+	return r.next(int(r.uint16()))
+}
@@ -61,10 +61,11 @@ func (c *CachedDPIEngine) AnalyzePacket(packet gopacket.Packet) *core.AnalysisRe
 		if result, ok := cached.(*core.AnalysisResult); ok {
 			// Create a copy to avoid mutation issues
 			return &core.AnalysisResult{
-				Protocol:   result.Protocol,
-				Confidence: result.Confidence,
-				Details:    copyInterfaceMap(result.Details),
-				Metadata:   copyStringMap(result.Metadata),
+				Protocol:    result.Protocol,
+				Subprotocol: result.Subprotocol,
+				Confidence:  result.Confidence,
+				Details:     copyInterfaceMap(result.Details),
+				Metadata:    copyStringMap(result.Metadata),
 			}
 		}
 	}
@@ -76,10 +77,11 @@ func (c *CachedDPIEngine) AnalyzePacket(packet gopacket.Packet) *core.AnalysisRe
 	if result != nil && result.Confidence >= 0.7 {
 		// Create a copy for caching to avoid mutation issues
 		cachedResult := &core.AnalysisResult{
-			Protocol:   result.Protocol,
-			Confidence: result.Confidence,
-			Details:    copyInterfaceMap(result.Details),
-			Metadata:   copyStringMap(result.Metadata),
+			Protocol:    result.Protocol,
+			Subprotocol: result.Subprotocol,
+			Confidence:  result.Confidence,
+			Details:     copyInterfaceMap(result.Details),
+			Metadata:    copyStringMap(result.Metadata),
 		}
 		c.cache.Put(cacheKey, cachedResult)
 	}
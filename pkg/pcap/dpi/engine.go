@@ -2,9 +2,13 @@ package dpi
 
 import (
 	"cipgram/pkg/pcap/core"
+	"fmt"
+	"hash/fnv"
+	"sort"
 	"sync"
 
 	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 )
 
 // ModularDPIEngine coordinates multiple protocol analyzers
@@ -23,6 +27,10 @@ type ModularDPIEngine struct {
 	cache      map[string]*core.AnalysisResult
 	cacheSize  int
 	cacheMutex sync.RWMutex
+
+	// TCP reassembly for AnalyzeStream
+	streams      *tcpReassembler
+	streamsMutex sync.Mutex
 }
 
 // DPIStats tracks DPI performance statistics
@@ -53,6 +61,7 @@ func NewModularDPIEngine(config *core.DPIConfig) *ModularDPIEngine {
 		},
 		cache:     make(map[string]*core.AnalysisResult),
 		cacheSize: 5000,
+		streams:   newTCPReassembler(),
 	}
 
 	engine.initializeAnalyzers()
@@ -79,6 +88,7 @@ func (engine *ModularDPIEngine) AnalyzePacket(packet gopacket.Packet) *core.Anal
 
 	var bestResult *core.AnalysisResult
 	bestConfidence := float32(0.0)
+	bestName := ""
 
 	// Try each analyzer
 	engine.analyzersMutex.RLock()
@@ -87,10 +97,12 @@ func (engine *ModularDPIEngine) AnalyzePacket(packet gopacket.Packet) *core.Anal
 			if result := analyzer.Analyze(packet); result != nil {
 				engine.updateAnalyzerStats(name, true, result.Confidence)
 
-				// Keep the result with highest confidence
-				if result.Confidence > bestConfidence {
+				// Keep the result with highest confidence; ties go to the first analyzer
+				// by name so that the result does not depend on map order
+				if result.Confidence > bestConfidence || (result.Confidence == bestConfidence && name < bestName) {
 					bestConfidence = result.Confidence
 					bestResult = result
+					bestName = name
 				}
 			} else {
 				engine.updateAnalyzerStats(name, false, 0.0)
@@ -110,6 +122,120 @@ func (engine *ModularDPIEngine) AnalyzePacket(packet gopacket.Packet) *core.Anal
 	return bestResult
 }
 
+// AnalyzeStream analyzes a packet as part of its TCP stream and returns a result for
+// every PDU the packet completes. Payload is reassembled per direction, so analyzers
+// that frame PDUs see messages split across segments whole and each message of a
+// segment carrying several; retransmissions yield nothing. Other packets, and streams
+// no framing analyzer recognises, are analyzed packet by packet like AnalyzePacket.
+func (engine *ModularDPIEngine) AnalyzeStream(packet gopacket.Packet) []*core.AnalysisResult {
+	tcp, isTCP := packet.Layer(layers.LayerTypeTCP).(*layers.TCP)
+	network := packet.NetworkLayer()
+	if !isTCP || network == nil {
+		return resultList(engine.AnalyzePacket(packet))
+	}
+
+	engine.streamsMutex.Lock()
+	defer engine.streamsMutex.Unlock()
+
+	key := streamKey{network: network.NetworkFlow(), transport: tcp.TransportFlow()}
+	stream := engine.streams.add(key, tcp, packet.Metadata().Timestamp)
+	if len(stream.data) == 0 {
+		return nil // Nothing new in order: a retransmission, a gap or no payload
+	}
+
+	var results []*core.AnalysisResult
+	for len(stream.data) > 0 {
+		if stream.analyzer == nil {
+			result := engine.recogniseStream(packet, stream)
+			if stream.analyzer == nil {
+				// Not at a PDU boundary of a framed protocol: analyze packet by packet
+				stream.reset()
+				if len(results) == 0 {
+					return resultList(result)
+				}
+				break
+			}
+		}
+
+		length := stream.analyzer.PDULength(stream.data)
+		if length < 0 || length > streamBufferLimit || (length == 0 && len(stream.data) > streamBufferLimit) {
+			// Lost the PDU boundaries; recognise the stream again at a later segment
+			stream.reset()
+			if len(results) == 0 {
+				return resultList(engine.AnalyzePacket(packet))
+			}
+			break
+		}
+		if length == 0 || length > len(stream.data) {
+			break // Wait for the rest of the PDU
+		}
+
+		// Results may keep slices of the PDU, so framed bytes are never overwritten
+		pdu := stream.data[:length:length]
+		stream.data = stream.data[length:]
+		result := stream.analyzer.AnalyzePDU(packet, pdu)
+
+		engine.statsMutex.Lock()
+		engine.stats.TotalAnalyses++
+		if result != nil {
+			engine.stats.SuccessfulAnalyses++
+		}
+		engine.statsMutex.Unlock()
+		if result != nil {
+			engine.updateAnalyzerStats(stream.analyzerName, true, result.Confidence)
+			results = append(results, result)
+		} else {
+			engine.updateAnalyzerStats(stream.analyzerName, false, 0.0)
+		}
+	}
+	if len(stream.data) == 0 {
+		stream.data = nil
+	}
+	return results
+}
+
+// recogniseStream picks the analyzer that frames a stream's PDUs: the one that best
+// analyzes the packet, or failing that the first framing analyzer by name that accepts
+// it, provided the stream's data starts with one of its PDUs or is too short to tell.
+// It returns the per-packet analysis for streams left unframed.
+func (engine *ModularDPIEngine) recogniseStream(packet gopacket.Packet, stream *tcpStream) *core.AnalysisResult {
+	result := engine.AnalyzePacket(packet)
+
+	engine.analyzersMutex.RLock()
+	defer engine.analyzersMutex.RUnlock()
+
+	names := make([]string, 0, len(engine.analyzers))
+	for name := range engine.analyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		analyzer, frames := engine.analyzers[name].(core.StreamAnalyzer)
+		if !frames {
+			continue
+		}
+		if result != nil {
+			if analyzer.GetProtocolName() != result.Protocol || analyzer.PDULength(stream.data) < 0 {
+				continue
+			}
+		} else if !analyzer.CanAnalyze(packet) || analyzer.PDULength(stream.data) < 0 {
+			continue
+		}
+		stream.analyzer, stream.analyzerName = analyzer, name
+		return nil
+	}
+	return result
+}
+
+// resultList returns a single analysis result as a list, empty for no result
+func resultList(result *core.AnalysisResult) []*core.AnalysisResult {
+	if result == nil {
+		return nil
+	}
+	return []*core.AnalysisResult{result}
+}
+
 // RegisterAnalyzer registers a new DPI analyzer
 func (engine *ModularDPIEngine) RegisterAnalyzer(name string, analyzer core.DPIAnalyzer) {
 	engine.analyzersMutex.Lock()
@@ -177,6 +303,9 @@ func (engine *ModularDPIEngine) initializeAnalyzers() {
 	if engine.config.EnableIndustrial {
 		engine.RegisterAnalyzer("Modbus", NewModbusAnalyzer())
 		engine.RegisterAnalyzer("EtherNetIP", NewEtherNetIPAnalyzer())
+		engine.RegisterAnalyzer("S7Comm", NewS7CommAnalyzer())
+		engine.RegisterAnalyzer("OPCUA", NewOPCUAAnalyzer())
+		engine.RegisterAnalyzer("Profinet", NewProfinetAnalyzer())
 		engine.RegisterAnalyzer("DNP3", NewDNP3Analyzer())
 		engine.RegisterAnalyzer("BACnet", NewBACnetAnalyzer())
 		engine.RegisterAnalyzer("CoAP", NewCoAPAnalyzer())
@@ -218,15 +347,33 @@ func (engine *ModularDPIEngine) generateCacheKey(packet gopacket.Packet) string
 	if appLayer != nil {
 		payload := appLayer.Payload()
 		if len(payload) > 0 {
-			// Simple hash based on first few bytes and length
-			hash := uint32(len(payload))
-			for i := 0; i < min(len(payload), 16); i++ {
-				hash = hash*31 + uint32(payload[i])
+			// Hash the whole payload: function and service codes can sit past
+			// the first bytes, and results feed per-flow DPI attributes. Analyzers
+			// also read ports and addresses, so those are part of the key and a
+			// cached result is exactly what analysis of this packet would return.
+			hash := fnv.New64a()
+			if network := packet.NetworkLayer(); network != nil {
+				flow := network.NetworkFlow()
+				hash.Write(flow.Src().Raw())
+				hash.Write(flow.Dst().Raw())
+			}
+			if transport := packet.TransportLayer(); transport != nil {
+				flow := transport.TransportFlow()
+				hash.Write(flow.Src().Raw())
+				hash.Write(flow.Dst().Raw())
 			}
-			return string(rune(hash))
+			hash.Write(payload)
+			return fmt.Sprintf("%d:%x", len(payload), hash.Sum64())
 		}
 	}
 
+	// Layer 2 protocols such as PROFINET have no application layer; key on the whole frame
+	if data := packet.Data(); len(data) > 0 {
+		hash := fnv.New64a()
+		hash.Write(data)
+		return fmt.Sprintf("frame:%d:%x", len(data), hash.Sum64())
+	}
+
 	// Fallback to layer types
 	key := ""
 	for _, layer := range packet.Layers() {
@@ -406,11 +553,3 @@ func (engine *ModularDPIEngine) GetDPIReport() map[string]interface{} {
 		},
 	}
 }
-
-// Helper function
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,161 @@
+package dpi
+
+import (
+	"sort"
+	"time"
+
+	"cipgram/pkg/pcap/core"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Reassembly limits for one direction of a TCP connection
+const (
+	streamBufferLimit = 256 * 1024      // Bytes held in order and ahead of a gap
+	streamIdleTimeout = 2 * time.Minute // A direction idle this long starts over
+)
+
+// streamKey identifies one direction of a TCP connection
+type streamKey struct {
+	network   gopacket.Flow
+	transport gopacket.Flow
+}
+
+// tcpSegment is payload received ahead of a gap in the stream
+type tcpSegment struct {
+	seq  uint32
+	data []byte
+}
+
+// tcpStream is the reassembly state of one direction of a TCP connection
+type tcpStream struct {
+	nextSeq  uint32       // Sequence number of the next in-order byte
+	data     []byte       // In-order bytes not yet framed into PDUs
+	pending  []tcpSegment // Segments after a gap, by sequence number
+	buffered int          // Bytes in pending
+	lastSeen time.Time
+
+	// Analyzer framing the stream's PDUs, nil until one recognises the stream
+	analyzer     core.StreamAnalyzer
+	analyzerName string
+}
+
+// tcpReassembler puts the payload of each TCP connection direction back in order.
+// Retransmitted bytes are dropped, segments after a gap wait for it to fill, and a gap
+// that outlasts the buffer limit is skipped.
+type tcpReassembler struct {
+	streams   map[streamKey]*tcpStream
+	lastSweep time.Time
+}
+
+func newTCPReassembler() *tcpReassembler {
+	return &tcpReassembler{streams: make(map[streamKey]*tcpStream)}
+}
+
+// add feeds a segment into its stream and returns the stream, with newly in-order
+// payload appended to its data. FIN and RST end the stream after this segment.
+func (r *tcpReassembler) add(key streamKey, tcp *layers.TCP, seen time.Time) *tcpStream {
+	r.sweep(seen)
+
+	seq := tcp.Seq
+	if tcp.SYN {
+		seq++
+	}
+	stream := r.streams[key]
+	if stream == nil || seen.Sub(stream.lastSeen) > streamIdleTimeout || (tcp.SYN && stream.nextSeq != seq) {
+		// Without a SYN the stream is picked up at the first segment seen
+		stream = &tcpStream{nextSeq: seq}
+		r.streams[key] = stream
+	}
+	stream.lastSeen = seen
+	stream.insert(seq, tcp.Payload)
+
+	if tcp.FIN || tcp.RST {
+		delete(r.streams, key)
+	}
+	return stream
+}
+
+// sweep forgets streams idle for longer than the timeout. A stream seen again after
+// that long would start over anyway, so sweeping only frees memory.
+func (r *tcpReassembler) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < streamIdleTimeout {
+		return
+	}
+	r.lastSweep = now
+	for key, stream := range r.streams {
+		if now.Sub(stream.lastSeen) > streamIdleTimeout {
+			delete(r.streams, key)
+		}
+	}
+}
+
+// insert adds payload starting at seq to the stream
+func (s *tcpStream) insert(seq uint32, payload []byte) {
+	// Drop bytes already delivered, e.g. retransmissions
+	if behind := int32(s.nextSeq - seq); behind > 0 {
+		if int(behind) >= len(payload) {
+			return
+		}
+		payload = payload[behind:]
+		seq = s.nextSeq
+	}
+	if len(payload) == 0 {
+		return
+	}
+
+	if seq == s.nextSeq {
+		s.data = append(s.data, payload...)
+		s.nextSeq += uint32(len(payload))
+		s.drainPending()
+		return
+	}
+
+	// Hold the segment until the gap before it fills
+	i := sort.Search(len(s.pending), func(i int) bool { return int32(s.pending[i].seq-seq) >= 0 })
+	if i < len(s.pending) && s.pending[i].seq == seq && len(s.pending[i].data) >= len(payload) {
+		return // Retransmission of a held segment
+	}
+	s.pending = append(s.pending, tcpSegment{})
+	copy(s.pending[i+1:], s.pending[i:])
+	s.pending[i] = tcpSegment{seq: seq, data: append([]byte(nil), payload...)}
+	s.buffered += len(payload)
+
+	if len(s.data)+s.buffered > streamBufferLimit {
+		s.skipGap()
+	}
+}
+
+// drainPending moves held segments that the in-order data has reached into it
+func (s *tcpStream) drainPending() {
+	for len(s.pending) > 0 {
+		next := s.pending[0]
+		overlap := int32(s.nextSeq - next.seq)
+		if overlap < 0 {
+			return
+		}
+		s.pending = s.pending[1:]
+		s.buffered -= len(next.data)
+		if int(overlap) < len(next.data) {
+			s.data = append(s.data, next.data[overlap:]...)
+			s.nextSeq += uint32(len(next.data) - int(overlap))
+		}
+	}
+}
+
+// skipGap gives up on missing bytes. Data before the gap cannot complete its PDU, so it
+// is dropped and the stream resumes at the first held segment, which may start mid-PDU.
+func (s *tcpStream) skipGap() {
+	s.reset()
+	s.nextSeq = s.pending[0].seq
+	s.drainPending()
+}
+
+// reset drops unframed data and forgets the analyzer, which must recognise the stream
+// again at the next PDU boundary
+func (s *tcpStream) reset() {
+	s.data = nil
+	s.analyzer = nil
+	s.analyzerName = ""
+}
@@ -179,6 +179,18 @@ func (edf *EnhancedDeviceFingerprinter) FingerprintDevice(asset *types.Asset, pa
 		indicators = append(indicators, fmt.Sprintf("Behavior: %s", behaviorInfo.Pattern))
 	}
 
+	// 6. TLS Handshakes
+	if tlsInfo := edf.analyzeTLSProfile(asset.TLS); tlsInfo != nil {
+		if tlsInfo.Manufacturer != "" && deviceInfo.Manufacturer == "Unknown" {
+			deviceInfo.Manufacturer = tlsInfo.Manufacturer
+		}
+		if tlsInfo.DeviceType != "" && deviceInfo.DeviceType == "Unknown" {
+			deviceInfo.DeviceType = tlsInfo.DeviceType
+		}
+		confidenceScores = append(confidenceScores, tlsInfo.Confidence)
+		indicators = append(indicators, tlsInfo.Indicators...)
+	}
+
 	// Calculate overall confidence
 	if len(confidenceScores) > 0 {
 		var total float32
@@ -684,6 +696,44 @@ type BehaviorInfo struct {
 	Confidence float32
 }
 
+// TLSInfo contains TLS handshake-based device information
+type TLSInfo struct {
+	Manufacturer string
+	DeviceType   string
+	Indicators   []string
+	Confidence   float32
+}
+
+// analyzeTLSProfile uses the asset's TLS fingerprints and certificates. Embedded devices
+// commonly serve a certificate naming their manufacturer in the subject organisation.
+func (edf *EnhancedDeviceFingerprinter) analyzeTLSProfile(profile *types.TLSProfile) *TLSInfo {
+	if profile == nil {
+		return nil
+	}
+
+	tlsInfo := &TLSInfo{Confidence: 0.5}
+	for _, ja3 := range profile.JA3 {
+		tlsInfo.Indicators = append(tlsInfo.Indicators, fmt.Sprintf("TLS Client JA3: %s", ja3))
+	}
+	for _, ja3s := range profile.JA3S {
+		tlsInfo.Indicators = append(tlsInfo.Indicators, fmt.Sprintf("TLS Server JA3S: %s", ja3s))
+	}
+	for _, cert := range profile.Certificates {
+		tlsInfo.Indicators = append(tlsInfo.Indicators, fmt.Sprintf("TLS Certificate: %s", cert.Subject))
+	}
+
+	for _, organization := range profile.Organizations() {
+		if deviceType := edf.inferDeviceTypeFromVendor(organization); deviceType != "" {
+			tlsInfo.Manufacturer = organization
+			tlsInfo.DeviceType = deviceType
+			tlsInfo.Confidence = 0.8
+			break
+		}
+	}
+
+	return tlsInfo
+}
+
 // analyzeBehaviorPattern analyzes communication behavior patterns
 func (edf *EnhancedDeviceFingerprinter) analyzeBehaviorPattern(packets []gopacket.Packet) *BehaviorInfo {
 	if len(packets) < 10 { // Need sufficient packets for analysis
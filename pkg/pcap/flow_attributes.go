@@ -0,0 +1,283 @@
+package pcap
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cipgram/pkg/pcap/core"
+	"cipgram/pkg/types"
+)
+
+// recordFlowAttributes folds the DPI result of one packet into the flow's attributes
+func recordFlowAttributes(model *types.NetworkModel, flow *types.Flow, result *core.AnalysisResult, seen time.Time) {
+	if result == nil || len(result.Details) == 0 {
+		return
+	}
+	details := result.Details
+
+	attrs := flow.DPI
+	if attrs == nil {
+		attrs = types.NewFlowDPI()
+	}
+	recorded := false
+
+	// Modbus, DNP3 and S7comm function codes
+	if code, ok := detailUint(details, "function_code"); ok {
+		attrs.RecordOperation(types.OperationFunction, uint16(code), detailString(details, "function_name"), seen)
+		recorded = true
+	}
+
+	// Modbus unit identifier
+	if unitID, ok := detailUint(details, "unit_id"); ok {
+		attrs.AddUnitID(uint8(unitID))
+		recorded = true
+	}
+
+	// CIP service and the object it addressed
+	if code, ok := detailUint(details, "cip_service_code"); ok {
+		attrs.RecordOperation(types.OperationCIPService, uint16(code), detailString(details, "cip_service"), seen)
+		recorded = true
+	}
+	if path := cipObjectPath(details); path != "" {
+		attrs.AddCIPObject(path)
+		recorded = true
+	}
+
+	// OPC UA service request and response types
+	if code, ok := detailUint(details, "opcua_service_id"); ok {
+		attrs.RecordOperation(types.OperationOPCUA, uint16(code), detailString(details, "opcua_service"), seen)
+		recorded = true
+	}
+
+	// BACnet service requests; confirmed and unconfirmed service choices overlap
+	if code, ok := detailUint(details, "bacnet_service_choice"); ok {
+		switch detailString(details, "apdu_type") {
+		case "Confirmed-Request":
+			attrs.RecordOperation(types.OperationBACnetConfirmed, uint16(code), detailString(details, "bacnet_service"), seen)
+			recorded = true
+		case "Unconfirmed-Request":
+			attrs.RecordOperation(types.OperationBACnetUnconfirmed, uint16(code), detailString(details, "bacnet_service"), seen)
+			recorded = true
+		}
+	}
+
+	// HTTP client software
+	if agent := detailString(details, "user_agent"); agent != "" {
+		attrs.AddUserAgent(agent)
+		recorded = true
+	}
+
+	// Controller stop, program download and device restarts
+	if operation := detailString(details, "high_risk_operation"); operation != "" {
+		attrs.AddHighRiskOperation(operation)
+		recorded = true
+	}
+
+	if recorded {
+		flow.DPI = attrs
+	}
+
+	switch result.Protocol {
+	case "Modbus TCP":
+		recordModbusProfile(model, flow, details)
+	case "OPC-UA":
+		recordOPCUAChannel(flow, details)
+	case "DNP3":
+		recordDNP3Session(model, flow, details, seen)
+	case "TLS":
+		recordTLSSession(model, flow, details)
+	}
+}
+
+// recordDNP3Session updates the session of the master/outstation address pair. Like Modbus
+// profiles, sessions live on the master -> outstation flow when it has been seen.
+func recordDNP3Session(model *types.NetworkModel, flow *types.Flow, details map[string]interface{}, seen time.Time) {
+	master, ok := detailUint(details, "master_address")
+	if !ok {
+		return
+	}
+	outstation, _ := detailUint(details, "outstation_address")
+	fromMaster, _ := details["from_master"].(bool)
+
+	target := flow
+	masterHost, outstationHost := flow.Source, flow.Destination
+	if !fromMaster {
+		masterHost, outstationHost = flow.Destination, flow.Source
+		reverse := types.FlowKey{SrcIP: masterHost, DstIP: outstationHost, Proto: flow.Protocol}
+		if requestFlow := model.Flows[reverse]; requestFlow != nil {
+			target = requestFlow
+		}
+	}
+
+	if target.DPI == nil {
+		target.DPI = types.NewFlowDPI()
+	}
+	session := target.DPI.DNP3Session(uint16(master), uint16(outstation))
+	session.MasterHost, session.OutstationHost = masterHost, outstationHost
+
+	if secure, _ := details["secure_authentication"].(bool); secure {
+		session.SecureAuthentication = true
+	}
+	// Pending class events are routine; the other indications describe the outstation
+	flags, _ := details["iin_flags"].([]string)
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "CLASS_") {
+			session.AddIINFlag(flag)
+		}
+	}
+
+	code, ok := detailUint(details, "function_code")
+	if !ok {
+		return
+	}
+	switch code {
+	case 0x04, 0x05, 0x06: // Operate, Direct Operate, Direct Operate No Response
+		points, _ := details["control_points"].([]string)
+		session.RecordControl(points, seen)
+	case 0x0D, 0x0E: // Cold and Warm Restart
+		session.RecordRestart(seen)
+	case 0x14: // Enable Unsolicited
+		session.UnsolicitedDisabled = false
+	case 0x15: // Disable Unsolicited
+		session.UnsolicitedDisabled = true
+	case 0x82:
+		session.UnsolicitedResponses++
+	}
+}
+
+// recordOPCUAChannel notes the security policy, mode and endpoint URLs of an OPC UA flow
+func recordOPCUAChannel(flow *types.Flow, details map[string]interface{}) {
+	policy := detailString(details, "security_policy")
+	endpoint := detailString(details, "endpoint_url")
+	endpoints, _ := details["endpoint_urls"].([]string)
+	if policy == "" && endpoint == "" && len(endpoints) == 0 {
+		return
+	}
+
+	if flow.DPI == nil {
+		flow.DPI = types.NewFlowDPI()
+	}
+	channel := flow.DPI.OPCUA
+	if channel == nil {
+		channel = &types.OPCUAChannel{}
+		flow.DPI.OPCUA = channel
+	}
+
+	if policy != "" {
+		channel.RecordSecurity(policy, detailString(details, "security_mode"))
+	}
+	channel.AddEndpointURL(endpoint)
+	for _, url := range endpoints {
+		channel.AddEndpointURL(url)
+	}
+}
+
+// recordModbusProfile updates the profile of the master/slave pair the packet belongs to.
+// Requests are recorded on the master -> slave flow; exception responses travel the other
+// way and are attributed to that same flow when it has been seen.
+func recordModbusProfile(model *types.NetworkModel, flow *types.Flow, details map[string]interface{}) {
+	isResponse := detailString(details, "direction") == "response"
+
+	target := flow
+	master, slave := flow.Source, flow.Destination
+	if isResponse {
+		master, slave = flow.Destination, flow.Source
+		reverse := types.FlowKey{SrcIP: master, DstIP: slave, Proto: flow.Protocol}
+		if requestFlow := model.Flows[reverse]; requestFlow != nil {
+			target = requestFlow
+		}
+	}
+
+	if target.DPI == nil {
+		target.DPI = types.NewFlowDPI()
+	}
+	profile := target.DPI.Modbus
+	if profile == nil {
+		profile = types.NewModbusProfile(master, slave)
+		target.DPI.Modbus = profile
+	}
+
+	if exception, _ := details["exception"].(bool); exception {
+		name := detailString(details, "exception_name")
+		if name == "" {
+			name = "Unknown Exception"
+		}
+		profile.RecordException(name)
+		return
+	}
+	if isResponse {
+		return
+	}
+
+	table := detailString(details, "table")
+	start, _ := detailUint(details, "start_address")
+	quantity, _ := detailUint(details, "quantity")
+
+	switch detailString(details, "access") {
+	case "read":
+		profile.RecordRead(table, uint16(start), uint16(quantity))
+	case "write":
+		// Single coil/register writes address one location
+		if address, ok := detailUint(details, "address"); ok {
+			start, quantity = address, 1
+		}
+		profile.RecordWrite(table, uint16(start), uint16(quantity))
+	case "read_write":
+		profile.RecordRead(table, uint16(start), uint16(quantity))
+		writeStart, _ := detailUint(details, "write_start_address")
+		writeQuantity, _ := detailUint(details, "write_quantity")
+		profile.RecordWrite(table, uint16(writeStart), uint16(writeQuantity))
+	case "diagnostic":
+		profile.DiagnosticCount++
+	case "device_identification":
+		profile.DeviceIDCount++
+	}
+}
+
+// cipObjectPath formats class/instance/attribute as "Identity/1/7", using the class ID for unknown classes
+func cipObjectPath(details map[string]interface{}) string {
+	classID, ok := detailUint(details, "cip_class_id")
+	if !ok {
+		return ""
+	}
+
+	path := detailString(details, "cip_class")
+	if path == "" || strings.HasPrefix(path, "Unknown") {
+		path = fmt.Sprintf("0x%02X", classID)
+	}
+	if instance, ok := detailUint(details, "cip_instance"); ok {
+		path += fmt.Sprintf("/%d", instance)
+		if attribute, ok := detailUint(details, "cip_attribute"); ok {
+			path += fmt.Sprintf("/%d", attribute)
+		}
+	}
+	return path
+}
+
+// detailUint reads an unsigned numeric DPI detail regardless of its integer width
+func detailUint(details map[string]interface{}, key string) (uint64, bool) {
+	switch v := details[key].(type) {
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case int:
+		if v >= 0 {
+			return uint64(v), true
+		}
+	}
+	return 0, false
+}
+
+// detailString reads a string DPI detail
+func detailString(details map[string]interface{}, key string) string {
+	if v, ok := details[key].(string); ok {
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package pcap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CaptureInput is a capture file to merge and the capture point it was recorded at
+type CaptureInput struct {
+	Path   string
+	Source string // The directory or pattern the file was found by, else the file itself
+}
+
+// ExpandCaptureInputs resolves capture files, directories and glob patterns into the
+// capture files to merge. Directories and patterns contribute every pcap or pcapng
+// file they match whatever its extension, so rotated tcpdump -W/-C sets are found.
+// Each input is one capture point: the files of a directory or pattern share its
+// source, so packets repeated across a rotation are not taken for SPAN duplicates.
+func ExpandCaptureInputs(inputs []string) ([]CaptureInput, error) {
+	var files []CaptureInput
+	seen := make(map[string]bool)
+	add := func(path, source string) {
+		clean := filepath.Clean(path)
+		if !seen[clean] {
+			seen[clean] = true
+			files = append(files, CaptureInput{Path: clean, Source: filepath.Clean(source)})
+		}
+	}
+
+	for _, input := range inputs {
+		if strings.ContainsAny(input, "*?[") {
+			matches, err := filepath.Glob(input)
+			if err != nil {
+				return nil, fmt.Errorf("invalid capture pattern %s: %v", input, err)
+			}
+			found := 0
+			for _, match := range matches {
+				if isCaptureFile(match) {
+					add(match, input)
+					found++
+				}
+			}
+			if found == 0 {
+				return nil, fmt.Errorf("no capture files match %s", input)
+			}
+			continue
+		}
+
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot access capture input %s: %v", input, err)
+		}
+		if !info.IsDir() {
+			add(input, input)
+			continue
+		}
+
+		entries, err := os.ReadDir(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read capture directory %s: %v", input, err)
+		}
+		var captures []string
+		for _, entry := range entries {
+			path := filepath.Join(input, entry.Name())
+			if entry.Type().IsRegular() && isCaptureFile(path) {
+				captures = append(captures, path)
+			}
+		}
+		if len(captures) == 0 {
+			return nil, fmt.Errorf("no capture files found in %s", input)
+		}
+		sort.Strings(captures)
+		for _, path := range captures {
+			add(path, input)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no capture files given")
+	}
+	return files, nil
+}
+
+// isCaptureFile reports whether a file starts with a pcap or pcapng header
+func isCaptureFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	_, ok := fileLinkType(path)
+	return ok
+}
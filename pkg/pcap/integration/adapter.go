@@ -6,6 +6,7 @@ import (
 	"cipgram/pkg/pcap/dpi"
 
 	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 )
 
 // ModularDetectionAdapter adapts the new modular detection system to work with existing code
@@ -63,6 +64,21 @@ func (adapter *ModularDetectionAdapter) DetectProtocolWithDetails(packet gopacke
 	}
 }
 
+// AnalyzePayload returns the DPI results for a packet in the context of its TCP stream:
+// one per PDU the packet completes, see ModularDPIEngine.AnalyzeStream. Every TCP
+// segment is passed on, since SYN, FIN and RST mark where streams begin and end. Stream
+// state lives in the adapter, so both directions of a connection must be analyzed by
+// the same adapter, in capture order.
+func (adapter *ModularDetectionAdapter) AnalyzePayload(packet gopacket.Packet) []*core.AnalysisResult {
+	if !adapter.config.Detection.EnableDPI {
+		return nil
+	}
+	if packet.ApplicationLayer() == nil && packet.NetworkLayer() != nil && packet.Layer(layers.LayerTypeTCP) == nil {
+		return nil
+	}
+	return adapter.dpiEngine.AnalyzeStream(packet)
+}
+
 // DetectionDetails contains detailed detection information
 type DetectionDetails struct {
 	Protocol   string
@@ -78,19 +94,33 @@ func (adapter *ModularDetectionAdapter) GetSupportedProtocols() []string {
 
 // GetDetectionStats returns detection statistics
 func (adapter *ModularDetectionAdapter) GetDetectionStats() map[string]interface{} {
-	stats := adapter.detector.GetDetectionStats()
+	return CombinedDetectionStats(adapter)
+}
 
+// CombinedDetectionStats returns the detection statistics of several adapters added up,
+// such as those of the shards of a parallel parse
+func CombinedDetectionStats(adapters ...*ModularDetectionAdapter) map[string]interface{} {
+	var total, successful int64
 	methodStats := make(map[string]int64)
-	for method, count := range stats.MethodBreakdown {
-		methodStats[methodToString(method)] = count
+	protocolCounts := make(map[string]int64)
+	for _, adapter := range adapters {
+		stats := adapter.detector.GetDetectionStats()
+		total += stats.TotalPackets
+		successful += stats.SuccessfulDetections
+		for method, count := range stats.MethodBreakdown {
+			methodStats[methodToString(method)] += count
+		}
+		for protocol, count := range stats.ProtocolCounts {
+			protocolCounts[protocol] += count
+		}
 	}
 
 	return map[string]interface{}{
-		"total_packets":         stats.TotalPackets,
-		"successful_detections": stats.SuccessfulDetections,
-		"success_rate":          float32(stats.SuccessfulDetections) / float32(stats.TotalPackets),
+		"total_packets":         total,
+		"successful_detections": successful,
+		"success_rate":          float32(successful) / float32(total),
 		"method_breakdown":      methodStats,
-		"protocol_counts":       stats.ProtocolCounts,
+		"protocol_counts":       protocolCounts,
 	}
 }
 
@@ -0,0 +1,169 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"cipgram/pkg/types"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// linkTypeLinuxSLL2 is LINKTYPE_LINUX_SLL2, written by "tcpdump -i any" since libpcap 1.10.
+// gopacket's LinkType is a uint8 and cannot represent it.
+const linkTypeLinuxSLL2 = 276
+
+// layerTypeLinuxSLL2 is the Linux cooked capture v2 header
+var layerTypeLinuxSLL2 = gopacket.RegisterLayerType(2276, gopacket.LayerTypeMetadata{
+	Name:    "LinuxSLL2",
+	Decoder: gopacket.DecodeFunc(decodeLinuxSLL2),
+})
+
+// linuxSLL2 is the 20-byte Linux cooked capture v2 header
+type linuxSLL2 struct {
+	layers.BaseLayer
+	ProtocolType   layers.EthernetType
+	InterfaceIndex uint32
+	ARPHRDType     uint16
+	PacketType     layers.LinuxSLLPacketType
+	Addr           net.HardwareAddr // Link-layer address of the sender
+}
+
+func (sll *linuxSLL2) LayerType() gopacket.LayerType { return layerTypeLinuxSLL2 }
+
+func (sll *linuxSLL2) LinkFlow() gopacket.Flow {
+	return gopacket.NewFlow(layers.EndpointMAC, sll.Addr, nil)
+}
+
+func (sll *linuxSLL2) NextLayerType() gopacket.LayerType {
+	return sll.ProtocolType.LayerType()
+}
+
+func decodeLinuxSLL2(data []byte, p gopacket.PacketBuilder) error {
+	if len(data) < 20 {
+		return fmt.Errorf("Linux SLL2 header too short: %d bytes", len(data))
+	}
+	addrLen := int(data[11])
+	if addrLen > 8 {
+		addrLen = 8
+	}
+
+	sll := &linuxSLL2{
+		ProtocolType:   layers.EthernetType(binary.BigEndian.Uint16(data[0:2])),
+		InterfaceIndex: binary.BigEndian.Uint32(data[4:8]),
+		ARPHRDType:     binary.BigEndian.Uint16(data[8:10]),
+		PacketType:     layers.LinuxSLLPacketType(data[10]),
+		Addr:           net.HardwareAddr(data[12 : 12+addrLen]),
+	}
+	sll.BaseLayer = layers.BaseLayer{Contents: data[:20], Payload: data[20:]}
+	p.AddLayer(sll)
+	p.SetLinkLayer(sll)
+	return p.NextDecoder(sll.ProtocolType)
+}
+
+// packetDecoder picks the decoder for a capture's link type. SLL2 is recognised from the
+// file header because the handle reports the link type truncated to a byte.
+func packetDecoder(path string, handleLinkType layers.LinkType) gopacket.Decoder {
+	if linkType, ok := fileLinkType(path); ok && linkType == linkTypeLinuxSLL2 {
+		return layerTypeLinuxSLL2
+	}
+	return handleLinkType
+}
+
+// fileLinkType reads the link type from a pcap file header or from the first interface
+// description block of a pcapng file
+func fileLinkType(path string) (uint32, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, false
+	}
+
+	switch binary.BigEndian.Uint32(header[0:4]) {
+	case 0xA1B2C3D4, 0xA1B23C4D: // Microsecond and nanosecond pcap, big endian
+		return binary.BigEndian.Uint32(header[20:24]) & 0xFFFF, true
+	case 0xD4C3B2A1, 0x4D3CB2A1:
+		return binary.LittleEndian.Uint32(header[20:24]) & 0xFFFF, true
+	case 0x0A0D0D0A: // pcapng section header block
+		var order binary.ByteOrder = binary.LittleEndian
+		if binary.BigEndian.Uint32(header[8:12]) == 0x1A2B3C4D {
+			order = binary.BigEndian
+		}
+		sectionLength := int64(order.Uint32(header[4:8]))
+		block := make([]byte, 10)
+		if _, err := file.ReadAt(block, sectionLength); err != nil {
+			return 0, false
+		}
+		if order.Uint32(block[0:4]) != 1 { // Interface description block
+			return 0, false
+		}
+		return uint32(order.Uint16(block[8:10])), true
+	}
+	return 0, false
+}
+
+// linkAddresses returns the source and destination MAC addresses of a packet, or "" where
+// the capture does not carry them. Cooked captures only record the sender's address and
+// raw IP captures carry none.
+func linkAddresses(packet gopacket.Packet) (string, string) {
+	switch link := packet.LinkLayer().(type) {
+	case *layers.Ethernet:
+		return link.SrcMAC.String(), link.DstMAC.String()
+	case *layers.LinuxSLL:
+		if link.AddrType == 1 && len(link.Addr) == 6 { // ARPHRD_ETHER
+			return link.Addr.String(), ""
+		}
+	case *linuxSLL2:
+		if link.ARPHRDType == 1 && len(link.Addr) == 6 {
+			return link.Addr.String(), ""
+		}
+	}
+	return "", ""
+}
+
+// vlanTags returns the 802.1Q VLAN IDs of a frame, outermost first. Priority tags
+// (VLAN 0) carry no VLAN and are skipped.
+func vlanTags(packet gopacket.Packet) []uint16 {
+	var tags []uint16
+	for _, layer := range packet.Layers() {
+		if dot1q, ok := layer.(*layers.Dot1Q); ok && dot1q.VLANIdentifier != 0 {
+			tags = append(tags, dot1q.VLANIdentifier)
+		}
+	}
+	return tags
+}
+
+// recordVLANs notes a frame's VLAN tags on its flow and on the sender. The innermost tag
+// is the customer VLAN; with QinQ the outermost is the service VLAN. Routed frames carry
+// the router's MAC, so the sender only takes the VLAN when the frame came from its MAC.
+func recordVLANs(src *types.Asset, srcMAC string, flow *types.Flow, tags []uint16) {
+	if len(tags) == 0 {
+		return
+	}
+	vlan := tags[len(tags)-1]
+	flow.VLANs = appendUniqueVLAN(flow.VLANs, vlan)
+	if len(tags) > 1 {
+		flow.ServiceVLANs = appendUniqueVLAN(flow.ServiceVLANs, tags[0])
+	}
+	if srcMAC != "" && srcMAC == src.MAC {
+		src.VLANs = appendUniqueVLAN(src.VLANs, vlan)
+	}
+}
+
+func appendUniqueVLAN(vlans []uint16, vlan uint16) []uint16 {
+	for _, existing := range vlans {
+		if existing == vlan {
+			return vlans
+		}
+	}
+	return append(vlans, vlan)
+}
@@ -0,0 +1,246 @@
+package pcap
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"time"
+
+	"cipgram/pkg/logging"
+	"cipgram/pkg/types"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// spanDuplicateWindow is how far apart two SPAN points may report the same packet
+const spanDuplicateWindow = 100 * time.Millisecond
+
+// packetSource yields the packets of one capture in file order
+type packetSource interface {
+	NextPacket() (gopacket.Packet, error)
+}
+
+// captureReader reads one capture file of a merged analysis
+type captureReader struct {
+	index      int
+	source     packetSource
+	close      func()
+	file       *types.CaptureFile
+	interfaces bool            // pcapng, whose packets may come from several interfaces
+	next       gopacket.Packet // Next packet to merge, nil once the file is exhausted
+}
+
+// openCaptureReader opens a capture file, decoding it by the link type in its header.
+// Lazy readers only decode the layers the merger asks for and leave the rest to workers.
+func openCaptureReader(index int, path string, file *types.CaptureFile, lazy bool) (*captureReader, error) {
+	data, linkType, closeFile, err := openCapture(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PCAP file %s: %w", path, err)
+	}
+	source := gopacket.NewPacketSource(data, packetDecoder(path, linkType))
+	source.Lazy = lazy
+	_, interfaces := data.(*pcapgo.NgReader)
+	return &captureReader{
+		index:      index,
+		source:     source,
+		close:      closeFile,
+		file:       file,
+		interfaces: interfaces,
+	}, nil
+}
+
+// openCapture opens a capture file for reading. pcapng files are read with pcapgo, which
+// keeps the interface each packet was captured on; libpcap reports interface 0 for all.
+func openCapture(path string) (gopacket.PacketDataSource, layers.LinkType, func(), error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err == nil && binary.BigEndian.Uint32(magic) == 0x0A0D0D0A {
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			if reader, err := pcapgo.NewNgReader(file, pcapgo.DefaultNgReaderOptions); err == nil {
+				return reader, reader.LinkType(), func() { file.Close() }, nil
+			}
+		}
+	}
+	file.Close()
+
+	handle, err := pcap.OpenOffline(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return handle, handle.LinkType(), handle.Close, nil
+}
+
+// advance reads the reader's next packet. Read errors end the file like EOF does, so a
+// truncated capture still contributes the packets before the damage.
+func (r *captureReader) advance() bool {
+	packet, err := r.source.NextPacket()
+	if err != nil {
+		if err != io.EOF {
+			logging.NewLogger("pcap-parser", logging.INFO, false).Warn("Stopped reading capture file", map[string]interface{}{
+				"file_path": r.file.Path,
+				"packets":   r.file.Packets,
+				"error":     err.Error(),
+			})
+		}
+		r.next = nil
+		return false
+	}
+
+	timestamp := packet.Metadata().Timestamp
+	if r.file.Packets == 0 || timestamp.Before(r.file.FirstPacket) {
+		r.file.FirstPacket = timestamp
+	}
+	if timestamp.After(r.file.LastPacket) {
+		r.file.LastPacket = timestamp
+	}
+	r.file.Packets++
+	r.next = packet
+	return true
+}
+
+// readerHeap orders readers by the timestamp of their next packet, then by input order
+type readerHeap []*captureReader
+
+func (h readerHeap) Len() int { return len(h) }
+func (h readerHeap) Less(i, j int) bool {
+	ti, tj := h[i].next.Metadata().Timestamp, h[j].next.Metadata().Timestamp
+	if ti.Equal(tj) {
+		return h[i].index < h[j].index
+	}
+	return ti.Before(tj)
+}
+func (h readerHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *readerHeap) Push(x interface{}) { *h = append(*h, x.(*captureReader)) }
+func (h *readerHeap) Pop() interface{} {
+	old := *h
+	reader := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return reader
+}
+
+// captureMerger interleaves the packets of several captures in timestamp order and drops
+// copies of a packet that another capture point already reported
+type captureMerger struct {
+	readers readerHeap
+	dedup   *spanDeduplicator // nil when all packets come from one capture point
+}
+
+func newCaptureMerger(readers []*captureReader) *captureMerger {
+	m := &captureMerger{}
+	sources := make(map[string]bool)
+	interfaces := false
+	for _, reader := range readers {
+		sources[reader.file.Source] = true
+		interfaces = interfaces || reader.interfaces
+		if reader.advance() {
+			m.readers = append(m.readers, reader)
+		}
+	}
+	heap.Init(&m.readers)
+	if len(sources) > 1 || interfaces {
+		m.dedup = newSPANDeduplicator()
+	}
+	return m
+}
+
+// Next returns the earliest unread packet of all captures, or nil when all are exhausted
+func (m *captureMerger) Next() gopacket.Packet {
+	for len(m.readers) > 0 {
+		reader := m.readers[0]
+		packet := reader.next
+		if reader.advance() {
+			heap.Fix(&m.readers, 0)
+		} else {
+			heap.Pop(&m.readers)
+		}
+
+		if m.dedup != nil && m.dedup.duplicate(packet, captureSource{reader.file.Source, packet.Metadata().InterfaceIndex}) {
+			reader.file.Duplicates++
+			continue
+		}
+		return packet
+	}
+	return nil
+}
+
+// captureSource is the capture point a packet was recorded at: the input its file came
+// from and, for pcapng, the interface
+type captureSource struct {
+	input string
+	iface int
+}
+
+type seenPacket struct {
+	key       uint64
+	source    captureSource
+	timestamp time.Time
+}
+
+// spanDeduplicator recognises a packet reported by two capture points. Only copies from
+// different capture points count; repeats at one point, even across the files of a
+// rotated set, are retransmissions, not duplicates.
+type spanDeduplicator struct {
+	seen  map[uint64]seenPacket
+	order []seenPacket // Packets in timestamp order, for expiry
+}
+
+func newSPANDeduplicator() *spanDeduplicator {
+	return &spanDeduplicator{seen: make(map[uint64]seenPacket)}
+}
+
+func (d *spanDeduplicator) duplicate(packet gopacket.Packet, source captureSource) bool {
+	timestamp := packet.Metadata().Timestamp
+	cutoff := timestamp.Add(-spanDuplicateWindow)
+	for len(d.order) > 0 && d.order[0].timestamp.Before(cutoff) {
+		expired := d.order[0]
+		if d.seen[expired.key].timestamp.Equal(expired.timestamp) {
+			delete(d.seen, expired.key)
+		}
+		d.order = d.order[1:]
+	}
+
+	key := packetFingerprint(packet)
+	if previous, ok := d.seen[key]; ok && previous.source != source {
+		return true
+	}
+	entry := seenPacket{key: key, source: source, timestamp: timestamp}
+	d.seen[key] = entry
+	d.order = append(d.order, entry)
+	return false
+}
+
+// packetFingerprint hashes a packet from its network layer on. Link headers, VLAN tags
+// and the hop count differ between capture points, so they are left out.
+func packetFingerprint(packet gopacket.Packet) uint64 {
+	hash := fnv.New64a()
+	network := packet.NetworkLayer()
+	if network == nil {
+		hash.Write(packet.Data())
+		return hash.Sum64()
+	}
+
+	header := append([]byte(nil), network.LayerContents()...)
+	switch network.(type) {
+	case *layers.IPv4:
+		if len(header) >= 12 {
+			header[8] = 0                                // TTL
+			binary.BigEndian.PutUint16(header[10:12], 0) // Checksum
+		}
+	case *layers.IPv6:
+		if len(header) >= 8 {
+			header[7] = 0 // Hop limit
+		}
+	}
+	hash.Write(header)
+	hash.Write(network.LayerPayload())
+	return hash.Sum64()
+}
@@ -0,0 +1,149 @@
+package pcap
+
+import (
+	"context"
+	"strconv"
+
+	"cipgram/pkg/logging"
+	"cipgram/pkg/pcap/core"
+	"cipgram/pkg/pcap/integration"
+	"cipgram/pkg/pcap/workers"
+	"cipgram/pkg/types"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// shardBufferSize is how many packets a shard may decode ahead of the model update
+const shardBufferSize = 1024
+
+// inspectedPacket is a decoded packet with the protocol and DPI results its shard found.
+// It is the packetInspector the model update uses for the packet.
+type inspectedPacket struct {
+	packet   gopacket.Packet
+	protocol string
+	results  []*core.AnalysisResult
+}
+
+// DetectProtocol returns the protocol the shard detected
+func (i inspectedPacket) DetectProtocol(gopacket.Packet) string {
+	return i.protocol
+}
+
+// AnalyzePayload returns the shard's DPI results
+func (i inspectedPacket) AnalyzePayload(gopacket.Packet) []*core.AnalysisResult {
+	return i.results
+}
+
+// shardInspector decodes, detects and inspects the streams hashed to one shard. Every
+// shard has its own detection adapter, so detection and DPI caches and TCP reassembly
+// are never shared between workers, and each stream is reassembled by the one shard
+// that sees all its packets.
+type shardInspector struct {
+	adapter *integration.ModularDetectionAdapter
+	out     chan inspectedPacket
+}
+
+// ProcessPacket implements workers.PacketProcessor
+func (s *shardInspector) ProcessPacket(ctx context.Context, job *workers.PacketJob) (*workers.JobResult, error) {
+	packet := job.Packet
+	packet.Layers() // Finish the lazy decode on this worker
+
+	inspected := inspectedPacket{packet: packet, results: s.adapter.AnalyzePayload(packet)}
+	if detectsProtocol(packet) {
+		inspected.protocol = s.adapter.DetectProtocol(packet)
+	}
+	select {
+	case s.out <- inspected:
+		return &workers.JobResult{JobID: job.ID, Success: true}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// processPacketsParallel decodes, detects and inspects packets on one worker queue per
+// shard and returns the number of packets read. Packets are sharded by a hash of their
+// 5-tuple, so each stream is inspected in order by a single worker. The model is only
+// updated here, in capture order, which needs no lock and gives the same model as
+// sequential parsing.
+func (p *PCAPParser) processPacketsParallel(merger *captureMerger, model *types.NetworkModel, shards int, logger *logging.Logger) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inspectors := make([]*shardInspector, shards)
+	queues := make([]*workers.InMemoryWorkerQueue, shards)
+	p.shardAdapters = make([]*integration.ModularDetectionAdapter, shards)
+	for i := range queues {
+		p.shardAdapters[i] = integration.NewModularDetectionAdapter(p.config.ConfigPath)
+		inspectors[i] = &shardInspector{
+			adapter: p.shardAdapters[i],
+			out:     make(chan inspectedPacket, shardBufferSize),
+		}
+		queues[i] = workers.NewInMemoryWorkerQueue(1, shardBufferSize, inspectors[i])
+		queues[i].SetQuiet(true)
+		queues[i].Start(ctx)
+	}
+	logger.Info("Started worker queues", map[string]interface{}{
+		"shards": shards,
+	})
+	defer func() {
+		for _, queue := range queues {
+			queue.Drain()
+		}
+	}()
+
+	// The reader hands each packet to its shard and records the shard in capture order
+	order := make(chan int, shards*shardBufferSize)
+	go func() {
+		defer close(order)
+		seq := 0
+		for packet := merger.Next(); packet != nil; packet = merger.Next() {
+			shard := int(streamHash(packet) % uint64(shards))
+			job := &workers.PacketJob{ID: strconv.Itoa(seq), Packet: packet}
+			if err := queues[shard].EnqueueWait(ctx, job); err != nil {
+				return
+			}
+			select {
+			case order <- shard:
+			case <-ctx.Done():
+				return
+			}
+			seq++
+		}
+	}()
+
+	packetCount := 0
+	for shard := range order {
+		inspected := <-inspectors[shard].out
+		packetCount++
+		p.applyPacket(inspected.packet, model, packetCount, inspected, logger)
+	}
+	return packetCount
+}
+
+// detectsProtocol reports whether processPacket detects the packet's protocol, which it
+// does for IP packets. Shards detect nothing else, so that detection statistics match
+// sequential parsing.
+func detectsProtocol(packet gopacket.Packet) bool {
+	if packet.Layer(layers.LayerTypeARP) != nil {
+		return false
+	}
+	return packet.Layer(layers.LayerTypeIPv4) != nil || packet.Layer(layers.LayerTypeIPv6) != nil
+}
+
+// streamHash hashes a packet's 5-tuple so that both directions of a stream hash alike.
+// Frames without a network layer hash by their link addresses.
+func streamHash(packet gopacket.Packet) uint64 {
+	if network := packet.NetworkLayer(); network != nil {
+		hash := network.NetworkFlow().FastHash()
+		if transport := packet.TransportLayer(); transport != nil {
+			hash = hash*31 + uint64(transport.LayerType())
+			hash = hash*31 + transport.TransportFlow().FastHash()
+		}
+		return hash
+	}
+	if link := packet.LinkLayer(); link != nil {
+		return link.LinkFlow().FastHash()
+	}
+	return 0
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"cipgram/pkg/logging"
+	"cipgram/pkg/pcap/core"
 	"cipgram/pkg/pcap/fingerprinting"
 	"cipgram/pkg/pcap/integration"
 	"cipgram/pkg/pcap/optimization"
@@ -21,18 +22,21 @@ import (
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 )
 
 // PCAPParser implements InputSource for PCAP file analysis
 type PCAPParser struct {
-	pcapPath         string
+	pcapPaths        []string // Capture files merged in timestamp order
+	sources          []string // Capture point of each file, see CaptureInput
 	config           *PCAPConfig
 	detectionAdapter *integration.ModularDetectionAdapter
+	shardAdapters    []*integration.ModularDetectionAdapter // Detection and DPI of parallel parsing
 	fingerprinter    *fingerprinting.EnhancedDeviceFingerprinter
 	packetCache      map[string][]gopacket.Packet // Cache packets per asset for fingerprinting
 	optimizer        *performance.PerformanceOptimizer
 	stringOptimizer  *optimization.StringOptimizer
+	cip              *cipTracker     // EtherNet/IP session and connection state
+	sessions         *sessionTracker // TCP and UDP sessions by 5-tuple
 }
 
 // PCAPConfig holds configuration for PCAP parsing
@@ -44,10 +48,33 @@ type PCAPConfig struct {
 	HideUnknown        bool
 	MaxNodes           int
 	ConfigPath         string // Optional Purdue config
+	Workers            int    // Decode and DPI workers; 0 or 1 parses sequentially
 }
 
 // NewPCAPParser creates a new PCAP parser with enhanced capabilities
 func NewPCAPParser(pcapPath string, config *PCAPConfig) *PCAPParser {
+	return NewMultiPCAPParser([]string{pcapPath}, config)
+}
+
+// NewMultiPCAPParser creates a parser that merges several capture files, each from its
+// own capture point, into one time-ordered analysis
+func NewMultiPCAPParser(pcapPaths []string, config *PCAPConfig) *PCAPParser {
+	inputs := make([]CaptureInput, len(pcapPaths))
+	for i, path := range pcapPaths {
+		inputs[i] = CaptureInput{Path: path, Source: path}
+	}
+	return NewCaptureInputsParser(inputs, config)
+}
+
+// NewCaptureInputsParser creates a parser that merges capture files, e.g. rotated files
+// or captures from several SPAN ports, into one time-ordered analysis. A packet that
+// a second capture point reported within spanDuplicateWindow is counted once.
+func NewCaptureInputsParser(inputs []CaptureInput, config *PCAPConfig) *PCAPParser {
+	pcapPaths := make([]string, len(inputs))
+	sources := make([]string, len(inputs))
+	for i, input := range inputs {
+		pcapPaths[i], sources[i] = input.Path, input.Source
+	}
 	if config == nil {
 		config = &PCAPConfig{
 			ShowHostnames:      true,
@@ -60,34 +87,52 @@ func NewPCAPParser(pcapPath string, config *PCAPConfig) *PCAPParser {
 	}
 
 	return &PCAPParser{
-		pcapPath:         pcapPath,
+		pcapPaths:        pcapPaths,
+		sources:          sources,
 		config:           config,
 		detectionAdapter: integration.NewModularDetectionAdapter(config.ConfigPath),
 		fingerprinter:    fingerprinting.NewEnhancedDeviceFingerprinter(),
 		packetCache:      make(map[string][]gopacket.Packet),
-		optimizer:        performance.NewPerformanceOptimizer(performance.GetAdaptiveConfig(pcapPath)),
+		optimizer:        performance.NewPerformanceOptimizer(performance.GetAdaptiveConfig(pcapPaths[0])),
 		stringOptimizer:  optimization.NewStringOptimizer(),
+		cip:              newCIPTracker(),
+		sessions:         newSessionTracker(),
 	}
 }
 
-// Parse implements InputSource.Parse for PCAP files using sequential processing
+// Parse implements InputSource.Parse for PCAP files. With more than one worker, packets
+// are decoded and inspected in parallel; the model is the same either way.
 func (p *PCAPParser) Parse() (*types.NetworkModel, error) {
-	// Check file size for logging
-	info, err := os.Stat(p.pcapPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat PCAP file: %v", err)
+	// Check file sizes for logging
+	var totalSize int64
+	for _, path := range p.pcapPaths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat PCAP file: %v", err)
+		}
+		totalSize += info.Size()
 	}
 
 	logger := logging.NewLogger("pcap-parser", logging.INFO, false)
-	logger.Info("Processing PCAP file using optimized sequential processing", map[string]interface{}{
-		"file_path":    p.pcapPath,
-		"file_size_mb": info.Size() / (1024 * 1024),
+	logger.Info("Processing PCAP file using optimized processing", map[string]interface{}{
+		"file_path":    strings.Join(p.pcapPaths, ", "),
+		"file_count":   len(p.pcapPaths),
+		"file_size_mb": totalSize / (1024 * 1024),
+		"workers":      p.workers(),
 	})
-	return p.parseSequential()
+	return p.parseCaptures()
+}
+
+// workers returns the number of decode and DPI workers, 1 when parsing sequentially
+func (p *PCAPParser) workers() int {
+	if p.config.Workers > 1 {
+		return p.config.Workers
+	}
+	return 1
 }
 
-// parseSequential processes packets sequentially with optimized performance
-func (p *PCAPParser) parseSequential() (*types.NetworkModel, error) {
+// parseCaptures reads all capture files and builds the model with optimized performance
+func (p *PCAPParser) parseCaptures() (*types.NetworkModel, error) {
 	logger := logging.NewLogger("pcap-parser", logging.INFO, false)
 	start := time.Now()
 	defer func() {
@@ -98,13 +143,6 @@ func (p *PCAPParser) parseSequential() (*types.NetworkModel, error) {
 		})
 	}()
 
-	// Open PCAP file
-	handle, err := pcap.OpenOffline(p.pcapPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open PCAP file %s: %w", p.pcapPath, err)
-	}
-	defer handle.Close()
-
 	model := &types.NetworkModel{
 		Assets:   make(map[string]*types.Asset),
 		Networks: make(map[string]*types.NetworkSegment),
@@ -113,39 +151,30 @@ func (p *PCAPParser) parseSequential() (*types.NetworkModel, error) {
 		Metadata: p.GetMetadata(),
 	}
 
-	// Parse packets and build model
-	src := gopacket.NewPacketSource(handle, handle.LinkType())
-	packetCount := 0
-
-	for packet := range src.Packets() {
-		packetCount++
-
-		// Record packet processing start time
-		processingStart := time.Now()
-
-		if err := p.processPacket(packet, model); err != nil {
-			// Log error but continue processing - packet errors shouldn't stop analysis
-			logger.Warn("Failed to process packet", map[string]interface{}{
-				"packet_number": packetCount,
-				"timestamp":     packet.Metadata().Timestamp,
-				"error":         err.Error(),
-			})
-			continue
+	// Open every capture file; per-file statistics land in the model metadata
+	readers := make([]*captureReader, 0, len(p.pcapPaths))
+	defer func() {
+		for _, reader := range readers {
+			reader.close()
 		}
+	}()
+	for i, path := range p.pcapPaths {
+		reader, err := openCaptureReader(i, path, &model.Metadata.Files[i], p.workers() > 1)
+		if err != nil {
+			return nil, err
+		}
+		readers = append(readers, reader)
+	}
 
-		// Record processing time for performance tracking
-		processingTime := time.Since(processingStart)
-		p.optimizer.RecordPacketProcessed(processingTime)
-
-		if packetCount%10000 == 0 {
-			logger.Debug("Processing progress", map[string]interface{}{
-				"packets_processed": packetCount,
-			})
-
-			// Optimize garbage collection periodically for large PCAPs
-			if packetCount%50000 == 0 {
-				p.optimizer.OptimizeGC()
-			}
+	// Parse packets in timestamp order across files and build model
+	merger := newCaptureMerger(readers)
+	packetCount := 0
+	if workers := p.workers(); workers > 1 {
+		packetCount = p.processPacketsParallel(merger, model, workers, logger)
+	} else {
+		for packet := merger.Next(); packet != nil; packet = merger.Next() {
+			packetCount++
+			p.applyPacket(packet, model, packetCount, p.detectionAdapter, logger)
 		}
 	}
 
@@ -156,6 +185,14 @@ func (p *PCAPParser) parseSequential() (*types.NetworkModel, error) {
 		"processing_time":    processingTime.String(),
 		"packets_per_second": int(packetsPerSecond),
 	})
+	for _, file := range model.Metadata.Files {
+		if file.Duplicates > 0 {
+			logger.Info("Dropped packets already captured at another SPAN point", map[string]interface{}{
+				"file_path":  file.Path,
+				"duplicates": file.Duplicates,
+			})
+		}
+	}
 
 	// Post-processing: deduplicate, classify, enhance
 	enhanceStart := time.Now()
@@ -175,8 +212,7 @@ func (p *PCAPParser) parseSequential() (*types.NetworkModel, error) {
 	p.printEnhancedStatistics()
 
 	// Print detection statistics
-	stats := p.detectionAdapter.GetDetectionStats()
-	log.Printf("Detection Statistics: %+v", stats)
+	log.Printf("Detection Statistics: %+v", p.detectionStats())
 
 	// Print performance optimization report
 	p.optimizer.PrintPerformanceReport()
@@ -203,24 +239,68 @@ func (p *PCAPParser) parseSequential() (*types.NetworkModel, error) {
 	return model, nil
 }
 
-// GetMetadata implements InputSource.GetMetadata
+// applyPacket adds one packet to the model and records processing performance. Packet
+// errors are logged and do not stop the analysis.
+func (p *PCAPParser) applyPacket(packet gopacket.Packet, model *types.NetworkModel, packetNumber int, inspector packetInspector, logger *logging.Logger) {
+	// Record packet processing start time
+	processingStart := time.Now()
+
+	if err := p.processPacket(packet, model, inspector); err != nil {
+		logger.Warn("Failed to process packet", map[string]interface{}{
+			"packet_number": packetNumber,
+			"timestamp":     packet.Metadata().Timestamp,
+			"error":         err.Error(),
+		})
+		return
+	}
+
+	// Record processing time for performance tracking
+	p.optimizer.RecordPacketProcessed(time.Since(processingStart))
+
+	if packetNumber%10000 == 0 {
+		logger.Debug("Processing progress", map[string]interface{}{
+			"packets_processed": packetNumber,
+		})
+
+		// Optimize garbage collection periodically for large PCAPs
+		if packetNumber%50000 == 0 {
+			p.optimizer.OptimizeGC()
+		}
+	}
+}
+
+// GetMetadata implements InputSource.GetMetadata. A merged analysis is described by all
+// of its files, with a hash over the per-file hashes.
 func (p *PCAPParser) GetMetadata() types.InputMetadata {
-	info, _ := os.Stat(p.pcapPath)
-	size := int64(0)
-	modTime := time.Now()
+	metadata := types.InputMetadata{
+		Source: strings.Join(p.pcapPaths, ", "),
+		Type:   types.InputTypePCAP,
+		Files:  make([]types.CaptureFile, len(p.pcapPaths)),
+	}
 
-	if info != nil {
-		size = info.Size()
-		modTime = info.ModTime()
+	combined := sha256.New()
+	for i, path := range p.pcapPaths {
+		file := types.CaptureFile{Path: path, Source: p.sources[i], Hash: calculateFileHash(path)}
+		if info, _ := os.Stat(path); info != nil {
+			file.Size = info.Size()
+			if info.ModTime().After(metadata.Timestamp) {
+				metadata.Timestamp = info.ModTime()
+			}
+		}
+		metadata.Files[i] = file
+		metadata.Size += file.Size
+		combined.Write([]byte(file.Hash))
 	}
 
-	return types.InputMetadata{
-		Source:    p.pcapPath,
-		Type:      types.InputTypePCAP,
-		Timestamp: modTime,
-		Size:      size,
-		Hash:      calculateFileHash(p.pcapPath),
+	if metadata.Timestamp.IsZero() {
+		metadata.Timestamp = time.Now()
 	}
+	if len(metadata.Files) == 1 {
+		metadata.Hash = metadata.Files[0].Hash
+	} else {
+		metadata.Hash = hex.EncodeToString(combined.Sum(nil))
+	}
+	return metadata
 }
 
 // GetType implements InputSource.GetType
@@ -228,8 +308,16 @@ func (p *PCAPParser) GetType() types.InputType {
 	return types.InputTypePCAP
 }
 
+// packetInspector detects a packet's protocol and returns its DPI results, one per PDU
+// it completes. The detection adapter is one; parallel parsing hands over what a shard
+// worker already found.
+type packetInspector interface {
+	DetectProtocol(packet gopacket.Packet) string
+	AnalyzePayload(packet gopacket.Packet) []*core.AnalysisResult
+}
+
 // processPacket processes a single packet and updates the model
-func (p *PCAPParser) processPacket(packet gopacket.Packet, model *types.NetworkModel) error {
+func (p *PCAPParser) processPacket(packet gopacket.Packet, model *types.NetworkModel, inspector packetInspector) error {
 	// Extract network layers
 	ethLayer := packet.Layer(layers.LayerTypeEthernet)
 	arpLayer := packet.Layer(layers.LayerTypeARP)
@@ -262,23 +350,27 @@ func (p *PCAPParser) processPacket(packet gopacket.Packet, model *types.NetworkM
 		srcIP = ip.SrcIP
 		dstIP = ip.DstIP
 	} else {
-		// Handle L2-only protocols like Profinet
+		// Handle L2-only protocols like Profinet, which is commonly VLAN tagged for priority
 		if eth != nil && eth.EthernetType == layers.EthernetType(0x8892) {
-			return p.processL2Protocol(packet, model, eth)
+			return p.processL2Protocol(packet, model, eth, inspector)
+		}
+		if dot1q, ok := packet.Layer(layers.LayerTypeDot1Q).(*layers.Dot1Q); ok && eth != nil && dot1q.Type == layers.EthernetType(0x8892) {
+			return p.processL2Protocol(packet, model, eth, inspector)
 		}
 		return nil // Skip non-IP packets for now
 	}
 
-	// Create or update assets
-	srcAsset := p.getOrCreateAsset(model, srcIP.String(), eth.SrcMAC.String())
-	dstAsset := p.getOrCreateAsset(model, dstIP.String(), eth.DstMAC.String())
+	// Create or update assets; cooked and raw IP captures carry fewer or no MACs
+	srcMAC, dstMAC := linkAddresses(packet)
+	srcAsset := p.getOrCreateAsset(model, srcIP.String(), srcMAC)
+	dstAsset := p.getOrCreateAsset(model, dstIP.String(), dstMAC)
 
 	// Cache packets for fingerprinting (limit to 50 packets per asset)
 	p.cachePacketForFingerprinting(srcAsset.ID, packet)
 	p.cachePacketForFingerprinting(dstAsset.ID, packet)
 
-	// Detect protocol using optimized detection
-	protocol := p.detectionAdapter.DetectProtocol(packet)
+	// Detect protocol using optimized detection; DPI attributes are kept per packet
+	protocol, dpiResults := inspector.DetectProtocol(packet), inspector.AnalyzePayload(packet)
 	internedProtocol := p.stringOptimizer.InternString(protocol)
 	flowKey := types.FlowKey{
 		SrcIP: srcAsset.ID,
@@ -303,6 +395,14 @@ func (p *PCAPParser) processPacket(packet gopacket.Packet, model *types.NetworkM
 	flow.Packets++
 	flow.Bytes += int64(len(packet.Data()))
 	flow.LastSeen = packet.Metadata().Timestamp
+	recordVLANs(srcAsset, srcMAC, flow, vlanTags(packet))
+	p.sessions.observe(model, flow, packet, srcAsset.ID, dstAsset.ID, flowKey.Proto)
+	for _, dpiResult := range dpiResults {
+		recordFlowAttributes(model, flow, dpiResult, packet.Metadata().Timestamp)
+		p.cip.observe(srcAsset, dstAsset, flow, dpiResult, packet.Metadata().Timestamp)
+		recordAssetAttributes(srcAsset, dpiResult)
+		recordNameResolution(model, srcAsset, dpiResult)
+	}
 
 	// Update asset protocol information
 	p.updateAssetProtocols(srcAsset, dstAsset, protocol, tcpLayer, udpLayer, icmpLayer, icmp6Layer)
@@ -311,13 +411,17 @@ func (p *PCAPParser) processPacket(packet gopacket.Packet, model *types.NetworkM
 }
 
 // processL2Protocol handles Layer 2 protocols like Profinet
-func (p *PCAPParser) processL2Protocol(packet gopacket.Packet, model *types.NetworkModel, eth *layers.Ethernet) error {
+func (p *PCAPParser) processL2Protocol(packet gopacket.Packet, model *types.NetworkModel, eth *layers.Ethernet, inspector packetInspector) error {
 	// Create assets based on MAC addresses
 	srcAsset := p.getOrCreateAsset(model, eth.SrcMAC.String(), eth.SrcMAC.String())
 	dstAsset := p.getOrCreateAsset(model, eth.DstMAC.String(), eth.DstMAC.String())
 
-	// Detect L2 protocol
-	protocol := p.stringOptimizer.InternString("Profinet-DCP") // Could be enhanced with payload analysis
+	// Label the flow by frame type: cyclic RT, alarms, DCP or PTCP
+	var dpiResult *core.AnalysisResult
+	if results := inspector.AnalyzePayload(packet); len(results) > 0 {
+		dpiResult = results[0]
+	}
+	protocol := p.stringOptimizer.InternString(profinetFlowProtocol(dpiResult))
 
 	flowKey := types.FlowKey{
 		SrcIP: srcAsset.ID,
@@ -341,6 +445,9 @@ func (p *PCAPParser) processL2Protocol(packet gopacket.Packet, model *types.Netw
 	flow.Packets++
 	flow.Bytes += int64(len(packet.Data()))
 	flow.LastSeen = packet.Metadata().Timestamp
+	recordVLANs(srcAsset, srcAsset.MAC, flow, vlanTags(packet))
+	recordFlowAttributes(model, flow, dpiResult, packet.Metadata().Timestamp)
+	recordProfinet(model, srcAsset, dstAsset, dpiResult, packet.Metadata().Timestamp)
 
 	// Update asset protocols
 	srcAsset.Protocols = p.addProtocolIfNotExists(srcAsset.Protocols, types.Protocol(protocol))
@@ -385,6 +492,7 @@ func (p *PCAPParser) processARPPacket(packet gopacket.Packet, model *types.Netwo
 		flow.Packets++
 		flow.Bytes += int64(len(packet.Data()))
 		flow.LastSeen = packet.Metadata().Timestamp
+		recordVLANs(srcAsset, srcAsset.MAC, flow, vlanTags(packet))
 
 		// Update asset protocols
 		srcAsset.Protocols = p.addProtocolIfNotExists(srcAsset.Protocols, types.Protocol("ARP"))
@@ -428,24 +536,23 @@ func (p *PCAPParser) getOrCreateAsset(model *types.NetworkModel, ip, mac string)
 			vendorName = vendor.LookupOUI(mac)
 		}
 
-		// Get hostname if DNS lookup is enabled
-		var hostname string
-		if p.config.EnableDNSLookup && ip != "" && ip != mac {
-			hostname = vendor.ResolveHostname(ip)
-		}
-
 		// Use performance optimizer to get asset from pool
 		asset = p.optimizer.GetAsset()
 		asset.ID = id
 		asset.IP = ip
 		asset.MAC = mac
-		asset.Hostname = hostname
 		asset.Vendor = vendorName
 		asset.PurdueLevel = types.Unknown
 		asset.IEC62443Zone = "" // Will be inferred later
 		asset.Criticality = types.LowAsset
 		asset.Exposure = types.OTOnly
 		model.Assets[id] = asset
+	} else if asset.MAC == "" && mac != "" {
+		// First seen in a frame without link addresses, e.g. as the destination of a cooked capture
+		asset.MAC = mac
+		if p.config.EnableVendorLookup && len(mac) >= 8 {
+			asset.Vendor = vendor.LookupOUI(mac)
+		}
 	}
 
 	return asset
@@ -610,6 +717,9 @@ func (p *PCAPParser) addProtocolIfNotExists(protocols []types.Protocol, proto ty
 
 // enhanceModel performs post-processing enhancement
 func (p *PCAPParser) enhanceModel(model *types.NetworkModel) error {
+	// Name assets from DNS, mDNS, LLMNR and NBNS answers in the capture
+	p.applyHostnames(model)
+
 	// Perform device fingerprinting first
 	p.performDeviceFingerprinting(model)
 
@@ -620,8 +730,12 @@ func (p *PCAPParser) enhanceModel(model *types.NetworkModel) error {
 		asset.Criticality = p.assessAssetCriticality(asset, model)
 		asset.Exposure = p.assessAssetExposure(asset, model)
 
-		// Set device name based on protocols and patterns
-		asset.DeviceName = p.inferDeviceName(asset)
+		// Set device name based on protocols and patterns, unless the device reported its type
+		if asset.Profinet != nil && asset.Profinet.TypeOfStation != "" {
+			asset.DeviceName = asset.Profinet.TypeOfStation
+		} else {
+			asset.DeviceName = p.inferDeviceName(asset)
+		}
 	}
 
 	return nil
@@ -646,8 +760,13 @@ func (p *PCAPParser) performDeviceFingerprinting(model *types.NetworkModel) {
 				asset.DeviceName = deviceInfo.DeviceType
 				asset.Vendor = deviceInfo.Manufacturer
 				asset.OS = deviceInfo.OS
-				asset.Model = deviceInfo.Model
-				asset.Version = deviceInfo.Version
+				// Keep model and version reported by the device itself over DPI
+				if asset.Model == "" {
+					asset.Model = deviceInfo.Model
+				}
+				if asset.Version == "" {
+					asset.Version = deviceInfo.Version
+				}
 
 				// Store fingerprinting details
 				asset.FingerprintingDetails = map[string]interface{}{
@@ -776,8 +895,8 @@ func (p *PCAPParser) updateAssetRoles(asset *types.Asset) {
 func (p *PCAPParser) printEnhancedStatistics() {
 	log.Printf("\n=== Enhanced Protocol Detection Statistics ===")
 
-	// Print performance statistics from the detection adapter
-	stats := p.detectionAdapter.GetDetectionStats()
+	// Print performance statistics from the detection adapters
+	stats := p.detectionStats()
 	log.Printf("Detection Performance:")
 	if totalPackets, ok := stats["total_packets"].(int64); ok {
 		log.Printf("  Total Packets: %d", totalPackets)
@@ -794,41 +913,97 @@ func (p *PCAPParser) printEnhancedStatistics() {
 	}
 }
 
-// inferNetworkSegments creates network segments from traffic patterns
+// detectionStats returns the detection statistics of the parse, over the shards when
+// parsing in parallel
+func (p *PCAPParser) detectionStats() map[string]interface{} {
+	return integration.CombinedDetectionStats(append([]*integration.ModularDetectionAdapter{p.detectionAdapter}, p.shardAdapters...)...)
+}
+
+// inferNetworkSegments creates network segments from traffic patterns. Assets seen on a
+// single VLAN are grouped by that VLAN; the rest are grouped by /24 network.
 func (p *PCAPParser) inferNetworkSegments(model *types.NetworkModel) {
-	// Group assets by network patterns (simplified)
 	networks := make(map[string][]*types.Asset)
+	vlans := make(map[uint16][]*types.Asset)
 
 	for _, asset := range model.Assets {
 		if asset.IP != "" && asset.IP != asset.MAC {
-			// Group by /24 network (simplified)
 			ip := net.ParseIP(asset.IP)
-			if ip != nil && ip.To4() != nil {
-				network := fmt.Sprintf("%d.%d.%d.0/24", ip[12], ip[13], ip[14])
-				networks[network] = append(networks[network], asset)
+			if ip == nil || ip.To4() == nil {
+				continue
+			}
+			if len(asset.VLANs) == 1 {
+				vlans[asset.VLANs[0]] = append(vlans[asset.VLANs[0]], asset)
+				continue
 			}
+			// Group by /24 network (simplified)
+			network := fmt.Sprintf("%d.%d.%d.0/24", ip[12], ip[13], ip[14])
+			networks[network] = append(networks[network], asset)
 		}
 	}
 
 	// Create network segments
 	for cidr, assets := range networks {
-		if len(assets) < 2 { // Skip single-asset networks
+		p.addNetworkSegment(model, cidr, 0, assets)
+	}
+	for vlan, assets := range vlans {
+		if cidr, ok := vlanNetwork(assets); ok {
+			p.addNetworkSegment(model, cidr, vlan, assets)
 			continue
 		}
-
-		segment := &types.NetworkSegment{
-			ID:       fmt.Sprintf("network_%s", strings.ReplaceAll(cidr, "/", "_")),
-			CIDR:     cidr,
-			Name:     fmt.Sprintf("Inferred Network %s", cidr),
-			Assets:   assets,
-			Policies: []*types.SecurityPolicy{},
-			Zone:     p.inferNetworkZone(assets),
-			Risk:     p.assessNetworkRisk(assets),
-			Purpose:  p.inferNetworkPurpose(assets),
+		// Addresses too far apart to describe the VLAN with one prefix
+		subnets := make(map[string][]*types.Asset)
+		for _, asset := range assets {
+			ip := net.ParseIP(asset.IP).To4()
+			network := fmt.Sprintf("%d.%d.%d.0/24", ip[0], ip[1], ip[2])
+			subnets[network] = append(subnets[network], asset)
 		}
+		for cidr, subnetAssets := range subnets {
+			p.addNetworkSegment(model, cidr, vlan, subnetAssets)
+		}
+	}
+}
+
+// addNetworkSegment adds an inferred segment, skipping single-asset networks
+func (p *PCAPParser) addNetworkSegment(model *types.NetworkModel, cidr string, vlan uint16, assets []*types.Asset) {
+	if len(assets) < 2 {
+		return
+	}
+
+	segment := &types.NetworkSegment{
+		ID:       fmt.Sprintf("network_%s", strings.ReplaceAll(cidr, "/", "_")),
+		CIDR:     cidr,
+		Name:     fmt.Sprintf("Inferred Network %s", cidr),
+		Assets:   assets,
+		Policies: []*types.SecurityPolicy{},
+		Zone:     p.inferNetworkZone(assets),
+		Risk:     p.assessNetworkRisk(assets),
+		Purpose:  p.inferNetworkPurpose(assets),
+		VLAN:     vlan,
+	}
+	if vlan != 0 {
+		segment.ID = fmt.Sprintf("vlan_%d_%s", vlan, strings.ReplaceAll(cidr, "/", "_"))
+		segment.Name = fmt.Sprintf("VLAN %d (%s)", vlan, cidr)
+	}
+
+	model.Networks[segment.ID] = segment
+}
 
-		model.Networks[segment.ID] = segment
+// vlanNetwork returns the smallest prefix between /16 and /24 covering the IPv4
+// addresses of a VLAN's assets
+func vlanNetwork(assets []*types.Asset) (string, bool) {
+	first := net.ParseIP(assets[0].IP).To4()
+	prefix := 24
+	for _, asset := range assets[1:] {
+		ip := net.ParseIP(asset.IP).To4()
+		for prefix >= 16 && !first.Mask(net.CIDRMask(prefix, 32)).Equal(ip.Mask(net.CIDRMask(prefix, 32))) {
+			prefix--
+		}
+	}
+	if prefix < 16 {
+		return "", false
 	}
+	network := net.IPNet{IP: first.Mask(net.CIDRMask(prefix, 32)), Mask: net.CIDRMask(prefix, 32)}
+	return network.String(), true
 }
 
 // Classification helper functions (simplified versions)
@@ -836,7 +1011,7 @@ func (p *PCAPParser) classifyAssetPurdueLevel(asset *types.Asset, model *types.N
 	// Simplified classification based on protocols and communication patterns
 	for _, proto := range asset.Protocols {
 		switch proto {
-		case "EtherNet/IP I/O", "Profinet":
+		case "EtherNet/IP I/O", "Profinet", "Profinet-RT", "Profinet-DCP", "Profinet-Alarm":
 			return types.L1 // Field devices
 		case "EtherNet/IP", "Modbus TCP", "S7Comm":
 			// Check if more client or server behavior
@@ -333,6 +333,12 @@ func (po *PerformanceOptimizer) GetAsset() *types.Asset {
 	asset.OS = ""
 	asset.Model = ""
 	asset.Version = ""
+	asset.CIP = nil
+	asset.BACnet = nil
+	asset.Profinet = nil
+	asset.TLS = nil
+	asset.DNSQueries = asset.DNSQueries[:0]
+	asset.VLANs = asset.VLANs[:0]
 	asset.PurdueLevel = types.Unknown
 	asset.IEC62443Zone = ""
 	asset.Protocols = asset.Protocols[:0]
@@ -381,6 +387,12 @@ func (po *PerformanceOptimizer) GetFlow() *types.Flow {
 	flow.Bytes = 0
 	flow.FirstSeen = time.Time{}
 	flow.Allowed = true
+	flow.PolicyID = ""
+	flow.DPI = nil
+	flow.VLANs = flow.VLANs[:0]
+	flow.ServiceVLANs = flow.ServiceVLANs[:0]
+	flow.Sessions = flow.Sessions[:0]
+	flow.SessionCount = 0
 
 	po.statsMutex.Lock()
 	po.stats.PoolHits++
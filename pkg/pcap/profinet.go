@@ -0,0 +1,135 @@
+package pcap
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cipgram/pkg/pcap/core"
+	"cipgram/pkg/types"
+)
+
+// profinetFlowProtocol labels a PROFINET flow by frame type ("Profinet-RT", "Profinet-DCP",
+// ...). Frames DPI could not decode keep the historical "Profinet-DCP" label.
+func profinetFlowProtocol(result *core.AnalysisResult) string {
+	if result == nil || result.Protocol != "Profinet" {
+		return "Profinet-DCP"
+	}
+	frameType := detailString(result.Details, "frame_type")
+	if frameType == "" {
+		return "Profinet-DCP"
+	}
+	return "Profinet-" + frameType
+}
+
+// recordProfinet applies DCP identification to the device that sent it and reports
+// DCP Set requests that rename, re-address or reset the device they are sent to
+func recordProfinet(model *types.NetworkModel, src, dst *types.Asset, result *core.AnalysisResult, seen time.Time) {
+	if result == nil || result.Protocol != "Profinet" {
+		return
+	}
+	details := result.Details
+	if detailString(details, "frame_type") != "DCP" {
+		return
+	}
+
+	service := detailString(details, "dcp_service")
+	response := detailString(details, "direction") == "response"
+
+	switch {
+	case service == "Set" && !response:
+		if detail := dcpSetDetail(details); detail != "" {
+			model.Events = append(model.Events, &types.NotableEvent{
+				Time:     seen,
+				Source:   src.ID,
+				Target:   dst.ID,
+				Protocol: types.Protocol(profinetFlowProtocol(result)),
+				Kind:     "DCP Set",
+				Detail:   detail,
+			})
+		}
+	case response || service == "Hello":
+		if _, unsupported := details["dcp_status"]; unsupported {
+			return
+		}
+		setProfinetIdentity(src, details)
+		// The same device may also be known by its IP from routed traffic
+		if src.Profinet != nil && src.Profinet.IP != "" {
+			if byIP := model.Assets[src.Profinet.IP]; byIP != nil && byIP != src {
+				setProfinetIdentity(byIP, details)
+			}
+		}
+	}
+}
+
+// setProfinetIdentity stores DCP identification on the asset and gives MAC-only assets
+// the station name and IP address the device reported
+func setProfinetIdentity(asset *types.Asset, details map[string]interface{}) {
+	device := asset.Profinet
+	if device == nil {
+		device = &types.ProfinetDevice{}
+	}
+
+	if name := detailString(details, "name_of_station"); name != "" {
+		device.NameOfStation = name
+	}
+	if stationType := detailString(details, "type_of_station"); stationType != "" {
+		device.TypeOfStation = stationType
+	}
+	if vendorID, ok := detailUint(details, "vendor_id"); ok {
+		device.VendorID = uint16(vendorID)
+	}
+	if deviceID, ok := detailUint(details, "device_id"); ok {
+		device.DeviceID = uint16(deviceID)
+	}
+	if role := detailString(details, "device_role"); role != "" {
+		device.Role = role
+	}
+	// 0.0.0.0 means the device has not been assigned an address yet
+	if ip := detailString(details, "ip_address"); ip != "" && ip != "0.0.0.0" {
+		device.IP = ip
+		device.SubnetMask = detailString(details, "subnet_mask")
+		device.Gateway = detailString(details, "gateway")
+	}
+
+	if *device == (types.ProfinetDevice{}) {
+		return
+	}
+	asset.Profinet = device
+
+	if asset.Hostname == "" {
+		asset.Hostname = device.NameOfStation
+	}
+	if asset.DeviceName == "" {
+		asset.DeviceName = device.TypeOfStation
+	}
+	if asset.IP == asset.MAC && device.IP != "" {
+		asset.IP = device.IP
+	}
+}
+
+// dcpSetDetail describes the changes a DCP Set request makes, or "" if it changes
+// nothing notable
+func dcpSetDetail(details map[string]interface{}) string {
+	operation := detailString(details, "high_risk_operation")
+	if operation == "" {
+		return ""
+	}
+
+	var parts []string
+	if name, exists := details["dcp_set_name"]; exists {
+		parts = append(parts, fmt.Sprintf("NameOfStation %q", name))
+	}
+	if ip := detailString(details, "dcp_set_ip"); ip != "" {
+		parts = append(parts, fmt.Sprintf("IP %s/%s gateway %s", ip,
+			detailString(details, "dcp_set_subnet_mask"), detailString(details, "dcp_set_gateway")))
+	}
+	// Transaction start/end controls accompany most Sets; only resets are notable
+	if control := detailString(details, "dcp_control"); control != "" && strings.Contains(operation, control) {
+		parts = append(parts, control)
+	}
+	if len(parts) == 0 {
+		return operation
+	}
+	return strings.Join(parts, ", ")
+}
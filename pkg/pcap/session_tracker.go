@@ -0,0 +1,170 @@
+package pcap
+
+import (
+	"time"
+
+	"cipgram/pkg/types"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// ephemeralPortStart is where client port ranges start on common stacks: Linux uses
+// 32768 and up, Windows and the IANA range 49152 and up
+const ephemeralPortStart = 32768
+
+// sessionTracker follows TCP and UDP sessions by 5-tuple so that both directions of a
+// conversation count towards one session with a known client and server. A new SYN on
+// a 5-tuple that already carried a connection opens a new session.
+type sessionTracker struct {
+	sessions map[types.SessionKey]*sessionProgress // Latest session per 5-tuple, keyed without Attempt
+}
+
+// sessionProgress is the handshake and teardown state behind a session
+type sessionProgress struct {
+	session   *types.Session
+	flows     []*types.Flow // Flows already linked to the session
+	synSeen   bool
+	synAcked  bool
+	clientFIN bool
+	serverFIN bool
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{sessions: make(map[types.SessionKey]*sessionProgress)}
+}
+
+// observe counts a packet from src to dst towards its session, creating the session on
+// its first packet, and links the session to the packet's flow. Packets without TCP or
+// UDP have no session.
+func (t *sessionTracker) observe(model *types.NetworkModel, flow *types.Flow, packet gopacket.Packet, src, dst string, protocol types.Protocol) {
+	var tcp *layers.TCP
+	var transport string
+	var srcPort, dstPort uint16
+	switch layer := packet.TransportLayer().(type) {
+	case *layers.TCP:
+		tcp, transport = layer, "tcp"
+		srcPort, dstPort = uint16(layer.SrcPort), uint16(layer.DstPort)
+	case *layers.UDP:
+		transport = "udp"
+		srcPort, dstPort = uint16(layer.SrcPort), uint16(layer.DstPort)
+	default:
+		return
+	}
+
+	timestamp := packet.Metadata().Timestamp
+	forward := types.SessionKey{Transport: transport, Client: src, ClientPort: srcPort, Server: dst, ServerPort: dstPort}
+	reverse := types.SessionKey{Transport: transport, Client: dst, ClientPort: dstPort, Server: src, ServerPort: srcPort}
+	progress, fromClient := t.sessions[forward], true
+	if progress == nil {
+		progress, fromClient = t.sessions[reverse], false
+	}
+	switch {
+	case progress == nil:
+		key := forward
+		switch {
+		case tcp != nil && tcp.SYN:
+			if tcp.ACK {
+				// A SYN-ACK answers a SYN the capture missed, so its receiver is the client
+				key = reverse
+			}
+		case srcPort < ephemeralPortStart && dstPort >= ephemeralPortStart:
+			// Without a SYN the first packet may be a reply; clients send from ephemeral ports
+			key = reverse
+		}
+		fromClient = key == forward
+		progress = t.open(model, key, protocol, timestamp, tcp != nil)
+
+	case tcp != nil && tcp.SYN && !tcp.ACK && fromClient && progress.session.State != types.SessionSynSent:
+		// The client port was reused for a new connection; keep the earlier one as it was
+		key := progress.session.Key
+		key.Attempt++
+		progress = t.open(model, key, protocol, timestamp, true)
+	}
+
+	session := progress.session
+	session.LastSeen = timestamp
+	if fromClient {
+		session.ClientPackets++
+		session.ClientBytes += int64(len(packet.Data()))
+	} else {
+		session.ServerPackets++
+		session.ServerBytes += int64(len(packet.Data()))
+	}
+	if tcp != nil {
+		progress.observeTCP(tcp, fromClient)
+	}
+
+	for _, linked := range progress.flows {
+		if linked == flow {
+			return
+		}
+	}
+	progress.flows = append(progress.flows, flow)
+	flow.SessionCount++
+	if len(flow.Sessions) < types.MaxFlowSessions {
+		flow.Sessions = append(flow.Sessions, session.Key)
+	}
+}
+
+// open starts a session and makes it the one later packets on its 5-tuple count towards
+func (t *sessionTracker) open(model *types.NetworkModel, key types.SessionKey, protocol types.Protocol, timestamp time.Time, isTCP bool) *sessionProgress {
+	progress := &sessionProgress{session: &types.Session{
+		Key:       key,
+		Protocol:  protocol,
+		FirstSeen: timestamp,
+	}}
+	if isTCP {
+		progress.session.State = types.SessionMidstream
+	}
+
+	tuple := key
+	tuple.Attempt = 0
+	t.sessions[tuple] = progress
+	if model.Sessions == nil {
+		model.Sessions = make(map[types.SessionKey]*types.Session)
+	}
+	model.Sessions[key] = progress.session
+	return progress
+}
+
+// observeTCP advances the session state from a segment's flags
+func (p *sessionProgress) observeTCP(tcp *layers.TCP, fromClient bool) {
+	session := p.session
+	switch {
+	case tcp.RST:
+		session.Resets++
+		if session.State == types.SessionSynSent && !p.synAcked {
+			session.State = types.SessionRefused
+		} else {
+			session.State = types.SessionReset
+		}
+
+	case tcp.SYN && !tcp.ACK && fromClient:
+		p.synSeen = true
+		session.InitiatedBySYN = true
+		session.State = types.SessionSynSent
+
+	case tcp.SYN && tcp.ACK && !fromClient:
+		p.synAcked = true
+		session.InitiatedBySYN = true
+		if session.State == types.SessionMidstream {
+			session.State = types.SessionSynSent
+		}
+
+	case tcp.ACK && fromClient && p.synAcked && session.State == types.SessionSynSent:
+		session.State = types.SessionEstablished
+		session.Handshake = p.synSeen
+	}
+
+	if tcp.FIN {
+		if fromClient {
+			p.clientFIN = true
+		} else {
+			p.serverFIN = true
+		}
+		if p.clientFIN && p.serverFIN && session.State != types.SessionReset && session.State != types.SessionRefused {
+			session.State = types.SessionClosed
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pcap
+
+import (
+	"time"
+
+	"cipgram/pkg/types"
+)
+
+// recordTLSSession updates the TLS summary of a connection. Like Modbus profiles, the
+// summary lives on the client -> server flow when it has been seen.
+func recordTLSSession(model *types.NetworkModel, flow *types.Flow, details map[string]interface{}) {
+	if _, handshake := details["handshake_types"]; !handshake {
+		return
+	}
+
+	target := flow
+	if detailString(details, "direction") == "response" {
+		reverse := types.FlowKey{SrcIP: flow.Destination, DstIP: flow.Source, Proto: flow.Protocol}
+		if requestFlow := model.Flows[reverse]; requestFlow != nil {
+			target = requestFlow
+		}
+	}
+
+	if target.DPI == nil {
+		target.DPI = types.NewFlowDPI()
+	}
+	session := target.DPI.TLS
+	if session == nil {
+		session = &types.TLSSession{}
+		target.DPI.TLS = session
+	}
+
+	if name := detailString(details, "server_name"); name != "" {
+		session.ServerName = name
+	}
+	if hash := detailString(details, "ja3_hash"); hash != "" {
+		session.JA3 = hash
+		session.JA4 = detailString(details, "ja4")
+		session.ALPN, _ = details["alpn"].([]string)
+	}
+	if hash := detailString(details, "ja3s_hash"); hash != "" {
+		session.JA3S = hash
+		session.Version = detailString(details, "tls_version")
+		session.CipherSuite = detailString(details, "cipher_suite_name")
+	}
+	if cert := tlsCertificate(details); cert != nil {
+		session.Certificate = cert
+	}
+	weaknesses, _ := details["tls_weaknesses"].([]string)
+	for _, weakness := range weaknesses {
+		session.AddWeakness(weakness)
+	}
+}
+
+// recordTLSProfile adds the fingerprints, negotiated parameters and certificate of a
+// handshake to the asset that sent it
+func recordTLSProfile(asset *types.Asset, details map[string]interface{}) {
+	ja3 := detailString(details, "ja3_hash")
+	ja3s := detailString(details, "ja3s_hash")
+	cert := tlsCertificate(details)
+	if ja3 == "" && ja3s == "" && cert == nil {
+		return
+	}
+
+	profile := asset.TLS
+	if profile == nil {
+		profile = &types.TLSProfile{}
+		asset.TLS = profile
+	}
+
+	if ja3 != "" {
+		profile.RecordClientHello(ja3, detailString(details, "ja4"), detailString(details, "server_name"))
+	}
+	if ja3s != "" {
+		profile.RecordServerHello(ja3s, detailString(details, "tls_version"), detailString(details, "cipher_suite_name"))
+	}
+	if cert != nil {
+		profile.AddCertificate(cert)
+	}
+	weaknesses, _ := details["tls_weaknesses"].([]string)
+	for _, weakness := range weaknesses {
+		profile.AddWeakness(weakness)
+	}
+}
+
+// tlsCertificate converts the certificate details of a Certificate message, or returns nil
+func tlsCertificate(details map[string]interface{}) *types.TLSCertificate {
+	subject := detailString(details, "cert_subject")
+	if subject == "" {
+		return nil
+	}
+	cert := &types.TLSCertificate{
+		Subject:      subject,
+		Issuer:       detailString(details, "cert_issuer"),
+		Organization: detailString(details, "cert_organization"),
+	}
+	cert.NotBefore, _ = details["cert_not_before"].(time.Time)
+	cert.NotAfter, _ = details["cert_not_after"].(time.Time)
+	cert.SelfSigned, _ = details["cert_self_signed"].(bool)
+	cert.Expired, _ = details["cert_expired"].(bool)
+	return cert
+}
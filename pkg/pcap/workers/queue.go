@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/gopacket"
@@ -123,6 +124,7 @@ type InMemoryWorkerQueue struct {
 
 	// State
 	running bool
+	quiet   bool
 	mutex   sync.RWMutex
 }
 
@@ -133,7 +135,7 @@ type Worker struct {
 	processor PacketProcessor
 
 	// State
-	active     bool
+	active     atomic.Bool
 	lastJob    time.Time
 	jobCount   int64
 	errorCount int64
@@ -183,7 +185,9 @@ func (q *InMemoryWorkerQueue) Start(ctx context.Context) error {
 	q.running = true
 	q.stats.StartTime = time.Now()
 
-	log.Printf("Started worker queue with %d workers", q.workerCount)
+	if !q.quiet {
+		log.Printf("Started worker queue with %d workers", q.workerCount)
+	}
 	return nil
 }
 
@@ -207,7 +211,30 @@ func (q *InMemoryWorkerQueue) Stop() error {
 	close(q.resultChan)
 
 	q.running = false
-	log.Printf("Stopped worker queue")
+	if !q.quiet {
+		log.Printf("Stopped worker queue")
+	}
+	return nil
+}
+
+// Drain stops accepting jobs, waits until the workers have processed every queued job
+// and then stops the queue
+func (q *InMemoryWorkerQueue) Drain() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !q.running {
+		return fmt.Errorf("queue is not running")
+	}
+
+	// Workers exit once the closed job channel is empty
+	close(q.jobChan)
+	q.workerWg.Wait()
+
+	close(q.stopChan)
+	close(q.resultChan)
+
+	q.running = false
 	return nil
 }
 
@@ -231,6 +258,29 @@ func (q *InMemoryWorkerQueue) Enqueue(job *PacketJob) error {
 	}
 }
 
+// EnqueueWait adds a job to the queue, waiting for room instead of failing when the
+// queue is full. Jobs from one caller are processed in order when there is one worker.
+func (q *InMemoryWorkerQueue) EnqueueWait(ctx context.Context, job *PacketJob) error {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if !q.running {
+		return fmt.Errorf("queue is not running")
+	}
+
+	select {
+	case q.jobChan <- job:
+		q.statsMutex.Lock()
+		q.stats.QueuedJobs++
+		q.statsMutex.Unlock()
+		return nil
+	case <-q.stopChan:
+		return fmt.Errorf("queue is stopping")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // EnqueueBatch adds multiple jobs to the queue
 func (q *InMemoryWorkerQueue) EnqueueBatch(jobs []*PacketJob) error {
 	for _, job := range jobs {
@@ -248,6 +298,14 @@ func (q *InMemoryWorkerQueue) SetWorkerCount(count int) error {
 	return fmt.Errorf("dynamic worker scaling not implemented - restart queue with new count")
 }
 
+// SetQuiet turns off the start and stop log lines, for callers that run several
+// queues and report them once
+func (q *InMemoryWorkerQueue) SetQuiet(quiet bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.quiet = quiet
+}
+
 // GetWorkerCount returns the current number of workers
 func (q *InMemoryWorkerQueue) GetWorkerCount() int {
 	return q.workerCount
@@ -276,7 +334,7 @@ func (q *InMemoryWorkerQueue) GetStats() QueueStats {
 	// Count active/idle workers
 	activeWorkers := 0
 	for _, worker := range q.workers {
-		if worker.active {
+		if worker != nil && worker.active.Load() {
 			activeWorkers++
 		}
 	}
@@ -333,9 +391,9 @@ func (w *Worker) run(ctx context.Context) {
 
 // processJob processes a single job
 func (w *Worker) processJob(ctx context.Context, job *PacketJob) {
-	w.active = true
+	w.active.Store(true)
 	w.lastJob = time.Now()
-	defer func() { w.active = false }()
+	defer func() { w.active.Store(false) }()
 
 	start := time.Now()
 	result, err := w.processor.ProcessPacket(ctx, job)
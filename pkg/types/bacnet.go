@@ -0,0 +1,9 @@
+package types
+
+// BACnetDevice holds what a BACnet device announced about itself in I-Am
+type BACnetDevice struct {
+	DeviceInstance uint32 // Instance number of the device object
+	VendorID       uint16 // ASHRAE-assigned vendor identifier
+	MaxAPDU        uint32 // Maximum APDU length accepted
+	Segmentation   string // segmented-both, segmented-transmit, segmented-receive or no-segmentation
+}
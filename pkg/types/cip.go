@@ -0,0 +1,62 @@
+package types
+
+import "time"
+
+// CIPIdentity holds the Identity object (class 0x01) of an EtherNet/IP device
+type CIPIdentity struct {
+	VendorID     uint16
+	DeviceType   uint16
+	ProductCode  uint16
+	Revision     string // major.minor
+	SerialNumber uint32
+	ProductName  string
+}
+
+// CIPConnection is an implicit I/O connection set up by Forward_Open or Large_Forward_Open
+type CIPConnection struct {
+	Originator       string // Scanner asset ID
+	Target           string // Adapter asset ID
+	OTConnectionID   uint32 // Originator -> target, chosen by the target
+	TOConnectionID   uint32 // Target -> originator, chosen by the originator
+	ConnectionSerial uint16
+	OriginatorVendor uint16
+	OriginatorSerial uint32
+	OTRPI            uint32 // Requested packet interval in microseconds
+	TORPI            uint32
+	OTSize           uint16 // Connection size in bytes
+	TOSize           uint16
+	LargeForwardOpen bool
+	Path             string // Connection path, e.g. "Assembly/1/cp:100/cp:150"
+	Established      bool   // A successful Forward_Open reply was seen
+	Closed           bool   // A Forward_Close was seen
+	OpenedAt         time.Time
+	IOPackets        int64 // Implicit I/O packets carrying either connection ID
+	LastIO           time.Time
+}
+
+// CIPDeviceInfo collects what EtherNet/IP explicit messaging revealed about a device
+type CIPDeviceInfo struct {
+	Identity    *CIPIdentity
+	Sessions    []uint32         // RegisterSession handles
+	Connections []*CIPConnection // Connections the device originated or accepted
+}
+
+// AddSession records a session handle once
+func (c *CIPDeviceInfo) AddSession(handle uint32) {
+	for _, existing := range c.Sessions {
+		if existing == handle {
+			return
+		}
+	}
+	c.Sessions = append(c.Sessions, handle)
+}
+
+// AddConnection records a connection once
+func (c *CIPDeviceInfo) AddConnection(conn *CIPConnection) {
+	for _, existing := range c.Connections {
+		if existing == conn {
+			return
+		}
+	}
+	c.Connections = append(c.Connections, conn)
+}
@@ -118,6 +118,15 @@ const (
 	Log    RuleAction = "LOG"
 )
 
+// NATType represents the kind of address translation a NAT rule performs
+type NATType string
+
+const (
+	PortForwardNAT NATType = "PORT_FORWARD" // Destination NAT of selected ports
+	OneToOneNAT    NATType = "ONE_TO_ONE"   // Bidirectional address mapping
+	OutboundNAT    NATType = "OUTBOUND"     // Source NAT / masquerade
+)
+
 // FlowKey uniquely identifies a network flow
 type FlowKey struct {
 	SrcIP string   `json:"src_ip"`
@@ -207,6 +216,12 @@ type Asset struct {
 	Criticality           CriticalityLevel
 	Exposure              ExposureLevel
 	FingerprintingDetails map[string]interface{} // Enhanced fingerprinting metadata
+	CIP                   *CIPDeviceInfo         // EtherNet/IP sessions, connections and identity, nil if none seen
+	BACnet                *BACnetDevice          // BACnet I-Am announcement, nil if none seen
+	Profinet              *ProfinetDevice        // PROFINET DCP identification, nil if none seen
+	TLS                   *TLSProfile            // TLS fingerprints and certificates, nil if no handshake seen
+	DNSQueries            []string               // Domains the asset looked up over unicast DNS
+	VLANs                 []uint16               // 802.1Q VLANs of frames sent from the asset's own MAC
 }
 
 // NetworkSegment represents a logical or physical network segment
@@ -219,32 +234,72 @@ type NetworkSegment struct {
 	Policies []*SecurityPolicy
 	Risk     RiskLevel
 	Purpose  string // "Production", "Development", "DMZ", etc.
+	VLAN     uint16 // 802.1Q VLAN the segment was inferred from, 0 when inferred from addressing
 }
 
 // SecurityPolicy represents firewall rules and network policies
 type SecurityPolicy struct {
-	ID          string
-	Source      NetworkRange
-	Destination NetworkRange
-	Ports       []Port
-	Protocol    Protocol
-	Action      RuleAction
-	Zone        string
-	Description string
-	Enabled     bool
+	ID                string
+	Source            NetworkRange
+	Destination       NetworkRange
+	Ports             []Port // Destination ports; empty for any
+	SourcePorts       []Port // Source ports; empty for any
+	NegatePorts       bool   // Ports lists the destination ports the rule does not match ("! --dport 22")
+	NegateSourcePorts bool   // SourcePorts lists the source ports the rule does not match
+	Protocol          Protocol
+	Action            RuleAction
+	Zone              string // Interfaces or segments the rule is bound to; "all", "any" or empty when unbound
+	Description       string
+	Enabled           bool
+	Direction         string      // "in", "out" or "any"; empty when the vendor has no rule direction
+	EgressZone        string      // Interfaces traffic must leave through; Zone is then the ingress binding
+	Local             bool        // Filters traffic to or from the firewall itself, never forwarded flows
+	States            []string    // Connection states the rule is limited to (NEW, ESTABLISHED, RELATED); empty for any
+	IPFamily          string      // "inet", "inet6" or "inet46"; empty when not recorded
+	Floating          bool        // Multi-interface rule evaluated before interface rules
+	Quick             bool        // Stops evaluation on match; false means last match wins (pf semantics)
+	Hits              *PolicyHits // Hit counters exported by the firewall, nil when unavailable
+}
+
+// NATRule represents an address translation configured on a firewall
+type NATRule struct {
+	ID                    string
+	Type                  NATType
+	Interface             string // Interface the translation applies on (usually the WAN)
+	Protocol              Protocol
+	Source                NetworkRange // Original source
+	Destination           NetworkRange // Original destination
+	Ports                 []Port       // Original destination ports
+	TranslatedSource      NetworkRange
+	TranslatedDestination NetworkRange
+	TranslatedPorts       []Port
+	Description           string
+	Enabled               bool
+}
+
+// PolicyHits holds packet/byte counters recorded against a firewall rule
+type PolicyHits struct {
+	Packets int64
+	Bytes   int64
 }
 
 // Flow represents communication between assets
 type Flow struct {
-	Source      string
-	Destination string
-	Protocol    Protocol
-	Ports       []Port
-	Packets     int64
-	Bytes       int64
-	FirstSeen   time.Time
-	LastSeen    time.Time
-	Allowed     bool // Based on firewall policies
+	Source       string
+	Destination  string
+	Protocol     Protocol
+	Ports        []Port
+	Packets      int64
+	Bytes        int64
+	FirstSeen    time.Time
+	LastSeen     time.Time
+	Allowed      bool         // Based on firewall policies
+	PolicyID     string       // First-match policy that decided Allowed, "" when not evaluated
+	DPI          *FlowDPI     // Application-layer attributes, nil when DPI extracted nothing
+	VLANs        []uint16     // Customer (innermost) 802.1Q VLANs the flow was seen on
+	ServiceVLANs []uint16     // Outer QinQ service VLANs, empty for single-tagged frames
+	Sessions     []SessionKey // TCP and UDP sessions the flow's packets belong to, the first MaxFlowSessions in order seen
+	SessionCount int          // All sessions the flow's packets belong to
 }
 
 // Configuration mapping types
@@ -0,0 +1,48 @@
+package types
+
+import "time"
+
+// DNP3Session summarises the traffic between one DNP3 master and one outstation,
+// identified by their link-layer addresses. A single TCP connection to a gateway can
+// carry several of these.
+type DNP3Session struct {
+	Master         uint16 `json:"master"`          // Master link address
+	Outstation     uint16 `json:"outstation"`      // Outstation link address
+	MasterHost     string `json:"master_host"`     // Asset ID of the master
+	OutstationHost string `json:"outstation_host"` // Asset ID of the outstation
+
+	Controls      int64     `json:"controls"`                 // Operate, Direct Operate and Direct Operate No Response
+	ControlPoints []string  `json:"control_points,omitempty"` // e.g. "CROB 3 LATCH_ON", "AO 7 = 1500"
+	LastControl   time.Time `json:"last_control"`
+	Restarts      int64     `json:"restarts"` // Cold and Warm Restart
+	LastRestart   time.Time `json:"last_restart"`
+
+	UnsolicitedResponses int64    `json:"unsolicited_responses"`
+	UnsolicitedDisabled  bool     `json:"unsolicited_disabled"`  // Last Enable/Disable Unsolicited request disabled it
+	IINFlags             []string `json:"iin_flags,omitempty"`   // Internal Indications reported by the outstation
+	SecureAuthentication bool     `json:"secure_authentication"` // SAv5 messages were exchanged
+}
+
+// RecordControl counts a control request and the points it operated
+func (s *DNP3Session) RecordControl(points []string, seen time.Time) {
+	s.Controls++
+	for _, point := range points {
+		s.ControlPoints = appendUnique(s.ControlPoints, point)
+	}
+	if seen.After(s.LastControl) {
+		s.LastControl = seen
+	}
+}
+
+// RecordRestart counts a cold or warm restart request
+func (s *DNP3Session) RecordRestart(seen time.Time) {
+	s.Restarts++
+	if seen.After(s.LastRestart) {
+		s.LastRestart = seen
+	}
+}
+
+// AddIINFlag records an Internal Indication bit the outstation reported
+func (s *DNP3Session) AddIINFlag(flag string) {
+	s.IINFlags = appendUnique(s.IINFlags, flag)
+}
@@ -0,0 +1,142 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Operation kinds recorded on a flow
+const (
+	OperationFunction   = "function"    // Modbus / DNP3 / S7comm function code
+	OperationCIPService = "cip_service" // CIP service code
+	OperationOPCUA      = "opcua"       // OPC UA service encoding ID
+
+	OperationBACnetConfirmed   = "bacnet_confirmed"   // BACnet confirmed service choice
+	OperationBACnetUnconfirmed = "bacnet_unconfirmed" // BACnet unconfirmed service choice
+)
+
+// FlowOperation counts one application-layer operation seen on a flow
+type FlowOperation struct {
+	Kind      string    `json:"kind"`
+	Code      uint16    `json:"code"`
+	Name      string    `json:"name"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// FlowDPI collects the attributes extracted by deep packet inspection over the life of a flow
+type FlowDPI struct {
+	Operations map[string]*FlowOperation `json:"-"` // Histogram keyed by kind and code
+	UnitIDs    []uint8                   `json:"unit_ids,omitempty"`
+	CIPObjects []string                  `json:"cip_objects,omitempty"` // Class/instance/attribute paths touched
+	UserAgents []string                  `json:"user_agents,omitempty"`
+	Modbus     *ModbusProfile            `json:"modbus,omitempty"` // Set on the master -> slave flow
+	OPCUA      *OPCUAChannel             `json:"opcua,omitempty"`
+	DNP3       []*DNP3Session            `json:"dnp3,omitempty"` // Per master/outstation address pair
+	TLS        *TLSSession               `json:"tls,omitempty"`  // Set on the client -> server flow
+
+	// HighRiskOperations names operations that change what a controller runs,
+	// such as "PLC Stop" or "Program Download"
+	HighRiskOperations []string `json:"high_risk_operations,omitempty"`
+}
+
+// NewFlowDPI creates an empty attribute set
+func NewFlowDPI() *FlowDPI {
+	return &FlowDPI{
+		Operations: make(map[string]*FlowOperation),
+	}
+}
+
+// RecordOperation adds one occurrence of an operation to the histogram
+func (d *FlowDPI) RecordOperation(kind string, code uint16, name string, seen time.Time) {
+	key := fmt.Sprintf("%s:0x%02X", kind, code)
+	op := d.Operations[key]
+	if op == nil {
+		op = &FlowOperation{Kind: kind, Code: code, Name: name, FirstSeen: seen}
+		d.Operations[key] = op
+	}
+	op.Count++
+	if seen.Before(op.FirstSeen) {
+		op.FirstSeen = seen
+	}
+	if seen.After(op.LastSeen) {
+		op.LastSeen = seen
+	}
+}
+
+// AddUnitID records a Modbus unit identifier
+func (d *FlowDPI) AddUnitID(id uint8) {
+	for _, existing := range d.UnitIDs {
+		if existing == id {
+			return
+		}
+	}
+	d.UnitIDs = append(d.UnitIDs, id)
+}
+
+// AddCIPObject records a CIP object path
+func (d *FlowDPI) AddCIPObject(path string) {
+	d.CIPObjects = appendUnique(d.CIPObjects, path)
+}
+
+// AddUserAgent records an HTTP user agent
+func (d *FlowDPI) AddUserAgent(agent string) {
+	d.UserAgents = appendUnique(d.UserAgents, agent)
+}
+
+// AddHighRiskOperation flags an operation that changes the state or program of a controller
+func (d *FlowDPI) AddHighRiskOperation(name string) {
+	d.HighRiskOperations = appendUnique(d.HighRiskOperations, name)
+}
+
+// DNP3Session returns the session for a master/outstation address pair, creating it on first use
+func (d *FlowDPI) DNP3Session(master, outstation uint16) *DNP3Session {
+	for _, session := range d.DNP3 {
+		if session.Master == master && session.Outstation == outstation {
+			return session
+		}
+	}
+	session := &DNP3Session{Master: master, Outstation: outstation}
+	d.DNP3 = append(d.DNP3, session)
+	return session
+}
+
+// SortedOperations returns the histogram ordered by kind and code
+func (d *FlowDPI) SortedOperations() []*FlowOperation {
+	ops := make([]*FlowOperation, 0, len(d.Operations))
+	for _, op := range d.Operations {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Kind != ops[j].Kind {
+			return ops[i].Kind < ops[j].Kind
+		}
+		return ops[i].Code < ops[j].Code
+	})
+	return ops
+}
+
+// TopOperation returns the most frequent operation of a kind, or nil
+func (d *FlowDPI) TopOperation(kind string) *FlowOperation {
+	var top *FlowOperation
+	for _, op := range d.SortedOperations() {
+		if op.Kind == kind && (top == nil || op.Count > top.Count) {
+			top = op
+		}
+	}
+	return top
+}
+
+func appendUnique(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ModbusAddressRange is an inclusive span of addresses in one Modbus data table
+type ModbusAddressRange struct {
+	Table string `json:"table"` // coils, discrete_inputs, holding_registers, input_registers
+	Start uint16 `json:"start"`
+	End   uint16 `json:"end"`
+}
+
+// String renders the range as "holding_registers 0-9"
+func (r ModbusAddressRange) String() string {
+	if r.Start == r.End {
+		return fmt.Sprintf("%s %d", r.Table, r.Start)
+	}
+	return fmt.Sprintf("%s %d-%d", r.Table, r.Start, r.End)
+}
+
+// ModbusProfile summarises the Modbus operations one master issued to one slave
+type ModbusProfile struct {
+	Master          string               `json:"master"`
+	Slave           string               `json:"slave"`
+	Reads           []ModbusAddressRange `json:"reads,omitempty"`
+	Writes          []ModbusAddressRange `json:"writes,omitempty"`
+	WriteCount      int64                `json:"write_count"`      // FC 5/6/15/16/22/23
+	DiagnosticCount int64                `json:"diagnostic_count"` // FC 8
+	DeviceIDCount   int64                `json:"device_id_count"`  // FC 43/14
+	Exceptions      map[string]int64     `json:"exceptions,omitempty"`
+}
+
+// NewModbusProfile creates an empty profile for a master/slave pair
+func NewModbusProfile(master, slave string) *ModbusProfile {
+	return &ModbusProfile{
+		Master:     master,
+		Slave:      slave,
+		Exceptions: make(map[string]int64),
+	}
+}
+
+// RecordRead adds quantity addresses starting at start to the read ranges
+func (p *ModbusProfile) RecordRead(table string, start, quantity uint16) {
+	p.Reads = mergeModbusRange(p.Reads, table, start, quantity)
+}
+
+// RecordWrite counts a write operation and adds its addresses to the write ranges.
+// A zero quantity counts the operation without an address range.
+func (p *ModbusProfile) RecordWrite(table string, start, quantity uint16) {
+	p.WriteCount++
+	p.Writes = mergeModbusRange(p.Writes, table, start, quantity)
+}
+
+// RecordException counts an exception response by name
+func (p *ModbusProfile) RecordException(name string) {
+	p.Exceptions[name]++
+}
+
+// mergeModbusRange adds a range and coalesces overlapping or adjacent ranges of the same table
+func mergeModbusRange(ranges []ModbusAddressRange, table string, start, quantity uint16) []ModbusAddressRange {
+	if table == "" || quantity == 0 {
+		return ranges
+	}
+
+	end := uint32(start) + uint32(quantity) - 1
+	if end > 0xFFFF {
+		end = 0xFFFF
+	}
+	ranges = append(ranges, ModbusAddressRange{Table: table, Start: start, End: uint16(end)})
+
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].Table != ranges[j].Table {
+			return ranges[i].Table < ranges[j].Table
+		}
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Table == last.Table && uint32(r.Start) <= uint32(last.End)+1 {
+			if r.End > last.End {
+				last.End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
@@ -30,7 +30,20 @@ type InputMetadata struct {
 	Type      InputType
 	Timestamp time.Time
 	Size      int64
-	Hash      string // For integrity checking
+	Hash      string        // For integrity checking
+	Files     []CaptureFile // Capture files merged into a PCAP analysis, in input order
+}
+
+// CaptureFile describes one capture file of a merged PCAP analysis
+type CaptureFile struct {
+	Path        string
+	Source      string // Capture point; packets are only deduplicated across sources
+	Size        int64
+	Hash        string
+	FirstPacket time.Time // Zero when the file held no packets
+	LastPacket  time.Time
+	Packets     int // Packets read from the file, duplicates included
+	Duplicates  int // Packets dropped as copies of one already read at another capture point
 }
 
 // NetworkModel represents the unified data model from all input sources
@@ -39,7 +52,27 @@ type NetworkModel struct {
 	Networks map[string]*NetworkSegment
 	Flows    map[FlowKey]*Flow
 	Policies []*SecurityPolicy
+	NATRules []*NATRule
+	Events   []*NotableEvent
 	Metadata InputMetadata
+
+	// Hostnames maps addresses to the names seen for them in DNS, mDNS, LLMNR and NBNS
+	// answers, in the order they were first seen
+	Hostnames map[string][]string
+
+	// Sessions holds the TCP and UDP sessions seen in captures, oriented client to server
+	Sessions map[SessionKey]*Session
+}
+
+// NotableEvent records a single packet worth an analyst's attention, such as a device
+// being renamed or re-addressed on the wire
+type NotableEvent struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"` // Asset ID of the sender
+	Target   string    `json:"target"` // Asset ID of the affected device
+	Protocol Protocol  `json:"protocol"`
+	Kind     string    `json:"kind"` // Short label, e.g. "DCP Set"
+	Detail   string    `json:"detail"`
 }
 
 // AnalysisResult represents the output of network analysis
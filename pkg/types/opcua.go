@@ -0,0 +1,24 @@
+package types
+
+// OPCUAChannel summarises the OPC UA secure channels and endpoints seen on a flow
+type OPCUAChannel struct {
+	SecurityPolicy string   `json:"security_policy,omitempty"` // Short name, e.g. "Basic256Sha256"
+	SecurityMode   string   `json:"security_mode,omitempty"`   // None, Sign or SignAndEncrypt
+	EndpointURLs   []string `json:"endpoint_urls,omitempty"`
+	Insecure       bool     `json:"insecure"` // A channel was opened with SecurityPolicy#None
+}
+
+// RecordSecurity notes the policy and mode of an OpenSecureChannel exchange. The mode
+// is only readable when the policy is None; otherwise the request body is encrypted.
+func (c *OPCUAChannel) RecordSecurity(policy, mode string) {
+	c.SecurityPolicy = policy
+	c.SecurityMode = mode
+	if policy == "None" {
+		c.Insecure = true
+	}
+}
+
+// AddEndpointURL records an endpoint URL from a Hello or GetEndpoints response
+func (c *OPCUAChannel) AddEndpointURL(url string) {
+	c.EndpointURLs = appendUnique(c.EndpointURLs, url)
+}
@@ -0,0 +1,13 @@
+package types
+
+// ProfinetDevice holds what a PROFINET device reported about itself over DCP
+type ProfinetDevice struct {
+	NameOfStation string // Configured station name, e.g. "plc-line1"
+	TypeOfStation string // Vendor-supplied device type string
+	VendorID      uint16
+	DeviceID      uint16
+	Role          string // IO-Device, IO-Controller, IO-Multidevice or IO-Supervisor, comma separated
+	IP            string
+	SubnetMask    string
+	Gateway       string
+}
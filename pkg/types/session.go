@@ -0,0 +1,78 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxFlowSessions caps the session keys kept on a flow; Flow.SessionCount has the total
+const MaxFlowSessions = 64
+
+// SessionKey identifies a TCP or UDP session by its 5-tuple, oriented from the client
+// that initiated it to the server
+type SessionKey struct {
+	Transport  string `json:"transport"` // "tcp" or "udp"
+	Client     string `json:"client"`    // Asset ID of the initiator
+	ClientPort uint16 `json:"client_port"`
+	Server     string `json:"server"`
+	ServerPort uint16 `json:"server_port"`
+	Attempt    int    `json:"attempt,omitempty"` // Earlier connections on the same 5-tuple (client port reuse)
+}
+
+// String returns the session as "tcp 10.0.0.2:50000->10.0.0.1:502", followed by "#2"
+// and up for later connections reusing the client port
+func (k SessionKey) String() string {
+	s := fmt.Sprintf("%s %s:%d->%s:%d", k.Transport, k.Client, k.ClientPort, k.Server, k.ServerPort)
+	if k.Attempt > 0 {
+		s += fmt.Sprintf(" #%d", k.Attempt+1)
+	}
+	return s
+}
+
+// SessionState is where a TCP session got to; UDP sessions have no state
+type SessionState string
+
+const (
+	SessionSynSent     SessionState = "SYN_SENT"    // SYN not answered yet
+	SessionEstablished SessionState = "ESTABLISHED" // Three-way handshake completed
+	SessionMidstream   SessionState = "MIDSTREAM"   // Picked up after the handshake
+	SessionRefused     SessionState = "REFUSED"     // SYN answered by a reset
+	SessionReset       SessionState = "RESET"       // Reset after data could flow
+	SessionClosed      SessionState = "CLOSED"      // Both sides sent FIN
+)
+
+// Session is one transport conversation between a client and a server. The client is
+// the sender of the SYN. When the handshake was missed it is the side on an ephemeral
+// port, or failing that the sender of the first packet seen.
+type Session struct {
+	Key            SessionKey   `json:"key"`
+	Protocol       Protocol     `json:"protocol"`
+	InitiatedBySYN bool         `json:"initiated_by_syn"` // Client known from the handshake, not guessed
+	State          SessionState `json:"state,omitempty"`
+	Handshake      bool         `json:"handshake"` // SYN, SYN-ACK and ACK all seen
+	Resets         int          `json:"resets"`
+	ClientPackets  int64        `json:"client_packets"`
+	ClientBytes    int64        `json:"client_bytes"`
+	ServerPackets  int64        `json:"server_packets"`
+	ServerBytes    int64        `json:"server_bytes"`
+	FirstSeen      time.Time    `json:"first_seen"`
+	LastSeen       time.Time    `json:"last_seen"`
+}
+
+// Failed reports a connection attempt that never completed its handshake, because the
+// server refused it or never answered
+func (s *Session) Failed() bool {
+	return s.State == SessionRefused || s.State == SessionSynSent
+}
+
+// IsReply reports whether most of the flow's sessions were initiated by its destination,
+// so that the flow carries a server's replies to its client
+func (f *Flow) IsReply() bool {
+	replies := 0
+	for _, key := range f.Sessions {
+		if key.Client == f.Destination {
+			replies++
+		}
+	}
+	return replies*2 > len(f.Sessions)
+}
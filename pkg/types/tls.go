@@ -0,0 +1,82 @@
+package types
+
+import "time"
+
+// TLSCertificate describes the leaf certificate a server sent in a TLS 1.2 (or older) handshake
+type TLSCertificate struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	Organization string    `json:"organization,omitempty"` // First O= of the subject
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+	SelfSigned   bool      `json:"self_signed"`
+	Expired      bool      `json:"expired"` // Outside its validity period when captured
+}
+
+// TLSSession summarises the handshakes seen on a client -> server flow
+type TLSSession struct {
+	ServerName  string          `json:"server_name,omitempty"` // SNI requested by the client
+	ALPN        []string        `json:"alpn,omitempty"`
+	Version     string          `json:"version,omitempty"`      // Negotiated, e.g. "TLS 1.2"
+	CipherSuite string          `json:"cipher_suite,omitempty"` // Negotiated suite name
+	JA3         string          `json:"ja3,omitempty"`          // Client fingerprint (MD5)
+	JA4         string          `json:"ja4,omitempty"`
+	JA3S        string          `json:"ja3s,omitempty"` // Server fingerprint (MD5)
+	Certificate *TLSCertificate `json:"certificate,omitempty"`
+	Weaknesses  []string        `json:"weaknesses,omitempty"` // Deprecated versions and cipher suites
+}
+
+// AddWeakness records a deprecated version or cipher suite once
+func (s *TLSSession) AddWeakness(weakness string) {
+	s.Weaknesses = appendUnique(s.Weaknesses, weakness)
+}
+
+// TLSProfile collects the TLS facts an asset presented as a client and as a server
+type TLSProfile struct {
+	JA3          []string // Client fingerprints
+	JA4          []string
+	ServerNames  []string // Names requested as a client
+	JA3S         []string // Server fingerprints
+	Versions     []string // Negotiated as a server
+	CipherSuites []string // Negotiated as a server
+	Certificates []*TLSCertificate
+	Weaknesses   []string
+}
+
+// RecordClientHello records the fingerprints and requested server name of a ClientHello
+func (p *TLSProfile) RecordClientHello(ja3, ja4, serverName string) {
+	p.JA3 = appendUnique(p.JA3, ja3)
+	p.JA4 = appendUnique(p.JA4, ja4)
+	p.ServerNames = appendUnique(p.ServerNames, serverName)
+}
+
+// RecordServerHello records the fingerprint and negotiated parameters of a ServerHello
+func (p *TLSProfile) RecordServerHello(ja3s, version, cipherSuite string) {
+	p.JA3S = appendUnique(p.JA3S, ja3s)
+	p.Versions = appendUnique(p.Versions, version)
+	p.CipherSuites = appendUnique(p.CipherSuites, cipherSuite)
+}
+
+// AddWeakness records a deprecated version or cipher suite once
+func (p *TLSProfile) AddWeakness(weakness string) {
+	p.Weaknesses = appendUnique(p.Weaknesses, weakness)
+}
+
+// AddCertificate records a server certificate once per subject and validity period
+func (p *TLSProfile) AddCertificate(cert *TLSCertificate) {
+	for _, existing := range p.Certificates {
+		if existing.Subject == cert.Subject && existing.NotAfter.Equal(cert.NotAfter) {
+			return
+		}
+	}
+	p.Certificates = append(p.Certificates, cert)
+}
+
+// Organizations returns the distinct subject organisations of the recorded certificates
+func (p *TLSProfile) Organizations() []string {
+	var organizations []string
+	for _, cert := range p.Certificates {
+		organizations = appendUnique(organizations, cert.Organization)
+	}
+	return organizations
+}
@@ -0,0 +1,115 @@
+package writers_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cipgram/internal/writers"
+	"cipgram/pkg/types"
+)
+
+func natModel() *types.NetworkModel {
+	return &types.NetworkModel{
+		Assets: make(map[string]*types.Asset),
+		Networks: map[string]*types.NetworkSegment{
+			"wan":  {ID: "wan", Name: "WAN", CIDR: "203.0.113.2/29", Zone: types.DMZZone},
+			"opt2": {ID: "opt2", Name: "ASSEMBLY", CIDR: "10.30.0.1/24", Zone: types.IndustrialZone},
+		},
+		Flows:    make(map[types.FlowKey]*types.Flow),
+		Policies: []*types.SecurityPolicy{},
+		NATRules: []*types.NATRule{
+			{
+				ID:                    "pf1",
+				Type:                  types.PortForwardNAT,
+				Interface:             "wan",
+				Protocol:              "tcp",
+				Destination:           types.NetworkRange{CIDR: "wanip", IPs: []string{"203.0.113.2"}},
+				Ports:                 []types.Port{{Number: 502, Protocol: "tcp:Modbus"}},
+				TranslatedDestination: types.NetworkRange{CIDR: "PLC1", IPs: []string{"10.30.0.10"}},
+				TranslatedPorts:       []types.Port{{Number: 502, Protocol: "tcp:Modbus"}},
+				Description:           "Vendor Modbus access",
+				Enabled:               true,
+			},
+			{
+				ID:                    "pf2",
+				Type:                  types.PortForwardNAT,
+				Interface:             "wan",
+				Destination:           types.NetworkRange{CIDR: "wanip"},
+				Ports:                 []types.Port{{Number: 443, Protocol: "tcp:HTTPS"}},
+				TranslatedDestination: types.NetworkRange{CIDR: "10.30.0.20", IPs: []string{"10.30.0.20"}},
+				Description:           "Web portal",
+				Enabled:               true,
+			},
+			{
+				ID:                    "pf3",
+				Type:                  types.PortForwardNAT,
+				Interface:             "wan",
+				Destination:           types.NetworkRange{CIDR: "wanip"},
+				Ports:                 []types.Port{{Number: 44818, Protocol: "tcp:44818-44818"}},
+				TranslatedDestination: types.NetworkRange{CIDR: "10.30.0.30", IPs: []string{"10.30.0.30"}},
+				Description:           "Disabled ENIP",
+				Enabled:               false,
+			},
+			{
+				ID:                    "pf4",
+				Type:                  types.PortForwardNAT,
+				Interface:             "wan",
+				Destination:           types.NetworkRange{CIDR: "203.0.113.3"},
+				TranslatedDestination: types.NetworkRange{CIDR: "10.30.0.40", IPs: []string{"10.30.0.40"}},
+				Description:           "Whole HMI forwarded",
+				Enabled:               true,
+			},
+		},
+		Metadata: types.InputMetadata{Source: "config.xml", Type: types.InputTypeOPNsense, Timestamp: time.Now()},
+	}
+}
+
+func TestFirewallRulesSummary_NATExposure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	if err := writers.NewFirewallDiagramGenerator(natModel()).GenerateFirewallRulesSummary(path); err != nil {
+		t.Fatalf("GenerateFirewallRulesSummary failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	summary := string(data)
+
+	if !strings.Contains(summary, "NAT TRANSLATIONS") || !strings.Contains(summary, "PLC1:502") {
+		t.Error("Expected NAT translations section with the redirect target")
+	}
+	if !strings.Contains(summary, "INDUSTRIAL SERVICES EXPOSED THROUGH NAT") || !strings.Contains(summary, "pf1 (PORT_FORWARD on wan)") {
+		t.Error("Expected the Modbus port forward to be flagged")
+	}
+	if !strings.Contains(summary, "Modbus/502") {
+		t.Error("Expected exposed service name and port")
+	}
+	if !strings.Contains(summary, "pf4 (PORT_FORWARD on wan)") || !strings.Contains(summary, "all ports of an "+string(types.IndustrialZone)+" host") {
+		t.Error("Expected a forward without ports to expose the whole industrial host")
+	}
+	if strings.Contains(summary, "pf2 (PORT_FORWARD") || strings.Contains(summary, "pf3 (PORT_FORWARD") {
+		t.Error("HTTPS and disabled forwards must not be flagged as industrial exposure")
+	}
+}
+
+func TestNetworkTopologyDiagram_NATEdges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.dot")
+	if err := writers.NewFirewallDiagramGenerator(natModel()).GenerateNetworkTopologyDiagram(path); err != nil {
+		t.Fatalf("GenerateNetworkTopologyDiagram failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read diagram: %v", err)
+	}
+	dot := string(data)
+
+	if !strings.Contains(dot, "net_wan -> net_opt2") {
+		t.Error("Expected NAT edge from WAN to the assembly network")
+	}
+	if !strings.Contains(dot, "#d32f2f") {
+		t.Error("Expected industrial exposure to be highlighted")
+	}
+}
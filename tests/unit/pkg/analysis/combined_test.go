@@ -0,0 +1,165 @@
+package analysis_test
+
+import (
+	"testing"
+	"time"
+
+	"cipgram/pkg/analysis"
+	"cipgram/pkg/types"
+)
+
+// staticSource returns a fixed model, standing in for a PCAP or firewall parser
+type staticSource struct {
+	model *types.NetworkModel
+}
+
+func (s *staticSource) Parse() (*types.NetworkModel, error) { return s.model, nil }
+func (s *staticSource) GetMetadata() types.InputMetadata  { return s.model.Metadata }
+func (s *staticSource) GetType() types.InputType          { return s.model.Metadata.Type }
+
+func newModel(inputType types.InputType) *types.NetworkModel {
+	return &types.NetworkModel{
+		Assets:   make(map[string]*types.Asset),
+		Networks: make(map[string]*types.NetworkSegment),
+		Flows:    make(map[types.FlowKey]*types.Flow),
+		Policies: []*types.SecurityPolicy{},
+		Metadata: types.InputMetadata{Source: string(inputType), Type: inputType, Timestamp: time.Now()},
+	}
+}
+
+func TestCombinedAnalyzer_AliasPoliciesMatchFlows(t *testing.T) {
+	firewall := newModel(types.InputTypeOPNsense)
+	firewall.Policies = []*types.SecurityPolicy{
+		{
+			ID:          "engineering",
+			Action:      types.Allow,
+			Enabled:     true,
+			Source:      types.NetworkRange{CIDR: "lan", IPs: []string{"10.10.0.0/24"}},
+			Destination: types.NetworkRange{CIDR: "OT_DEVICES", IPs: []string{"10.20.0.10", "10.20.0.11"}},
+			Ports:       []types.Port{{Number: 502, Protocol: "tcp:Modbus"}, {Number: 2222, Protocol: "tcp:2222-2223"}},
+		},
+		{
+			ID:          "implicit-default-deny",
+			Action:      types.Deny,
+			Enabled:     true,
+			Source:      types.NetworkRange{CIDR: "any"},
+			Destination: types.NetworkRange{CIDR: "any"},
+		},
+	}
+
+	pcap := newModel(types.InputTypePCAP)
+	flows := map[string]*types.Flow{
+		"modbus":    {Source: "10.10.0.5", Destination: "10.20.0.10", Ports: []types.Port{{Number: 502}}},
+		"range":     {Source: "10.10.0.5", Destination: "10.20.0.11", Ports: []types.Port{{Number: 2223}}},
+		"otherHost": {Source: "10.10.0.5", Destination: "10.20.0.99", Ports: []types.Port{{Number: 502}}},
+		"otherSrc":  {Source: "192.168.1.5", Destination: "10.20.0.10", Ports: []types.Port{{Number: 502}}},
+		"otherPort": {Source: "10.10.0.5", Destination: "10.20.0.10", Ports: []types.Port{{Number: 80}}},
+	}
+	for name, flow := range flows {
+		pcap.Flows[types.FlowKey{SrcIP: flow.Source, DstIP: flow.Destination, Proto: types.Protocol(name)}] = flow
+	}
+
+	analyzer := analysis.NewCombinedAnalyzer(&staticSource{firewall}, &staticSource{pcap})
+	if err := analyzer.ParseAllSources(); err != nil {
+		t.Fatalf("ParseAllSources failed: %v", err)
+	}
+	if _, err := analyzer.GenerateCombinedModel(); err != nil {
+		t.Fatalf("GenerateCombinedModel failed: %v", err)
+	}
+
+	expected := map[string]bool{
+		"modbus":    true,
+		"range":     true,
+		"otherHost": false,
+		"otherSrc":  false,
+		"otherPort": false,
+	}
+	for name, allowed := range expected {
+		if flows[name].Allowed != allowed {
+			t.Errorf("Flow %s: expected allowed=%v, got %v", name, allowed, flows[name].Allowed)
+		}
+		rule := "engineering"
+		if !allowed {
+			rule = "implicit-default-deny"
+		}
+		if flows[name].PolicyID != rule {
+			t.Errorf("Flow %s: expected to hit rule %s, got %q", name, rule, flows[name].PolicyID)
+		}
+	}
+}
+
+func TestCombinedAnalyzer_DisabledAndFamilyPolicies(t *testing.T) {
+	firewall := newModel(types.InputTypeOPNsense)
+	firewall.Policies = []*types.SecurityPolicy{
+		{ID: "disabled", Action: types.Allow, Enabled: false, Source: types.NetworkRange{CIDR: "any"}, Destination: types.NetworkRange{CIDR: "any"}},
+		{ID: "v6-only", Action: types.Allow, Enabled: true, IPFamily: "inet6", Source: types.NetworkRange{CIDR: "any"}, Destination: types.NetworkRange{CIDR: "any"}},
+	}
+
+	pcap := newModel(types.InputTypePCAP)
+	v4 := &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10"}
+	v6 := &types.Flow{Source: "fd00::5", Destination: "fd00::10"}
+	pcap.Flows[types.FlowKey{SrcIP: v4.Source, DstIP: v4.Destination, Proto: "TCP"}] = v4
+	pcap.Flows[types.FlowKey{SrcIP: v6.Source, DstIP: v6.Destination, Proto: "TCP"}] = v6
+
+	analyzer := analysis.NewCombinedAnalyzer(&staticSource{firewall}, &staticSource{pcap})
+	if err := analyzer.ParseAllSources(); err != nil {
+		t.Fatalf("ParseAllSources failed: %v", err)
+	}
+	if _, err := analyzer.GenerateCombinedModel(); err != nil {
+		t.Fatalf("GenerateCombinedModel failed: %v", err)
+	}
+
+	if v4.Allowed {
+		t.Error("IPv4 flow must not match a disabled rule or an inet6 rule")
+	}
+	if !v6.Allowed {
+		t.Error("IPv6 flow should match the inet6 rule")
+	}
+}
+
+func TestCombinedAnalyzer_SessionsDecideReplies(t *testing.T) {
+	firewall := newModel(types.InputTypeOPNsense)
+	firewall.Policies = []*types.SecurityPolicy{
+		{ID: "engineering", Action: types.Allow, Enabled: true, Protocol: "tcp",
+			Source:      types.NetworkRange{CIDR: "10.10.0.5"},
+			Destination: types.NetworkRange{CIDR: "10.20.0.10"},
+			Ports:       []types.Port{{Number: 502, Protocol: "tcp:Modbus"}}},
+		{ID: "implicit-default-deny", Action: types.Deny, Enabled: true,
+			Source: types.NetworkRange{CIDR: "any"}, Destination: types.NetworkRange{CIDR: "any"}},
+	}
+
+	polling := types.SessionKey{Transport: "tcp", Client: "10.10.0.5", ClientPort: 50000, Server: "10.20.0.10", ServerPort: 502}
+	ssh := types.SessionKey{Transport: "tcp", Client: "10.10.0.5", ClientPort: 50001, Server: "10.20.0.10", ServerPort: 22}
+	pcap := newModel(types.InputTypePCAP)
+	pcap.Sessions = map[types.SessionKey]*types.Session{
+		polling: {Key: polling, State: types.SessionEstablished},
+		ssh:     {Key: ssh, State: types.SessionRefused},
+	}
+	request := &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "Modbus TCP", Sessions: []types.SessionKey{polling}}
+	reply := &types.Flow{Source: "10.20.0.10", Destination: "10.10.0.5", Protocol: "Modbus TCP", Sessions: []types.SessionKey{polling}}
+	refused := &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "SSH", Sessions: []types.SessionKey{ssh}}
+	refusedReply := &types.Flow{Source: "10.20.0.10", Destination: "10.10.0.5", Protocol: "SSH", Sessions: []types.SessionKey{ssh}}
+	for _, flow := range []*types.Flow{request, reply, refused, refusedReply} {
+		pcap.Flows[types.FlowKey{SrcIP: flow.Source, DstIP: flow.Destination, Proto: flow.Protocol}] = flow
+	}
+
+	analyzer := analysis.NewCombinedAnalyzer(&staticSource{firewall}, &staticSource{pcap})
+	if err := analyzer.ParseAllSources(); err != nil {
+		t.Fatalf("ParseAllSources failed: %v", err)
+	}
+	combined, err := analyzer.GenerateCombinedModel()
+	if err != nil {
+		t.Fatalf("GenerateCombinedModel failed: %v", err)
+	}
+
+	if !reply.Allowed || reply.PolicyID != "engineering" {
+		t.Errorf("Expected replies to follow the allowed request, got allowed=%v rule %q", reply.Allowed, reply.PolicyID)
+	}
+	if refusedReply.Allowed || refusedReply.PolicyID != "implicit-default-deny" {
+		t.Errorf("Expected replies to a denied request to be denied, got allowed=%v rule %q", refusedReply.Allowed, refusedReply.PolicyID)
+	}
+	// Only the SSH request is a violation; its reset reply is reported with it
+	if combined.Metadata.Size != 1 {
+		t.Errorf("Expected 1 violation, got %d", combined.Metadata.Size)
+	}
+}
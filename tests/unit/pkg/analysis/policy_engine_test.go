@@ -0,0 +1,251 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/analysis"
+	"cipgram/pkg/types"
+)
+
+func engineNetworks() map[string]*types.NetworkSegment {
+	return map[string]*types.NetworkSegment{
+		"lan":  {ID: "lan", CIDR: "10.10.0.1/24"},
+		"opt1": {ID: "opt1", CIDR: "10.20.0.1/24"},
+		"wan":  {ID: "wan", Purpose: "Internet"},
+	}
+}
+
+func anyRange() types.NetworkRange {
+	return types.NetworkRange{CIDR: "any", IPs: []string{}}
+}
+
+func TestPolicyEngine_FirstMatchWins(t *testing.T) {
+	policies := []*types.SecurityPolicy{
+		{ID: "block-historian", Action: types.Deny, Enabled: true, Zone: "lan", Direction: "in", Quick: true,
+			Source: types.NetworkRange{CIDR: "lan"}, Destination: types.NetworkRange{CIDR: "10.20.0.20"}},
+		{ID: "lan-to-ot", Action: types.Allow, Enabled: true, Zone: "lan", Direction: "in", Quick: true, Protocol: "tcp",
+			Source: types.NetworkRange{CIDR: "lan"}, Destination: types.NetworkRange{CIDR: "opt1"},
+			Ports: []types.Port{{Number: 502, Protocol: "tcp:Modbus"}, {Number: 44818, Protocol: "tcp:44818-44819"}}},
+		{ID: "not-lan", Action: types.Allow, Enabled: true, Zone: "opt1", Direction: "in", Quick: true,
+			Source: types.NetworkRange{CIDR: "opt1"}, Destination: types.NetworkRange{CIDR: "!lan"}},
+		{ID: "implicit-default-deny", Action: types.Deny, Enabled: true, Zone: "all", Quick: true,
+			Source: anyRange(), Destination: anyRange()},
+	}
+	engine := analysis.NewPolicyEngine(policies, engineNetworks())
+
+	tests := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"earlier deny wins", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.20", Protocol: "Modbus TCP"}, "block-historian", false},
+		{"protocol and port from name", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "Modbus TCP"}, "lan-to-ot", true},
+		{"typed protocol constant", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: types.ProtoENIP_Explicit}, "lan-to-ot", true},
+		{"port range", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Ports: []types.Port{{Number: 44819}}}, "lan-to-ot", true},
+		{"wrong port", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "HTTP"}, "implicit-default-deny", false},
+		{"wrong transport", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "DNS"}, "implicit-default-deny", false},
+		{"no known port", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "TCP"}, "implicit-default-deny", false},
+		{"port from session", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "Unknown",
+			Sessions: []types.SessionKey{{Transport: "tcp", Client: "10.10.0.5", ClientPort: 50000, Server: "10.20.0.10", ServerPort: 502}}}, "lan-to-ot", true},
+		{"wrong interface", &types.Flow{Source: "10.20.0.5", Destination: "10.10.0.9", Protocol: "Modbus TCP"}, "implicit-default-deny", false},
+		{"negated destination", &types.Flow{Source: "10.20.0.5", Destination: "8.8.8.8", Protocol: "DNS"}, "not-lan", true},
+		{"unknown source enters on WAN", &types.Flow{Source: "198.51.100.7", Destination: "10.20.0.10", Protocol: "Modbus TCP"}, "implicit-default-deny", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.Evaluate(tt.flow)
+			if decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+				t.Errorf("Expected rule %s (allowed=%v), got %s (allowed=%v)", tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+			}
+		})
+	}
+}
+
+func TestPolicyEngine_FloatingAndDisabledRules(t *testing.T) {
+	policies := []*types.SecurityPolicy{
+		{ID: "float-pass", Action: types.Allow, Enabled: true, Floating: true, Zone: "lan,opt1", Direction: "any",
+			Source: anyRange(), Destination: anyRange()},
+		{ID: "float-block-quick", Action: types.Deny, Enabled: true, Floating: true, Quick: true, Zone: "opt1", Direction: "out",
+			Source: anyRange(), Destination: types.NetworkRange{CIDR: "10.20.0.66"}},
+		{ID: "disabled-pass", Action: types.Allow, Enabled: false, Zone: "lan", Direction: "in", Quick: true,
+			Source: anyRange(), Destination: anyRange()},
+	}
+	engine := analysis.NewPolicyEngine(policies, engineNetworks())
+
+	// Non-quick floating match is remembered and wins when nothing else matches
+	decision := engine.Evaluate(&types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10"})
+	if decision.RuleID != "float-pass" || !decision.Allowed {
+		t.Errorf("Expected last-match floating pass, got %+v", decision)
+	}
+
+	// A later quick floating rule overrides the remembered match
+	decision = engine.Evaluate(&types.Flow{Source: "10.10.0.5", Destination: "10.20.0.66"})
+	if decision.RuleID != "float-block-quick" || decision.Allowed {
+		t.Errorf("Expected quick floating block, got %+v", decision)
+	}
+
+	// Disabled rules are skipped entirely
+	engine = analysis.NewPolicyEngine(policies[2:], engineNetworks())
+	decision = engine.Evaluate(&types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10"})
+	if decision.RuleID != analysis.ImplicitDenyRuleID || decision.Allowed {
+		t.Errorf("Expected implicit deny when only a disabled rule exists, got %+v", decision)
+	}
+}
+
+func TestPolicyEngine_TrafficNotCrossingFirewall(t *testing.T) {
+	engine := analysis.NewPolicyEngine([]*types.SecurityPolicy{}, engineNetworks())
+
+	for _, flow := range []*types.Flow{
+		{Source: "10.10.0.5", Destination: "10.10.0.6", Protocol: "Modbus TCP"},
+		{Source: "MAC-00:11:22:33:44:55", Destination: "MAC-ff:ff:ff:ff:ff:ff", Protocol: "Profinet-DCP"},
+	} {
+		decision := engine.Evaluate(flow)
+		if !decision.Allowed || decision.RuleID != "" || decision.Reason == "" {
+			t.Errorf("Expected %s -> %s to bypass policy evaluation, got %+v", flow.Source, flow.Destination, decision)
+		}
+	}
+}
+
+func TestPolicyEngine_EgressBindingAndLocalRules(t *testing.T) {
+	policies := []*types.SecurityPolicy{
+		{ID: "input-accept", Action: types.Allow, Enabled: true, Local: true, Zone: "lan", Direction: "in",
+			Source: anyRange(), Destination: anyRange()},
+		{ID: "lan-to-opt1", Action: types.Allow, Enabled: true, Zone: "lan", Direction: "in", EgressZone: "opt1",
+			Source: anyRange(), Destination: anyRange()},
+		{ID: "implicit-default-deny", Action: types.Deny, Enabled: true, Zone: "all",
+			Source: anyRange(), Destination: anyRange()},
+	}
+	engine := analysis.NewPolicyEngine(policies, engineNetworks())
+
+	tests := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"enters and leaves on the bound interfaces", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10"}, "lan-to-opt1", true},
+		{"reverse direction", &types.Flow{Source: "10.20.0.10", Destination: "10.10.0.5"}, "implicit-default-deny", false},
+		{"leaves on another interface", &types.Flow{Source: "10.10.0.5", Destination: "8.8.8.8"}, "implicit-default-deny", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.Evaluate(tt.flow)
+			if decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+				t.Errorf("Expected rule %s (allowed=%v), got %s (allowed=%v)", tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+			}
+		})
+	}
+}
+
+func TestPolicyEngine_UnknownInterfaces(t *testing.T) {
+	policies := []*types.SecurityPolicy{
+		{ID: "vpn-any", Action: types.Allow, Enabled: true, Zone: "openvpn", Direction: "in",
+			Source: anyRange(), Destination: anyRange()},
+		{ID: "lan-or-vpn", Action: types.Allow, Enabled: true, Zone: "openvpn,lan", Direction: "in",
+			Source: anyRange(), Destination: anyRange()},
+		{ID: "unbound", Action: types.Reject, Enabled: true, Zone: "any",
+			Source: anyRange(), Destination: anyRange()},
+	}
+	engine := analysis.NewPolicyEngine(policies, engineNetworks())
+
+	tests := []struct {
+		name string
+		flow *types.Flow
+		rule string
+	}{
+		{"unknown interface never matches", &types.Flow{Source: "10.20.0.10", Destination: "10.10.0.5"}, "unbound"},
+		{"known interface in the list still matches", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10"}, "lan-or-vpn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if decision := engine.Evaluate(tt.flow); decision.RuleID != tt.rule {
+				t.Errorf("Expected rule %s, got %s", tt.rule, decision.RuleID)
+			}
+		})
+	}
+}
+
+func TestPolicyEngine_ReplyFlows(t *testing.T) {
+	// The HMI on lan polls the PLC on opt1; the PLC's packets are the replies
+	polling := []types.SessionKey{{Transport: "tcp", Client: "10.10.0.5", ClientPort: 50000, Server: "10.20.0.10", ServerPort: 502}}
+	reply := &types.Flow{Source: "10.20.0.10", Destination: "10.10.0.5", Protocol: "Modbus TCP", Sessions: polling}
+
+	lanToOT := &types.SecurityPolicy{ID: "lan-to-ot", Action: types.Allow, Enabled: true, Zone: "lan", Direction: "in",
+		Protocol: "tcp", Ports: []types.Port{{Number: 502, Protocol: "tcp:Modbus"}},
+		Source: anyRange(), Destination: anyRange()}
+	established := &types.SecurityPolicy{ID: "established", Action: types.Allow, Enabled: true, Zone: "any",
+		States: []string{"RELATED", "ESTABLISHED"}, Source: anyRange(), Destination: anyRange()}
+	deny := &types.SecurityPolicy{ID: "deny", Action: types.Drop, Enabled: true, Zone: "any",
+		Source: anyRange(), Destination: anyRange()}
+
+	tests := []struct {
+		name     string
+		policies []*types.SecurityPolicy
+		rule     string
+		allowed  bool
+	}{
+		{"stateful firewall passes replies of allowed connections", []*types.SecurityPolicy{lanToOT, deny}, "lan-to-ot", true},
+		{"replies of denied connections are denied", []*types.SecurityPolicy{deny}, "deny", false},
+		{"conntrack rule set accepts established replies", []*types.SecurityPolicy{established, lanToOT, deny}, "established", true},
+		{"conntrack rule set without an established rule", []*types.SecurityPolicy{lanToOT, deny,
+			{ID: "new-only", Action: types.Allow, Enabled: true, Zone: "lan", Direction: "out", States: []string{"NEW"},
+				Source: anyRange(), Destination: anyRange()}}, "deny", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := analysis.NewPolicyEngine(tt.policies, engineNetworks()).Evaluate(reply)
+			if decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+				t.Errorf("Expected rule %s (allowed=%v), got %s (allowed=%v)", tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+			}
+		})
+	}
+
+	// The established rule must not open new connections
+	request := &types.Flow{Source: "10.20.0.10", Destination: "10.10.0.5", Protocol: "Modbus TCP"}
+	if decision := analysis.NewPolicyEngine([]*types.SecurityPolicy{established, deny}, engineNetworks()).Evaluate(request); decision.RuleID != "deny" {
+		t.Errorf("Expected new connection to skip the established rule, got %s", decision.RuleID)
+	}
+}
+
+func TestPolicyEngine_SourceAndNegatedPorts(t *testing.T) {
+	session := func(clientPort, serverPort uint16) []types.SessionKey {
+		return []types.SessionKey{{Transport: "tcp", Client: "10.10.0.5", ClientPort: clientPort, Server: "10.20.0.10", ServerPort: serverPort}}
+	}
+	policies := []*types.SecurityPolicy{
+		{ID: "not-ssh", Action: types.Allow, Enabled: true, Zone: "lan", Direction: "in", Quick: true, Protocol: "tcp",
+			Source: types.NetworkRange{CIDR: "10.10.0.6"}, Destination: anyRange(),
+			Ports: []types.Port{{Number: 22, Protocol: "tcp:SSH"}}, NegatePorts: true},
+		{ID: "https-from-ephemeral", Action: types.Allow, Enabled: true, Zone: "lan", Direction: "in", Quick: true, Protocol: "tcp",
+			Source: types.NetworkRange{CIDR: "10.10.0.5"}, Destination: anyRange(),
+			Ports:       []types.Port{{Number: 443, Protocol: "tcp:HTTPS"}},
+			SourcePorts: []types.Port{{Number: 1024, Protocol: "tcp:1024-65535"}}},
+		{ID: "implicit-default-deny", Action: types.Deny, Enabled: true, Zone: "all", Quick: true,
+			Source: anyRange(), Destination: anyRange()},
+	}
+	engine := analysis.NewPolicyEngine(policies, engineNetworks())
+
+	tests := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"negated port excludes it", &types.Flow{Source: "10.10.0.6", Destination: "10.20.0.10", Protocol: "SSH"}, "implicit-default-deny", false},
+		{"negated port matches others", &types.Flow{Source: "10.10.0.6", Destination: "10.20.0.10", Protocol: "Modbus TCP"}, "not-ssh", true},
+		{"source port in range", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Sessions: session(50000, 443)}, "https-from-ephemeral", true},
+		{"source port outside range", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Sessions: session(999, 443)}, "implicit-default-deny", false},
+		{"source port is not a destination port", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Sessions: session(443, 2000)}, "implicit-default-deny", false},
+		{"no known source port", &types.Flow{Source: "10.10.0.5", Destination: "10.20.0.10", Protocol: "HTTPS"}, "https-from-ephemeral", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := engine.Evaluate(tt.flow)
+			if decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+				t.Errorf("Expected rule %s (allowed=%v), got %s (allowed=%v)", tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+			}
+		})
+	}
+}
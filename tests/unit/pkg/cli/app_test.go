@@ -0,0 +1,27 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"cipgram/pkg/cli"
+)
+
+func TestFirewallOutputNames(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "audit")
+	files := []string{
+		filepath.Join(root, "site-a", "config.xml"),
+		filepath.Join(root, "site-b", "config.xml"),
+		filepath.Join(root, "fortigate.conf"),
+		filepath.Join(root, "archive", "site-a", "config.xml"),
+		filepath.Join(root, "rules.v4"),
+		filepath.Join(root, "rules.v4"),
+	}
+
+	// Shared file names take their parent directory, then a counter
+	expected := []string{"site-a_config_xml", "site-b_config_xml", "fortigate_conf", "site-a_config_xml_2", "audit_rules_v4", "audit_rules_v4_2"}
+	if names := cli.FirewallOutputNames(files); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
@@ -0,0 +1,124 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cipgram/pkg/cli"
+)
+
+func writeFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("placeholder"), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestParseArgs_ConfigAcceptsMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	opnsense := writeFile(t, dir, "site-a.xml")
+	fortigate := writeFile(t, dir, "site-b.conf")
+	iptables := writeFile(t, dir, "rules.v4")
+
+	config, err := cli.ParseArgs([]string{"config", opnsense, fortigate, iptables, "project", "SiteAudit", "no-images"})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+
+	if len(config.FirewallConfigs) != 3 || config.FirewallConfigs[2] != iptables {
+		t.Errorf("Expected 3 config files, got %v", config.FirewallConfigs)
+	}
+	if config.FirewallConfig != opnsense {
+		t.Errorf("Expected first file as FirewallConfig, got %s", config.FirewallConfig)
+	}
+	if config.ProjectName != "SiteAudit" || config.GenerateImages {
+		t.Errorf("Expected flags after the files to be parsed, got %+v", config)
+	}
+	if config.FirewallType != "" {
+		t.Errorf("Expected vendor to be left for auto-detection, got %q", config.FirewallType)
+	}
+}
+
+func TestParseArgs_ConfigSingleFileWithType(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "backup.conf")
+
+	config, err := cli.ParseArgs([]string{"config", path, "type", "fortigate"})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if len(config.FirewallConfigs) != 1 || config.FirewallType != "fortigate" {
+		t.Errorf("Unexpected config: files=%v type=%q", config.FirewallConfigs, config.FirewallType)
+	}
+}
+
+func TestParseArgs_ConfigRejectsMissingFile(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "site-a.xml")
+
+	if _, err := cli.ParseArgs([]string{"config", path, filepath.Join(t.TempDir(), "missing.conf")}); err == nil {
+		t.Error("Expected error for missing second config file")
+	}
+}
+
+func TestParseArgs_PcapAcceptsSeveralInputs(t *testing.T) {
+	span1 := t.TempDir()
+	span2 := t.TempDir()
+	rotated := writeFile(t, span2, "trace.pcap3")
+	writeFile(t, span2, "trace.pcap4")
+
+	config, err := cli.ParseArgs([]string{"pcap", span1, rotated, filepath.Join(span2, "trace.pcap*"), "fast"})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if len(config.PcapPaths) != 3 || config.PcapPath != span1 {
+		t.Errorf("Expected directory, rotated file and glob as inputs, got %v", config.PcapPaths)
+	}
+	if !config.FastMode {
+		t.Error("Expected flags after the inputs to be parsed")
+	}
+
+	// A glob names the project after its directory
+	config, err = cli.ParseArgs([]string{"pcap", filepath.Join(span2, "*.pcap*")})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if config.ProjectName != filepath.Base(span2) {
+		t.Errorf("Expected project %s, got %s", filepath.Base(span2), config.ProjectName)
+	}
+}
+
+func TestParseArgs_PcapWorkers(t *testing.T) {
+	capture := writeFile(t, t.TempDir(), "trace.pcap")
+
+	config, err := cli.ParseArgs([]string{"pcap", capture, "workers", "8"})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if config.Workers != 8 {
+		t.Errorf("Expected 8 workers, got %d", config.Workers)
+	}
+
+	if _, err := cli.ParseArgs([]string{"pcap", capture, "workers", "many"}); err == nil {
+		t.Error("Expected error for a non-numeric worker count")
+	}
+
+	config.Workers = -1
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for a negative worker count")
+	}
+}
+
+func TestParseArgs_CombinedRejectsUnusedFlags(t *testing.T) {
+	dir := t.TempDir()
+	capture := writeFile(t, dir, "trace.pcap")
+	firewall := writeFile(t, dir, "config.xml")
+
+	// The combined analysis neither picks a vendor nor parses in parallel yet
+	for _, flag := range [][]string{{"type", "opnsense"}, {"workers", "4"}} {
+		if _, err := cli.ParseArgs(append([]string{"combined", capture, firewall}, flag...)); err == nil {
+			t.Errorf("Expected %s to be rejected by the combined command", flag[0])
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package firewall_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cipgram/pkg/firewall"
+	"cipgram/pkg/types"
+)
+
+func TestDetectFirewallContent(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      types.InputType
+		version       string
+		minConfidence float32
+	}{
+		{
+			name:          "OPNsense",
+			content:       "<?xml version=\"1.0\"?>\n<opnsense>\n  <interfaces><lan/></interfaces>\n</opnsense>\n",
+			expected:      types.InputTypeOPNsense,
+			minConfidence: 0.9,
+		},
+		{
+			name:          "pfSense",
+			content:       "\xef\xbb\xbf<?xml version=\"1.0\"?>\n<pfsense>\n  <version>23.3</version>\n  <interfaces/>\n</pfsense>\n",
+			expected:      types.InputTypePfSense,
+			version:       "23.3",
+			minConfidence: 0.99,
+		},
+		{
+			name:          "FortiGate header",
+			content:       "#config-version=FGT60F-7.2.5-FW-build1517-230606:opmode=0:vdom=0:user=admin\n#conf_file_ver=1\nconfig system global\n    set hostname \"fw\"\nend\n",
+			expected:      types.InputTypeFortiGate,
+			version:       "7.2.5",
+			minConfidence: 0.99,
+		},
+		{
+			name:          "FortiGate without header",
+			content:       "config firewall address\n    edit \"PLC\"\n        set subnet 10.0.0.1 255.255.255.255\n    next\nend\n",
+			expected:      types.InputTypeFortiGate,
+			minConfidence: 0.7,
+		},
+		{
+			name:          "iptables-save",
+			content:       "# Generated by iptables-save v1.8.7 on Mon Oct  6 10:00:00 2025\n*filter\n:INPUT DROP [0:0]\n-A INPUT -i lo -j ACCEPT\nCOMMIT\n",
+			expected:      types.InputTypeIptables,
+			version:       "1.8.7",
+			minConfidence: 0.99,
+		},
+		{
+			name:          "iptables without header",
+			content:       "*filter\n:INPUT ACCEPT [0:0]\nCOMMIT\n",
+			expected:      types.InputTypeIptables,
+			minConfidence: 0.85,
+		},
+		{
+			name:          "VyOS config.boot",
+			content:       "interfaces {\n    ethernet eth0 {\n        address 10.0.0.1/24\n    }\n}\nsystem {\n    host-name vyos\n}\n// Warning: Do not remove the following line.\n// vyos-config-version: \"bgp@1:firewall@5\"\n// Release version: 1.3.2\n",
+			expected:      types.InputTypeVyatta,
+			version:       "1.3.2",
+			minConfidence: 0.99,
+		},
+		{
+			name:          "Vyatta config.boot",
+			content:       "firewall {\n}\ninterfaces {\n}\n/* === vyatta-config-version: \"firewall@4\" === */\n/* Release version: VyOS 1.1.8 */\n",
+			expected:      types.InputTypeVyatta,
+			version:       "1.1.8",
+			minConfidence: 0.99,
+		},
+		{
+			name:          "VyOS set commands",
+			content:       "set interfaces ethernet eth0 address '10.0.0.1/24'\nset firewall name WAN_IN default-action 'drop'\nset system host-name 'vyos'\n",
+			expected:      types.InputTypeVyatta,
+			minConfidence: 0.85,
+		},
+		{
+			name:          "Firewalla",
+			content:       "{\"version\": \"1.979\", \"interfaces\": [], \"networks\": [], \"rules\": [], \"vpns\": []}",
+			expected:      types.InputTypeFirewalla,
+			version:       "1.979",
+			minConfidence: 0.9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := firewall.DetectFirewallContent([]byte(tt.content))
+			if result == nil {
+				t.Fatal("Expected a detection result")
+			}
+			if result.Type != tt.expected {
+				t.Errorf("Expected %s, got %s (%s)", tt.expected, result.Type, result.Evidence)
+			}
+			if result.Version != tt.version {
+				t.Errorf("Expected version %q, got %q", tt.version, result.Version)
+			}
+			if result.Confidence < tt.minConfidence || result.Confidence > 1 {
+				t.Errorf("Expected confidence >= %.2f, got %.2f", tt.minConfidence, result.Confidence)
+			}
+		})
+	}
+}
+
+func TestDetectFirewallContent_Unknown(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"<html><body>not a firewall</body></html>",
+		"{\"name\": \"some other json\"}",
+		"hostname router\ninterface GigabitEthernet0/0\n",
+	} {
+		if result := firewall.DetectFirewallContent([]byte(content)); result != nil {
+			t.Errorf("Expected no detection for %q, got %+v", content, result)
+		}
+	}
+}
+
+func TestDetectFirewallType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.xml")
+	if err := os.WriteFile(path, []byte("<opnsense><interfaces/></opnsense>"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	firewallType, err := firewall.DetectFirewallType(path)
+	if err != nil || firewallType != types.InputTypeOPNsense {
+		t.Errorf("Expected opnsense, got %s (%v)", firewallType, err)
+	}
+
+	if _, err := firewall.DetectFirewallType(filepath.Join(t.TempDir(), "missing.xml")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
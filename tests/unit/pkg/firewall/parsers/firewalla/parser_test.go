@@ -0,0 +1,243 @@
+package firewalla_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/firewall/parsers/firewalla"
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/firewall/parsers/parsertest"
+)
+
+const sampleExport = `{
+  "version": "1.979",
+  "timestamp": "2025-10-06T10:00:00Z",
+  "interfaces": [
+    {"name": "eth0", "type": "ethernet", "ip": "198.51.100.20", "netmask": "255.255.255.0", "gateway": "198.51.100.1", "description": "Cellular uplink", "enabled": true},
+    {"name": "br0", "type": "ethernet", "ip": "192.168.20.1", "netmask": "255.255.255.0", "enabled": true},
+    {"name": "br0.30", "type": "vlan", "vlan": 30, "ip": "10.30.0.1", "netmask": "24", "enabled": true},
+    {"name": "eth2", "type": "ethernet", "ip": "172.16.0.1", "netmask": "255.255.0.0", "enabled": false}
+  ],
+  "networks": [
+    {"id": "lan", "name": "Office LAN", "cidr": "192.168.20.0/24", "interface": "br0", "type": "lan"},
+    {"id": "scada", "name": "Pump Station SCADA", "interface": "br0.30", "type": "iot", "isolation": true}
+  ],
+  "devices": [
+    {"id": "AA:BB:CC:00:00:01", "name": "PLC-1", "mac": "AA:BB:CC:00:00:01", "ip": "10.30.0.10", "network": "scada", "type": "plc", "vendor": "Schneider"},
+    {"id": "AA:BB:CC:00:00:02", "name": "RTU-1", "mac": "AA:BB:CC:00:00:02", "ip": "10.30.0.11", "type": "rtu"},
+    {"id": "AA:BB:CC:00:00:03", "name": "Engineering laptop", "mac": "AA:BB:CC:00:00:03", "ip": "192.168.20.50"}
+  ],
+  "groups": [
+    {"id": "g1", "name": "Controllers", "devices": ["AA:BB:CC:00:00:01", "AA:BB:CC:00:00:02"]}
+  ],
+  "policies": [
+    {"id": "p1", "name": "SCADA lockdown", "type": "security", "networks": ["scada"], "rules": ["r2"], "enabled": true},
+    {"id": "p2", "name": "Bandwidth", "type": "qos", "networks": ["lan"], "rules": ["r1"], "enabled": true}
+  ],
+  "rules": [
+    {"id": "r2", "name": "Block internet", "type": "block", "direction": "outbound",
+     "source": {"type": "any"}, "destination": {"type": "any"},
+     "service": {"type": "any"}, "priority": 20, "enabled": true},
+    {"id": "r1", "name": "Laptop to controllers", "type": "allow", "direction": "both",
+     "source": {"type": "device", "devices": ["AA:BB:CC:00:00:03"]},
+     "destination": {"type": "group", "groups": ["g1"]},
+     "service": {"type": "custom", "protocols": ["tcp"], "ports": ["502", "20000-20001"]},
+     "schedule": {"type": "time_range", "start_time": "08:00", "end_time": "17:00"},
+     "priority": 10, "enabled": true}
+  ],
+  "vpns": [
+    {"id": "wg0", "name": "Operator VPN", "type": "wireguard", "server_ip": "198.51.100.20", "server_port": 51820,
+     "networks": ["scada"], "enabled": true,
+     "clients": [
+       {"id": "c1", "name": "On-call phone", "ip": "10.6.0.2/32", "enabled": true},
+       {"id": "c2", "name": "Old laptop", "ip": "10.6.0.3/32", "enabled": false}
+     ]}
+  ],
+  "ids": {"enabled": true, "mode": "block", "blacklist_ips": ["203.0.113.66"]}
+}`
+
+func TestFirewallaParser_Validate(t *testing.T) {
+	parser := firewalla.NewFirewallaParser(parsertest.WriteFile(t, "firewalla.json", sampleExport))
+	if err := parser.Validate(); err != nil {
+		t.Fatalf("Expected valid export, got: %v", err)
+	}
+	if cfg := parser.GetConfig(); len(cfg.Devices) != 3 || len(cfg.Groups) != 1 {
+		t.Errorf("Expected devices and groups to be decoded, got %+v", cfg)
+	}
+
+	for name, content := range map[string]string{
+		"invalid json": `{"interfaces": [`,
+		"empty":        `{}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := firewalla.NewFirewallaParser(parsertest.WriteFile(t, "firewalla.json", content)).Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestFirewallaParser_Segments(t *testing.T) {
+	model, err := firewalla.NewFirewallaParser(parsertest.WriteFile(t, "firewalla.json", sampleExport)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	scada := model.Networks["scada"]
+	if scada == nil || scada.CIDR != "10.30.0.1/24" || scada.Name != "Pump Station SCADA (VLAN 30)" {
+		t.Fatalf("Unexpected SCADA segment: %+v", scada)
+	}
+	if scada.Zone != types.IndustrialZone || scada.Risk != types.HighRisk {
+		t.Errorf("Expected high-risk industrial zone, got %s/%s", scada.Zone, scada.Risk)
+	}
+	if len(scada.Assets) != 2 {
+		t.Errorf("Expected PLC and RTU (by CIDR) in SCADA segment, got %d assets", len(scada.Assets))
+	}
+
+	if lan := model.Networks["lan"]; lan == nil || lan.Zone != types.EnterpriseZone {
+		t.Errorf("Expected enterprise office LAN, got %+v", lan)
+	}
+	if wan := model.Networks["eth0"]; wan == nil || wan.Zone != types.DMZZone || wan.CIDR != "198.51.100.20/24" {
+		t.Errorf("Expected uplink as DMZ segment, got %+v", wan)
+	}
+	for _, skipped := range []string{"br0", "br0.30", "eth2"} {
+		if _, ok := model.Networks[skipped]; ok {
+			t.Errorf("Expected interface %s not to become its own segment", skipped)
+		}
+	}
+
+	if plc := model.Assets["10.30.0.10"]; plc == nil || plc.Vendor != "Schneider" || plc.IEC62443Zone != types.IndustrialZone {
+		t.Errorf("Unexpected PLC asset: %+v", plc)
+	}
+}
+
+func TestFirewallaParser_Rules(t *testing.T) {
+	model, err := firewalla.NewFirewallaParser(parsertest.WriteFile(t, "firewalla.json", sampleExport)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// The IDS block list comes first; priority 10 comes before priority 20; QoS policies do not scope rules
+	order := []string{"ids-blacklist", "r1", "r1-reverse", "p1/r2"}
+	for i, id := range order {
+		if model.Policies[i].ID != id {
+			t.Fatalf("Expected policy %d to be %s, got %s", i, id, model.Policies[i].ID)
+		}
+	}
+
+	laptop := parsertest.FindPolicy(model, "r1")
+	if laptop.Source.CIDR != "192.168.20.50" || laptop.Destination.CIDR != "Controllers" {
+		t.Errorf("Unexpected targets: %+v -> %+v", laptop.Source, laptop.Destination)
+	}
+	if len(laptop.Destination.IPs) != 2 {
+		t.Errorf("Expected group members resolved to IPs, got %v", laptop.Destination.IPs)
+	}
+	if len(laptop.Ports) != 2 || laptop.Ports[0].Protocol != "tcp:Modbus" || laptop.Ports[1].Protocol != "tcp:20000-20001" {
+		t.Errorf("Unexpected ports: %+v", laptop.Ports)
+	}
+	if laptop.Description != "Laptop to controllers [schedule 08:00-17:00]" {
+		t.Errorf("Unexpected description: %q", laptop.Description)
+	}
+
+	if reverse := parsertest.FindPolicy(model, "r1-reverse"); reverse.Source.CIDR != "Controllers" {
+		t.Errorf("Expected mirrored policy for bidirectional rule, got %+v", reverse.Source)
+	}
+
+	lockdown := parsertest.FindPolicy(model, "p1/r2")
+	if lockdown.Action != types.Drop || lockdown.Source.CIDR != "scada" || lockdown.Zone != "scada" {
+		t.Errorf("Expected block rule scoped to the SCADA network, got %+v", lockdown)
+	}
+
+	isolation := parsertest.FindPolicy(model, "network-scada-isolation")
+	if isolation == nil || isolation.Action != types.Drop || isolation.Destination.CIDR != "lan" {
+		t.Errorf("Unexpected isolation policy: %+v", isolation)
+	}
+
+	if ids := parsertest.FindPolicy(model, "ids-blacklist"); ids == nil || ids.Source.CIDR != "203.0.113.66" {
+		t.Errorf("Unexpected IDS policy: %+v", ids)
+	}
+
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"laptop to PLC", &types.Flow{Source: "192.168.20.50", Destination: "10.30.0.10", Protocol: "Modbus TCP"}, "r1", true},
+		{"PLC to laptop", &types.Flow{Source: "10.30.0.10", Destination: "192.168.20.50", Protocol: "DNP3"}, "r1-reverse", true},
+		{"PLC to the internet", &types.Flow{Source: "10.30.0.10", Destination: "8.8.8.8", Protocol: "HTTPS"}, "p1/r2", false},
+		{"office to PLC", &types.Flow{Source: "192.168.20.9", Destination: "10.30.0.10", Protocol: "Modbus TCP"}, "implicit-default-deny", false},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
+
+func TestFirewallaParser_IDSAheadOfRules(t *testing.T) {
+	export := `{
+  "networks": [
+    {"id": "scada", "name": "SCADA", "cidr": "10.30.0.0/24", "type": "iot"}
+  ],
+  "devices": [
+    {"id": "AA:BB:CC:00:00:01", "name": "PLC-1", "mac": "AA:BB:CC:00:00:01", "ip": "10.30.0.10", "network": "scada", "type": "plc"}
+  ],
+  "rules": [
+    {"id": "r1", "name": "Remote monitoring", "type": "allow", "direction": "inbound",
+     "source": {"type": "any"}, "destination": {"type": "device", "devices": ["AA:BB:CC:00:00:01"]},
+     "service": {"type": "custom", "protocols": ["tcp"], "ports": ["502"]}, "priority": 10, "enabled": true}
+  ],
+  "ids": {"enabled": true, "mode": "block", "blacklist_ips": ["203.0.113.66"]}
+}`
+	model, err := firewalla.NewFirewallaParser(parsertest.WriteFile(t, "firewalla.json", export)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"blacklisted address allowed by a rule", &types.Flow{Source: "203.0.113.66", Destination: "10.30.0.10", Protocol: "Modbus TCP"}, "ids-blacklist", false},
+		{"other address allowed by the rule", &types.Flow{Source: "198.51.100.7", Destination: "10.30.0.10", Protocol: "Modbus TCP"}, "r1", true},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
+
+func TestFirewallaParser_VPN(t *testing.T) {
+	model, err := firewalla.NewFirewallaParser(parsertest.WriteFile(t, "firewalla.json", sampleExport)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	vpn := model.Networks["vpn-wg0"]
+	if vpn == nil || vpn.Zone != types.RemoteAccessZone {
+		t.Fatalf("Expected Remote Access Zone segment, got %+v", vpn)
+	}
+	if len(vpn.Assets) != 1 || vpn.Assets[0].IP != "10.6.0.2" {
+		t.Errorf("Expected only the enabled client, got %+v", vpn.Assets)
+	}
+
+	listener := parsertest.FindPolicy(model, "vpn-wg0-listener")
+	if listener == nil || len(listener.Ports) != 1 || listener.Ports[0].Protocol != "udp:WireGuard" {
+		t.Errorf("Unexpected listener policy: %+v", listener)
+	}
+
+	if listener != nil && !listener.Local {
+		t.Error("Expected listener to filter traffic to the Firewalla itself")
+	}
+
+	access := parsertest.FindPolicy(model, "vpn-wg0-access")
+	if access == nil || access.Source.CIDR != "vpn-wg0" || access.Destination.CIDR != "scada" {
+		t.Errorf("Unexpected access policy: %+v", access)
+	}
+	if decision := parsertest.Evaluate(model, &types.Flow{Source: "10.6.0.2", Destination: "10.30.0.10", Protocol: "Modbus TCP"}); decision.RuleID != "vpn-wg0-access" {
+		t.Errorf("Expected VPN client access to the SCADA network, got %s", decision.RuleID)
+	}
+}
@@ -0,0 +1,396 @@
+package fortigate_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/firewall/parsers/fortigate"
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/firewall/parsers/parsertest"
+)
+
+const sampleConfig = `#config-version=FGT60F-7.2.5-FW-build1517-230606:opmode=0:vdom=0:user=admin
+#conf_file_ver=1234567890
+#buildno=1517
+config system global
+    set hostname "PLANT1-FW"
+end
+config system interface
+    edit "wan1"
+        set ip 203.0.113.2 255.255.255.248
+        set allowaccess ping https
+        set type physical
+        set role wan
+    next
+    edit "internal1"
+        set ip 10.10.1.1 255.255.255.0
+        set alias "OT Control"
+        set type physical
+        set role lan
+    next
+    edit "internal2"
+        set ip 10.20.0.1/24
+        set description "Corporate office"
+        set role lan
+    next
+    edit "internal3"
+        set status down
+        set ip 10.30.0.1 255.255.255.0
+    next
+end
+config system zone
+    edit "OT-ZONE"
+        set interface "internal1"
+    next
+end
+config firewall address
+    edit "all"
+    next
+    edit "PLC-1"
+        set subnet 10.10.1.10 255.255.255.255
+    next
+    edit "PLC-2"
+        set subnet 10.10.1.11 255.255.255.255
+    next
+    edit "Historian"
+        set subnet 10.20.0.50 255.255.255.255
+        set comment "Plant historian"
+    next
+    edit "Vendor range"
+        set type iprange
+        set start-ip 10.20.0.100
+        set end-ip 10.20.0.120
+    next
+end
+config firewall addrgrp
+    edit "PLCs"
+        set member "PLC-1" "PLC-2"
+    next
+end
+config firewall service custom
+    edit "Modbus"
+        set tcp-portrange 502
+    next
+    edit "ENIP"
+        set tcp-portrange 44818
+        set udp-portrange 2222 44818
+    next
+end
+config firewall service group
+    edit "OT-Services"
+        set member "Modbus" "ENIP"
+    next
+end
+config firewall policy
+    edit 1
+        set name "Historian to PLCs"
+        set srcintf "internal2"
+        set dstintf "OT-ZONE"
+        set srcaddr "Historian"
+        set dstaddr "PLCs"
+        set action accept
+        set schedule "always"
+        set service "OT-Services"
+    next
+    edit 2
+        set srcintf "internal2"
+        set dstintf "wan1"
+        set srcaddr "all"
+        set dstaddr "all"
+        set action accept
+        set service "HTTPS"
+        set nat enable
+        set status disable
+        set comments "Temporary internet access"
+    next
+    edit 3
+        set srcintf "wan1"
+        set dstintf "OT-ZONE"
+        set srcaddr "all"
+        set dstaddr "PLCs"
+        set service "ALL"
+    next
+end
+config router static
+    edit 1
+        set gateway 203.0.113.1
+        set device "wan1"
+    next
+    edit 2
+        set dst 172.16.0.0 255.255.0.0
+        set gateway 10.20.0.254
+        set device "internal2"
+        set distance 20
+    next
+end
+`
+
+func TestFortiGateParser_Validate(t *testing.T) {
+	parser := fortigate.NewFortiGateParser(parsertest.WriteFile(t, "fortigate.conf", sampleConfig))
+	if err := parser.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+
+	cfg := parser.GetConfig()
+	if cfg.Hostname != "PLANT1-FW" {
+		t.Errorf("Expected hostname PLANT1-FW, got %s", cfg.Hostname)
+	}
+	if cfg.Version != "7.2.5" || cfg.Model != "FGT60F" {
+		t.Errorf("Expected FGT60F 7.2.5, got %s %s", cfg.Model, cfg.Version)
+	}
+	if len(cfg.Routes) != 2 {
+		t.Fatalf("Expected 2 static routes, got %d", len(cfg.Routes))
+	}
+	if cfg.Routes[0].Destination != "0.0.0.0/0" || cfg.Routes[1].Destination != "172.16.0.0/16" {
+		t.Errorf("Unexpected route destinations: %+v", cfg.Routes)
+	}
+	if cfg.Routes[1].Distance != 20 {
+		t.Errorf("Expected distance 20, got %d", cfg.Routes[1].Distance)
+	}
+}
+
+func TestFortiGateParser_ValidateRejectsBrokenConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unterminated block", "config system interface\n    edit \"port1\"\n    next\n"},
+		{"stray end", "end\n"},
+		{"no interfaces or policies", "config system global\n    set hostname \"x\"\nend\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := fortigate.NewFortiGateParser(parsertest.WriteFile(t, "fortigate.conf", tt.content))
+			if err := parser.Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestFortiGateParser_ParseSegments(t *testing.T) {
+	parser := fortigate.NewFortiGateParser(parsertest.WriteFile(t, "fortigate.conf", sampleConfig))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if model.Metadata.Type != types.InputTypeFortiGate {
+		t.Errorf("Expected metadata type fortigate, got %s", model.Metadata.Type)
+	}
+
+	if len(model.Networks) != 3 {
+		t.Fatalf("Expected 3 segments (down interface skipped), got %d", len(model.Networks))
+	}
+
+	tests := []struct {
+		id   string
+		name string
+		cidr string
+		zone types.IEC62443Zone
+	}{
+		{"wan1", "wan1", "203.0.113.2/29", types.DMZZone},
+		{"internal1", "OT Control", "10.10.1.1/24", types.IndustrialZone},
+		{"internal2", "Corporate office", "10.20.0.1/24", types.EnterpriseZone},
+	}
+
+	for _, tt := range tests {
+		segment, ok := model.Networks[tt.id]
+		if !ok {
+			t.Errorf("Missing segment %s", tt.id)
+			continue
+		}
+		if segment.Name != tt.name {
+			t.Errorf("%s: expected name %q, got %q", tt.id, tt.name, segment.Name)
+		}
+		if segment.CIDR != tt.cidr {
+			t.Errorf("%s: expected CIDR %s, got %s", tt.id, tt.cidr, segment.CIDR)
+		}
+		if segment.Zone != tt.zone {
+			t.Errorf("%s: expected zone %s, got %s", tt.id, tt.zone, segment.Zone)
+		}
+	}
+}
+
+func TestFortiGateParser_ParsePolicies(t *testing.T) {
+	parser := fortigate.NewFortiGateParser(parsertest.WriteFile(t, "fortigate.conf", sampleConfig))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Three configured policies plus the implicit deny
+	if len(model.Policies) != 4 {
+		t.Fatalf("Expected 4 policies, got %d", len(model.Policies))
+	}
+
+	first := model.Policies[0]
+	if first.ID != "policy-1" || first.Action != types.Allow || !first.Enabled {
+		t.Errorf("Unexpected first policy: %+v", first)
+	}
+	if first.Source.CIDR != "10.20.0.50/32" {
+		t.Errorf("Expected single address to resolve to its CIDR, got %s", first.Source.CIDR)
+	}
+	if first.Destination.CIDR != "PLCs" || len(first.Destination.IPs) != 2 {
+		t.Errorf("Expected group to resolve to 2 members, got %+v", first.Destination)
+	}
+	if first.Protocol != "tcp/udp" {
+		t.Errorf("Expected protocol tcp/udp, got %s", first.Protocol)
+	}
+	if len(first.Ports) != 4 {
+		t.Errorf("Expected 4 ports from service group, got %d: %+v", len(first.Ports), first.Ports)
+	}
+	if first.Zone != "internal2" || first.Direction != "in" || first.EgressZone != "internal1" {
+		t.Errorf("Expected internal2 ingress and OT-ZONE members as egress, got %q/%q -> %q",
+			first.Zone, first.Direction, first.EgressZone)
+	}
+
+	second := model.Policies[1]
+	if second.Enabled {
+		t.Error("Expected disabled policy to be marked disabled")
+	}
+	if second.Description != "Temporary internet access" {
+		t.Errorf("Expected comments as description, got %q", second.Description)
+	}
+	if second.Source.CIDR != "any" || second.Protocol != "tcp" {
+		t.Errorf("Unexpected second policy: %+v", second)
+	}
+
+	third := model.Policies[2]
+	if third.Action != types.Deny {
+		t.Errorf("Expected policy without action to default to deny, got %s", third.Action)
+	}
+	if third.Protocol != "any" || len(third.Ports) != 0 {
+		t.Errorf("Expected ALL service to match any protocol, got %s %+v", third.Protocol, third.Ports)
+	}
+
+	if model.Policies[3].ID != "implicit-default-deny" {
+		t.Errorf("Expected implicit deny last, got %s", model.Policies[3].ID)
+	}
+
+	// Policies are attached to the segments of their source interfaces
+	if len(model.Networks["internal2"].Policies) != 2 {
+		t.Errorf("Expected 2 policies on internal2, got %d", len(model.Networks["internal2"].Policies))
+	}
+
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"historian to PLC", &types.Flow{Source: "10.20.0.50", Destination: "10.10.1.10", Protocol: "Modbus TCP"}, "policy-1", true},
+		{"PLC to historian enters the wrong interface", &types.Flow{Source: "10.10.1.10", Destination: "10.20.0.50", Protocol: "Modbus TCP"}, "implicit-default-deny", false},
+		{"historian outside the OT services", &types.Flow{Source: "10.20.0.50", Destination: "10.10.1.10", Protocol: "HTTPS"}, "implicit-default-deny", false},
+		{"internet to PLC", &types.Flow{Source: "198.51.100.7", Destination: "10.10.1.11", Protocol: "Modbus TCP"}, "policy-3", false},
+		{"disabled internet access", &types.Flow{Source: "10.20.0.60", Destination: "198.51.100.7", Protocol: "HTTPS"}, "implicit-default-deny", false},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
+
+func TestFortiGateParser_DHCPInterface(t *testing.T) {
+	content := `#config-version=FGT40F-7.2.5-FW-build1517-230606:opmode=0:vdom=0:user=admin
+config system interface
+    edit "wan1"
+        set mode dhcp
+        set role wan
+    next
+    edit "internal1"
+        set ip 10.10.1.1 255.255.255.0
+        set role lan
+    next
+    edit "modem"
+        set mode pppoe
+        set status down
+    next
+    edit "ssl.root"
+        set type tunnel
+    next
+end
+config firewall policy
+    edit 1
+        set srcintf "wan1"
+        set dstintf "internal1"
+        set srcaddr "all"
+        set dstaddr "all"
+        set action accept
+        set service "HTTPS"
+    next
+end
+`
+	model, err := fortigate.NewFortiGateParser(parsertest.WriteFile(t, "fortigate.conf", content)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(model.Networks) != 2 {
+		t.Fatalf("Expected wan1 and internal1 (down and unaddressed interfaces skipped), got %d segments", len(model.Networks))
+	}
+	wan := model.Networks["wan1"]
+	if wan == nil || wan.CIDR != "" || len(wan.Policies) != 1 {
+		t.Fatalf("Expected DHCP wan1 as a segment without CIDR holding its policy, got %+v", wan)
+	}
+
+	flow := &types.Flow{Source: "198.51.100.7", Destination: "10.10.1.10", Protocol: "HTTPS"}
+	if decision := parsertest.Evaluate(model, flow); decision.RuleID != "policy-1" || !decision.Allowed {
+		t.Errorf("Expected internet traffic entering wan1 to match policy-1, got %s (allowed=%v)", decision.RuleID, decision.Allowed)
+	}
+}
+
+func TestFortiGateParser_QuotedAndMultiVDOM(t *testing.T) {
+	content := `#config-version=FG100F-7.4.1-FW-build2463-230830:opmode=0:vdom=1:user=admin
+config vdom
+    edit "root"
+    next
+end
+config global
+    config system global
+        set hostname "SITE \"B\""
+    end
+end
+config vdom
+    edit "root"
+        config system interface
+            edit "port1"
+                set ip 192.168.10.1 255.255.255.0
+                set description "line 1
+line 2"
+            next
+        end
+        config firewall policy
+            edit 7
+                set srcintf "port1"
+                set dstintf "port1"
+                set srcaddr "all"
+                set dstaddr "all"
+                set action accept
+                set service "Unknown Service"
+            next
+        end
+    next
+end
+`
+	parser := fortigate.NewFortiGateParser(parsertest.WriteFile(t, "fortigate.conf", content))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parser.GetConfig().Hostname != `SITE "B"` {
+		t.Errorf("Expected escaped hostname, got %q", parser.GetConfig().Hostname)
+	}
+	if segment, ok := model.Networks["port1"]; !ok || segment.Name != "line 1\nline 2" {
+		t.Errorf("Expected multi-line description on port1, got %+v", model.Networks["port1"])
+	}
+	if len(model.Policies) != 2 || model.Policies[0].ID != "policy-7" {
+		t.Fatalf("Expected VDOM policy to be parsed, got %d policies", len(model.Policies))
+	}
+	ports := model.Policies[0].Ports
+	if len(ports) != 1 || ports[0].Protocol != "any:Unknown Service" {
+		t.Errorf("Expected unresolved service kept by name, got %+v", ports)
+	}
+}
@@ -0,0 +1,347 @@
+package iptables_test
+
+import (
+	"strings"
+	"testing"
+
+	"cipgram/pkg/firewall/parsers/iptables"
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/firewall/parsers/parsertest"
+)
+
+const sampleSave = `# Generated by iptables-save v1.8.7 on Mon Oct  6 10:00:00 2025
+*nat
+:PREROUTING ACCEPT [0:0]
+:POSTROUTING ACCEPT [0:0]
+[5:300] -A POSTROUTING -o eth0 -j MASQUERADE
+COMMIT
+*filter
+:INPUT DROP [120:7200]
+:FORWARD DROP [42:2520]
+:OUTPUT ACCEPT [900:81000]
+:OT_IN - [0:0]
+[800:64000] -A INPUT -i lo -j ACCEPT
+[10:600] -A INPUT -i eth1 -p tcp -m tcp --dport 22 -m conntrack --ctstate NEW -j ACCEPT
+[3000:240000] -A FORWARD -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+[25:1500] -A FORWARD -s 10.20.0.0/24 -i eth1 -o eth2 -j OT_IN
+[0:0] -A FORWARD ! -s 10.30.0.0/24 -i eth3 -p udp -m multiport --dports 2222,44818,47808:47810 -j DROP
+[0:0] -A FORWARD -m iprange --src-range 10.20.0.100-10.20.0.120 -j LOG --log-prefix "vendor "
+[20:1200] -A OT_IN -d 10.10.1.0/24 -p tcp -m tcp --dport 502 -m comment --comment "Historian to PLC Modbus" -j ACCEPT
+[5:300] -A OT_IN -j RETURN
+[0:0] -A OT_IN -j REJECT --reject-with icmp-port-unreachable
+COMMIT
+# Completed on Mon Oct  6 10:00:00 2025
+# Generated by ip6tables-save v1.8.7 on Mon Oct  6 10:00:00 2025
+*filter
+:INPUT DROP [0:0]
+:FORWARD DROP [0:0]
+:OUTPUT ACCEPT [0:0]
+[0:0] -A INPUT -p ipv6-icmp -j ACCEPT
+COMMIT
+`
+
+func TestIptablesParser_Validate(t *testing.T) {
+	parser := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", sampleSave))
+	if err := parser.Validate(); err != nil {
+		t.Fatalf("Expected valid rules, got: %v", err)
+	}
+
+	cfg := parser.GetConfig()
+	if !cfg.HasCounters {
+		t.Error("Expected rule counters to be detected")
+	}
+	if len(cfg.Tables) != 3 {
+		t.Fatalf("Expected nat, filter and ip6:filter tables, got %v", cfg.TableOrder)
+	}
+
+	filter := cfg.Tables["filter"]
+	if filter.Policies["FORWARD"] != "DROP" {
+		t.Errorf("Expected FORWARD policy DROP, got %s", filter.Policies["FORWARD"])
+	}
+	if filter.Chains["OT_IN"].Type != "user-defined" {
+		t.Errorf("Expected OT_IN to be user-defined")
+	}
+
+	rule := filter.Chains["FORWARD"].Rules[2]
+	if rule.Source.Address != "!10.30.0.0/24" {
+		t.Errorf("Expected negated source, got %s", rule.Source.Address)
+	}
+	if len(rule.Match.Multiport.DestPorts) != 3 {
+		t.Errorf("Expected 3 multiport entries, got %v", rule.Match.Multiport.DestPorts)
+	}
+
+	logRule := filter.Chains["FORWARD"].Rules[3]
+	if logRule.Target != "LOG" || len(logRule.TargetOptions) != 2 || logRule.TargetOptions[1] != "vendor " {
+		t.Errorf("Unexpected LOG target parsing: %+v", logRule)
+	}
+}
+
+func TestIptablesParser_ValidateRejectsBrokenInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing commit", "*filter\n:INPUT ACCEPT [0:0]\n"},
+		{"undeclared chain", "*filter\n:INPUT ACCEPT [0:0]\n-A NOPE -j ACCEPT\nCOMMIT\n"},
+		{"rule outside table", "-A INPUT -j ACCEPT\n"},
+		{"empty", "# nothing here\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", tt.content))
+			if err := parser.Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestIptablesParser_ParsePolicies(t *testing.T) {
+	parser := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", sampleSave))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Rule order follows evaluation order, with OT_IN inlined at its jump up to its RETURN
+	expected := []string{
+		"INPUT-1", "INPUT-2", "INPUT-policy",
+		"FORWARD-1", "FORWARD-2/OT_IN-1", "FORWARD-3", "FORWARD-4", "FORWARD-policy",
+		"OUTPUT-policy",
+		"ip6-INPUT-1", "ip6-INPUT-policy", "ip6-FORWARD-policy", "ip6-OUTPUT-policy",
+	}
+	if len(model.Policies) != len(expected) {
+		ids := []string{}
+		for _, policy := range model.Policies {
+			ids = append(ids, policy.ID)
+		}
+		t.Fatalf("Expected policies %v, got %v", expected, ids)
+	}
+	for i, id := range expected {
+		if model.Policies[i].ID != id {
+			t.Errorf("Policy %d: expected %s, got %s", i, id, model.Policies[i].ID)
+		}
+	}
+
+	// Jump rule matches are inherited by the called chain
+	modbus := parsertest.FindPolicy(model, "FORWARD-2/OT_IN-1")
+	if modbus.Source.CIDR != "10.20.0.0/24" || modbus.Destination.CIDR != "10.10.1.0/24" {
+		t.Errorf("Unexpected addresses: %+v -> %+v", modbus.Source, modbus.Destination)
+	}
+	if modbus.Action != types.Allow || modbus.Protocol != "tcp" {
+		t.Errorf("Unexpected Modbus policy: %+v", modbus)
+	}
+	if modbus.Zone != "eth1" || modbus.Direction != "in" || modbus.EgressZone != "eth2" || modbus.Local {
+		t.Errorf("Expected forwarded rule bound to eth1 -> eth2, got %q/%q -> %q (local=%v)",
+			modbus.Zone, modbus.Direction, modbus.EgressZone, modbus.Local)
+	}
+	if len(modbus.Ports) != 1 || modbus.Ports[0].Number != 502 || modbus.Ports[0].Protocol != "tcp:Modbus" {
+		t.Errorf("Unexpected ports: %+v", modbus.Ports)
+	}
+	if modbus.Description != "Historian to PLC Modbus" {
+		t.Errorf("Expected comment as description, got %q", modbus.Description)
+	}
+
+	multiport := parsertest.FindPolicy(model, "FORWARD-3")
+	if multiport.Action != types.Drop || len(multiport.Ports) != 3 {
+		t.Errorf("Unexpected multiport policy: %+v", multiport)
+	}
+	if multiport.Ports[2].Protocol != "udp:47808-47810" {
+		t.Errorf("Expected port range, got %s", multiport.Ports[2].Protocol)
+	}
+
+	vendor := parsertest.FindPolicy(model, "FORWARD-4")
+	if vendor.Action != types.Log || vendor.Source.CIDR != "10.20.0.100-10.20.0.120" {
+		t.Errorf("Unexpected iprange policy: %+v", vendor)
+	}
+
+	established := parsertest.FindPolicy(model, "FORWARD-1")
+	if established.Description != "ctstate RELATED,ESTABLISHED" {
+		t.Errorf("Expected conntrack state description, got %q", established.Description)
+	}
+	if len(established.States) != 2 || established.Zone != "any" {
+		t.Errorf("Expected unbound rule limited to RELATED,ESTABLISHED, got zone %q states %v",
+			established.Zone, established.States)
+	}
+
+	// INPUT and OUTPUT filter the firewall's own traffic
+	for _, id := range []string{"INPUT-1", "INPUT-2", "INPUT-policy", "OUTPUT-policy", "ip6-INPUT-1"} {
+		if policy := parsertest.FindPolicy(model, id); !policy.Local {
+			t.Errorf("Expected %s to be a local rule", id)
+		}
+	}
+	if ssh := parsertest.FindPolicy(model, "INPUT-2"); ssh.Zone != "eth1" || ssh.Direction != "in" {
+		t.Errorf("Expected INPUT rule bound to eth1 ingress, got %q/%q", ssh.Zone, ssh.Direction)
+	}
+
+	defaultPolicy := parsertest.FindPolicy(model, "FORWARD-policy")
+	if defaultPolicy.Action != types.Drop || defaultPolicy.Hits.Packets != 42 || defaultPolicy.Local {
+		t.Errorf("Unexpected FORWARD default policy: %+v", defaultPolicy)
+	}
+
+	// Every policy is limited to the address family of its table
+	for _, policy := range model.Policies {
+		family := "inet"
+		if strings.HasPrefix(policy.ID, "ip6-") {
+			family = "inet6"
+		}
+		if policy.IPFamily != family {
+			t.Errorf("Expected %s to be limited to %s, got %q", policy.ID, family, policy.IPFamily)
+		}
+	}
+}
+
+func TestIptablesParser_Evaluate(t *testing.T) {
+	parser := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", sampleSave))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// The historian opened the connection, so the PLC's packets are replies
+	session := []types.SessionKey{{Transport: "tcp", Client: "10.20.0.5", ClientPort: 50000, Server: "10.10.1.10", ServerPort: 502}}
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"historian to PLC", &types.Flow{Source: "10.20.0.5", Destination: "10.10.1.10", Protocol: "Modbus TCP", Sessions: session}, "FORWARD-2/OT_IN-1", true},
+		{"PLC replies", &types.Flow{Source: "10.10.1.10", Destination: "10.20.0.5", Protocol: "Modbus TCP", Sessions: session}, "FORWARD-1", true},
+		{"new connection is not established", &types.Flow{Source: "10.20.0.5", Destination: "10.10.1.10", Protocol: "HTTP"}, "FORWARD-policy", false},
+		{"unmatched forward", &types.Flow{Source: "10.10.1.10", Destination: "10.20.0.5", Protocol: "Modbus TCP"}, "FORWARD-policy", false},
+		{"firewall SSH rule ignores transit", &types.Flow{Source: "10.20.0.5", Destination: "198.51.100.7", Protocol: "SSH"}, "FORWARD-policy", false},
+		{"IPv6 forward meets the ip6tables policy", &types.Flow{Source: "fd00::5", Destination: "fd00:1::10", Protocol: "Modbus TCP"}, "ip6-FORWARD-policy", false},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
+
+func TestIptablesParser_ReturnAndNegation(t *testing.T) {
+	rules := `*filter
+:INPUT ACCEPT [0:0]
+:FORWARD DROP [0:0]
+:OUTPUT ACCEPT [0:0]
+:CHK - [0:0]
+-A FORWARD -s 10.1.0.0/16 -d 10.9.0.0/16 -i eth1 -o eth2 -j CHK
+-A FORWARD -s 10.1.0.9/32 -p udp -m udp --dport 161 -j ACCEPT
+-A FORWARD -s 10.2.0.0/16 -j ACCEPT
+-A CHK -s 10.1.0.9/32 -j RETURN
+-A CHK -p udp -j ACCEPT
+-A CHK -p tcp -m multiport ! --sports 1024:65535 -j REJECT
+-A CHK -p tcp -m tcp ! --dport 22 -m conntrack ! --ctstate INVALID -j ACCEPT
+COMMIT
+`
+	model, err := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", rules)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Traffic returned by CHK-1 meets the rest of FORWARD before the rules below the RETURN;
+	// FORWARD-3 cannot match it, as 10.1.0.9 is outside 10.2.0.0/16
+	expected := []string{
+		"INPUT-policy",
+		"FORWARD-1/CHK-1/FORWARD-2", "FORWARD-1/CHK-1/FORWARD-policy",
+		"FORWARD-1/CHK-2", "FORWARD-1/CHK-3", "FORWARD-1/CHK-4", "FORWARD-2", "FORWARD-3", "FORWARD-policy",
+		"OUTPUT-policy",
+	}
+	ids := []string{}
+	for _, policy := range model.Policies {
+		ids = append(ids, policy.ID)
+	}
+	if strings.Join(ids, " ") != strings.Join(expected, " ") {
+		t.Fatalf("Expected policies %v, got %v", expected, ids)
+	}
+
+	if returned := parsertest.FindPolicy(model, "FORWARD-1/CHK-1/FORWARD-policy"); returned.Source.CIDR != "10.1.0.9/32" || returned.Action != types.Drop {
+		t.Errorf("Expected the FORWARD policy limited to the returned source, got %+v", returned)
+	}
+	privileged := parsertest.FindPolicy(model, "FORWARD-1/CHK-3")
+	if !privileged.NegateSourcePorts || len(privileged.SourcePorts) != 1 || privileged.SourcePorts[0].Protocol != "tcp:1024-65535" {
+		t.Errorf("Expected negated source ports 1024-65535, got %+v", privileged)
+	}
+	notSSH := parsertest.FindPolicy(model, "FORWARD-1/CHK-4")
+	if !notSSH.NegatePorts || len(notSSH.Ports) != 1 || notSSH.Ports[0].Number != 22 {
+		t.Errorf("Expected negated port 22, got %+v", notSSH.Ports)
+	}
+	if strings.Join(notSSH.States, ",") != "NEW,ESTABLISHED,RELATED,UNTRACKED" {
+		t.Errorf("Expected every state but INVALID, got %v", notSSH.States)
+	}
+
+	session := func(client string, clientPort, serverPort uint16) []types.SessionKey {
+		return []types.SessionKey{{Transport: "tcp", Client: client, ClientPort: clientPort, Server: "10.9.0.5", ServerPort: serverPort}}
+	}
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"returned UDP skips the UDP accept", &types.Flow{Source: "10.1.0.9", Destination: "10.9.0.5", Protocol: "DNS"}, "FORWARD-1/CHK-1/FORWARD-policy", false},
+		{"returned SNMP accepted by FORWARD", &types.Flow{Source: "10.1.0.9", Destination: "10.9.0.5", Protocol: "SNMP"}, "FORWARD-1/CHK-1/FORWARD-2", true},
+		{"other UDP accepted by CHK", &types.Flow{Source: "10.1.0.7", Destination: "10.9.0.5", Protocol: "DNS"}, "FORWARD-1/CHK-2", true},
+		{"privileged source port rejected", &types.Flow{Source: "10.1.0.7", Destination: "10.9.0.5", Protocol: "HTTPS", Sessions: session("10.1.0.7", 80, 443)}, "FORWARD-1/CHK-3", false},
+		{"HTTPS passes the negated port", &types.Flow{Source: "10.1.0.7", Destination: "10.9.0.5", Protocol: "HTTPS", Sessions: session("10.1.0.7", 50000, 443)}, "FORWARD-1/CHK-4", true},
+		{"SSH excluded by the negated port", &types.Flow{Source: "10.1.0.7", Destination: "10.9.0.5", Protocol: "SSH", Sessions: session("10.1.0.7", 50000, 22)}, "FORWARD-policy", false},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
+
+func TestIptablesParser_Counters(t *testing.T) {
+	parser := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", sampleSave))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	used := parsertest.FindPolicy(model, "FORWARD-2/OT_IN-1")
+	if used.Hits == nil || used.Hits.Packets != 20 || used.Hits.Bytes != 1200 {
+		t.Errorf("Expected 20 packets / 1200 bytes, got %+v", used.Hits)
+	}
+
+	unused := parsertest.FindPolicy(model, "FORWARD-3")
+	if unused.Hits == nil || unused.Hits.Packets != 0 {
+		t.Errorf("Expected zero-hit counters on unused rule, got %+v", unused.Hits)
+	}
+
+	// Without -c the rules carry no counters at all
+	noCounters := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", "*filter\n:INPUT ACCEPT [0:0]\n-A INPUT -i eth1 -j ACCEPT\nCOMMIT\n"))
+	model, err = noCounters.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if model.Policies[0].Hits != nil {
+		t.Errorf("Expected nil hits without -c, got %+v", model.Policies[0].Hits)
+	}
+}
+
+func TestIptablesParser_Segments(t *testing.T) {
+	parser := iptables.NewIptablesParser(parsertest.WriteFile(t, "rules.v4", sampleSave))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if _, ok := model.Networks["lo"]; ok {
+		t.Error("Loopback should not become a segment")
+	}
+	for _, iface := range []string{"eth1", "eth2", "eth3"} {
+		if _, ok := model.Networks[iface]; !ok {
+			t.Errorf("Missing segment for %s", iface)
+		}
+	}
+	if cidr := model.Networks["eth1"].CIDR; cidr != "10.20.0.0/24" {
+		t.Errorf("Expected eth1 CIDR from its first -s match, got %q", cidr)
+	}
+	if cidr := model.Networks["eth3"].CIDR; cidr != "" {
+		t.Errorf("Negated source must not define a CIDR, got %q", cidr)
+	}
+}
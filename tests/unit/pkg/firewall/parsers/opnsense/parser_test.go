@@ -0,0 +1,419 @@
+package opnsense_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/firewall/parsers/opnsense"
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/firewall/parsers/parsertest"
+)
+
+const aliasConfig = `<?xml version="1.0"?>
+<opnsense>
+	<interfaces>
+		<lan>
+			<if>igb1</if>
+			<descr>LAN_CORPORATE</descr>
+			<ipaddr>10.10.0.1</ipaddr>
+			<subnet>24</subnet>
+		</lan>
+		<opt1>
+			<if>igb2</if>
+			<descr>OT_CELL</descr>
+			<ipaddr>10.20.0.1</ipaddr>
+			<subnet>24</subnet>
+			<enable>1</enable>
+		</opt1>
+	</interfaces>
+	<aliases>
+		<alias>
+			<name>CORP_NET</name>
+			<type>network</type>
+			<address>10.10.0.0/24</address>
+			<descr>Corporate LAN</descr>
+		</alias>
+		<alias>
+			<name>PLCS</name>
+			<type>host</type>
+			<address>10.20.0.10,10.20.0.11</address>
+		</alias>
+		<alias>
+			<name>OT_DEVICES</name>
+			<type>host</type>
+			<address>PLCS,10.20.0.50</address>
+		</alias>
+		<alias>
+			<name>OT_PORTS</name>
+			<type>port</type>
+			<address>502,44818,2222:2223</address>
+		</alias>
+		<alias>
+			<name>BAD_IPS</name>
+			<type>urltable</type>
+			<address>https://example.com/drop.txt</address>
+		</alias>
+	</aliases>
+	<filter>
+		<rule uuid="r1">
+			<type>pass</type>
+			<interface>lan</interface>
+			<protocol>tcp</protocol>
+			<source>
+				<network>lan</network>
+			</source>
+			<destination>
+				<address>OT_DEVICES</address>
+			</destination>
+			<dstport>OT_PORTS</dstport>
+			<descr>Engineering to OT devices</descr>
+		</rule>
+		<rule uuid="r2">
+			<type>block</type>
+			<interface>lan</interface>
+			<source>
+				<address>BAD_IPS</address>
+			</source>
+			<destination>
+				<any></any>
+			</destination>
+			<descr>Block feed</descr>
+		</rule>
+		<rule uuid="r3">
+			<type>pass</type>
+			<interface>opt1</interface>
+			<protocol>tcp</protocol>
+			<source>
+				<address>HISTORIAN</address>
+			</source>
+			<destination>
+				<network>lanip</network>
+				<port>443</port>
+			</destination>
+			<descr>Historian to firewall</descr>
+		</rule>
+	</filter>
+	<OPNsense>
+		<Firewall>
+			<Alias>
+				<aliases>
+					<alias uuid="a1">
+						<enabled>1</enabled>
+						<name>HISTORIAN</name>
+						<type>host</type>
+						<content>10.20.0.20
+10.20.0.21</content>
+					</alias>
+					<alias uuid="a2">
+						<enabled>0</enabled>
+						<name>OLD_NET</name>
+						<type>network</type>
+						<content>10.20.0.0/24</content>
+					</alias>
+				</aliases>
+			</Alias>
+		</Firewall>
+	</OPNsense>
+</opnsense>
+`
+
+func TestOPNsenseParser_AliasSegmentNames(t *testing.T) {
+	model, err := opnsense.NewOPNsenseParser(parsertest.WriteFile(t, "config.xml", aliasConfig)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if name := model.Networks["lan"].Name; name != "LAN_CORPORATE (CORP_NET)" {
+		t.Errorf("Expected LAN to be named after CORP_NET, got %q", name)
+	}
+	// OLD_NET matches opt1 but is disabled
+	if name := model.Networks["opt1"].Name; name != "OT_CELL" {
+		t.Errorf("Disabled alias should not rename segment, got %q", name)
+	}
+}
+
+func TestOPNsenseParser_AliasExpansion(t *testing.T) {
+	model, err := opnsense.NewOPNsenseParser(parsertest.WriteFile(t, "config.xml", aliasConfig)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	engineering := parsertest.FindPolicy(model, "r1")
+	if engineering == nil {
+		t.Fatal("Expected policy r1")
+	}
+	if engineering.Source.CIDR != "lan" || len(engineering.Source.IPs) != 1 || engineering.Source.IPs[0] != "10.10.0.0/24" {
+		t.Errorf("Expected lan to resolve to its subnet, got %+v", engineering.Source)
+	}
+	expected := []string{"10.20.0.10", "10.20.0.11", "10.20.0.50"}
+	if len(engineering.Destination.IPs) != len(expected) {
+		t.Fatalf("Expected nested alias to expand to %v, got %v", expected, engineering.Destination.IPs)
+	}
+	for i, ip := range expected {
+		if engineering.Destination.IPs[i] != ip {
+			t.Errorf("Destination IP %d: expected %s, got %s", i, ip, engineering.Destination.IPs[i])
+		}
+	}
+
+	if len(engineering.Ports) != 3 {
+		t.Fatalf("Expected 3 ports from OT_PORTS, got %+v", engineering.Ports)
+	}
+	if engineering.Ports[0].Number != 502 || engineering.Ports[0].Protocol != "tcp:Modbus" {
+		t.Errorf("Unexpected first port: %+v", engineering.Ports[0])
+	}
+	if engineering.Ports[2].Number != 2222 || engineering.Ports[2].Protocol != "tcp:2222-2223" {
+		t.Errorf("Expected alias range 2222:2223, got %+v", engineering.Ports[2])
+	}
+
+	// urltable content is not available offline; the name is kept without IPs
+	feed := parsertest.FindPolicy(model, "r2")
+	if feed.Source.CIDR != "BAD_IPS" || len(feed.Source.IPs) != 0 {
+		t.Errorf("Expected unresolved urltable alias, got %+v", feed.Source)
+	}
+	if feed.Destination.CIDR != "any" {
+		t.Errorf("Expected empty <any></any> to mean any, got %q", feed.Destination.CIDR)
+	}
+
+	// Alias model (<OPNsense><Firewall><Alias>) with newline separated content
+	historian := parsertest.FindPolicy(model, "r3")
+	if len(historian.Source.IPs) != 2 || historian.Source.IPs[1] != "10.20.0.21" {
+		t.Errorf("Expected model alias to expand, got %+v", historian.Source)
+	}
+	if len(historian.Destination.IPs) != 1 || historian.Destination.IPs[0] != "10.10.0.1" {
+		t.Errorf("Expected lanip to resolve to the interface address, got %+v", historian.Destination)
+	}
+	if len(historian.Ports) != 1 || historian.Ports[0].Number != 443 {
+		t.Errorf("Expected port from <destination><port>, got %+v", historian.Ports)
+	}
+}
+
+const natConfig = `<?xml version="1.0"?>
+<opnsense>
+	<interfaces>
+		<wan>
+			<if>igb0</if>
+			<descr>WAN</descr>
+			<ipaddr>203.0.113.2</ipaddr>
+			<subnet>29</subnet>
+		</wan>
+		<opt2>
+			<if>igb3</if>
+			<descr>MANUFACTURING_ASSEMBLY</descr>
+			<ipaddr>10.30.0.1</ipaddr>
+			<subnet>24</subnet>
+		</opt2>
+	</interfaces>
+	<aliases>
+		<alias>
+			<name>PLC1</name>
+			<type>host</type>
+			<address>10.30.0.10</address>
+		</alias>
+	</aliases>
+	<nat>
+		<rule uuid="pf1">
+			<protocol>tcp</protocol>
+			<interface>wan</interface>
+			<source>
+				<any>1</any>
+			</source>
+			<destination>
+				<network>wanip</network>
+				<port>502</port>
+			</destination>
+			<target>PLC1</target>
+			<local-port>502</local-port>
+			<descr>Vendor Modbus access</descr>
+		</rule>
+		<rule uuid="pf2">
+			<disabled>1</disabled>
+			<protocol>tcp</protocol>
+			<interface>wan</interface>
+			<destination>
+				<network>wanip</network>
+				<port>8000-8010</port>
+			</destination>
+			<target>10.30.0.20</target>
+			<local-port>9000</local-port>
+			<descr>Old web range</descr>
+		</rule>
+		<onetoone>
+			<interface>wan</interface>
+			<type>binat</type>
+			<external>203.0.113.5</external>
+			<source>
+				<address>10.30.0.50</address>
+			</source>
+			<destination>
+				<any>1</any>
+			</destination>
+			<descr>HMI 1:1</descr>
+		</onetoone>
+		<outbound>
+			<mode>hybrid</mode>
+			<rule uuid="out1">
+				<interface>wan</interface>
+				<source>
+					<network>10.30.0.0/24</network>
+				</source>
+				<destination>
+					<any>1</any>
+				</destination>
+				<target></target>
+				<descr>Assembly masquerade</descr>
+			</rule>
+		</outbound>
+	</nat>
+</opnsense>
+`
+
+func TestOPNsenseParser_NAT(t *testing.T) {
+	model, err := opnsense.NewOPNsenseParser(parsertest.WriteFile(t, "config.xml", natConfig)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(model.NATRules) != 4 {
+		t.Fatalf("Expected 2 port forwards, one 1:1 and one outbound rule, got %d", len(model.NATRules))
+	}
+
+	forward := model.NATRules[0]
+	if forward.Type != types.PortForwardNAT || !forward.Enabled || forward.Interface != "wan" {
+		t.Errorf("Unexpected port forward: %+v", forward)
+	}
+	if len(forward.Destination.IPs) != 1 || forward.Destination.IPs[0] != "203.0.113.2" {
+		t.Errorf("Expected wanip to resolve to the WAN address, got %+v", forward.Destination)
+	}
+	if len(forward.Ports) != 1 || forward.Ports[0].Number != 502 {
+		t.Errorf("Expected original port 502, got %+v", forward.Ports)
+	}
+	if forward.TranslatedDestination.CIDR != "PLC1" || len(forward.TranslatedDestination.IPs) != 1 ||
+		forward.TranslatedDestination.IPs[0] != "10.30.0.10" {
+		t.Errorf("Expected target alias to resolve to the PLC, got %+v", forward.TranslatedDestination)
+	}
+
+	ranged := model.NATRules[1]
+	if ranged.Enabled {
+		t.Error("Expected disabled port forward to be marked inactive")
+	}
+	if len(ranged.TranslatedPorts) != 1 || ranged.TranslatedPorts[0].Protocol != "tcp:9000-9010" {
+		t.Errorf("Expected range to keep its width on the local port, got %+v", ranged.TranslatedPorts)
+	}
+
+	binat := model.NATRules[2]
+	if binat.Type != types.OneToOneNAT || binat.Destination.CIDR != "203.0.113.5" ||
+		binat.TranslatedDestination.IPs[0] != "10.30.0.50" {
+		t.Errorf("Unexpected 1:1 rule: %+v", binat)
+	}
+
+	outbound := model.NATRules[3]
+	if outbound.Type != types.OutboundNAT || !outbound.Enabled {
+		t.Errorf("Expected active outbound rule in hybrid mode, got %+v", outbound)
+	}
+	if outbound.Source.IPs[0] != "10.30.0.0/24" || outbound.TranslatedSource.IPs[0] != "203.0.113.2" {
+		t.Errorf("Expected masquerade to the WAN address, got %+v -> %+v", outbound.Source, outbound.TranslatedSource)
+	}
+}
+
+const semanticsConfig = `<?xml version="1.0"?>
+<opnsense>
+	<interfaces>
+		<lan>
+			<if>igb1</if>
+			<descr>LAN</descr>
+			<ipaddr>10.10.0.1</ipaddr>
+			<subnet>24</subnet>
+		</lan>
+	</interfaces>
+	<filter>
+		<rule uuid="lan-allow">
+			<type>pass</type>
+			<interface>lan</interface>
+			<ipprotocol>inet6</ipprotocol>
+			<source>
+				<network>lan</network>
+			</source>
+			<destination>
+				<not>1</not>
+				<network>lan</network>
+			</destination>
+		</rule>
+		<rule uuid="lan-old">
+			<disabled>1</disabled>
+			<type>pass</type>
+			<interface>lan</interface>
+			<source>
+				<any>1</any>
+			</source>
+			<destination>
+				<any>1</any>
+			</destination>
+		</rule>
+		<rule uuid="float-block">
+			<type>block</type>
+			<interface>lan,wan</interface>
+			<floating>yes</floating>
+			<quick>1</quick>
+			<direction>out</direction>
+			<log>1</log>
+			<source>
+				<any>1</any>
+			</source>
+			<destination>
+				<any>1</any>
+			</destination>
+			<descr>Block outbound</descr>
+		</rule>
+		<rule uuid="float-match">
+			<type>pass</type>
+			<interface>lan</interface>
+			<floating>yes</floating>
+			<source>
+				<any>1</any>
+			</source>
+			<destination>
+				<any>1</any>
+			</destination>
+		</rule>
+	</filter>
+</opnsense>
+`
+
+func TestOPNsenseParser_RuleSemantics(t *testing.T) {
+	model, err := opnsense.NewOPNsenseParser(parsertest.WriteFile(t, "config.xml", semanticsConfig)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	order := []string{"float-block", "float-match", "lan-allow", "lan-old", "implicit-default-deny"}
+	if len(model.Policies) != len(order) {
+		t.Fatalf("Expected %d policies, got %d", len(order), len(model.Policies))
+	}
+	for i, id := range order {
+		if model.Policies[i].ID != id {
+			t.Errorf("Position %d: expected %s (floating rules first), got %s", i, id, model.Policies[i].ID)
+		}
+	}
+
+	floating := model.Policies[0]
+	if !floating.Floating || !floating.Quick || floating.Direction != "out" || floating.Zone != "lan,wan" {
+		t.Errorf("Unexpected floating rule: %+v", floating)
+	}
+	if floating.Description != "Block outbound [floating, out] [logged]" {
+		t.Errorf("Unexpected floating description: %q", floating.Description)
+	}
+	if match := model.Policies[1]; match.Quick || match.Direction != "any" {
+		t.Errorf("Floating rule without <quick> should be last-match for any direction: %+v", match)
+	}
+
+	lan := model.Policies[2]
+	if !lan.Enabled || !lan.Quick || lan.Direction != "in" || lan.IPFamily != "inet6" {
+		t.Errorf("Unexpected interface rule: %+v", lan)
+	}
+	if lan.Destination.CIDR != "!lan" {
+		t.Errorf("Expected negated destination to be kept, got %q", lan.Destination.CIDR)
+	}
+
+	if old := model.Policies[3]; old.Enabled || old.IPFamily != "inet" {
+		t.Errorf("Expected disabled IPv4 rule to be kept inactive, got %+v", old)
+	}
+}
@@ -0,0 +1,37 @@
+// Package parsertest holds helpers shared by the firewall parser tests
+package parsertest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cipgram/pkg/analysis"
+	"cipgram/pkg/types"
+)
+
+// WriteFile writes content to a file called name in a temporary directory and returns its path
+func WriteFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// FindPolicy returns the policy with the given ID, nil when the model has none
+func FindPolicy(model *types.NetworkModel, id string) *types.SecurityPolicy {
+	for _, policy := range model.Policies {
+		if policy.ID == id {
+			return policy
+		}
+	}
+	return nil
+}
+
+// Evaluate decides a flow against the model's policies and networks the way the
+// combined analysis does
+func Evaluate(model *types.NetworkModel, flow *types.Flow) analysis.PolicyDecision {
+	return analysis.NewPolicyEngine(model.Policies, model.Networks).Evaluate(flow)
+}
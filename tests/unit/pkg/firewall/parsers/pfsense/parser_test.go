@@ -0,0 +1,238 @@
+package pfsense_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/firewall/parsers/pfsense"
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/firewall/parsers/parsertest"
+)
+
+const sampleConfig = `<?xml version="1.0"?>
+<pfsense>
+	<version>23.3</version>
+	<system>
+		<hostname>plant-fw</hostname>
+		<domain>example.local</domain>
+	</system>
+	<interfaces>
+		<wan>
+			<enable></enable>
+			<if>igb0</if>
+			<descr><![CDATA[WAN]]></descr>
+			<ipaddr>dhcp</ipaddr>
+		</wan>
+		<lan>
+			<enable></enable>
+			<if>igb1</if>
+			<descr><![CDATA[Office]]></descr>
+			<ipaddr>192.168.1.1</ipaddr>
+			<subnet>24</subnet>
+		</lan>
+		<opt7>
+			<enable></enable>
+			<if>igb1.20</if>
+			<descr><![CDATA[SCADA Cell]]></descr>
+			<ipaddr>10.20.0.1</ipaddr>
+			<subnet>24</subnet>
+		</opt7>
+		<opt8>
+			<if>igb2</if>
+			<descr><![CDATA[Spare]]></descr>
+			<ipaddr>172.16.0.1</ipaddr>
+			<subnet>24</subnet>
+		</opt8>
+	</interfaces>
+	<aliases>
+		<alias>
+			<name>PLCS</name>
+			<type>host</type>
+			<address>10.20.0.10 10.20.0.11 HMI</address>
+			<detail><![CDATA[PLC 1||PLC 2||HMI]]></detail>
+		</alias>
+		<alias>
+			<name>HMI</name>
+			<type>host</type>
+			<address>10.20.0.50</address>
+		</alias>
+		<alias>
+			<name>OT_PORTS</name>
+			<type>port</type>
+			<address>502 44818 2222:2223</address>
+		</alias>
+	</aliases>
+	<filter>
+		<rule>
+			<id></id>
+			<tracker>1700000001</tracker>
+			<type>pass</type>
+			<interface>lan</interface>
+			<ipprotocol>inet</ipprotocol>
+			<protocol>tcp</protocol>
+			<source>
+				<network>lan</network>
+			</source>
+			<destination>
+				<address>PLCS</address>
+				<port>OT_PORTS</port>
+			</destination>
+			<descr><![CDATA[Engineering to PLCs]]></descr>
+		</rule>
+		<rule>
+			<tracker>1700000002</tracker>
+			<type>pass</type>
+			<interface>wan</interface>
+			<protocol>tcp</protocol>
+			<source>
+				<any></any>
+				<port>1024-65535</port>
+			</source>
+			<destination>
+				<address>10.20.0.50</address>
+				<port>8000-8080</port>
+			</destination>
+			<descr><![CDATA[NAT HMI web]]></descr>
+			<associated-rule-id>nat_5f1a2b3c4d5e6</associated-rule-id>
+		</rule>
+		<rule>
+			<tracker>1700000003</tracker>
+			<type>block</type>
+			<interface>opt7</interface>
+			<source>
+				<network>opt7</network>
+			</source>
+			<destination>
+				<not></not>
+				<network>opt7</network>
+			</destination>
+			<descr><![CDATA[Isolate cell]]></descr>
+			<disabled></disabled>
+			<log></log>
+		</rule>
+		<rule>
+			<tracker>1700000004</tracker>
+			<type>reject</type>
+			<interface>lan,opt7</interface>
+			<floating>yes</floating>
+			<direction>out</direction>
+			<source>
+				<any></any>
+			</source>
+			<destination>
+				<any></any>
+			</destination>
+			<descr><![CDATA[Floating reject]]></descr>
+		</rule>
+	</filter>
+</pfsense>
+`
+
+func TestPfSenseParser_Validate(t *testing.T) {
+	parser := pfsense.NewPfSenseParser(parsertest.WriteFile(t, "config.xml", sampleConfig))
+	if err := parser.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+	if hostname := parser.GetConfig().System.Hostname; hostname != "plant-fw" {
+		t.Errorf("Expected hostname plant-fw, got %q", hostname)
+	}
+
+	// An OPNsense config is not a pfSense config
+	opnsense := pfsense.NewPfSenseParser(parsertest.WriteFile(t, "config.xml", "<opnsense><interfaces/></opnsense>"))
+	if err := opnsense.Validate(); err == nil {
+		t.Error("Expected OPNsense root element to be rejected")
+	}
+}
+
+func TestPfSenseParser_Interfaces(t *testing.T) {
+	model, err := pfsense.NewPfSenseParser(parsertest.WriteFile(t, "config.xml", sampleConfig)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(model.Networks) != 3 {
+		t.Fatalf("Expected wan, lan and opt7 (opt8 lacks <enable/>), got %d networks", len(model.Networks))
+	}
+	if _, ok := model.Networks["opt8"]; ok {
+		t.Error("Interface without <enable/> should be skipped")
+	}
+
+	cell := model.Networks["opt7"]
+	if cell.CIDR != "10.20.0.1/24" || cell.Zone != types.IndustrialZone || cell.Risk != types.HighRisk {
+		t.Errorf("Unexpected opt7 segment: %+v", cell)
+	}
+	if wan := model.Networks["wan"]; wan.CIDR != "" || wan.Zone != types.DMZZone {
+		t.Errorf("Unexpected WAN segment: %+v", wan)
+	}
+}
+
+func TestPfSenseParser_Rules(t *testing.T) {
+	model, err := pfsense.NewPfSenseParser(parsertest.WriteFile(t, "config.xml", sampleConfig)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(model.Policies) != 5 {
+		t.Fatalf("Expected 4 rules plus default deny, got %d", len(model.Policies))
+	}
+
+	engineering := parsertest.FindPolicy(model, "1700000001")
+	if engineering == nil || engineering.Action != types.Allow || engineering.Source.CIDR != "lan" {
+		t.Fatalf("Unexpected engineering rule: %+v", engineering)
+	}
+	if len(engineering.Destination.IPs) != 3 || engineering.Destination.IPs[2] != "10.20.0.50" {
+		t.Errorf("Expected nested alias expansion, got %v", engineering.Destination.IPs)
+	}
+	if len(engineering.Ports) != 3 || engineering.Ports[0].Protocol != "tcp:Modbus" || engineering.Ports[2].Protocol != "tcp:2222-2223" {
+		t.Errorf("Unexpected port alias expansion: %+v", engineering.Ports)
+	}
+
+	nat := parsertest.FindPolicy(model, "1700000002")
+	if nat.Description != "NAT HMI web [NAT nat_5f1a2b3c4d5e6]" {
+		t.Errorf("Unexpected description: %q", nat.Description)
+	}
+	if nat.Source.CIDR != "any" || len(nat.Ports) != 1 || nat.Ports[0].Protocol != "tcp:8000-8080" {
+		t.Errorf("Unexpected NAT rule: %+v", nat)
+	}
+	if len(nat.SourcePorts) != 1 || nat.SourcePorts[0].Protocol != "tcp:1024-65535" {
+		t.Errorf("Expected the source port range apart from the destination ports, got %+v", nat.SourcePorts)
+	}
+
+	isolate := parsertest.FindPolicy(model, "1700000003")
+	if isolate.Enabled || isolate.Action != types.Drop || isolate.Destination.CIDR != "!opt7" {
+		t.Errorf("Unexpected isolation rule: %+v", isolate)
+	}
+
+	floating := parsertest.FindPolicy(model, "1700000004")
+	if floating.Action != types.Reject || floating.Description != "Floating reject [floating, out]" {
+		t.Errorf("Unexpected floating rule: %+v", floating)
+	}
+	if len(model.Networks["opt7"].Policies) != 2 {
+		t.Errorf("Expected floating rule attached to each of its interfaces")
+	}
+
+	last := model.Policies[len(model.Policies)-1]
+	if last.ID != "implicit-default-deny" || last.Action != types.Drop {
+		t.Errorf("Expected pfSense default deny last, got %+v", last)
+	}
+
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"engineering to PLC", &types.Flow{Source: "192.168.1.20", Destination: "10.20.0.10", Protocol: "Modbus TCP"}, "1700000001", true},
+		{"engineering outside the port alias", &types.Flow{Source: "192.168.1.20", Destination: "10.20.0.10", Protocol: "HTTP"}, "implicit-default-deny", false},
+		{"internet to HMI web", &types.Flow{Source: "198.51.100.7", Destination: "10.20.0.50", Protocol: "TCP", Ports: []types.Port{{Number: 8080}}}, "1700000002", true},
+		{"internet to HMI outside the web ports", &types.Flow{Source: "198.51.100.7", Destination: "10.20.0.50", Protocol: "TCP", Ports: []types.Port{{Number: 2000}}}, "implicit-default-deny", false},
+		{"internet to HMI web from a privileged port", &types.Flow{Source: "198.51.100.7", Destination: "10.20.0.50",
+			Sessions: []types.SessionKey{{Transport: "tcp", Client: "198.51.100.7", ClientPort: 80, Server: "10.20.0.50", ServerPort: 8080}}}, "implicit-default-deny", false},
+		{"SCADA cell to office", &types.Flow{Source: "10.20.0.10", Destination: "192.168.1.20", Protocol: "HTTP"}, "implicit-default-deny", false},
+		{"office to the internet", &types.Flow{Source: "192.168.1.20", Destination: "198.51.100.7", Protocol: "HTTPS"}, "implicit-default-deny", false},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package vyatta_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/firewall/parsers/vyatta"
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/firewall/parsers/parsertest"
+)
+
+const sampleConfigBoot = `firewall {
+    group {
+        address-group PLCS {
+            address 10.10.1.10
+            address 10.10.1.11
+        }
+        port-group OT_PORTS {
+            port 502
+            port 44818
+        }
+    }
+    name WAN_IN {
+        default-action drop
+        rule 10 {
+            action accept
+            state {
+                established enable
+                related enable
+            }
+        }
+        rule 20 {
+            action drop
+            disable
+            protocol tcp
+            destination {
+                port 22
+            }
+        }
+    }
+    name IT_TO_OT {
+        default-action reject
+        rule 10 {
+            action accept
+            description "Historian polling"
+            protocol tcp
+            destination {
+                group {
+                    address-group PLCS
+                    port-group OT_PORTS
+                }
+            }
+        }
+    }
+}
+interfaces {
+    ethernet eth0 {
+        address dhcp
+        description "WAN uplink"
+        firewall {
+            in {
+                name WAN_IN
+            }
+        }
+    }
+    ethernet eth1 {
+        address 192.168.10.1/24
+        description "Corporate LAN"
+        vif 100 {
+            address 10.10.1.1/24
+            description "PLC cell"
+        }
+    }
+    ethernet eth2 {
+        disable
+        address 172.16.0.1/24
+    }
+    loopback lo {
+    }
+}
+protocols {
+    static {
+        route 0.0.0.0/0 {
+            next-hop 203.0.113.1 {
+            }
+        }
+    }
+}
+system {
+    host-name edge-fw
+}
+zone-policy {
+    zone CORP {
+        default-action drop
+        interface eth1
+    }
+    zone OT {
+        default-action drop
+        description "Control network"
+        from CORP {
+            firewall {
+                name IT_TO_OT
+            }
+        }
+        interface eth1.100
+    }
+}
+`
+
+const sampleSetCommands = `set system host-name 'edge-fw'
+set interfaces ethernet eth1 address '192.168.10.1/24'
+set interfaces ethernet eth1 description 'Corporate LAN'
+set interfaces ethernet eth1 firewall local name 'LOCAL'
+set firewall name LOCAL default-action 'accept'
+set firewall name LOCAL rule 5 action 'reject'
+set firewall name LOCAL rule 5 protocol 'tcp_udp'
+set firewall name LOCAL rule 5 source address '192.168.10.0/24'
+set firewall name LOCAL rule 5 destination port '161-162,8080'
+`
+
+func TestVyattaParser_Validate(t *testing.T) {
+	parser := vyatta.NewVyattaParser(parsertest.WriteFile(t, "config.boot", sampleConfigBoot))
+	if err := parser.Validate(); err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+
+	cfg := parser.GetConfig()
+	if cfg.System.HostName != "edge-fw" {
+		t.Errorf("Expected host-name edge-fw, got %q", cfg.System.HostName)
+	}
+	if _, ok := cfg.Interfaces["eth1.100"]; !ok {
+		t.Error("Expected VLAN interface eth1.100")
+	}
+	if cfg.Interfaces["eth0"].Firewall.In != "WAN_IN" {
+		t.Errorf("Expected WAN_IN bound inbound on eth0, got %+v", cfg.Interfaces["eth0"].Firewall)
+	}
+	if !cfg.Firewall.Name["WAN_IN"].Rules[20].Disabled {
+		t.Error("Expected WAN_IN rule 20 to be disabled")
+	}
+	if cfg.ZonePolicy["OT"].From["CORP"] != "IT_TO_OT" {
+		t.Errorf("Expected OT from CORP to use IT_TO_OT, got %+v", cfg.ZonePolicy["OT"].From)
+	}
+	if cfg.Protocols.Static.Route["0.0.0.0/0"].NextHop != "203.0.113.1" {
+		t.Errorf("Unexpected static route: %+v", cfg.Protocols.Static.Route)
+	}
+}
+
+func TestVyattaParser_ValidateRejectsBrokenInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unbalanced braces", "interfaces {\n    ethernet eth0 {\n"},
+		{"unterminated quote", "set interfaces ethernet eth0 description 'LAN\n"},
+		{"no interfaces", "system {\n    host-name fw\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := vyatta.NewVyattaParser(parsertest.WriteFile(t, "config.boot", tt.content))
+			if err := parser.Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestVyattaParser_Segments(t *testing.T) {
+	parser := vyatta.NewVyattaParser(parsertest.WriteFile(t, "config.boot", sampleConfigBoot))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	for _, skipped := range []string{"eth2", "lo"} {
+		if _, ok := model.Networks[skipped]; ok {
+			t.Errorf("Expected %s to be skipped", skipped)
+		}
+	}
+
+	plc := model.Networks["eth1.100"]
+	if plc == nil || plc.CIDR != "10.10.1.1/24" {
+		t.Fatalf("Expected PLC VLAN segment, got %+v", plc)
+	}
+	if plc.Zone != types.IndustrialZone || plc.Risk != types.HighRisk {
+		t.Errorf("Expected industrial high-risk zone for OT zone member, got %s/%s", plc.Zone, plc.Risk)
+	}
+
+	if wan := model.Networks["eth0"]; wan == nil || wan.Zone != types.DMZZone {
+		t.Errorf("Expected filtered DHCP WAN interface as DMZ segment, got %+v", wan)
+	}
+	if corp := model.Networks["eth1"]; corp == nil || corp.Zone != types.EnterpriseZone {
+		t.Errorf("Expected corporate enterprise segment, got %+v", corp)
+	}
+}
+
+func TestVyattaParser_InterfacePolicies(t *testing.T) {
+	parser := vyatta.NewVyattaParser(parsertest.WriteFile(t, "config.boot", sampleConfigBoot))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	established := parsertest.FindPolicy(model, "eth0-in-WAN_IN-10")
+	if established == nil || established.Zone != "eth0" || established.Direction != "in" || established.Action != types.Allow {
+		t.Fatalf("Unexpected established policy: %+v", established)
+	}
+	if len(established.States) != 2 || established.States[0] != "established" {
+		t.Errorf("Expected rule limited to established,related, got %v", established.States)
+	}
+	if established.Description != "WAN_IN rule 10 [state established,related]" {
+		t.Errorf("Unexpected description: %q", established.Description)
+	}
+
+	if ssh := parsertest.FindPolicy(model, "eth0-in-WAN_IN-20"); ssh == nil || ssh.Enabled {
+		t.Errorf("Expected disabled SSH policy, got %+v", ssh)
+	}
+
+	def := parsertest.FindPolicy(model, "eth0-in-WAN_IN-default")
+	if def == nil || def.Action != types.Drop {
+		t.Errorf("Expected drop default policy, got %+v", def)
+	}
+	if len(model.Networks["eth0"].Policies) != 3 {
+		t.Errorf("Expected 3 policies on eth0, got %d", len(model.Networks["eth0"].Policies))
+	}
+}
+
+func TestVyattaParser_ZonePolicies(t *testing.T) {
+	parser := vyatta.NewVyattaParser(parsertest.WriteFile(t, "config.boot", sampleConfigBoot))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	policy := parsertest.FindPolicy(model, "zone-CORP-OT-IT_TO_OT-10")
+	if policy == nil {
+		t.Fatal("Expected zone-pair policy")
+	}
+	if policy.Zone != "eth1" || policy.Direction != "in" || policy.EgressZone != "eth1.100" {
+		t.Errorf("Expected CORP interfaces as ingress and OT interfaces as egress, got %q/%q -> %q",
+			policy.Zone, policy.Direction, policy.EgressZone)
+	}
+	if policy.Source.CIDR != "eth1" {
+		t.Errorf("Expected source scoped to CORP interfaces, got %+v", policy.Source)
+	}
+	if len(policy.Destination.IPs) != 2 || policy.Destination.IPs[1] != "10.10.1.11" {
+		t.Errorf("Expected PLCS address-group members, got %+v", policy.Destination)
+	}
+	if len(policy.Ports) != 2 || policy.Ports[0].Protocol != "tcp:Modbus" || policy.Ports[1].Protocol != "tcp:ENIP" {
+		t.Errorf("Expected port-group members, got %+v", policy.Ports)
+	}
+	expected := "Historian polling [" + string(types.EnterpriseZone) + " → " + string(types.IndustrialZone) + "]"
+	if policy.Description != expected {
+		t.Errorf("Expected %q, got %q", expected, policy.Description)
+	}
+
+	def := parsertest.FindPolicy(model, "zone-CORP-OT-IT_TO_OT-default")
+	if def == nil || def.Action != types.Reject || def.Destination.CIDR != "eth1.100" {
+		t.Errorf("Unexpected zone default policy: %+v", def)
+	}
+}
+
+func TestVyattaParser_Evaluate(t *testing.T) {
+	parser := vyatta.NewVyattaParser(parsertest.WriteFile(t, "config.boot", sampleConfigBoot))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// The workstation opened the connection, so the PLC's packets are replies
+	session := []types.SessionKey{{Transport: "tcp", Client: "192.168.10.5", ClientPort: 50000, Server: "10.10.1.10", ServerPort: 502}}
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"corporate to PLC", &types.Flow{Source: "192.168.10.5", Destination: "10.10.1.10", Protocol: "Modbus TCP"}, "zone-CORP-OT-IT_TO_OT-10", true},
+		{"corporate to PLC outside the port group", &types.Flow{Source: "192.168.10.5", Destination: "10.10.1.10", Protocol: "HTTP"}, "zone-CORP-OT-IT_TO_OT-default", false},
+		{"PLC to corporate has no zone pair", &types.Flow{Source: "10.10.1.10", Destination: "192.168.10.5", Protocol: "Modbus TCP"}, "implicit-default-deny", false},
+		{"new connection from the internet", &types.Flow{Source: "198.51.100.7", Destination: "192.168.10.5", Protocol: "HTTPS"}, "eth0-in-WAN_IN-default", false},
+		{"PLC replies need an established rule from OT to CORP", &types.Flow{Source: "10.10.1.10", Destination: "192.168.10.5", Protocol: "Modbus TCP", Sessions: session}, "implicit-default-deny", false},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
+
+func TestVyattaParser_SetCommands(t *testing.T) {
+	parser := vyatta.NewVyattaParser(parsertest.WriteFile(t, "commands.txt", sampleSetCommands))
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if seg := model.Networks["eth1"]; seg == nil || seg.CIDR != "192.168.10.1/24" || seg.Name != "Corporate LAN" {
+		t.Fatalf("Unexpected segment from set commands: %+v", seg)
+	}
+
+	policy := parsertest.FindPolicy(model, "eth1-local-LOCAL-5")
+	if policy == nil {
+		t.Fatal("Expected local ruleset policy")
+	}
+	if policy.Action != types.Reject || policy.Protocol != "tcp/udp" || policy.Source.CIDR != "192.168.10.0/24" {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+	if !policy.Local || policy.Zone != "eth1" {
+		t.Errorf("Expected local ruleset to filter traffic to the router on eth1, got %+v", policy)
+	}
+	if len(policy.Ports) != 2 || policy.Ports[0].Protocol != "tcp/udp:161-162" || policy.Ports[1].Number != 8080 {
+		t.Errorf("Unexpected ports: %+v", policy.Ports)
+	}
+
+	if def := parsertest.FindPolicy(model, "eth1-local-LOCAL-default"); def == nil || def.Action != types.Allow {
+		t.Errorf("Expected accept default policy, got %+v", def)
+	}
+}
+
+func TestVyattaParser_NegatedAndSourcePorts(t *testing.T) {
+	commands := `set interfaces ethernet eth0 address '10.0.0.1/24'
+set interfaces ethernet eth0 firewall in name 'LAN_IN'
+set interfaces ethernet eth1 address '10.1.0.1/24'
+set firewall name LAN_IN default-action 'drop'
+set firewall name LAN_IN rule 10 action 'accept'
+set firewall name LAN_IN rule 10 protocol 'tcp'
+set firewall name LAN_IN rule 10 source address '10.0.0.5'
+set firewall name LAN_IN rule 10 destination port '!22'
+set firewall name LAN_IN rule 20 action 'accept'
+set firewall name LAN_IN rule 20 protocol 'tcp'
+set firewall name LAN_IN rule 20 source address '10.0.0.6'
+set firewall name LAN_IN rule 20 source port '1024-65535'
+set firewall name LAN_IN rule 20 destination port '443'
+`
+	model, err := vyatta.NewVyattaParser(parsertest.WriteFile(t, "commands.txt", commands)).Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	notSSH := parsertest.FindPolicy(model, "eth0-in-LAN_IN-10")
+	if notSSH == nil || !notSSH.NegatePorts || len(notSSH.Ports) != 1 || notSSH.Ports[0].Number != 22 {
+		t.Fatalf("Expected negated port 22, got %+v", notSSH)
+	}
+	https := parsertest.FindPolicy(model, "eth0-in-LAN_IN-20")
+	if https == nil || len(https.Ports) != 1 || len(https.SourcePorts) != 1 || https.SourcePorts[0].Protocol != "tcp:1024-65535" {
+		t.Fatalf("Expected destination port 443 and source ports 1024-65535, got %+v", https)
+	}
+
+	session := func(client string, clientPort, serverPort uint16) []types.SessionKey {
+		return []types.SessionKey{{Transport: "tcp", Client: client, ClientPort: clientPort, Server: "10.1.0.10", ServerPort: serverPort}}
+	}
+	decisions := []struct {
+		name    string
+		flow    *types.Flow
+		rule    string
+		allowed bool
+	}{
+		{"SSH excluded by the negated port", &types.Flow{Source: "10.0.0.5", Destination: "10.1.0.10", Protocol: "SSH"}, "eth0-in-LAN_IN-default", false},
+		{"other ports pass the negated port", &types.Flow{Source: "10.0.0.5", Destination: "10.1.0.10", Protocol: "Modbus TCP"}, "eth0-in-LAN_IN-10", true},
+		{"HTTPS from an ephemeral port", &types.Flow{Source: "10.0.0.6", Destination: "10.1.0.10", Sessions: session("10.0.0.6", 50000, 443)}, "eth0-in-LAN_IN-20", true},
+		{"source port range does not open destination ports", &types.Flow{Source: "10.0.0.6", Destination: "10.1.0.10", Sessions: session("10.0.0.6", 50000, 2000)}, "eth0-in-LAN_IN-default", false},
+	}
+	for _, tt := range decisions {
+		if decision := parsertest.Evaluate(model, tt.flow); decision.RuleID != tt.rule || decision.Allowed != tt.allowed {
+			t.Errorf("%s: expected %s (allowed=%v), got %s (allowed=%v)", tt.name, tt.rule, tt.allowed, decision.RuleID, decision.Allowed)
+		}
+	}
+}
@@ -199,7 +199,7 @@ func TestDefaultConfigManager_GetEnabledAnalyzers(t *testing.T) {
 	analyzers := cm.GetEnabledAnalyzers()
 
 	// Should include HTTP, TLS, DNS by default
-	expectedAnalyzers := []string{"HTTP", "TLS", "DNS", "Modbus", "EtherNet/IP", "DNP3", "BACnet"}
+	expectedAnalyzers := []string{"HTTP", "TLS", "DNS", "Modbus", "EtherNet/IP", "S7Comm", "OPC-UA", "Profinet", "DNP3", "BACnet"}
 
 	if len(analyzers) != len(expectedAnalyzers) {
 		t.Errorf("Expected %d analyzers, got %d", len(expectedAnalyzers), len(analyzers))
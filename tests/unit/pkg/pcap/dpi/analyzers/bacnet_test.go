@@ -0,0 +1,122 @@
+package analyzers_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+)
+
+// bvlc wraps an NPDU in a BACnet/IP BVLC header
+func bvlc(function byte, npdu []byte) []byte {
+	frame := []byte{0x81, function, 0x00, 0x00}
+	frame = append(frame, npdu...)
+	binary.BigEndian.PutUint16(frame[2:4], uint16(len(frame)))
+	return frame
+}
+
+// iAm is an I-Am for device 1234, max APDU 1476, segmented-both, vendor 5
+var iAm = []byte{0x10, 0x00, 0xC4, 0x02, 0x00, 0x04, 0xD2, 0x22, 0x05, 0xC4, 0x91, 0x00, 0x21, 0x05}
+
+func TestBACnetAnalyzer_Services(t *testing.T) {
+	analyzer := analyzers.NewBACnetAnalyzer()
+
+	tests := []struct {
+		name    string
+		payload []byte
+		details map[string]interface{}
+	}{
+		{
+			name:    "I-Am",
+			payload: bvlc(0x0B, append([]byte{0x01, 0x00}, iAm...)),
+			details: map[string]interface{}{
+				"bacnet_service": "I-Am", "apdu_type": "Unconfirmed-Request", "device_instance": uint32(1234),
+				"max_apdu": uint32(1476), "segmentation": "segmented-both", "vendor_id": uint16(5),
+			},
+		},
+		{
+			name:    "routed I-Am",
+			payload: bvlc(0x0B, append([]byte{0x01, 0x08, 0x00, 0x05, 0x01, 0x0A}, iAm...)),
+			details: map[string]interface{}{
+				"snet": uint16(5), "sadr": "0a", "device_instance": uint32(1234),
+			},
+		},
+		{
+			name:    "global Who-Is with range",
+			payload: bvlc(0x0B, []byte{0x01, 0x20, 0xFF, 0xFF, 0x00, 0xFF, 0x10, 0x08, 0x09, 0x00, 0x1A, 0x27, 0x10}),
+			details: map[string]interface{}{
+				"bacnet_service": "Who-Is", "dnet": uint16(0xFFFF), "dadr": "", "hop_count": uint8(255),
+				"who_is_low": uint32(0), "who_is_high": uint32(10000),
+			},
+		},
+		{
+			name:    "ReadProperty",
+			payload: bvlc(0x0A, []byte{0x01, 0x04, 0x00, 0x05, 0x01, 0x0C, 0x0C, 0x00, 0x00, 0x00, 0x01, 0x19, 0x55}),
+			details: map[string]interface{}{
+				"bacnet_service": "ReadProperty", "invoke_id": uint8(1), "object": "analog-input:1",
+				"property": "present-value", "expecting_reply": true,
+			},
+		},
+		{
+			name: "WriteProperty",
+			payload: bvlc(0x0A, []byte{0x01, 0x04, 0x00, 0x05, 0x02, 0x0F, 0x0C, 0x00, 0x40, 0x00, 0x03, 0x19, 0x55,
+				0x3E, 0x44, 0x42, 0x90, 0x00, 0x00, 0x3F, 0x49, 0x08}),
+			details: map[string]interface{}{
+				"bacnet_service": "WriteProperty", "object": "analog-output:3", "property_id": uint32(85),
+			},
+		},
+		{
+			name:    "SubscribeCOV",
+			payload: bvlc(0x0A, []byte{0x01, 0x04, 0x00, 0x05, 0x03, 0x05, 0x09, 0x12, 0x1C, 0x00, 0xC0, 0x00, 0x07}),
+			details: map[string]interface{}{
+				"bacnet_service": "SubscribeCOV", "subscriber_process_id": uint32(18), "object": "binary-input:7",
+			},
+		},
+		{
+			name:    "DeviceCommunicationControl disable",
+			payload: bvlc(0x0A, []byte{0x01, 0x04, 0x00, 0x05, 0x04, 0x11, 0x09, 0x3C, 0x19, 0x01}),
+			details: map[string]interface{}{
+				"dcc_duration": uint32(60), "dcc_state": "disable", "high_risk_operation": "Disable Device Communication",
+			},
+		},
+		{
+			name:    "ReinitializeDevice",
+			payload: bvlc(0x0A, []byte{0x01, 0x04, 0x00, 0x05, 0x05, 0x14, 0x09, 0x01}),
+			details: map[string]interface{}{
+				"reinitialize_state": "warmstart", "high_risk_operation": "Reinitialize Device",
+			},
+		},
+		{
+			name:    "forwarded NPDU",
+			payload: bvlc(0x04, append([]byte{0x0A, 0x01, 0x02, 0x03, 0xBA, 0xC0, 0x01, 0x00}, iAm...)),
+			details: map[string]interface{}{
+				"forwarded_from": "10.1.2.3:47808", "bacnet_service": "I-Am",
+			},
+		},
+		{
+			name:    "SimpleACK",
+			payload: bvlc(0x0A, []byte{0x01, 0x00, 0x20, 0x02, 0x0F}),
+			details: map[string]interface{}{
+				"apdu_type": "SimpleACK", "bacnet_service": "WriteProperty", "invoke_id": uint8(2),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := createUDPPortsPacket(47808, 47808, tt.payload)
+			if !analyzer.CanAnalyze(packet) {
+				t.Fatal("Expected analyzer to accept BACnet/IP")
+			}
+			result := analyzer.Analyze(packet)
+			if result == nil {
+				t.Fatal("Expected datagram to be analyzed")
+			}
+			for key, value := range tt.details {
+				if result.Details[key] != value {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package analyzers_test
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+)
+
+func crc16DNP(data []byte) []byte {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA6BC
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return binary.LittleEndian.AppendUint16(nil, ^crc)
+}
+
+// dnp3Frame builds a link frame with CRCs carrying one transport segment (FIR and FIN)
+// of the given application fragment
+func dnp3Frame(control byte, destination, source uint16, fragment []byte) []byte {
+	userData := append([]byte{0xC0}, fragment...)
+	header := []byte{0x05, 0x64, byte(5 + len(userData)), control}
+	header = binary.LittleEndian.AppendUint16(header, destination)
+	header = binary.LittleEndian.AppendUint16(header, source)
+	frame := append(header, crc16DNP(header)...)
+	for len(userData) > 0 {
+		block := userData
+		if len(block) > 16 {
+			block = block[:16]
+		}
+		frame = append(frame, block...)
+		frame = append(frame, crc16DNP(block)...)
+		userData = userData[len(block):]
+	}
+	return frame
+}
+
+const (
+	fromMaster     = 0xC4 // DIR, PRM, unconfirmed user data
+	fromOutstation = 0x44 // PRM, unconfirmed user data
+)
+
+func TestDNP3Analyzer_Functions(t *testing.T) {
+	analyzer := analyzers.NewDNP3Analyzer()
+
+	tests := []struct {
+		name    string
+		payload []byte
+		details map[string]interface{}
+		absent  []string
+	}{
+		{
+			name:    "link reset",
+			payload: []byte{0x05, 0x64, 0x05, 0xC0, 0x01, 0x00, 0x00, 0x04, 0xE9, 0x21},
+			details: map[string]interface{}{
+				"link_function": "Reset Link States", "crc_valid": true, "from_master": true,
+				"master_address": uint16(1024), "outstation_address": uint16(1),
+			},
+			absent: []string{"function_code"},
+		},
+		{
+			name: "Direct Operate CROB",
+			payload: dnp3Frame(fromMaster, 10, 1, []byte{0xC3, 0x05, 0x0C, 0x01, 0x28, 0x01, 0x00, 0x03, 0x00,
+				0x03, 0x01, 0xE8, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}),
+			details: map[string]interface{}{
+				"function_name": "Direct Operate", "direction": "request", "crc_valid": true, "app_seq": uint8(3),
+				"high_risk_operation": "Direct Operate", "object_count": 1,
+			},
+		},
+		{
+			name:    "Select analog output",
+			payload: dnp3Frame(fromMaster, 10, 1, []byte{0xC0, 0x03, 0x29, 0x02, 0x17, 0x01, 0x07, 0xDC, 0x05, 0x00}),
+			details: map[string]interface{}{"function_name": "Select"},
+			absent:  []string{"high_risk_operation"},
+		},
+		{
+			name:    "Cold Restart",
+			payload: dnp3Frame(fromMaster, 10, 1, []byte{0xC0, 0x0D}),
+			details: map[string]interface{}{"function_code": uint8(0x0D), "high_risk_operation": "Cold Restart"},
+		},
+		{
+			name:    "Disable Unsolicited",
+			payload: dnp3Frame(fromMaster, 10, 1, []byte{0xC0, 0x15, 0x3C, 0x02, 0x06, 0x3C, 0x03, 0x06, 0x3C, 0x04, 0x06}),
+			details: map[string]interface{}{"high_risk_operation": "Disable Unsolicited", "object_count": 3},
+		},
+		{
+			name:    "unsolicited response after restart",
+			payload: dnp3Frame(fromOutstation, 1, 10, []byte{0xF0, 0x82, 0x80, 0x00, 0x02, 0x01, 0x17, 0x01, 0x00, 0x81}),
+			details: map[string]interface{}{
+				"direction": "response", "unsolicited": true, "device_restart": true, "iin": uint16(0x0080),
+				"from_master": false, "master_address": uint16(1), "outstation_address": uint16(10), "object_count": 1,
+			},
+		},
+		{
+			name: "SAv5 challenge",
+			payload: dnp3Frame(fromOutstation, 1, 10, []byte{0xC0, 0x83, 0x00, 0x00, 0x78, 0x01, 0x5B, 0x01, 0x04, 0x00,
+				0x01, 0x00, 0x00, 0x00}),
+			details: map[string]interface{}{
+				"function_name": "Authenticate Response", "secure_authentication": true, "sa_message": "Challenge",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := createTCPPortsPacket(20000, 40000, tt.payload)
+			if !analyzer.CanAnalyze(packet) {
+				t.Fatal("Expected analyzer to accept DNP3")
+			}
+			result := analyzer.Analyze(packet)
+			if result == nil {
+				t.Fatal("Expected frame to be analyzed")
+			}
+			for key, value := range tt.details {
+				if result.Details[key] != value {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+				}
+			}
+			for _, key := range tt.absent {
+				if value, exists := result.Details[key]; exists {
+					t.Errorf("Expected no %s, got %v", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestDNP3Analyzer_ObjectValues(t *testing.T) {
+	analyzer := analyzers.NewDNP3Analyzer()
+
+	tests := []struct {
+		name     string
+		fragment []byte
+		points   []string
+		headers  []string
+	}{
+		{
+			name: "CROB with 2-byte index prefix",
+			fragment: []byte{0xC0, 0x05, 0x0C, 0x01, 0x28, 0x01, 0x00, 0x03, 0x00,
+				0x41, 0x01, 0xE8, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			points:  []string{"CROB 3 PULSE_ON CLOSE"},
+			headers: []string{"g12v1 q0x28 count 1"},
+		},
+		{
+			name:     "16-bit analog output",
+			fragment: []byte{0xC0, 0x03, 0x29, 0x02, 0x17, 0x01, 0x07, 0xDC, 0x05, 0x00},
+			points:   []string{"AO 7 = 1500"},
+			headers:  []string{"g41v2 q0x17 count 1"},
+		},
+		{
+			name:     "time write then CROB",
+			fragment: append([]byte{0xC0, 0x05, 0x32, 0x01, 0x07, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x01, 0x00, 0x02, 0x02}, make([]byte, 11)...),
+			points:   []string{"CROB 2 NUL"},
+			headers:  []string{"g50v1 q0x07 count 1", "g12v1 q0x00 range 2-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := analyzer.Analyze(createTCPPortsPacket(40000, 20000, dnp3Frame(fromMaster, 10, 1, tt.fragment)))
+			if result == nil {
+				t.Fatal("Expected frame to be analyzed")
+			}
+			if points, _ := result.Details["control_points"].([]string); !reflect.DeepEqual(points, tt.points) {
+				t.Errorf("Expected control points %v, got %v", tt.points, result.Details["control_points"])
+			}
+			if headers, _ := result.Details["object_headers"].([]string); !reflect.DeepEqual(headers, tt.headers) {
+				t.Errorf("Expected object headers %v, got %v", tt.headers, result.Details["object_headers"])
+			}
+		})
+	}
+}
+
+func TestDNP3Analyzer_BadCRC(t *testing.T) {
+	analyzer := analyzers.NewDNP3Analyzer()
+
+	frame := dnp3Frame(fromMaster, 10, 1, []byte{0xC0, 0x01, 0x3C, 0x01, 0x06})
+	frame[len(frame)-1] ^= 0xFF
+
+	result := analyzer.Analyze(createTCPPortsPacket(40000, 20000, frame))
+	if result == nil {
+		t.Fatal("Expected frame to be analyzed")
+	}
+	if valid, _ := result.Details["crc_valid"].(bool); valid {
+		t.Error("Expected crc_valid false for a corrupted data block")
+	}
+
+	// Off-port traffic is only claimed when the header CRC checks out
+	frame[8] ^= 0xFF
+	if analyzer.CanAnalyze(createTCPPortsPacket(40000, 40001, frame)) {
+		t.Error("Expected analyzer to reject an off-port frame with a bad header CRC")
+	}
+}
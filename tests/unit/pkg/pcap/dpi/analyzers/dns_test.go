@@ -0,0 +1,199 @@
+package analyzers_test
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+)
+
+// dnsName encodes a domain name as uncompressed labels
+func dnsName(name string) []byte {
+	var encoded []byte
+	for _, label := range strings.Split(name, ".") {
+		encoded = append(encoded, byte(len(label)))
+		encoded = append(encoded, label...)
+	}
+	return append(encoded, 0x00)
+}
+
+// netbiosName first-level encodes a NetBIOS name with its suffix byte
+func netbiosName(name string, suffix byte) []byte {
+	padded := []byte(name + strings.Repeat(" ", 15-len(name)))
+	padded = append(padded, suffix)
+	encoded := []byte{0x20}
+	for _, b := range padded {
+		encoded = append(encoded, 'A'+b>>4, 'A'+b&0x0F)
+	}
+	return append(encoded, 0x00)
+}
+
+func dnsQuestion(name []byte, qtype uint16) []byte {
+	return append(name, byte(qtype>>8), byte(qtype), 0x00, 0x01)
+}
+
+func dnsRecord(name []byte, rtype uint16, rdata []byte) []byte {
+	record := append(name, byte(rtype>>8), byte(rtype), 0x00, 0x01, 0x00, 0x00, 0x00, 0x3C)
+	record = binary.BigEndian.AppendUint16(record, uint16(len(rdata)))
+	return append(record, rdata...)
+}
+
+// dnsMessage builds a message from its questions, answers and additional records
+func dnsMessage(flags uint16, questions, answers, additional [][]byte) []byte {
+	message := []byte{0x12, 0x34}
+	message = binary.BigEndian.AppendUint16(message, flags)
+	for _, count := range []int{len(questions), len(answers), 0, len(additional)} {
+		message = binary.BigEndian.AppendUint16(message, uint16(count))
+	}
+	for _, section := range [][][]byte{questions, answers, additional} {
+		for _, entry := range section {
+			message = append(message, entry...)
+		}
+	}
+	return message
+}
+
+func TestDNSAnalyzer_Resolution(t *testing.T) {
+	analyzer := analyzers.NewDNSAnalyzer()
+
+	// Answer owner names point back at the question name at offset 12
+	cnameResponse := dnsMessage(0x8180,
+		[][]byte{dnsQuestion(dnsName("historian.example.com"), 1)},
+		[][]byte{
+			dnsRecord([]byte{0xC0, 0x0C}, 5, dnsName("edge.cdn.net")),
+			dnsRecord(dnsName("edge.cdn.net"), 1, []byte{93, 184, 216, 34}),
+		}, nil)
+
+	tests := []struct {
+		name     string
+		srcPort  uint16
+		dstPort  uint16
+		payload  []byte
+		details  map[string]interface{}
+		resolved map[string][]string
+	}{
+		{
+			name:    "DNS query",
+			srcPort: 50000, dstPort: 53,
+			payload: dnsMessage(0x0100, [][]byte{dnsQuestion(dnsName("update.vendor.com"), 1)}, nil, nil),
+			details: map[string]interface{}{"dns_variant": "DNS", "direction": "request"},
+		},
+		{
+			name:    "CNAME chain",
+			srcPort: 53, dstPort: 50000,
+			payload:  cnameResponse,
+			details:  map[string]interface{}{"direction": "response", "answer_count": uint16(2)},
+			resolved: map[string][]string{"93.184.216.34": {"edge.cdn.net", "historian.example.com"}},
+		},
+		{
+			name:    "PTR answer",
+			srcPort: 53, dstPort: 50000,
+			payload: dnsMessage(0x8180,
+				[][]byte{dnsQuestion(dnsName("20.1.0.10.in-addr.arpa"), 12)},
+				[][]byte{dnsRecord([]byte{0xC0, 0x0C}, 12, dnsName("plc1.plant.local"))}, nil),
+			resolved: map[string][]string{"10.0.1.20": {"plc1.plant.local"}},
+		},
+		{
+			name:    "mDNS announcement",
+			srcPort: 5353, dstPort: 5353,
+			payload: dnsMessage(0x8400, nil,
+				[][]byte{dnsRecord(dnsName("hmi-01.local"), 1, []byte{10, 0, 1, 30})}, nil),
+			details:  map[string]interface{}{"dns_variant": "mDNS"},
+			resolved: map[string][]string{"10.0.1.30": {"hmi-01.local"}},
+		},
+		{
+			name:    "LLMNR response",
+			srcPort: 5355, dstPort: 50000,
+			payload: dnsMessage(0x8000,
+				[][]byte{dnsQuestion(dnsName("eng-ws02"), 1)},
+				[][]byte{dnsRecord([]byte{0xC0, 0x0C}, 1, []byte{10, 0, 2, 15})}, nil),
+			details:  map[string]interface{}{"dns_variant": "LLMNR"},
+			resolved: map[string][]string{"10.0.2.15": {"eng-ws02"}},
+		},
+		{
+			name:    "NBNS registration",
+			srcPort: 137, dstPort: 137,
+			payload: dnsMessage(0x2910,
+				[][]byte{dnsQuestion(netbiosName("ENG-WS01", 0x00), 0x20)}, nil,
+				[][]byte{dnsRecord([]byte{0xC0, 0x0C}, 0x20, []byte{0x00, 0x00, 10, 0, 1, 40})}),
+			details: map[string]interface{}{
+				"dns_variant": "NBNS", "nbns_operation": "Registration", "direction": "request",
+			},
+			resolved: map[string][]string{"10.0.1.40": {"ENG-WS01"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := createUDPPortsPacket(tt.srcPort, tt.dstPort, tt.payload)
+			if !analyzer.CanAnalyze(packet) {
+				t.Fatal("Expected analyzer to accept the message")
+			}
+			result := analyzer.Analyze(packet)
+			if result == nil {
+				t.Fatal("Expected message to be analyzed")
+			}
+			for key, value := range tt.details {
+				if result.Details[key] != value {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+				}
+			}
+			resolved, _ := result.Details["resolved_addresses"].(map[string][]string)
+			if len(tt.resolved) > 0 && !reflect.DeepEqual(resolved, tt.resolved) {
+				t.Errorf("Expected resolved addresses %v, got %v", tt.resolved, resolved)
+			}
+		})
+	}
+}
+
+func TestDNSAnalyzer_NodeStatus(t *testing.T) {
+	analyzer := analyzers.NewDNSAnalyzer()
+
+	entry := func(name string, suffix byte, flags uint16) []byte {
+		padded := append([]byte(name+strings.Repeat(" ", 15-len(name))), suffix)
+		return binary.BigEndian.AppendUint16(padded, flags)
+	}
+	rdata := []byte{3}
+	rdata = append(rdata, entry("PLC-GW", 0x00, 0x0400)...)
+	rdata = append(rdata, entry("WORKGROUP", 0x00, 0x8400)...) // Group name
+	rdata = append(rdata, entry("PLC-GW", 0x20, 0x0400)...)
+
+	payload := dnsMessage(0x8400, nil, [][]byte{dnsRecord(netbiosName("*", 0x00), 0x21, rdata)}, nil)
+	result := analyzer.Analyze(createUDPPortsPacket(137, 50000, payload))
+	if result == nil {
+		t.Fatal("Expected node status response to be analyzed")
+	}
+	resolved, _ := result.Details["resolved_addresses"].(map[string][]string)
+	if !reflect.DeepEqual(resolved, map[string][]string{"192.168.1.1": {"PLC-GW"}}) {
+		t.Errorf("Expected the responder's unique name, got %v", resolved)
+	}
+}
+
+func TestDNSAnalyzer_TCPAndMalformed(t *testing.T) {
+	analyzer := analyzers.NewDNSAnalyzer()
+
+	query := dnsMessage(0x0100, [][]byte{dnsQuestion(dnsName("scada.example.org"), 28)}, nil, nil)
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	result := analyzer.Analyze(createTCPPortsPacket(50000, 53, append(framed, query...)))
+	if result == nil {
+		t.Fatal("Expected DNS over TCP to be analyzed")
+	}
+	if queries, _ := result.Details["queries"].([]string); !reflect.DeepEqual(queries, []string{"scada.example.org"}) {
+		t.Errorf("Expected query for scada.example.org, got %v", result.Details["queries"])
+	}
+	if types, _ := result.Details["query_types"].([]string); !reflect.DeepEqual(types, []string{"AAAA"}) {
+		t.Errorf("Expected AAAA query, got %v", result.Details["query_types"])
+	}
+
+	// A question name pointing at itself must not loop
+	loop := dnsMessage(0x0100, [][]byte{{0xC0, 0x0C, 0x00, 0x01, 0x00, 0x01}}, nil, nil)
+	if result := analyzer.Analyze(createUDPPortsPacket(50000, 53, loop)); result != nil {
+		t.Errorf("Expected a self-referencing name to be rejected, got %v", result.Details)
+	}
+
+	if analyzer.CanAnalyze(createUDPPortsPacket(50000, 5000, query)) {
+		t.Error("Expected analyzer to ignore non name-resolution ports")
+	}
+}
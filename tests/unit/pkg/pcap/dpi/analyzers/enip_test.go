@@ -0,0 +1,184 @@
+package analyzers_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// encapsulate wraps CPF data in an EtherNet/IP header with session 0x11223344 and sender context 7
+func encapsulate(command uint16, data []byte) []byte {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint16(header[0:2], command)
+	binary.LittleEndian.PutUint16(header[2:4], uint16(len(data)))
+	binary.LittleEndian.PutUint32(header[4:8], 0x11223344)
+	binary.LittleEndian.PutUint64(header[12:20], 7)
+	return append(header, data...)
+}
+
+// unconnectedCPF builds SendRRData data: interface handle, timeout, null address item and unconnected data item
+func unconnectedCPF(message []byte) []byte {
+	data := []byte{0, 0, 0, 0, 0, 0, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0xB2, 0x00}
+	data = binary.LittleEndian.AppendUint16(data, uint16(len(message)))
+	return append(data, message...)
+}
+
+func identityAttributes() []byte {
+	attrs := []byte{
+		0x01, 0x00, // Vendor: Rockwell Automation
+		0x0E, 0x00, // Device type: PLC
+		0x36, 0x00, // Product code
+		0x14, 0x0B, // Revision 20.11
+		0x60, 0x30, // Status
+		0x78, 0x56, 0x34, 0x12, // Serial
+	}
+	name := "1756-L71/B LOGIX5571"
+	attrs = append(attrs, byte(len(name)))
+	return append(attrs, name...)
+}
+
+func TestEtherNetIPAnalyzer_ForwardOpen(t *testing.T) {
+	analyzer := analyzers.NewEtherNetIPAnalyzer()
+
+	request := []byte{
+		0x54, 0x02, 0x20, 0x06, 0x24, 0x01, // Forward_Open to Connection_Manager/1
+		0x0A, 0x0E, // Priority/tick, timeout ticks
+		0x00, 0x00, 0x00, 0x00, // O->T connection ID (chosen by target)
+		0x01, 0x00, 0x10, 0x80, // T->O connection ID
+		0x34, 0x12, // Connection serial
+		0x4D, 0x00, // Originator vendor
+		0xEF, 0xBE, 0xAD, 0xDE, // Originator serial
+		0x01, 0x00, 0x00, 0x00, // Timeout multiplier + reserved
+		0x10, 0x27, 0x00, 0x00, // O->T RPI 10000us
+		0x20, 0x48, // O->T params, size 32
+		0x20, 0x4E, 0x00, 0x00, // T->O RPI 20000us
+		0x64, 0x48, // T->O params, size 100
+		0x01,                                           // Transport trigger
+		0x04,                                           // Path size in words
+		0x20, 0x04, 0x24, 0x01, 0x2C, 0x64, 0x2C, 0x96, // Assembly/1/cp:100/cp:150
+	}
+	result := analyzer.Analyze(createTCPPortsPacket(50000, 44818, encapsulate(0x006F, unconnectedCPF(request))))
+	if result == nil {
+		t.Fatal("Expected Forward_Open request to be analyzed")
+	}
+
+	expected := map[string]interface{}{
+		"cip_service":        "Forward_Open",
+		"cip_class_id":       uint16(0x06),
+		"forward_open":       "request",
+		"to_connection_id":   uint32(0x80100001),
+		"connection_serial":  uint16(0x1234),
+		"originator_serial":  uint32(0xDEADBEEF),
+		"ot_rpi":             uint32(10000),
+		"ot_connection_size": uint16(32),
+		"to_rpi":             uint32(20000),
+		"to_connection_size": uint16(100),
+		"connection_path":    "Assembly/1/cp:100/cp:150",
+		"direction":          "request",
+	}
+	for key, value := range expected {
+		if result.Details[key] != value {
+			t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+		}
+	}
+
+	reply := []byte{
+		0xD4, 0x00, 0x00, 0x00, // Forward_Open reply, success
+		0x02, 0x00, 0x20, 0x00, // O->T connection ID assigned by target
+		0x01, 0x00, 0x10, 0x80, // T->O connection ID
+		0x34, 0x12, 0x4D, 0x00, 0xEF, 0xBE, 0xAD, 0xDE,
+		0x10, 0x27, 0x00, 0x00, 0x20, 0x4E, 0x00, 0x00, // Actual packet intervals
+		0x00, 0x00,
+	}
+	result = analyzer.Analyze(createTCPPortsPacket(44818, 50000, encapsulate(0x006F, unconnectedCPF(reply))))
+	if result == nil {
+		t.Fatal("Expected Forward_Open reply to be analyzed")
+	}
+	if result.Details["forward_open"] != "reply" || result.Details["ot_connection_id"] != uint32(0x00200002) ||
+		result.Details["direction"] != "response" {
+		t.Errorf("Unexpected Forward_Open reply details: %v", result.Details)
+	}
+}
+
+func TestEtherNetIPAnalyzer_Identity(t *testing.T) {
+	analyzer := analyzers.NewEtherNetIPAnalyzer()
+
+	// Get_Attributes_All reply
+	reply := append([]byte{0x81, 0x00, 0x00, 0x00}, identityAttributes()...)
+	result := analyzer.Analyze(createTCPPortsPacket(44818, 50000, encapsulate(0x006F, unconnectedCPF(reply))))
+	if result == nil {
+		t.Fatal("Expected Get_Attributes_All reply to be analyzed")
+	}
+	if result.Details["identity_product_name"] != "1756-L71/B LOGIX5571" || result.Details["identity_revision"] != "20.11" ||
+		result.Details["identity_source"] != "get_attributes_all" {
+		t.Errorf("Unexpected identity details: %v", result.Details)
+	}
+
+	// ListIdentity reply: encapsulation version, socket address, attributes and state
+	item := append(make([]byte, 18), identityAttributes()...)
+	item = append(item, 0x03)
+	cpf := []byte{0x01, 0x00, 0x0C, 0x00}
+	cpf = binary.LittleEndian.AppendUint16(cpf, uint16(len(item)))
+	cpf = append(cpf, item...)
+	result = analyzer.Analyze(createUDPPortsPacket(44818, 50000, encapsulate(0x0064, cpf)))
+	if result == nil {
+		t.Fatal("Expected ListIdentity reply to be analyzed")
+	}
+	if result.Details["identity_vendor_id"] != uint16(1) || result.Details["identity_serial"] != uint32(0x12345678) ||
+		result.Details["identity_source"] != "list_identity" {
+		t.Errorf("Unexpected ListIdentity details: %v", result.Details)
+	}
+}
+
+func TestEtherNetIPAnalyzer_ImplicitIO(t *testing.T) {
+	analyzer := analyzers.NewEtherNetIPAnalyzer()
+
+	datagram := []byte{
+		0x02, 0x00, // Item count
+		0x02, 0x80, 0x08, 0x00, 0x02, 0x00, 0x20, 0x00, 0x05, 0x00, 0x00, 0x00, // Sequenced address
+		0xB1, 0x00, 0x04, 0x00, 0x05, 0x00, 0xAA, 0xBB, // Connected data
+	}
+	packet := createUDPPortsPacket(2222, 2222, datagram)
+	if !analyzer.CanAnalyze(packet) {
+		t.Fatal("Expected UDP 2222 to be analyzed")
+	}
+	result := analyzer.Analyze(packet)
+	if result == nil || result.Protocol != "EtherNet/IP I/O" {
+		t.Fatalf("Expected implicit I/O result, got %+v", result)
+	}
+	if result.Details["connection_id"] != uint32(0x00200002) || result.Details["sequence_number"] != uint32(5) {
+		t.Errorf("Unexpected implicit I/O details: %v", result.Details)
+	}
+}
+
+func createUDPPortsPacket(srcPort, dstPort uint16, payload []byte) gopacket.Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+		DstMAC:       []byte{0x00, 0x06, 0x07, 0x08, 0x09, 0x0a},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolUDP,
+		SrcIP:    []byte{192, 168, 1, 1},
+		DstIP:    []byte{192, 168, 1, 2},
+	}
+
+	udp := &layers.UDP{
+		SrcPort: layers.UDPPort(srcPort),
+		DstPort: layers.UDPPort(dstPort),
+	}
+
+	buffer := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	udp.SetNetworkLayerForChecksum(ip)
+	gopacket.SerializeLayers(buffer, opts, eth, ip, udp, gopacket.Payload(payload))
+
+	return gopacket.NewPacket(buffer.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+}
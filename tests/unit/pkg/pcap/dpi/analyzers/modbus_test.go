@@ -0,0 +1,124 @@
+package analyzers_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestModbusAnalyzer_OperationProfiling(t *testing.T) {
+	analyzer := analyzers.NewModbusAnalyzer()
+
+	tests := []struct {
+		name    string
+		packet  gopacket.Packet
+		details map[string]interface{}
+	}{
+		{
+			name:   "read holding registers request",
+			packet: createTCPPortsPacket(50000, 502, []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x64, 0x00, 0x0A}),
+			details: map[string]interface{}{
+				"direction": "request", "access": "read", "table": "holding_registers",
+				"start_address": uint16(100), "quantity": uint16(10),
+			},
+		},
+		{
+			name:   "read discrete inputs request",
+			packet: createTCPPortsPacket(50000, 502, []byte{0x00, 0x02, 0x00, 0x00, 0x00, 0x06, 0x01, 0x02, 0x00, 0x00, 0x00, 0x08}),
+			details: map[string]interface{}{
+				"access": "read", "table": "discrete_inputs",
+			},
+		},
+		{
+			name:   "write single coil request",
+			packet: createTCPPortsPacket(50000, 502, []byte{0x00, 0x03, 0x00, 0x00, 0x00, 0x06, 0x01, 0x05, 0x00, 0x04, 0xFF, 0x00}),
+			details: map[string]interface{}{
+				"access": "write", "table": "coils", "address": uint16(4),
+			},
+		},
+		{
+			name: "read/write multiple registers request",
+			packet: createTCPPortsPacket(50000, 502, []byte{0x00, 0x04, 0x00, 0x00, 0x00, 0x0F, 0x01, 0x17,
+				0x00, 0x00, 0x00, 0x02, 0x00, 0x20, 0x00, 0x01, 0x02, 0x00, 0x01}),
+			details: map[string]interface{}{
+				"access": "read_write", "write_start_address": uint16(32), "write_quantity": uint16(1),
+			},
+		},
+		{
+			name:   "diagnostics request",
+			packet: createTCPPortsPacket(50000, 502, []byte{0x00, 0x05, 0x00, 0x00, 0x00, 0x06, 0x01, 0x08, 0x00, 0x00, 0x12, 0x34}),
+			details: map[string]interface{}{
+				"access": "diagnostic", "sub_function": uint16(0),
+			},
+		},
+		{
+			name:   "read device identification request",
+			packet: createTCPPortsPacket(50000, 502, []byte{0x00, 0x06, 0x00, 0x00, 0x00, 0x05, 0x01, 0x2B, 0x0E, 0x01, 0x00}),
+			details: map[string]interface{}{
+				"access": "device_identification", "mei_type": uint8(0x0E), "read_device_id_code": uint8(1),
+			},
+		},
+		{
+			name:   "read response carries no address range",
+			packet: createTCPPortsPacket(502, 50000, []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x01, 0x03, 0x02, 0x00, 0x2A}),
+			details: map[string]interface{}{
+				"direction": "response", "access": "read", "start_address": nil,
+			},
+		},
+		{
+			name:   "exception response",
+			packet: createTCPPortsPacket(502, 50000, []byte{0x00, 0x07, 0x00, 0x00, 0x00, 0x03, 0x01, 0x86, 0x02}),
+			details: map[string]interface{}{
+				"direction": "response", "exception": true, "exception_code": uint8(2),
+				"exception_name": "Illegal Data Address", "function_name": "Exception: Write Single Register",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := analyzer.Analyze(tt.packet)
+			if result == nil {
+				t.Fatal("Expected Modbus frame to be analyzed")
+			}
+			for key, expected := range tt.details {
+				if actual := result.Details[key]; actual != expected {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, expected, expected, actual, actual)
+				}
+			}
+		})
+	}
+}
+
+func createTCPPortsPacket(srcPort, dstPort uint16, payload []byte) gopacket.Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+		DstMAC:       []byte{0x00, 0x06, 0x07, 0x08, 0x09, 0x0a},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    []byte{192, 168, 1, 1},
+		DstIP:    []byte{192, 168, 1, 2},
+	}
+
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(srcPort),
+		DstPort: layers.TCPPort(dstPort),
+		PSH:     true,
+		ACK:     true,
+	}
+
+	buffer := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	tcp.SetNetworkLayerForChecksum(ip)
+	gopacket.SerializeLayers(buffer, opts, eth, ip, tcp, gopacket.Payload(payload))
+
+	return gopacket.NewPacket(buffer.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+}
@@ -0,0 +1,141 @@
+package analyzers_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+)
+
+var uaNull = []byte{0xFF, 0xFF, 0xFF, 0xFF}
+
+func uaString(value string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(value)))
+	return append(b, value...)
+}
+
+// uaMessage prepends an OPC UA message header to body
+func uaMessage(messageType string, body []byte) []byte {
+	msg := append([]byte(messageType), 'F')
+	msg = binary.LittleEndian.AppendUint32(msg, uint32(8+len(body)))
+	return append(msg, body...)
+}
+
+// uaServiceType is a four-byte NodeId in namespace 0
+func uaServiceType(id uint16) []byte {
+	return binary.LittleEndian.AppendUint16([]byte{0x01, 0x00}, id)
+}
+
+func uaConcat(parts ...[]byte) []byte {
+	var b []byte
+	for _, part := range parts {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func TestOPCUAAnalyzer_HandshakeAndServices(t *testing.T) {
+	analyzer := analyzers.NewOPCUAAnalyzer()
+
+	hello := uaMessage("HEL", uaConcat(make([]byte, 20), uaString("opc.tcp://plc-7:4841/line3")))
+	packet := createTCPPortsPacket(50000, 4841, hello)
+	if !analyzer.CanAnalyze(packet) {
+		t.Fatal("Expected Hello to be recognised off port 4840")
+	}
+	result := analyzer.Analyze(packet)
+	if result == nil {
+		t.Fatal("Expected Hello to be analyzed")
+	}
+	if result.Protocol != "OPC-UA" || result.Details["endpoint_url"] != "opc.tcp://plc-7:4841/line3" {
+		t.Errorf("Unexpected Hello result: %s %v", result.Protocol, result.Details)
+	}
+
+	requestHeader := uaConcat([]byte{0x00, 0x00}, make([]byte, 16), uaNull, make([]byte, 4), []byte{0x00, 0x00, 0x00})
+	open := uaMessage("OPN", uaConcat(
+		make([]byte, 4),
+		uaString("http://opcfoundation.org/UA/SecurityPolicy#None"), uaNull, uaNull,
+		[]byte{0x33, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00},
+		uaServiceType(446), requestHeader,
+		make([]byte, 8),                // Client protocol version, request type Issue
+		[]byte{0x01, 0x00, 0x00, 0x00}, // Security mode None
+		make([]byte, 4),                // Empty client nonce
+		[]byte{0x80, 0xEE, 0x36, 0x00}, // Requested lifetime
+	))
+	result = analyzer.Analyze(createTCPPortsPacket(50000, 4840, open))
+	if result == nil {
+		t.Fatal("Expected OpenSecureChannel to be analyzed")
+	}
+	expected := map[string]interface{}{
+		"security_policy":      "None",
+		"security_policy_none": true,
+		"security_mode":        "None",
+		"request_type":         "Issue",
+		"opcua_service":        "OpenSecureChannelRequest",
+		"direction":            "request",
+	}
+	for key, value := range expected {
+		if result.Details[key] != value {
+			t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+		}
+	}
+
+	// A signed and encrypted channel only exposes its policy
+	secure := uaMessage("OPN", uaConcat(
+		make([]byte, 4),
+		uaString("http://opcfoundation.org/UA/SecurityPolicy#Basic256Sha256"), uaString("cert"), uaString("thumb"),
+		[]byte{0xDE, 0xAD, 0xBE, 0xEF, 0x01, 0x02, 0x03, 0x04},
+	))
+	result = analyzer.Analyze(createTCPPortsPacket(50000, 4840, secure))
+	if result == nil || result.Details["security_policy"] != "Basic256Sha256" || result.Details["security_policy_none"] != nil {
+		t.Errorf("Unexpected encrypted OpenSecureChannel result: %v", result)
+	}
+
+	read := uaMessage("MSG", uaConcat(make([]byte, 16), uaServiceType(631), requestHeader))
+	result = analyzer.Analyze(createTCPPortsPacket(50000, 4841, read))
+	if result == nil {
+		t.Fatal("Expected Read request to be analyzed")
+	}
+	if result.Details["opcua_service"] != "ReadRequest" || result.Details["opcua_service_id"] != uint16(631) {
+		t.Errorf("Unexpected Read request details: %v", result.Details)
+	}
+}
+
+func TestOPCUAAnalyzer_GetEndpoints(t *testing.T) {
+	analyzer := analyzers.NewOPCUAAnalyzer()
+
+	responseHeader := uaConcat(make([]byte, 16), []byte{0x00}, uaNull, []byte{0x00, 0x00, 0x00})
+	endpoint := uaConcat(
+		uaString("opc.tcp://10.0.0.5:4840"),
+		uaString("urn:plc"), uaString("urn:vendor"),
+		[]byte{0x02}, uaString("PLC"), // Application name
+		make([]byte, 4), uaNull, uaNull, uaNull,
+		uaNull,                         // Server certificate
+		[]byte{0x01, 0x00, 0x00, 0x00}, // Security mode None
+		uaString("http://opcfoundation.org/UA/SecurityPolicy#None"),
+		[]byte{0x01, 0x00, 0x00, 0x00}, uaString("anonymous"), make([]byte, 4), uaNull, uaNull, uaNull,
+		uaString("http://opcfoundation.org/UA-Profile/Transport/uatcp-uasc-uabinary"),
+		[]byte{0x00},
+	)
+	response := uaMessage("MSG", uaConcat(
+		make([]byte, 16), uaServiceType(431), responseHeader,
+		[]byte{0x01, 0x00, 0x00, 0x00}, endpoint,
+	))
+
+	result := analyzer.Analyze(createTCPPortsPacket(4840, 50000, response))
+	if result == nil {
+		t.Fatal("Expected GetEndpoints response to be analyzed")
+	}
+	urls, _ := result.Details["endpoint_urls"].([]string)
+	if len(urls) != 1 || urls[0] != "opc.tcp://10.0.0.5:4840" || result.Details["direction"] != "response" {
+		t.Errorf("Unexpected GetEndpoints details: %v", result.Details)
+	}
+	endpoints, _ := result.Details["endpoints"].([]string)
+	if len(endpoints) != 1 || endpoints[0] != "opc.tcp://10.0.0.5:4840 None/None" {
+		t.Errorf("Unexpected endpoint summary: %v", endpoints)
+	}
+
+	// Text protocols that happen to start with a message type are rejected
+	if analyzer.CanAnalyze(createTCPPortsPacket(50000, 8080, []byte("HELLO world\r\n"))) {
+		t.Error("Expected non-OPC UA payload to be rejected")
+	}
+}
@@ -0,0 +1,208 @@
+package analyzers_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// profinetFrame builds an Ethernet frame carrying a PROFINET payload, optionally
+// behind a priority tag
+func profinetFrame(payload []byte, tagged bool) gopacket.Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       []byte{0x00, 0x1B, 0x1B, 0x01, 0x02, 0x03},
+		DstMAC:       []byte{0x01, 0x0E, 0xCF, 0x00, 0x00, 0x00},
+		EthernetType: layers.EthernetType(0x8892),
+	}
+	serialized := []gopacket.SerializableLayer{eth}
+	if tagged {
+		eth.EthernetType = layers.EthernetTypeDot1Q
+		serialized = append(serialized, &layers.Dot1Q{Priority: 6, Type: layers.EthernetType(0x8892)})
+	}
+	serialized = append(serialized, gopacket.Payload(payload))
+
+	buffer := gopacket.NewSerializeBuffer()
+	gopacket.SerializeLayers(buffer, gopacket.SerializeOptions{FixLengths: true}, serialized...)
+	return gopacket.NewPacket(buffer.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+}
+
+// dcpBlock encodes a DCP block; prefixed blocks carry BlockInfo or a BlockQualifier
+func dcpBlock(option, suboption byte, prefixed bool, value []byte) []byte {
+	if prefixed {
+		value = append([]byte{0x00, 0x00}, value...)
+	}
+	block := []byte{option, suboption, 0x00, 0x00}
+	binary.BigEndian.PutUint16(block[2:4], uint16(len(value)))
+	block = append(block, value...)
+	if len(value)%2 != 0 {
+		block = append(block, 0x00)
+	}
+	return block
+}
+
+// dcpPDU builds a DCP PDU with the given frame ID, service and blocks
+func dcpPDU(frameID uint16, service, serviceType byte, blocks ...[]byte) []byte {
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, block...)
+	}
+	pdu := make([]byte, 12)
+	binary.BigEndian.PutUint16(pdu[0:2], frameID)
+	pdu[2] = service
+	pdu[3] = serviceType
+	binary.BigEndian.PutUint32(pdu[4:8], 0x01000001)
+	binary.BigEndian.PutUint16(pdu[10:12], uint16(len(data)))
+	return append(pdu, data...)
+}
+
+var pnIPBlock = []byte{192, 168, 0, 10, 255, 255, 255, 0, 192, 168, 0, 1}
+
+func TestProfinetAnalyzer_DCP(t *testing.T) {
+	analyzer := analyzers.NewProfinetAnalyzer()
+
+	tests := []struct {
+		name    string
+		payload []byte
+		details map[string]interface{}
+		absent  []string
+	}{
+		{
+			name: "Identify response",
+			payload: dcpPDU(0xFEFF, 0x05, 0x01,
+				dcpBlock(0x02, 0x01, true, []byte("S7-1500")),
+				dcpBlock(0x02, 0x02, true, []byte("plc-line1")),
+				dcpBlock(0x02, 0x03, true, []byte{0x00, 0x2A, 0x01, 0x0E}),
+				dcpBlock(0x02, 0x04, true, []byte{0x03, 0x00}),
+				dcpBlock(0x01, 0x02, true, pnIPBlock)),
+			details: map[string]interface{}{
+				"frame_type": "DCP", "dcp_service": "Identify", "direction": "response",
+				"type_of_station": "S7-1500", "name_of_station": "plc-line1",
+				"vendor_id": uint16(0x002A), "device_id": uint16(0x010E), "device_role": "IO-Device,IO-Controller",
+				"ip_address": "192.168.0.10", "subnet_mask": "255.255.255.0", "gateway": "192.168.0.1",
+			},
+		},
+		{
+			name:    "Identify request by name",
+			payload: dcpPDU(0xFEFE, 0x05, 0x00, dcpBlock(0x02, 0x02, false, []byte("io-device-7"))),
+			details: map[string]interface{}{
+				"dcp_service": "Identify", "direction": "request", "identify_filter_name": "io-device-7",
+			},
+			absent: []string{"name_of_station"},
+		},
+		{
+			name: "Set NameOfStation and IP",
+			payload: dcpPDU(0xFEFD, 0x04, 0x00,
+				dcpBlock(0x02, 0x02, true, []byte("plc-new")),
+				dcpBlock(0x01, 0x02, true, pnIPBlock),
+				dcpBlock(0x05, 0x02, true, nil)),
+			details: map[string]interface{}{
+				"dcp_service": "Set", "dcp_set_name": "plc-new", "dcp_set_ip": "192.168.0.10",
+				"dcp_control": "End Transaction", "high_risk_operation": "DCP Set NameOfStation + IP",
+			},
+		},
+		{
+			name:    "Set factory reset",
+			payload: dcpPDU(0xFEFD, 0x04, 0x00, dcpBlock(0x05, 0x06, true, []byte{0x00, 0x02})),
+			details: map[string]interface{}{
+				"dcp_control": "Reset to Factory", "high_risk_operation": "DCP Set Reset to Factory",
+			},
+		},
+		{
+			name:    "Set response",
+			payload: dcpPDU(0xFEFD, 0x04, 0x01, dcpBlock(0x05, 0x04, false, []byte{0x02, 0x02, 0x00})),
+			details: map[string]interface{}{"dcp_service": "Set", "direction": "response"},
+			absent:  []string{"high_risk_operation"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := profinetFrame(tt.payload, false)
+			if !analyzer.CanAnalyze(packet) {
+				t.Fatal("Expected analyzer to accept a PROFINET frame")
+			}
+			result := analyzer.Analyze(packet)
+			if result == nil {
+				t.Fatal("Expected frame to be analyzed")
+			}
+			for key, value := range tt.details {
+				if result.Details[key] != value {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+				}
+			}
+			for _, key := range tt.absent {
+				if value, exists := result.Details[key]; exists {
+					t.Errorf("Expected no %s, got %v", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestProfinetAnalyzer_RealTime(t *testing.T) {
+	analyzer := analyzers.NewProfinetAnalyzer()
+
+	// 40 bytes of I/O data, cycle counter 0x1234, data status valid/run, transfer status OK
+	cyclic := make([]byte, 46)
+	binary.BigEndian.PutUint16(cyclic[0:2], 0x8001)
+	copy(cyclic[42:], []byte{0x12, 0x34, 0x35, 0x00})
+
+	// RTA DATA PDU carrying a Diagnosis alarm for slot 1, subslot 0x8001
+	alarm := []byte{0xFE, 0x01, 0x00, 0x01, 0x00, 0x02, 0x11, 0x00, 0x00, 0x05, 0xFF, 0xFF, 0x00, 0x10,
+		0x00, 0x02, 0x00, 0x0C, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x80, 0x01}
+
+	tests := []struct {
+		name    string
+		payload []byte
+		tagged  bool
+		details map[string]interface{}
+	}{
+		{
+			name:    "tagged cyclic RT_CLASS_1",
+			payload: cyclic,
+			tagged:  true,
+			details: map[string]interface{}{
+				"frame_type": "RT", "rt_class": "RT_CLASS_1", "io_data_length": 40,
+				"cycle_counter": uint16(0x1234), "data_valid": true, "provider_state": "run",
+			},
+		},
+		{
+			name:    "low priority alarm",
+			payload: alarm,
+			details: map[string]interface{}{
+				"frame_type": "Alarm", "alarm_priority": "low", "alarm_pdu_type": "DATA", "send_sequence": uint16(5),
+				"alarm_type": "Diagnosis", "slot": uint16(1), "subslot": uint16(0x8001),
+			},
+		},
+		{
+			name:    "PTCP",
+			payload: []byte{0xFF, 0x40, 0x00, 0x00},
+			details: map[string]interface{}{"frame_type": "PTCP"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := analyzer.Analyze(profinetFrame(tt.payload, tt.tagged))
+			if result == nil {
+				t.Fatal("Expected frame to be analyzed")
+			}
+			for key, value := range tt.details {
+				if result.Details[key] != value {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+				}
+			}
+		})
+	}
+}
+
+func TestProfinetAnalyzer_RejectsOtherEtherTypes(t *testing.T) {
+	analyzer := analyzers.NewProfinetAnalyzer()
+	if analyzer.CanAnalyze(createUDPPacket(34964, "\xfe\xfe\x05\x00")) {
+		t.Error("Expected analyzer to reject UDP traffic")
+	}
+}
@@ -0,0 +1,204 @@
+package analyzers_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+)
+
+// tpkt wraps an S7 PDU in a TPKT header and a COTP data TPDU
+func tpkt(pdu []byte) []byte {
+	frame := []byte{0x03, 0x00, 0x00, 0x00, 0x02, 0xF0, 0x80}
+	frame = append(frame, pdu...)
+	binary.BigEndian.PutUint16(frame[2:4], uint16(len(frame)))
+	return frame
+}
+
+// s7PDU builds an S7comm PDU; ack-data PDUs get a zero error class and code
+func s7PDU(rosctr byte, param, data []byte) []byte {
+	pdu := []byte{0x32, rosctr, 0x00, 0x00, 0x01, 0x00}
+	pdu = binary.BigEndian.AppendUint16(pdu, uint16(len(param)))
+	pdu = binary.BigEndian.AppendUint16(pdu, uint16(len(data)))
+	if rosctr == 0x02 || rosctr == 0x03 {
+		pdu = append(pdu, 0x00, 0x00)
+	}
+	pdu = append(pdu, param...)
+	return append(pdu, data...)
+}
+
+func padded(value string, length int) []byte {
+	field := make([]byte, length)
+	for i := range field {
+		field[i] = ' '
+	}
+	copy(field, value)
+	return field
+}
+
+func TestS7CommAnalyzer_JobFunctions(t *testing.T) {
+	analyzer := analyzers.NewS7CommAnalyzer()
+
+	tests := []struct {
+		name    string
+		param   []byte
+		details map[string]interface{}
+	}{
+		{
+			name:  "setup communication",
+			param: []byte{0xF0, 0x00, 0x00, 0x01, 0x00, 0x01, 0x01, 0xE0},
+			details: map[string]interface{}{
+				"function_name": "Setup Communication", "pdu_length": uint16(480),
+			},
+		},
+		{
+			name:  "read var",
+			param: []byte{0x04, 0x01, 0x12, 0x0A, 0x10, 0x02, 0x00, 0x04, 0x00, 0x01, 0x84, 0x00, 0x00, 0x00},
+			details: map[string]interface{}{
+				"function_code": uint8(0x04), "item_count": uint8(1),
+			},
+		},
+		{
+			name:  "plc stop",
+			param: append([]byte{0x29, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09}, "P_PROGRAM"...),
+			details: map[string]interface{}{
+				"function_name": "PLC Stop", "pi_service": "P_PROGRAM", "plc_control": "stop",
+				"high_risk_operation": "PLC Stop",
+			},
+		},
+		{
+			name: "plc control start",
+			param: append([]byte{0x28, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFD, 0x00, 0x00, 0x09},
+				"P_PROGRAM"...),
+			details: map[string]interface{}{
+				"function_name": "PLC Control", "plc_control": "start",
+			},
+		},
+		{
+			name:  "request download",
+			param: append([]byte{0x1A, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09}, "_0800001P"...),
+			details: map[string]interface{}{
+				"function_name": "Request Download", "block": "OB1", "high_risk_operation": "Program Download",
+			},
+		},
+		{
+			name:  "start upload",
+			param: append([]byte{0x1D, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09}, "_0A00042A"...),
+			details: map[string]interface{}{
+				"function_name": "Start Upload", "block": "DB42",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet := createTCPPortsPacket(50000, 102, tpkt(s7PDU(0x01, tt.param, nil)))
+			if !analyzer.CanAnalyze(packet) {
+				t.Fatal("Expected analyzer to accept port 102 traffic")
+			}
+			result := analyzer.Analyze(packet)
+			if result == nil {
+				t.Fatal("Expected job to be analyzed")
+			}
+			if result.Protocol != "S7Comm" || result.Details["s7_pdu_type"] != "Job" {
+				t.Errorf("Unexpected result: %s %v", result.Protocol, result.Details)
+			}
+			for key, value := range tt.details {
+				if result.Details[key] != value {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+				}
+			}
+		})
+	}
+
+	// The CPU acknowledging a stop is not itself a high-risk request
+	result := analyzer.Analyze(createTCPPortsPacket(102, 50000, tpkt(s7PDU(0x03, []byte{0x29}, nil))))
+	if result == nil {
+		t.Fatal("Expected ack-data to be analyzed")
+	}
+	if _, flagged := result.Details["high_risk_operation"]; flagged || result.Details["direction"] != "response" {
+		t.Errorf("Unexpected ack-data details: %v", result.Details)
+	}
+}
+
+func TestS7CommAnalyzer_SZLResponse(t *testing.T) {
+	analyzer := analyzers.NewS7CommAnalyzer()
+
+	// Read SZL request for module identification
+	param := []byte{0x00, 0x01, 0x12, 0x04, 0x11, 0x44, 0x01, 0x00}
+	data := []byte{0xFF, 0x09, 0x00, 0x04, 0x00, 0x11, 0x00, 0x00}
+	result := analyzer.Analyze(createTCPPortsPacket(50000, 102, tpkt(s7PDU(0x07, param, data))))
+	if result == nil {
+		t.Fatal("Expected SZL request to be analyzed")
+	}
+	if result.Details["userdata_function"] != "Read SZL" || result.Details["szl_id"] != uint16(0x0011) {
+		t.Errorf("Unexpected SZL request details: %v", result.Details)
+	}
+
+	// Response with the order number and firmware records
+	records := []byte{0x00, 0x01}
+	records = append(records, padded("6ES7 315-2EH14-0AB0", 20)...)
+	records = append(records, 0x00, 0xC0, 0x00, 0x04, 0x00, 0x01)
+	records = append(records, 0x00, 0x07)
+	records = append(records, padded("", 20)...)
+	records = append(records, 0x00, 0xC0, 'V', 3, 2, 6)
+
+	param = []byte{0x00, 0x01, 0x12, 0x08, 0x12, 0x84, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00}
+	data = []byte{0xFF, 0x09, 0x00, 0x00, 0x00, 0x11, 0x00, 0x00, 0x00, 0x1C, 0x00, 0x02}
+	data = append(data, records...)
+	binary.BigEndian.PutUint16(data[2:4], uint16(len(data)-4))
+
+	result = analyzer.Analyze(createTCPPortsPacket(102, 50000, tpkt(s7PDU(0x07, param, data))))
+	if result == nil {
+		t.Fatal("Expected SZL response to be analyzed")
+	}
+	if result.Details["s7_order_number"] != "6ES7 315-2EH14-0AB0" || result.Details["s7_firmware_version"] != "V3.2.6" {
+		t.Errorf("Unexpected SZL response details: %v", result.Details)
+	}
+
+	// Component identification carries the module type name
+	records = []byte{0x00, 0x07}
+	records = append(records, padded("CPU 315-2 PN/DP", 32)...)
+	data = []byte{0xFF, 0x09, 0x00, 0x00, 0x00, 0x1C, 0x00, 0x00, 0x00, 0x22, 0x00, 0x01}
+	data = append(data, records...)
+	result = analyzer.Analyze(createTCPPortsPacket(102, 50000, tpkt(s7PDU(0x07, param, data))))
+	if result == nil || result.Details["s7_module_type"] != "CPU 315-2 PN/DP" {
+		t.Errorf("Expected module type to be decoded, got %v", result)
+	}
+}
+
+func TestS7CommAnalyzer_COTPAndPlus(t *testing.T) {
+	analyzer := analyzers.NewS7CommAnalyzer()
+
+	// Connection request to rack 0, slot 2
+	cr := []byte{0x03, 0x00, 0x00, 0x16, 0x11, 0xE0, 0x00, 0x00, 0x00, 0x01, 0x00,
+		0xC0, 0x01, 0x0A, 0xC1, 0x02, 0x01, 0x00, 0xC2, 0x02, 0x01, 0x02}
+	result := analyzer.Analyze(createTCPPortsPacket(50000, 102, cr))
+	if result == nil {
+		t.Fatal("Expected COTP connection request to be analyzed")
+	}
+	if result.Details["cotp_pdu_type"] != "Connection Request" || result.Details["slot"] != uint8(2) ||
+		result.Details["dst_tsap"] != uint16(0x0102) {
+		t.Errorf("Unexpected connection request details: %v", result.Details)
+	}
+
+	// S7comm-plus is identified by opcode on any port
+	plus := tpkt([]byte{0x72, 0x03, 0x00, 0x1D, 0x31, 0x00, 0x00, 0x04, 0xCA})
+	packet := createTCPPortsPacket(50000, 40000, plus)
+	if !analyzer.CanAnalyze(packet) {
+		t.Fatal("Expected TPKT payload to be recognised off port 102")
+	}
+	result = analyzer.Analyze(packet)
+	if result == nil {
+		t.Fatal("Expected S7comm-plus to be analyzed")
+	}
+	if result.Details["s7comm_plus"] != true || result.Details["s7plus_opcode_name"] != "Request" ||
+		result.Subprotocol != "S7comm-plus (Request)" {
+		t.Errorf("Unexpected S7comm-plus result: %s %v", result.Subprotocol, result.Details)
+	}
+
+	// Other ISO-on-TCP payloads are not S7
+	if result := analyzer.Analyze(createTCPPortsPacket(50000, 102, tpkt([]byte{0x61, 0x82}))); result != nil {
+		t.Errorf("Expected non-S7 payload to be rejected, got %v", result)
+	}
+}
@@ -0,0 +1,251 @@
+package analyzers_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"cipgram/pkg/pcap/dpi/analyzers"
+)
+
+// tlsVector prefixes data with its length in n bytes
+func tlsVector(n int, data ...[]byte) []byte {
+	var body []byte
+	for _, d := range data {
+		body = append(body, d...)
+	}
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(body)))
+	return append(length[4-n:], body...)
+}
+
+func tlsUint16s(values ...uint16) []byte {
+	var out []byte
+	for _, v := range values {
+		out = binary.BigEndian.AppendUint16(out, v)
+	}
+	return out
+}
+
+func tlsExtension(extType uint16, data []byte) []byte {
+	return append(tlsUint16s(extType), tlsVector(2, data)...)
+}
+
+// tlsRecord wraps handshake messages of the given type into one handshake record
+func tlsRecord(version uint16, messages ...[]byte) []byte {
+	record := append([]byte{0x16}, tlsUint16s(version)...)
+	return append(record, tlsVector(2, messages...)...)
+}
+
+func tlsHandshakeMessage(msgType byte, body []byte) []byte {
+	return append([]byte{msgType}, tlsVector(3, body)...)
+}
+
+func tlsClientHello(version uint16, ciphers []uint16, extensions ...[]byte) []byte {
+	body := tlsUint16s(version)
+	body = append(body, make([]byte, 32)...)           // Random
+	body = append(body, tlsVector(1, []byte{0x01})...) // Session ID
+	body = append(body, tlsVector(2, tlsUint16s(ciphers...))...)
+	body = append(body, tlsVector(1, []byte{0x00})...) // null compression
+	body = append(body, tlsVector(2, extensions...)...)
+	return tlsHandshakeMessage(0x01, body)
+}
+
+func tlsServerHello(version, suite uint16, extensions ...[]byte) []byte {
+	body := tlsUint16s(version)
+	body = append(body, make([]byte, 32)...)
+	body = append(body, tlsVector(1)...)
+	body = append(body, tlsUint16s(suite)...)
+	body = append(body, 0x00)
+	body = append(body, tlsVector(2, extensions...)...)
+	return tlsHandshakeMessage(0x02, body)
+}
+
+// modernClientHello offers TLS 1.3 and 1.2 with GREASE values that fingerprints must skip
+var modernClientHello = tlsRecord(0x0301, tlsClientHello(0x0303, []uint16{0x0A0A, 0x1301, 0xC02F},
+	tlsExtension(0x1A1A, nil),
+	tlsExtension(0x0000, tlsVector(2, []byte{0x00}, tlsVector(2, []byte("plc1.plant.local")))),
+	tlsExtension(0x000A, tlsVector(2, tlsUint16s(0x2A2A, 29, 23))),
+	tlsExtension(0x000B, tlsVector(1, []byte{0x00})),
+	tlsExtension(0x000D, tlsVector(2, tlsUint16s(0x0403, 0x0804))),
+	tlsExtension(0x0010, tlsVector(2, tlsVector(1, []byte("h2")), tlsVector(1, []byte("http/1.1")))),
+	tlsExtension(0x002B, tlsVector(1, tlsUint16s(0x3A3A, 0x0304, 0x0303))),
+))
+
+func sha256Prefix(input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func TestTLSAnalyzer_ClientHello(t *testing.T) {
+	analyzer := analyzers.NewTLSAnalyzer()
+
+	// TLS on a vendor port rather than 443
+	packet := createTCPPortsPacket(50000, 10443, modernClientHello)
+	if !analyzer.CanAnalyze(packet) {
+		t.Fatal("Expected analyzer to accept a ClientHello on a non-standard port")
+	}
+	result := analyzer.Analyze(packet)
+	if result == nil {
+		t.Fatal("Expected ClientHello to be analyzed")
+	}
+
+	ja3 := "771,4865-49199,0-10-11-13-16-43,29-23,0"
+	details := map[string]interface{}{
+		"direction":      "request",
+		"client_version": "TLS 1.2",
+		"server_name":    "plc1.plant.local",
+		"ja3":            ja3,
+		"ja3_hash":       fmt.Sprintf("%x", md5.Sum([]byte(ja3))),
+		"ja4": "t13d0206h2_" + sha256Prefix("1301,c02f") + "_" +
+			sha256Prefix("000a,000b,000d,002b_0403,0804"),
+	}
+	for key, value := range details {
+		if result.Details[key] != value {
+			t.Errorf("Detail %s: expected %v, got %v", key, value, result.Details[key])
+		}
+	}
+	if alpn, _ := result.Details["alpn"].([]string); !reflect.DeepEqual(alpn, []string{"h2", "http/1.1"}) {
+		t.Errorf("Expected ALPN [h2 http/1.1], got %v", result.Details["alpn"])
+	}
+	if versions, _ := result.Details["supported_versions"].([]string); !reflect.DeepEqual(versions, []string{"TLS 1.3", "TLS 1.2"}) {
+		t.Errorf("Expected supported versions without GREASE, got %v", result.Details["supported_versions"])
+	}
+	if _, weak := result.Details["tls_weaknesses"]; weak {
+		t.Errorf("Expected no weaknesses for a TLS 1.3 client, got %v", result.Details["tls_weaknesses"])
+	}
+	if result.Confidence < 0.9 {
+		t.Errorf("Expected high confidence for a parsed handshake, got %f", result.Confidence)
+	}
+}
+
+func TestTLSAnalyzer_ServerHello(t *testing.T) {
+	analyzer := analyzers.NewTLSAnalyzer()
+
+	tests := []struct {
+		name       string
+		payload    []byte
+		details    map[string]interface{}
+		weaknesses []string
+	}{
+		{
+			name:    "TLS 1.0 with RC4",
+			payload: tlsRecord(0x0301, tlsServerHello(0x0301, 0x0005, tlsExtension(0xFF01, []byte{0x00}))),
+			details: map[string]interface{}{
+				"direction": "response", "tls_version": "TLS 1.0", "cipher_suite": uint16(0x0005),
+				"cipher_suite_name": "TLS_RSA_WITH_RC4_128_SHA", "ja3s": "769,5,65281",
+			},
+			weaknesses: []string{"TLS 1.0", "TLS_RSA_WITH_RC4_128_SHA"},
+		},
+		{
+			name:    "TLS 1.3 via supported_versions",
+			payload: tlsRecord(0x0303, tlsServerHello(0x0303, 0x1302, tlsExtension(0x002B, tlsUint16s(0x0304)))),
+			details: map[string]interface{}{
+				"tls_version": "TLS 1.3", "cipher_suite_name": "TLS_AES_256_GCM_SHA384", "ja3s": "771,4866,43",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := analyzer.Analyze(createTCPPortsPacket(4843, 50000, tt.payload))
+			if result == nil {
+				t.Fatal("Expected ServerHello to be analyzed")
+			}
+			for key, value := range tt.details {
+				if result.Details[key] != value {
+					t.Errorf("Detail %s: expected %v (%T), got %v (%T)", key, value, value, result.Details[key], result.Details[key])
+				}
+			}
+			weaknesses, _ := result.Details["tls_weaknesses"].([]string)
+			if !reflect.DeepEqual(weaknesses, tt.weaknesses) {
+				t.Errorf("Expected weaknesses %v, got %v", tt.weaknesses, weaknesses)
+			}
+		})
+	}
+}
+
+func TestTLSAnalyzer_Certificate(t *testing.T) {
+	analyzer := analyzers.NewTLSAnalyzer()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	subject := pkix.Name{CommonName: "plc1", Organization: []string{"Siemens AG"}}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      subject,
+		NotBefore:    time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	// ServerHello, Certificate and ServerHelloDone in one record
+	certificates := tlsVector(3, tlsVector(3, der))
+	payload := tlsRecord(0x0303,
+		tlsServerHello(0x0303, 0xC02F),
+		tlsHandshakeMessage(0x0B, certificates),
+		tlsHandshakeMessage(0x0E, nil))
+
+	result := analyzer.Analyze(createTCPPortsPacket(443, 50000, payload))
+	if result == nil {
+		t.Fatal("Expected handshake to be analyzed")
+	}
+	details := map[string]interface{}{
+		"cert_subject":      "CN=plc1,O=Siemens AG",
+		"cert_issuer":       "CN=plc1,O=Siemens AG",
+		"cert_organization": "Siemens AG",
+		"cert_self_signed":  true,
+		"cipher_suite_name": "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"tls_version":       "TLS 1.2",
+	}
+	for key, value := range details {
+		if result.Details[key] != value {
+			t.Errorf("Detail %s: expected %v, got %v", key, value, result.Details[key])
+		}
+	}
+	if notAfter, _ := result.Details["cert_not_after"].(time.Time); !notAfter.Equal(template.NotAfter) {
+		t.Errorf("Expected not after %v, got %v", template.NotAfter, result.Details["cert_not_after"])
+	}
+	names, _ := result.Details["handshake_types"].([]string)
+	if !reflect.DeepEqual(names, []string{"ServerHello", "Certificate", "ServerHelloDone"}) {
+		t.Errorf("Expected three handshake messages, got %v", names)
+	}
+}
+
+func TestTLSAnalyzer_RejectsNonTLS(t *testing.T) {
+	analyzer := analyzers.NewTLSAnalyzer()
+
+	payloads := map[string][]byte{
+		"Modbus request":      {0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x00, 0x00, 0x0A},
+		"HTTP request":        []byte("GET / HTTP/1.1\r\n"),
+		"unknown handshake":   {0x16, 0x03, 0x01, 0x00, 0x04, 0x63, 0x00, 0x00, 0x00},
+		"SSLv2-style version": {0x16, 0x02, 0x00, 0x00, 0x04, 0x01, 0x00, 0x00, 0x00},
+	}
+	for name, payload := range payloads {
+		if analyzer.CanAnalyze(createTCPPortsPacket(50000, 443, payload)) {
+			t.Errorf("Expected analyzer to reject %s", name)
+		}
+	}
+
+	// Application data is still recognised from the record header
+	appData := []byte{0x17, 0x03, 0x03, 0x00, 0x04, 0xDE, 0xAD, 0xBE, 0xEF}
+	if !analyzer.CanAnalyze(createTCPPortsPacket(50000, 443, appData)) {
+		t.Error("Expected analyzer to accept an application data record")
+	}
+}
@@ -0,0 +1,165 @@
+package dpi_test
+
+import (
+	"testing"
+	"time"
+
+	"cipgram/pkg/pcap/core"
+	"cipgram/pkg/pcap/dpi"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+var (
+	readHoldingRegisters = []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x64, 0x00, 0x0A}
+	writeSingleCoil      = []byte{0x00, 0x02, 0x00, 0x00, 0x00, 0x06, 0x01, 0x05, 0x00, 0x04, 0xFF, 0x00}
+)
+
+func TestModularDPIEngine_AnalyzeStream(t *testing.T) {
+	both := append(append([]byte(nil), readHoldingRegisters...), writeSingleCoil...)
+
+	tests := []struct {
+		name     string
+		segments []segment
+		want     [][]string // Function names of the results per segment
+	}{
+		{
+			name: "ADU split across segments",
+			segments: []segment{
+				{seq: 1000, payload: readHoldingRegisters[:8]},
+				{seq: 1008, payload: readHoldingRegisters[8:]},
+			},
+			want: [][]string{nil, {"Read Holding Registers"}},
+		},
+		{
+			name: "first segment shorter than the ADU header",
+			segments: []segment{
+				{seq: 999, syn: true},
+				{seq: 1000, payload: readHoldingRegisters[:5]},
+				{seq: 1005, payload: readHoldingRegisters[5:]},
+				{seq: 1012, payload: writeSingleCoil},
+			},
+			want: [][]string{nil, nil, {"Read Holding Registers"}, {"Write Single Coil"}},
+		},
+		{
+			name:     "several ADUs in one segment",
+			segments: []segment{{seq: 1000, payload: both}},
+			want:     [][]string{{"Read Holding Registers", "Write Single Coil"}},
+		},
+		{
+			name: "ADU boundary inside a segment",
+			segments: []segment{
+				{seq: 1000, payload: both[:16]},
+				{seq: 1016, payload: both[16:]},
+			},
+			want: [][]string{{"Read Holding Registers"}, {"Write Single Coil"}},
+		},
+		{
+			name: "out of order segments",
+			segments: []segment{
+				{seq: 1000, payload: both[:8]},
+				{seq: 1016, payload: both[16:]},
+				{seq: 1008, payload: both[8:16]},
+			},
+			want: [][]string{nil, nil, {"Read Holding Registers", "Write Single Coil"}},
+		},
+		{
+			name: "retransmission",
+			segments: []segment{
+				{seq: 1000, payload: readHoldingRegisters},
+				{seq: 1000, payload: readHoldingRegisters},
+				{seq: 1012, payload: writeSingleCoil},
+			},
+			want: [][]string{{"Read Holding Registers"}, nil, {"Write Single Coil"}},
+		},
+		{
+			name: "connection reopened with a new SYN",
+			segments: []segment{
+				{seq: 1000, payload: readHoldingRegisters[:8]},
+				{seq: 5000, syn: true},
+				{seq: 5001, payload: writeSingleCoil},
+			},
+			want: [][]string{nil, nil, {"Write Single Coil"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := dpi.NewModularDPIEngine(&core.DPIConfig{EnableIndustrial: true})
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			for i, seg := range tt.segments {
+				packet := createModbusSegment(seg, start.Add(time.Duration(i)*time.Millisecond))
+				results := engine.AnalyzeStream(packet)
+				if len(results) != len(tt.want[i]) {
+					t.Fatalf("segment %d: expected %d results, got %d", i, len(tt.want[i]), len(results))
+				}
+				for j, result := range results {
+					if result.Protocol != "Modbus TCP" || result.Subprotocol != tt.want[i][j] {
+						t.Errorf("segment %d result %d: expected Modbus TCP %q, got %s %q",
+							i, j, tt.want[i][j], result.Protocol, result.Subprotocol)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestModularDPIEngine_AnalyzeStreamIdleTimeout(t *testing.T) {
+	engine := dpi.NewModularDPIEngine(&core.DPIConfig{EnableIndustrial: true})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	engine.AnalyzeStream(createModbusSegment(segment{seq: 1000, payload: readHoldingRegisters[:8]}, start))
+
+	// The rest of the ADU arrives after the stream went idle, so it cannot complete it
+	later := createModbusSegment(segment{seq: 1008, payload: readHoldingRegisters[8:]}, start.Add(10*time.Minute))
+	for _, result := range engine.AnalyzeStream(later) {
+		if result.Subprotocol == "Read Holding Registers" {
+			t.Error("Expected the idle stream to start over")
+		}
+	}
+
+	results := engine.AnalyzeStream(createModbusSegment(segment{seq: 1012, payload: writeSingleCoil}, start.Add(10*time.Minute)))
+	if len(results) != 1 || results[0].Subprotocol != "Write Single Coil" {
+		t.Errorf("Expected the next ADU to be analyzed, got %v", results)
+	}
+}
+
+type segment struct {
+	seq     uint32
+	syn     bool
+	payload []byte
+}
+
+func createModbusSegment(seg segment, timestamp time.Time) gopacket.Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+		DstMAC:       []byte{0x00, 0x06, 0x07, 0x08, 0x09, 0x0a},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    []byte{192, 168, 1, 1},
+		DstIP:    []byte{192, 168, 1, 2},
+	}
+	tcp := &layers.TCP{
+		SrcPort: 50000,
+		DstPort: 502,
+		Seq:     seg.seq,
+		SYN:     seg.syn,
+		ACK:     !seg.syn,
+		PSH:     len(seg.payload) > 0,
+	}
+
+	buffer := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	tcp.SetNetworkLayerForChecksum(ip)
+	gopacket.SerializeLayers(buffer, opts, eth, ip, tcp, gopacket.Payload(seg.payload))
+
+	packet := gopacket.NewPacket(buffer.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	packet.Metadata().Timestamp = timestamp
+	return packet
+}
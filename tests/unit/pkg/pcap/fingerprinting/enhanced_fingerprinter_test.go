@@ -87,6 +87,40 @@ func TestFingerprintDevice(t *testing.T) {
 	}
 }
 
+func TestFingerprintDevice_TLSCertificate(t *testing.T) {
+	fingerprinter := fingerprinting.NewEnhancedDeviceFingerprinter()
+
+	// No MAC or protocol hints: the device is only known from its TLS certificate
+	asset := &types.Asset{
+		ID: "10.0.1.20",
+		IP: "10.0.1.20",
+		TLS: &types.TLSProfile{
+			JA3S: []string{"ae4edc6faf64d08308082ad26be60767"},
+			Certificates: []*types.TLSCertificate{
+				{Subject: "CN=plc1,O=Siemens AG", Issuer: "CN=plc1,O=Siemens AG", Organization: "Siemens AG", SelfSigned: true},
+			},
+		},
+	}
+
+	deviceInfo := fingerprinter.FingerprintDevice(asset, nil)
+	if deviceInfo.Manufacturer != "Siemens AG" {
+		t.Errorf("Expected manufacturer from certificate, got %s", deviceInfo.Manufacturer)
+	}
+	if deviceInfo.DeviceType != "PLC" {
+		t.Errorf("Expected PLC from certificate vendor, got %s", deviceInfo.DeviceType)
+	}
+
+	found := false
+	for _, indicator := range deviceInfo.Indicators {
+		if indicator == "TLS Certificate: CN=plc1,O=Siemens AG" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected certificate indicator, got %v", deviceInfo.Indicators)
+	}
+}
+
 func TestUpdateSignatures(t *testing.T) {
 	fingerprinter := fingerprinting.NewEnhancedDeviceFingerprinter()
 
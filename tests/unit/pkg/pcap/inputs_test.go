@@ -0,0 +1,76 @@
+package pcap_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"cipgram/pkg/pcap"
+)
+
+// writeCapture writes an empty little-endian pcap file with an Ethernet link type
+func writeCapture(t *testing.T, dir, name string) string {
+	t.Helper()
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], 0xA1B2C3D4)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], 65535)
+	binary.LittleEndian.PutUint32(header[20:24], 1)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, header, 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestExpandCaptureInputs(t *testing.T) {
+	span1 := t.TempDir()
+	span2 := t.TempDir()
+
+	// Rotated files are recognised by their header, not their extension
+	first := writeCapture(t, span1, "trace.pcap0")
+	second := writeCapture(t, span1, "trace.pcap1")
+	if err := os.WriteFile(filepath.Join(span1, "notes.txt"), []byte("SPAN port 3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	east := writeCapture(t, span2, "east.pcapng")
+	west := writeCapture(t, span2, "west.cap")
+
+	pattern := filepath.Join(span2, "*")
+	files, err := pcap.ExpandCaptureInputs([]string{span1, pattern, first})
+	if err != nil {
+		t.Fatalf("ExpandCaptureInputs failed: %v", err)
+	}
+	// Files keep the input they were first found by as their capture point
+	expected := []pcap.CaptureInput{
+		{Path: first, Source: span1},
+		{Path: second, Source: span1},
+		{Path: east, Source: pattern},
+		{Path: west, Source: pattern},
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+}
+
+func TestExpandCaptureInputs_Errors(t *testing.T) {
+	empty := t.TempDir()
+	if err := os.WriteFile(filepath.Join(empty, "readme.md"), []byte("no captures"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := map[string][]string{
+		"directory without captures": {empty},
+		"pattern without captures":   {filepath.Join(empty, "*.pcap")},
+		"missing file":               {filepath.Join(empty, "missing.pcap")},
+		"no inputs":                  nil,
+	}
+	for name, input := range inputs {
+		if _, err := pcap.ExpandCaptureInputs(input); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
@@ -255,6 +255,54 @@ func TestConfigurableDetector(t *testing.T) {
 	}
 }
 
+func TestModularDetectionAdapter_AnalyzePayload(t *testing.T) {
+	adapter := integration.NewModularDetectionAdapter("")
+
+	// Two requests in one segment: each ADU must yield its own function code
+	read := []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x00, 0x00, 0x0A}
+	write := []byte{0x00, 0x02, 0x00, 0x00, 0x00, 0x09, 0x07, 0x10, 0x00, 0x10, 0x00, 0x01, 0x02, 0x00, 0xFF}
+	results := adapter.AnalyzePayload(createTCPPacket(50000, 502, append(read, write...)))
+
+	expected := []struct {
+		function uint8
+		unitID   uint8
+	}{
+		{0x03, 1},
+		{0x10, 7},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d DPI results for the Modbus payload, got %d", len(expected), len(results))
+	}
+	for i, tt := range expected {
+		result := results[i]
+		if code, _ := result.Details["function_code"].(uint8); code != tt.function {
+			t.Errorf("Expected function code 0x%02X, got %v", tt.function, result.Details["function_code"])
+		}
+		if unitID, _ := result.Details["unit_id"].(uint8); unitID != tt.unitID {
+			t.Errorf("Expected unit ID %d, got %v", tt.unitID, result.Details["unit_id"])
+		}
+	}
+
+	// Packets without payload carry no DPI attributes
+	if results := adapter.AnalyzePayload(createTCPPacket(50000, 502, nil)); len(results) != 0 {
+		t.Errorf("Expected no DPI result for an empty segment, got %+v", results)
+	}
+
+	// PROFINET runs directly over Ethernet; DPI must still see the frame
+	eth := &layers.Ethernet{
+		SrcMAC:       []byte{0x00, 0x1B, 0x1B, 0x01, 0x02, 0x03},
+		DstMAC:       []byte{0x01, 0x0E, 0xCF, 0x00, 0x00, 0x00},
+		EthernetType: layers.EthernetType(0x8892),
+	}
+	identify := []byte{0xFE, 0xFE, 0x05, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x80, 0x00, 0x04, 0xFF, 0xFF, 0x00, 0x00}
+	buffer := gopacket.NewSerializeBuffer()
+	gopacket.SerializeLayers(buffer, gopacket.SerializeOptions{}, eth, gopacket.Payload(identify))
+	frame := gopacket.NewPacket(buffer.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	if results := adapter.AnalyzePayload(frame); len(results) != 1 || results[0].Protocol != "Profinet" {
+		t.Errorf("Expected a Profinet DPI result for a DCP Identify frame, got %+v", results)
+	}
+}
+
 // Helper functions for creating test packets
 
 func createHTTPPacket() gopacket.Packet {
@@ -0,0 +1,151 @@
+package pcap_test
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/pcap/pcaptest"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const linkTypeLinuxSLL2 = 276
+
+// modbusResponse answers modbusRequest with one register
+var modbusResponse = []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x01, 0x03, 0x02, 0x00, 0x2A}
+
+// The relink helpers re-encode an Ethernet frame from pcaptest with another link layer
+
+func linuxSLL(p pcaptest.Packet) pcaptest.Packet {
+	header := make([]byte, 16)
+	binary.BigEndian.PutUint16(header[2:4], 1) // ARPHRD_ETHER
+	binary.BigEndian.PutUint16(header[4:6], 6)
+	copy(header[6:12], p.Data[6:12]) // Sender MAC
+	copy(header[14:16], p.Data[12:14])
+	p.Data = append(header, p.IP()...)
+	return p
+}
+
+func linuxSLL2(p pcaptest.Packet) pcaptest.Packet {
+	header := make([]byte, 20)
+	copy(header[0:2], p.Data[12:14])
+	binary.BigEndian.PutUint32(header[4:8], 3)  // Interface index
+	binary.BigEndian.PutUint16(header[8:10], 1) // ARPHRD_ETHER
+	header[11] = 6
+	copy(header[12:18], p.Data[6:12])
+	p.Data = append(header, p.IP()...)
+	return p
+}
+
+func rawIP(p pcaptest.Packet) pcaptest.Packet {
+	p.Data = p.IP()
+	return p
+}
+
+// qinq tags a frame with service VLAN 100 around customer VLAN 10
+func qinq(p pcaptest.Packet) pcaptest.Packet {
+	eth := &layers.Ethernet{SrcMAC: p.Data[6:12], DstMAC: p.Data[0:6], EthernetType: layers.EthernetTypeQinQ}
+	service := &layers.Dot1Q{VLANIdentifier: 100, Type: layers.EthernetTypeDot1Q}
+	customer := &layers.Dot1Q{VLANIdentifier: 10, Type: layers.EthernetType(binary.BigEndian.Uint16(p.Data[12:14]))}
+	p.Data = pcaptest.Serialize(eth, service, customer, gopacket.Payload(p.IP()))
+	return p
+}
+
+// parseModbusConversation writes a handshake, a request and its response, re-encoded
+// by relink, and parses the capture
+func parseModbusConversation(t *testing.T, client, server string, write func(dir string, packets ...pcaptest.Packet) string, relink func(pcaptest.Packet) pcaptest.Packet) *types.NetworkModel {
+	t.Helper()
+	conn := pcaptest.TCP(client, 50000, server, 502)
+	packets := append(conn.Handshake(), conn.Client("PA", modbusRequest), conn.Server("PA", modbusResponse))
+	for i := range packets {
+		packets[i] = relink(packets[i])
+	}
+	return pcaptest.Parse(t, 1, write(t.TempDir(), packets...))
+}
+
+func TestPCAPParser_LinkTypes(t *testing.T) {
+	writer := func(linkType layers.LinkType) func(string, ...pcaptest.Packet) string {
+		return func(dir string, packets ...pcaptest.Packet) string {
+			return pcaptest.WriteCapture(t, dir, "capture.pcap", linkType, packets...)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		client  string
+		server  string
+		write   func(string, ...pcaptest.Packet) string
+		relink  func(pcaptest.Packet) pcaptest.Packet
+		hasMACs bool // Cooked captures record the sender's MAC, raw IP none
+	}{
+		{"Linux cooked", "10.0.0.2", "10.0.0.1", writer(layers.LinkTypeLinuxSLL), linuxSLL, true},
+		{"Linux cooked v2", "10.0.0.2", "10.0.0.1", func(dir string, packets ...pcaptest.Packet) string {
+			return pcaptest.WriteWideLinkTypeCapture(t, dir, "capture.pcap", linkTypeLinuxSLL2, packets...)
+		}, linuxSLL2, true},
+		{"raw IPv4", "10.0.0.2", "10.0.0.1", writer(layers.LinkTypeRaw), rawIP, false},
+		{"raw IPv6", "fd00::2", "fd00::1", writer(layers.LinkTypeRaw), rawIP, false},
+		{"QinQ", "10.0.10.2", "10.0.10.1", writer(layers.LinkTypeEthernet), qinq, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := parseModbusConversation(t, tt.client, tt.server, tt.write, tt.relink)
+
+			if flow := flowBetween(t, model, tt.client, tt.server); flow.Protocol != "Modbus TCP" || flow.Packets != 3 {
+				t.Errorf("Expected 3 Modbus TCP packets to the server, got %d %s", flow.Packets, flow.Protocol)
+			}
+			if len(model.Sessions) != 1 {
+				t.Fatalf("Expected 1 session, got %d", len(model.Sessions))
+			}
+			for _, session := range model.Sessions {
+				if session.State != types.SessionEstablished || session.Key.Client != tt.client {
+					t.Errorf("Expected an established session from %s, got %s from %s", tt.client, session.State, session.Key.Client)
+				}
+			}
+
+			for _, ip := range []string{tt.client, tt.server} {
+				asset := model.Assets[ip]
+				if asset == nil {
+					t.Fatalf("No asset for %s", ip)
+				}
+				expected := ""
+				if tt.hasMACs {
+					expected = pcaptest.MAC(net.ParseIP(ip)).String()
+				}
+				if asset.MAC != expected {
+					t.Errorf("Expected %s to have MAC %q, got %q", ip, expected, asset.MAC)
+				}
+			}
+		})
+	}
+}
+
+func TestPCAPParser_QinQSegment(t *testing.T) {
+	model := parseModbusConversation(t, "10.0.10.2", "10.0.10.1", func(dir string, packets ...pcaptest.Packet) string {
+		return pcaptest.WriteCapture(t, dir, "qinq.pcap", layers.LinkTypeEthernet, packets...)
+	}, qinq)
+
+	flow := flowBetween(t, model, "10.0.10.2", "10.0.10.1")
+	if len(flow.VLANs) != 1 || flow.VLANs[0] != 10 {
+		t.Errorf("Expected customer VLAN 10 on the flow, got %v", flow.VLANs)
+	}
+	if len(flow.ServiceVLANs) != 1 || flow.ServiceVLANs[0] != 100 {
+		t.Errorf("Expected service VLAN 100 on the flow, got %v", flow.ServiceVLANs)
+	}
+
+	// The segment follows the customer VLAN, not the provider's outer tag
+	var segments []*types.NetworkSegment
+	for _, segment := range model.Networks {
+		segments = append(segments, segment)
+	}
+	if len(segments) != 1 {
+		t.Fatalf("Expected 1 segment, got %d", len(segments))
+	}
+	if segment := segments[0]; segment.VLAN != 10 || segment.CIDR != "10.0.10.0/24" || len(segment.Assets) != 2 {
+		t.Errorf("Expected VLAN 10 segment 10.0.10.0/24 with both assets, got VLAN %d %s with %d assets",
+			segment.VLAN, segment.CIDR, len(segment.Assets))
+	}
+}
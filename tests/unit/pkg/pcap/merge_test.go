@@ -0,0 +1,141 @@
+package pcap_test
+
+import (
+	"testing"
+	"time"
+
+	"cipgram/pkg/pcap"
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/pcap/pcaptest"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestCaptureMerge_TimeOrder(t *testing.T) {
+	// Asymmetric routing: each SPAN point sees one direction of the connection
+	conn := pcaptest.TCP("10.0.0.2", 50000, "10.0.0.1", 502)
+	syn := conn.Client("S", nil).At(0)
+	synAck := conn.Server("SA", nil).At(1 * time.Millisecond)
+	ack := conn.Client("A", nil).At(2 * time.Millisecond)
+	request := conn.Client("PA", modbusRequest).At(3 * time.Millisecond)
+	response := conn.Server("PA", []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x01, 0x03, 0x02, 0x00, 0x2A}).At(10 * time.Millisecond)
+
+	dir := t.TempDir()
+	toPLC := pcaptest.WriteCapture(t, dir, "to_plc.pcap", layers.LinkTypeEthernet, syn, ack, request)
+	fromPLC := pcaptest.WriteCapture(t, dir, "from_plc.pcap", layers.LinkTypeEthernet, synAck, response)
+
+	orders := map[string][]string{
+		"requests first":  {toPLC, fromPLC},
+		"responses first": {fromPLC, toPLC},
+	}
+	for name, paths := range orders {
+		t.Run(name, func(t *testing.T) {
+			model := pcaptest.Parse(t, 1, paths...)
+
+			// The handshake only completes when the SYN-ACK is read between SYN and ACK
+			if len(model.Sessions) != 1 {
+				t.Fatalf("Expected 1 session, got %d", len(model.Sessions))
+			}
+			for _, session := range model.Sessions {
+				if session.State != types.SessionEstablished || !session.Handshake {
+					t.Errorf("Expected an established session with handshake, got %s (handshake %v)", session.State, session.Handshake)
+				}
+			}
+			if reply := flowBetween(t, model, "10.0.0.1", "10.0.0.2"); !reply.FirstSeen.Equal(synAck.Time) {
+				t.Errorf("Expected the reply flow to start at %v, got %v", synAck.Time, reply.FirstSeen)
+			}
+			for _, file := range model.Metadata.Files {
+				if file.Duplicates != 0 {
+					t.Errorf("Expected no duplicates in %s, got %d", file.Path, file.Duplicates)
+				}
+			}
+		})
+	}
+}
+
+func TestCaptureMerge_SPANDuplicates(t *testing.T) {
+	conn := pcaptest.TCP("10.0.0.2", 50000, "10.0.0.1", 502)
+	first := conn.Client("PA", modbusRequest)
+	second := conn.Client("PA", modbusRequest)
+	copies := func(offset time.Duration) []pcaptest.Packet {
+		return []pcaptest.Packet{first.At(offset), second.At(offset + 50*time.Millisecond)}
+	}
+
+	tests := []struct {
+		name       string
+		parse      func(t *testing.T, dir string) *types.NetworkModel
+		packets    int64 // Packets counted on the flow
+		duplicates int   // Packets dropped as duplicates over all files
+	}{
+		{
+			name: "copies from a second SPAN point",
+			parse: func(t *testing.T, dir string) *types.NetworkModel {
+				span1 := pcaptest.WriteCapture(t, dir, "span1.pcap", layers.LinkTypeEthernet, copies(0)...)
+				span2 := pcaptest.WriteCapture(t, dir, "span2.pcap", layers.LinkTypeEthernet, copies(5*time.Millisecond)...)
+				return pcaptest.Parse(t, 1, span1, span2)
+			},
+			packets:    2,
+			duplicates: 2,
+		},
+		{
+			name: "copies outside the duplicate window",
+			parse: func(t *testing.T, dir string) *types.NetworkModel {
+				span1 := pcaptest.WriteCapture(t, dir, "span1.pcap", layers.LinkTypeEthernet, copies(0)...)
+				span2 := pcaptest.WriteCapture(t, dir, "span2.pcap", layers.LinkTypeEthernet, copies(200*time.Millisecond)...)
+				return pcaptest.Parse(t, 1, span1, span2)
+			},
+			packets:    4,
+			duplicates: 0,
+		},
+		{
+			name: "retransmission across a file rotation",
+			parse: func(t *testing.T, dir string) *types.NetworkModel {
+				trace0 := pcaptest.WriteCapture(t, dir, "trace.pcap0", layers.LinkTypeEthernet, first.At(0))
+				trace1 := pcaptest.WriteCapture(t, dir, "trace.pcap1", layers.LinkTypeEthernet, first.At(5*time.Millisecond))
+				return pcaptest.ParseInputs(t,
+					pcap.CaptureInput{Path: trace0, Source: dir},
+					pcap.CaptureInput{Path: trace1, Source: dir})
+			},
+			packets:    2,
+			duplicates: 0,
+		},
+		{
+			name: "copies on two interfaces of a pcapng file",
+			parse: func(t *testing.T, dir string) *types.NetworkModel {
+				capture := pcaptest.WriteNgCapture(t, dir, "both.pcapng", layers.LinkTypeEthernet, 2,
+					first.At(0).On(0), first.At(time.Millisecond).On(1),
+					second.At(50*time.Millisecond).On(1), second.At(51*time.Millisecond).On(0))
+				return pcaptest.Parse(t, 1, capture)
+			},
+			packets:    2,
+			duplicates: 2,
+		},
+		{
+			name: "retransmission on one interface of a pcapng file",
+			parse: func(t *testing.T, dir string) *types.NetworkModel {
+				capture := pcaptest.WriteNgCapture(t, dir, "both.pcapng", layers.LinkTypeEthernet, 2,
+					first.At(0).On(1), first.At(time.Millisecond).On(1))
+				return pcaptest.Parse(t, 1, capture)
+			},
+			packets:    2,
+			duplicates: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := tt.parse(t, t.TempDir())
+
+			if flow := flowBetween(t, model, "10.0.0.2", "10.0.0.1"); flow.Packets != tt.packets {
+				t.Errorf("Expected %d packets on the flow, got %d", tt.packets, flow.Packets)
+			}
+			duplicates := 0
+			for _, file := range model.Metadata.Files {
+				duplicates += file.Duplicates
+			}
+			if duplicates != tt.duplicates {
+				t.Errorf("Expected %d duplicates, got %d", tt.duplicates, duplicates)
+			}
+		})
+	}
+}
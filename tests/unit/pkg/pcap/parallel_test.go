@@ -0,0 +1,72 @@
+package pcap_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cipgram/tests/unit/pkg/pcap/pcaptest"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestPCAPParser_ParallelMatchesSequential(t *testing.T) {
+	// Modbus polling from two HMIs to three PLCs, interleaved so that every shard sees
+	// streams whose packets alternate with other shards' packets
+	var conns []*pcaptest.Conn
+	for h := 1; h <= 2; h++ {
+		for p := 1; p <= 3; p++ {
+			conns = append(conns, pcaptest.TCP(fmt.Sprintf("10.0.0.%d", 100+h), uint16(50000+10*h+p), fmt.Sprintf("10.0.1.%d", p), 502))
+		}
+	}
+	bacnet := pcaptest.UDP("10.0.0.101", 47808, "10.0.1.255", 47808)
+	response := []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x17, 0x01, 0x03, 0x14,
+		0, 1, 0, 2, 0, 3, 0, 4, 0, 5, 0, 6, 0, 7, 0, 8, 0, 9, 0, 10}
+
+	var packets []pcaptest.Packet
+	for _, conn := range conns {
+		packets = append(packets, conn.Handshake()...)
+	}
+	for round := 0; round < 5; round++ {
+		for _, conn := range conns {
+			// The request is split across two segments in odd rounds
+			if round%2 == 1 {
+				packets = append(packets, conn.Client("PA", modbusRequest[:5]), conn.Client("PA", modbusRequest[5:]))
+			} else {
+				packets = append(packets, conn.Client("PA", modbusRequest))
+			}
+		}
+		for _, conn := range conns {
+			packets = append(packets, conn.Server("PA", response))
+		}
+		packets = append(packets, bacnet.Client("", []byte{0x81, 0x0b, 0x00, 0x0c, 0x01, 0x20, 0xff, 0xff, 0x00, 0xff, 0x10, 0x08}))
+	}
+	for _, conn := range conns {
+		packets = append(packets, conn.Client("FA", nil), conn.Server("FA", nil), conn.Client("A", nil))
+	}
+	path := pcaptest.WriteCapture(t, t.TempDir(), "polling.pcap", layers.LinkTypeEthernet, packets...)
+
+	sequential := pcaptest.Parse(t, 1, path)
+	parallel := pcaptest.Parse(t, 4, path)
+	if len(sequential.Sessions) != len(conns)+1 {
+		t.Fatalf("Expected %d sessions, got %d", len(conns)+1, len(sequential.Sessions))
+	}
+
+	if len(parallel.Flows) != len(sequential.Flows) {
+		t.Fatalf("Expected %d flows, got %d", len(sequential.Flows), len(parallel.Flows))
+	}
+	for key, flow := range sequential.Flows {
+		if !reflect.DeepEqual(parallel.Flows[key], flow) {
+			t.Errorf("Flow %v differs:\nsequential %+v\nparallel   %+v", key, flow, parallel.Flows[key])
+		}
+	}
+	if !reflect.DeepEqual(parallel.Sessions, sequential.Sessions) {
+		t.Error("Sessions differ between sequential and parallel parsing")
+	}
+	if !reflect.DeepEqual(parallel.Assets, sequential.Assets) {
+		t.Error("Assets differ between sequential and parallel parsing")
+	}
+	if !reflect.DeepEqual(parallel.Events, sequential.Events) {
+		t.Error("Events differ between sequential and parallel parsing")
+	}
+}
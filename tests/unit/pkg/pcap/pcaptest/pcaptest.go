@@ -0,0 +1,290 @@
+// Package pcaptest writes small capture files for PCAP parser tests
+package pcaptest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cipgram/pkg/pcap"
+	"cipgram/pkg/types"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// Start is the capture time of the first packet in a file
+var Start = time.Date(2025, 10, 6, 10, 0, 0, 0, time.UTC)
+
+// Packet is a frame to write to a capture. A zero Time places the packet one
+// millisecond after the one before it.
+type Packet struct {
+	Time      time.Time
+	Data      []byte
+	Interface int // pcapng interface the packet was captured on
+}
+
+// At returns the packet captured at the given offset from Start
+func (p Packet) At(offset time.Duration) Packet {
+	p.Time = Start.Add(offset)
+	return p
+}
+
+// On returns the packet captured on the given pcapng interface
+func (p Packet) On(iface int) Packet {
+	p.Interface = iface
+	return p
+}
+
+// WriteCapture writes packets to a pcap file called name in dir and returns its path
+func WriteCapture(t *testing.T, dir, name string, linkType layers.LinkType, packets ...Packet) string {
+	t.Helper()
+	return writeFile(t, dir, name, func(file *os.File) error {
+		writer := pcapgo.NewWriter(file)
+		if err := writer.WriteFileHeader(65535, linkType); err != nil {
+			return err
+		}
+		return writePackets(writer.WritePacket, packets)
+	})
+}
+
+// WriteWideLinkTypeCapture writes packets to a pcap file with a link type gopacket's
+// byte-sized LinkType cannot hold, such as LINKTYPE_LINUX_SLL2 (276)
+func WriteWideLinkTypeCapture(t *testing.T, dir, name string, linkType uint32, packets ...Packet) string {
+	t.Helper()
+	path := WriteCapture(t, dir, name, layers.LinkTypeNull, packets...)
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	defer file.Close()
+	field := make([]byte, 4)
+	binary.LittleEndian.PutUint32(field, linkType) // pcapgo writes little-endian headers
+	if _, err := file.WriteAt(field, 20); err != nil {
+		t.Fatalf("Failed to set the link type of %s: %v", name, err)
+	}
+	return path
+}
+
+// WriteNgCapture writes packets to a pcapng file called name in dir, describing the
+// given number of interfaces, and returns its path
+func WriteNgCapture(t *testing.T, dir, name string, linkType layers.LinkType, interfaces int, packets ...Packet) string {
+	t.Helper()
+	return writeFile(t, dir, name, func(file *os.File) error {
+		iface := pcapgo.DefaultNgInterface
+		iface.LinkType = linkType
+		writer, err := pcapgo.NewNgWriterInterface(file, iface, pcapgo.DefaultNgWriterOptions)
+		if err != nil {
+			return err
+		}
+		for i := 1; i < interfaces; i++ {
+			iface.Name = fmt.Sprintf("intf%d", i)
+			if _, err := writer.AddInterface(iface); err != nil {
+				return err
+			}
+		}
+		if err := writePackets(writer.WritePacket, packets); err != nil {
+			return err
+		}
+		return writer.Flush()
+	})
+}
+
+func writeFile(t *testing.T, dir, name string, write func(*os.File) error) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", name, err)
+	}
+	defer file.Close()
+	if err := write(file); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func writePackets(write func(gopacket.CaptureInfo, []byte) error, packets []Packet) error {
+	at := Start
+	for i, packet := range packets {
+		if !packet.Time.IsZero() {
+			at = packet.Time
+		} else if i > 0 {
+			at = at.Add(time.Millisecond)
+		}
+		info := gopacket.CaptureInfo{
+			Timestamp:      at,
+			CaptureLength:  len(packet.Data),
+			Length:         len(packet.Data),
+			InterfaceIndex: packet.Interface,
+		}
+		if err := write(info, packet.Data); err != nil {
+			return fmt.Errorf("packet %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Parse runs the PCAP parser over the capture files with the given number of workers.
+// Every file is its own capture point.
+func Parse(t *testing.T, workers int, paths ...string) *types.NetworkModel {
+	t.Helper()
+	return parse(t, pcap.NewMultiPCAPParser(paths, &pcap.PCAPConfig{Workers: workers}))
+}
+
+// ParseInputs runs the PCAP parser sequentially over capture files with their capture points
+func ParseInputs(t *testing.T, inputs ...pcap.CaptureInput) *types.NetworkModel {
+	t.Helper()
+	return parse(t, pcap.NewCaptureInputsParser(inputs, &pcap.PCAPConfig{Workers: 1}))
+}
+
+func parse(t *testing.T, parser *pcap.PCAPParser) *types.NetworkModel {
+	t.Helper()
+	model, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	return model
+}
+
+// Conn builds the Ethernet frames of one TCP or UDP conversation, tracking TCP
+// sequence numbers for both sides
+type Conn struct {
+	udp        bool
+	client     net.IP
+	server     net.IP
+	clientPort uint16
+	serverPort uint16
+	clientSeq  uint32
+	serverSeq  uint32
+}
+
+// TCP starts a TCP conversation from client:clientPort to server:serverPort over IPv4
+// or IPv6
+func TCP(client string, clientPort uint16, server string, serverPort uint16) *Conn {
+	return &Conn{
+		client:     address(client),
+		server:     address(server),
+		clientPort: clientPort,
+		serverPort: serverPort,
+		clientSeq:  1000,
+		serverSeq:  5000,
+	}
+}
+
+// UDP starts a UDP conversation from client:clientPort to server:serverPort
+func UDP(client string, clientPort uint16, server string, serverPort uint16) *Conn {
+	conn := TCP(client, clientPort, server, serverPort)
+	conn.udp = true
+	return conn
+}
+
+func address(ip string) net.IP {
+	parsed := net.ParseIP(ip)
+	if v4 := parsed.To4(); v4 != nil {
+		return v4
+	}
+	return parsed
+}
+
+// Handshake returns the SYN, SYN-ACK and ACK opening the conversation
+func (c *Conn) Handshake() []Packet {
+	return []Packet{c.Client("S", nil), c.Server("SA", nil), c.Client("A", nil)}
+}
+
+// Client returns a frame from the client. flags holds the TCP flags as letters
+// (S, A, P, F, R) and is ignored for UDP.
+func (c *Conn) Client(flags string, payload []byte) Packet {
+	return c.frame(true, flags, payload)
+}
+
+// Server returns a frame from the server
+func (c *Conn) Server(flags string, payload []byte) Packet {
+	return c.frame(false, flags, payload)
+}
+
+func (c *Conn) frame(fromClient bool, flags string, payload []byte) Packet {
+	src, dst := c.client, c.server
+	srcPort, dstPort := c.clientPort, c.serverPort
+	seq, ack := &c.clientSeq, &c.serverSeq
+	if !fromClient {
+		src, dst = dst, src
+		srcPort, dstPort = dstPort, srcPort
+		seq, ack = ack, seq
+	}
+
+	protocol := layers.IPProtocolTCP
+	if c.udp {
+		protocol = layers.IPProtocolUDP
+	}
+	var ip gopacket.NetworkLayer
+	var network gopacket.SerializableLayer
+	ethernetType := layers.EthernetTypeIPv4
+	if src.To4() != nil {
+		ip4 := &layers.IPv4{Version: 4, TTL: 64, Protocol: protocol, SrcIP: src, DstIP: dst}
+		ip, network = ip4, ip4
+	} else {
+		ip6 := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: protocol, SrcIP: src, DstIP: dst}
+		ip, network, ethernetType = ip6, ip6, layers.EthernetTypeIPv6
+	}
+
+	var transport gopacket.SerializableLayer
+	if c.udp {
+		udp := &layers.UDP{SrcPort: layers.UDPPort(srcPort), DstPort: layers.UDPPort(dstPort)}
+		udp.SetNetworkLayerForChecksum(ip)
+		transport = udp
+	} else {
+		tcp := &layers.TCP{
+			SrcPort: layers.TCPPort(srcPort),
+			DstPort: layers.TCPPort(dstPort),
+			Seq:     *seq,
+			Window:  65535,
+			SYN:     strings.Contains(flags, "S"),
+			ACK:     strings.Contains(flags, "A"),
+			PSH:     strings.Contains(flags, "P"),
+			FIN:     strings.Contains(flags, "F"),
+			RST:     strings.Contains(flags, "R"),
+		}
+		if tcp.ACK {
+			tcp.Ack = *ack
+		}
+		tcp.SetNetworkLayerForChecksum(ip)
+		transport = tcp
+
+		*seq += uint32(len(payload))
+		if tcp.SYN || tcp.FIN {
+			*seq++
+		}
+	}
+
+	eth := &layers.Ethernet{SrcMAC: MAC(src), DstMAC: MAC(dst), EthernetType: ethernetType}
+	return Packet{Data: Serialize(eth, network, transport, gopacket.Payload(payload))}
+}
+
+// IP returns the IP packet of a frame built by Conn, without its Ethernet header
+func (p Packet) IP() []byte {
+	return p.Data[14:]
+}
+
+// MAC returns a locally administered MAC address derived from the last four bytes of an
+// IP address
+func MAC(ip net.IP) net.HardwareAddr {
+	ip = ip.To16()
+	return net.HardwareAddr{0x02, 0x00, ip[12], ip[13], ip[14], ip[15]}
+}
+
+// Serialize encodes layers into a frame, computing lengths and checksums
+func Serialize(frame ...gopacket.SerializableLayer) []byte {
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buffer, options, frame...); err != nil {
+		panic(err)
+	}
+	return buffer.Bytes()
+}
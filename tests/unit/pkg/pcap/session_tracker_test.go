@@ -0,0 +1,192 @@
+package pcap_test
+
+import (
+	"fmt"
+	"testing"
+
+	"cipgram/pkg/types"
+	"cipgram/tests/unit/pkg/pcap/pcaptest"
+
+	"github.com/google/gopacket/layers"
+)
+
+// modbusRequest is a Read Holding Registers request for unit 1
+var modbusRequest = []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x06, 0x01, 0x03, 0x00, 0x00, 0x00, 0x0A}
+
+// parseConversation writes packets to a capture and parses it sequentially
+func parseConversation(t *testing.T, packets ...[]pcaptest.Packet) *types.NetworkModel {
+	t.Helper()
+	var all []pcaptest.Packet
+	for _, group := range packets {
+		all = append(all, group...)
+	}
+	path := pcaptest.WriteCapture(t, t.TempDir(), "sessions.pcap", layers.LinkTypeEthernet, all...)
+	return pcaptest.Parse(t, 1, path)
+}
+
+// flowBetween returns the flow from src to dst, failing the test when there is none
+func flowBetween(t *testing.T, model *types.NetworkModel, src, dst string) *types.Flow {
+	t.Helper()
+	for _, flow := range model.Flows {
+		if flow.Source == src && flow.Destination == dst {
+			return flow
+		}
+	}
+	t.Fatalf("No flow from %s to %s", src, dst)
+	return nil
+}
+
+func TestSessionTracker_HandshakeStates(t *testing.T) {
+	single := func(p pcaptest.Packet) []pcaptest.Packet { return []pcaptest.Packet{p} }
+
+	tests := []struct {
+		name      string
+		packets   func(conn *pcaptest.Conn) [][]pcaptest.Packet
+		state     types.SessionState
+		handshake bool
+		failed    bool
+	}{
+		{"established", func(c *pcaptest.Conn) [][]pcaptest.Packet {
+			return [][]pcaptest.Packet{c.Handshake(), single(c.Client("PA", modbusRequest))}
+		}, types.SessionEstablished, true, false},
+		{"closed", func(c *pcaptest.Conn) [][]pcaptest.Packet {
+			return [][]pcaptest.Packet{c.Handshake(), single(c.Client("FA", nil)), single(c.Server("FA", nil)), single(c.Client("A", nil))}
+		}, types.SessionClosed, true, false},
+		{"unanswered", func(c *pcaptest.Conn) [][]pcaptest.Packet {
+			return [][]pcaptest.Packet{single(c.Client("S", nil)), single(c.Client("S", nil))}
+		}, types.SessionSynSent, false, true},
+		{"refused", func(c *pcaptest.Conn) [][]pcaptest.Packet {
+			return [][]pcaptest.Packet{single(c.Client("S", nil)), single(c.Server("RA", nil))}
+		}, types.SessionRefused, false, true},
+		{"reset", func(c *pcaptest.Conn) [][]pcaptest.Packet {
+			return [][]pcaptest.Packet{c.Handshake(), single(c.Client("PA", modbusRequest)), single(c.Server("R", nil))}
+		}, types.SessionReset, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := parseConversation(t, tt.packets(pcaptest.TCP("10.0.0.2", 50000, "10.0.0.1", 502))...)
+
+			key := types.SessionKey{Transport: "tcp", Client: "10.0.0.2", ClientPort: 50000, Server: "10.0.0.1", ServerPort: 502}
+			session := model.Sessions[key]
+			if session == nil || len(model.Sessions) != 1 {
+				t.Fatalf("Expected one session %s, got %v", key, model.Sessions)
+			}
+			if session.State != tt.state || session.Handshake != tt.handshake || session.Failed() != tt.failed {
+				t.Errorf("Expected %s (handshake=%v, failed=%v), got %s (handshake=%v, failed=%v)",
+					tt.state, tt.handshake, tt.failed, session.State, session.Handshake, session.Failed())
+			}
+			if !session.InitiatedBySYN {
+				t.Error("Expected the client to be known from the SYN")
+			}
+		})
+	}
+}
+
+func TestSessionTracker_EphemeralPortDirection(t *testing.T) {
+	// The capture starts mid-connection with the PLC's reply to the HMI
+	conn := pcaptest.TCP("10.0.0.2", 50000, "10.0.0.1", 502)
+	model := parseConversation(t, []pcaptest.Packet{
+		conn.Server("PA", []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x05, 0x01, 0x03, 0x02, 0x00, 0x2A}),
+		conn.Client("PA", modbusRequest),
+		conn.Client("PA", modbusRequest),
+	})
+
+	key := types.SessionKey{Transport: "tcp", Client: "10.0.0.2", ClientPort: 50000, Server: "10.0.0.1", ServerPort: 502}
+	session := model.Sessions[key]
+	if session == nil {
+		t.Fatalf("Expected the HMI on its ephemeral port as client, got %v", model.Sessions)
+	}
+	if session.State != types.SessionMidstream || session.InitiatedBySYN {
+		t.Errorf("Expected a midstream session with a guessed client, got %s (syn=%v)", session.State, session.InitiatedBySYN)
+	}
+	if session.ClientPackets != 2 || session.ServerPackets != 1 {
+		t.Errorf("Expected 2 client and 1 server packets, got %d/%d", session.ClientPackets, session.ServerPackets)
+	}
+	if !flowBetween(t, model, "10.0.0.1", "10.0.0.2").IsReply() {
+		t.Error("Expected the PLC's flow to carry replies")
+	}
+	if flowBetween(t, model, "10.0.0.2", "10.0.0.1").IsReply() {
+		t.Error("Expected the HMI's flow to carry requests")
+	}
+}
+
+func TestSessionTracker_PortReuse(t *testing.T) {
+	// A refused attempt followed by a successful connection from the same client port
+	conn := pcaptest.TCP("10.0.0.2", 50000, "10.0.0.1", 502)
+	model := parseConversation(t,
+		[]pcaptest.Packet{conn.Client("S", nil), conn.Server("RA", nil)},
+		conn.Handshake(),
+		[]pcaptest.Packet{conn.Client("PA", modbusRequest)},
+	)
+
+	first := types.SessionKey{Transport: "tcp", Client: "10.0.0.2", ClientPort: 50000, Server: "10.0.0.1", ServerPort: 502}
+	second := first
+	second.Attempt = 1
+	if len(model.Sessions) != 2 || model.Sessions[first] == nil || model.Sessions[second] == nil {
+		t.Fatalf("Expected sessions %s and %s, got %v", first, second, model.Sessions)
+	}
+	if state := model.Sessions[first].State; state != types.SessionRefused {
+		t.Errorf("Expected the first attempt to stay refused, got %s", state)
+	}
+	if state := model.Sessions[second].State; state != types.SessionEstablished {
+		t.Errorf("Expected the second connection established, got %s", state)
+	}
+	if packets := model.Sessions[second].ClientPackets; packets != 3 {
+		t.Errorf("Expected the second connection to count its own 3 client packets, got %d", packets)
+	}
+
+	flow := flowBetween(t, model, "10.0.0.2", "10.0.0.1")
+	if flow.SessionCount != 2 || len(flow.Sessions) != 2 || flow.Sessions[1] != second {
+		t.Errorf("Expected the flow linked to both sessions, got %d: %v", flow.SessionCount, flow.Sessions)
+	}
+}
+
+func TestSessionTracker_UDP(t *testing.T) {
+	poll := pcaptest.UDP("10.0.0.2", 50001, "10.0.0.1", 161)
+	// The first packet seen is the agent's answer to a poll sent before the capture
+	missed := pcaptest.UDP("10.0.0.3", 50002, "10.0.0.1", 161)
+	model := parseConversation(t, []pcaptest.Packet{
+		poll.Client("", []byte("get")),
+		poll.Server("", []byte("response")),
+		missed.Server("", []byte("response")),
+	})
+
+	for _, key := range []types.SessionKey{
+		{Transport: "udp", Client: "10.0.0.2", ClientPort: 50001, Server: "10.0.0.1", ServerPort: 161},
+		{Transport: "udp", Client: "10.0.0.3", ClientPort: 50002, Server: "10.0.0.1", ServerPort: 161},
+	} {
+		session := model.Sessions[key]
+		if session == nil {
+			t.Errorf("Expected session %s, got %v", key, model.Sessions)
+			continue
+		}
+		if session.State != "" || session.Failed() {
+			t.Errorf("Expected a stateless UDP session, got %q", session.State)
+		}
+	}
+	if session := model.Sessions[types.SessionKey{Transport: "udp", Client: "10.0.0.2", ClientPort: 50001, Server: "10.0.0.1", ServerPort: 161}]; session != nil &&
+		(session.ClientPackets != 1 || session.ServerPackets != 1) {
+		t.Errorf("Expected one packet each way, got %d/%d", session.ClientPackets, session.ServerPackets)
+	}
+}
+
+func TestSessionTracker_FlowSessionCap(t *testing.T) {
+	var packets []pcaptest.Packet
+	sessions := types.MaxFlowSessions + 6
+	for i := 0; i < sessions; i++ {
+		packets = append(packets, pcaptest.UDP("10.0.0.2", uint16(40000+i), "10.0.0.1", 161).Client("", []byte("get")))
+	}
+	model := parseConversation(t, packets)
+
+	flow := flowBetween(t, model, "10.0.0.2", "10.0.0.1")
+	if flow.SessionCount != sessions || len(flow.Sessions) != types.MaxFlowSessions {
+		t.Errorf("Expected %d sessions with %d keys kept, got %d with %d keys",
+			sessions, types.MaxFlowSessions, flow.SessionCount, len(flow.Sessions))
+	}
+	if len(model.Sessions) != sessions {
+		t.Errorf("Expected every session in the model, got %d", len(model.Sessions))
+	}
+	if first := flow.Sessions[0]; first.ClientPort != 40000 {
+		t.Errorf("Expected keys kept in the order seen, got %s first", fmt.Sprint(first))
+	}
+}
@@ -281,6 +281,52 @@ func TestWorkerQueue_FullQueue(t *testing.T) {
 	}
 }
 
+func TestWorkerQueue_EnqueueWaitAndDrain(t *testing.T) {
+	var processed []string
+	processor := &MockPacketProcessor{
+		processFunc: func(ctx context.Context, job *workers.PacketJob) (*workers.JobResult, error) {
+			time.Sleep(time.Millisecond)
+			processed = append(processed, job.ID)
+			return &workers.JobResult{JobID: job.ID, Success: true}, nil
+		},
+	}
+
+	// A single worker with a tiny buffer: EnqueueWait must block rather than fail
+	queue := workers.NewInMemoryWorkerQueue(1, 2, processor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := queue.Start(ctx); err != nil {
+		t.Fatalf("Failed to start queue: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		job := &workers.PacketJob{ID: fmt.Sprintf("job_%d", i)}
+		if err := queue.EnqueueWait(ctx, job); err != nil {
+			t.Fatalf("Failed to enqueue job %d: %v", i, err)
+		}
+	}
+
+	// Drain processes every queued job before stopping
+	if err := queue.Drain(); err != nil {
+		t.Fatalf("Failed to drain queue: %v", err)
+	}
+	if len(processed) != 10 {
+		t.Fatalf("Expected 10 processed jobs, got %d", len(processed))
+	}
+	for i, id := range processed {
+		if id != fmt.Sprintf("job_%d", i) {
+			t.Errorf("Expected jobs in order, got %v", processed)
+			break
+		}
+	}
+
+	if err := queue.EnqueueWait(ctx, &workers.PacketJob{ID: "late"}); err == nil {
+		t.Error("Expected error enqueueing on a drained queue")
+	}
+}
+
 func TestWorkerQueue_ContextCancellation(t *testing.T) {
 	processor := &MockPacketProcessor{
 		processFunc: func(ctx context.Context, job *workers.PacketJob) (*workers.JobResult, error) {
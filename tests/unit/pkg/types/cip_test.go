@@ -0,0 +1,25 @@
+package types_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/types"
+)
+
+func TestCIPDeviceInfo_RecordsOnce(t *testing.T) {
+	info := &types.CIPDeviceInfo{}
+	conn := &types.CIPConnection{Originator: "10.0.0.5", Target: "10.0.0.10", ConnectionSerial: 0x1234}
+
+	info.AddSession(0x11223344)
+	info.AddSession(0x11223344)
+	info.AddConnection(conn)
+	info.AddConnection(conn)
+	info.AddConnection(&types.CIPConnection{Originator: "10.0.0.5", Target: "10.0.0.11"})
+
+	if len(info.Sessions) != 1 {
+		t.Errorf("Expected one session, got %v", info.Sessions)
+	}
+	if len(info.Connections) != 2 || info.Connections[0] != conn {
+		t.Errorf("Expected two distinct connections, got %d", len(info.Connections))
+	}
+}
@@ -0,0 +1,35 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"cipgram/pkg/types"
+)
+
+func TestFlowDPI_DNP3Sessions(t *testing.T) {
+	dpi := types.NewFlowDPI()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// One gateway connection, two outstations behind it
+	first := dpi.DNP3Session(1, 10)
+	first.RecordControl([]string{"CROB 3 LATCH_ON"}, start.Add(time.Second))
+	first.RecordControl([]string{"CROB 3 LATCH_ON", "AO 7 = 1500"}, start)
+	dpi.DNP3Session(1, 11).RecordRestart(start)
+
+	if again := dpi.DNP3Session(1, 10); again != first {
+		t.Error("Expected the same session for the same address pair")
+	}
+	if len(dpi.DNP3) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(dpi.DNP3))
+	}
+	if first.Controls != 2 || len(first.ControlPoints) != 2 {
+		t.Errorf("Expected 2 controls on 2 points, got %d on %v", first.Controls, first.ControlPoints)
+	}
+	if !first.LastControl.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected last control at %v, got %v", start.Add(time.Second), first.LastControl)
+	}
+	if restarted := dpi.DNP3[1]; restarted.Outstation != 11 || restarted.Restarts != 1 || restarted.Controls != 0 {
+		t.Errorf("Unexpected second session: %+v", restarted)
+	}
+}
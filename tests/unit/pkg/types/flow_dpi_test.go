@@ -0,0 +1,54 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"cipgram/pkg/types"
+)
+
+func TestFlowDPI_OperationHistogram(t *testing.T) {
+	dpi := types.NewFlowDPI()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	dpi.RecordOperation(types.OperationFunction, 0x10, "Write Multiple Registers", start.Add(2*time.Second))
+	dpi.RecordOperation(types.OperationFunction, 0x03, "Read Holding Registers", start.Add(time.Second))
+	dpi.RecordOperation(types.OperationFunction, 0x03, "Read Holding Registers", start)
+	dpi.RecordOperation(types.OperationFunction, 0x03, "Read Holding Registers", start.Add(3*time.Second))
+
+	ops := dpi.SortedOperations()
+	if len(ops) != 2 {
+		t.Fatalf("Expected 2 distinct operations, got %d", len(ops))
+	}
+	read := ops[0]
+	if read.Code != 0x03 || read.Count != 3 {
+		t.Errorf("Expected 3 reads first, got %+v", read)
+	}
+	if !read.FirstSeen.Equal(start) || !read.LastSeen.Equal(start.Add(3*time.Second)) {
+		t.Errorf("Unexpected first/last seen: %v - %v", read.FirstSeen, read.LastSeen)
+	}
+	if top := dpi.TopOperation(types.OperationFunction); top != read {
+		t.Errorf("Expected reads to be the top operation, got %+v", top)
+	}
+	if top := dpi.TopOperation(types.OperationCIPService); top != nil {
+		t.Errorf("Expected no CIP service, got %+v", top)
+	}
+}
+
+func TestFlowDPI_UniqueAttributes(t *testing.T) {
+	dpi := types.NewFlowDPI()
+
+	dpi.AddUnitID(1)
+	dpi.AddUnitID(1)
+	dpi.AddUnitID(255)
+	dpi.AddCIPObject("Identity/1/7")
+	dpi.AddCIPObject("Identity/1/7")
+	dpi.AddUserAgent("")
+	dpi.AddUserAgent("RSLinx")
+	dpi.AddHighRiskOperation("PLC Stop")
+	dpi.AddHighRiskOperation("PLC Stop")
+
+	if len(dpi.UnitIDs) != 2 || len(dpi.CIPObjects) != 1 || len(dpi.UserAgents) != 1 || len(dpi.HighRiskOperations) != 1 {
+		t.Errorf("Expected duplicates and empty values to be dropped, got %+v", dpi)
+	}
+}
@@ -0,0 +1,43 @@
+package types_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/types"
+)
+
+func TestModbusProfile_AddressRanges(t *testing.T) {
+	profile := types.NewModbusProfile("10.0.0.5", "10.0.0.10")
+
+	profile.RecordRead("holding_registers", 10, 10)
+	profile.RecordRead("holding_registers", 0, 10) // adjacent, merges into 0-19
+	profile.RecordRead("holding_registers", 5, 3)  // contained
+	profile.RecordRead("input_registers", 0, 2)
+	profile.RecordRead("holding_registers", 100, 1)
+	profile.RecordWrite("coils", 4, 1)
+	profile.RecordWrite("holding_registers", 0xFFFF, 5) // clamped to the address space
+	profile.RecordWrite("holding_registers", 0, 0)      // counted without an address range
+
+	expectedReads := []string{"holding_registers 0-19", "holding_registers 100", "input_registers 0-1"}
+	if len(profile.Reads) != len(expectedReads) {
+		t.Fatalf("Expected reads %v, got %v", expectedReads, profile.Reads)
+	}
+	for i, expected := range expectedReads {
+		if profile.Reads[i].String() != expected {
+			t.Errorf("Read range %d: expected %s, got %s", i, expected, profile.Reads[i])
+		}
+	}
+
+	if len(profile.Writes) != 2 || profile.Writes[1].String() != "holding_registers 65535" {
+		t.Errorf("Unexpected write ranges: %v", profile.Writes)
+	}
+	if profile.WriteCount != 3 {
+		t.Errorf("Expected 3 writes, got %d", profile.WriteCount)
+	}
+
+	profile.RecordException("Illegal Data Address")
+	profile.RecordException("Illegal Data Address")
+	if profile.Exceptions["Illegal Data Address"] != 2 {
+		t.Errorf("Expected 2 exceptions, got %v", profile.Exceptions)
+	}
+}
@@ -0,0 +1,27 @@
+package types_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/types"
+)
+
+func TestOPCUAChannel_RecordSecurity(t *testing.T) {
+	channel := &types.OPCUAChannel{}
+
+	channel.RecordSecurity("None", "None")
+	channel.RecordSecurity("Basic256Sha256", "")
+	channel.AddEndpointURL("opc.tcp://plc:4840")
+	channel.AddEndpointURL("opc.tcp://plc:4840")
+	channel.AddEndpointURL("")
+
+	if !channel.Insecure {
+		t.Error("Expected a None channel to keep the flow flagged insecure")
+	}
+	if channel.SecurityPolicy != "Basic256Sha256" || channel.SecurityMode != "" {
+		t.Errorf("Unexpected policy/mode: %s/%s", channel.SecurityPolicy, channel.SecurityMode)
+	}
+	if len(channel.EndpointURLs) != 1 {
+		t.Errorf("Expected one endpoint URL, got %v", channel.EndpointURLs)
+	}
+}
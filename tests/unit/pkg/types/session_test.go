@@ -0,0 +1,57 @@
+package types_test
+
+import (
+	"testing"
+
+	"cipgram/pkg/types"
+)
+
+func TestSessionKey_String(t *testing.T) {
+	key := types.SessionKey{Transport: "tcp", Client: "10.0.0.2", ClientPort: 50000, Server: "10.0.0.1", ServerPort: 502}
+	if expected := "tcp 10.0.0.2:50000->10.0.0.1:502"; key.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, key.String())
+	}
+}
+
+func TestSession_Failed(t *testing.T) {
+	failed := map[types.SessionState]bool{
+		types.SessionSynSent:     true,
+		types.SessionRefused:     true,
+		types.SessionEstablished: false,
+		types.SessionMidstream:   false,
+		types.SessionReset:       false,
+		types.SessionClosed:      false,
+		"":                       false, // UDP
+	}
+	for state, expected := range failed {
+		session := &types.Session{State: state}
+		if session.Failed() != expected {
+			t.Errorf("State %q: expected Failed() = %v", state, expected)
+		}
+	}
+}
+
+func TestFlow_IsReply(t *testing.T) {
+	toPLC := types.SessionKey{Transport: "tcp", Client: "10.0.0.2", ClientPort: 50000, Server: "10.0.0.1", ServerPort: 502}
+	fromPLC := types.SessionKey{Transport: "tcp", Client: "10.0.0.1", ClientPort: 50001, Server: "10.0.0.2", ServerPort: 502}
+
+	tests := []struct {
+		name     string
+		sessions []types.SessionKey
+		expected bool
+	}{
+		{"no sessions", nil, false},
+		{"requests", []types.SessionKey{fromPLC}, false},
+		{"replies", []types.SessionKey{toPLC}, true},
+		{"as many requests as replies", []types.SessionKey{toPLC, fromPLC}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The PLC's packets to the HMI
+			flow := &types.Flow{Source: "10.0.0.1", Destination: "10.0.0.2", Sessions: tt.sessions}
+			if flow.IsReply() != tt.expected {
+				t.Errorf("Expected IsReply() = %v", tt.expected)
+			}
+		})
+	}
+}
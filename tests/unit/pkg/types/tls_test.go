@@ -0,0 +1,37 @@
+package types_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"cipgram/pkg/types"
+)
+
+func TestTLSProfile_Record(t *testing.T) {
+	profile := &types.TLSProfile{}
+
+	profile.RecordClientHello("ja3a", "t13d0206h2_a_b", "plc1.plant.local")
+	profile.RecordClientHello("ja3a", "t13d0206h2_a_b", "")
+	profile.RecordServerHello("ja3s", "TLS 1.0", "TLS_RSA_WITH_RC4_128_SHA")
+	profile.AddWeakness("TLS 1.0")
+	profile.AddWeakness("TLS 1.0")
+
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	profile.AddCertificate(&types.TLSCertificate{Subject: "CN=plc1,O=Siemens AG", Organization: "Siemens AG", NotAfter: expiry})
+	profile.AddCertificate(&types.TLSCertificate{Subject: "CN=plc1,O=Siemens AG", Organization: "Siemens AG", NotAfter: expiry})
+	profile.AddCertificate(&types.TLSCertificate{Subject: "CN=hmi"})
+
+	if !reflect.DeepEqual(profile.JA3, []string{"ja3a"}) || !reflect.DeepEqual(profile.ServerNames, []string{"plc1.plant.local"}) {
+		t.Errorf("Unexpected client facts: %v %v", profile.JA3, profile.ServerNames)
+	}
+	if !reflect.DeepEqual(profile.Weaknesses, []string{"TLS 1.0"}) {
+		t.Errorf("Expected one weakness, got %v", profile.Weaknesses)
+	}
+	if len(profile.Certificates) != 2 {
+		t.Errorf("Expected two distinct certificates, got %d", len(profile.Certificates))
+	}
+	if organizations := profile.Organizations(); !reflect.DeepEqual(organizations, []string{"Siemens AG"}) {
+		t.Errorf("Expected [Siemens AG], got %v", organizations)
+	}
+}